@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+)
+
+// defaultConfigPath is where the driver containers mount their configuration
+const defaultConfigPath = "/etc/csi-arca-storage/config.yaml"
+
+// apiTimeout bounds an api request, including retries
+const apiTimeout = 5 * time.Minute
+
+// api sends a raw request to the ARCA API with the client the driver builds
+// from its configuration (endpoints, authentication, TLS and retries) and
+// prints the response, indented when it is JSON
+func api(args []string) error {
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	configPath := fs.String("config", defaultConfigPath, "Driver configuration file")
+	backend := fs.String("backend", "", "Tenant backend name from the configuration; empty for the default backend")
+	raw := fs.Bool("raw", false, "Print the response as received")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 || fs.NArg() > 3 {
+		return fmt.Errorf("usage: arcactl api [--config PATH] [--backend NAME] GET|POST PATH [BODY|@FILE|-]")
+	}
+
+	method := strings.ToUpper(fs.Arg(0))
+	if method != http.MethodGet && method != http.MethodPost {
+		return fmt.Errorf("method must be GET or POST, not %q", fs.Arg(0))
+	}
+	target, err := url.Parse(fs.Arg(1))
+	if err != nil || !strings.HasPrefix(target.Path, "/") || target.Host != "" {
+		return fmt.Errorf("path %q must be an absolute API path, e.g. /v1/svms", fs.Arg(1))
+	}
+
+	var body json.RawMessage
+	if fs.NArg() == 3 {
+		if method == http.MethodGet {
+			return fmt.Errorf("GET requests take no body")
+		}
+		if body, err = readBody(fs.Arg(2)); err != nil {
+			return err
+		}
+	}
+
+	client, err := configuredClient(*configPath, *backend)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	resp, err := client.Do(ctx, method, target.Path, target.Query(), body)
+	if err != nil {
+		return err
+	}
+
+	if !*raw {
+		var indented bytes.Buffer
+		if json.Indent(&indented, resp, "", "  ") == nil {
+			resp = indented.Bytes()
+		}
+	}
+	if _, err := os.Stdout.Write(resp); err != nil {
+		return err
+	}
+	if len(resp) > 0 && resp[len(resp)-1] != '\n' {
+		fmt.Println()
+	}
+	return nil
+}
+
+// readBody reads a request body given inline, as @FILE or as "-" for
+// stdin, and checks it is JSON
+func readBody(arg string) (json.RawMessage, error) {
+	var data []byte
+	var err error
+	switch {
+	case arg == "-":
+		data, err = io.ReadAll(os.Stdin)
+	case strings.HasPrefix(arg, "@"):
+		data, err = os.ReadFile(arg[1:])
+	default:
+		data = []byte(arg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("request body is not valid JSON")
+	}
+	return data, nil
+}
+
+// configuredClient creates the ARCA client of a backend as the driver would
+// from its configuration file
+func configuredClient(path, backend string) (*arca.Client, error) {
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConfig := cfg.ToArcaClientConfig()
+	if backend != "" {
+		clientConfig = nil
+		for i := range cfg.Tenants {
+			if cfg.Tenants[i].Name == backend {
+				clientConfig = cfg.Tenants[i].ToArcaClientConfig()
+				break
+			}
+		}
+		if clientConfig == nil {
+			return nil, fmt.Errorf("backend %q is not configured in %s", backend, path)
+		}
+	}
+
+	client, err := arca.NewClient(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ARCA client: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/akam1o/csi-arca-storage/pkg/conformance"
+)
+
+// conformanceCheck runs the driver's controller against an in-memory ARCA
+// backend and reports how it handles storms of duplicated calls. It needs
+// neither a cluster nor a backend.
+func conformanceCheck(args []string) error {
+	fs := flag.NewFlagSet("conformance", flag.ContinueOnError)
+	count := fs.Int("count", conformance.DefaultCount, "Number of identical concurrent calls per storm")
+	latency := fs.Duration("latency", conformance.DefaultLatency, "Delay of every backend mutation")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Time limit of the check")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || fs.Arg(0) != "idempotency" {
+		return fmt.Errorf("usage: arcactl conformance [--count N] [--latency DURATION] idempotency")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	results, err := conformance.RunIdempotency(ctx, conformance.Options{Count: *count, Latency: *latency})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STORM\tCALLS\tABORTED\tMUTATIONS\tRESULT")
+	for _, r := range results {
+		outcome := "ok"
+		if !r.Passed() {
+			outcome = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", r.Name, r.Calls, r.Aborted, total(r.Mutations), outcome)
+	}
+	w.Flush()
+
+	for _, r := range results {
+		for _, f := range r.Failures {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", r.Name, f)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d storms failed", failed, len(results))
+	}
+	return nil
+}
+
+// total sums the counts of mutations
+func total(mutations map[string]int) int {
+	n := 0
+	for _, c := range mutations {
+		n += c
+	}
+	return n
+}
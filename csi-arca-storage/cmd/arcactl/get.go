@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/akam1o/csi-arca-storage/pkg/store/crd"
+)
+
+// get prints the ArcaVolume or ArcaSnapshot recorded for a CSI name, or
+// the pods using an SVM
+func get(kubeconfig string, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: arcactl get volume|snapshot|pods NAME")
+	}
+	if args[0] == "pods" {
+		return getPods(kubeconfig, args[1])
+	}
+
+	config, err := loadConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+	c, err := newClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	st, err := crd.NewStore(c, config, crd.Check{})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	switch args[0] {
+	case "volume":
+		v, err := st.GetVolumeByName(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "VOLUME ID\tPVC\tPV\tSVM\tVIP\tPATH\tCAPACITY\tBACKEND")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n", v.VolumeID, claimName(v.PVCNamespace, v.PVCName), orNone(v.PVName),
+			v.SVMName, v.VIP, v.Path, v.CapacityBytes, backendName(v.Backend))
+	case "snapshot":
+		s, err := st.GetSnapshotByName(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "SNAPSHOT ID\tSOURCE VOLUME\tSVM\tPATH\tSIZE\tREADY\tBACKEND")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%t\t%s\n", s.SnapshotID, s.SourceVolumeID, s.SVMName, s.Path, s.SizeBytes, s.ReadyToUse, backendName(s.Backend))
+	default:
+		return fmt.Errorf("unknown resource %q (want volume, snapshot or pods)", args[0])
+	}
+	return w.Flush()
+}
+
+// claimName formats a PersistentVolumeClaim as "namespace/name", or
+// "<none>" for volumes that did not record one
+func claimName(namespace, name string) string {
+	if name == "" {
+		return "<none>"
+	}
+	return namespace + "/" + name
+}
+
+// orNone returns s, or "<none>" when it is empty
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/akam1o/csi-arca-storage/pkg/stateimport"
+	"github.com/akam1o/csi-arca-storage/pkg/store/crd"
+)
+
+// importState creates the ArcaVolumes and ArcaSnapshots missing for volumes
+// and snapshots provisioned by driver versions without the CRD store,
+// rebuilt from the cluster's PVs and VolumeSnapshotContents or read from a
+// JSON dump
+func importState(kubeconfig string, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := fs.String("file", "", "Read volumes and snapshots from this JSON dump instead of the PVs and VolumeSnapshotContents")
+	dryRun := fs.Bool("dry-run", false, "Only print what would be imported")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: arcactl import [--file DUMP] [--dry-run]")
+	}
+
+	config, err := loadConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	var records *stateimport.Records
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		records, err = stateimport.ReadDump(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", *file, err)
+		}
+	} else {
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		c, err := newClientForConfig(config)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		records, err = stateimport.FromCluster(ctx, clientset, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	c, err := newClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	st, err := crd.NewStore(c, config, crd.Check{})
+	if err != nil {
+		return err
+	}
+	result, err := stateimport.Import(st, records, *dryRun)
+	if result != nil {
+		verb := "Imported"
+		if *dryRun {
+			verb = "Would import"
+		}
+		fmt.Printf("%s %d volumes (%d already recorded) and %d snapshots (%d already recorded)\n",
+			verb, result.VolumesCreated, result.VolumesExisting, result.SnapshotsCreated, result.SnapshotsExisting)
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+// Command arcactl is an operator tool for ARCA storage resources.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: arcactl [--kubeconfig PATH] <command> [args]
+
+Commands:
+  get volume NAME | get snapshot NAME
+        Show the backend volume or snapshot created for a CSI name
+        (the PV name "pvc-<uid>" or "snapshot-<uid>")
+  get pods SVM
+        List the pods using volumes of an SVM (e.g. k8s-team-a)
+  migrate create --namespace NS [--target-backend NAME] [--name NAME]
+        Start moving a namespace's SVM and volumes to another backend
+  migrate confirm NAME
+        Confirm the data copy so the migration can cut over
+  migrate status [NAME]
+        Show one or all migrations
+  svm update NAME [--mtu N] [--gateway IP]
+        Change the MTU or gateway of an SVM (e.g. k8s-team-a)
+  svm resize NAME SIZE
+        Change the capacity of an SVM (e.g. 10Ti)
+  svm status [NAME]
+        Show the attributes of one or all SVMs
+  import [--file DUMP] [--dry-run]
+        Create the ArcaVolumes and ArcaSnapshots missing for volumes and
+        snapshots provisioned without the CRD store, from the driver's PVs
+        and VolumeSnapshotContents or a JSON dump
+  orphans --arca-url URL [--backend NAME] [--svm SVM] [--grace DURATION]
+        List volume directories and snapshots on ARCA that no ArcaVolume
+        or ArcaSnapshot records
+        (authenticates with $ARCA_AUTH_TOKEN)
+  api [--config PATH] [--backend NAME] [--raw] GET|POST PATH [BODY|@FILE|-]
+        Send a request to an ARCA API path (e.g. /v1/svms?limit=10) with
+        the client of the driver configuration (default
+        /etc/csi-arca-storage/config.yaml) and print the response
+  conformance [--count N] [--latency DURATION] idempotency
+        Fire storms of identical concurrent CreateVolume, CreateSnapshot
+        and DeleteVolume calls at the controller with an in-memory ARCA
+        backend and check each changes the backend exactly once
+`
+
+func main() {
+	kubeconfig := flag.String("kubeconfig", "", "Path to kubeconfig file (defaults to the standard loading rules)")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch args[0] {
+	case "get":
+		err = get(*kubeconfig, args[1:])
+	case "migrate":
+		err = migrate(*kubeconfig, args[1:])
+	case "svm":
+		err = svm(*kubeconfig, args[1:])
+	case "import":
+		err = importState(*kubeconfig, args[1:])
+	case "orphans":
+		err = orphans(*kubeconfig, args[1:])
+	case "api":
+		err = api(args[1:])
+	case "conformance":
+		err = conformanceCheck(args[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "arcactl: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+)
+
+// requestTimeout bounds each Kubernetes API call
+const requestTimeout = 30 * time.Second
+
+// migrate dispatches the "migrate" subcommands
+func migrate(kubeconfig string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate requires a subcommand (create, confirm, status)")
+	}
+
+	c, err := newClient(kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	switch args[0] {
+	case "create":
+		return migrateCreate(ctx, c, args[1:])
+	case "confirm":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: arcactl migrate confirm NAME")
+		}
+		return migrateConfirm(ctx, c, args[1])
+	case "status":
+		if len(args) > 2 {
+			return fmt.Errorf("usage: arcactl migrate status [NAME]")
+		}
+		name := ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		return migrateStatus(ctx, c, name)
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q", args[0])
+	}
+}
+
+// migrateCreate creates an ArcaMigration
+func migrateCreate(ctx context.Context, c client.Client, args []string) error {
+	fs := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+	namespace := fs.String("namespace", "", "Namespace whose SVM is migrated (required)")
+	targetBackend := fs.String("target-backend", "", "Target backend (tenant) name; empty for the default backend")
+	name := fs.String("name", "", "Migration name (defaults to migrate-<namespace>)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *namespace == "" {
+		return fmt.Errorf("--namespace is required")
+	}
+	if *name == "" {
+		*name = "migrate-" + *namespace
+	}
+
+	m := &v1alpha1.ArcaMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: *name},
+		Spec: v1alpha1.ArcaMigrationSpec{
+			Namespace:     *namespace,
+			TargetBackend: *targetBackend,
+		},
+	}
+	if err := c.Create(ctx, m); err != nil {
+		return fmt.Errorf("failed to create migration: %w", err)
+	}
+
+	fmt.Printf("arcamigration/%s created\n", m.Name)
+	return nil
+}
+
+// migrateConfirm marks the data copy of a migration as done
+func migrateConfirm(ctx context.Context, c client.Client, name string) error {
+	var m v1alpha1.ArcaMigration
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, &m); err != nil {
+		return fmt.Errorf("failed to get migration %s: %w", name, err)
+	}
+	if m.Status.Phase != v1alpha1.ArcaMigrationPhaseAwaitingCopy {
+		return fmt.Errorf("migration %s is in phase %q, not %q", name, m.Status.Phase, v1alpha1.ArcaMigrationPhaseAwaitingCopy)
+	}
+
+	m.Spec.DataCopied = true
+	if err := c.Update(ctx, &m); err != nil {
+		return fmt.Errorf("failed to confirm migration %s: %w", name, err)
+	}
+
+	fmt.Printf("arcamigration/%s confirmed\n", name)
+	return nil
+}
+
+// migrateStatus prints one or all migrations
+func migrateStatus(ctx context.Context, c client.Client, name string) error {
+	var items []v1alpha1.ArcaMigration
+	if name != "" {
+		var m v1alpha1.ArcaMigration
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, &m); err != nil {
+			return fmt.Errorf("failed to get migration %s: %w", name, err)
+		}
+		items = append(items, m)
+	} else {
+		var list v1alpha1.ArcaMigrationList
+		if err := c.List(ctx, &list); err != nil {
+			return fmt.Errorf("failed to list migrations: %w", err)
+		}
+		items = list.Items
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tNAMESPACE\tSOURCE\tTARGET\tPHASE\tVOLUMES\tMESSAGE")
+	for _, m := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", m.Name, m.Spec.Namespace,
+			backendName(m.Status.SourceBackend), backendName(m.Spec.TargetBackend),
+			m.Status.Phase, len(m.Status.Volumes), m.Status.Message)
+	}
+	return w.Flush()
+}
+
+// backendName displays the default backend as "default"
+func backendName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// loadConfig loads the REST config using the standard kubeconfig rules
+func loadConfig(kubeconfig string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return config, nil
+}
+
+// newClient creates a controller-runtime client for the ARCA API group
+func newClient(kubeconfig string) (client.Client, error) {
+	config, err := loadConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return newClientForConfig(config)
+}
+
+// newClientForConfig creates a controller-runtime client for the ARCA API
+// group from a loaded REST config
+func newClientForConfig(config *rest.Config) (client.Client, error) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add v1alpha1 to scheme: %w", err)
+	}
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return c, nil
+}
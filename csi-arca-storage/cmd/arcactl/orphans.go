@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/orphan"
+)
+
+// orphans prints the volume directories and snapshots on an ARCA backend
+// that no ArcaVolume or ArcaSnapshot records. The backend is reached
+// directly, authenticated with the ARCA_AUTH_TOKEN environment variable.
+func orphans(kubeconfig string, args []string) error {
+	fs := flag.NewFlagSet("orphans", flag.ContinueOnError)
+	arcaURL := fs.String("arca-url", "", "ARCA API base URL (required)")
+	caCert := fs.String("arca-ca-cert", "", "CA certificate of the ARCA API")
+	backend := fs.String("backend", "", "Backend (tenant) name of the ARCA API; empty for the default backend")
+	svmName := fs.String("svm", "", "Only scan this SVM (e.g. k8s-team-a)")
+	snapshotDir := fs.String("snapshot-dir", "", "Snapshot directory configured as svm.snapshot_dir, if not the default")
+	grace := fs.Duration("grace", orphan.DefaultGracePeriod, "Skip directories and snapshots younger than this")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *arcaURL == "" {
+		return fmt.Errorf("--arca-url is required")
+	}
+
+	arcaConfig := &arca.ClientConfig{
+		BaseURL:   *arcaURL,
+		AuthToken: os.Getenv("ARCA_AUTH_TOKEN"),
+	}
+	if *caCert != "" {
+		arcaConfig.TLSConfig = &arca.TLSConfig{CACertPath: *caCert}
+	}
+	arcaClient, err := arca.NewClient(arcaConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create ARCA client: %w", err)
+	}
+	c, err := newClient(kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	// List the ArcaVolumes and ArcaSnapshots first, so anything created
+	// meanwhile is young enough to be skipped
+	index, err := orphan.LoadIndex(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	svms := []string{*svmName}
+	if *svmName == "" {
+		list, err := arcaClient.ListSVMs(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list SVMs: %w", err)
+		}
+		svms = svms[:0]
+		for _, svm := range list {
+			if strings.HasPrefix(svm.Name, arca.SVMNamePrefix) {
+				svms = append(svms, svm.Name)
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SVM\tKIND\tPATH\tSIZE\tCREATED")
+	for _, name := range svms {
+		dirs, err := orphan.FindDirectories(ctx, arcaClient, *backend, name, index, *grace)
+		if err != nil {
+			return err
+		}
+		for _, dir := range dirs {
+			fmt.Fprintf(w, "%s\tdirectory\t%s\t%d\t%s\n", name, dir.Path, dir.UsedBytes, dir.CreatedAt.Format(time.RFC3339))
+		}
+		snapshots, err := orphan.FindSnapshots(ctx, arcaClient, *backend, name, *snapshotDir, index, *grace)
+		if err != nil {
+			return err
+		}
+		for _, snap := range snapshots {
+			fmt.Fprintf(w, "%s\tsnapshot\t%s\t%d\t%s\n", name, snap.Path, snap.SizeBytes, snap.CreatedAt.Format(time.RFC3339))
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+)
+
+// getPods prints the pods using volumes of an SVM, found through the PVs
+// whose volume context names the SVM and the PVCs bound to them
+func getPods(kubeconfig, svmName string) error {
+	config, err := loadConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pvs, err := clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list PVs: %w", err)
+	}
+	claims := make(map[string]map[string]string) // namespace -> PVC -> volume ID
+	for _, pv := range pvs.Items {
+		csi := pv.Spec.CSI
+		if csi == nil || csi.Driver != driver.DriverName || csi.VolumeAttributes["svm"] != svmName || pv.Spec.ClaimRef == nil {
+			continue
+		}
+		ref := pv.Spec.ClaimRef
+		if claims[ref.Namespace] == nil {
+			claims[ref.Namespace] = make(map[string]string)
+		}
+		claims[ref.Namespace][ref.Name] = csi.VolumeHandle
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tPOD\tNODE\tPHASE\tPVC\tVOLUME ID")
+	for namespace, pvcs := range claims {
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list pods in %s: %w", namespace, err)
+		}
+		for _, pod := range pods.Items {
+			for _, v := range pod.Spec.Volumes {
+				if v.PersistentVolumeClaim == nil {
+					continue
+				}
+				if volumeID, ok := pvcs[v.PersistentVolumeClaim.ClaimName]; ok {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", namespace, pod.Name, pod.Spec.NodeName, pod.Status.Phase, v.PersistentVolumeClaim.ClaimName, volumeID)
+				}
+			}
+		}
+	}
+	return w.Flush()
+}
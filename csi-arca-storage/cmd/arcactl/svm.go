@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+)
+
+// svm dispatches the "svm" subcommands
+func svm(kubeconfig string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("svm requires a subcommand (update, resize, status)")
+	}
+
+	c, err := newClient(kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	switch args[0] {
+	case "update":
+		return svmUpdate(ctx, c, args[1:])
+	case "resize":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: arcactl svm resize NAME SIZE")
+		}
+		return svmResize(ctx, c, args[1], args[2])
+	case "status":
+		if len(args) > 2 {
+			return fmt.Errorf("usage: arcactl svm status [NAME]")
+		}
+		name := ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		return svmStatus(ctx, c, name)
+	default:
+		return fmt.Errorf("unknown svm subcommand %q", args[0])
+	}
+}
+
+// svmUpdate requests a new MTU or gateway for an SVM in its ArcaSVM
+func svmUpdate(ctx context.Context, c client.Client, args []string) error {
+	fs := flag.NewFlagSet("svm update", flag.ContinueOnError)
+	mtu := fs.Int("mtu", 0, "Network MTU")
+	gateway := fs.String("gateway", "", "Default gateway address")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: arcactl svm update NAME [--mtu N] [--gateway IP]")
+	}
+	if *mtu == 0 && *gateway == "" {
+		return fmt.Errorf("--mtu or --gateway is required")
+	}
+	if *mtu != 0 && (*mtu < 68 || *mtu > 65535) {
+		return fmt.Errorf("--mtu must be between 68 and 65535")
+	}
+	if *gateway != "" && net.ParseIP(*gateway) == nil {
+		return fmt.Errorf("--gateway %q is not an IP address", *gateway)
+	}
+
+	name := fs.Arg(0)
+	var s v1alpha1.ArcaSVM
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, &s); err != nil {
+		return fmt.Errorf("failed to get SVM %s: %w", name, err)
+	}
+	if *mtu != 0 {
+		s.Spec.MTU = *mtu
+	}
+	if *gateway != "" {
+		s.Spec.Gateway = *gateway
+	}
+	if err := c.Update(ctx, &s); err != nil {
+		return fmt.Errorf("failed to update SVM %s: %w", name, err)
+	}
+
+	fmt.Printf("arcasvm/%s updated\n", name)
+	return nil
+}
+
+// svmResize requests a new capacity for an SVM in its ArcaSVM
+func svmResize(ctx context.Context, c client.Client, name, size string) error {
+	q, err := resource.ParseQuantity(size)
+	if err != nil {
+		return fmt.Errorf("invalid size %q: %w", size, err)
+	}
+	if q.Sign() <= 0 {
+		return fmt.Errorf("size must be positive")
+	}
+
+	var s v1alpha1.ArcaSVM
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, &s); err != nil {
+		return fmt.Errorf("failed to get SVM %s: %w", name, err)
+	}
+	s.Spec.CapacityBytes = q.Value()
+	if err := c.Update(ctx, &s); err != nil {
+		return fmt.Errorf("failed to resize SVM %s: %w", name, err)
+	}
+
+	fmt.Printf("arcasvm/%s resized to %d bytes\n", name, s.Spec.CapacityBytes)
+	return nil
+}
+
+// svmStatus prints the attributes of one or all SVMs
+func svmStatus(ctx context.Context, c client.Client, name string) error {
+	var items []v1alpha1.ArcaSVM
+	if name != "" {
+		var s v1alpha1.ArcaSVM
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, &s); err != nil {
+			return fmt.Errorf("failed to get SVM %s: %w", name, err)
+		}
+		items = append(items, s)
+	} else {
+		var list v1alpha1.ArcaSVMList
+		if err := c.List(ctx, &list); err != nil {
+			return fmt.Errorf("failed to list SVMs: %w", err)
+		}
+		items = list.Items
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBACKEND\tVIP\tMTU\tGATEWAY\tCAPACITY\tIN SYNC\tDRIFT")
+	for _, s := range items {
+		inSync := "Unknown"
+		if cond := meta.FindStatusCondition(s.Status.Conditions, v1alpha1.ArcaSVMConditionAttributesInSync); cond != nil {
+			inSync = string(cond.Status)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%d\t%s\t%s\n", s.Name, backendName(s.Spec.Backend),
+			s.Status.VIP, s.Status.MTU, s.Status.Gateway, s.Status.CapacityBytes,
+			inSync, strings.Join(s.Status.AttributeDrift, "; "))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,11 @@
+// Command controller runs the CSI controller plugin.
+package main
+
+import (
+	"github.com/akam1o/csi-arca-storage/cmd/internal/cli"
+	"github.com/akam1o/csi-arca-storage/pkg/app/controller"
+)
+
+func main() {
+	cli.Main("controller", controller.Runtime{})
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/akam1o/csi-arca-storage/pkg/app"
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+)
+
+// runChecks validates the configuration and the environment the driver
+// would start in, writes one line per check to w and reports whether all
+// checks passed
+func runChecks(ctx context.Context, w io.Writer, mode string) bool {
+	report := func(name string, err error) bool {
+		if err != nil {
+			fmt.Fprintf(w, "[FAIL] %s: %v\n", name, err)
+			return false
+		}
+		fmt.Fprintf(w, "[ OK ] %s\n", name)
+		return true
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if !report("config "+*configPath, err) {
+		return false
+	}
+
+	if *nodeID != "" {
+		cfg.Driver.NodeID = *nodeID
+	}
+	switch {
+	case mode == "controller" && cfg.Driver.NodeID != "":
+		err = fmt.Errorf("controller mode requires node-id to be empty")
+	case mode == "node" && cfg.Driver.NodeID == "":
+		err = fmt.Errorf("node mode requires --node-id flag")
+	}
+	if !report("mode "+mode, err) {
+		return false
+	}
+
+	ok := true
+	for _, r := range app.Check(ctx, mode, cfg, app.WithKubeconfig(*kubeconfig), app.WithKubeRateLimit(float32(*kubeAPIQPS), *kubeAPIBurst), app.WithControllerRuntime(controllerRuntime)) {
+		ok = report(r.Name, r.Err) && ok
+	}
+	return ok
+}
@@ -0,0 +1,191 @@
+// Package cli implements the command line shared by the driver binaries.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/app"
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/logging"
+)
+
+var (
+	configPath = flag.String("config", "/etc/csi-arca-storage/config.yaml", "Path to configuration file")
+	nodeID     = flag.String("node-id", "", "Node ID (required for node plugin)")
+	kubeconfig = flag.String("kubeconfig", "", "Path to kubeconfig file (optional, uses in-cluster config if not specified)")
+	version    = flag.Bool("version", false, "Print version information and exit")
+	checkOnly  = flag.Bool("check-only", false, "Check configuration, ARCA connectivity and credentials, Kubernetes permissions and CRDs, then exit (non-zero on failure)")
+
+	selfTest          = flag.Bool("self-test", false, "Create, snapshot, clone, expand and (in node mode) mount a throwaway volume, delete it again, then exit (non-zero on failure)")
+	selfTestNamespace = flag.String("self-test-namespace", "default", "Namespace whose SVM holds the --self-test volumes")
+	selfTestCapacity  = flag.String("self-test-capacity", "1Gi", "Capacity of the --self-test volumes")
+
+	kubeAPIQPS   = flag.Float64("kube-api-qps", 20, "Queries per second allowed to the Kubernetes API server")
+	kubeAPIBurst = flag.Int("kube-api-burst", 40, "Burst of queries allowed to the Kubernetes API server above --kube-api-qps")
+
+	metricsAddress = flag.String("metrics-address", "", "Address to serve Prometheus metrics on (e.g. :9808, disabled if empty)")
+)
+
+// controllerRuntime builds the controller plugin's manager and CRD store;
+// nil in binaries that only run the node plugin
+var controllerRuntime app.ControllerRuntime
+
+// Main parses flags and runs the driver (or the gen-manifests subcommand). When fixedMode is non-empty the
+// --mode flag is not registered and the binary always runs in that mode. rt is nil in the node binary,
+// which then cannot build the controller plugin.
+func Main(fixedMode string, rt app.ControllerRuntime) {
+	controllerRuntime = rt
+	if len(os.Args) > 1 && os.Args[1] == "gen-manifests" {
+		if err := genManifests(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "gen-manifests: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	mode := &fixedMode
+	if fixedMode == "" {
+		mode = flag.String("mode", "", "Driver mode: 'controller' or 'node' (required)")
+	}
+
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if *version {
+		fmt.Printf("CSI ARCA Storage Driver\n")
+		fmt.Printf("Version: %s\n", driver.DriverVersion)
+		fmt.Printf("Driver Name: %s\n", driver.DriverName)
+		os.Exit(0)
+	}
+
+	klog.Infof("Starting CSI ARCA Storage Driver version %s", driver.DriverVersion)
+
+	// Validate mode flag
+	if *mode == "" {
+		klog.Fatal("--mode flag is required (must be 'controller' or 'node')")
+	}
+	if *mode != "controller" && *mode != "node" {
+		klog.Fatalf("Invalid mode '%s': must be 'controller' or 'node'", *mode)
+	}
+	klog.Infof("Running in %s mode", *mode)
+
+	if *checkOnly {
+		if !runChecks(context.Background(), os.Stdout, *mode) {
+			os.Exit(1)
+		}
+		return
+	}
+	if *selfTest {
+		if !runSelfTest(context.Background(), os.Stdout, *mode) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		klog.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		klog.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Apply logging configuration; an explicit --v flag overrides logging.level
+	logOpts := cfg.ToLoggingOptions()
+	if v := flagValue("v"); v != "" {
+		logOpts.Level, _ = strconv.Atoi(v)
+	}
+	closeLogs, err := logging.Setup(logOpts)
+	if err != nil {
+		klog.Fatalf("Failed to configure logging: %v", err)
+	}
+	defer closeLogs()
+
+	// Override node ID from command line if specified
+	if *nodeID != "" {
+		cfg.Driver.NodeID = *nodeID
+	}
+
+	// Validate mode consistency with node-id flag
+	isControllerMode := (*mode == "controller")
+	hasNodeID := (*nodeID != "" || cfg.Driver.NodeID != "")
+
+	if isControllerMode && hasNodeID {
+		klog.Fatal("Inconsistent configuration: controller mode requires node-id to be empty")
+	}
+	if !isControllerMode && !hasNodeID {
+		klog.Fatal("Inconsistent configuration: node mode requires --node-id flag")
+	}
+
+	// Override CSI endpoint from environment if set (useful for deployment manifests)
+	if envEndpoint := os.Getenv("CSI_ENDPOINT"); envEndpoint != "" {
+		cfg.Driver.Endpoint = envEndpoint
+	}
+
+	klog.Infof("Configuration loaded successfully")
+	klog.V(2).Infof("ARCA API endpoint: %s", cfg.ARCA.BaseURL)
+	klog.V(2).Infof("CSI endpoint: %s", cfg.Driver.Endpoint)
+	if cfg.Driver.NodeID != "" {
+		klog.V(2).Infof("Node ID: %s", cfg.Driver.NodeID)
+	}
+
+	// Build driver
+	opts := []app.Option{
+		app.WithKubeconfig(*kubeconfig),
+		app.WithKubeRateLimit(float32(*kubeAPIQPS), *kubeAPIBurst),
+		app.WithConfigReload(*configPath),
+		app.WithMetricsAddress(*metricsAddress),
+		app.WithControllerRuntime(controllerRuntime),
+	}
+	build := app.BuildNode
+	if isControllerMode {
+		build = app.BuildController
+	}
+	a, err := build(cfg, opts...)
+	if err != nil {
+		klog.Fatalf("Failed to build driver: %v", err)
+	}
+
+	// Setup signal handling for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-sigCh
+		klog.Infof("Received signal %v, initiating shutdown...", sig)
+		cancel()
+	}()
+
+	// Run driver
+	if err := a.Run(ctx); err != nil && err != context.Canceled {
+		klog.Fatalf("Driver exited with error: %v", err)
+	}
+
+	klog.Info("Driver stopped")
+}
+
+// flagValue returns the value of a flag set on the command line, or ""
+func flagValue(name string) string {
+	value := ""
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			value = f.Value.String()
+		}
+	})
+	return value
+}
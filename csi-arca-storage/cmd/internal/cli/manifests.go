@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/akam1o/csi-arca-storage/pkg/manifests"
+)
+
+// genManifests renders the deployment YAML from a values file
+func genManifests(args []string) error {
+	fs := flag.NewFlagSet("gen-manifests", flag.ContinueOnError)
+	valuesPath := fs.String("values", "", "Path to values file (defaults are used if not specified)")
+	outputPath := fs.String("output", "", "Write manifests to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	values, err := manifests.LoadValues(*valuesPath)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *outputPath != "" {
+		f, err := os.Create(*outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return manifests.Render(w, values)
+}
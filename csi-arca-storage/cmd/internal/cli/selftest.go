@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/akam1o/csi-arca-storage/pkg/app"
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+)
+
+// selfTestTimeout bounds the whole self-test, including SVM creation for a
+// namespace that has none yet
+const selfTestTimeout = 15 * time.Minute
+
+// selfTestPollInterval is how often the self-test asks whether its
+// snapshot is ready
+const selfTestPollInterval = 5 * time.Second
+
+// selfTestDir holds the staging and publish targets of the node steps
+const selfTestDir = "/var/lib/kubelet/plugins/csi.arca-storage.io/self-test"
+
+// selfTestCapability is the capability the self-test volumes are created,
+// staged and published with
+var selfTestCapability = &csi.VolumeCapability{
+	AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+	AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+}
+
+// selfTestRun runs the steps of a volume lifecycle against the configured
+// backend, writes one line per step to w and tears down what it created
+type selfTestRun struct {
+	w          io.Writer
+	controller *app.App
+	node       *app.App // nil skips the node steps
+	namespace  string
+	capacity   int64
+	failed     bool
+
+	// Created resources, deleted by cleanup
+	volumeID   string
+	snapshotID string
+	cloneID    string
+}
+
+// runSelfTest provisions a throwaway volume, snapshots and clones it,
+// expands the clone and, in node mode, mounts the volume and writes to it,
+// then deletes everything again. It reports whether all steps passed.
+func runSelfTest(ctx context.Context, w io.Writer, mode string) bool {
+	cfg, err := config.LoadConfig(*configPath)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(w, "[FAIL] config %s: %v\n", *configPath, err)
+		return false
+	}
+	capacity, err := resource.ParseQuantity(*selfTestCapacity)
+	if err != nil || capacity.Value() <= 0 {
+		fmt.Fprintf(w, "[FAIL] --self-test-capacity %q is not a positive quantity\n", *selfTestCapacity)
+		return false
+	}
+
+	opts := []app.Option{
+		app.WithKubeconfig(*kubeconfig),
+		app.WithKubeRateLimit(float32(*kubeAPIQPS), *kubeAPIBurst),
+		app.WithControllerRuntime(controllerRuntime),
+	}
+	t := &selfTestRun{w: w, namespace: *selfTestNamespace, capacity: capacity.Value()}
+
+	// The controller steps run in every mode; the node steps need a node
+	// plugin built from the same configuration. The node binary cannot
+	// build the controller plugin.
+	if controllerRuntime == nil {
+		fmt.Fprintf(w, "[FAIL] build controller: this binary only runs the node plugin, run the self-test with csi-driver --mode node\n")
+		return false
+	}
+	controllerCfg := *cfg
+	controllerCfg.Driver.NodeID = ""
+	if t.controller, err = app.BuildController(&controllerCfg, opts...); err != nil {
+		fmt.Fprintf(w, "[FAIL] build controller: %v\n", err)
+		return false
+	}
+	if mode == "node" {
+		if *nodeID != "" {
+			cfg.Driver.NodeID = *nodeID
+		}
+		if t.node, err = app.BuildNode(cfg, opts...); err != nil {
+			fmt.Fprintf(w, "[FAIL] build node plugin: %v\n", err)
+			return false
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+
+	t.run(ctx)
+	t.cleanup(context.Background())
+	return !t.failed
+}
+
+// step runs one step unless an earlier one failed
+func (t *selfTestRun) step(name string, fn func() error) {
+	if t.failed {
+		fmt.Fprintf(t.w, "[SKIP] %s\n", name)
+		return
+	}
+	start := time.Now()
+	if err := fn(); err != nil {
+		fmt.Fprintf(t.w, "[FAIL] %s: %v\n", name, err)
+		t.failed = true
+		return
+	}
+	fmt.Fprintf(t.w, "[ OK ] %s (%s)\n", name, time.Since(start).Round(time.Millisecond))
+}
+
+// report writes the result of a teardown step, which runs whether or not
+// earlier steps failed
+func (t *selfTestRun) report(name string, err error) {
+	if err != nil {
+		fmt.Fprintf(t.w, "[FAIL] %s: %v\n", name, err)
+		t.failed = true
+		return
+	}
+	fmt.Fprintf(t.w, "[ OK ] %s\n", name)
+}
+
+// run performs the lifecycle steps
+func (t *selfTestRun) run(ctx context.Context) {
+	d := t.controller.Driver
+	name := "selftest-" + uuid.NewString()
+	var volume *csi.Volume
+
+	t.step("create volume", func() error {
+		resp, err := d.CreateVolume(ctx, t.createVolumeRequest(name, nil))
+		if err != nil {
+			return err
+		}
+		volume = resp.GetVolume()
+		t.volumeID = volume.GetVolumeId()
+		return nil
+	})
+
+	t.step("create snapshot", func() error {
+		req := &csi.CreateSnapshotRequest{Name: "snapshot-" + name, SourceVolumeId: t.volumeID}
+		for {
+			resp, err := d.CreateSnapshot(ctx, req)
+			if err != nil {
+				return err
+			}
+			t.snapshotID = resp.GetSnapshot().GetSnapshotId()
+			if resp.GetSnapshot().GetReadyToUse() {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("snapshot %s not ready: %w", t.snapshotID, ctx.Err())
+			case <-time.After(selfTestPollInterval):
+			}
+		}
+	})
+
+	t.step("clone volume from snapshot", func() error {
+		source := &csi.VolumeContentSource{Type: &csi.VolumeContentSource_Snapshot{
+			Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: t.snapshotID},
+		}}
+		resp, err := d.CreateVolume(ctx, t.createVolumeRequest(name+"-clone", source))
+		if err != nil {
+			return err
+		}
+		t.cloneID = resp.GetVolume().GetVolumeId()
+		return nil
+	})
+
+	t.step("expand clone", func() error {
+		resp, err := d.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+			VolumeId:         t.cloneID,
+			CapacityRange:    &csi.CapacityRange{RequiredBytes: 2 * t.capacity},
+			VolumeCapability: selfTestCapability,
+		})
+		if err != nil {
+			return err
+		}
+		if resp.GetCapacityBytes() < 2*t.capacity {
+			return fmt.Errorf("capacity is %d bytes, want at least %d", resp.GetCapacityBytes(), 2*t.capacity)
+		}
+		return nil
+	})
+
+	if t.node == nil {
+		fmt.Fprintln(t.w, "[SKIP] mount volume (run with --mode node on a node)")
+		return
+	}
+	t.mount(ctx, volume)
+}
+
+// mount stages and publishes the volume, writes and reads back a file and
+// unpublishes and unstages it again
+func (t *selfTestRun) mount(ctx context.Context, volume *csi.Volume) {
+	if t.failed {
+		fmt.Fprintln(t.w, "[SKIP] mount volume")
+		return
+	}
+
+	n := t.node.Driver
+	dir := filepath.Join(selfTestDir, t.volumeID)
+	staging := filepath.Join(dir, "staging")
+	target := filepath.Join(dir, "target")
+	defer os.RemoveAll(dir)
+
+	t.step("stage volume", func() error {
+		_, err := n.NodeStageVolume(ctx, &csi.NodeStageVolumeRequest{
+			VolumeId:          t.volumeID,
+			StagingTargetPath: staging,
+			VolumeCapability:  selfTestCapability,
+			VolumeContext:     volume.GetVolumeContext(),
+		})
+		return err
+	})
+	t.step("publish volume", func() error {
+		_, err := n.NodePublishVolume(ctx, &csi.NodePublishVolumeRequest{
+			VolumeId:          t.volumeID,
+			StagingTargetPath: staging,
+			TargetPath:        target,
+			VolumeCapability:  selfTestCapability,
+			VolumeContext:     volume.GetVolumeContext(),
+		})
+		return err
+	})
+	t.step("write and read file", func() error {
+		file := filepath.Join(target, "self-test")
+		data := []byte(t.volumeID)
+		if err := os.WriteFile(file, data, 0600); err != nil {
+			return err
+		}
+		read, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if string(read) != string(data) {
+			return fmt.Errorf("read back %q, wrote %q", read, data)
+		}
+		return os.Remove(file)
+	})
+
+	// Unmount even after a failed step, so the volume can be deleted
+	_, err := n.NodeUnpublishVolume(ctx, &csi.NodeUnpublishVolumeRequest{VolumeId: t.volumeID, TargetPath: target})
+	t.report("unpublish volume", err)
+	_, err = n.NodeUnstageVolume(ctx, &csi.NodeUnstageVolumeRequest{VolumeId: t.volumeID, StagingTargetPath: staging})
+	t.report("unstage volume", err)
+}
+
+// cleanup deletes the clone, snapshot and volume the self-test created,
+// whether or not the steps passed
+func (t *selfTestRun) cleanup(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+
+	d := t.controller.Driver
+	if t.cloneID != "" {
+		_, err := d.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: t.cloneID})
+		t.report("delete clone "+t.cloneID, err)
+	}
+	if t.snapshotID != "" {
+		_, err := d.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: t.snapshotID})
+		t.report("delete snapshot "+t.snapshotID, err)
+	}
+	if t.volumeID != "" {
+		_, err := d.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: t.volumeID})
+		t.report("delete volume "+t.volumeID, err)
+	}
+}
+
+// createVolumeRequest returns the CreateVolume request of a self-test
+// volume in the self-test namespace
+func (t *selfTestRun) createVolumeRequest(name string, source *csi.VolumeContentSource) *csi.CreateVolumeRequest {
+	return &csi.CreateVolumeRequest{
+		Name:               name,
+		CapacityRange:      &csi.CapacityRange{RequiredBytes: t.capacity},
+		VolumeCapabilities: []*csi.VolumeCapability{selfTestCapability},
+		Parameters: map[string]string{
+			"csi.storage.k8s.io/pvc/namespace": t.namespace,
+			"csi.storage.k8s.io/pvc/name":      name,
+		},
+		VolumeContentSource: source,
+	}
+}
@@ -0,0 +1,67 @@
+// Command mount-helper performs NFS and bind mounts for an unprivileged
+// node plugin configured with driver.mount_helper_socket. It is the only
+// privileged container of a rootless node deployment.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+	"github.com/akam1o/csi-arca-storage/pkg/mounthelper"
+)
+
+var (
+	configPath = flag.String("config", "/etc/csi-arca-storage/config.yaml", "Path to the node plugin's configuration file")
+	socketPath = flag.String("socket", "", "Unix socket to listen on (default: driver.mount_helper_socket or "+mounthelper.DefaultSocketPath+")")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		klog.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		klog.Fatalf("Invalid configuration: %v", err)
+	}
+
+	socket := *socketPath
+	if socket == "" {
+		socket = cfg.Driver.MountHelperSocket
+	}
+	if socket == "" {
+		socket = mounthelper.DefaultSocketPath
+	}
+
+	allowedPaths := cfg.Driver.MountHelperAllowedPaths
+	if len(allowedPaths) == 0 {
+		baseMountPath := cfg.Driver.BaseMountPath
+		if baseMountPath == "" {
+			baseMountPath = driver.DefaultBaseMountPath
+		}
+		allowedPaths = []string{baseMountPath, mounthelper.DefaultKubeletPath}
+	}
+
+	mounter, err := mount.NewMounter(cfg.Driver.MountAudit, cfg.Driver.MountBinary)
+	if err != nil {
+		klog.Fatalf("Failed to initialize mounter: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := mounthelper.NewServer(mounter, allowedPaths).Serve(ctx, socket); err != nil {
+		klog.Fatalf("Mount helper failed: %v", err)
+	}
+	klog.Info("Mount helper stopped")
+}
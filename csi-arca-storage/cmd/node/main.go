@@ -0,0 +1,9 @@
+// Command node runs the CSI node plugin. It does not link the controller
+// plugin's controller-runtime manager and CRD store.
+package main
+
+import "github.com/akam1o/csi-arca-storage/cmd/internal/cli"
+
+func main() {
+	cli.Main("node", nil)
+}
@@ -0,0 +1,10 @@
+// Package deploy embeds deployment assets that are generated from the API
+// types so the manifest generator can render them without a checkout.
+package deploy
+
+import "embed"
+
+// CRDs holds the generated CustomResourceDefinition manifests
+//
+//go:embed crds/storage.arca.io_*.yaml
+var CRDs embed.FS
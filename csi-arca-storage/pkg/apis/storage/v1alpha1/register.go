@@ -35,6 +35,20 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ArcaVolumeList{},
 		&ArcaSnapshot{},
 		&ArcaSnapshotList{},
+		&ArcaMigration{},
+		&ArcaMigrationList{},
+		&ArcaCapacityReservation{},
+		&ArcaCapacityReservationList{},
+		&ArcaSVM{},
+		&ArcaSVMList{},
+		&ArcaLock{},
+		&ArcaLockList{},
+		&ArcaVolumeEvent{},
+		&ArcaVolumeEventList{},
+		&ArcaDriverStatus{},
+		&ArcaDriverStatusList{},
+		&ArcaOperation{},
+		&ArcaOperationList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
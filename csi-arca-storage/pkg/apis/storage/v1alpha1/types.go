@@ -24,25 +24,25 @@ type ArcaContentSource struct {
 
 	// SourceVolumeID is required when type=Volume.
 	// +kubebuilder:validation:Optional
-	// +kubebuilder:validation:Pattern=`^pvc-[a-f0-9]{16}$`
+	// +kubebuilder:validation:Pattern=`^pvc-([a-f0-9]{16}|[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})$`
 	// +kubebuilder:validation:MinLength=20
-	// +kubebuilder:validation:MaxLength=20
+	// +kubebuilder:validation:MaxLength=40
 	SourceVolumeID *string `json:"sourceVolumeID,omitempty"`
 
 	// SourceSnapshotID is required when type=Snapshot.
 	// +kubebuilder:validation:Optional
-	// +kubebuilder:validation:Pattern=`^[a-f0-9]{16}$`
+	// +kubebuilder:validation:Pattern=`^([a-f0-9]{16}|[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})$`
 	// +kubebuilder:validation:MinLength=16
-	// +kubebuilder:validation:MaxLength=16
+	// +kubebuilder:validation:MaxLength=36
 	SourceSnapshotID *string `json:"sourceSnapshotID,omitempty"`
 }
 
 type ArcaVolumeSpec struct {
 	// VolumeID is the ARCA backend identifier for this volume.
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Pattern=`^pvc-[a-f0-9]{16}$`
+	// +kubebuilder:validation:Pattern=`^pvc-([a-f0-9]{16}|[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})$`
 	// +kubebuilder:validation:MinLength=20
-	// +kubebuilder:validation:MaxLength=20
+	// +kubebuilder:validation:MaxLength=40
 	VolumeID string `json:"volumeID"`
 
 	// Name is a human-friendly name for the volume (distinct from metadata.name).
@@ -83,6 +83,71 @@ type ArcaVolumeSpec struct {
 	// ContentSource describes the source used to create this volume (clone/restore).
 	// +kubebuilder:validation:Optional
 	ContentSource *ArcaContentSource `json:"contentSource,omitempty"`
+
+	// Backend is the configured ARCA backend (tenant) holding the volume.
+	// Empty means the default backend.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	Backend string `json:"backend,omitempty"`
+
+	// ProvisioningMode is "thin" or "thick" (space preallocated on the
+	// backend). Empty means thin.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=thin;thick
+	ProvisioningMode string `json:"provisioningMode,omitempty"`
+
+	// InodeLimit is the maximum number of files and directories in the
+	// volume. Zero means unlimited.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	InodeLimit int64 `json:"inodeLimit,omitempty"`
+
+	// ExportPath is the NFS export of the SVM when the backend reported
+	// one. Empty means the export path template.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=1024
+	// +kubebuilder:validation:Pattern=`^/[A-Za-z0-9._/-]*$`
+	ExportPath string `json:"exportPath,omitempty"`
+
+	// SecureDelete wipes the volume's data before its directory is deleted
+	// (StorageClass parameter secureDelete).
+	// +kubebuilder:validation:Optional
+	SecureDelete bool `json:"secureDelete,omitempty"`
+
+	// MountProfile is the NFS mount tuning of the volume (StorageClass
+	// parameter mountProfile). Empty means general.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=database;ai-training
+	MountProfile string `json:"mountProfile,omitempty"`
+
+	// AttributeCache are the NFS attribute cache options of the volume,
+	// e.g. "actimeo=3,nocto" (StorageClass parameters actimeo, noac, nocto
+	// and lookupcache). Empty means the mount profile's.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=128
+	// +kubebuilder:validation:Pattern=`^[a-z0-9=,]*$`
+	AttributeCache string `json:"attributeCache,omitempty"`
+
+	// FSCache caches the volume's data on the local disks of the nodes
+	// (StorageClass parameter fsCache).
+	// +kubebuilder:validation:Optional
+	FSCache bool `json:"fsCache,omitempty"`
+
+	// PVCNamespace and PVCName are the PersistentVolumeClaim the volume was
+	// provisioned for (CSI parameters csi.storage.k8s.io/pvc/namespace and
+	// csi.storage.k8s.io/pvc/name).
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	PVCNamespace string `json:"pvcNamespace,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	PVCName string `json:"pvcName,omitempty"`
+
+	// PVName is the PersistentVolume bound to the volume (CSI parameter
+	// csi.storage.k8s.io/pv/name).
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	PVName string `json:"pvName,omitempty"`
 }
 
 type ArcaVolumeStatus struct {
@@ -95,6 +160,31 @@ type ArcaVolumeStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Efficiency reports the backend's deduplication and compression savings.
+	// +kubebuilder:validation:Optional
+	Efficiency *ArcaVolumeEfficiency `json:"efficiency,omitempty"`
+}
+
+// ArcaVolumeEfficiency is the physical usage of a volume on the backend
+type ArcaVolumeEfficiency struct {
+	// LogicalBytes is the data written to the volume before data reduction.
+	LogicalBytes int64 `json:"logicalBytes"`
+
+	// PhysicalBytes is the backend space the volume occupies.
+	PhysicalBytes int64 `json:"physicalBytes"`
+
+	// DedupeSavedBytes is the space saved by deduplication (including
+	// blocks shared with snapshots and clones).
+	// +kubebuilder:validation:Optional
+	DedupeSavedBytes int64 `json:"dedupeSavedBytes,omitempty"`
+
+	// CompressionSavedBytes is the space saved by compression.
+	// +kubebuilder:validation:Optional
+	CompressionSavedBytes int64 `json:"compressionSavedBytes,omitempty"`
+
+	// LastUpdateTime is when the statistics last changed.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
 }
 
 // ArcaVolume is a cluster-scoped persistent record of an ARCA volume.
@@ -104,6 +194,9 @@ type ArcaVolumeStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:storageversion
 // +kubebuilder:printcolumn:name="VolumeID",type="string",JSONPath=".spec.volumeID",description="Backend volume identifier"
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.pvcNamespace",description="Namespace of the PersistentVolumeClaim"
+// +kubebuilder:printcolumn:name="PVC",type="string",JSONPath=".spec.pvcName",description="PersistentVolumeClaim"
+// +kubebuilder:printcolumn:name="PV",type="string",JSONPath=".spec.pvName",description="PersistentVolume",priority=1
 // +kubebuilder:printcolumn:name="SVM",type="string",JSONPath=".spec.svmName",description="Storage virtual machine"
 // +kubebuilder:printcolumn:name="VIP",type="string",JSONPath=".spec.vip",description="Storage endpoint VIP"
 // +kubebuilder:printcolumn:name="Path",type="string",JSONPath=".spec.path",description="Backend path"
@@ -127,9 +220,9 @@ type ArcaVolumeList struct {
 type ArcaSnapshotSpec struct {
 	// SnapshotID is the ARCA backend identifier for this snapshot.
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Pattern=`^[a-f0-9]{16}$`
+	// +kubebuilder:validation:Pattern=`^([a-f0-9]{16}|[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})$`
 	// +kubebuilder:validation:MinLength=16
-	// +kubebuilder:validation:MaxLength=16
+	// +kubebuilder:validation:MaxLength=36
 	SnapshotID string `json:"snapshotID"`
 
 	// Name is a human-friendly name for the snapshot (distinct from metadata.name).
@@ -141,9 +234,9 @@ type ArcaSnapshotSpec struct {
 
 	// SourceVolumeID is the backend identifier of the volume this snapshot was taken from.
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Pattern=`^pvc-[a-f0-9]{16}$`
+	// +kubebuilder:validation:Pattern=`^pvc-([a-f0-9]{16}|[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})$`
 	// +kubebuilder:validation:MinLength=20
-	// +kubebuilder:validation:MaxLength=20
+	// +kubebuilder:validation:MaxLength=40
 	SourceVolumeID string `json:"sourceVolumeID"`
 
 	// SVMName is the storage virtual machine name.
@@ -167,6 +260,22 @@ type ArcaSnapshotSpec struct {
 	// CreatedAt is the backend creation timestamp.
 	// +kubebuilder:validation:Required
 	CreatedAt metav1.Time `json:"createdAt"`
+
+	// Backend is the configured ARCA backend (tenant) holding the snapshot.
+	// Empty means the default backend.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	Backend string `json:"backend,omitempty"`
+
+	// Parameters are the VolumeSnapshotClass parameters the snapshot was
+	// created with (without csi.storage.k8s.io/ ones), for policy processing.
+	// +kubebuilder:validation:Optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// SecretKeys are the keys of the snapshotter secret passed to
+	// CreateSnapshot. Secret values are never stored.
+	// +kubebuilder:validation:Optional
+	SecretKeys []string `json:"secretKeys,omitempty"`
 }
 
 type ArcaSnapshotStatus struct {
@@ -212,3 +321,663 @@ type ArcaSnapshotList struct {
 	metav1.ListMeta `json:"metadata,omitempty"`
 	Items           []ArcaSnapshot `json:"items"`
 }
+
+type ArcaMigrationPhase string
+
+const (
+	ArcaMigrationPhasePending      ArcaMigrationPhase = "Pending"
+	ArcaMigrationPhasePreparing    ArcaMigrationPhase = "Preparing"
+	ArcaMigrationPhaseAwaitingCopy ArcaMigrationPhase = "AwaitingCopy"
+	ArcaMigrationPhaseCuttingOver  ArcaMigrationPhase = "CuttingOver"
+	ArcaMigrationPhaseCompleted    ArcaMigrationPhase = "Completed"
+	ArcaMigrationPhaseFailed       ArcaMigrationPhase = "Failed"
+)
+
+type ArcaMigrationSpec struct {
+	// Namespace is the Kubernetes namespace whose SVM is migrated.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="namespace is immutable"
+	Namespace string `json:"namespace"`
+
+	// TargetBackend is the configured ARCA backend (tenant) to move the SVM to.
+	// Empty means the default backend.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="targetBackend is immutable"
+	TargetBackend string `json:"targetBackend,omitempty"`
+
+	// DataCopied confirms that volume data has been copied from the source
+	// SVM to the target SVM, allowing cutover to proceed.
+	// +kubebuilder:validation:Optional
+	DataCopied bool `json:"dataCopied,omitempty"`
+}
+
+type ArcaMigrationStatus struct {
+	// ObservedGeneration is the most recent generation observed for this resource.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Phase is the current step of the migration.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Pending;Preparing;AwaitingCopy;CuttingOver;Completed;Failed
+	Phase ArcaMigrationPhase `json:"phase,omitempty"`
+
+	// SVMName is the storage virtual machine being migrated.
+	// +kubebuilder:validation:Optional
+	SVMName string `json:"svmName,omitempty"`
+
+	// SourceBackend is the backend holding the volumes before cutover.
+	// +kubebuilder:validation:Optional
+	SourceBackend string `json:"sourceBackend,omitempty"`
+
+	// SourceVIP is the SVM VIP on the source backend.
+	// +kubebuilder:validation:Optional
+	SourceVIP string `json:"sourceVIP,omitempty"`
+
+	// TargetVIP is the SVM VIP on the target backend.
+	// +kubebuilder:validation:Optional
+	TargetVIP string `json:"targetVIP,omitempty"`
+
+	// Volumes lists the volume IDs moved by this migration.
+	// +kubebuilder:validation:Optional
+	Volumes []string `json:"volumes,omitempty"`
+
+	// Message describes the current phase or the failure reason.
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+// ArcaMigration moves a namespace's SVM and volumes to another ARCA backend.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,path=arcamigrations,singular=arcamigration,shortName=amig,categories=storage;arca
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.namespace",description="Migrated namespace"
+// +kubebuilder:printcolumn:name="Source",type="string",JSONPath=".status.sourceBackend",description="Source backend"
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.targetBackend",description="Target backend"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Migration phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ArcaMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ArcaMigrationSpec   `json:"spec"`
+	Status ArcaMigrationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type ArcaMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ArcaMigration `json:"items"`
+}
+
+type ArcaCapacityReservationPhase string
+
+const (
+	ArcaCapacityReservationPhaseActive    ArcaCapacityReservationPhase = "Active"
+	ArcaCapacityReservationPhaseFulfilled ArcaCapacityReservationPhase = "Fulfilled"
+	ArcaCapacityReservationPhaseExpired   ArcaCapacityReservationPhase = "Expired"
+)
+
+type ArcaCapacityReservationSpec struct {
+	// Namespace is the Kubernetes namespace whose SVM the capacity is reserved on.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="namespace is immutable"
+	Namespace string `json:"namespace"`
+
+	// CapacityBytes is the quota held for volumes later created in the namespace.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	CapacityBytes int64 `json:"capacityBytes"`
+
+	// ExpiresAt releases the unused part of the reservation at this time.
+	// +kubebuilder:validation:Optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+type ArcaCapacityReservationStatus struct {
+	// ObservedGeneration is the most recent generation observed for this resource.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Phase is Active while capacity is held, Fulfilled once volumes have
+	// consumed all of it and Expired after expiresAt.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Active;Fulfilled;Expired
+	Phase ArcaCapacityReservationPhase `json:"phase,omitempty"`
+
+	// Backend is the ARCA backend (tenant) the namespace is routed to.
+	// +kubebuilder:validation:Optional
+	Backend string `json:"backend,omitempty"`
+
+	// SVMName is the storage virtual machine of the namespace.
+	// +kubebuilder:validation:Optional
+	SVMName string `json:"svmName,omitempty"`
+
+	// ConsumedBytes is the capacity of volumes created in the namespace since
+	// the reservation was made.
+	// +kubebuilder:validation:Optional
+	ConsumedBytes int64 `json:"consumedBytes,omitempty"`
+
+	// RemainingBytes is the capacity still held for the namespace.
+	// +kubebuilder:validation:Optional
+	RemainingBytes int64 `json:"remainingBytes,omitempty"`
+}
+
+// ArcaCapacityReservation holds backend capacity for volumes that will be
+// created in a namespace.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,path=arcacapacityreservations,singular=arcacapacityreservation,shortName=acr,categories=storage;arca
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.namespace",description="Namespace holding the reservation"
+// +kubebuilder:printcolumn:name="Capacity",type="integer",JSONPath=".spec.capacityBytes",description="Reserved bytes"
+// +kubebuilder:printcolumn:name="Remaining",type="integer",JSONPath=".status.remainingBytes",description="Bytes still held"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Reservation phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ArcaCapacityReservation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ArcaCapacityReservationSpec   `json:"spec"`
+	Status ArcaCapacityReservationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type ArcaCapacityReservationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ArcaCapacityReservation `json:"items"`
+}
+
+// ArcaSVMConditionExportsInSync reports whether the SVM's export rules match
+// the expected cluster clients
+const ArcaSVMConditionExportsInSync = "ExportsInSync"
+
+// ArcaSVMConditionAttributesInSync reports whether the SVM's MTU, gateway
+// and capacity match the spec and the driver configuration
+const ArcaSVMConditionAttributesInSync = "AttributesInSync"
+
+type ArcaSVMSpec struct {
+	// Namespace is the Kubernetes namespace the SVM serves.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Namespace string `json:"namespace"`
+
+	// Backend is the configured ARCA backend (tenant) holding the SVM.
+	// Empty means the default backend.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	Backend string `json:"backend,omitempty"`
+
+	// MTU requests a network MTU for the SVM instead of the configured one.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=68
+	// +kubebuilder:validation:Maximum=65535
+	MTU int `json:"mtu,omitempty"`
+
+	// Gateway requests a default gateway for the SVM instead of its pool's.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=45
+	Gateway string `json:"gateway,omitempty"`
+
+	// CapacityBytes requests a resize of the SVM's storage.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	CapacityBytes int64 `json:"capacityBytes,omitempty"`
+}
+
+// ArcaExportRule is an NFS export rule as configured on the backend
+type ArcaExportRule struct {
+	// Volume is the exported volume.
+	Volume string `json:"volume"`
+
+	// Client is the client address or CIDR the rule applies to.
+	Client string `json:"client"`
+
+	// Access is "rw" or "ro".
+	// +kubebuilder:validation:Optional
+	Access string `json:"access,omitempty"`
+
+	// RootSquash maps root on the client to an anonymous user.
+	// +kubebuilder:validation:Optional
+	RootSquash bool `json:"rootSquash,omitempty"`
+
+	// Sec lists the allowed security flavors.
+	// +kubebuilder:validation:Optional
+	Sec []string `json:"sec,omitempty"`
+}
+
+type ArcaSVMStatus struct {
+	// ObservedGeneration is the most recent generation observed for this resource.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// VIP is the SVM's NFS address.
+	// +kubebuilder:validation:Optional
+	VIP string `json:"vip,omitempty"`
+
+	// MTU is the SVM's network MTU.
+	// +kubebuilder:validation:Optional
+	MTU int `json:"mtu,omitempty"`
+
+	// Gateway is the SVM's default gateway.
+	// +kubebuilder:validation:Optional
+	Gateway string `json:"gateway,omitempty"`
+
+	// CapacityBytes is the SVM's total capacity, read while a resize is
+	// requested in the spec.
+	// +kubebuilder:validation:Optional
+	CapacityBytes int64 `json:"capacityBytes,omitempty"`
+
+	// AttributeDrift describes differences between the SVM's MTU, gateway
+	// and capacity and the spec or the driver configuration.
+	// +kubebuilder:validation:Optional
+	AttributeDrift []string `json:"attributeDrift,omitempty"`
+
+	// ExportRules are the SVM's export rules as last read from the backend.
+	// +kubebuilder:validation:Optional
+	ExportRules []ArcaExportRule `json:"exportRules,omitempty"`
+
+	// Drift describes differences between the export rules and the
+	// expected cluster clients.
+	// +kubebuilder:validation:Optional
+	Drift []string `json:"drift,omitempty"`
+
+	// Conditions represent the latest available observations of this resource's state.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ArcaSVM records the backend state of a storage virtual machine created by
+// the driver.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,path=arcasvms,singular=arcasvm,shortName=asvm,categories=storage;arca
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.namespace",description="Served namespace"
+// +kubebuilder:printcolumn:name="Backend",type="string",JSONPath=".spec.backend",description="ARCA backend"
+// +kubebuilder:printcolumn:name="VIP",type="string",JSONPath=".status.vip",description="Storage endpoint VIP"
+// +kubebuilder:printcolumn:name="InSync",type="string",JSONPath=".status.conditions[?(@.type==\"ExportsInSync\")].status",description="Export rules match the expected clients"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ArcaSVM struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ArcaSVMSpec   `json:"spec"`
+	Status ArcaSVMStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type ArcaSVMList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ArcaSVM `json:"items"`
+}
+
+type ArcaLockSpec struct {
+	// HolderIdentity is the controller instance holding the lock.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	HolderIdentity string `json:"holderIdentity"`
+
+	// LeaseDurationSeconds is how long the lock is held without renewal.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	LeaseDurationSeconds int32 `json:"leaseDurationSeconds"`
+
+	// RenewTime is when the holder last renewed the lock.
+	// +kubebuilder:validation:Required
+	RenewTime metav1.MicroTime `json:"renewTime"`
+}
+
+// ArcaLock is a distributed lock held by the controller, used instead of a
+// Lease where creating Leases is not allowed.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,path=arcalocks,singular=arcalock,shortName=alock,categories=storage;arca
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Holder",type="string",JSONPath=".spec.holderIdentity",description="Lock holder"
+// +kubebuilder:printcolumn:name="Renewed",type="date",JSONPath=".spec.renewTime",description="Last renewal"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ArcaLock struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ArcaLockSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+type ArcaLockList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ArcaLock `json:"items"`
+}
+
+// Volume access operations recorded in ArcaVolumeEvents
+const (
+	ArcaVolumeEventStage     = "Stage"
+	ArcaVolumeEventUnstage   = "Unstage"
+	ArcaVolumeEventPublish   = "Publish"
+	ArcaVolumeEventUnpublish = "Unpublish"
+)
+
+// Results of ArcaVolumeEvent operations
+const (
+	ArcaVolumeEventSucceeded = "Succeeded"
+	ArcaVolumeEventFailed    = "Failed"
+)
+
+type ArcaVolumeEventSpec struct {
+	// VolumeID is the volume the node accessed.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=128
+	VolumeID string `json:"volumeID"`
+
+	// NodeID is the node plugin that performed the operation.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	NodeID string `json:"nodeID"`
+
+	// Operation is the node call.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Stage;Unstage;Publish;Unpublish
+	Operation string `json:"operation"`
+
+	// Result is "Succeeded" or "Failed".
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Succeeded;Failed
+	Result string `json:"result"`
+
+	// Message is the error of a failed operation.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=1024
+	Message string `json:"message,omitempty"`
+
+	// Time is when the operation completed.
+	// +kubebuilder:validation:Required
+	Time metav1.MicroTime `json:"time"`
+
+	// TargetPath is the staging or publish path on the node.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=4096
+	TargetPath string `json:"targetPath,omitempty"`
+
+	// ReadOnly is set for read-only publishes.
+	// +kubebuilder:validation:Optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// PodNamespace, PodName and PodUID identify the pod a volume was
+	// published for, when kubelet passed pod information.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	PodNamespace string `json:"podNamespace,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	PodName string `json:"podName,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=36
+	PodUID string `json:"podUID,omitempty"`
+}
+
+// ArcaVolumeEvent records a node plugin staging, unstaging, publishing or
+// unpublishing a volume, for auditing which nodes accessed which volumes.
+// Events are only ever created and deleted.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,path=arcavolumeevents,singular=arcavolumeevent,shortName=ave,categories=storage;arca
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="VolumeID",type="string",JSONPath=".spec.volumeID",description="Accessed volume"
+// +kubebuilder:printcolumn:name="Node",type="string",JSONPath=".spec.nodeID",description="Node plugin"
+// +kubebuilder:printcolumn:name="Operation",type="string",JSONPath=".spec.operation",description="Node call"
+// +kubebuilder:printcolumn:name="Result",type="string",JSONPath=".spec.result",description="Outcome"
+// +kubebuilder:printcolumn:name="Time",type="date",JSONPath=".spec.time",description="Completion time"
+type ArcaVolumeEvent struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ArcaVolumeEventSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+type ArcaVolumeEventList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ArcaVolumeEvent `json:"items"`
+}
+
+// ArcaDriverStatusName is the name of the ArcaDriverStatus written by the
+// controller
+const ArcaDriverStatusName = "arca-csi"
+
+// ArcaDriverBackendStatus is the connectivity of an ARCA backend.
+type ArcaDriverBackendStatus struct {
+	// Name is the backend (tenant) name, "default" for the default backend.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Endpoints is the number of configured API endpoints.
+	// +kubebuilder:validation:Required
+	Endpoints int `json:"endpoints"`
+
+	// HealthyEndpoints is the number of endpoints in rotation.
+	// +kubebuilder:validation:Required
+	HealthyEndpoints int `json:"healthyEndpoints"`
+
+	// LastError is the last error of an unhealthy endpoint.
+	// +kubebuilder:validation:Optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// ArcaDriverPoolStatus is the utilization of an IP pool.
+type ArcaDriverPoolStatus struct {
+	// Backend is the backend (tenant) name, "default" for the default backend.
+	// +kubebuilder:validation:Required
+	Backend string `json:"backend"`
+
+	// Name is the pool name.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// VLANID is the pool's VLAN.
+	// +kubebuilder:validation:Optional
+	VLANID int `json:"vlanID,omitempty"`
+
+	// Addresses is the number of allocatable addresses.
+	// +kubebuilder:validation:Required
+	Addresses int `json:"addresses"`
+
+	// Allocated is the number of SVMs holding an address of the pool.
+	// +kubebuilder:validation:Required
+	Allocated int `json:"allocated"`
+
+	// Drained is set for pools that receive no new allocations.
+	// +kubebuilder:validation:Optional
+	Drained bool `json:"drained,omitempty"`
+}
+
+type ArcaDriverStatusStatus struct {
+	// ControllerVersion is the driver version of the reporting controller.
+	// +kubebuilder:validation:Optional
+	ControllerVersion string `json:"controllerVersion,omitempty"`
+
+	// ReportedBy is the controller pod that wrote the status.
+	// +kubebuilder:validation:Optional
+	ReportedBy string `json:"reportedBy,omitempty"`
+
+	// LastUpdated is when the status was written.
+	// +kubebuilder:validation:Optional
+	LastUpdated metav1.Time `json:"lastUpdated,omitempty"`
+
+	// Backends is the connectivity of each ARCA backend.
+	// +kubebuilder:validation:Optional
+	Backends []ArcaDriverBackendStatus `json:"backends,omitempty"`
+
+	// Pools is the utilization of each IP pool; omitted while SVMs cannot
+	// be listed.
+	// +kubebuilder:validation:Optional
+	Pools []ArcaDriverPoolStatus `json:"pools,omitempty"`
+
+	// Volumes is the number of ArcaVolumes.
+	// +kubebuilder:validation:Optional
+	Volumes int `json:"volumes"`
+
+	// Snapshots is the number of ArcaSnapshots.
+	// +kubebuilder:validation:Optional
+	Snapshots int `json:"snapshots"`
+
+	// PendingOperations is the number of controller operations in progress.
+	// +kubebuilder:validation:Optional
+	PendingOperations int `json:"pendingOperations"`
+
+	// NodeVersions counts the live node plugins per driver version, when
+	// version skew detection is enabled.
+	// +kubebuilder:validation:Optional
+	NodeVersions map[string]int `json:"nodeVersions,omitempty"`
+
+	// Conditions represent the latest available observations of the driver.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ArcaDriverStatus summarizes the health of the driver for operators and
+// monitoring: backend connectivity, IP pool utilization, volume and snapshot
+// counts, pending operations and component versions. The controller keeps
+// the single ArcaDriverStatus named "arca-csi" up to date.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,path=arcadriverstatuses,singular=arcadriverstatus,shortName=ads,categories=storage;arca
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.controllerVersion",description="Controller version"
+// +kubebuilder:printcolumn:name="Healthy",type="string",JSONPath=".status.conditions[?(@.type==\"BackendsReachable\")].status",description="All backends reachable"
+// +kubebuilder:printcolumn:name="Volumes",type="integer",JSONPath=".status.volumes",description="ArcaVolumes"
+// +kubebuilder:printcolumn:name="Snapshots",type="integer",JSONPath=".status.snapshots",description="ArcaSnapshots"
+// +kubebuilder:printcolumn:name="Updated",type="date",JSONPath=".status.lastUpdated",description="Last report"
+type ArcaDriverStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status ArcaDriverStatusStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type ArcaDriverStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ArcaDriverStatus `json:"items"`
+}
+
+type ArcaOperationPhase string
+
+const (
+	ArcaOperationPhasePending ArcaOperationPhase = "Pending"
+	ArcaOperationPhaseRunning ArcaOperationPhase = "Running"
+	ArcaOperationPhaseFailed  ArcaOperationPhase = "Failed"
+)
+
+type ArcaOperationSpec struct {
+	// Type is the kind of operation; VolumeID and SnapshotID records are
+	// markers of an assigned ID rather than operations.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=SVMCreate;Clone;VolumeDelete;VolumeID;SnapshotID
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="type is immutable"
+	Type string `json:"type"`
+
+	// Key identifies the operation within its type, e.g. the namespace of
+	// an SVM or the ID of a volume. An operation runs at most once at a
+	// time per type and key.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="key is immutable"
+	Key string `json:"key"`
+
+	// Backend is the ARCA backend (tenant) the operation runs on, empty for
+	// the default backend.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	Backend string `json:"backend,omitempty"`
+
+	// Parameters are the arguments needed to resume the operation.
+	// +kubebuilder:validation:Optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+type ArcaOperationStatus struct {
+	// Phase is Running while a controller executes the operation, Pending
+	// while it waits for a (next) attempt and Failed once attempts are
+	// exhausted.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Pending;Running;Failed
+	Phase ArcaOperationPhase `json:"phase,omitempty"`
+
+	// HolderIdentity is the controller instance running the operation.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	HolderIdentity string `json:"holderIdentity,omitempty"`
+
+	// RenewTime is when the holder last confirmed it is still running the
+	// operation; another controller takes over once it is stale.
+	// +kubebuilder:validation:Optional
+	RenewTime *metav1.MicroTime `json:"renewTime,omitempty"`
+
+	// Attempts is the number of times the operation was started.
+	// +kubebuilder:validation:Optional
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// NextAttempt is when a Pending operation is retried.
+	// +kubebuilder:validation:Optional
+	NextAttempt *metav1.Time `json:"nextAttempt,omitempty"`
+
+	// LastError is the error of the last failed attempt.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=1024
+	LastError string `json:"lastError,omitempty"`
+}
+
+// ArcaOperation records a long-running controller operation (SVM creation,
+// clone, volume deletion) while it is in progress, so that a restarted
+// controller resumes it. Completed operations are deleted.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,path=arcaoperations,singular=arcaoperation,shortName=aop,categories=storage;arca
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description="Operation type"
+// +kubebuilder:printcolumn:name="Key",type="string",JSONPath=".spec.key",description="Operation key"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Operation phase"
+// +kubebuilder:printcolumn:name="Attempts",type="integer",JSONPath=".status.attempts",description="Attempts"
+// +kubebuilder:printcolumn:name="Holder",type="string",JSONPath=".status.holderIdentity",description="Running controller",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ArcaOperation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ArcaOperationSpec   `json:"spec"`
+	Status ArcaOperationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type ArcaOperationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ArcaOperation `json:"items"`
+}
@@ -11,27 +11,653 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaCapacityReservation) DeepCopyInto(out *ArcaCapacityReservation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaCapacityReservation.
+func (in *ArcaCapacityReservation) DeepCopy() *ArcaCapacityReservation {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaCapacityReservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaCapacityReservation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaCapacityReservationList) DeepCopyInto(out *ArcaCapacityReservationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ArcaCapacityReservation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaCapacityReservationList.
+func (in *ArcaCapacityReservationList) DeepCopy() *ArcaCapacityReservationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaCapacityReservationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaCapacityReservationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaCapacityReservationSpec) DeepCopyInto(out *ArcaCapacityReservationSpec) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaCapacityReservationSpec.
+func (in *ArcaCapacityReservationSpec) DeepCopy() *ArcaCapacityReservationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaCapacityReservationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaCapacityReservationStatus) DeepCopyInto(out *ArcaCapacityReservationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaCapacityReservationStatus.
+func (in *ArcaCapacityReservationStatus) DeepCopy() *ArcaCapacityReservationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaCapacityReservationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ArcaContentSource) DeepCopyInto(out *ArcaContentSource) {
 	*out = *in
-	if in.SourceVolumeID != nil {
-		in, out := &in.SourceVolumeID, &out.SourceVolumeID
-		*out = new(string)
-		**out = **in
+	if in.SourceVolumeID != nil {
+		in, out := &in.SourceVolumeID, &out.SourceVolumeID
+		*out = new(string)
+		**out = **in
+	}
+	if in.SourceSnapshotID != nil {
+		in, out := &in.SourceSnapshotID, &out.SourceSnapshotID
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaContentSource.
+func (in *ArcaContentSource) DeepCopy() *ArcaContentSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaContentSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaDriverBackendStatus) DeepCopyInto(out *ArcaDriverBackendStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaDriverBackendStatus.
+func (in *ArcaDriverBackendStatus) DeepCopy() *ArcaDriverBackendStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaDriverBackendStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaDriverPoolStatus) DeepCopyInto(out *ArcaDriverPoolStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaDriverPoolStatus.
+func (in *ArcaDriverPoolStatus) DeepCopy() *ArcaDriverPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaDriverPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaDriverStatus) DeepCopyInto(out *ArcaDriverStatus) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaDriverStatus.
+func (in *ArcaDriverStatus) DeepCopy() *ArcaDriverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaDriverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaDriverStatus) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaDriverStatusList) DeepCopyInto(out *ArcaDriverStatusList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ArcaDriverStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaDriverStatusList.
+func (in *ArcaDriverStatusList) DeepCopy() *ArcaDriverStatusList {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaDriverStatusList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaDriverStatusList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaDriverStatusStatus) DeepCopyInto(out *ArcaDriverStatusStatus) {
+	*out = *in
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+	if in.Backends != nil {
+		in, out := &in.Backends, &out.Backends
+		*out = make([]ArcaDriverBackendStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]ArcaDriverPoolStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeVersions != nil {
+		in, out := &in.NodeVersions, &out.NodeVersions
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaDriverStatusStatus.
+func (in *ArcaDriverStatusStatus) DeepCopy() *ArcaDriverStatusStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaDriverStatusStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaExportRule) DeepCopyInto(out *ArcaExportRule) {
+	*out = *in
+	if in.Sec != nil {
+		in, out := &in.Sec, &out.Sec
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaExportRule.
+func (in *ArcaExportRule) DeepCopy() *ArcaExportRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaExportRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaLock) DeepCopyInto(out *ArcaLock) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaLock.
+func (in *ArcaLock) DeepCopy() *ArcaLock {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaLock)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaLock) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaLockList) DeepCopyInto(out *ArcaLockList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ArcaLock, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaLockList.
+func (in *ArcaLockList) DeepCopy() *ArcaLockList {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaLockList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaLockList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaLockSpec) DeepCopyInto(out *ArcaLockSpec) {
+	*out = *in
+	in.RenewTime.DeepCopyInto(&out.RenewTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaLockSpec.
+func (in *ArcaLockSpec) DeepCopy() *ArcaLockSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaLockSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaMigration) DeepCopyInto(out *ArcaMigration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaMigration.
+func (in *ArcaMigration) DeepCopy() *ArcaMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaMigration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaMigrationList) DeepCopyInto(out *ArcaMigrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ArcaMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaMigrationList.
+func (in *ArcaMigrationList) DeepCopy() *ArcaMigrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaMigrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaMigrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaMigrationSpec) DeepCopyInto(out *ArcaMigrationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaMigrationSpec.
+func (in *ArcaMigrationSpec) DeepCopy() *ArcaMigrationSpec {
+	if in == nil {
+		return nil
 	}
-	if in.SourceSnapshotID != nil {
-		in, out := &in.SourceSnapshotID, &out.SourceSnapshotID
-		*out = new(string)
-		**out = **in
+	out := new(ArcaMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaMigrationStatus) DeepCopyInto(out *ArcaMigrationStatus) {
+	*out = *in
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaContentSource.
-func (in *ArcaContentSource) DeepCopy() *ArcaContentSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaMigrationStatus.
+func (in *ArcaMigrationStatus) DeepCopy() *ArcaMigrationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ArcaContentSource)
+	out := new(ArcaMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaOperation) DeepCopyInto(out *ArcaOperation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaOperation.
+func (in *ArcaOperation) DeepCopy() *ArcaOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaOperation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaOperationList) DeepCopyInto(out *ArcaOperationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ArcaOperation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaOperationList.
+func (in *ArcaOperationList) DeepCopy() *ArcaOperationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaOperationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaOperationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaOperationSpec) DeepCopyInto(out *ArcaOperationSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaOperationSpec.
+func (in *ArcaOperationSpec) DeepCopy() *ArcaOperationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaOperationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaOperationStatus) DeepCopyInto(out *ArcaOperationStatus) {
+	*out = *in
+	if in.RenewTime != nil {
+		in, out := &in.RenewTime, &out.RenewTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextAttempt != nil {
+		in, out := &in.NextAttempt, &out.NextAttempt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaOperationStatus.
+func (in *ArcaOperationStatus) DeepCopy() *ArcaOperationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaOperationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaSVM) DeepCopyInto(out *ArcaSVM) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaSVM.
+func (in *ArcaSVM) DeepCopy() *ArcaSVM {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaSVM)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaSVM) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaSVMList) DeepCopyInto(out *ArcaSVMList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ArcaSVM, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaSVMList.
+func (in *ArcaSVMList) DeepCopy() *ArcaSVMList {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaSVMList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaSVMList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaSVMSpec) DeepCopyInto(out *ArcaSVMSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaSVMSpec.
+func (in *ArcaSVMSpec) DeepCopy() *ArcaSVMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaSVMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaSVMStatus) DeepCopyInto(out *ArcaSVMStatus) {
+	*out = *in
+	if in.AttributeDrift != nil {
+		in, out := &in.AttributeDrift, &out.AttributeDrift
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExportRules != nil {
+		in, out := &in.ExportRules, &out.ExportRules
+		*out = make([]ArcaExportRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaSVMStatus.
+func (in *ArcaSVMStatus) DeepCopy() *ArcaSVMStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaSVMStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -99,6 +725,18 @@ func (in *ArcaSnapshotList) DeepCopyObject() runtime.Object {
 func (in *ArcaSnapshotSpec) DeepCopyInto(out *ArcaSnapshotSpec) {
 	*out = *in
 	in.CreatedAt.DeepCopyInto(&out.CreatedAt)
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecretKeys != nil {
+		in, out := &in.SecretKeys, &out.SecretKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaSnapshotSpec.
@@ -160,6 +798,96 @@ func (in *ArcaVolume) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaVolumeEfficiency) DeepCopyInto(out *ArcaVolumeEfficiency) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaVolumeEfficiency.
+func (in *ArcaVolumeEfficiency) DeepCopy() *ArcaVolumeEfficiency {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaVolumeEfficiency)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaVolumeEvent) DeepCopyInto(out *ArcaVolumeEvent) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaVolumeEvent.
+func (in *ArcaVolumeEvent) DeepCopy() *ArcaVolumeEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaVolumeEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaVolumeEvent) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaVolumeEventList) DeepCopyInto(out *ArcaVolumeEventList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ArcaVolumeEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaVolumeEventList.
+func (in *ArcaVolumeEventList) DeepCopy() *ArcaVolumeEventList {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaVolumeEventList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ArcaVolumeEventList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArcaVolumeEventSpec) DeepCopyInto(out *ArcaVolumeEventSpec) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaVolumeEventSpec.
+func (in *ArcaVolumeEventSpec) DeepCopy() *ArcaVolumeEventSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ArcaVolumeEventSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ArcaVolumeList) DeepCopyInto(out *ArcaVolumeList) {
 	*out = *in
@@ -223,6 +951,11 @@ func (in *ArcaVolumeStatus) DeepCopyInto(out *ArcaVolumeStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Efficiency != nil {
+		in, out := &in.Efficiency, &out.Efficiency
+		*out = new(ArcaVolumeEfficiency)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArcaVolumeStatus.
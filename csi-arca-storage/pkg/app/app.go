@@ -0,0 +1,800 @@
+// Package app wires the CSI driver from configuration so it can be embedded
+// in other programs and exercised by integration tests without the binary.
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+	"github.com/akam1o/csi-arca-storage/pkg/csidriver"
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/driverstatus"
+	"github.com/akam1o/csi-arca-storage/pkg/efficiency"
+	"github.com/akam1o/csi-arca-storage/pkg/exportaudit"
+	"github.com/akam1o/csi-arca-storage/pkg/lock"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+	"github.com/akam1o/csi-arca-storage/pkg/migration"
+	"github.com/akam1o/csi-arca-storage/pkg/nscleanup"
+	"github.com/akam1o/csi-arca-storage/pkg/opqueue"
+	"github.com/akam1o/csi-arca-storage/pkg/orphan"
+	"github.com/akam1o/csi-arca-storage/pkg/policy"
+	"github.com/akam1o/csi-arca-storage/pkg/pvannotation"
+	"github.com/akam1o/csi-arca-storage/pkg/reservation"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+	"github.com/akam1o/csi-arca-storage/pkg/svmevent"
+	"github.com/akam1o/csi-arca-storage/pkg/versionskew"
+	"github.com/akam1o/csi-arca-storage/pkg/volumeevent"
+)
+
+// configReloadInterval is how often the config file is checked for changes
+const configReloadInterval = 30 * time.Second
+
+// csiDriverTimeout bounds creating or updating the CSIDriver object
+const csiDriverTimeout = 30 * time.Second
+
+// App is a built driver together with its background workers
+type App struct {
+	Driver *driver.Driver
+
+	// runners are started in the background by Run
+	runners []func(ctx context.Context)
+	// waiters must complete before the driver starts serving
+	waiters []func(ctx context.Context)
+}
+
+// BuildController builds the controller plugin from configuration
+func BuildController(cfg *config.Config, opts ...Option) (*App, error) {
+	return build("controller", cfg, opts)
+}
+
+// BuildNode builds the node plugin from configuration (cfg.Driver.NodeID is required)
+func BuildNode(cfg *config.Config, opts ...Option) (*App, error) {
+	if cfg.Driver.NodeID == "" {
+		return nil, fmt.Errorf("node mode requires a node ID")
+	}
+	return build("node", cfg, opts)
+}
+
+// Run starts background workers and serves the CSI driver until ctx is cancelled
+func (a *App) Run(ctx context.Context) error {
+	for _, run := range a.runners {
+		go run(ctx)
+	}
+	for _, wait := range a.waiters {
+		wait(ctx)
+	}
+	return a.Driver.Run(ctx)
+}
+
+func build(mode string, cfg *config.Config, opts []Option) (*App, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	isControllerMode := mode == "controller"
+	if isControllerMode && cfg.Driver.NodeID != "" {
+		return nil, fmt.Errorf("controller mode requires node ID to be empty")
+	}
+
+	app := &App{}
+
+	// Leases live in the plugin's namespace; the controller holds them as
+	// its pod, node plugins publish theirs under the node ID
+	pod, err := podIdentityFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	leaseNamespace := pod.leaseNamespace()
+	lockIdentity, leaseIdentity := cfg.Driver.NodeID, cfg.Driver.NodeID
+	if isControllerMode {
+		if lockIdentity, err = pod.holderIdentity(); err != nil {
+			return nil, err
+		}
+		leaseIdentity = pod.name
+	}
+	klog.V(2).Infof("Using lease namespace %s and lock identity %s", leaseNamespace, lockIdentity)
+
+	// Kubernetes client is needed for leases and CRDs in controller mode and
+	// for the ArcaVolume reader in node mode
+	needsReader := !isControllerMode && (cfg.Driver.VolumeLookup || cfg.Driver.ValidateVolumeContext)
+	if o.k8sClient == nil && (isControllerMode || needsReader || cfg.Driver.VersionSkewCheck) {
+		restConfig, clientset, err := createKubernetesClient(o.kubeconfig, o.kubeQPS, o.kubeBurst)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+		o.restConfig = restConfig
+		o.k8sClient = clientset
+	}
+
+	// Controller-runtime client: the controller shares one manager between
+	// the CRD store and its background controllers and watches
+	var kubeClient client.Client
+	var mgr Manager
+	if o.restConfig != nil {
+		var err error
+		if isControllerMode {
+			if o.controllerRuntime == nil {
+				return nil, errNoControllerRuntime
+			}
+			if mgr, err = o.controllerRuntime.NewManager(o.restConfig); err != nil {
+				return nil, err
+			}
+			kubeClient = mgr.GetClient()
+			app.runners = append(app.runners, runManager(mgr))
+		} else if kubeClient, err = newClient(o.restConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fail fast on missing RBAC instead of failing later inside a CSI RPC
+	if o.k8sClient != nil && !cfg.Driver.SkipPermissionCheck {
+		if err := checkPermissions(context.Background(), o.k8sClient, requiredPermissions(isControllerMode, needsReader, leaseNamespace, cfg)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create ARCA API client
+	arcaClient := o.arcaClient
+	if arcaClient == nil {
+		var err error
+		arcaClient, err = arca.NewClient(cfg.ToArcaClientConfig())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ARCA client: %w", err)
+		}
+	}
+
+	// Create network allocator
+	allocator, err := arca.NewStandaloneAllocator(cfg.ToArcaPoolConfigs(), arcaClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create network allocator: %w", err)
+	}
+	if err := allocator.SetStrategy(arca.AllocationStrategy(cfg.Network.AllocationStrategy)); err != nil {
+		return nil, fmt.Errorf("failed to configure network allocator: %w", err)
+	}
+	allocator.SetConflictHold(cfg.Network.ConflictHold.Duration)
+	if isControllerMode && len(cfg.Network.Affinity) > 0 {
+		affinity, err := policy.NewPoolAffinity(cfg.Network.ToPoolRules(), poolNames(allocator), o.k8sClient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network.affinity: %w", err)
+		}
+		allocator.SetPoolAffinity(affinity)
+	}
+
+	// Create lock manager
+	var lockBackend lock.Backend
+	var lockManager *lock.Manager
+	if o.k8sClient != nil {
+		lockBackend = lock.NewLeaseBackend(o.k8sClient, leaseNamespace)
+		if isControllerMode && cfg.Driver.LockBackend == lock.BackendCRD {
+			if o.restConfig == nil {
+				return nil, fmt.Errorf("driver.lock_backend crd requires a Kubernetes REST config")
+			}
+			lockBackend = lock.NewCRDBackend(kubeClient)
+		}
+		lockManager = lock.NewManager(lockBackend, lockIdentity)
+	}
+
+	// Create SVM manager
+	svmManager := arca.NewSVMManager(arcaClient, allocator, lockManager, cfg.Network.MTU)
+	svmManager.SetCacheTTL(cfg.SVM.CacheTTL.Duration)
+	if err := svmManager.SetHealthPolicy(arca.UnhealthyAction(cfg.SVM.UnhealthyAction), cfg.SVM.ReadyTimeout.Duration); err != nil {
+		return nil, fmt.Errorf("invalid svm.unhealthy_action: %w", err)
+	}
+	if cfg.SVM.ScopeExports {
+		if err := svmManager.SetExportClients(cfg.SVM.ExportClients); err != nil {
+			return nil, fmt.Errorf("invalid svm.export_clients: %w", err)
+		}
+	}
+	var svmEvents *svmevent.Recorder
+	if isControllerMode && cfg.SVM.Events {
+		svmEvents = svmevent.NewRecorder(o.k8sClient, driver.DriverName)
+		svmManager.SetObserver(svmEvents)
+	}
+	var nsFilter *policy.NamespaceFilter
+	if isControllerMode && (len(cfg.SVM.AllowedNamespaces) > 0 || len(cfg.SVM.DeniedNamespaces) > 0 || cfg.SVM.NamespaceSelector != "") {
+		nsFilter, err = policy.NewNamespaceFilter(cfg.SVM.AllowedNamespaces, cfg.SVM.DeniedNamespaces, cfg.SVM.NamespaceSelector, o.k8sClient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SVM namespace filter: %w", err)
+		}
+		svmManager.SetNamespaceFilter(nsFilter)
+	}
+
+	// Create per-namespace tenant backends (controller only)
+	backends := arca.NewBackendRouter(&arca.Backend{
+		Client:     arcaClient,
+		SVMManager: svmManager,
+		Allocator:  allocator,
+	})
+	if isControllerMode {
+		for i := range cfg.Tenants {
+			tenant := &cfg.Tenants[i]
+			backend, err := newTenantBackend(cfg, tenant, lockManager, o.k8sClient)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create backend for tenant %s: %w", tenant.Name, err)
+			}
+			if nsFilter != nil {
+				backend.SVMManager.SetNamespaceFilter(nsFilter)
+			}
+			if svmEvents != nil {
+				backend.SVMManager.SetObserver(svmEvents)
+			}
+			if err := backends.AddBackend(backend, tenant.Namespaces); err != nil {
+				return nil, err
+			}
+			klog.Infof("Routing namespaces %v to ARCA backend %s (%s)", tenant.Namespaces, tenant.Name, tenant.ARCA.BaseURL)
+		}
+	}
+
+	// Create metadata store (CRD-based with caching)
+	metadataStore := o.store
+	if metadataStore == nil {
+		if isControllerMode {
+			// Controller mode: use persistent CRD store
+			if kubeClient == nil {
+				return nil, fmt.Errorf("the CRD store requires a Kubernetes REST config")
+			}
+			crdStore, err := o.controllerRuntime.NewStore(kubeClient, o.restConfig, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create CRD store: %w", err)
+			}
+
+			// Wrap with cache for performance (60s TTL, 1000 volumes, 10000 snapshots)
+			cachedStore, err := store.NewCachedStore(crdStore, 60*time.Second, 1000, 10000)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create cached store: %w", err)
+			}
+
+			metadataStore = cachedStore
+			klog.Info("Using CRD-based persistent store with caching")
+		} else {
+			// Node mode: use in-memory store (not needed for node operations)
+			metadataStore = store.NewMemoryStore()
+			klog.Info("Using in-memory store (node mode)")
+		}
+	}
+
+	// Create read-only ArcaVolume reader (node only, optional)
+	var volumeReader *store.VolumeReader
+	if needsReader {
+		volumeReader, err = store.NewVolumeReader(o.restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ArcaVolume reader: %w", err)
+		}
+		klog.Info("Using ArcaVolume reader for volume context lookup/validation")
+
+		app.runners = append(app.runners, func(ctx context.Context) {
+			if err := volumeReader.Start(ctx); err != nil {
+				klog.Errorf("ArcaVolume reader stopped: %v", err)
+			}
+		})
+		app.waiters = append(app.waiters, func(ctx context.Context) {
+			if !volumeReader.WaitForSync(ctx) {
+				klog.Warning("ArcaVolume reader did not sync before shutdown")
+			}
+		})
+	}
+
+	// Create provisioning policy engine (controller only)
+	provisioningPolicy := o.policy
+	if provisioningPolicy == nil && isControllerMode && len(cfg.Policy.Rules) > 0 {
+		rules, err := cfg.ToPolicyRules()
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy configuration: %w", err)
+		}
+		engine, err := policy.NewEngine(rules, cfg.Policy.DryRun)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create policy engine: %w", err)
+		}
+		provisioningPolicy = engine
+	}
+
+	// Track ArcaCapacityReservations (controller only)
+	var reservations *reservation.Tracker
+
+	fscacheBudget, err := cfg.ToFSCacheBudget()
+	if err != nil {
+		return nil, err
+	}
+	if isControllerMode && cfg.SVM.CapacityReservations {
+		if o.restConfig == nil {
+			return nil, fmt.Errorf("svm.capacity_reservations requires a Kubernetes REST config")
+		}
+		reservations = reservation.NewTracker(kubeClient, metadataStore, backends)
+		interval := cfg.SVM.ReservationInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			reservations.Run(ctx, interval)
+		})
+		klog.Info("ArcaCapacityReservation tracking enabled")
+	}
+
+	// Record volume access events (node) and prune them (controller)
+	var volumeEvents *volumeevent.Recorder
+	if cfg.Driver.VolumeEvents {
+		if o.restConfig == nil {
+			return nil, fmt.Errorf("driver.volume_events requires a Kubernetes REST config")
+		}
+		if isControllerMode {
+			pruner := volumeevent.NewPruner(kubeClient, cfg.Driver.VolumeEventRetention.Duration, cfg.Driver.VolumeEventMaxPerVolume, cfg.Driver.VolumeEventMaxTotal)
+			interval := cfg.Driver.VolumeEventPruneInterval.Duration
+			app.runners = append(app.runners, func(ctx context.Context) {
+				pruner.Run(ctx, interval)
+			})
+		} else {
+			volumeEvents = volumeevent.NewRecorder(kubeClient, cfg.Driver.NodeID, cfg.Driver.VolumeEventQueueSize)
+			app.runners = append(app.runners, volumeEvents.Run)
+		}
+		klog.Info("Volume access events enabled")
+	}
+
+	// Record long-running operations for resumption (controller only)
+	var operations *opqueue.Queue
+	if isControllerMode && cfg.Driver.OperationQueue {
+		if o.restConfig == nil {
+			return nil, fmt.Errorf("driver.operation_queue requires a Kubernetes REST config")
+		}
+		operations = opqueue.NewQueue(kubeClient, lockIdentity)
+		interval := cfg.Driver.OperationQueueInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			operations.Run(ctx, interval)
+		})
+		klog.Info("Operation queue enabled")
+	}
+
+	// Create driver
+	driverCfg := &driver.DriverConfig{
+		Name:          driver.DriverName,
+		Version:       driver.DriverVersion,
+		Mode:          mode,
+		NodeID:        cfg.Driver.NodeID,
+		Endpoint:      cfg.Driver.Endpoint,
+		ArcaClient:    arcaClient,
+		SVMManager:    svmManager,
+		Allocator:     allocator,
+		Backends:      backends,
+		K8sClient:     o.k8sClient,
+		LockManager:   lockManager,
+		Store:         metadataStore,
+		Policy:        provisioningPolicy,
+		StateFilePath: cfg.Driver.StateFilePath,
+		BaseMountPath: cfg.Driver.BaseMountPath,
+
+		Reservations:       reservations,
+		CapacityCacheTTL:   cfg.SVM.CapacityCacheTTL.Duration,
+		SVMDNSTemplate:     cfg.SVM.DNSNameTemplate,
+		ExportPathTemplate: cfg.SVM.ExportPathTemplate,
+		SnapshotDir:        cfg.SVM.SnapshotDir,
+		TokenAudience:      cfg.Driver.TokenAudience,
+		VolumeEvents:       volumeEvents,
+		IDMode:             cfg.Driver.IDMode,
+		SnapshotIDSalt:     []byte(cfg.Driver.SnapshotIDSalt),
+		SnapshotIDCompat:   cfg.Driver.SnapshotIDCompat,
+		DNSCacheTTL:        cfg.Driver.DNSCacheTTL.Duration,
+		VolumeStatsQuota:   cfg.Driver.VolumeStatsQuota,
+		VolumeReader:       volumeReader,
+		VolumeLookup:       cfg.Driver.VolumeLookup,
+
+		VolumeStatsQuotaTTL: cfg.Driver.VolumeStatsQuotaTTL.Duration,
+
+		ValidateVolumeContext: cfg.Driver.ValidateVolumeContext,
+
+		VolumeContextKey:           []byte(cfg.Driver.VolumeContextKey),
+		RequireSignedVolumeContext: cfg.Driver.RequireSignedVolumeContext,
+
+		StagingDirMode: cfg.Driver.StagingDirMode.FileMode,
+		TargetDirMode:  cfg.Driver.TargetDirMode.FileMode,
+		SELinuxMount:   cfg.Driver.SELinuxMount,
+		Mounter:        o.mounter,
+		Filesystem:     o.filesystem,
+		MountAudit:     cfg.Driver.MountAudit,
+		MountBinary:    cfg.Driver.MountBinary,
+
+		MountHelperSocket: cfg.Driver.MountHelperSocket,
+
+		StateJournal:           cfg.Driver.StateJournal,
+		StateJournalCompaction: cfg.Driver.StateJournalCompaction,
+		StatePersistMode:       cfg.Driver.StatePersistMode,
+		StatePersistDelay:      cfg.Driver.StatePersistDelay.Duration,
+		StateFormat:            cfg.Driver.StateFormat,
+		ReconcileWorkers:       cfg.Driver.ReconcileWorkers,
+		ReconcileTimeout:       cfg.Driver.ReconcileTimeout.Duration,
+		MountBackoffInitial:    cfg.Driver.MountBackoffInitial.Duration,
+		MountBackoffMax:        cfg.Driver.MountBackoffMax.Duration,
+		UnmountLinger:          cfg.Driver.UnmountLinger.Duration,
+		MountProfileSysctls:    cfg.Driver.MountProfileSysctls,
+		FSCache:                cfg.Driver.FSCache,
+		FSCacheDir:             cfg.Driver.FSCacheDir,
+		FSCacheBudget:          fscacheBudget,
+
+		OperationTimeouts: driver.OperationTimeouts{
+			CreateVolume:   cfg.Driver.OperationTimeouts.CreateVolume.Duration,
+			DeleteVolume:   cfg.Driver.OperationTimeouts.DeleteVolume.Duration,
+			ExpandVolume:   cfg.Driver.OperationTimeouts.ExpandVolume.Duration,
+			CreateSnapshot: cfg.Driver.OperationTimeouts.CreateSnapshot.Duration,
+			DeleteSnapshot: cfg.Driver.OperationTimeouts.DeleteSnapshot.Duration,
+		},
+		CreateVolumeSLO:     cfg.Driver.CreateVolumeSLO.Duration,
+		QuotaVerifyTimeout:  cfg.Driver.QuotaVerifyTimeout.Duration,
+		Topology:            cfg.Driver.Topology,
+		TopologyPools:       cfg.TopologyPools(),
+		NamespaceRateLimits: namespaceRateLimits(&cfg.Driver.NamespaceRateLimit),
+		RetryPolicies:       cfg.ToRetryPolicies(),
+		SecureDelete:        cfg.Driver.SecureDelete,
+		WipeJobImage:        cfg.Driver.WipeJobImage,
+		WipeJobNamespace:    leaseNamespace,
+		DeferredDelete:      cfg.Driver.DeferredDelete,
+		Operations:          operations,
+	}
+
+	d, err := driver.NewDriver(driverCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create driver: %w", err)
+	}
+	app.Driver = d
+
+	if o.metricsAddress != "" {
+		addr, ready := o.metricsAddress, readiness(d, arcaClient, isControllerMode)
+		app.runners = append(app.runners, func(ctx context.Context) {
+			if err := metrics.Serve(ctx, addr, ready); err != nil {
+				klog.Errorf("Metrics server failed: %v", err)
+			}
+		})
+	}
+
+	// Keep the CSIDriver object in line with the enabled features
+	if isControllerMode && cfg.Driver.ManageCSIDriver {
+		if o.k8sClient == nil {
+			return nil, fmt.Errorf("driver.manage_csidriver requires a Kubernetes client")
+		}
+		spec := csidriver.Spec(csidriver.Options{
+			StorageCapacity: cfg.SVM.CapacityReservations,
+			SELinuxMount:    cfg.Driver.SELinuxMount,
+			TokenRequests:   tokenRequests(cfg),
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), csiDriverTimeout)
+		err := csidriver.Ensure(ctx, o.k8sClient, driver.DriverName, spec)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Probe mounted SVMs with a canary file
+	if !isControllerMode && cfg.Driver.Canary {
+		interval, timeout := cfg.Driver.CanaryInterval.Duration, cfg.Driver.CanaryTimeout.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			d.RunCanary(ctx, interval, timeout)
+		})
+		klog.Info("SVM canary probes enabled")
+	}
+
+	// Export the statistics of the local data cache
+	if !isControllerMode && cfg.Driver.FSCache {
+		interval := cfg.Driver.FSCacheStatsInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			d.RunFSCacheMonitor(ctx, interval)
+		})
+		klog.Info("Local data cache (fsc) enabled")
+	}
+
+	// Retry acknowledged volume deletions in the background
+	if isControllerMode && cfg.Driver.DeferredDelete {
+		interval := cfg.Driver.DeleteQueueInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			d.RunDeleteQueue(ctx, interval)
+		})
+		klog.Info("Deferred volume deletion enabled")
+	}
+
+	// Pause provisioning while the maintenance ConfigMap says so
+	if isControllerMode && cfg.Driver.MaintenanceConfigMap != "" && o.k8sClient != nil {
+		name, interval := cfg.Driver.MaintenanceConfigMap, cfg.Driver.MaintenanceInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			d.WatchMaintenance(ctx, leaseNamespace, name, interval)
+		})
+		klog.Infof("Watching maintenance ConfigMap %s/%s", leaseNamespace, name)
+	}
+
+	// Move SVMs between backends as requested by ArcaMigrations
+	if isControllerMode && cfg.SVM.Migrations {
+		if o.restConfig == nil {
+			return nil, fmt.Errorf("svm.migrations requires a Kubernetes REST config")
+		}
+		migrations := migration.NewController(kubeClient, metadataStore, backends)
+		migrations.SetExportPathTemplate(cfg.SVM.ExportPathTemplate)
+		interval := cfg.SVM.MigrationInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			migrations.Run(ctx, interval)
+		})
+		klog.Info("ArcaMigration controller enabled")
+	}
+
+	// Collect per-volume efficiency statistics from ARCA
+	if isControllerMode && cfg.Driver.EfficiencyStats {
+		if o.restConfig == nil {
+			return nil, fmt.Errorf("driver.efficiency_stats requires a Kubernetes REST config")
+		}
+		collector := efficiency.NewCollector(kubeClient, backends)
+		interval := cfg.Driver.EfficiencyInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			collector.Run(ctx, interval)
+		})
+		klog.Info("Volume efficiency statistics enabled")
+	}
+
+	// Report backend directories and snapshots no ArcaVolume or ArcaSnapshot
+	// records
+	if isControllerMode && cfg.Driver.OrphanScan {
+		if o.restConfig == nil {
+			return nil, fmt.Errorf("driver.orphan_scan requires a Kubernetes REST config")
+		}
+		scanner := orphan.NewScanner(kubeClient, backends, cfg.Driver.OrphanGracePeriod.Duration)
+		scanner.SetSnapshotPolicy(cfg.Driver.OrphanSnapshotPolicy)
+		scanner.SetSnapshotDir(cfg.SVM.SnapshotDir)
+		interval := cfg.Driver.OrphanScanInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			scanner.Run(ctx, interval)
+		})
+		klog.Infof("Orphan scan enabled (snapshot policy: %s)", cfg.Driver.OrphanSnapshotPolicy)
+	}
+
+	// Show the backend placement of volumes on their PersistentVolumes
+	if isControllerMode && cfg.Driver.AnnotatePVs {
+		if o.restConfig == nil {
+			return nil, fmt.Errorf("driver.annotate_pvs requires a Kubernetes REST config")
+		}
+		annotator := pvannotation.NewAnnotator(kubeClient, driver.DriverName)
+		interval := cfg.Driver.AnnotatePVInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			annotator.Run(ctx, interval)
+		})
+		klog.Info("PersistentVolume annotations enabled")
+	}
+
+	// Audit SVM export rules into ArcaSVM resources
+	if isControllerMode && cfg.SVM.ExportAudit {
+		if o.restConfig == nil {
+			return nil, fmt.Errorf("svm.export_audit requires a Kubernetes REST config")
+		}
+		auditor, err := exportaudit.NewAuditor(kubeClient, backends, cfg.SVM.ExportClients)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create export auditor: %w", err)
+		}
+		auditor.SetAttributeReconcile(cfg.SVM.AttributeReconcile)
+		interval := cfg.SVM.ExportAuditInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			auditor.Run(ctx, interval)
+		})
+		klog.Info("SVM export audit enabled")
+	}
+
+	// Clean up the storage of deleted namespaces
+	if isControllerMode && cfg.SVM.NamespaceCleanup != "" {
+		if o.restConfig == nil || o.k8sClient == nil {
+			return nil, fmt.Errorf("svm.namespace_cleanup requires a Kubernetes REST config")
+		}
+		cleaner := nscleanup.NewCleaner(kubeClient, mgr.GetCache(), o.k8sClient, backends, driver.DriverName, cfg.SVM.NamespaceCleanup)
+		interval := cfg.SVM.NamespaceCleanupInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			cleaner.Run(ctx, interval)
+		})
+		klog.Infof("Namespace cleanup enabled (mode: %s)", cfg.SVM.NamespaceCleanup)
+	}
+
+	// Summarize the driver's health in the ArcaDriverStatus
+	if isControllerMode && cfg.Driver.StatusReport {
+		if o.restConfig == nil {
+			return nil, fmt.Errorf("driver.status_report requires a Kubernetes REST config")
+		}
+		reporter := driverstatus.NewReporter(kubeClient, backends, driver.DriverVersion, leaseIdentity, d.PendingOperations)
+		if cfg.Driver.VersionSkewCheck && o.k8sClient != nil {
+			reporter.SetNodeVersions(o.k8sClient, leaseNamespace)
+		}
+		interval := cfg.Driver.StatusReportInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			reporter.Run(ctx, interval)
+		})
+		klog.Info("Driver status reporting enabled")
+	}
+
+	// Publish this component's version and, in the controller, warn about
+	// incompatible node plugins
+	if cfg.Driver.VersionSkewCheck && o.k8sClient != nil {
+		component, features := versionskew.ComponentNode, driver.NodeFeatures()
+		if isControllerMode {
+			component, features = versionskew.ComponentController, driver.RequiredNodeFeatures(cfg.SVM.DNSNameTemplate, cfg.Driver.VolumeContextKey != "")
+		}
+		publisher := versionskew.NewPublisher(o.k8sClient, leaseNamespace, component, leaseIdentity, driver.DriverVersion, features)
+		interval := cfg.Driver.VersionSkewInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			publisher.Run(ctx, interval)
+		})
+		if isControllerMode {
+			checker := versionskew.NewChecker(o.k8sClient, leaseNamespace, driver.DriverName, driver.RequiredNodeFeatures(cfg.SVM.DNSNameTemplate, cfg.Driver.VolumeContextKey != ""))
+			app.runners = append(app.runners, func(ctx context.Context) {
+				checker.Run(ctx, interval)
+			})
+		}
+		klog.Info("Version skew detection enabled")
+	}
+
+	// Delete SVM locks a crashed controller left behind
+	if isControllerMode && lockBackend != nil {
+		collector := lock.NewCollector(lockBackend)
+		interval := cfg.Driver.LockGCInterval.Duration
+		app.runners = append(app.runners, func(ctx context.Context) {
+			collector.Run(ctx, interval)
+		})
+	}
+
+	// Probe ARCA API endpoints so failed ones rejoin rotation promptly, and
+	// cancel calls stuck past the ceiling
+	if isControllerMode {
+		app.runners = append(app.runners, func(ctx context.Context) {
+			arcaClient.RunHealthCheck(ctx, cfg.ARCA.HealthCheckInterval.Duration)
+		}, arcaClient.RunWatchdog)
+		for i := range cfg.Tenants {
+			tenant := &cfg.Tenants[i]
+			backend, err := backends.Get(tenant.Name)
+			if err != nil {
+				return nil, err
+			}
+			client, interval := backend.Client, tenant.ARCA.HealthCheckInterval.Duration
+			app.runners = append(app.runners, func(ctx context.Context) {
+				client.RunHealthCheck(ctx, interval)
+			}, client.RunWatchdog)
+		}
+	}
+
+	// Reload runtime-tunable settings (IP pools) when the config file changes
+	if isControllerMode && o.configPath != "" {
+		path := o.configPath
+		app.runners = append(app.runners, func(ctx context.Context) {
+			config.Watch(ctx, path, configReloadInterval, func(newCfg *config.Config) {
+				if err := allocator.UpdatePools(newCfg.ToArcaPoolConfigs()); err != nil {
+					klog.Errorf("Failed to apply reloaded IP pools: %v", err)
+				}
+				for i := range newCfg.Tenants {
+					tenant := &newCfg.Tenants[i]
+					backend, err := backends.Get(tenant.Name)
+					if err != nil {
+						klog.Warningf("Ignoring reloaded tenant %s: tenants are only added at startup", tenant.Name)
+						continue
+					}
+					if err := backend.Allocator.UpdatePools(newCfg.TenantNetwork(tenant).ToArcaPoolConfigs()); err != nil {
+						klog.Errorf("Failed to apply reloaded IP pools for tenant %s: %v", tenant.Name, err)
+					}
+				}
+			})
+		})
+	}
+
+	return app, nil
+}
+
+// poolNames returns the names of the allocator's pools, including the
+// default names of unnamed pools
+func poolNames(allocator *arca.StandaloneAllocator) []string {
+	pools := allocator.Pools()
+	names := make([]string, len(pools))
+	for i := range pools {
+		names[i] = pools[i].Name
+	}
+	return names
+}
+
+// newTenantBackend creates the ARCA client, allocator and SVM manager for a tenant
+func newTenantBackend(cfg *config.Config, tenant *config.TenantConfig, lockManager *lock.Manager, k8sClient kubernetes.Interface) (*arca.Backend, error) {
+	client, err := arca.NewClient(tenant.ToArcaClientConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ARCA client: %w", err)
+	}
+
+	network := cfg.TenantNetwork(tenant)
+	allocator, err := arca.NewStandaloneAllocator(network.ToArcaPoolConfigs(), client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create network allocator: %w", err)
+	}
+	if err := allocator.SetStrategy(arca.AllocationStrategy(network.AllocationStrategy)); err != nil {
+		return nil, fmt.Errorf("failed to configure network allocator: %w", err)
+	}
+	allocator.SetConflictHold(network.ConflictHold.Duration)
+	if len(network.Affinity) > 0 {
+		affinity, err := policy.NewPoolAffinity(network.ToPoolRules(), poolNames(allocator), k8sClient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid affinity: %w", err)
+		}
+		allocator.SetPoolAffinity(affinity)
+	}
+
+	svmManager := arca.NewSVMManager(client, allocator, lockManager, network.MTU)
+	svmManager.SetCacheTTL(cfg.SVM.CacheTTL.Duration)
+	if err := svmManager.SetHealthPolicy(arca.UnhealthyAction(cfg.SVM.UnhealthyAction), cfg.SVM.ReadyTimeout.Duration); err != nil {
+		return nil, fmt.Errorf("invalid svm.unhealthy_action: %w", err)
+	}
+	if cfg.SVM.ScopeExports {
+		if err := svmManager.SetExportClients(cfg.SVM.ExportClients); err != nil {
+			return nil, fmt.Errorf("invalid svm.export_clients: %w", err)
+		}
+	}
+
+	return &arca.Backend{
+		Name:       tenant.Name,
+		Client:     client,
+		SVMManager: svmManager,
+		Allocator:  allocator,
+	}, nil
+}
+
+// namespaceRateLimits converts the namespace_rate_limit section
+func namespaceRateLimits(c *config.NamespaceRateLimitConfig) driver.NamespaceRateLimits {
+	limits := driver.NamespaceRateLimits{
+		Default:    driver.RateLimit{QPS: c.QPS, Burst: c.Burst},
+		Namespaces: make(map[string]driver.RateLimit, len(c.Namespaces)),
+	}
+	for namespace, limit := range c.Namespaces {
+		limits.Namespaces[namespace] = driver.RateLimit{QPS: limit.QPS, Burst: limit.Burst}
+	}
+	return limits
+}
+
+// createKubernetesClient creates a Kubernetes clientset whose requests, and
+// those of every client built from the returned config, are rate limited to
+// qps and burst (client-go defaults when zero)
+func createKubernetesClient(kubeconfigPath string, qps float32, burst int) (*rest.Config, *kubernetes.Clientset, error) {
+	var config *rest.Config
+	var err error
+
+	if kubeconfigPath != "" {
+		// Use kubeconfig file
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to build config from kubeconfig: %w", err)
+		}
+		klog.V(2).Infof("Using kubeconfig: %s", kubeconfigPath)
+	} else {
+		// Use in-cluster config
+		config, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+		}
+		klog.V(2).Info("Using in-cluster Kubernetes configuration")
+	}
+	if qps > 0 {
+		config.QPS = qps
+	}
+	if burst > 0 {
+		config.Burst = burst
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	return config, clientset, nil
+}
+
+// tokenRequests returns the CSIDriver token requests for the configured
+// mount token audience
+func tokenRequests(cfg *config.Config) []storagev1.TokenRequest {
+	if cfg.Driver.TokenAudience == "" {
+		return nil
+	}
+	request := storagev1.TokenRequest{Audience: cfg.Driver.TokenAudience}
+	if d := cfg.Driver.TokenExpiration.Duration; d > 0 {
+		seconds := int64(d.Seconds())
+		request.ExpirationSeconds = &seconds
+	}
+	return []storagev1.TokenRequest{request}
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+	"github.com/akam1o/csi-arca-storage/pkg/lock"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// checkTimeout bounds each startup check
+const checkTimeout = 30 * time.Second
+
+// readinessTimeout bounds the ARCA request made by a readiness probe
+const readinessTimeout = 5 * time.Second
+
+// CheckResult is the outcome of one startup check
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// Check validates that the plugin built from cfg could start, without
+// starting it: every ARCA endpoint is reachable and accepts the credentials,
+// and the Kubernetes permissions and CRDs the plugin needs are in place. All
+// checks run even when one fails, so a single pass reports every problem.
+// mode is "controller" or "node".
+func Check(ctx context.Context, mode string, cfg *config.Config, opts ...Option) []CheckResult {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	isControllerMode := mode == "controller"
+
+	var results []CheckResult
+	add := func(name string, err error) {
+		results = append(results, CheckResult{Name: name, Err: err})
+	}
+
+	// ARCA connectivity and credentials of every backend, named by config key
+	arcaConfigs := map[string]*arca.ClientConfig{"arca": cfg.ToArcaClientConfig()}
+	keys := []string{"arca"}
+	if isControllerMode {
+		for i := range cfg.Tenants {
+			key := fmt.Sprintf("tenants[%s].arca", cfg.Tenants[i].Name)
+			arcaConfigs[key] = cfg.Tenants[i].ToArcaClientConfig()
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		client, err := arca.NewClient(arcaConfigs[key])
+		if err != nil {
+			add(key+" client", err)
+			continue
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		for _, ep := range client.CheckEndpoints(checkCtx) {
+			var err error
+			if !ep.Healthy {
+				err = errors.New(ep.LastError)
+			}
+			add(fmt.Sprintf("%s endpoint %s", key, ep.BaseURL), err)
+		}
+		add(key+" auth", client.CheckAuth(checkCtx))
+		cancel()
+	}
+
+	// Kubernetes access
+	needsReader := !isControllerMode && (cfg.Driver.VolumeLookup || cfg.Driver.ValidateVolumeContext)
+	if !isControllerMode && !needsReader && !cfg.Driver.VersionSkewCheck {
+		return results
+	}
+	if o.k8sClient == nil {
+		restConfig, clientset, err := createKubernetesClient(o.kubeconfig, o.kubeQPS, o.kubeBurst)
+		add("kubernetes client", err)
+		if err != nil {
+			return results
+		}
+		o.restConfig = restConfig
+		o.k8sClient = clientset
+	}
+
+	if !cfg.Driver.SkipPermissionCheck {
+		pod, err := podIdentityFromEnv()
+		if err != nil {
+			add("pod identity", err)
+		} else {
+			add("kubernetes permissions", checkPermissions(ctx, o.k8sClient, requiredPermissions(isControllerMode, needsReader, pod.leaseNamespace(), cfg)))
+		}
+	}
+
+	if isControllerMode && o.restConfig != nil {
+		if o.controllerRuntime == nil {
+			add("crds", errNoControllerRuntime)
+			return results
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		add("crds", o.controllerRuntime.VerifyCRDs(checkCtx, o.restConfig, requiredCRDs(cfg)...))
+		cancel()
+	}
+	return results
+}
+
+// requiredCRDs returns the CRDs the controller plugin built from cfg uses
+func requiredCRDs(cfg *config.Config) []string {
+	crds := append([]string(nil), store.RequiredCRDs...)
+	if cfg.SVM.Migrations {
+		crds = append(crds, "arcamigrations.storage.arca.io")
+	}
+	if cfg.SVM.CapacityReservations {
+		crds = append(crds, "arcacapacityreservations.storage.arca.io")
+	}
+	if cfg.SVM.ExportAudit {
+		crds = append(crds, "arcasvms.storage.arca.io")
+	}
+	if cfg.Driver.LockBackend == lock.BackendCRD {
+		crds = append(crds, "arcalocks.storage.arca.io")
+	}
+	if cfg.Driver.VolumeEvents {
+		crds = append(crds, "arcavolumeevents.storage.arca.io")
+	}
+	if cfg.Driver.StatusReport {
+		crds = append(crds, "arcadriverstatuses.storage.arca.io")
+	}
+	if cfg.Driver.OperationQueue {
+		crds = append(crds, "arcaoperations.storage.arca.io")
+	}
+	return crds
+}
+
+// readiness returns the readiness check served on the metrics address: the
+// driver must be ready (see driver.Driver.Readiness) and, in controller
+// mode, the ARCA API must accept requests
+func readiness(d interface{ Readiness() error }, client *arca.Client, isControllerMode bool) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := d.Readiness(); err != nil {
+			return err
+		}
+		if !isControllerMode {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+		defer cancel()
+		if err := client.CheckAuth(ctx); err != nil {
+			return fmt.Errorf("ARCA API check failed: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+// Package controller provides the controller-runtime manager and the CRD
+// store of the controller plugin. It is kept out of package app so that
+// binaries running only the node plugin do not link them.
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/akam1o/csi-arca-storage/pkg/app"
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+	"github.com/akam1o/csi-arca-storage/pkg/store/crd"
+)
+
+// Runtime is the app.ControllerRuntime of the driver binaries
+type Runtime struct{}
+
+var _ app.ControllerRuntime = Runtime{}
+
+// NewManager creates the controller-runtime manager shared by the
+// controller's store, background controllers and watches. Its client reads
+// from the API server rather than the informer cache, so the store and the
+// controllers see their own writes and work before the manager is started;
+// the cache only backs watches. Metrics are served by the driver's metrics
+// endpoint, and each controller replica does its own work, so the manager
+// neither serves metrics nor elects a leader.
+func (Runtime) NewManager(config *rest.Config) (app.Manager, error) {
+	ctrllog.SetLogger(klog.NewKlogr())
+
+	scheme, err := app.NewScheme()
+	if err != nil {
+		return nil, err
+	}
+
+	mgr, err := manager.New(config, manager.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: "0"},
+		HealthProbeBindAddress: "0",
+		NewClient: func(config *rest.Config, options client.Options) (client.Client, error) {
+			options.Cache = nil
+			return client.New(config, options)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create controller manager: %w", err)
+	}
+	return mgr, nil
+}
+
+// NewStore creates the CRD store
+func (Runtime) NewStore(c client.Client, config *rest.Config, cfg *config.Config) (store.Store, error) {
+	return crd.NewStore(c, config, crd.Check{
+		Wait: cfg.Driver.CRDWait.Duration,
+		Lazy: cfg.Driver.LazyCRDCheck,
+	})
+}
+
+// VerifyCRDs checks that the named CRDs are installed
+func (Runtime) VerifyCRDs(ctx context.Context, config *rest.Config, names ...string) error {
+	return crd.VerifyCRDs(ctx, config, names...)
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Downward API environment variables set in the plugin manifests
+const (
+	envPodNamespace = "POD_NAMESPACE"
+	envPodName      = "POD_NAME"
+	envPodUID       = "POD_UID"
+)
+
+// defaultLeaseNamespace holds the driver's Leases and maintenance ConfigMap
+// when the plugin does not know its own namespace
+const defaultLeaseNamespace = "kube-system"
+
+// podIdentity is the plugin's pod as reported by the downward API
+type podIdentity struct {
+	namespace string
+	name      string
+	uid       string
+}
+
+// podIdentityFromEnv reads and validates the downward API variables; unset
+// variables stay empty
+func podIdentityFromEnv() (podIdentity, error) {
+	id := podIdentity{
+		namespace: os.Getenv(envPodNamespace),
+		name:      os.Getenv(envPodName),
+		uid:       os.Getenv(envPodUID),
+	}
+	if id.namespace != "" {
+		if errs := validation.IsDNS1123Label(id.namespace); len(errs) > 0 {
+			return podIdentity{}, fmt.Errorf("invalid %s %q: %s", envPodNamespace, id.namespace, errs[0])
+		}
+	}
+	if id.name != "" {
+		if errs := validation.IsDNS1123Subdomain(id.name); len(errs) > 0 {
+			return podIdentity{}, fmt.Errorf("invalid %s %q: %s", envPodName, id.name, errs[0])
+		}
+	}
+	if id.uid != "" {
+		if _, err := uuid.Parse(id.uid); err != nil {
+			return podIdentity{}, fmt.Errorf("invalid %s %q: %w", envPodUID, id.uid, err)
+		}
+	}
+	return id, nil
+}
+
+// leaseNamespace returns the namespace of the driver's Leases and
+// maintenance ConfigMap: the pod's own, or kube-system without POD_NAMESPACE
+func (p podIdentity) leaseNamespace() string {
+	if p.namespace == "" {
+		return defaultLeaseNamespace
+	}
+	return p.namespace
+}
+
+// holderIdentity returns the identity the controller holds Leases with. A
+// StatefulSet pod keeps its name across restarts, so the UID keeps a new pod
+// from taking over the Leases of the one it replaced, or of a second pod
+// with the same name.
+func (p podIdentity) holderIdentity() (string, error) {
+	if p.name == "" || p.uid == "" {
+		return "", fmt.Errorf("%s and %s must be set from the downward API in controller mode", envPodName, envPodUID)
+	}
+	return p.name + "_" + p.uid, nil
+}
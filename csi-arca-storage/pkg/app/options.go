@@ -0,0 +1,118 @@
+package app
+
+import (
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	mountutils "k8s.io/mount-utils"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+	"github.com/akam1o/csi-arca-storage/pkg/policy"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// options holds optional dependencies for building the driver
+type options struct {
+	kubeconfig     string
+	kubeQPS        float32
+	kubeBurst      int
+	restConfig     *rest.Config
+	k8sClient      kubernetes.Interface
+	store          store.Store
+	arcaClient     *arca.Client
+	policy         policy.Policy
+	mounter        mountutils.Interface
+	filesystem     mount.Filesystem
+	configPath     string
+	metricsAddress string
+
+	controllerRuntime ControllerRuntime
+}
+
+// Option customizes how the driver is built
+type Option func(*options)
+
+// WithKubeconfig uses the given kubeconfig file instead of in-cluster config
+func WithKubeconfig(path string) Option {
+	return func(o *options) {
+		o.kubeconfig = path
+	}
+}
+
+// WithKubeRateLimit limits the requests of the Kubernetes clients the driver
+// creates to qps per second with the given burst. Zero values keep the
+// client-go defaults.
+func WithKubeRateLimit(qps float32, burst int) Option {
+	return func(o *options) {
+		o.kubeQPS = qps
+		o.kubeBurst = burst
+	}
+}
+
+// WithKubernetes uses an existing Kubernetes client (e.g. a fake clientset).
+// restConfig is only needed for CRD-backed components and may be nil when a
+// custom store is supplied.
+func WithKubernetes(restConfig *rest.Config, client kubernetes.Interface) Option {
+	return func(o *options) {
+		o.restConfig = restConfig
+		o.k8sClient = client
+	}
+}
+
+// WithStore uses a custom metadata store instead of the CRD store
+func WithStore(s store.Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
+}
+
+// WithArcaClient uses an existing ARCA API client
+func WithArcaClient(c *arca.Client) Option {
+	return func(o *options) {
+		o.arcaClient = c
+	}
+}
+
+// WithPolicy uses a custom provisioning policy instead of configured rules
+func WithPolicy(p policy.Policy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// WithMounter uses a custom mounter for node operations
+func WithMounter(m mountutils.Interface) Option {
+	return func(o *options) {
+		o.mounter = m
+	}
+}
+
+// WithFilesystem uses a custom filesystem for node operations
+func WithFilesystem(fs mount.Filesystem) Option {
+	return func(o *options) {
+		o.filesystem = fs
+	}
+}
+
+// WithConfigReload watches the configuration file and applies runtime-tunable
+// settings (IP pools) when it changes
+func WithConfigReload(path string) Option {
+	return func(o *options) {
+		o.configPath = path
+	}
+}
+
+// WithMetricsAddress serves Prometheus metrics on the given address
+func WithMetricsAddress(addr string) Option {
+	return func(o *options) {
+		o.metricsAddress = addr
+	}
+}
+
+// WithControllerRuntime supplies the manager and CRD store of the controller
+// plugin, which it needs unless WithStore is used without a REST config
+func WithControllerRuntime(rt ControllerRuntime) Option {
+	return func(o *options) {
+		o.controllerRuntime = rt
+	}
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/lock"
+)
+
+// permissionCheckTimeout bounds the startup permission self-check
+const permissionCheckTimeout = 30 * time.Second
+
+// permission is a Kubernetes API access the driver needs
+type permission struct {
+	group       string
+	resource    string
+	subresource string
+	namespace   string // empty for cluster-scoped resources or any namespace
+	name        string // empty for any object
+	verbs       []string
+}
+
+// String formats the resource as "resource[/subresource].group [in namespace]"
+func (p permission) String() string {
+	name := p.resource
+	if p.subresource != "" {
+		name += "/" + p.subresource
+	}
+	if p.group != "" {
+		name += "." + p.group
+	}
+	if p.name != "" {
+		name += " " + p.name
+	}
+	if p.namespace != "" {
+		name += " in namespace " + p.namespace
+	}
+	return name
+}
+
+// controllerPermissions returns the permissions the controller plugin built
+// from cfg uses; SVM locks are Leases unless the lock backend is
+// lock.BackendCRD
+func controllerPermissions(leaseNamespace string, cfg *config.Config) []permission {
+	locks := permission{group: "coordination.k8s.io", resource: "leases", namespace: leaseNamespace, verbs: []string{"get", "list", "create", "update", "delete"}}
+	if cfg.Driver.LockBackend == lock.BackendCRD {
+		locks = permission{group: "storage.arca.io", resource: "arcalocks", verbs: []string{"get", "list", "create", "update", "delete"}}
+	}
+	perms := []permission{
+		locks,
+		{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verbs: []string{"get"}},
+		{group: "storage.arca.io", resource: "arcavolumes", verbs: []string{"get", "list", "watch", "create", "update", "delete"}},
+		{group: "storage.arca.io", resource: "arcasnapshots", verbs: []string{"get", "list", "watch", "create", "update", "delete"}},
+		{group: "storage.arca.io", resource: "arcasnapshots", subresource: "status", verbs: []string{"update"}},
+	}
+	if usesNamespaceSelector(cfg) {
+		perms = append(perms, permission{resource: "namespaces", verbs: []string{"get"}})
+	}
+	if cfg.Driver.MaintenanceConfigMap != "" {
+		perms = append(perms, permission{resource: "configmaps", namespace: leaseNamespace, name: cfg.Driver.MaintenanceConfigMap, verbs: []string{"get"}})
+	}
+	if cfg.SVM.Migrations {
+		perms = append(perms,
+			permission{group: "storage.arca.io", resource: "arcamigrations", verbs: []string{"list", "update"}},
+			permission{group: "storage.arca.io", resource: "arcamigrations", subresource: "status", verbs: []string{"update"}},
+		)
+	}
+	if cfg.Driver.EfficiencyStats {
+		perms = append(perms, permission{group: "storage.arca.io", resource: "arcavolumes", subresource: "status", verbs: []string{"update"}})
+	}
+	if cfg.SVM.CapacityReservations {
+		perms = append(perms,
+			permission{group: "storage.arca.io", resource: "arcacapacityreservations", verbs: []string{"list"}},
+			permission{group: "storage.arca.io", resource: "arcacapacityreservations", subresource: "status", verbs: []string{"update"}},
+		)
+	}
+	if cfg.Driver.VersionSkewCheck {
+		perms = append(perms, permission{resource: "events", verbs: []string{"create", "patch"}})
+		if cfg.Driver.LockBackend == lock.BackendCRD {
+			perms = append(perms, permission{group: "coordination.k8s.io", resource: "leases", namespace: leaseNamespace, verbs: []string{"get", "list", "create", "update"}})
+		}
+	}
+	if cfg.Driver.AnnotatePVs {
+		perms = append(perms, permission{resource: "persistentvolumes", verbs: []string{"list", "patch"}})
+	}
+	if cfg.Driver.VolumeEvents {
+		perms = append(perms, permission{group: "storage.arca.io", resource: "arcavolumeevents", verbs: []string{"list", "delete"}})
+	}
+	if cfg.Driver.DeferredDelete {
+		perms = append(perms, permission{resource: "persistentvolumes", verbs: []string{"get"}})
+	}
+	if cfg.Driver.StatusReport {
+		perms = append(perms,
+			permission{group: "storage.arca.io", resource: "arcadriverstatuses", verbs: []string{"get", "create"}},
+			permission{group: "storage.arca.io", resource: "arcadriverstatuses", subresource: "status", verbs: []string{"update"}},
+		)
+	}
+	if cfg.Driver.WipeJobImage != "" {
+		perms = append(perms, permission{group: "batch", resource: "jobs", namespace: leaseNamespace, verbs: []string{"get", "create", "delete"}})
+	}
+	if cfg.SVM.ExportAudit {
+		perms = append(perms,
+			permission{group: "storage.arca.io", resource: "arcasvms", verbs: []string{"list", "create", "update", "delete"}},
+			permission{group: "storage.arca.io", resource: "arcasvms", subresource: "status", verbs: []string{"update"}},
+		)
+	}
+	return perms
+}
+
+// usesNamespaceSelector reports whether the controller reads Namespaces to
+// evaluate a label selector
+func usesNamespaceSelector(cfg *config.Config) bool {
+	if cfg.SVM.NamespaceSelector != "" {
+		return true
+	}
+	networks := []*config.NetworkConfig{&cfg.Network}
+	for i := range cfg.Tenants {
+		networks = append(networks, cfg.TenantNetwork(&cfg.Tenants[i]))
+	}
+	for _, n := range networks {
+		for _, a := range n.Affinity {
+			if a.NamespaceSelector != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requiredPermissions returns the permissions of the controller or node
+// plugin built from cfg, whose Leases live in leaseNamespace
+func requiredPermissions(isControllerMode, volumeReader bool, leaseNamespace string, cfg *config.Config) []permission {
+	if !isControllerMode {
+		return nodePermissions(leaseNamespace, volumeReader, cfg.Driver.VersionSkewCheck, cfg.Driver.VolumeEvents)
+	}
+	perms := controllerPermissions(leaseNamespace, cfg)
+	if cfg.Driver.ManageCSIDriver {
+		perms = append(perms,
+			permission{group: "storage.k8s.io", resource: "csidrivers", name: driver.DriverName, verbs: []string{"get", "update", "delete"}},
+			permission{group: "storage.k8s.io", resource: "csidrivers", verbs: []string{"create"}},
+		)
+	}
+	if cfg.Driver.OperationQueue {
+		perms = append(perms, permission{group: "storage.arca.io", resource: "arcaoperations", verbs: []string{"get", "list", "create", "update", "delete"}})
+	}
+	if cfg.SVM.Events {
+		perms = append(perms, permission{resource: "events", verbs: []string{"create", "patch"}})
+	}
+	if cfg.SVM.NamespaceCleanup != "" {
+		perms = append(perms,
+			permission{resource: "namespaces", verbs: []string{"get", "list", "watch"}},
+			permission{resource: "persistentvolumes", verbs: []string{"list"}},
+			permission{resource: "events", verbs: []string{"create", "patch"}},
+		)
+	}
+	return perms
+}
+
+// nodePermissions returns the permissions the node plugin uses
+func nodePermissions(leaseNamespace string, volumeReader, versionSkew, volumeEvents bool) []permission {
+	var perms []permission
+	if volumeReader {
+		perms = append(perms, permission{group: "storage.arca.io", resource: "arcavolumes", verbs: []string{"get", "list", "watch"}})
+	}
+	if versionSkew {
+		perms = append(perms, permission{group: "coordination.k8s.io", resource: "leases", namespace: leaseNamespace, verbs: []string{"get", "create", "update"}})
+	}
+	if volumeEvents {
+		perms = append(perms, permission{group: "storage.arca.io", resource: "arcavolumeevents", verbs: []string{"create"}})
+	}
+	return perms
+}
+
+// checkPermissions verifies each permission with a SelfSubjectAccessReview
+// and returns an error listing every missing verb
+func checkPermissions(ctx context.Context, client kubernetes.Interface, perms []permission) error {
+	ctx, cancel := context.WithTimeout(ctx, permissionCheckTimeout)
+	defer cancel()
+
+	var missing []string
+	for _, p := range perms {
+		var denied []string
+		for _, verb := range p.verbs {
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace:   p.namespace,
+						Verb:        verb,
+						Group:       p.group,
+						Resource:    p.resource,
+						Subresource: p.subresource,
+						Name:        p.name,
+					},
+				},
+			}
+			result, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to review access to %s: %w", p, err)
+			}
+			if !result.Status.Allowed {
+				denied = append(denied, verb)
+			}
+		}
+		if len(denied) > 0 {
+			missing = append(missing, fmt.Sprintf("%s (%s)", p, strings.Join(denied, ", ")))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("service account is missing permissions: %s", strings.Join(missing, "; "))
+	}
+
+	klog.Infof("Verified %d Kubernetes API permissions", len(perms))
+	return nil
+}
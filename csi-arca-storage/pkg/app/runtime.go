@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/config"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// ControllerRuntime creates the Kubernetes machinery only the controller
+// plugin uses: the controller-runtime manager and the CRD store. Package
+// app/controller implements it, so binaries that only run the node plugin
+// link neither the manager nor the apiextensions client.
+type ControllerRuntime interface {
+	// NewManager creates the manager shared by the CRD store and the
+	// background controllers
+	NewManager(config *rest.Config) (Manager, error)
+	// NewStore creates the persistent metadata store on the manager's
+	// client, verifying the CRDs as configured by cfg.Driver
+	NewStore(c client.Client, config *rest.Config, cfg *config.Config) (store.Store, error)
+	// VerifyCRDs checks that the named CRDs are installed
+	VerifyCRDs(ctx context.Context, config *rest.Config, names ...string) error
+}
+
+// Manager is the controller-runtime manager of the controller plugin
+type Manager interface {
+	GetClient() client.Client
+	GetCache() cache.Cache
+	Start(ctx context.Context) error
+}
+
+// errNoControllerRuntime is returned when a controller plugin is built
+// without a ControllerRuntime
+var errNoControllerRuntime = errors.New("the controller plugin requires a controller runtime (see package app/controller)")
+
+// NewScheme returns the scheme of the driver's Kubernetes clients: the
+// built-in types and the ARCA CRDs
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add client-go types to scheme: %w", err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add v1alpha1 to scheme: %w", err)
+	}
+	return scheme, nil
+}
+
+// runManager starts the manager until ctx is cancelled
+func runManager(mgr Manager) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		if err := mgr.Start(ctx); err != nil {
+			klog.Errorf("Controller manager stopped: %v", err)
+		}
+	}
+}
+
+// newClient creates a controller-runtime client with the driver's scheme
+// for node plugins, which run no manager (node only)
+func newClient(config *rest.Config) (client.Client, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create controller-runtime client: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,606 @@
+// Package arcatest provides an in-memory ARCA API server, so the driver can
+// be exercised against a backend without an ARCA cluster. It implements the
+// SVM, directory, quota and snapshot endpoints the controller uses and
+// counts the requests that changed its state.
+package arcatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+)
+
+// Mutations counted by the server, named after the client methods
+const (
+	CreateSVM       = "CreateSVM"
+	UpdateSVM       = "UpdateSVM"
+	DeleteSVM       = "DeleteSVM"
+	RepairSVM       = "RepairSVM"
+	CreateDirectory = "CreateDirectory"
+	DeleteDirectory = "DeleteDirectory"
+	SetQuota        = "SetQuota"
+	ExpandQuota     = "ExpandQuota"
+	CreateSnapshot  = "CreateSnapshot"
+	RestoreSnapshot = "RestoreSnapshot"
+	DeleteSnapshot  = "DeleteSnapshot"
+)
+
+// DefaultSVMCapacity is the capacity reported for every SVM
+const DefaultSVMCapacity = 1 << 40
+
+// entry is a directory or snapshot of an SVM
+type entry struct {
+	snapshot   bool
+	quotaBytes int64
+	inodeLimit int64
+	createdAt  time.Time
+}
+
+// Server is an in-memory ARCA API served over HTTP
+type Server struct {
+	// URL is the base URL of the API
+	URL string
+
+	httpServer *httptest.Server
+
+	mu        sync.Mutex
+	svms      map[string]*arca.SVM
+	entries   map[string]map[string]*entry
+	mutations map[string]int
+	requests  map[string]int
+	latency   time.Duration
+	// noRestore answers the restore endpoint like a backend without it
+	noRestore bool
+	// noSnapshotState answers the snapshot state endpoint like a backend
+	// without it
+	noSnapshotState bool
+}
+
+// NewServer starts a server with no SVMs; Close stops it
+func NewServer() *Server {
+	s := &Server{
+		svms:      make(map[string]*arca.SVM),
+		entries:   make(map[string]map[string]*entry),
+		mutations: make(map[string]int),
+		requests:  make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleHealth)
+	mux.HandleFunc("GET /v1/svms", s.handleListSVMs)
+	mux.HandleFunc("POST /v1/svms", s.handleCreateSVM)
+	mux.HandleFunc("GET /v1/svms/{name}", s.handleGetSVM)
+	mux.HandleFunc("PUT /v1/svms/{name}", s.handleUpdateSVM)
+	mux.HandleFunc("DELETE /v1/svms/{name}", s.handleDeleteSVM)
+	mux.HandleFunc("POST /v1/svms/{name}/repair", s.handleRepairSVM)
+	mux.HandleFunc("GET /v1/svms/{name}/capacity", s.handleGetCapacity)
+	mux.HandleFunc("GET /v1/exports", s.handleListExports)
+	mux.HandleFunc("GET /v1/directories", s.handleListDirectories)
+	mux.HandleFunc("POST /v1/directories", s.handleCreateDirectory)
+	mux.HandleFunc("GET /v1/directories/{svm}", s.handleGetDirectory)
+	mux.HandleFunc("DELETE /v1/directories/{svm}", s.handleDeleteDirectory)
+	mux.HandleFunc("POST /v1/quotas", s.handleSetQuota)
+	mux.HandleFunc("PATCH /v1/quotas", s.handleExpandQuota)
+	mux.HandleFunc("GET /v1/quotas/{svm}", s.handleGetQuota)
+	mux.HandleFunc("GET /v1/snapshots", s.handleListSnapshots)
+	mux.HandleFunc("POST /v1/snapshots", s.handleCreateSnapshot)
+	mux.HandleFunc("POST /v1/snapshots/restore", s.handleRestoreSnapshot)
+	mux.HandleFunc("GET /v1/snapshots/{svm}", s.handleGetSnapshot)
+	mux.HandleFunc("DELETE /v1/snapshots/{svm}", s.handleDeleteSnapshot)
+
+	s.httpServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.Method+" "+r.URL.Path]++
+		s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// SetLatency delays every request that changes the server's state by d,
+// widening the windows in which concurrent calls can interleave
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Mutations returns how many requests changed the server's state, by
+// mutation (e.g. CreateDirectory); requests that failed or found the
+// result already in place are not counted
+func (s *Server) Mutations() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int, len(s.mutations))
+	for name, n := range s.mutations {
+		counts[name] = n
+	}
+	return counts
+}
+
+// Requests returns how many requests the server received, by method and
+// path (e.g. "POST /v1/snapshots/restore"), whatever their outcome
+func (s *Server) Requests() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int, len(s.requests))
+	for key, n := range s.requests {
+		counts[key] = n
+	}
+	return counts
+}
+
+// SetRestoreSupported makes the server answer the snapshot restore
+// endpoint like an older backend without it (false) or serve it (true, the
+// default)
+func (s *Server) SetRestoreSupported(supported bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noRestore = !supported
+}
+
+// SetSnapshotStateSupported makes the server answer the snapshot state
+// endpoint like an older backend without it (false) or serve it (true, the
+// default)
+func (s *Server) SetSnapshotStateSupported(supported bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noSnapshotState = !supported
+}
+
+// Exists reports whether a directory or snapshot exists at path on an SVM
+func (s *Server) Exists(svmName, path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.entries[svmName][path]
+	return ok
+}
+
+// SetSVMState sets the state an SVM reports, e.g. arca.SVMStateError;
+// false when the SVM does not exist
+func (s *Server) SetSVMState(svmName, state string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	svm, ok := s.svms[svmName]
+	if ok {
+		svm.State = state
+	}
+	return ok
+}
+
+// mutate waits for the configured latency and then runs fn under the
+// server's lock, counting the mutation when fn succeeds
+func (s *Server) mutate(w http.ResponseWriter, name string, fn func() (interface{}, int, string)) {
+	s.mu.Lock()
+	latency := s.latency
+	s.mu.Unlock()
+	time.Sleep(latency)
+
+	s.mu.Lock()
+	data, code, message := fn()
+	if code < 300 {
+		s.mutations[name]++
+	}
+	s.mu.Unlock()
+
+	if code >= 300 {
+		writeError(w, code, message)
+		return
+	}
+	writeData(w, code, data)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeData(w, http.StatusOK, nil)
+}
+
+func (s *Server) handleListSVMs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	svms := make([]arca.SVM, 0, len(s.svms))
+	for _, svm := range s.svms {
+		svms = append(svms, *svm)
+	}
+	s.mu.Unlock()
+	writeData(w, http.StatusOK, svms)
+}
+
+func (s *Server) handleCreateSVM(w http.ResponseWriter, r *http.Request) {
+	var req arca.CreateSVMRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.mutate(w, CreateSVM, func() (interface{}, int, string) {
+		if _, ok := s.svms[req.Name]; ok {
+			return nil, http.StatusConflict, fmt.Sprintf("svm %s already exists", req.Name)
+		}
+		vip, _, _ := strings.Cut(req.IPCIDR, "/")
+		if net.ParseIP(vip) == nil {
+			return nil, http.StatusBadRequest, fmt.Sprintf("invalid ip_cidr %q", req.IPCIDR)
+		}
+		for _, svm := range s.svms {
+			if svm.VLANID == req.VLANID && svm.VIP == vip {
+				return nil, http.StatusConflict, fmt.Sprintf("ip %s is already used on vlan %d", vip, req.VLANID)
+			}
+		}
+		svm := &arca.SVM{
+			Name:      req.Name,
+			VLANID:    req.VLANID,
+			IPCIDR:    req.IPCIDR,
+			VIP:       vip,
+			Gateway:   req.Gateway,
+			MTU:       req.MTU,
+			State:     arca.SVMStateAvailable,
+			CreatedAt: time.Now(),
+		}
+		s.svms[req.Name] = svm
+		s.entries[req.Name] = make(map[string]*entry)
+		return svm, http.StatusCreated, ""
+	})
+}
+
+func (s *Server) handleGetSVM(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	svm, ok := s.svms[r.PathValue("name")]
+	var data arca.SVM
+	if ok {
+		data = *svm
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("svm %s not found", r.PathValue("name")))
+		return
+	}
+	writeData(w, http.StatusOK, data)
+}
+
+func (s *Server) handleUpdateSVM(w http.ResponseWriter, r *http.Request) {
+	var req arca.UpdateSVMRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	name := r.PathValue("name")
+	s.mutate(w, UpdateSVM, func() (interface{}, int, string) {
+		svm, ok := s.svms[name]
+		if !ok {
+			return nil, http.StatusNotFound, fmt.Sprintf("svm %s not found", name)
+		}
+		if req.Gateway != "" {
+			svm.Gateway = req.Gateway
+		}
+		if req.MTU != 0 {
+			svm.MTU = req.MTU
+		}
+		return *svm, http.StatusOK, ""
+	})
+}
+
+func (s *Server) handleRepairSVM(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	s.mutate(w, RepairSVM, func() (interface{}, int, string) {
+		svm, ok := s.svms[name]
+		if !ok {
+			return nil, http.StatusNotFound, fmt.Sprintf("svm %s not found", name)
+		}
+		svm.State = arca.SVMStateAvailable
+		return *svm, http.StatusOK, ""
+	})
+}
+
+func (s *Server) handleDeleteSVM(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	s.mutate(w, DeleteSVM, func() (interface{}, int, string) {
+		if _, ok := s.svms[name]; !ok {
+			return nil, http.StatusNotFound, fmt.Sprintf("svm %s not found", name)
+		}
+		delete(s.svms, name)
+		delete(s.entries, name)
+		return nil, http.StatusOK, ""
+	})
+}
+
+func (s *Server) handleGetCapacity(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	s.mu.Lock()
+	entries, ok := s.entries[name]
+	var used int64
+	for _, e := range entries {
+		used += e.quotaBytes
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("svm %s not found", name))
+		return
+	}
+	writeData(w, http.StatusOK, arca.CapacityInfo{
+		TotalBytes:     DefaultSVMCapacity,
+		AvailableBytes: DefaultSVMCapacity - used,
+		UsedBytes:      used,
+	})
+}
+
+// handleListExports reports no export rules: SVMs export to the backend's
+// defaults
+func (s *Server) handleListExports(w http.ResponseWriter, r *http.Request) {
+	writeData(w, http.StatusOK, struct {
+		Items []arca.Export `json:"items"`
+	}{Items: []arca.Export{}})
+}
+
+func (s *Server) handleListDirectories(w http.ResponseWriter, r *http.Request) {
+	svmName := r.URL.Query().Get("svm")
+	s.mu.Lock()
+	entries, ok := s.entries[svmName]
+	items := []arca.DirectoryInfo{}
+	for path, e := range entries {
+		if !e.snapshot && !strings.Contains(path, "/") {
+			items = append(items, e.directoryInfo(path))
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("svm %s not found", svmName))
+		return
+	}
+	writeData(w, http.StatusOK, struct {
+		Items      []arca.DirectoryInfo `json:"items"`
+		NextCursor string               `json:"next_cursor"`
+	}{Items: items})
+}
+
+func (s *Server) handleCreateDirectory(w http.ResponseWriter, r *http.Request) {
+	var req arca.CreateDirectoryRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.mutate(w, CreateDirectory, func() (interface{}, int, string) {
+		entries, ok := s.entries[req.SVMName]
+		if !ok {
+			return nil, http.StatusNotFound, fmt.Sprintf("svm %s not found", req.SVMName)
+		}
+		if _, ok := entries[req.Path]; ok {
+			return nil, http.StatusConflict, fmt.Sprintf("directory %s already exists", req.Path)
+		}
+		entries[req.Path] = &entry{quotaBytes: req.QuotaBytes, createdAt: time.Now()}
+		return nil, http.StatusCreated, ""
+	})
+}
+
+func (s *Server) handleGetDirectory(w http.ResponseWriter, r *http.Request) {
+	svmName, path := r.PathValue("svm"), r.URL.Query().Get("path")
+	s.mu.Lock()
+	e, ok := s.entries[svmName][path]
+	var info arca.DirectoryInfo
+	if ok {
+		info = e.directoryInfo(path)
+	}
+	s.mu.Unlock()
+	if !ok || e.snapshot {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("directory %s not found", path))
+		return
+	}
+	writeData(w, http.StatusOK, info)
+}
+
+func (s *Server) handleDeleteDirectory(w http.ResponseWriter, r *http.Request) {
+	svmName, path := r.PathValue("svm"), r.URL.Query().Get("path")
+	s.mutate(w, DeleteDirectory, func() (interface{}, int, string) {
+		e, ok := s.entries[svmName][path]
+		if !ok || e.snapshot {
+			return nil, http.StatusNotFound, fmt.Sprintf("directory %s not found", path)
+		}
+		delete(s.entries[svmName], path)
+		return nil, http.StatusOK, ""
+	})
+}
+
+func (s *Server) handleSetQuota(w http.ResponseWriter, r *http.Request) {
+	var req arca.SetQuotaRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.mutate(w, SetQuota, func() (interface{}, int, string) {
+		e, ok := s.entries[req.SVMName][req.Path]
+		if !ok {
+			return nil, http.StatusNotFound, fmt.Sprintf("directory %s not found", req.Path)
+		}
+		e.quotaBytes = req.QuotaBytes
+		e.inodeLimit = req.InodeLimit
+		return nil, http.StatusOK, ""
+	})
+}
+
+func (s *Server) handleExpandQuota(w http.ResponseWriter, r *http.Request) {
+	var req arca.ExpandQuotaRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.mutate(w, ExpandQuota, func() (interface{}, int, string) {
+		e, ok := s.entries[req.SVMName][req.Path]
+		if !ok {
+			return nil, http.StatusNotFound, fmt.Sprintf("quota of %s not found", req.Path)
+		}
+		e.quotaBytes = req.NewQuotaBytes
+		return nil, http.StatusOK, ""
+	})
+}
+
+func (s *Server) handleGetQuota(w http.ResponseWriter, r *http.Request) {
+	svmName, path := r.PathValue("svm"), r.URL.Query().Get("path")
+	s.mu.Lock()
+	e, ok := s.entries[svmName][path]
+	var info arca.QuotaInfo
+	if ok {
+		info = arca.QuotaInfo{Path: path, QuotaBytes: e.quotaBytes, InodeLimit: e.inodeLimit}
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("quota of %s not found", path))
+		return
+	}
+	writeData(w, http.StatusOK, info)
+}
+
+func (s *Server) handleListSnapshots(w http.ResponseWriter, r *http.Request) {
+	svmName := r.URL.Query().Get("svm")
+	s.mu.Lock()
+	entries, ok := s.entries[svmName]
+	items := []arca.SnapshotInfo{}
+	for path, e := range entries {
+		if e.snapshot {
+			items = append(items, e.snapshotInfo(path))
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("svm %s not found", svmName))
+		return
+	}
+	writeData(w, http.StatusOK, struct {
+		Items      []arca.SnapshotInfo `json:"items"`
+		NextCursor string              `json:"next_cursor"`
+	}{Items: items})
+}
+
+// handleCreateSnapshot copies a directory or snapshot to a new path; clones
+// copy a volume to a new volume directory the same way
+func (s *Server) handleCreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	var req arca.CreateSnapshotRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.mutate(w, CreateSnapshot, func() (interface{}, int, string) {
+		return s.copyLocked(req.SVMName, req.SourcePath, req.SnapshotPath)
+	})
+}
+
+func (s *Server) handleRestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	noRestore := s.noRestore
+	s.mu.Unlock()
+	if noRestore {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req arca.RestoreSnapshotRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.mutate(w, RestoreSnapshot, func() (interface{}, int, string) {
+		return s.copyLocked(req.SVMName, req.SnapshotPath, req.TargetPath)
+	})
+}
+
+// copyLocked copies the entry at source to target; a copy into the
+// snapshot directory becomes a snapshot, any other a directory (must hold
+// the lock)
+func (s *Server) copyLocked(svmName, source, target string) (interface{}, int, string) {
+	entries, ok := s.entries[svmName]
+	if !ok {
+		return nil, http.StatusNotFound, fmt.Sprintf("svm %s not found", svmName)
+	}
+	src, ok := entries[source]
+	if !ok {
+		return nil, http.StatusNotFound, fmt.Sprintf("snapshot source path %s not found", source)
+	}
+	if _, ok := entries[target]; ok {
+		return nil, http.StatusConflict, fmt.Sprintf("snapshot %s already exists", target)
+	}
+	dir, _, _ := strings.Cut(target, "/")
+	_, inDir := entries[dir]
+	entries[target] = &entry{
+		snapshot:   dir != target && inDir,
+		quotaBytes: src.quotaBytes,
+		createdAt:  time.Now(),
+	}
+	return nil, http.StatusCreated, ""
+}
+
+func (s *Server) handleGetSnapshot(w http.ResponseWriter, r *http.Request) {
+	svmName, path := r.PathValue("svm"), r.URL.Query().Get("path")
+	s.mu.Lock()
+	if s.noSnapshotState {
+		s.mu.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	e, ok := s.entries[svmName][path]
+	var info arca.SnapshotInfo
+	if ok {
+		info = e.snapshotInfo(path)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("snapshot %s not found", path))
+		return
+	}
+	writeData(w, http.StatusOK, info)
+}
+
+func (s *Server) handleDeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	svmName, path := r.PathValue("svm"), r.URL.Query().Get("path")
+	s.mutate(w, DeleteSnapshot, func() (interface{}, int, string) {
+		e, ok := s.entries[svmName][path]
+		if !ok || !e.snapshot {
+			return nil, http.StatusNotFound, fmt.Sprintf("snapshot %s not found", path)
+		}
+		delete(s.entries[svmName], path)
+		return nil, http.StatusOK, ""
+	})
+}
+
+// directoryInfo describes the entry as a directory
+func (e *entry) directoryInfo(path string) arca.DirectoryInfo {
+	return arca.DirectoryInfo{
+		Path:             path,
+		QuotaBytes:       e.quotaBytes,
+		ProvisioningMode: arca.ProvisioningModeThin,
+		CreatedAt:        e.createdAt,
+	}
+}
+
+// snapshotInfo describes the entry as a completed snapshot
+func (e *entry) snapshotInfo(path string) arca.SnapshotInfo {
+	return arca.SnapshotInfo{
+		Path:      path,
+		State:     arca.SnapshotStateAvailable,
+		SizeBytes: e.quotaBytes,
+		CreatedAt: e.createdAt,
+	}
+}
+
+// decode reads a JSON request body, answering 400 when it is invalid
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return false
+	}
+	return true
+}
+
+// writeData answers with the API's data envelope
+func writeData(w http.ResponseWriter, code int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(arca.APIResponse{Data: data})
+}
+
+// writeError answers with the API's error envelope
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(arca.APIResponse{Error: message})
+}
@@ -0,0 +1,116 @@
+package arca
+
+import (
+	"fmt"
+	"path"
+	"sync"
+)
+
+// DefaultBackend is the name of the backend configured under "arca"
+const DefaultBackend = ""
+
+// Backend is an ARCA cluster the controller provisions onto
+type Backend struct {
+	Name       string
+	Client     *Client
+	SVMManager *SVMManager
+	Allocator  *StandaloneAllocator
+}
+
+// backendRoute maps namespace glob patterns to a backend
+type backendRoute struct {
+	namespaces []string
+	backend    *Backend
+}
+
+// BackendRouter selects the ARCA backend for a namespace. Namespaces that
+// match no route use the default backend.
+type BackendRouter struct {
+	def    *Backend
+	routes []backendRoute
+	byName map[string]*Backend
+
+	// pinned overrides routes for namespaces moved by an SVM migration
+	mu     sync.RWMutex
+	pinned map[string]*Backend
+}
+
+// NewBackendRouter creates a router with the given default backend
+func NewBackendRouter(def *Backend) *BackendRouter {
+	def.Name = DefaultBackend
+	return &BackendRouter{
+		def:    def,
+		byName: map[string]*Backend{DefaultBackend: def},
+		pinned: make(map[string]*Backend),
+	}
+}
+
+// AddBackend routes namespaces matching any of the glob patterns to the
+// backend. Routes are evaluated in the order they were added.
+func (r *BackendRouter) AddBackend(b *Backend, namespaces []string) error {
+	if b.Name == DefaultBackend {
+		return fmt.Errorf("backend name is required")
+	}
+	if _, exists := r.byName[b.Name]; exists {
+		return fmt.Errorf("duplicate backend %q", b.Name)
+	}
+	for _, pattern := range namespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q for backend %s: %w", pattern, b.Name, err)
+		}
+	}
+
+	r.routes = append(r.routes, backendRoute{namespaces: namespaces, backend: b})
+	r.byName[b.Name] = b
+	return nil
+}
+
+// ForNamespace returns the backend that provisions volumes for a namespace
+func (r *BackendRouter) ForNamespace(namespace string) *Backend {
+	r.mu.RLock()
+	b, ok := r.pinned[namespace]
+	r.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	for _, route := range r.routes {
+		for _, pattern := range route.namespaces {
+			if matched, _ := path.Match(pattern, namespace); matched {
+				return route.backend
+			}
+		}
+	}
+	return r.def
+}
+
+// PinNamespace routes a namespace to the named backend regardless of the
+// configured patterns (used once an SVM migration has cut over)
+func (r *BackendRouter) PinNamespace(namespace, name string) error {
+	b, err := r.Get(name)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.pinned[namespace] = b
+	r.mu.Unlock()
+	return nil
+}
+
+// Get returns the backend with the given name ("" is the default backend)
+func (r *BackendRouter) Get(name string) (*Backend, error) {
+	b, ok := r.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown ARCA backend %q", name)
+	}
+	return b, nil
+}
+
+// Backends returns all backends, default first
+func (r *BackendRouter) Backends() []*Backend {
+	backends := []*Backend{r.def}
+	for _, route := range r.routes {
+		backends = append(backends, route.backend)
+	}
+	return backends
+}
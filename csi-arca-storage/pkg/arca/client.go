@@ -6,11 +6,13 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -18,20 +20,100 @@ import (
 
 // Client is an ARCA REST API client
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
-	timeout    time.Duration
-	retryCount int
-	authToken  string
+	endpoints       *endpointPool
+	httpClient      atomic.Pointer[http.Client]
+	newTransport    func() http.RoundTripper
+	watchdog        *watchdog
+	timeout         time.Duration
+	timeouts        OperationTimeouts
+	retry           RetryPolicy
+	authToken       string
+	healthCheckPath string
+	maxResponseSize int64
+
+	// restoreUnsupported holds the endpoints that lacked the snapshot
+	// restore endpoint, so restores go straight to the fallback
+	restoreUnsupported endpointLatch
 }
 
 // ClientConfig holds configuration for the ARCA client
 type ClientConfig struct {
-	BaseURL    string
-	Timeout    time.Duration
+	BaseURL   string
+	Timeout   time.Duration
+	AuthToken string
+	TLSConfig *TLSConfig
+
+	// RetryCount is the number of retries after a failed request (default
+	// 3, negative for none)
 	RetryCount int
-	AuthToken  string
-	TLSConfig  *TLSConfig
+	// RetryOn lists the failure classes retried (default RetryClasses)
+	RetryOn []string
+
+	// Endpoints are additional API base URLs tried when BaseURL fails
+	Endpoints []string
+	// EndpointPolicy is "failover" (default) or "round-robin"
+	EndpointPolicy EndpointPolicy
+	// EndpointCooldown is how long a failed endpoint is skipped (default 30s)
+	EndpointCooldown time.Duration
+	// HealthCheckPath is probed by RunHealthCheck (default "/")
+	HealthCheckPath string
+	// OperationTimeouts override Timeout for each kind of API call
+	OperationTimeouts OperationTimeouts
+
+	// StuckCallCeiling is how long a request may run before the watchdog
+	// cancels it, however its timeout is set (0 disables the watchdog)
+	StuckCallCeiling time.Duration
+	// StuckCallReconnect is how many calls in a row may get stuck before
+	// the client drops its connections (default 3)
+	StuckCallReconnect int
+
+	// MaxResponseSize is the largest response body read, in bytes (default
+	// DefaultMaxResponseSize); larger ones fail with ResponseTooLargeError
+	MaxResponseSize int64
+}
+
+// DefaultMaxResponseSize is the default limit of response bodies; list
+// pages of a few thousand entries stay far below it
+const DefaultMaxResponseSize = 32 << 20
+
+// OperationTimeouts bound a single API request per kind of operation; zero
+// values fall back to ClientConfig.Timeout
+type OperationTimeouts struct {
+	SVM       time.Duration
+	Directory time.Duration
+	Snapshot  time.Duration
+	Quota     time.Duration
+}
+
+// operation is the kind of ARCA API call, used to select its timeout
+type operation int
+
+const (
+	opSVM operation = iota
+	opDirectory
+	opSnapshot
+	opQuota
+	// opRaw is a request sent through Do, bounded by the default timeout
+	opRaw
+)
+
+// timeoutFor returns the per-request timeout for an operation
+func (c *Client) timeoutFor(op operation) time.Duration {
+	var t time.Duration
+	switch op {
+	case opSVM:
+		t = c.timeouts.SVM
+	case opDirectory:
+		t = c.timeouts.Directory
+	case opSnapshot:
+		t = c.timeouts.Snapshot
+	case opQuota:
+		t = c.timeouts.Quota
+	}
+	if t == 0 {
+		t = c.timeout
+	}
+	return t
 }
 
 // TLSConfig holds TLS configuration
@@ -47,32 +129,61 @@ func NewClient(config *ClientConfig) (*Client, error) {
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
-	if config.RetryCount == 0 {
-		config.RetryCount = 3
+	retry := RetryPolicy{Retries: config.RetryCount, RetryOn: config.RetryOn}
+	if retry.Retries == 0 {
+		retry.Retries = 3
+	} else if retry.Retries < 0 {
+		retry.Retries = 0
 	}
-
-	httpClient := &http.Client{
-		Timeout: config.Timeout,
+	if retry.RetryOn == nil {
+		retry.RetryOn = RetryClasses
 	}
 
-	// Configure TLS if provided
+	// Configure TLS if provided; the watchdog builds a new transport the
+	// same way when it reconnects
+	newTransport := func() http.RoundTripper {
+		return http.DefaultTransport.(*http.Transport).Clone()
+	}
 	if config.TLSConfig != nil {
 		tlsConfig, err := buildTLSConfig(config.TLSConfig)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build TLS config: %w", err)
 		}
-		httpClient.Transport = &http.Transport{
-			TLSClientConfig: tlsConfig,
+		newTransport = func() http.RoundTripper {
+			return &http.Transport{
+				TLSClientConfig: tlsConfig.Clone(),
+			}
 		}
 	}
 
-	return &Client{
-		baseURL:    config.BaseURL,
-		httpClient: httpClient,
-		timeout:    config.Timeout,
-		retryCount: config.RetryCount,
-		authToken:  config.AuthToken,
-	}, nil
+	endpoints, err := newEndpointPool(append([]string{config.BaseURL}, config.Endpoints...), config.EndpointPolicy, config.EndpointCooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	healthCheckPath := config.HealthCheckPath
+	if healthCheckPath == "" {
+		healthCheckPath = DefaultHealthCheckPath
+	}
+	maxResponseSize := config.MaxResponseSize
+	if maxResponseSize <= 0 {
+		maxResponseSize = DefaultMaxResponseSize
+	}
+
+	c := &Client{
+		endpoints:       endpoints,
+		newTransport:    newTransport,
+		watchdog:        newWatchdog(config.StuckCallCeiling, config.StuckCallReconnect),
+		timeout:         config.Timeout,
+		timeouts:        config.OperationTimeouts,
+		retry:           retry,
+		authToken:       config.AuthToken,
+		healthCheckPath: healthCheckPath,
+		maxResponseSize: maxResponseSize,
+	}
+	// Requests are bounded per operation in doRequestAnyEndpoint
+	c.httpClient.Store(&http.Client{Transport: newTransport()})
+	return c, nil
 }
 
 // buildTLSConfig builds TLS configuration from file paths
@@ -106,14 +217,31 @@ func buildTLSConfig(config *TLSConfig) (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
-// doRequest performs HTTP request with exponential backoff retry
-func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, queryParams ...url.Values) ([]byte, error) {
+// doRequest performs HTTP request with exponential backoff retry, as far as
+// the retry policy of ctx allows
+func (c *Client) doRequest(ctx context.Context, op operation, method, path string, body interface{}, queryParams ...url.Values) ([]byte, error) {
+	return c.doRequestWith(ctx, op, method, path, body, nil, queryParams...)
+}
+
+// doRequestDecode performs a request like doRequest and decodes the
+// response into out; list endpoints use it. The decoded page is held in
+// full, so the response size limit is what bounds its memory.
+func (c *Client) doRequestDecode(ctx context.Context, op operation, method, path string, out interface{}, queryParams ...url.Values) error {
+	_, err := c.doRequestWith(ctx, op, method, path, nil, out, queryParams...)
+	return err
+}
+
+// doRequestWith performs a request with retries, returning the response
+// body, or decoding it into out if non-nil
+func (c *Client) doRequestWith(ctx context.Context, op operation, method, path string, body, out interface{}, queryParams ...url.Values) ([]byte, error) {
+	policy := c.retryPolicyFor(ctx)
 	var lastErr error
 
-	for attempt := 0; attempt <= c.retryCount; attempt++ {
+	attempt := 0
+	for ; attempt <= policy.Retries; attempt++ {
 		if attempt > 0 {
 			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
-			klog.V(4).Infof("Retrying request (attempt %d/%d) after %v", attempt+1, c.retryCount+1, backoff)
+			klog.V(4).Infof("Retrying request (attempt %d/%d) after %v", attempt+1, policy.Retries+1, backoff)
 			select {
 			case <-time.After(backoff):
 			case <-ctx.Done():
@@ -121,7 +249,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 			}
 		}
 
-		resp, err := c.doRequestOnce(ctx, method, path, body, queryParams...)
+		resp, err := c.doRequestAnyEndpoint(ctx, c.timeoutFor(op), method, path, body, out, queryParams...)
 		if err == nil {
 			return resp, nil
 		}
@@ -129,21 +257,58 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 		lastErr = err
 
 		// Don't retry on certain errors
-		if isNonRetryableError(err) {
-			klog.V(4).Infof("Non-retryable error: %v", err)
+		if !policy.retries(err) {
+			klog.V(4).Infof("Not retrying error: %v", err)
+			attempt++
 			break
 		}
 
-		klog.V(4).Infof("Request failed (attempt %d/%d): %v", attempt+1, c.retryCount+1, err)
+		klog.V(4).Infof("Request failed (attempt %d/%d): %v", attempt+1, policy.Retries+1, err)
 	}
 
-	return nil, fmt.Errorf("request failed after %d attempts: %w", c.retryCount+1, lastErr)
+	return nil, fmt.Errorf("request failed after %d attempts: %w", attempt, lastErr)
 }
 
-// doRequestOnce performs a single HTTP request
-func (c *Client) doRequestOnce(ctx context.Context, method, path string, body interface{}, queryParams ...url.Values) ([]byte, error) {
+// Do sends a request to an arbitrary API path with the client's endpoints,
+// authentication, TLS settings and retries, and returns the response body.
+// body must be JSON and is sent as is (nil sends none). It is meant for
+// debugging; the driver uses the typed methods.
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body json.RawMessage) ([]byte, error) {
+	var payload interface{}
+	if body != nil {
+		payload = body
+	}
+	return c.doRequest(ctx, opRaw, method, path, payload, query)
+}
+
+// doRequestAnyEndpoint performs a request against the endpoints in order,
+// moving to the next endpoint when one is unavailable or times out
+func (c *Client) doRequestAnyEndpoint(ctx context.Context, timeout time.Duration, method, path string, body, out interface{}, queryParams ...url.Values) ([]byte, error) {
+	var lastErr error
+	for _, ep := range c.endpoints.candidates() {
+		reqCtx, cancel := context.WithTimeout(ctx, timeout)
+		reqCtx, finish := c.trackCall(reqCtx, ep.baseURL, method, path)
+		resp, err := c.doRequestOnce(reqCtx, ep.baseURL, method, path, body, out, queryParams...)
+		err = finish(err)
+		cancel()
+		if err == nil {
+			c.endpoints.markUp(ep)
+			return resp, nil
+		}
+		if ctx.Err() != nil || !isEndpointFailure(err) {
+			return nil, err
+		}
+		c.endpoints.markDown(ep, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// doRequestOnce performs a single HTTP request. A successful response is
+// decoded into out if non-nil, else returned.
+func (c *Client) doRequestOnce(ctx context.Context, baseURL, method, path string, body, out interface{}, queryParams ...url.Values) ([]byte, error) {
 	// Build URL
-	reqURL := c.baseURL + path
+	reqURL := baseURL + path
 	if len(queryParams) > 0 && queryParams[0] != nil {
 		reqURL += "?" + queryParams[0].Encode()
 	}
@@ -171,33 +336,113 @@ func (c *Client) doRequestOnce(ctx context.Context, method, path string, body in
 	}
 
 	// Execute request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.httpClient.Load().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("http request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// Bodies beyond the limit fail instead of being buffered
+	limited := &limitedBody{
+		r:         resp.Body,
+		remaining: c.maxResponseSize,
+		err:       &ResponseTooLargeError{Method: method, Path: path, Limit: c.maxResponseSize},
+	}
+
+	// Decode successful responses straight from the limited body
+	if out != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err := json.NewDecoder(limited).Decode(out); err != nil {
+			if errors.Is(err, ErrResponseTooLarge) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil, nil
+	}
+
 	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(limited)
 	if err != nil {
+		if errors.Is(err, ErrResponseTooLarge) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		// Try to parse error message from response
+		message := string(respBody)
 		var apiResp APIResponse
 		if err := json.Unmarshal(respBody, &apiResp); err == nil && apiResp.Error != "" {
-			return nil, MapHTTPStatusToError(resp.StatusCode, apiResp.Error)
+			message = apiResp.Error
+		}
+		err := MapHTTPStatusToError(resp.StatusCode, message)
+		if optional, ok := ctx.Value(optionalEndpointKey{}).(*optionalEndpoint); ok {
+			if resp.StatusCode == http.StatusNotFound && isUnknownRoute(message) {
+				err = ErrNotSupported
+			}
+			if errors.Is(err, ErrNotSupported) && optional.unsupported != nil {
+				optional.unsupported(baseURL)
+			}
 		}
-		return nil, MapHTTPStatusToError(resp.StatusCode, string(respBody))
+		return nil, err
 	}
 
 	return respBody, nil
 }
 
+// optionalEndpointKey is the context key of an optionalEndpoint
+type optionalEndpointKey struct{}
+
+// optionalEndpoint marks a request to an API endpoint older backends lack
+type optionalEndpoint struct {
+	// unsupported, if set, is called with the base URL of an ARCA endpoint
+	// that lacks it
+	unsupported func(baseURL string)
+}
+
+// withOptionalEndpoint marks the request made with ctx as one to an API
+// endpoint older backends lack: a 404 from the backend's router rather
+// than the API ("404 page not found") fails with ErrNotSupported instead of
+// a missing resource. unsupported may be nil.
+func withOptionalEndpoint(ctx context.Context, unsupported func(baseURL string)) context.Context {
+	return context.WithValue(ctx, optionalEndpointKey{}, &optionalEndpoint{unsupported: unsupported})
+}
+
+// limitedBody reads a response body up to a limit and fails with err once
+// the body turns out to be longer
+type limitedBody struct {
+	r         io.Reader
+	remaining int64
+	err       error
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Tell a body of exactly the limit from a longer one
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, l.err
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
 // isNonRetryableError checks if an error should not be retried
 func isNonRetryableError(err error) bool {
+	// An oversized response comes back the same on retry
+	if errors.Is(err, ErrResponseTooLarge) {
+		return true
+	}
+
 	// Don't retry on 4xx errors except 408 (timeout) and 429 (rate limit)
 	if apiErr, ok := err.(*APIError); ok {
 		if apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 {
@@ -207,9 +452,11 @@ func isNonRetryableError(err error) bool {
 
 	// Don't retry on specific known errors
 	switch err {
-	case ErrSVMAlreadyExists, ErrDirectoryAlreadyExists, ErrSnapshotAlreadyExists:
+	case ErrSVMAlreadyExists, ErrDirectoryAlreadyExists, ErrSnapshotAlreadyExists, ErrExportAlreadyExists:
+		return true
+	case ErrSVMNotFound, ErrDirectoryNotFound, ErrSnapshotNotFound, ErrQuotaNotFound, ErrExportNotFound:
 		return true
-	case ErrSVMNotFound, ErrDirectoryNotFound, ErrSnapshotNotFound, ErrQuotaNotFound:
+	case ErrSnapshotHasDependents, ErrNotSupported:
 		return true
 	}
 
@@ -218,7 +465,7 @@ func isNonRetryableError(err error) bool {
 
 // GetSVM retrieves SVM information
 func (c *Client) GetSVM(ctx context.Context, name string) (*SVM, error) {
-	respBody, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/v1/svms/%s", name), nil)
+	respBody, err := c.doRequest(ctx, opSVM, http.MethodGet, fmt.Sprintf("/v1/svms/%s", name), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -235,10 +482,10 @@ func (c *Client) GetSVM(ctx context.Context, name string) (*SVM, error) {
 
 // CreateSVM creates a new SVM (idempotent)
 func (c *Client) CreateSVM(ctx context.Context, req *CreateSVMRequest) (*SVM, error) {
-	respBody, err := c.doRequest(ctx, http.MethodPost, "/v1/svms", req)
+	respBody, err := c.doRequest(ctx, opSVM, http.MethodPost, "/v1/svms", req)
 	if err != nil {
 		// If SVM already exists, try to get it
-		if err == ErrSVMAlreadyExists {
+		if errors.Is(err, ErrSVMAlreadyExists) {
 			return c.GetSVM(ctx, req.Name)
 		}
 		return nil, err
@@ -254,11 +501,47 @@ func (c *Client) CreateSVM(ctx context.Context, req *CreateSVMRequest) (*SVM, er
 	return &response.Data, nil
 }
 
+// UpdateSVM changes the attributes of an existing SVM
+func (c *Client) UpdateSVM(ctx context.Context, name string, req *UpdateSVMRequest) (*SVM, error) {
+	respBody, err := c.doRequest(ctx, opSVM, http.MethodPut, fmt.Sprintf("/v1/svms/%s", name), req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data SVM `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response.Data, nil
+}
+
+// RepairSVM asks the backend to restore an SVM in the error state, e.g. by
+// restarting its NFS server and re-plumbing its VIP. Backends without the
+// endpoint return ErrNotSupported.
+func (c *Client) RepairSVM(ctx context.Context, name string) (*SVM, error) {
+	respBody, err := c.doRequest(withOptionalEndpoint(ctx, nil), opSVM, http.MethodPost, fmt.Sprintf("/v1/svms/%s/repair", name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data SVM `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response.Data, nil
+}
+
 // DeleteSVM deletes an SVM (idempotent)
 func (c *Client) DeleteSVM(ctx context.Context, name string) error {
-	_, err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/v1/svms/%s", name), nil)
+	_, err := c.doRequest(ctx, opSVM, http.MethodDelete, fmt.Sprintf("/v1/svms/%s", name), nil)
 	if err != nil {
-		if err == ErrSVMNotFound {
+		if errors.Is(err, ErrSVMNotFound) {
 			return nil // Idempotent
 		}
 		return err
@@ -268,16 +551,11 @@ func (c *Client) DeleteSVM(ctx context.Context, name string) error {
 
 // ListSVMs lists all SVMs
 func (c *Client) ListSVMs(ctx context.Context) ([]SVM, error) {
-	respBody, err := c.doRequest(ctx, http.MethodGet, "/v1/svms", nil)
-	if err != nil {
-		return nil, err
-	}
-
 	var response struct {
 		Data []SVM `json:"data"`
 	}
-	if err := json.Unmarshal(respBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := c.doRequestDecode(ctx, opSVM, http.MethodGet, "/v1/svms", &response); err != nil {
+		return nil, err
 	}
 
 	return response.Data, nil
@@ -285,7 +563,7 @@ func (c *Client) ListSVMs(ctx context.Context) ([]SVM, error) {
 
 // GetSVMCapacity retrieves SVM capacity information
 func (c *Client) GetSVMCapacity(ctx context.Context, svmName string) (*CapacityInfo, error) {
-	respBody, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/v1/svms/%s/capacity", svmName), nil)
+	respBody, err := c.doRequest(ctx, opSVM, http.MethodGet, fmt.Sprintf("/v1/svms/%s/capacity", svmName), nil)
 	if err != nil {
 		return nil, err
 	}
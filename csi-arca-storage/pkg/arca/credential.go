@@ -0,0 +1,41 @@
+package arca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MountCredentialRequest exchanges a pod's bound service account token for
+// access to an SVM's exports from a node
+type MountCredentialRequest struct {
+	Token  string `json:"token"`
+	NodeID string `json:"node_id"`
+}
+
+// MountCredential is a short-lived grant for a node to mount an SVM on behalf
+// of a namespace
+type MountCredential struct {
+	Namespace string    `json:"namespace"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExchangeMountToken asks ARCA to verify a service account token and grant
+// the node access to the SVM if the token's namespace owns it
+func (c *Client) ExchangeMountToken(ctx context.Context, svmName string, req *MountCredentialRequest) (*MountCredential, error) {
+	respBody, err := c.doRequest(ctx, opSVM, http.MethodPost, fmt.Sprintf("/v1/svms/%s/mount-credentials", svmName), req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data MountCredential `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response.Data, nil
+}
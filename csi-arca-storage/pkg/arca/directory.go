@@ -2,6 +2,8 @@ package arca
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -9,9 +11,9 @@ import (
 
 // CreateDirectory creates a directory with optional quota (idempotent)
 func (c *Client) CreateDirectory(ctx context.Context, req *CreateDirectoryRequest) error {
-	_, err := c.doRequest(ctx, http.MethodPost, "/v1/directories", req)
+	_, err := c.doRequest(ctx, opDirectory, http.MethodPost, "/v1/directories", req)
 	if err != nil {
-		if err == ErrDirectoryAlreadyExists {
+		if errors.Is(err, ErrDirectoryAlreadyExists) {
 			return nil // Idempotent
 		}
 		return err
@@ -24,12 +26,76 @@ func (c *Client) DeleteDirectory(ctx context.Context, svmName, path string) erro
 	params := url.Values{}
 	params.Set("path", path)
 
-	_, err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/v1/directories/%s", svmName), nil, params)
+	_, err := c.doRequest(ctx, opDirectory, http.MethodDelete, fmt.Sprintf("/v1/directories/%s", svmName), nil, params)
 	if err != nil {
-		if err == ErrDirectoryNotFound {
+		if errors.Is(err, ErrDirectoryNotFound) {
 			return nil // Idempotent
 		}
 		return err
 	}
 	return nil
 }
+
+// WipeDirectory overwrites the data of the files in a directory on the
+// backend, leaving the directory itself for DeleteDirectory. Backends
+// without the endpoint return ErrNotSupported.
+func (c *Client) WipeDirectory(ctx context.Context, svmName, path string) error {
+	params := url.Values{}
+	params.Set("path", path)
+
+	_, err := c.doRequest(withOptionalEndpoint(ctx, nil), opDirectory, http.MethodPost, fmt.Sprintf("/v1/directories/%s/wipe", svmName), nil, params)
+	return err
+}
+
+// GetDirectory retrieves a directory of an SVM
+func (c *Client) GetDirectory(ctx context.Context, svmName, path string) (*DirectoryInfo, error) {
+	params := url.Values{}
+	params.Set("path", path)
+
+	respBody, err := c.doRequest(ctx, opDirectory, http.MethodGet, fmt.Sprintf("/v1/directories/%s", svmName), nil, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data DirectoryInfo `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response.Data, nil
+}
+
+// directoryPageSize is the page size used when listing directories
+const directoryPageSize = 200
+
+// ListDirectories lists the top-level directories of an SVM
+func (c *Client) ListDirectories(ctx context.Context, svmName string) ([]DirectoryInfo, error) {
+	var directories []DirectoryInfo
+	cursor := ""
+	for {
+		params := url.Values{}
+		params.Set("svm", svmName)
+		params.Set("limit", fmt.Sprintf("%d", directoryPageSize))
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		var response struct {
+			Data struct {
+				Items      []DirectoryInfo `json:"items"`
+				NextCursor string          `json:"next_cursor"`
+			} `json:"data"`
+		}
+		if err := c.doRequestDecode(ctx, opDirectory, http.MethodGet, "/v1/directories", &response, params); err != nil {
+			return nil, err
+		}
+
+		directories = append(directories, response.Data.Items...)
+		if response.Data.NextCursor == "" {
+			return directories, nil
+		}
+		cursor = response.Data.NextCursor
+	}
+}
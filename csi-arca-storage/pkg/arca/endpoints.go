@@ -0,0 +1,293 @@
+package arca
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// EndpointPolicy selects how requests are spread across ARCA API endpoints
+type EndpointPolicy string
+
+const (
+	// EndpointFailover sends requests to the first healthy endpoint in order
+	EndpointFailover EndpointPolicy = "failover"
+	// EndpointRoundRobin rotates requests across healthy endpoints
+	EndpointRoundRobin EndpointPolicy = "round-robin"
+)
+
+const (
+	// DefaultEndpointCooldown is how long a failed endpoint is skipped
+	DefaultEndpointCooldown = 30 * time.Second
+	// DefaultHealthCheckPath is probed by the endpoint health checker
+	DefaultHealthCheckPath = "/"
+)
+
+// endpoint is a single ARCA API base URL and its health
+type endpoint struct {
+	baseURL   string
+	downUntil time.Time
+	lastErr   error
+}
+
+// endpointPool tracks ARCA API endpoints and orders them per request
+type endpointPool struct {
+	mu        sync.Mutex
+	endpoints []*endpoint
+	policy    EndpointPolicy
+	cooldown  time.Duration
+	next      int
+}
+
+// newEndpointPool creates a pool from base URLs (the first is the primary)
+func newEndpointPool(baseURLs []string, policy EndpointPolicy, cooldown time.Duration) (*endpointPool, error) {
+	switch policy {
+	case "":
+		policy = EndpointFailover
+	case EndpointFailover, EndpointRoundRobin:
+	default:
+		return nil, fmt.Errorf("unknown endpoint policy %q", policy)
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultEndpointCooldown
+	}
+
+	p := &endpointPool{policy: policy, cooldown: cooldown}
+	seen := make(map[string]bool, len(baseURLs))
+	for _, u := range baseURLs {
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		p.endpoints = append(p.endpoints, &endpoint{baseURL: u})
+	}
+	if len(p.endpoints) == 0 {
+		return nil, fmt.Errorf("at least one ARCA endpoint is required")
+	}
+	return p, nil
+}
+
+// candidates returns the endpoints to try for a request: healthy endpoints
+// (ordered by policy) followed by endpoints in cooldown as a last resort
+func (p *endpointPool) candidates() []*endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var healthy, down []*endpoint
+	for _, ep := range p.endpoints {
+		if now.Before(ep.downUntil) {
+			down = append(down, ep)
+		} else {
+			healthy = append(healthy, ep)
+		}
+	}
+
+	if p.policy == EndpointRoundRobin && len(healthy) > 1 {
+		start := p.next % len(healthy)
+		p.next++
+		healthy = append(healthy[start:], healthy[:start]...)
+	}
+
+	return append(healthy, down...)
+}
+
+// baseURLs returns the base URLs of all endpoints
+func (p *endpointPool) baseURLs() []string {
+	urls := make([]string, len(p.endpoints))
+	for i, ep := range p.endpoints {
+		urls[i] = ep.baseURL
+	}
+	return urls
+}
+
+// markDown takes an endpoint out of rotation for the cooldown period
+func (p *endpointPool) markDown(ep *endpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Now().After(ep.downUntil) {
+		klog.Warningf("ARCA endpoint %s unavailable, skipping for %v: %v", ep.baseURL, p.cooldown, err)
+	}
+	ep.downUntil = time.Now().Add(p.cooldown)
+	ep.lastErr = err
+}
+
+// markUp returns an endpoint to rotation
+func (p *endpointPool) markUp(ep *endpoint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !ep.downUntil.IsZero() {
+		klog.Infof("ARCA endpoint %s is available again", ep.baseURL)
+	}
+	ep.downUntil = time.Time{}
+	ep.lastErr = nil
+}
+
+// isEndpointFailure reports whether an error means the endpoint itself is
+// unavailable (as opposed to the request being rejected)
+func isEndpointFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrUnavailable) || errors.Is(err, ErrStuckCall) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	// Transport errors (connection refused, timeouts, TLS failures)
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// EndpointStatus describes the health of an ARCA API endpoint
+type EndpointStatus struct {
+	BaseURL   string
+	Healthy   bool
+	DownUntil time.Time
+	LastError string
+}
+
+// EndpointStatuses returns the current health of all configured endpoints
+func (c *Client) EndpointStatuses() []EndpointStatus {
+	c.endpoints.mu.Lock()
+	defer c.endpoints.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]EndpointStatus, len(c.endpoints.endpoints))
+	for i, ep := range c.endpoints.endpoints {
+		statuses[i] = EndpointStatus{
+			BaseURL:   ep.baseURL,
+			Healthy:   !now.Before(ep.downUntil),
+			DownUntil: ep.downUntil,
+		}
+		if ep.lastErr != nil {
+			statuses[i].LastError = ep.lastErr.Error()
+		}
+	}
+	return statuses
+}
+
+// CheckEndpoints probes every configured endpoint once, regardless of
+// whether it is in rotation, and returns the result for each
+func (c *Client) CheckEndpoints(ctx context.Context) []EndpointStatus {
+	statuses := make([]EndpointStatus, len(c.endpoints.endpoints))
+	for i, ep := range c.endpoints.endpoints {
+		statuses[i] = EndpointStatus{BaseURL: ep.baseURL, Healthy: true}
+		if err := c.probe(ctx, ep); err != nil {
+			statuses[i].Healthy = false
+			statuses[i].LastError = err.Error()
+		}
+	}
+	return statuses
+}
+
+// CheckAuth verifies that the API accepts the client's credentials by
+// making an authenticated read request
+func (c *Client) CheckAuth(ctx context.Context) error {
+	_, err := c.ListSVMs(ctx)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+		return fmt.Errorf("credentials rejected: %w", err)
+	}
+	return err
+}
+
+// RunHealthCheck probes every endpoint at the given interval until ctx is
+// cancelled, so failed endpoints rejoin (or leave) rotation without waiting
+// for a request to hit them
+func (c *Client) RunHealthCheck(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || len(c.endpoints.endpoints) < 2 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, ep := range c.endpoints.endpoints {
+			if err := c.probe(ctx, ep); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				c.endpoints.markDown(ep, err)
+			} else {
+				c.endpoints.markUp(ep)
+			}
+		}
+	}
+}
+
+// probe checks that an endpoint answers HTTP requests (any non-5xx status)
+func (c *Client) probe(ctx context.Context, ep *endpoint) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.baseURL+c.healthCheckPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+	if c.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
+
+	resp, err := c.httpClient.Load().Do(req)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// endpointLatch records the endpoints that lack an optional API endpoint,
+// each for a while only, so an upgraded backend is used again
+type endpointLatch struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+// set records baseURL for ttl and reports whether it was not recorded yet
+func (l *endpointLatch) set(baseURL string, ttl time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.until == nil {
+		l.until = make(map[string]time.Time)
+	}
+	fresh := time.Now().After(l.until[baseURL])
+	l.until[baseURL] = time.Now().Add(ttl)
+	return fresh
+}
+
+// all reports whether every one of baseURLs is recorded
+func (l *endpointLatch) all(baseURLs []string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for _, u := range baseURLs {
+		if !now.Before(l.until[u]) {
+			return false
+		}
+	}
+	return true
+}
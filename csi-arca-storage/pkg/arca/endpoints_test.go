@@ -0,0 +1,38 @@
+package arca
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEndpointLatch(t *testing.T) {
+	var latch endpointLatch
+	urls := []string{"https://a.example", "https://b.example"}
+
+	if latch.all(urls) {
+		t.Fatal("empty latch records all endpoints")
+	}
+	if !latch.set(urls[0], time.Hour) {
+		t.Error("first set of an endpoint is not reported as new")
+	}
+	if latch.set(urls[0], time.Hour) {
+		t.Error("second set of an endpoint is reported as new")
+	}
+	if latch.all(urls) {
+		t.Error("latch records all endpoints after setting one of two")
+	}
+
+	latch.set(urls[1], time.Hour)
+	if !latch.all(urls) {
+		t.Error("latch does not record all endpoints after setting both")
+	}
+
+	// An expired entry no longer counts
+	latch.set(urls[1], 0)
+	if latch.all(urls) {
+		t.Error("latch records an expired endpoint")
+	}
+	if !latch.set(urls[1], time.Hour) {
+		t.Error("set of an expired endpoint is not reported as new")
+	}
+}
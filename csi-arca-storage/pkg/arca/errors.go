@@ -18,6 +18,24 @@ var (
 	// ErrAllPoolsExhausted indicates all IP pools are exhausted
 	ErrAllPoolsExhausted = errors.New("all IP pools exhausted")
 
+	// ErrStaticVIPInUse indicates a namespace's pinned VIP is held by another SVM
+	ErrStaticVIPInUse = errors.New("static VIP already in use")
+
+	// ErrNoAllowedPool indicates none of the pools an allocation may use has
+	// a free address, e.g. because the requested topology reaches none
+	ErrNoAllowedPool = errors.New("no allowed IP pool available")
+
+	// ErrSVMNotReady indicates the SVM exists but is still being created or
+	// deleted
+	ErrSVMNotReady = errors.New("svm not ready")
+
+	// ErrSVMUnhealthy indicates the SVM exists but is in an error or unknown
+	// state
+	ErrSVMUnhealthy = errors.New("svm unhealthy")
+
+	// ErrSVMCreationDenied indicates the namespace is not allowed to create an SVM
+	ErrSVMCreationDenied = errors.New("svm creation not allowed for namespace")
+
 	// ErrDirectoryNotFound indicates the directory does not exist
 	ErrDirectoryNotFound = errors.New("directory not found")
 
@@ -30,9 +48,19 @@ var (
 	// ErrSnapshotAlreadyExists indicates the snapshot already exists
 	ErrSnapshotAlreadyExists = errors.New("snapshot already exists")
 
+	// ErrSnapshotHasDependents indicates the snapshot is still in use (e.g. by
+	// volumes restored from it on backends without independent reflinks)
+	ErrSnapshotHasDependents = errors.New("snapshot has dependents")
+
 	// ErrQuotaNotFound indicates the quota does not exist
 	ErrQuotaNotFound = errors.New("quota not found")
 
+	// ErrExportNotFound indicates the export rule does not exist
+	ErrExportNotFound = errors.New("export not found")
+
+	// ErrExportAlreadyExists indicates the export rule already exists
+	ErrExportAlreadyExists = errors.New("export already exists")
+
 	// ErrUnavailable indicates the ARCA service is unavailable
 	ErrUnavailable = errors.New("arca service unavailable")
 
@@ -41,8 +69,35 @@ var (
 
 	// ErrTimeout indicates the request timed out
 	ErrTimeout = errors.New("request timeout")
+
+	// ErrStuckCall indicates the watchdog cancelled a request that ran past
+	// the stuck call ceiling
+	ErrStuckCall = errors.New("request stuck")
+
+	// ErrNotSupported indicates the backend does not implement the endpoint
+	ErrNotSupported = errors.New("operation not supported by arca backend")
+
+	// ErrResponseTooLarge indicates a response body exceeded the client's
+	// size limit
+	ErrResponseTooLarge = errors.New("response too large")
 )
 
+// ResponseTooLargeError is returned for a response body larger than the
+// client's size limit; it matches ErrResponseTooLarge
+type ResponseTooLargeError struct {
+	Method string
+	Path   string
+	Limit  int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("arca api response to %s %s exceeds %d bytes", e.Method, e.Path, e.Limit)
+}
+
+func (e *ResponseTooLargeError) Unwrap() error {
+	return ErrResponseTooLarge
+}
+
 // APIError represents an error from the ARCA API
 type APIError struct {
 	StatusCode int
@@ -75,7 +130,9 @@ func MapHTTPStatusToError(statusCode int, message string) error {
 	switch statusCode {
 	case 404:
 		// Distinguish between different resource types based on message
-		if containsAny(message, "svm", "storage virtual machine") {
+		if containsAny(message, "export rule", "export not found") {
+			return ErrExportNotFound
+		} else if containsAny(message, "svm", "storage virtual machine") {
 			return ErrSVMNotFound
 		} else if containsAny(message, "directory", "path") {
 			return ErrDirectoryNotFound
@@ -87,7 +144,11 @@ func MapHTTPStatusToError(statusCode int, message string) error {
 		return ErrSVMNotFound // Default to SVM not found
 	case 409:
 		// Distinguish between existence conflicts and network conflicts
-		if containsAny(message, "ip", "vlan", "network") {
+		if containsAny(message, "snapshot") && containsAny(message, "dependent", "in use", "busy") {
+			return ErrSnapshotHasDependents
+		} else if containsAny(message, "export rule", "export already") {
+			return ErrExportAlreadyExists
+		} else if containsAny(message, "ip", "vlan", "network") {
 			return ErrNetworkConflict
 		} else if containsAny(message, "directory") {
 			return ErrDirectoryAlreadyExists
@@ -95,6 +156,8 @@ func MapHTTPStatusToError(statusCode int, message string) error {
 			return ErrSnapshotAlreadyExists
 		}
 		return ErrSVMAlreadyExists // Default to SVM already exists
+	case 405, 501:
+		return ErrNotSupported
 	case 503:
 		return ErrUnavailable
 	default:
@@ -102,19 +165,27 @@ func MapHTTPStatusToError(statusCode int, message string) error {
 	}
 }
 
+// isUnknownRoute reports whether a 404 message is the router's rather than
+// the API's, i.e. the backend has no such endpoint at all
+func isUnknownRoute(message string) bool {
+	return containsAny(message, "page not found", "no route")
+}
+
 // IsNotFoundError checks if an error is a "not found" error
 func IsNotFoundError(err error) bool {
 	return errors.Is(err, ErrSVMNotFound) ||
 		errors.Is(err, ErrDirectoryNotFound) ||
 		errors.Is(err, ErrSnapshotNotFound) ||
-		errors.Is(err, ErrQuotaNotFound)
+		errors.Is(err, ErrQuotaNotFound) ||
+		errors.Is(err, ErrExportNotFound)
 }
 
 // IsAlreadyExistsError checks if an error is an "already exists" error
 func IsAlreadyExistsError(err error) bool {
 	return errors.Is(err, ErrSVMAlreadyExists) ||
 		errors.Is(err, ErrDirectoryAlreadyExists) ||
-		errors.Is(err, ErrSnapshotAlreadyExists)
+		errors.Is(err, ErrSnapshotAlreadyExists) ||
+		errors.Is(err, ErrExportAlreadyExists)
 }
 
 // containsAny checks if s contains any of the substrings
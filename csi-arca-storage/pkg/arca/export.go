@@ -0,0 +1,115 @@
+package arca
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultExportPathTemplate is the NFS export of an SVM; "{svm}" is replaced
+// with the SVM name
+const DefaultExportPathTemplate = "/exports/{svm}"
+
+// ExportPath returns the NFS export of the named SVM: override when the
+// backend reported one, otherwise template (DefaultExportPathTemplate when
+// empty) expanded for the SVM
+func ExportPath(template, svmName, override string) string {
+	if override != "" {
+		return override
+	}
+	if template == "" {
+		template = DefaultExportPathTemplate
+	}
+	return strings.ReplaceAll(template, "{svm}", svmName)
+}
+
+// exportPageSize is the page size used when listing exports
+const exportPageSize = 200
+
+// ListExports lists the NFS export rules of an SVM
+func (c *Client) ListExports(ctx context.Context, svmName string) ([]Export, error) {
+	var exports []Export
+	cursor := ""
+	for {
+		params := url.Values{}
+		params.Set("svm", svmName)
+		params.Set("limit", fmt.Sprintf("%d", exportPageSize))
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		var response struct {
+			Data struct {
+				Items      []Export `json:"items"`
+				NextCursor string   `json:"next_cursor"`
+			} `json:"data"`
+		}
+		if err := c.doRequestDecode(ctx, opSVM, http.MethodGet, "/v1/exports", &response, params); err != nil {
+			return nil, err
+		}
+
+		exports = append(exports, response.Data.Items...)
+		if response.Data.NextCursor == "" {
+			return exports, nil
+		}
+		cursor = response.Data.NextCursor
+	}
+}
+
+// CreateExport adds an NFS export rule (idempotent)
+func (c *Client) CreateExport(ctx context.Context, req *CreateExportRequest) (*Export, error) {
+	respBody, err := c.doRequest(ctx, opSVM, http.MethodPost, "/v1/exports", req)
+	if err != nil {
+		// If the rule already exists, return it
+		if errors.Is(err, ErrExportAlreadyExists) {
+			return c.findExport(ctx, req.SVM, req.Volume, req.Client)
+		}
+		return nil, err
+	}
+
+	var response struct {
+		Data struct {
+			Export Export `json:"export"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response.Data.Export, nil
+}
+
+// DeleteExport removes the NFS export rule of a volume for a client
+// (idempotent)
+func (c *Client) DeleteExport(ctx context.Context, svmName, volume, client string) error {
+	params := url.Values{}
+	params.Set("svm", svmName)
+	if volume != "" {
+		params.Set("volume", volume)
+	}
+	params.Set("client", client)
+
+	_, err := c.doRequest(ctx, opSVM, http.MethodDelete, "/v1/exports", nil, params)
+	if err != nil && !errors.Is(err, ErrExportNotFound) {
+		return err
+	}
+	return nil
+}
+
+// findExport returns the export rule of a volume for a client
+func (c *Client) findExport(ctx context.Context, svmName, volume, client string) (*Export, error) {
+	exports, err := c.ListExports(ctx, svmName)
+	if err != nil {
+		return nil, err
+	}
+	for i := range exports {
+		if exports[i].Volume == volume && exports[i].Client == client {
+			return &exports[i], nil
+		}
+	}
+	return nil, ErrExportNotFound
+}
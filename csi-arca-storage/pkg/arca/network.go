@@ -3,62 +3,335 @@ package arca
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"net"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"k8s.io/klog/v2"
 )
 
 // IPPool represents a pool of IP addresses
 type IPPool struct {
+	Name      string
+	Drained   bool // Drained pools serve existing SVMs but receive no new allocations
 	Network   *net.IPNet
 	VLANID    int
 	Gateway   string
 	FirstHost net.IP
 	LastHost  net.IP
 	NumHosts  int
+
+	// Excluded holds addresses never handed out (infrastructure IPs)
+	Excluded map[string]bool
+	// StaticVIPs pins namespaces to specific addresses (namespace -> IP)
+	StaticVIPs map[string]string
 }
 
+// AllocationStrategy selects how free addresses are chosen from pools
+type AllocationStrategy string
+
+const (
+	// AllocationRoundRobin rotates across pools and takes the first free address
+	AllocationRoundRobin AllocationStrategy = "round-robin"
+
+	// AllocationHash hashes the namespace to a preferred pool and address slot,
+	// probing forward on conflict, so a re-created SVM tends to get the same VIP
+	AllocationHash AllocationStrategy = "hash"
+)
+
+// DefaultConflictHold is how long an address ARCA rejected as a duplicate
+// is skipped by allocations
+const DefaultConflictHold = 10 * time.Minute
+
+// maxConflictHoldFactor caps how far repeated rejections of an address
+// extend its hold
+const maxConflictHoldFactor = 8
+
 // StandaloneAllocator implements network allocation using static IP pools
 type StandaloneAllocator struct {
 	pools       []IPPool
 	poolCounter int32
+	strategy    AllocationStrategy
 	arcaClient  *Client
 	mu          sync.Mutex
+
+	// Addresses ARCA rejected as duplicates, e.g. because a device outside
+	// ARCA holds them; skipped until their hold expires
+	conflictHold time.Duration
+	conflicts    map[string]*conflict
+
+	// affinity restricts the pools of namespaces, nil allows every pool
+	affinity PoolAffinity
+}
+
+// PoolAffinity decides which pools a namespace's SVM may be allocated in,
+// e.g. to keep production namespaces on their own VLANs
+type PoolAffinity interface {
+	// PoolsFor returns the names of the pools the namespace may use
+	PoolsFor(ctx context.Context, namespace string) (map[string]bool, error)
+}
+
+// conflict is an address rejected as a duplicate
+type conflict struct {
+	// count of rejections in a row
+	count int
+	// factor of the hold, doubled by each rejection in a row up to
+	// maxConflictHoldFactor
+	factor int
+	until  time.Time
 }
 
 // PoolConfig represents configuration for a single IP pool
 type PoolConfig struct {
+	Name    string `json:"name"`
+	Drained bool   `json:"drained"`
 	CIDR    string `json:"cidr"`
 	Range   string `json:"range"` // e.g., "192.168.100.10-192.168.100.200"
 	VLANID  int    `json:"vlan"`
 	Gateway string `json:"gateway"`
+
+	// Exclude lists addresses or ranges ("IP1-IP2") that must never be allocated
+	Exclude []string `json:"exclude,omitempty"`
+	// StaticVIPs pins a namespace to a specific address in this pool
+	StaticVIPs map[string]string `json:"static_vips,omitempty"`
 }
 
 // NewStandaloneAllocator creates a new standalone network allocator
 func NewStandaloneAllocator(pools []PoolConfig, arcaClient *Client) (*StandaloneAllocator, error) {
+	ipPools, err := parsePoolConfigs(pools)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StandaloneAllocator{
+		pools:        ipPools,
+		strategy:     AllocationRoundRobin,
+		arcaClient:   arcaClient,
+		conflictHold: DefaultConflictHold,
+		conflicts:    make(map[string]*conflict),
+	}, nil
+}
+
+// SetConflictHold sets how long an address rejected as a duplicate is
+// skipped; 0 keeps DefaultConflictHold
+func (a *StandaloneAllocator) SetConflictHold(hold time.Duration) {
+	if hold <= 0 {
+		hold = DefaultConflictHold
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.conflictHold = hold
+}
+
+// SetPoolAffinity restricts the pools each namespace is allocated in
+func (a *StandaloneAllocator) SetPoolAffinity(affinity PoolAffinity) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.affinity = affinity
+}
+
+// RejectAddress records that ARCA rejected an allocated address (address or
+// CIDR) as a duplicate. Allocations skip it for the conflict hold, doubled
+// for each further rejection before the previous hold has decayed, so an
+// address held by a device outside ARCA is not proposed over and over.
+func (a *StandaloneAllocator) RejectAddress(address string) {
+	ip := address
+	if prefix, _, ok := strings.Cut(address, "/"); ok {
+		ip = prefix
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	c, ok := a.conflicts[ip]
+	// A rejection within one more hold of the last expiry counts as a
+	// repeat; the count decays back to one after that
+	if !ok || now.After(c.until.Add(a.conflictHold)) {
+		c = &conflict{}
+		a.conflicts[ip] = c
+	}
+	c.count++
+	c.factor = min(max(2*c.factor, 1), maxConflictHoldFactor)
+	hold := a.conflictHold * time.Duration(c.factor)
+	c.until = now.Add(hold)
+	klog.Warningf("Address %s was rejected as a duplicate (%d in a row), skipping it for %v", ip, c.count, hold)
+}
+
+// conflictingLocked reports whether an address is held after a rejection
+// (must hold lock)
+func (a *StandaloneAllocator) conflictingLocked(ip string, now time.Time) bool {
+	c, ok := a.conflicts[ip]
+	return ok && now.Before(c.until)
+}
+
+// pruneConflictsLocked forgets conflicts whose count has decayed (must hold
+// lock)
+func (a *StandaloneAllocator) pruneConflictsLocked(now time.Time) {
+	for ip, c := range a.conflicts {
+		if now.After(c.until.Add(a.conflictHold)) {
+			delete(a.conflicts, ip)
+		}
+	}
+}
+
+// SetStrategy sets the address selection strategy
+func (a *StandaloneAllocator) SetStrategy(strategy AllocationStrategy) error {
+	switch strategy {
+	case "":
+		strategy = AllocationRoundRobin
+	case AllocationRoundRobin, AllocationHash:
+	default:
+		return fmt.Errorf("unknown allocation strategy %q", strategy)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.strategy = strategy
+	klog.V(2).Infof("Using %s IP allocation strategy", strategy)
+	return nil
+}
+
+// UpdatePools replaces the configured pools at runtime (e.g. on config reload).
+// Removing a pool only stops new allocations; existing SVMs keep their VIPs.
+func (a *StandaloneAllocator) UpdatePools(pools []PoolConfig) error {
+	ipPools, err := parsePoolConfigs(pools)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pools = ipPools
+	klog.Infof("Updated IP pools: %d pools configured", len(ipPools))
+	return nil
+}
+
+// Pools returns a snapshot of the configured pools
+func (a *StandaloneAllocator) Pools() []IPPool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := make([]IPPool, len(a.pools))
+	copy(result, a.pools)
+	return result
+}
+
+// PoolForSVM returns the name of the pool an SVM was allocated from
+func (a *StandaloneAllocator) PoolForSVM(svm *SVM) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pool := a.findPoolLocked(svm.VLANID, svm.VIP)
+	if pool == nil {
+		return "", false
+	}
+	return pool.Name, true
+}
+
+// GatewayForSVM returns the gateway of the pool an SVM was allocated from,
+// false when the pool is no longer configured
+func (a *StandaloneAllocator) GatewayForSVM(svm *SVM) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pool := a.findPoolLocked(svm.VLANID, svm.VIP)
+	if pool == nil {
+		return "", false
+	}
+	return pool.Gateway, true
+}
+
+// PoolAllocations returns the SVM names currently holding an address in each pool.
+// Draining pools with no remaining allocations can be removed from configuration.
+func (a *StandaloneAllocator) PoolAllocations(ctx context.Context) (map[string][]string, error) {
+	svms, err := a.arcaClient.ListSVMs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SVMs: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := make(map[string][]string, len(a.pools))
+	for _, pool := range a.pools {
+		result[pool.Name] = nil
+	}
+	for i := range svms {
+		if pool := a.findPoolLocked(svms[i].VLANID, svms[i].VIP); pool != nil {
+			result[pool.Name] = append(result[pool.Name], svms[i].Name)
+		}
+	}
+	return result, nil
+}
+
+// findPoolLocked finds the pool containing the given VLAN and address (must hold lock)
+func (a *StandaloneAllocator) findPoolLocked(vlanID int, vip string) *IPPool {
+	ip := net.ParseIP(vip)
+	if ip == nil {
+		return nil
+	}
+	for i := range a.pools {
+		if a.pools[i].VLANID == vlanID && a.pools[i].Network.Contains(ip) {
+			return &a.pools[i]
+		}
+	}
+	return nil
+}
+
+// parsePoolConfigs parses and validates a list of pool configurations
+func parsePoolConfigs(pools []PoolConfig) ([]IPPool, error) {
 	if len(pools) == 0 {
 		return nil, fmt.Errorf("no IP pools configured")
 	}
 
 	ipPools := make([]IPPool, 0, len(pools))
+	names := make(map[string]bool, len(pools))
+	staticOwners := make(map[string]string) // namespace -> pool name
+	staticIPs := make(map[string]string)    // IP -> namespace
+	active := 0
 
 	for i, poolCfg := range pools {
 		pool, err := parsePoolConfig(&poolCfg)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse pool %d: %w", i, err)
 		}
+		if names[pool.Name] {
+			return nil, fmt.Errorf("duplicate pool name %s", pool.Name)
+		}
+		for ns, ip := range pool.StaticVIPs {
+			if other, exists := staticOwners[ns]; exists {
+				return nil, fmt.Errorf("namespace %s has static VIPs in pools %s and %s", ns, other, pool.Name)
+			}
+			if owner, exists := staticIPs[ip]; exists {
+				return nil, fmt.Errorf("static VIP %s assigned to both %s and %s", ip, owner, ns)
+			}
+			staticOwners[ns] = pool.Name
+			staticIPs[ip] = ns
+		}
+		names[pool.Name] = true
+		if !pool.Drained {
+			active++
+		}
 		ipPools = append(ipPools, *pool)
-		klog.V(2).Infof("Loaded IP pool: VLAN %d, network %s, range %s-%s (%d hosts)",
-			pool.VLANID, pool.Network.String(), pool.FirstHost, pool.LastHost, pool.NumHosts)
+		klog.V(2).Infof("Loaded IP pool %s: VLAN %d, network %s, range %s-%s (%d hosts, drained: %v)",
+			pool.Name, pool.VLANID, pool.Network.String(), pool.FirstHost, pool.LastHost, pool.NumHosts, pool.Drained)
 	}
 
-	return &StandaloneAllocator{
-		pools:      ipPools,
-		arcaClient: arcaClient,
-	}, nil
+	if active == 0 {
+		klog.Warning("All IP pools are drained; new SVMs cannot be created")
+	}
+
+	return ipPools, nil
 }
 
 // parsePoolConfig parses pool configuration into IPPool
@@ -70,10 +343,15 @@ func parsePoolConfig(cfg *PoolConfig) (*IPPool, error) {
 	}
 
 	pool := &IPPool{
+		Name:    cfg.Name,
+		Drained: cfg.Drained,
 		Network: network,
 		VLANID:  cfg.VLANID,
 		Gateway: cfg.Gateway,
 	}
+	if pool.Name == "" {
+		pool.Name = fmt.Sprintf("vlan%d-%s", cfg.VLANID, network.String())
+	}
 
 	// Parse range if provided
 	if cfg.Range != "" {
@@ -95,6 +373,44 @@ func parsePoolConfig(cfg *PoolConfig) (*IPPool, error) {
 		return nil, fmt.Errorf("invalid range: first IP must be <= last IP")
 	}
 
+	// Parse exclusions
+	pool.Excluded = make(map[string]bool)
+	for _, entry := range cfg.Exclude {
+		first, last := net.ParseIP(entry).To4(), net.IP(nil)
+		if first != nil {
+			last = first
+		} else {
+			var err error
+			first, last, err = parseIPRange(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclusion %s: %w", entry, err)
+			}
+		}
+		if first == nil || last == nil || ipToUint32(first) > ipToUint32(last) {
+			return nil, fmt.Errorf("invalid exclusion %s", entry)
+		}
+		for n := 0; n <= ipDiff(last, first); n++ {
+			pool.Excluded[incrementIP(first, n).String()] = true
+		}
+	}
+
+	// Validate static VIP assignments against the pool
+	pool.StaticVIPs = make(map[string]string, len(cfg.StaticVIPs))
+	for namespace, vip := range cfg.StaticVIPs {
+		ip := net.ParseIP(vip).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("invalid static VIP %s for namespace %s", vip, namespace)
+		}
+		if !pool.inRange(ip) {
+			return nil, fmt.Errorf("static VIP %s for namespace %s is outside pool range %s-%s",
+				vip, namespace, pool.FirstHost, pool.LastHost)
+		}
+		if pool.Excluded[ip.String()] {
+			return nil, fmt.Errorf("static VIP %s for namespace %s is excluded", vip, namespace)
+		}
+		pool.StaticVIPs[namespace] = ip.String()
+	}
+
 	return pool, nil
 }
 
@@ -126,20 +442,109 @@ func parseIPRange(rangeStr string) (net.IP, net.IP, error) {
 	return firstIP.To4(), lastIP.To4(), nil
 }
 
+// allowedPoolsKey is the context key of the pools an allocation may use
+type allowedPoolsKey struct{}
+
+// WithAllowedPools returns a context whose allocations only use the named
+// pools, e.g. those a node scheduled to use the SVM can reach
+func WithAllowedPools(ctx context.Context, pools map[string]bool) context.Context {
+	return context.WithValue(ctx, allowedPoolsKey{}, pools)
+}
+
+// allowedPools returns the pools allocations of ctx may use; nil allows
+// every pool
+func allowedPools(ctx context.Context) map[string]bool {
+	pools, _ := ctx.Value(allowedPoolsKey{}).(map[string]bool)
+	return pools
+}
+
+// namespacePools returns the pools a namespace may be allocated in: those
+// of its pool affinity that ctx allows; nil allows every pool
+func (a *StandaloneAllocator) namespacePools(ctx context.Context, namespace string) (map[string]bool, error) {
+	a.mu.Lock()
+	affinity := a.affinity
+	a.mu.Unlock()
+
+	allowed := allowedPools(ctx)
+	if affinity == nil {
+		return allowed, nil
+	}
+	pools, err := affinity.PoolsFor(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate pool affinity of namespace %s: %w", namespace, err)
+	}
+	if allowed == nil {
+		return pools, nil
+	}
+	both := make(map[string]bool)
+	for pool := range pools {
+		if allowed[pool] {
+			both[pool] = true
+		}
+	}
+	return both, nil
+}
+
 // Allocate allocates an IP address from pools (round-robin with collision detection)
 func (a *StandaloneAllocator) Allocate(ctx context.Context, namespace string, attempt int) (*NetworkAllocation, error) {
+	allowed, err := a.namespacePools(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// Round-robin pool selection
-	startIdx := int(atomic.LoadInt32(&a.poolCounter)) % len(a.pools)
-	atomic.AddInt32(&a.poolCounter, 1)
+	// Namespaces pinned to a static VIP always receive that address, and
+	// none while its pool is drained
+	for i := range a.pools {
+		if vip, ok := a.pools[i].StaticVIPs[namespace]; ok {
+			if a.pools[i].Drained {
+				return nil, fmt.Errorf("%w: namespace %s is pinned to drained pool %s", ErrNoAllowedPool, namespace, a.pools[i].Name)
+			}
+			if allowed != nil && !allowed[a.pools[i].Name] {
+				return nil, fmt.Errorf("%w: namespace %s is pinned to pool %s", ErrNoAllowedPool, namespace, a.pools[i].Name)
+			}
+			return a.allocateStaticLocked(ctx, &a.pools[i], namespace, vip)
+		}
+	}
+
+	now := time.Now()
+	a.pruneConflictsLocked(now)
+
+	// Addresses pinned to other namespaces are never handed out dynamically
+	reserved := make(map[string]bool)
+	for i := range a.pools {
+		for _, vip := range a.pools[i].StaticVIPs {
+			reserved[vip] = true
+		}
+	}
+
+	// Pool selection: hashed preference or round-robin
+	var nsHash uint64
+	var startIdx int
+	if a.strategy == AllocationHash {
+		nsHash = hashNamespace(namespace)
+		startIdx = int(nsHash % uint64(len(a.pools)))
+	} else {
+		startIdx = int(atomic.LoadInt32(&a.poolCounter)) % len(a.pools)
+		atomic.AddInt32(&a.poolCounter, 1)
+	}
 
 	for i := 0; i < len(a.pools); i++ {
 		poolIdx := (startIdx + i) % len(a.pools)
 		pool := a.pools[poolIdx]
 
-		klog.V(4).Infof("Attempting allocation from pool %d (VLAN %d), attempt %d", poolIdx, pool.VLANID, attempt)
+		if pool.Drained {
+			klog.V(4).Infof("Skipping drained pool %s", pool.Name)
+			continue
+		}
+		if allowed != nil && !allowed[pool.Name] {
+			klog.V(4).Infof("Skipping pool %s, not allowed for namespace %s", pool.Name, namespace)
+			continue
+		}
+
+		klog.V(4).Infof("Attempting allocation from pool %s (VLAN %d), attempt %d", pool.Name, pool.VLANID, attempt)
 
 		// Get used IPs in this VLAN
 		usedIPs, err := a.getUsedIPsInVLAN(ctx, pool.VLANID)
@@ -148,33 +553,77 @@ func (a *StandaloneAllocator) Allocate(ctx context.Context, namespace string, at
 			continue
 		}
 
-		// Find first free IP (with random offset on retry for collision avoidance)
+		// Find first free IP. The hash strategy starts at the namespace's preferred
+		// slot and skips ahead on retries; round-robin uses a random offset on retry
+		// for collision avoidance.
 		offset := 0
-		if attempt > 0 {
+		if a.strategy == AllocationHash {
+			offset = int((nsHash + uint64(attempt)) % uint64(pool.NumHosts))
+		} else if attempt > 0 {
 			offset = rand.Intn(pool.NumHosts)
 		}
 
 		for j := 0; j < pool.NumHosts; j++ {
 			ip := incrementIP(pool.FirstHost, (offset+j)%pool.NumHosts)
-			if !usedIPs[ip.String()] {
+			ipStr := ip.String()
+			if pool.Excluded[ipStr] || reserved[ipStr] {
+				continue
+			}
+			if a.conflictingLocked(ipStr, now) {
+				klog.V(4).Infof("Skipping %s, recently rejected as a duplicate", ipStr)
+				continue
+			}
+			if !usedIPs[ipStr] {
 				// Found free IP
 				ones, _ := pool.Network.Mask.Size()
 				allocation := &NetworkAllocation{
-					VLANID:  pool.VLANID,
-					IPCIDR:  fmt.Sprintf("%s/%d", ip.String(), ones),
-					Gateway: pool.Gateway,
+					VLANID:   pool.VLANID,
+					IPCIDR:   fmt.Sprintf("%s/%d", ip.String(), ones),
+					Gateway:  pool.Gateway,
+					PoolName: pool.Name,
 				}
-				klog.V(2).Infof("Allocated IP %s from VLAN %d for namespace %s", allocation.IPCIDR, pool.VLANID, namespace)
+				klog.V(2).Infof("Allocated IP %s from pool %s (VLAN %d) for namespace %s", allocation.IPCIDR, pool.Name, pool.VLANID, namespace)
 				return allocation, nil
 			}
 		}
 
-		klog.V(4).Infof("Pool %d (VLAN %d) exhausted", poolIdx, pool.VLANID)
+		klog.V(4).Infof("Pool %s (VLAN %d) exhausted", pool.Name, pool.VLANID)
 	}
 
+	if allowed != nil {
+		return nil, fmt.Errorf("%w for namespace %s", ErrNoAllowedPool, namespace)
+	}
 	return nil, ErrAllPoolsExhausted
 }
 
+// allocateStaticLocked returns the static VIP pinned to a namespace (must hold lock)
+func (a *StandaloneAllocator) allocateStaticLocked(ctx context.Context, pool *IPPool, namespace, vip string) (*NetworkAllocation, error) {
+	usedIPs, err := a.getUsedIPsInVLAN(ctx, pool.VLANID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get used IPs for VLAN %d: %w", pool.VLANID, err)
+	}
+	if usedIPs[vip] {
+		return nil, fmt.Errorf("%w: %s (namespace %s)", ErrStaticVIPInUse, vip, namespace)
+	}
+
+	ones, _ := pool.Network.Mask.Size()
+	allocation := &NetworkAllocation{
+		VLANID:   pool.VLANID,
+		IPCIDR:   fmt.Sprintf("%s/%d", vip, ones),
+		Gateway:  pool.Gateway,
+		PoolName: pool.Name,
+		Static:   true,
+	}
+	klog.V(2).Infof("Allocated static IP %s from pool %s (VLAN %d) for namespace %s", allocation.IPCIDR, pool.Name, pool.VLANID, namespace)
+	return allocation, nil
+}
+
+// inRange checks if an address lies within the pool's allocatable range
+func (p *IPPool) inRange(ip net.IP) bool {
+	v := ipToUint32(ip)
+	return v >= ipToUint32(p.FirstHost) && v <= ipToUint32(p.LastHost)
+}
+
 // getUsedIPsInVLAN queries ARCA API to get used IPs in a VLAN
 func (a *StandaloneAllocator) getUsedIPsInVLAN(ctx context.Context, vlanID int) (map[string]bool, error) {
 	svms, err := a.arcaClient.ListSVMs(ctx)
@@ -209,6 +658,19 @@ func incrementIP(ip net.IP, n int) net.IP {
 	return result
 }
 
+// hashNamespace returns a stable hash of a namespace name
+func hashNamespace(namespace string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(namespace))
+	return h.Sum64()
+}
+
+// ipToUint32 converts an IPv4 address to its integer representation
+func ipToUint32(ip net.IP) uint32 {
+	ip = ip.To4()
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+}
+
 // ipDiff calculates the difference between two IPs
 func ipDiff(ip1, ip2 net.IP) int {
 	ipUint1 := uint32(ip1[0])<<24 | uint32(ip1[1])<<16 | uint32(ip1[2])<<8 | uint32(ip1[3])
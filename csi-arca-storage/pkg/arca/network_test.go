@@ -0,0 +1,97 @@
+package arca_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/arca/arcatest"
+)
+
+// newTestAllocator returns an allocator of pools on an in-memory backend
+func newTestAllocator(t *testing.T, pools ...arca.PoolConfig) *arca.StandaloneAllocator {
+	t.Helper()
+
+	server := arcatest.NewServer()
+	t.Cleanup(server.Close)
+
+	client, err := arca.NewClient(&arca.ClientConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	allocator, err := arca.NewStandaloneAllocator(pools, client)
+	if err != nil {
+		t.Fatalf("NewStandaloneAllocator: %v", err)
+	}
+	return allocator
+}
+
+// staticPool is a pool of three addresses: one excluded, one pinned to
+// namespace team-a and one free
+func staticPool(drained bool) arca.PoolConfig {
+	return arca.PoolConfig{
+		Name:       "pool-a",
+		Drained:    drained,
+		CIDR:       "192.0.2.0/24",
+		Range:      "192.0.2.10-192.0.2.12",
+		VLANID:     100,
+		Gateway:    "192.0.2.1",
+		Exclude:    []string{"192.0.2.10"},
+		StaticVIPs: map[string]string{"team-a": "192.0.2.11"},
+	}
+}
+
+func TestAllocateStaticVIP(t *testing.T) {
+	a := newTestAllocator(t, staticPool(false))
+
+	alloc, err := a.Allocate(context.Background(), "team-a", 0)
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if alloc.IPCIDR != "192.0.2.11/24" || !alloc.Static {
+		t.Errorf("Allocate = %s (static %t), want static 192.0.2.11/24", alloc.IPCIDR, alloc.Static)
+	}
+
+	// Excluded and pinned addresses are never allocated dynamically
+	alloc, err = a.Allocate(context.Background(), "team-b", 0)
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if alloc.IPCIDR != "192.0.2.12/24" || alloc.Static {
+		t.Errorf("Allocate = %s (static %t), want dynamic 192.0.2.12/24", alloc.IPCIDR, alloc.Static)
+	}
+}
+
+func TestAllocateStaticVIPDrainedPool(t *testing.T) {
+	fallback := arca.PoolConfig{Name: "pool-b", CIDR: "198.51.100.0/24", VLANID: 101, Gateway: "198.51.100.1"}
+	a := newTestAllocator(t, staticPool(true), fallback)
+
+	// A pinned namespace gets neither its VIP nor an address of another pool
+	if _, err := a.Allocate(context.Background(), "team-a", 0); !errors.Is(err, arca.ErrNoAllowedPool) {
+		t.Errorf("Allocate in a drained pool = %v, want ErrNoAllowedPool", err)
+	}
+}
+
+func TestRejectAddressRepeatedly(t *testing.T) {
+	a := newTestAllocator(t, arca.PoolConfig{
+		Name:    "pool-a",
+		CIDR:    "192.0.2.0/24",
+		Range:   "192.0.2.10-192.0.2.11",
+		VLANID:  100,
+		Gateway: "192.0.2.1",
+	})
+
+	// A device holding the address out of band is rejected every hold
+	// period; the hold stays at its cap however often that happens
+	for range 70 {
+		a.RejectAddress("192.0.2.10/24")
+	}
+	alloc, err := a.Allocate(context.Background(), "team-a", 0)
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if alloc.IPCIDR != "192.0.2.11/24" {
+		t.Errorf("Allocate = %s, want 192.0.2.11/24 (192.0.2.10 is held)", alloc.IPCIDR)
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 // SetQuota sets XFS project quota for a directory
 func (c *Client) SetQuota(ctx context.Context, req *SetQuotaRequest) error {
-	_, err := c.doRequest(ctx, http.MethodPost, "/v1/quotas", req)
+	_, err := c.doRequest(ctx, opQuota, http.MethodPost, "/v1/quotas", req)
 	return err
 }
 
@@ -19,7 +19,7 @@ func (c *Client) GetQuota(ctx context.Context, svmName, path string) (*QuotaInfo
 	params := url.Values{}
 	params.Set("path", path)
 
-	respBody, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/v1/quotas/%s", svmName), nil, params)
+	respBody, err := c.doRequest(ctx, opQuota, http.MethodGet, fmt.Sprintf("/v1/quotas/%s", svmName), nil, params)
 	if err != nil {
 		return nil, err
 	}
@@ -34,8 +34,28 @@ func (c *Client) GetQuota(ctx context.Context, svmName, path string) (*QuotaInfo
 	return &response.Data, nil
 }
 
+// GetEfficiency gets deduplication and compression statistics for a path
+func (c *Client) GetEfficiency(ctx context.Context, svmName, path string) (*EfficiencyInfo, error) {
+	params := url.Values{}
+	params.Set("path", path)
+
+	respBody, err := c.doRequest(ctx, opQuota, http.MethodGet, fmt.Sprintf("/v1/efficiency/%s", svmName), nil, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data EfficiencyInfo `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response.Data, nil
+}
+
 // ExpandQuota expands existing quota
 func (c *Client) ExpandQuota(ctx context.Context, req *ExpandQuotaRequest) error {
-	_, err := c.doRequest(ctx, http.MethodPatch, "/v1/quotas", req)
+	_, err := c.doRequest(ctx, opQuota, http.MethodPatch, "/v1/quotas", req)
 	return err
 }
@@ -0,0 +1,83 @@
+package arca
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"slices"
+)
+
+// Failure classes a RetryPolicy can retry. Errors that cannot succeed on
+// retry (4xx other than 408 and 429, not found, already exists) are never
+// retried.
+const (
+	// RetryUnavailable covers 5xx responses (e.g. 503 during failover) and
+	// 408 and 429
+	RetryUnavailable = "unavailable"
+	// RetryTimeout covers requests that exceeded their per-request timeout
+	// or were cancelled by the stuck call watchdog
+	RetryTimeout = "timeout"
+	// RetryNetwork covers connection and TLS failures
+	RetryNetwork = "network"
+)
+
+// RetryClasses are the failure classes a RetryPolicy accepts
+var RetryClasses = []string{RetryUnavailable, RetryTimeout, RetryNetwork}
+
+// RetryPolicy decides how often and for which failures a call retries an
+// API request before returning the error, leaving further retries to the
+// kubelet or CSI sidecar that made the RPC. A negative Retries or nil
+// RetryOn keeps the client's setting.
+type RetryPolicy struct {
+	// Retries is the number of retries after the first attempt
+	Retries int
+	// RetryOn lists the failure classes retried
+	RetryOn []string
+}
+
+// retryPolicyKey is the context key of a RetryPolicy
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a context whose API calls use policy instead of
+// the client's retry settings
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// retryPolicyFor returns the client's retry settings, overridden by the
+// policy of ctx
+func (c *Client) retryPolicyFor(ctx context.Context) RetryPolicy {
+	policy := c.retry
+	if override, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		if override.Retries >= 0 {
+			policy.Retries = override.Retries
+		}
+		if override.RetryOn != nil {
+			policy.RetryOn = override.RetryOn
+		}
+	}
+	return policy
+}
+
+// retries reports whether the policy retries a request that failed with err
+func (p RetryPolicy) retries(err error) bool {
+	if isNonRetryableError(err) {
+		return false
+	}
+	return slices.Contains(p.RetryOn, failureClass(err))
+}
+
+// failureClass returns the RetryPolicy class of a retryable error
+func failureClass(err error) string {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrTimeout) || errors.Is(err, ErrStuckCall) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return RetryTimeout
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return RetryNetwork
+	}
+	return RetryUnavailable
+}
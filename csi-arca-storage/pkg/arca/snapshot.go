@@ -2,16 +2,21 @@ package arca
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
+
+	"k8s.io/klog/v2"
 )
 
 // CreateSnapshot creates a snapshot via ARCA API (server-side reflink, idempotent)
 func (c *Client) CreateSnapshot(ctx context.Context, req *CreateSnapshotRequest) error {
-	_, err := c.doRequest(ctx, http.MethodPost, "/v1/snapshots", req)
+	_, err := c.doRequest(ctx, opSnapshot, http.MethodPost, "/v1/snapshots", req)
 	if err != nil {
-		if err == ErrSnapshotAlreadyExists {
+		if errors.Is(err, ErrSnapshotAlreadyExists) {
 			return nil // Idempotent
 		}
 		return err
@@ -19,14 +24,68 @@ func (c *Client) CreateSnapshot(ctx context.Context, req *CreateSnapshotRequest)
 	return nil
 }
 
+// GetSnapshot returns the backend state of a snapshot. Backends without the
+// endpoint return ErrNotSupported; a missing snapshot is ErrSnapshotNotFound.
+func (c *Client) GetSnapshot(ctx context.Context, svmName, snapshotPath string) (*SnapshotInfo, error) {
+	params := url.Values{}
+	params.Set("path", snapshotPath)
+
+	respBody, err := c.doRequest(withOptionalEndpoint(ctx, nil), opSnapshot, http.MethodGet, fmt.Sprintf("/v1/snapshots/%s", svmName), nil, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data SnapshotInfo `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &response.Data, nil
+}
+
+// snapshotPageSize is the page size used when listing snapshots
+const snapshotPageSize = 200
+
+// ListSnapshots lists the snapshots of an SVM
+func (c *Client) ListSnapshots(ctx context.Context, svmName string) ([]SnapshotInfo, error) {
+	var snapshots []SnapshotInfo
+	cursor := ""
+	for {
+		params := url.Values{}
+		params.Set("svm", svmName)
+		params.Set("limit", fmt.Sprintf("%d", snapshotPageSize))
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		var response struct {
+			Data struct {
+				Items      []SnapshotInfo `json:"items"`
+				NextCursor string         `json:"next_cursor"`
+			} `json:"data"`
+		}
+		if err := c.doRequestDecode(ctx, opSnapshot, http.MethodGet, "/v1/snapshots", &response, params); err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, response.Data.Items...)
+		if response.Data.NextCursor == "" {
+			return snapshots, nil
+		}
+		cursor = response.Data.NextCursor
+	}
+}
+
 // DeleteSnapshot deletes a snapshot via ARCA API (idempotent)
 func (c *Client) DeleteSnapshot(ctx context.Context, svmName, snapshotPath string) error {
 	params := url.Values{}
 	params.Set("path", snapshotPath)
 
-	_, err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/v1/snapshots/%s", svmName), nil, params)
+	_, err := c.doRequest(ctx, opSnapshot, http.MethodDelete, fmt.Sprintf("/v1/snapshots/%s", svmName), nil, params)
 	if err != nil {
-		if err == ErrSnapshotNotFound {
+		if errors.Is(err, ErrSnapshotNotFound) {
 			return nil // Idempotent
 		}
 		return err
@@ -34,8 +93,34 @@ func (c *Client) DeleteSnapshot(ctx context.Context, svmName, snapshotPath strin
 	return nil
 }
 
-// RestoreSnapshot restores a volume from snapshot (reflink clone)
+// restoreUnsupportedTTL is how long restores skip the restore endpoint
+// once every ARCA endpoint lacked it
+var restoreUnsupportedTTL = 10 * time.Minute
+
+// RestoreSnapshot restores a volume from snapshot (reflink clone,
+// idempotent). Backends without the restore endpoint get a CreateSnapshot of
+// the snapshot path instead, which they treat the same way. Endpoints found
+// lacking it are remembered for restoreUnsupportedTTL; while all of them
+// are, restores go straight to the fallback.
 func (c *Client) RestoreSnapshot(ctx context.Context, req *RestoreSnapshotRequest) error {
-	_, err := c.doRequest(ctx, http.MethodPost, "/v1/snapshots/restore", req)
-	return err
+	if !c.restoreUnsupported.all(c.endpoints.baseURLs()) {
+		restoreCtx := withOptionalEndpoint(ctx, func(baseURL string) {
+			if c.restoreUnsupported.set(baseURL, restoreUnsupportedTTL) {
+				klog.Infof("ARCA endpoint %s does not support snapshot restore, falling back to snapshot creation", baseURL)
+			}
+		})
+		_, err := c.doRequest(restoreCtx, opSnapshot, http.MethodPost, "/v1/snapshots/restore", req)
+		if !errors.Is(err, ErrNotSupported) {
+			if IsAlreadyExistsError(err) {
+				return nil // Idempotent
+			}
+			return err
+		}
+	}
+
+	return c.CreateSnapshot(ctx, &CreateSnapshotRequest{
+		SVMName:      req.SVMName,
+		SourcePath:   req.SnapshotPath,
+		SnapshotPath: req.TargetPath,
+	})
 }
@@ -0,0 +1,146 @@
+package arca_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/arca/arcatest"
+)
+
+const (
+	testSVM      = "k8s-restore"
+	testSnapshot = "vol-1/.snapshot/snap-1"
+)
+
+// restorePath is the path of restore requests on the ARCA API
+const restorePath = "POST /v1/snapshots/restore"
+
+// newRestoreClient returns a client of servers, the first of them being
+// its primary endpoint
+func newRestoreClient(t *testing.T, servers ...*arcatest.Server) *arca.Client {
+	t.Helper()
+
+	config := &arca.ClientConfig{BaseURL: servers[0].URL, RetryCount: -1}
+	for _, srv := range servers[1:] {
+		config.Endpoints = append(config.Endpoints, srv.URL)
+	}
+	client, err := arca.NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+// newRestoreServer starts a server holding a volume and a snapshot of it
+func newRestoreServer(t *testing.T) *arcatest.Server {
+	t.Helper()
+
+	srv := arcatest.NewServer()
+	t.Cleanup(srv.Close)
+
+	client := newRestoreClient(t, srv)
+	ctx := context.Background()
+	if _, err := client.CreateSVM(ctx, &arca.CreateSVMRequest{Name: testSVM, VLANID: 100, IPCIDR: "192.0.2.10/24"}); err != nil {
+		t.Fatalf("CreateSVM: %v", err)
+	}
+	if err := client.CreateDirectory(ctx, &arca.CreateDirectoryRequest{SVMName: testSVM, Path: "vol-1", QuotaBytes: 1 << 30}); err != nil {
+		t.Fatalf("CreateDirectory: %v", err)
+	}
+	if err := client.CreateSnapshot(ctx, &arca.CreateSnapshotRequest{SVMName: testSVM, SourcePath: "vol-1", SnapshotPath: testSnapshot}); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	return srv
+}
+
+// restore restores the test snapshot to target
+func restore(t *testing.T, client *arca.Client, target string) {
+	t.Helper()
+
+	err := client.RestoreSnapshot(context.Background(), &arca.RestoreSnapshotRequest{
+		SVMName:      testSVM,
+		SnapshotPath: testSnapshot,
+		TargetPath:   target,
+	})
+	if err != nil {
+		t.Fatalf("RestoreSnapshot to %s: %v", target, err)
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	srv := newRestoreServer(t)
+	client := newRestoreClient(t, srv)
+
+	restore(t, client, "vol-2")
+	// Retries find the volume in place
+	restore(t, client, "vol-2")
+
+	if !srv.Exists(testSVM, "vol-2") {
+		t.Fatal("restored volume vol-2 does not exist")
+	}
+	mutations := srv.Mutations()
+	if mutations[arcatest.RestoreSnapshot] != 1 {
+		t.Errorf("RestoreSnapshot mutations = %d, want 1", mutations[arcatest.RestoreSnapshot])
+	}
+	// The fixture's snapshot is the only one created
+	if mutations[arcatest.CreateSnapshot] != 1 {
+		t.Errorf("CreateSnapshot mutations = %d, want 1", mutations[arcatest.CreateSnapshot])
+	}
+}
+
+func TestRestoreSnapshotFallback(t *testing.T) {
+	srv := newRestoreServer(t)
+	srv.SetRestoreSupported(false)
+	client := newRestoreClient(t, srv)
+
+	restore(t, client, "vol-2")
+	restore(t, client, "vol-2")
+	restore(t, client, "vol-3")
+
+	for _, target := range []string{"vol-2", "vol-3"} {
+		if !srv.Exists(testSVM, target) {
+			t.Errorf("restored volume %s does not exist", target)
+		}
+	}
+	// Only the first restore asks the endpoint; later ones remember it
+	// lacks the restore endpoint
+	if n := srv.Requests()[restorePath]; n != 1 {
+		t.Errorf("restore requests = %d, want 1", n)
+	}
+	if n := srv.Mutations()[arcatest.CreateSnapshot]; n != 3 {
+		t.Errorf("CreateSnapshot mutations = %d, want 3 (fixture and two fallbacks)", n)
+	}
+}
+
+func TestRestoreSnapshotFallbackPerEndpoint(t *testing.T) {
+	srv := newRestoreServer(t)
+	srv.SetRestoreSupported(false)
+	// A second endpoint that has the restore endpoint keeps restores
+	// asking for it
+	other := arcatest.NewServer()
+	t.Cleanup(other.Close)
+	client := newRestoreClient(t, srv, other)
+
+	restore(t, client, "vol-2")
+	restore(t, client, "vol-3")
+
+	if n := srv.Requests()[restorePath]; n != 2 {
+		t.Errorf("restore requests = %d, want 2", n)
+	}
+}
+
+func TestUnknownRouteOutsideRestore(t *testing.T) {
+	srv := newRestoreServer(t)
+	srv.SetRestoreSupported(false)
+	client := newRestoreClient(t, srv)
+
+	// Plain requests keep mapping 404s to missing resources
+	_, err := client.Do(context.Background(), "POST", "/v1/snapshots/restore", nil, []byte("{}"))
+	if errors.Is(err, arca.ErrNotSupported) {
+		t.Fatalf("Do on a missing route = %v, want no ErrNotSupported", err)
+	}
+	if !arca.IsNotFoundError(err) {
+		t.Fatalf("Do on a missing route = %v, want a not found error", err)
+	}
+}
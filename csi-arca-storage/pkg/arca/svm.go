@@ -4,19 +4,97 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/sync/singleflight"
 	"k8s.io/klog/v2"
 
 	"github.com/akam1o/csi-arca-storage/pkg/lock"
 )
 
+// SVMNamePrefix marks SVMs created by the driver
+const SVMNamePrefix = "k8s-"
+
+// SVMNameForNamespace returns the name of the SVM of a namespace
+func SVMNameForNamespace(namespace string) string {
+	return SVMNamePrefix + namespace
+}
+
+// NamespaceFilter decides whether a namespace may trigger SVM creation
+type NamespaceFilter interface {
+	AllowSVMCreation(ctx context.Context, namespace string) (bool, string, error)
+}
+
+// SVMObserver is told about the lifecycle of the SVMs a manager creates and
+// deletes, e.g. to record it as Kubernetes Events
+type SVMObserver interface {
+	// SVMCreated is called after the SVM of namespace was created on the
+	// network allocation
+	SVMCreated(namespace string, svm *SVM, alloc *NetworkAllocation)
+	// SVMCreateFailed is called when the SVM of namespace could not be
+	// created, including when its creation is denied
+	SVMCreateFailed(namespace string, err error)
+	// SVMDeleted is called after the SVM of namespace was deleted
+	SVMDeleted(namespace, svmName string)
+}
+
+// UnhealthyAction selects what EnsureSVM does with an SVM in the error or
+// an unknown state
+type UnhealthyAction string
+
+const (
+	// UnhealthyFail fails with ErrSVMUnhealthy (default)
+	UnhealthyFail UnhealthyAction = "fail"
+	// UnhealthyRepair asks the backend to repair the SVM once and waits for
+	// it to become available
+	UnhealthyRepair UnhealthyAction = "repair"
+	// UnhealthyIgnore returns the SVM whatever its state
+	UnhealthyIgnore UnhealthyAction = "ignore"
+)
+
+// DefaultSVMReadyTimeout is how long EnsureSVM waits for an SVM that is
+// being created or repaired
+const DefaultSVMReadyTimeout = 2 * time.Minute
+
+// svmCreateTimeout bounds the creation of an SVM shared by concurrent
+// EnsureSVM calls, covering the namespace lock and the retries of network
+// conflicts
+const svmCreateTimeout = 2 * time.Minute
+
+// Poll intervals of waiting for an SVM to become available
+const (
+	svmPollInitial = 500 * time.Millisecond
+	svmPollMax     = 5 * time.Second
+)
+
+// OperationRunner runs the creation of a namespace's SVM, e.g. recording it
+// so that a restarted controller resumes it
+type OperationRunner func(ctx context.Context, namespace string, create func(context.Context) error) error
+
 // SVMManager manages SVM lifecycle operations
 type SVMManager struct {
 	client    *Client
 	allocator *StandaloneAllocator
 	lockMgr   *lock.Manager
 	mtu       int
+	nsFilter  NamespaceFilter
+	cache     *svmCache
+	runCreate OperationRunner
+	creating  singleflight.Group
+	observer  SVMObserver
+
+	unhealthyAction UnhealthyAction
+	readyTimeout    time.Duration
+
+	// exportClients are the only clients new SVMs export to; exportsPending
+	// holds SVMs created since whose exports are not scoped yet
+	exportClients  []netip.Prefix
+	mu             sync.Mutex
+	exportsPending map[string]bool
 }
 
 // NewSVMManager creates a new SVM manager
@@ -30,26 +108,234 @@ func NewSVMManager(client *Client, allocator *StandaloneAllocator, lockMgr *lock
 		allocator: allocator,
 		lockMgr:   lockMgr,
 		mtu:       mtu,
+		cache:     newSVMCache(DefaultSVMCacheTTL),
+
+		unhealthyAction: UnhealthyFail,
+		readyTimeout:    DefaultSVMReadyTimeout,
+
+		exportsPending: make(map[string]bool),
+	}
+}
+
+// SetNamespaceFilter restricts which namespaces may trigger SVM creation
+func (m *SVMManager) SetNamespaceFilter(filter NamespaceFilter) {
+	m.nsFilter = filter
+}
+
+// SetCacheTTL sets how long EnsureSVM reuses a known SVM without asking the
+// ARCA API (DefaultSVMCacheTTL when ttl is not positive)
+func (m *SVMManager) SetCacheTTL(ttl time.Duration) {
+	m.cache = newSVMCache(ttl)
+}
+
+// SetExportClients makes new SVMs export their root read-write to exactly
+// these addresses or CIDRs, replacing the backend's default export rules
+func (m *SVMManager) SetExportClients(clients []string) error {
+	prefixes := make([]netip.Prefix, 0, len(clients))
+	for _, c := range clients {
+		prefix, err := parseExportClient(c)
+		if err != nil {
+			return err
+		}
+		prefixes = append(prefixes, prefix)
 	}
+	m.exportClients = prefixes
+	return nil
+}
+
+// SetOperationRunner runs SVM creations through run
+func (m *SVMManager) SetOperationRunner(run OperationRunner) {
+	m.runCreate = run
+}
+
+// SetHealthPolicy sets what EnsureSVM does with an SVM in the error state
+// and how long it waits for one becoming available (DefaultSVMReadyTimeout
+// when timeout is not positive)
+func (m *SVMManager) SetHealthPolicy(action UnhealthyAction, timeout time.Duration) error {
+	switch action {
+	case "":
+		action = UnhealthyFail
+	case UnhealthyFail, UnhealthyRepair, UnhealthyIgnore:
+	default:
+		return fmt.Errorf("unknown unhealthy SVM action %q", action)
+	}
+	if timeout <= 0 {
+		timeout = DefaultSVMReadyTimeout
+	}
+	m.unhealthyAction = action
+	m.readyTimeout = timeout
+	return nil
+}
+
+// SetObserver sets the observer of the SVMs created and deleted
+func (m *SVMManager) SetObserver(observer SVMObserver) {
+	m.observer = observer
+}
+
+// InvalidateSVM makes the next EnsureSVM for the named SVM ask the ARCA API,
+// for callers that found it missing
+func (m *SVMManager) InvalidateSVM(svmName string) {
+	m.cache.invalidate(svmName)
 }
 
 // EnsureSVM ensures an SVM exists for the given namespace (idempotent)
 func (m *SVMManager) EnsureSVM(ctx context.Context, namespace string) (*SVM, error) {
-	svmName := fmt.Sprintf("k8s-%s", namespace)
+	svm, err := m.ensureSVM(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if svm, err = m.awaitAvailable(ctx, svm); err != nil {
+		return nil, err
+	}
 
-	// Try to get existing SVM first (fast path)
-	svm, err := m.client.GetSVM(ctx, svmName)
+	// Scope the exports of an SVM this manager created, until it succeeds
+	m.mu.Lock()
+	pending := m.exportsPending[svm.Name]
+	m.mu.Unlock()
+	if pending {
+		if err := m.scopeExports(ctx, svm.Name); err != nil {
+			return nil, err
+		}
+	}
+	return svm, nil
+}
+
+// awaitAvailable returns svm once it is available. An SVM being created is
+// waited for; one being deleted fails with ErrSVMNotReady, so the retry
+// creates a new one once it is gone; one in the error or an unknown state
+// is handled as configured. Backends that report no state are trusted.
+func (m *SVMManager) awaitAvailable(ctx context.Context, svm *SVM) (*SVM, error) {
+	if m.unhealthyAction == UnhealthyIgnore {
+		return svm, nil
+	}
+
+	name := svm.Name
+	deadline := time.Now().Add(m.readyTimeout)
+	interval := svmPollInitial
+	repaired := false
+	for {
+		switch svm.State {
+		case "", SVMStateAvailable:
+			return svm, nil
+		case SVMStateDeleting:
+			m.cache.invalidate(name)
+			return nil, fmt.Errorf("%w: SVM %s is being deleted", ErrSVMNotReady, name)
+		case SVMStateCreating:
+			klog.V(4).Infof("SVM %s is still being created", name)
+		default:
+			if m.unhealthyAction != UnhealthyRepair {
+				m.cache.invalidate(name)
+				return nil, fmt.Errorf("%w: SVM %s is in state %q, repair it on the backend", ErrSVMUnhealthy, name, svm.State)
+			}
+			if !repaired {
+				klog.Warningf("SVM %s is in state %q, asking the backend to repair it", name, svm.State)
+				repairedSVM, err := m.client.RepairSVM(ctx, name)
+				if err != nil {
+					m.cache.invalidate(name)
+					return nil, fmt.Errorf("%w: SVM %s is in state %q and could not be repaired: %v", ErrSVMUnhealthy, name, svm.State, err)
+				}
+				repaired = true
+				svm = repairedSVM
+				continue
+			}
+		}
+
+		if !time.Now().Before(deadline) {
+			m.cache.invalidate(name)
+			if svm.State == SVMStateCreating {
+				return nil, fmt.Errorf("%w: SVM %s is still being created after %v", ErrSVMNotReady, name, m.readyTimeout)
+			}
+			return nil, fmt.Errorf("%w: SVM %s is still in state %q %v after the backend was asked to repair it", ErrSVMUnhealthy, name, svm.State, m.readyTimeout)
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		interval = min(interval*2, svmPollMax)
+
+		var err error
+		if svm, err = m.getSVM(ctx, name); err != nil {
+			return nil, fmt.Errorf("failed to check state of SVM %s: %w", name, err)
+		}
+	}
+}
+
+// ensureSVM returns the namespace's SVM, creating it when missing
+func (m *SVMManager) ensureSVM(ctx context.Context, namespace string) (*SVM, error) {
+	svmName := SVMNameForNamespace(namespace)
+
+	// An SVM seen recently still exists (fast path)
+	if svm, ok := m.cache.get(svmName); ok {
+		klog.V(5).Infof("SVM %s found in cache (VIP: %s)", svmName, svm.VIP)
+		return svm, nil
+	}
+
+	// Try to get existing SVM first
+	svm, err := m.getSVM(ctx, svmName)
 	if err == nil {
 		klog.V(4).Infof("SVM %s already exists (VIP: %s)", svmName, svm.VIP)
 		return svm, nil
 	}
 
-	if err != nil && err != ErrSVMNotFound {
+	if err != nil && !errors.Is(err, ErrSVMNotFound) {
 		return nil, fmt.Errorf("failed to check existing SVM: %w", err)
 	}
 
-	// SVM doesn't exist - need to create it with lock
-	return m.createSVMWithLock(ctx, namespace, svmName)
+	// SVM doesn't exist - check the namespace may create one
+	if m.nsFilter != nil {
+		allowed, reason, err := m.nsFilter.AllowSVMCreation(ctx, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check SVM creation policy: %w", err)
+		}
+		if !allowed {
+			err := fmt.Errorf("%w: %s", ErrSVMCreationDenied, reason)
+			if m.observer != nil {
+				m.observer.SVMCreateFailed(namespace, err)
+			}
+			return nil, err
+		}
+	}
+
+	// Need to create it with lock. The lock keeps other controllers out, but
+	// a holder renewing it acquires it again, so concurrent calls of this
+	// controller share a single creation. The creation outlives the call
+	// that started it: another call waiting for it must not fail because
+	// the first one was cancelled.
+	ch := m.creating.DoChan(namespace, func() (interface{}, error) {
+		createCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), svmCreateTimeout)
+		defer cancel()
+
+		svm, err := m.runCreateSVM(createCtx, namespace, svmName)
+		if err != nil && m.observer != nil && createCtx.Err() == nil {
+			m.observer.SVMCreateFailed(namespace, err)
+		}
+		return svm, err
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*SVM), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runCreateSVM creates the SVM of a namespace through the operation runner
+// when one is set
+func (m *SVMManager) runCreateSVM(ctx context.Context, namespace, svmName string) (*SVM, error) {
+	if m.runCreate == nil {
+		return m.createSVMWithLock(ctx, namespace, svmName)
+	}
+	var svm *SVM
+	err := m.runCreate(ctx, namespace, func(ctx context.Context) error {
+		var err error
+		svm, err = m.createSVMWithLock(ctx, namespace, svmName)
+		return err
+	})
+	return svm, err
 }
 
 // createSVMWithLock creates an SVM with distributed locking
@@ -69,13 +355,13 @@ func (m *SVMManager) createSVMWithLock(ctx context.Context, namespace, svmName s
 	}()
 
 	// Double-check after acquiring lock
-	svm, err := m.client.GetSVM(ctx, svmName)
+	svm, err := m.getSVM(ctx, svmName)
 	if err == nil {
 		klog.V(4).Infof("SVM %s was created by another controller", svmName)
 		return svm, nil
 	}
 
-	if err != nil && err != ErrSVMNotFound {
+	if err != nil && !errors.Is(err, ErrSVMNotFound) {
 		return nil, fmt.Errorf("failed to check existing SVM after lock: %w", err)
 	}
 
@@ -104,15 +390,24 @@ func (m *SVMManager) createSVMWithLock(ctx context.Context, namespace, svmName s
 		// Try to create SVM
 		svm, err = m.client.CreateSVM(ctx, req)
 		if err == nil {
-			klog.Infof("Created SVM %s for namespace %s (VIP: %s, VLAN: %d)",
-				svmName, namespace, svm.VIP, svm.VLANID)
+			klog.Infof("Created SVM %s for namespace %s (VIP: %s, VLAN: %d, pool: %s)",
+				svmName, namespace, svm.VIP, svm.VLANID, netAlloc.PoolName)
+			m.cache.put(svm)
+			if m.observer != nil {
+				m.observer.SVMCreated(namespace, svm, netAlloc)
+			}
+			if len(m.exportClients) > 0 {
+				m.mu.Lock()
+				m.exportsPending[svmName] = true
+				m.mu.Unlock()
+			}
 			return svm, nil
 		}
 
 		// Check error type
 		if errors.Is(err, ErrSVMAlreadyExists) {
 			// Another controller created it concurrently
-			svm, getErr := m.client.GetSVM(ctx, svmName)
+			svm, getErr := m.getSVM(ctx, svmName)
 			if getErr == nil {
 				return svm, nil
 			}
@@ -124,8 +419,13 @@ func (m *SVMManager) createSVMWithLock(ctx context.Context, namespace, svmName s
 			return nil, fmt.Errorf("failed to create SVM: %w", err)
 		}
 
-		// Network conflict - retry with different IP
+		// Network conflict - retry with different IP. A dynamic address is
+		// held back, as it may belong to a device outside ARCA; a static
+		// VIP is all the namespace may use.
 		klog.V(4).Infof("Network conflict for namespace %s, retrying with different IP", namespace)
+		if !netAlloc.Static {
+			m.allocator.RejectAddress(netAlloc.IPCIDR)
+		}
 		backoff := time.Duration(1<<uint(attempt)) * time.Second
 		select {
 		case <-time.After(backoff):
@@ -137,24 +437,170 @@ func (m *SVMManager) createSVMWithLock(ctx context.Context, namespace, svmName s
 	return nil, fmt.Errorf("failed to create SVM for namespace %s after %d attempts", namespace, maxAttempts)
 }
 
+// scopeExports exports an SVM's root read-write to the export clients and
+// removes its root export rules for any other client. Rules are added before
+// others are removed, so the clients never lose access.
+func (m *SVMManager) scopeExports(ctx context.Context, svmName string) error {
+	exports, err := m.client.ListExports(ctx, svmName)
+	if err != nil {
+		return fmt.Errorf("failed to list exports of SVM %s: %w", svmName, err)
+	}
+
+	present := make(map[netip.Prefix]bool)
+	var stale []Export
+	for _, e := range exports {
+		if e.Volume != "" {
+			continue
+		}
+		prefix, err := parseExportClient(e.Client)
+		switch {
+		case err == nil && slices.Contains(m.exportClients, prefix) && e.Access == "rw":
+			present[prefix] = true
+		case err == nil && slices.Contains(m.exportClients, prefix):
+			// Expected client with the wrong access: replace the rule
+			if err := m.client.DeleteExport(ctx, svmName, "", e.Client); err != nil {
+				return fmt.Errorf("failed to delete export of SVM %s to %s: %w", svmName, e.Client, err)
+			}
+		default:
+			stale = append(stale, e)
+		}
+	}
+
+	for _, prefix := range m.exportClients {
+		if present[prefix] {
+			continue
+		}
+		req := &CreateExportRequest{
+			SVM:    svmName,
+			Client: prefix.String(),
+			Access: "rw",
+			Sec:    []string{"sys"},
+		}
+		if _, err := m.client.CreateExport(ctx, req); err != nil {
+			return fmt.Errorf("failed to export SVM %s to %s: %w", svmName, prefix, err)
+		}
+	}
+	for _, e := range stale {
+		if err := m.client.DeleteExport(ctx, svmName, "", e.Client); err != nil {
+			return fmt.Errorf("failed to delete export of SVM %s to %s: %w", svmName, e.Client, err)
+		}
+		klog.Infof("Removed export of SVM %s to %s", svmName, e.Client)
+	}
+
+	m.mu.Lock()
+	delete(m.exportsPending, svmName)
+	m.mu.Unlock()
+	klog.Infof("Scoped exports of SVM %s to %d clients", svmName, len(m.exportClients))
+	return nil
+}
+
+// parseExportClient parses an export client address or CIDR; addresses are
+// treated as single-host prefixes
+func parseExportClient(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid export client %q", s)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid export client %q", s)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
 // DeleteSVM deletes an SVM (idempotent)
 func (m *SVMManager) DeleteSVM(ctx context.Context, svmName string) error {
+	m.cache.invalidate(svmName)
+	m.mu.Lock()
+	delete(m.exportsPending, svmName)
+	m.mu.Unlock()
 	err := m.client.DeleteSVM(ctx, svmName)
 	if err != nil {
 		return fmt.Errorf("failed to delete SVM %s: %w", svmName, err)
 	}
 
 	klog.Infof("Deleted SVM %s", svmName)
+	if namespace, ok := strings.CutPrefix(svmName, SVMNamePrefix); ok && m.observer != nil {
+		m.observer.SVMDeleted(namespace, svmName)
+	}
 	return nil
 }
 
+// DeleteNamespaceSVM deletes the SVM of a namespace under the lock that
+// serializes its creation, after unused confirms that nothing needs the SVM
+// anymore; an error from unused leaves the SVM in place
+func (m *SVMManager) DeleteNamespaceSVM(ctx context.Context, namespace string, unused func(context.Context) error) error {
+	svmName := SVMNameForNamespace(namespace)
+
+	lockCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	lockHandle, err := m.lockMgr.AcquireLock(lockCtx, namespace, 30*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock for namespace %s: %w", namespace, err)
+	}
+	defer func() {
+		if err := lockHandle.Release(ctx); err != nil {
+			klog.Warningf("Failed to release lock for namespace %s: %v", namespace, err)
+		}
+	}()
+
+	if err := unused(ctx); err != nil {
+		return err
+	}
+	return m.DeleteSVM(ctx, svmName)
+}
+
+// ConfiguredAttributes returns the MTU and gateway the SVM would be created
+// with now: the configured MTU and the gateway of its pool. Gateway is empty
+// when the pool is no longer configured.
+func (m *SVMManager) ConfiguredAttributes(svm *SVM) UpdateSVMRequest {
+	attrs := UpdateSVMRequest{MTU: m.mtu}
+	if m.allocator != nil {
+		attrs.Gateway, _ = m.allocator.GatewayForSVM(svm)
+	}
+	return attrs
+}
+
+// UpdateSVM changes the attributes of an existing SVM
+func (m *SVMManager) UpdateSVM(ctx context.Context, svmName string, req *UpdateSVMRequest) (*SVM, error) {
+	svm, err := m.client.UpdateSVM(ctx, svmName, req)
+	if err != nil {
+		m.cache.invalidate(svmName)
+		return nil, fmt.Errorf("failed to update SVM %s: %w", svmName, err)
+	}
+	m.cache.put(svm)
+	if req.CapacityBytes > 0 {
+		klog.Infof("Resized SVM %s to %d bytes", svmName, req.CapacityBytes)
+	}
+	if req.MTU != 0 || req.Gateway != "" {
+		klog.Infof("Updated SVM %s (MTU: %d, gateway: %s)", svmName, svm.MTU, svm.Gateway)
+	}
+	return svm, nil
+}
+
 // GetSVM retrieves SVM information
 func (m *SVMManager) GetSVM(ctx context.Context, svmName string) (*SVM, error) {
-	return m.client.GetSVM(ctx, svmName)
+	return m.getSVM(ctx, svmName)
 }
 
 // GetSVMForNamespace retrieves SVM for a given namespace
 func (m *SVMManager) GetSVMForNamespace(ctx context.Context, namespace string) (*SVM, error) {
-	svmName := fmt.Sprintf("k8s-%s", namespace)
-	return m.client.GetSVM(ctx, svmName)
+	svmName := SVMNameForNamespace(namespace)
+	return m.getSVM(ctx, svmName)
+}
+
+// getSVM retrieves an SVM from the ARCA API and refreshes its cache entry
+func (m *SVMManager) getSVM(ctx context.Context, svmName string) (*SVM, error) {
+	svm, err := m.client.GetSVM(ctx, svmName)
+	switch {
+	case err == nil:
+		m.cache.put(svm)
+	case errors.Is(err, ErrSVMNotFound):
+		m.cache.invalidate(svmName)
+	}
+	return svm, err
 }
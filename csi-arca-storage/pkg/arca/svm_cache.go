@@ -0,0 +1,69 @@
+package arca
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSVMCacheTTL is how long an SVM found or created by EnsureSVM is
+// reused without asking the ARCA API again
+const DefaultSVMCacheTTL = 30 * time.Second
+
+// svmCacheEntry is a cached SVM
+type svmCacheEntry struct {
+	svm       SVM
+	expiresAt time.Time
+}
+
+// svmCache remembers the SVMs known to exist, so CreateVolume for the second
+// and later volumes of a namespace skips the GetSVM roundtrip. Entries expire
+// after the TTL, which bounds how long an SVM deleted outside the driver is
+// still handed out.
+type svmCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]svmCacheEntry
+}
+
+// newSVMCache creates an empty SVM cache
+func newSVMCache(ttl time.Duration) *svmCache {
+	if ttl <= 0 {
+		ttl = DefaultSVMCacheTTL
+	}
+	return &svmCache{
+		ttl:     ttl,
+		entries: make(map[string]svmCacheEntry),
+	}
+}
+
+// get returns a copy of the cached SVM, or false when it is not cached or
+// expired
+func (c *svmCache) get(name string) (*SVM, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[name]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, name)
+		return nil, false
+	}
+	svm := entry.svm
+	return &svm, true
+}
+
+// put caches svm
+func (c *svmCache) put(svm *SVM) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[svm.Name] = svmCacheEntry{svm: *svm, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// invalidate drops the named SVM
+func (c *svmCache) invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, name)
+}
@@ -0,0 +1,72 @@
+package arca_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/arca/arcatest"
+	"github.com/akam1o/csi-arca-storage/pkg/lock"
+)
+
+// newTestSVMManager returns an SVM manager of an in-memory backend
+func newTestSVMManager(t *testing.T) (*arca.SVMManager, *arcatest.Server) {
+	t.Helper()
+
+	server := arcatest.NewServer()
+	t.Cleanup(server.Close)
+
+	client, err := arca.NewClient(&arca.ClientConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	allocator, err := arca.NewStandaloneAllocator([]arca.PoolConfig{{
+		Name:    "test",
+		CIDR:    "192.0.2.0/24",
+		Range:   "192.0.2.10-192.0.2.200",
+		VLANID:  100,
+		Gateway: "192.0.2.1",
+	}}, client)
+	if err != nil {
+		t.Fatalf("NewStandaloneAllocator: %v", err)
+	}
+	locks := lock.NewManager(lock.NewLeaseBackend(fake.NewClientset(), "default"), "controller-1")
+	return arca.NewSVMManager(client, allocator, locks, 0), server
+}
+
+func TestEnsureSVMSharedCreationOutlivesCaller(t *testing.T) {
+	m, server := newTestSVMManager(t)
+	server.SetLatency(200 * time.Millisecond)
+
+	// The first call starts the creation and is cancelled while the second
+	// waits for it
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := m.EnsureSVM(first, "team-a")
+		firstErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := m.EnsureSVM(context.Background(), "team-a")
+		second <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled EnsureSVM = %v, want context.Canceled", err)
+	}
+	if err := <-second; err != nil {
+		t.Fatalf("EnsureSVM waiting for the cancelled creation: %v", err)
+	}
+	if n := server.Mutations()[arcatest.CreateSVM]; n != 1 {
+		t.Errorf("CreateSVM called %d times, want 1", n)
+	}
+}
@@ -12,8 +12,24 @@ type SVM struct {
 	MTU       int       `json:"mtu"`
 	State     string    `json:"state"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// ExportPath is the SVM's NFS export on backends whose layout differs
+	// from the export path template; empty on others
+	ExportPath string `json:"export_path,omitempty"`
 }
 
+// SVM states reported by the backend
+const (
+	// SVMStateCreating means the SVM is still being set up
+	SVMStateCreating = "creating"
+	// SVMStateAvailable means the SVM serves its exports
+	SVMStateAvailable = "available"
+	// SVMStateDeleting means the SVM is being torn down
+	SVMStateDeleting = "deleting"
+	// SVMStateError means the SVM failed on the backend
+	SVMStateError = "error"
+)
+
 // CreateSVMRequest represents a request to create an SVM
 type CreateSVMRequest struct {
 	Name    string `json:"name"`
@@ -23,18 +39,90 @@ type CreateSVMRequest struct {
 	MTU     int    `json:"mtu"`
 }
 
+// UpdateSVMRequest changes the attributes of an existing SVM; zero fields
+// are left unchanged
+type UpdateSVMRequest struct {
+	Gateway string `json:"gateway,omitempty"`
+	MTU     int    `json:"mtu,omitempty"`
+
+	// CapacityBytes resizes the SVM's storage
+	CapacityBytes int64 `json:"capacity_bytes,omitempty"`
+}
+
+// Provisioning modes of a directory
+const (
+	// ProvisioningModeThin allocates space as data is written
+	ProvisioningModeThin = "thin"
+	// ProvisioningModeThick preallocates the full quota at creation
+	ProvisioningModeThick = "thick"
+)
+
 // CreateDirectoryRequest represents a request to create a directory
 type CreateDirectoryRequest struct {
 	SVMName    string `json:"svm_name"`
 	Path       string `json:"path"`
 	QuotaBytes int64  `json:"quota_bytes,omitempty"`
+	// ProvisioningMode is ProvisioningModeThin (default) or
+	// ProvisioningModeThick, which requires QuotaBytes
+	ProvisioningMode string `json:"provisioning_mode,omitempty"`
+	// Mode are the octal permission bits of the directory, e.g. "0700"
+	// (default: the backend's)
+	Mode string `json:"mode,omitempty"`
+	// ExcludeFromQuota keeps the directory's usage out of the SVM's quota
+	// accounting
+	ExcludeFromQuota bool `json:"exclude_from_quota,omitempty"`
 }
 
+// DirectoryInfo represents a directory of an SVM
+type DirectoryInfo struct {
+	Path             string    `json:"path"`
+	QuotaBytes       int64     `json:"quota_bytes"`
+	UsedBytes        int64     `json:"used_bytes"`
+	ProvisioningMode string    `json:"provisioning_mode,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Snapshot consistency levels
+const (
+	// SnapshotConsistencyCrash takes the snapshot as is (crash-consistent)
+	SnapshotConsistencyCrash = "crash"
+	// SnapshotConsistencyFilesystem flushes and freezes the backend
+	// filesystem around the snapshot
+	SnapshotConsistencyFilesystem = "filesystem"
+)
+
 // CreateSnapshotRequest represents a request to create a snapshot
 type CreateSnapshotRequest struct {
 	SVMName      string `json:"svm_name"`
 	SourcePath   string `json:"source_path"`
 	SnapshotPath string `json:"snapshot_path"`
+	// Label is a human-readable name shown for the snapshot on the backend
+	Label string `json:"label,omitempty"`
+	// Consistency is SnapshotConsistencyCrash (default) or
+	// SnapshotConsistencyFilesystem
+	Consistency string `json:"consistency,omitempty"`
+	// Secrets are the snapshotter secret of the VolumeSnapshotClass
+	Secrets map[string]string `json:"secrets,omitempty"`
+}
+
+// Snapshot states reported by the backend
+const (
+	// SnapshotStateCreating means the reflink copy is still in progress
+	SnapshotStateCreating = "creating"
+	// SnapshotStateAvailable means the snapshot is complete and usable
+	SnapshotStateAvailable = "available"
+	// SnapshotStateError means the snapshot failed on the backend
+	SnapshotStateError = "error"
+)
+
+// SnapshotInfo represents the backend state of a snapshot
+type SnapshotInfo struct {
+	Path      string `json:"path"`
+	State     string `json:"state"`
+	SizeBytes int64  `json:"size_bytes"`
+	// Message explains SnapshotStateError
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // RestoreSnapshotRequest represents a request to restore from snapshot
@@ -49,6 +137,8 @@ type SetQuotaRequest struct {
 	SVMName    string `json:"svm_name"`
 	Path       string `json:"path"`
 	QuotaBytes int64  `json:"quota_bytes"`
+	// InodeLimit caps the number of files and directories (0 = unlimited)
+	InodeLimit int64 `json:"inode_limit,omitempty"`
 }
 
 // ExpandQuotaRequest represents a request to expand quota
@@ -63,14 +153,51 @@ type QuotaInfo struct {
 	Path       string `json:"path"`
 	QuotaBytes int64  `json:"quota_bytes"`
 	UsedBytes  int64  `json:"used_bytes"`
+	InodeLimit int64  `json:"inode_limit"`
+	UsedInodes int64  `json:"used_inodes"`
 	ProjectID  int    `json:"project_id"`
 }
 
+// EfficiencyInfo represents the data reduction of a directory
+type EfficiencyInfo struct {
+	Path                  string `json:"path"`
+	LogicalBytes          int64  `json:"logical_bytes"`
+	PhysicalBytes         int64  `json:"physical_bytes"`
+	DedupeSavedBytes      int64  `json:"dedupe_saved_bytes"`
+	CompressionSavedBytes int64  `json:"compression_saved_bytes"`
+}
+
+// Export represents an NFS export rule of an SVM
+type Export struct {
+	SVM        string   `json:"svm"`
+	Volume     string   `json:"volume"`
+	Client     string   `json:"client"`
+	Access     string   `json:"access"`
+	RootSquash bool     `json:"root_squash"`
+	Sec        []string `json:"sec"`
+	Pseudo     string   `json:"pseudo"`
+	ExportID   int      `json:"export_id"`
+	Status     string   `json:"status"`
+}
+
+// CreateExportRequest represents a request to add an NFS export rule
+type CreateExportRequest struct {
+	SVM string `json:"svm"`
+	// Volume is the exported volume; empty exports the SVM's root
+	Volume     string   `json:"volume,omitempty"`
+	Client     string   `json:"client"`
+	Access     string   `json:"access"`
+	RootSquash bool     `json:"root_squash"`
+	Sec        []string `json:"sec,omitempty"`
+}
+
 // NetworkAllocation represents allocated network parameters
 type NetworkAllocation struct {
-	VLANID  int    `json:"vlan_id"`
-	IPCIDR  string `json:"ip_cidr"`
-	Gateway string `json:"gateway"`
+	VLANID   int    `json:"vlan_id"`
+	IPCIDR   string `json:"ip_cidr"`
+	Gateway  string `json:"gateway"`
+	PoolName string `json:"pool_name,omitempty"`
+	Static   bool   `json:"static,omitempty"` // Address pinned to the namespace by configuration
 }
 
 // APIResponse represents a generic API response wrapper
@@ -0,0 +1,166 @@
+package arca
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// DefaultStuckCallReconnect is how many calls in a row the watchdog
+// cancels before the client drops its connections
+const DefaultStuckCallReconnect = 3
+
+// Watchdog scan interval bounds; the watchdog scans four times per ceiling
+const (
+	watchdogMinInterval = 100 * time.Millisecond
+	watchdogMaxInterval = 10 * time.Second
+)
+
+// errCancelledStuck is the cancel cause of calls the watchdog aborted
+var errCancelledStuck = errors.New("cancelled by the stuck call watchdog")
+
+// watchdog records the start of each outbound request and cancels those
+// running past a hard ceiling. The per-request timeout bounds a request
+// through its context, but a connection that hangs while a response is
+// streamed can outlive it; the watchdog cancels independently of it and
+// replaces the transport when calls keep getting stuck, so new requests
+// do not queue behind dead connections.
+type watchdog struct {
+	ceiling        time.Duration
+	reconnectAfter int
+
+	mu     sync.Mutex
+	nextID uint64
+	calls  map[uint64]*trackedCall
+	// stuck counts the calls cancelled since the last call that succeeded
+	// or the last reconnection
+	stuck int
+}
+
+// trackedCall is an in-flight request
+type trackedCall struct {
+	start    time.Time
+	endpoint string
+	method   string
+	path     string
+	cancel   context.CancelCauseFunc
+	stuck    bool
+}
+
+// newWatchdog creates a watchdog cancelling calls after ceiling; nil when
+// ceiling is 0
+func newWatchdog(ceiling time.Duration, reconnectAfter int) *watchdog {
+	if ceiling <= 0 {
+		return nil
+	}
+	if reconnectAfter <= 0 {
+		reconnectAfter = DefaultStuckCallReconnect
+	}
+	return &watchdog{
+		ceiling:        ceiling,
+		reconnectAfter: reconnectAfter,
+		calls:          make(map[uint64]*trackedCall),
+	}
+}
+
+// trackCall registers a request to endpoint and returns its context and a
+// function to call with the request's result, which returns the error to
+// report: ErrStuckCall for calls the watchdog cancelled
+func (c *Client) trackCall(ctx context.Context, endpoint, method, path string) (context.Context, func(error) error) {
+	w := c.watchdog
+	if w == nil {
+		return ctx, func(err error) error { return err }
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	call := &trackedCall{start: time.Now(), endpoint: endpoint, method: method, path: path, cancel: cancel}
+
+	w.mu.Lock()
+	id := w.nextID
+	w.nextID++
+	w.calls[id] = call
+	w.mu.Unlock()
+
+	return ctx, func(err error) error {
+		w.mu.Lock()
+		delete(w.calls, id)
+		stuck := call.stuck
+		if err == nil && !stuck {
+			w.stuck = 0
+		}
+		w.mu.Unlock()
+		cancel(nil)
+
+		if stuck {
+			return fmt.Errorf("%w: %s %s%s did not complete within %v", ErrStuckCall, method, endpoint, path, w.ceiling)
+		}
+		return err
+	}
+}
+
+// RunWatchdog cancels requests running past the stuck call ceiling until
+// ctx is cancelled; it returns at once when the watchdog is disabled
+func (c *Client) RunWatchdog(ctx context.Context) {
+	w := c.watchdog
+	if w == nil {
+		return
+	}
+
+	interval := min(max(w.ceiling/4, watchdogMinInterval), watchdogMaxInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if w.cancelStuck() {
+			c.reconnect()
+		}
+	}
+}
+
+// cancelStuck cancels the calls past the ceiling and reports whether the
+// client should reconnect
+func (w *watchdog) cancelStuck() bool {
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, call := range w.calls {
+		if call.stuck || now.Sub(call.start) < w.ceiling {
+			continue
+		}
+		call.stuck = true
+		call.cancel(errCancelledStuck)
+		w.stuck++
+		metrics.ArcaStuckCalls.WithLabelValues(call.endpoint).Inc()
+		klog.Warningf("Cancelled ARCA call %s %s%s after %v without a response", call.method, call.endpoint, call.path, now.Sub(call.start).Truncate(time.Millisecond))
+	}
+
+	if w.stuck < w.reconnectAfter {
+		return false
+	}
+	w.stuck = 0
+	return true
+}
+
+// reconnect replaces the HTTP client with one on a new transport and
+// closes the idle connections of the old one; requests still in flight on
+// it finish or get cancelled on their own
+func (c *Client) reconnect() {
+	old := c.httpClient.Swap(&http.Client{Transport: c.newTransport()})
+	old.CloseIdleConnections()
+	metrics.ArcaReconnects.Inc()
+	klog.Warningf("ARCA calls keep getting stuck, reconnecting with a new transport")
+}
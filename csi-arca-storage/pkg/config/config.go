@@ -2,12 +2,29 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/exportaudit"
+	"github.com/akam1o/csi-arca-storage/pkg/idempotency"
+	"github.com/akam1o/csi-arca-storage/pkg/lock"
+	"github.com/akam1o/csi-arca-storage/pkg/logging"
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+	"github.com/akam1o/csi-arca-storage/pkg/nscleanup"
+	"github.com/akam1o/csi-arca-storage/pkg/orphan"
+	"github.com/akam1o/csi-arca-storage/pkg/policy"
 )
 
 // Config represents the CSI driver configuration
@@ -20,6 +37,33 @@ type Config struct {
 
 	// Driver configuration
 	Driver DriverConfig `yaml:"driver"`
+
+	// Provisioning policy configuration
+	Policy PolicyConfig `yaml:"policy"`
+
+	// SVM lifecycle configuration
+	SVM SVMConfig `yaml:"svm"`
+
+	// Tenants route namespaces to additional ARCA clusters (controller only)
+	Tenants []TenantConfig `yaml:"tenants"`
+
+	// Log verbosity and output
+	Logging LoggingConfig `yaml:"logging"`
+}
+
+// TenantConfig maps namespaces to a separate ARCA cluster and credentials
+type TenantConfig struct {
+	// Name identifies the backend in ArcaVolume/ArcaSnapshot records and
+	// must not change while volumes exist
+	Name string `yaml:"name"`
+	// Namespaces are glob patterns, e.g. "finance-*"; the first matching
+	// tenant wins and unmatched namespaces use the top-level arca backend
+	Namespaces []string `yaml:"namespaces"`
+	// AuthTokenEnv names an environment variable overriding arca.auth_token
+	AuthTokenEnv string     `yaml:"auth_token_env"`
+	ARCA         ArcaConfig `yaml:"arca"`
+	// Network defaults to the top-level network configuration
+	Network *NetworkConfig `yaml:"network"`
 }
 
 // ArcaConfig holds ARCA API configuration
@@ -28,6 +72,62 @@ type ArcaConfig struct {
 	Timeout   Duration  `yaml:"timeout"`
 	AuthToken string    `yaml:"auth_token"`
 	TLS       TLSConfig `yaml:"tls"`
+
+	// Endpoints are additional API base URLs used when base_url fails
+	Endpoints []string `yaml:"endpoints"`
+	// EndpointPolicy is "failover" (default) or "round-robin"
+	EndpointPolicy string `yaml:"endpoint_policy"`
+	// EndpointCooldown is how long a failed endpoint is skipped (default 30s)
+	EndpointCooldown Duration `yaml:"endpoint_cooldown"`
+	// HealthCheckInterval probes all endpoints periodically (0 disables)
+	HealthCheckInterval Duration `yaml:"health_check_interval"`
+	// HealthCheckPath is the probed URL path (default "/"; any non-5xx is healthy)
+	HealthCheckPath string `yaml:"health_check_path"`
+
+	// Timeouts override Timeout per kind of API call
+	Timeouts ArcaTimeouts `yaml:"timeouts"`
+
+	// Retries is how often a failed request is retried within an RPC
+	// (default 3) and RetryOn the failures retried: "unavailable",
+	// "timeout" and "network" (default all)
+	Retries *int     `yaml:"retries"`
+	RetryOn []string `yaml:"retry_on"`
+
+	// StuckCallCeiling cancels requests running longer than this, whatever
+	// their timeout, and StuckCallReconnect (default 3) is how many calls
+	// in a row may be cancelled before the client reconnects (0 disables;
+	// controller only)
+	StuckCallCeiling   Duration `yaml:"stuck_call_ceiling"`
+	StuckCallReconnect int      `yaml:"stuck_call_reconnect"`
+
+	// MaxResponseSize is the largest response body read from the API, in
+	// bytes (default 32 MiB)
+	MaxResponseSize int64 `yaml:"max_response_size"`
+}
+
+// RetryConfig overrides arca.retries and arca.retry_on for one RPC; unset
+// fields keep those of the backend
+type RetryConfig struct {
+	Retries *int     `yaml:"retries"`
+	RetryOn []string `yaml:"retry_on"`
+}
+
+// ArcaTimeouts holds per-operation ARCA request timeouts (0 uses arca.timeout)
+type ArcaTimeouts struct {
+	SVM       Duration `yaml:"svm"`
+	Directory Duration `yaml:"directory"`
+	Snapshot  Duration `yaml:"snapshot"`
+	Quota     Duration `yaml:"quota"`
+}
+
+// OperationTimeouts holds CSI-side deadlines per controller RPC (0 keeps the
+// deadline set by the calling sidecar)
+type OperationTimeouts struct {
+	CreateVolume   Duration `yaml:"create_volume"`
+	DeleteVolume   Duration `yaml:"delete_volume"`
+	ExpandVolume   Duration `yaml:"expand_volume"`
+	CreateSnapshot Duration `yaml:"create_snapshot"`
+	DeleteSnapshot Duration `yaml:"delete_snapshot"`
 }
 
 // TLSConfig holds TLS configuration
@@ -42,14 +142,45 @@ type TLSConfig struct {
 type NetworkConfig struct {
 	Pools []PoolConfig `yaml:"pools"`
 	MTU   int          `yaml:"mtu"`
+
+	// AllocationStrategy is "round-robin" (default) or "hash"
+	AllocationStrategy string `yaml:"allocation_strategy"`
+
+	// ConflictHold is how long an address ARCA rejected as a duplicate is
+	// skipped by allocations, doubled for repeated rejections (controller
+	// only; default 10m)
+	ConflictHold Duration `yaml:"conflict_hold"`
+
+	// Affinity reserves pools for matching namespaces, e.g. production
+	// VLANs for production namespaces (controller only)
+	Affinity []PoolAffinityConfig `yaml:"affinity"`
+}
+
+// PoolAffinityConfig reserves pools for the namespaces it matches. The first
+// matching rule gives a namespace's pools; namespaces no rule matches use
+// the pools of no rule.
+type PoolAffinityConfig struct {
+	Pools []string `yaml:"pools"`
+	// Namespaces are regular expressions matched against the full name;
+	// empty matches every namespace
+	Namespaces []string `yaml:"namespaces"`
+	// NamespaceSelector is a label selector the namespace must also match
+	NamespaceSelector string `yaml:"namespace_selector"`
 }
 
 // PoolConfig represents an IP pool configuration
 type PoolConfig struct {
+	Name    string `yaml:"name"`
+	Drained bool   `yaml:"drained"` // No new allocations; existing SVMs keep their VIPs
 	CIDR    string `yaml:"cidr"`
 	Range   string `yaml:"range"`
 	VLANID  int    `yaml:"vlan"`
 	Gateway string `yaml:"gateway"`
+
+	// Exclude lists infrastructure addresses or ranges ("IP1-IP2") never allocated
+	Exclude []string `yaml:"exclude"`
+	// StaticVIPs pins namespaces to specific addresses (namespace -> IP)
+	StaticVIPs map[string]string `yaml:"static_vips"`
 }
 
 // DriverConfig holds driver-specific configuration
@@ -58,6 +189,395 @@ type DriverConfig struct {
 	Endpoint      string `yaml:"endpoint"`
 	StateFilePath string `yaml:"state_file_path"`
 	BaseMountPath string `yaml:"base_mount_path"`
+
+	// SkipPermissionCheck disables the startup RBAC self-check
+	SkipPermissionCheck bool `yaml:"skip_permission_check"`
+
+	// MaintenanceConfigMap names a ConfigMap in the plugin's namespace
+	// (POD_NAMESPACE, default kube-system) whose "enabled" key pauses provisioning (controller only; empty disables the switch)
+	MaintenanceConfigMap string   `yaml:"maintenance_configmap"`
+	MaintenanceInterval  Duration `yaml:"maintenance_interval"`
+
+	// EfficiencyStats collects per-volume deduplication and compression
+	// statistics into ArcaVolume status and metrics (controller only)
+	EfficiencyStats    bool     `yaml:"efficiency_stats"`
+	EfficiencyInterval Duration `yaml:"efficiency_interval"`
+
+	// OrphanScan periodically reports backend volume directories and
+	// snapshots without an ArcaVolume or ArcaSnapshot (controller only);
+	// those younger than OrphanGracePeriod (default 1h) are skipped
+	OrphanScan         bool     `yaml:"orphan_scan"`
+	OrphanScanInterval Duration `yaml:"orphan_scan_interval"`
+	OrphanGracePeriod  Duration `yaml:"orphan_grace_period"`
+
+	// OrphanSnapshotPolicy is "report" (default) or "delete", which deletes
+	// orphaned snapshots
+	OrphanSnapshotPolicy string `yaml:"orphan_snapshot_policy"`
+
+	// AnnotatePVs annotates PersistentVolumes with the SVM, VIP and backend
+	// path of their ArcaVolume (controller only)
+	AnnotatePVs        bool     `yaml:"annotate_pvs"`
+	AnnotatePVInterval Duration `yaml:"annotate_pv_interval"`
+
+	// StatusReport keeps the ArcaDriverStatus "arca-csi" up to date with
+	// backend connectivity, pool utilization, volume and snapshot counts,
+	// pending operations and component versions (controller only)
+	StatusReport         bool     `yaml:"status_report"`
+	StatusReportInterval Duration `yaml:"status_report_interval"`
+
+	// SecureDelete wipes the data of volumes whose StorageClass has no
+	// secureDelete parameter before deleting them (controller only)
+	SecureDelete bool `yaml:"secure_delete"`
+	// WipeJobImage is the image of the Jobs wiping volumes on backends that
+	// cannot wipe directories; it needs sh, find and shred (empty fails
+	// secure deletes on such backends)
+	WipeJobImage string `yaml:"wipe_job_image"`
+
+	// DeferredDelete acknowledges DeleteVolume when deleting the backend
+	// data fails transiently and retries in the background with backoff,
+	// keeping the ArcaVolume until the data is gone (controller only)
+	DeferredDelete      bool     `yaml:"deferred_delete"`
+	DeleteQueueInterval Duration `yaml:"delete_queue_interval"`
+
+	// OperationQueue records SVM creations, clones and volume deletions as
+	// ArcaOperations while they run, so that a restarted controller resumes
+	// them; pending operations are checked every OperationQueueInterval
+	// (controller only)
+	OperationQueue         bool     `yaml:"operation_queue"`
+	OperationQueueInterval Duration `yaml:"operation_queue_interval"`
+
+	// TokenAudience makes kubelet pass a bound service account token of the
+	// pod with this audience to NodePublishVolume, which the node plugin
+	// exchanges with ARCA for a mount grant (empty disables). The CSIDriver
+	// needs a matching tokenRequests entry (see manage_csidriver). Not
+	// supported with tenants.
+	TokenAudience string `yaml:"token_audience"`
+	// TokenExpiration is the requested token lifetime (default: kubelet's,
+	// at least 10m)
+	TokenExpiration Duration `yaml:"token_expiration"`
+
+	// ManageCSIDriver creates or updates the CSIDriver object at startup from
+	// the enabled features instead of relying on deploy/csidriver.yaml
+	// (controller only)
+	ManageCSIDriver bool `yaml:"manage_csidriver"`
+
+	// VersionSkewCheck publishes each component's version and supported
+	// volume context fields in a Lease in the plugin's namespace; the
+	// controller warns about node plugins lacking fields it sets (both
+	// components)
+	VersionSkewCheck    bool     `yaml:"version_skew_check"`
+	VersionSkewInterval Duration `yaml:"version_skew_interval"`
+
+	// LockBackend stores SVM locks as "lease" (coordination.k8s.io Leases,
+	// default) or "crd" (cluster-scoped ArcaLocks) (controller only)
+	LockBackend string `yaml:"lock_backend"`
+
+	// CRDWait is how long the controller retries verifying the
+	// arcavolumes and arcasnapshots CRDs at startup before giving up, for
+	// CRDs installed after the driver (controller only; default 1m)
+	CRDWait Duration `yaml:"crd_wait"`
+
+	// LazyCRDCheck skips the startup verification of the CRDs; the first
+	// store operation verifies them instead and fails as unavailable until
+	// they are installed (controller only)
+	LazyCRDCheck bool `yaml:"lazy_crd_check"`
+
+	// LockGCInterval is how often the controller deletes SVM locks left
+	// behind by a crashed holder (default 5m)
+	LockGCInterval Duration `yaml:"lock_gc_interval"`
+
+	// IDMode is "hash" (default) or "uuid" for new volume/snapshot IDs
+	// (controller only)
+	IDMode string `yaml:"id_mode"`
+
+	// SnapshotIDSalt keys the hash of new snapshot IDs in hash mode, so
+	// tenants cannot derive each other's snapshot IDs (overridden by
+	// ARCA_SNAPSHOT_ID_SALT; empty keeps unkeyed hashes). With
+	// SnapshotIDCompat a retried CreateSnapshot keeps the ID recorded in its
+	// ArcaSnapshot, e.g. an unsalted one (controller only).
+	SnapshotIDSalt   string `yaml:"snapshot_id_salt"`
+	SnapshotIDCompat bool   `yaml:"snapshot_id_compat"`
+
+	// OperationTimeouts bound each controller RPC (controller only)
+	OperationTimeouts OperationTimeouts `yaml:"operation_timeouts"`
+
+	// CreateVolumeSLO logs a "slow operation" entry with the phase breakdown
+	// of each CreateVolume that takes longer (controller only; 0 disables)
+	CreateVolumeSLO Duration `yaml:"create_volume_slo"`
+
+	// QuotaVerifyTimeout is how long CreateVolume and ControllerExpandVolume
+	// read back a new quota until the backend reports it applied; the
+	// quota is sent again halfway (controller only; default 30s)
+	QuotaVerifyTimeout Duration `yaml:"quota_verify_timeout"`
+
+	// Topology reports the pools as CSI topology: the node plugin probes
+	// which pools have an address on its interfaces, and CreateVolume only
+	// places volumes in pools the scheduled node reaches. Requires named
+	// pools and the provisioner's Topology feature gate.
+	Topology bool `yaml:"topology"`
+
+	// NamespaceRateLimit throttles CreateVolume and CreateSnapshot per
+	// namespace (controller only)
+	NamespaceRateLimit NamespaceRateLimitConfig `yaml:"namespace_rate_limit"`
+
+	// RPCRetries override the ARCA retries per CSI RPC (e.g. CreateVolume),
+	// so RPCs whose caller retries with its own backoff can fail fast
+	RPCRetries map[string]RetryConfig `yaml:"rpc_retries"`
+
+	// DNSCacheTTL is how long resolved SVM hostnames are cached (node only)
+	DNSCacheTTL Duration `yaml:"dns_cache_ttl"`
+
+	// VolumeStatsQuota cross-checks the statfs figures of NodeGetVolumeStats
+	// with each volume's quota on the ARCA API and reports the quota when
+	// they disagree, read at most every VolumeStatsQuotaTTL (node only;
+	// default 1m)
+	VolumeStatsQuota    bool     `yaml:"volume_stats_quota"`
+	VolumeStatsQuotaTTL Duration `yaml:"volume_stats_quota_ttl"`
+
+	// VolumeLookup lets the node plugin read ArcaVolume CRs to fill in
+	// missing volume context (e.g. statically created PVs)
+	VolumeLookup bool `yaml:"volume_lookup"`
+
+	// ValidateVolumeContext rejects staging when PV volume attributes do
+	// not match the ArcaVolume CR (node only)
+	ValidateVolumeContext bool `yaml:"validate_volume_context"`
+
+	// VolumeContextKey is the HMAC key the controller signs the svm, vip,
+	// volumePath and svmHost volume context fields with and the node plugin
+	// verifies them with (overridden by ARCA_VOLUME_CONTEXT_KEY; empty
+	// disables signing)
+	VolumeContextKey string `yaml:"volume_context_key"`
+	// RequireSignedVolumeContext rejects staging volumes whose context is
+	// not signed, e.g. created before signing was enabled (node only)
+	RequireSignedVolumeContext bool `yaml:"require_signed_volume_context"`
+
+	// StagingDirMode/TargetDirMode are the permissions for created
+	// staging and publish directories (octal, default "0750")
+	StagingDirMode FileMode `yaml:"staging_dir_mode"`
+	TargetDirMode  FileMode `yaml:"target_dir_mode"`
+
+	// SELinuxMount mounts the NFS export of a staged volume with its
+	// "context=" mount option from the CO (must match seLinuxMount in the
+	// CSIDriver object)
+	SELinuxMount bool `yaml:"selinux_mount"`
+
+	// MountAudit executes mount operations via an allow-listed mount
+	// binary and logs every invocation (node only)
+	MountAudit  bool   `yaml:"mount_audit"`
+	MountBinary string `yaml:"mount_binary"`
+
+	// MountHelperSocket has the node plugin mount and unmount through the
+	// privileged mount-helper listening on this unix socket, so the plugin
+	// container needs no privileges (node only). The helper applies
+	// MountAudit and mounts only into MountHelperAllowedPaths (default:
+	// base_mount_path and /var/lib/kubelet).
+	MountHelperSocket       string   `yaml:"mount_helper_socket"`
+	MountHelperAllowedPaths []string `yaml:"mount_helper_allowed_paths"`
+
+	// StateJournal appends node state changes to a journal instead of
+	// rewriting the state file on every change (node only)
+	StateJournal bool `yaml:"state_journal"`
+	// StateJournalCompaction is the journal length that triggers a full
+	// state rewrite (default 1000)
+	StateJournalCompaction int `yaml:"state_journal_compaction"`
+
+	// StatePersistMode is "sync" (default), "coalesce" or "async"
+	StatePersistMode string `yaml:"state_persist_mode"`
+	// StatePersistDelay is the coalescing window (default 20ms)
+	StatePersistDelay Duration `yaml:"state_persist_delay"`
+	// StateFormat is the encoding of the state file, "json" (default) or
+	// "protobuf"; either is read on startup (node only)
+	StateFormat string `yaml:"state_format"`
+
+	// ReconcileWorkers bounds concurrent SVM remounts at startup (default 8)
+	ReconcileWorkers int `yaml:"reconcile_workers"`
+	// ReconcileTimeout bounds each SVM remount at startup (default 2m)
+	ReconcileTimeout Duration `yaml:"reconcile_timeout"`
+
+	// MountBackoffInitial/MountBackoffMax bound the retry delay after a
+	// failed SVM mount (defaults 5s and 5m, doubling per failure)
+	MountBackoffInitial Duration `yaml:"mount_backoff_initial"`
+	MountBackoffMax     Duration `yaml:"mount_backoff_max"`
+
+	// UnmountLinger keeps an SVM mounted this long after its last volume
+	// is unstaged (0 unmounts immediately)
+	UnmountLinger Duration `yaml:"unmount_linger"`
+
+	// MountProfileSysctls applies the host sysctls suggested by the
+	// mountProfile of staged volumes instead of only logging them; values
+	// are only raised (node only)
+	MountProfileSysctls bool `yaml:"mount_profile_sysctls"`
+
+	// FSCache mounts volumes whose StorageClass sets fsCache with fsc,
+	// caching their data in the cachefilesd cache at FSCacheDir (default
+	// /var/cache/fscache). Each SVM mounted with fsc holds
+	// FSCacheBudgetPerSVM of the cache filesystem (e.g. "50Gi", empty =
+	// unlimited); cache statistics are collected every FSCacheStatsInterval
+	// (default 30s) (node only)
+	FSCache              bool     `yaml:"fscache"`
+	FSCacheDir           string   `yaml:"fscache_dir"`
+	FSCacheBudgetPerSVM  string   `yaml:"fscache_budget_per_svm"`
+	FSCacheStatsInterval Duration `yaml:"fscache_stats_interval"`
+
+	// Canary writes and reads back a small file on each mounted SVM every
+	// CanaryInterval (default 30s); probes slower than CanaryTimeout
+	// (default 10s) mark the SVM unhealthy (node only)
+	Canary         bool     `yaml:"canary"`
+	CanaryInterval Duration `yaml:"canary_interval"`
+	CanaryTimeout  Duration `yaml:"canary_timeout"`
+
+	// VolumeEvents records stage, unstage, publish and unpublish calls as
+	// ArcaVolumeEvents, queueing up to VolumeEventQueueSize (default 1000)
+	// on each node. The controller deletes events older than
+	// VolumeEventRetention (default 720h) and those beyond the newest
+	// VolumeEventMaxPerVolume (default 100) of a volume or
+	// VolumeEventMaxTotal (default 10000) overall.
+	VolumeEvents             bool     `yaml:"volume_events"`
+	VolumeEventQueueSize     int      `yaml:"volume_event_queue_size"`
+	VolumeEventRetention     Duration `yaml:"volume_event_retention"`
+	VolumeEventMaxPerVolume  int      `yaml:"volume_event_max_per_volume"`
+	VolumeEventMaxTotal      int      `yaml:"volume_event_max_total"`
+	VolumeEventPruneInterval Duration `yaml:"volume_event_prune_interval"`
+}
+
+// PolicyConfig holds provisioning policy configuration
+type PolicyConfig struct {
+	// DryRun logs policy violations without rejecting requests
+	DryRun bool               `yaml:"dry_run"`
+	Rules  []PolicyRuleConfig `yaml:"rules"`
+}
+
+// PolicyRuleConfig represents a single CreateVolume deny rule
+type PolicyRuleConfig struct {
+	Name        string            `yaml:"name"`
+	Namespaces  []string          `yaml:"namespaces"`   // Glob patterns, e.g. "ci-*"
+	Parameters  map[string]string `yaml:"parameters"`   // StorageClass parameters to match ("*" = any value)
+	MinCapacity string            `yaml:"min_capacity"` // e.g. "1Gi"
+	MaxCapacity string            `yaml:"max_capacity"` // e.g. "500Gi"
+	Message     string            `yaml:"message"`
+}
+
+// SVMConfig holds SVM lifecycle configuration
+type SVMConfig struct {
+	// AllowedNamespaces/DeniedNamespaces are regular expressions matched
+	// against the full namespace name; deny takes precedence
+	AllowedNamespaces []string `yaml:"allowed_namespaces"`
+	DeniedNamespaces  []string `yaml:"denied_namespaces"`
+
+	// NamespaceSelector is a label selector the namespace must match
+	NamespaceSelector string `yaml:"namespace_selector"`
+
+	// CacheTTL is how long the controller reuses an SVM it found or created
+	// instead of asking the ARCA API on every CreateVolume (default 30s); an
+	// SVM deleted outside the driver may be handed out that long
+	CacheTTL Duration `yaml:"cache_ttl"`
+
+	// UnhealthyAction is what the controller does with an existing SVM in
+	// the error state: "fail" CreateVolume with FailedPrecondition
+	// (default), "repair" it through the ARCA API, or "ignore" the state
+	// (controller only)
+	UnhealthyAction string `yaml:"unhealthy_action"`
+
+	// ReadyTimeout is how long CreateVolume waits for an SVM being created
+	// or repaired to become available (controller only; default 2m)
+	ReadyTimeout Duration `yaml:"ready_timeout"`
+
+	// DNSNameTemplate publishes an SVM hostname in volume context
+	// ("{svm}" is replaced with the SVM name), e.g. "{svm}.storage.example.com"
+	DNSNameTemplate string `yaml:"dns_name_template"`
+
+	// ExportPathTemplate is the NFS export nodes mount for an SVM ("{svm}"
+	// is replaced with the SVM name; default "/exports/{svm}"). An export
+	// path reported by the ARCA API for an SVM takes precedence.
+	ExportPathTemplate string `yaml:"export_path_template"`
+
+	// SnapshotDir is the directory at each SVM's root new snapshots are
+	// created in, readable by root only, excluded from quota accounting and
+	// never staged as a volume (default ".snapshots")
+	SnapshotDir string `yaml:"snapshot_dir"`
+
+	// Migrations enables the ArcaMigration controller (controller only;
+	// requires the arcamigrations CRD)
+	Migrations bool `yaml:"migrations"`
+
+	// MigrationInterval is how often ArcaMigrations are reconciled
+	MigrationInterval Duration `yaml:"migration_interval"`
+
+	// CapacityReservations honors ArcaCapacityReservations in GetCapacity and
+	// CreateVolume (controller only; requires the arcacapacityreservations CRD)
+	CapacityReservations bool `yaml:"capacity_reservations"`
+
+	// ReservationInterval is how often reservations are recomputed
+	ReservationInterval Duration `yaml:"reservation_interval"`
+
+	// CapacityCacheTTL is how long GetCapacity results are reused per
+	// StorageClass and topology (controller only; default 10s)
+	CapacityCacheTTL Duration `yaml:"capacity_cache_ttl"`
+
+	// ExportAudit records each SVM's export rules in an ArcaSVM and flags
+	// drift from ExportClients (controller only; requires the arcasvms CRD)
+	ExportAudit bool `yaml:"export_audit"`
+
+	// ExportAuditInterval is how often export rules are audited
+	ExportAuditInterval Duration `yaml:"export_audit_interval"`
+
+	// ExportClients are the addresses or CIDRs of the cluster nodes every
+	// SVM is expected to export to
+	ExportClients []string `yaml:"export_clients"`
+
+	// ScopeExports replaces the default export rules of new SVMs with
+	// read-write rules for ExportClients only
+	ScopeExports bool `yaml:"scope_exports"`
+
+	// AttributeReconcile checks each SVM's MTU and gateway against the
+	// network configuration during the export audit: "report" flags drift
+	// in the ArcaSVM, "update" also corrects the SVM; empty disables it
+	AttributeReconcile string `yaml:"attribute_reconcile"`
+
+	// NamespaceCleanup watches Namespace deletions, warns about volumes
+	// still bound in terminating namespaces and, once a deleted namespace's
+	// volumes and snapshots are gone, "report"s or "delete"s its SVM; empty
+	// disables it (controller only)
+	NamespaceCleanup string `yaml:"namespace_cleanup"`
+
+	// NamespaceCleanupInterval is how often namespaces are checked besides
+	// on Namespace deletions
+	NamespaceCleanupInterval Duration `yaml:"namespace_cleanup_interval"`
+
+	// Events records the creation, VIP assignment, failed creation and
+	// deletion of each SVM as Events on its namespace (controller only)
+	Events bool `yaml:"events"`
+}
+
+// LoggingConfig configures log verbosity and output
+type LoggingConfig struct {
+	// Level is the default verbosity (klog -v); an explicit --v flag wins
+	Level int `yaml:"level"`
+	// Subsystems set the verbosity of single subsystems (arca, driver,
+	// mount, store, kubernetes, ...) instead of Level
+	Subsystems map[string]int `yaml:"subsystems"`
+	// Format is "text" (klog's format, default) or "json"
+	Format string `yaml:"format"`
+	// File writes logs to this path instead of stderr, rotated at
+	// FileMaxSize MiB (default 100) keeping FileMaxBackups old files
+	// (default 3)
+	File           string `yaml:"file"`
+	FileMaxSize    int    `yaml:"file_max_size"`
+	FileMaxBackups int    `yaml:"file_max_backups"`
+}
+
+// RateLimitConfig is a token bucket: qps calls per second sustained, with
+// bursts of up to burst calls
+type RateLimitConfig struct {
+	QPS   float64 `yaml:"qps"`
+	Burst int     `yaml:"burst"`
+}
+
+// NamespaceRateLimitConfig limits each namespace to qps/burst (unlimited
+// when qps is 0), with per-namespace overrides
+type NamespaceRateLimitConfig struct {
+	RateLimitConfig `yaml:",inline"`
+	Namespaces      map[string]RateLimitConfig `yaml:"namespaces"`
 }
 
 // Duration is a wrapper for time.Duration to support YAML unmarshaling
@@ -82,6 +602,31 @@ func (d Duration) MarshalYAML() (interface{}, error) {
 	return d.Duration.String(), nil
 }
 
+// FileMode is a wrapper for os.FileMode to support octal YAML strings
+type FileMode struct {
+	os.FileMode
+}
+
+func (m *FileMode) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid file mode %q: %w", s, err)
+	}
+	if mode&^uint64(os.ModePerm) != 0 {
+		return fmt.Errorf("invalid file mode %q: only permission bits are allowed", s)
+	}
+	m.FileMode = os.FileMode(mode)
+	return nil
+}
+
+func (m FileMode) MarshalYAML() (interface{}, error) {
+	return fmt.Sprintf("%04o", uint32(m.FileMode)), nil
+}
+
 // LoadConfig loads configuration from a file
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -101,12 +646,56 @@ func LoadConfig(path string) (*Config, error) {
 	if config.Network.MTU == 0 {
 		config.Network.MTU = 1500
 	}
-	
+	if config.Driver.OrphanSnapshotPolicy == "" {
+		config.Driver.OrphanSnapshotPolicy = orphan.SnapshotPolicyReport
+	}
+	if config.Driver.CRDWait.Duration == 0 {
+		config.Driver.CRDWait.Duration = time.Minute
+	}
+	if config.Driver.DNSCacheTTL.Duration == 0 {
+		config.Driver.DNSCacheTTL.Duration = 5 * time.Minute
+	}
+	if config.Driver.StagingDirMode.FileMode == 0 {
+		config.Driver.StagingDirMode.FileMode = 0750
+	}
+	if config.Driver.TargetDirMode.FileMode == 0 {
+		config.Driver.TargetDirMode.FileMode = 0750
+	}
+	if config.Logging.FileMaxSize == 0 {
+		config.Logging.FileMaxSize = 100
+	}
+	if config.Logging.FileMaxBackups == 0 {
+		config.Logging.FileMaxBackups = 3
+	}
+
 	// Override auth token from environment if set
 	if envToken := os.Getenv("ARCA_AUTH_TOKEN"); envToken != "" {
 		config.ARCA.AuthToken = envToken
 	}
 
+	if envKey := os.Getenv("ARCA_VOLUME_CONTEXT_KEY"); envKey != "" {
+		config.Driver.VolumeContextKey = envKey
+	}
+
+	if envSalt := os.Getenv("ARCA_SNAPSHOT_ID_SALT"); envSalt != "" {
+		config.Driver.SnapshotIDSalt = envSalt
+	}
+
+	for i := range config.Tenants {
+		tenant := &config.Tenants[i]
+		if tenant.ARCA.Timeout.Duration == 0 {
+			tenant.ARCA.Timeout.Duration = 30 * time.Second
+		}
+		if tenant.Network != nil && tenant.Network.MTU == 0 {
+			tenant.Network.MTU = config.Network.MTU
+		}
+		if tenant.AuthTokenEnv != "" {
+			if envToken := os.Getenv(tenant.AuthTokenEnv); envToken != "" {
+				tenant.ARCA.AuthToken = envToken
+			}
+		}
+	}
+
 	return &config, nil
 }
 
@@ -115,56 +704,598 @@ func (c *Config) Validate() error {
 	if c.ARCA.BaseURL == "" {
 		return fmt.Errorf("arca.base_url is required")
 	}
+	if err := validateArca("arca", &c.ARCA); err != nil {
+		return err
+	}
+
+	if err := validateNetwork("network", &c.Network); err != nil {
+		return err
+	}
+
+	if c.SVM.CacheTTL.Duration < 0 {
+		return fmt.Errorf("svm.cache_ttl must not be negative")
+	}
+
+	switch arca.UnhealthyAction(c.SVM.UnhealthyAction) {
+	case "", arca.UnhealthyFail, arca.UnhealthyRepair, arca.UnhealthyIgnore:
+	default:
+		return fmt.Errorf("svm.unhealthy_action must be %q, %q or %q", arca.UnhealthyFail, arca.UnhealthyRepair, arca.UnhealthyIgnore)
+	}
+	if c.SVM.ReadyTimeout.Duration < 0 {
+		return fmt.Errorf("svm.ready_timeout must not be negative")
+	}
+
+	if c.SVM.ExportPathTemplate != "" && !strings.HasPrefix(c.SVM.ExportPathTemplate, "/") {
+		return fmt.Errorf("svm.export_path_template must be an absolute path")
+	}
+
+	if c.SVM.DNSNameTemplate != "" && !strings.Contains(c.SVM.DNSNameTemplate, "{svm}") {
+		return fmt.Errorf("svm.dns_name_template must contain {svm}")
+	}
+
+	for name, d := range map[string]Duration{
+		"create_volume":   c.Driver.OperationTimeouts.CreateVolume,
+		"delete_volume":   c.Driver.OperationTimeouts.DeleteVolume,
+		"expand_volume":   c.Driver.OperationTimeouts.ExpandVolume,
+		"create_snapshot": c.Driver.OperationTimeouts.CreateSnapshot,
+		"delete_snapshot": c.Driver.OperationTimeouts.DeleteSnapshot,
+	} {
+		if d.Duration < 0 {
+			return fmt.Errorf("driver.operation_timeouts.%s must not be negative", name)
+		}
+	}
+
+	if c.Driver.CreateVolumeSLO.Duration < 0 {
+		return fmt.Errorf("driver.create_volume_slo must not be negative")
+	}
+	if c.Driver.VolumeStatsQuotaTTL.Duration < 0 {
+		return fmt.Errorf("driver.volume_stats_quota_ttl must not be negative")
+	}
+	if c.Driver.QuotaVerifyTimeout.Duration < 0 {
+		return fmt.Errorf("driver.quota_verify_timeout must not be negative")
+	}
+
+	for rpc, r := range c.Driver.RPCRetries {
+		if !slices.Contains(driver.RetryPolicyRPCs, rpc) {
+			return fmt.Errorf("driver.rpc_retries: %q is not an RPC that calls ARCA (%s)", rpc, strings.Join(driver.RetryPolicyRPCs, ", "))
+		}
+		if err := validateRetry("driver.rpc_retries."+rpc, r.Retries, r.RetryOn); err != nil {
+			return err
+		}
+	}
+
+	if err := validateRateLimit("driver.namespace_rate_limit", c.Driver.NamespaceRateLimit.RateLimitConfig); err != nil {
+		return err
+	}
+	for namespace, limit := range c.Driver.NamespaceRateLimit.Namespaces {
+		if err := validateRateLimit(fmt.Sprintf("driver.namespace_rate_limit.namespaces[%s]", namespace), limit); err != nil {
+			return err
+		}
+	}
+
+	switch c.Driver.IDMode {
+	case "", idempotency.ModeHash, idempotency.ModeUUID:
+	default:
+		return fmt.Errorf("driver.id_mode must be %q or %q", idempotency.ModeHash, idempotency.ModeUUID)
+	}
+	if c.Driver.SnapshotIDSalt != "" {
+		if c.Driver.IDMode == idempotency.ModeUUID {
+			return fmt.Errorf("driver.snapshot_id_salt requires driver.id_mode %q", idempotency.ModeHash)
+		}
+		if len(c.Driver.SnapshotIDSalt) < 16 {
+			return fmt.Errorf("driver.snapshot_id_salt must be at least 16 bytes")
+		}
+	}
+	if c.Driver.SnapshotIDCompat && c.Driver.SnapshotIDSalt == "" {
+		return fmt.Errorf("driver.snapshot_id_compat requires driver.snapshot_id_salt")
+	}
+
+	switch c.Driver.LockBackend {
+	case "", lock.BackendLease, lock.BackendCRD:
+	default:
+		return fmt.Errorf("driver.lock_backend must be %q or %q", lock.BackendLease, lock.BackendCRD)
+	}
 
-	if len(c.Network.Pools) == 0 {
-		return fmt.Errorf("at least one network pool is required")
+	if c.Driver.MountHelperSocket != "" && !path.IsAbs(c.Driver.MountHelperSocket) {
+		return fmt.Errorf("driver.mount_helper_socket must be an absolute path")
 	}
+	for _, p := range c.Driver.MountHelperAllowedPaths {
+		if !path.IsAbs(p) || path.Clean(p) == "/" {
+			return fmt.Errorf("driver.mount_helper_allowed_paths entry %q must be an absolute path other than /", p)
+		}
+	}
+
+	if _, err := c.ToFSCacheBudget(); err != nil {
+		return err
+	}
+	if c.Driver.FSCacheDir != "" && !path.IsAbs(c.Driver.FSCacheDir) {
+		return fmt.Errorf("driver.fscache_dir must be an absolute path")
+	}
+
+	if c.Driver.StateJournalCompaction < 0 {
+		return fmt.Errorf("driver.state_journal_compaction must not be negative")
+	}
+
+	for name, v := range map[string]int{
+		"volume_event_queue_size":     c.Driver.VolumeEventQueueSize,
+		"volume_event_max_per_volume": c.Driver.VolumeEventMaxPerVolume,
+		"volume_event_max_total":      c.Driver.VolumeEventMaxTotal,
+	} {
+		if v < 0 {
+			return fmt.Errorf("driver.%s must not be negative", name)
+		}
+	}
+	if c.Driver.VolumeEventRetention.Duration < 0 {
+		return fmt.Errorf("driver.volume_event_retention must not be negative")
+	}
+
+	switch mount.PersistMode(c.Driver.StatePersistMode) {
+	case "", mount.PersistSync, mount.PersistCoalesce, mount.PersistAsync:
+	default:
+		return fmt.Errorf("driver.state_persist_mode must be %q, %q or %q", mount.PersistSync, mount.PersistCoalesce, mount.PersistAsync)
+	}
+	switch mount.StateFormat(c.Driver.StateFormat) {
+	case "", mount.StateFormatJSON, mount.StateFormatProtobuf:
+	default:
+		return fmt.Errorf("driver.state_format must be %q or %q", mount.StateFormatJSON, mount.StateFormatProtobuf)
+	}
+
+	if c.Driver.Endpoint == "" {
+		return fmt.Errorf("driver.endpoint is required")
+	}
+
+	if c.Driver.TokenExpiration.Duration != 0 && c.Driver.TokenExpiration.Duration < 10*time.Minute {
+		return fmt.Errorf("driver.token_expiration must be at least 10m")
+	}
+
+	if c.Driver.VolumeContextKey != "" && len(c.Driver.VolumeContextKey) < 32 {
+		return fmt.Errorf("driver.volume_context_key must be at least 32 bytes")
+	}
+	if c.Driver.RequireSignedVolumeContext && c.Driver.VolumeContextKey == "" {
+		return fmt.Errorf("driver.require_signed_volume_context requires driver.volume_context_key")
+	}
+
+	if err := validateLogging(&c.Logging); err != nil {
+		return err
+	}
+
+	if c.SVM.ExportAudit && len(c.SVM.ExportClients) == 0 {
+		return fmt.Errorf("svm.export_clients is required when svm.export_audit is enabled")
+	}
+	if c.SVM.ScopeExports && len(c.SVM.ExportClients) == 0 {
+		return fmt.Errorf("svm.export_clients is required when svm.scope_exports is enabled")
+	}
+	switch c.Driver.OrphanSnapshotPolicy {
+	case "", orphan.SnapshotPolicyReport, orphan.SnapshotPolicyDelete:
+	default:
+		return fmt.Errorf("driver.orphan_snapshot_policy must be %q or %q", orphan.SnapshotPolicyReport, orphan.SnapshotPolicyDelete)
+	}
+
+	switch c.SVM.AttributeReconcile {
+	case "", exportaudit.AttributesReport, exportaudit.AttributesUpdate:
+	default:
+		return fmt.Errorf("svm.attribute_reconcile must be %q or %q", exportaudit.AttributesReport, exportaudit.AttributesUpdate)
+	}
+	if c.SVM.AttributeReconcile != "" && !c.SVM.ExportAudit {
+		return fmt.Errorf("svm.attribute_reconcile requires svm.export_audit")
+	}
+	if c.SVM.SnapshotDir != "" {
+		if err := driver.CheckSnapshotDir(c.SVM.SnapshotDir); err != nil {
+			return fmt.Errorf("svm.snapshot_dir: %w", err)
+		}
+	}
+	switch c.SVM.NamespaceCleanup {
+	case "", nscleanup.ModeReport, nscleanup.ModeDelete:
+	default:
+		return fmt.Errorf("svm.namespace_cleanup must be %q or %q", nscleanup.ModeReport, nscleanup.ModeDelete)
+	}
+	for i, client := range c.SVM.ExportClients {
+		if _, err := netip.ParsePrefix(client); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(client); err != nil {
+			return fmt.Errorf("svm.export_clients[%d]: %q is not an address or CIDR", i, client)
+		}
+	}
+
+	rules, err := c.ToPolicyRules()
+	if err != nil {
+		return err
+	}
+	if err := policy.ValidateRules(rules); err != nil {
+		return fmt.Errorf("invalid policy.rules: %w", err)
+	}
+
+	if err := c.validateTenants(); err != nil {
+		return err
+	}
+
+	if c.Driver.Topology {
+		if err := c.validateTopology(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateTopology checks that every pool has a name usable as topology
+// key, naming the same network wherever it is configured
+func (c *Config) validateTopology() error {
+	seen := make(map[string]arca.PoolConfig)
+	for _, pool := range c.TopologyPools() {
+		if pool.Name == "" {
+			return fmt.Errorf("driver.topology requires every pool to have a name (pool %s)", pool.CIDR)
+		}
+		if errs := validation.IsQualifiedName(driver.PoolTopologyKey(pool.Name)); len(errs) > 0 {
+			return fmt.Errorf("pool name %q is not usable in topology key %s: %s", pool.Name, driver.PoolTopologyKey(pool.Name), strings.Join(errs, "; "))
+		}
+		if prev, ok := seen[pool.Name]; ok && (prev.CIDR != pool.CIDR || prev.VLANID != pool.VLANID) {
+			return fmt.Errorf("driver.topology requires unique pool names, %q names both %s (VLAN %d) and %s (VLAN %d)",
+				pool.Name, prev.CIDR, prev.VLANID, pool.CIDR, pool.VLANID)
+		}
+		seen[pool.Name] = pool
+	}
+	return nil
+}
+
+// TopologyPools returns the pools of the top-level network and of every
+// tenant, which the node plugin probes for topology
+func (c *Config) TopologyPools() []arca.PoolConfig {
+	pools := c.Network.ToArcaPoolConfigs()
+	for i := range c.Tenants {
+		if c.Tenants[i].Network != nil {
+			pools = append(pools, c.Tenants[i].Network.ToArcaPoolConfigs()...)
+		}
+	}
+	return pools
+}
 
-	for i, pool := range c.Network.Pools {
+// validateRateLimit validates the rate limit at key
+func validateRateLimit(key string, l RateLimitConfig) error {
+	if l.QPS < 0 {
+		return fmt.Errorf("%s.qps must not be negative", key)
+	}
+	if l.Burst < 0 {
+		return fmt.Errorf("%s.burst must not be negative", key)
+	}
+	return nil
+}
+
+// validateLogging validates the logging section
+func validateLogging(l *LoggingConfig) error {
+	if l.Level < 0 {
+		return fmt.Errorf("logging.level must not be negative")
+	}
+	for name, level := range l.Subsystems {
+		if !slices.Contains(logging.Subsystems, name) {
+			return fmt.Errorf("logging.subsystems: unknown subsystem %q (known: %s)", name, strings.Join(logging.Subsystems, ", "))
+		}
+		if level < 0 {
+			return fmt.Errorf("logging.subsystems.%s must not be negative", name)
+		}
+	}
+	switch l.Format {
+	case "", logging.FormatText, logging.FormatJSON:
+	default:
+		return fmt.Errorf("logging.format must be %q or %q", logging.FormatText, logging.FormatJSON)
+	}
+	if l.FileMaxSize < 0 || l.FileMaxBackups < 0 {
+		return fmt.Errorf("logging.file_max_size and logging.file_max_backups must not be negative")
+	}
+	return nil
+}
+
+// validateArca validates ARCA endpoint settings under the given config key prefix
+func validateArca(prefix string, a *ArcaConfig) error {
+	switch arca.EndpointPolicy(a.EndpointPolicy) {
+	case "", arca.EndpointFailover, arca.EndpointRoundRobin:
+	default:
+		return fmt.Errorf("%s.endpoint_policy must be %q or %q", prefix, arca.EndpointFailover, arca.EndpointRoundRobin)
+	}
+	for i, endpoint := range a.Endpoints {
+		if endpoint == "" {
+			return fmt.Errorf("%s.endpoints[%d] must not be empty", prefix, i)
+		}
+	}
+	for name, d := range map[string]Duration{
+		"timeout":            a.Timeout,
+		"timeouts.svm":       a.Timeouts.SVM,
+		"timeouts.directory": a.Timeouts.Directory,
+		"timeouts.snapshot":  a.Timeouts.Snapshot,
+		"timeouts.quota":     a.Timeouts.Quota,
+		"stuck_call_ceiling": a.StuckCallCeiling,
+	} {
+		if d.Duration < 0 {
+			return fmt.Errorf("%s.%s must not be negative", prefix, name)
+		}
+	}
+	if a.StuckCallReconnect < 0 {
+		return fmt.Errorf("%s.stuck_call_reconnect must not be negative", prefix)
+	}
+	if a.MaxResponseSize < 0 {
+		return fmt.Errorf("%s.max_response_size must not be negative", prefix)
+	}
+	return validateRetry(prefix, a.Retries, a.RetryOn)
+}
+
+// validateRetry validates retry settings under the given config key prefix
+func validateRetry(prefix string, retries *int, retryOn []string) error {
+	if retries != nil && *retries < 0 {
+		return fmt.Errorf("%s.retries must not be negative", prefix)
+	}
+	for _, class := range retryOn {
+		if !slices.Contains(arca.RetryClasses, class) {
+			return fmt.Errorf("%s.retry_on entry %q must be one of %s", prefix, class, strings.Join(arca.RetryClasses, ", "))
+		}
+	}
+	return nil
+}
+
+// validateNetwork validates IP pools under the given config key prefix
+func validateNetwork(prefix string, n *NetworkConfig) error {
+	if len(n.Pools) == 0 {
+		return fmt.Errorf("at least one %s pool is required", prefix)
+	}
+
+	for i, pool := range n.Pools {
 		if pool.CIDR == "" {
-			return fmt.Errorf("network.pools[%d].cidr is required", i)
+			return fmt.Errorf("%s.pools[%d].cidr is required", prefix, i)
 		}
 		if pool.VLANID == 0 {
-			return fmt.Errorf("network.pools[%d].vlan is required", i)
+			return fmt.Errorf("%s.pools[%d].vlan is required", prefix, i)
 		}
 		if pool.Gateway == "" {
-			return fmt.Errorf("network.pools[%d].gateway is required", i)
+			return fmt.Errorf("%s.pools[%d].gateway is required", prefix, i)
 		}
 	}
 
-	if c.Driver.Endpoint == "" {
-		return fmt.Errorf("driver.endpoint is required")
+	switch arca.AllocationStrategy(n.AllocationStrategy) {
+	case "", arca.AllocationRoundRobin, arca.AllocationHash:
+	default:
+		return fmt.Errorf("%s.allocation_strategy must be %q or %q", prefix, arca.AllocationRoundRobin, arca.AllocationHash)
+	}
+
+	if n.ConflictHold.Duration < 0 {
+		return fmt.Errorf("%s.conflict_hold must not be negative", prefix)
+	}
+
+	if len(n.Affinity) > 0 {
+		// Selectors need a Kubernetes client to evaluate; parse them here
+		// and check the rest without
+		rules := n.ToPoolRules()
+		for i := range rules {
+			if _, err := labels.Parse(rules[i].Selector); err != nil {
+				return fmt.Errorf("%s.affinity[%d].namespace_selector is invalid: %w", prefix, i, err)
+			}
+			rules[i].Selector = ""
+		}
+		if _, err := policy.NewPoolAffinity(rules, n.PoolNames(), nil); err != nil {
+			return fmt.Errorf("invalid %s.affinity: %w", prefix, err)
+		}
 	}
 
 	return nil
 }
 
+// validateTenants validates per-namespace backend routing
+func (c *Config) validateTenants() error {
+	// Node plugins exchange tokens with the arca backend only, which cannot
+	// grant mounts of SVMs on tenant backends
+	if len(c.Tenants) > 0 && c.Driver.TokenAudience != "" {
+		return fmt.Errorf("driver.token_audience cannot be combined with tenants: mount grants are only exchanged with the arca backend")
+	}
+
+	names := make(map[string]bool, len(c.Tenants))
+	for i, t := range c.Tenants {
+		prefix := fmt.Sprintf("tenants[%d]", i)
+		if t.Name == "" {
+			return fmt.Errorf("%s.name is required", prefix)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("%s.name %q is duplicated", prefix, t.Name)
+		}
+		names[t.Name] = true
+
+		if len(t.Namespaces) == 0 {
+			return fmt.Errorf("%s.namespaces is required", prefix)
+		}
+		for _, pattern := range t.Namespaces {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s.namespaces: invalid pattern %q: %w", prefix, pattern, err)
+			}
+		}
+		if t.ARCA.BaseURL == "" {
+			return fmt.Errorf("%s.arca.base_url is required", prefix)
+		}
+		if err := validateArca(prefix+".arca", &t.ARCA); err != nil {
+			return err
+		}
+		if t.Network != nil {
+			if err := validateNetwork(prefix+".network", t.Network); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // ToArcaClientConfig converts to ARCA client configuration
 func (c *Config) ToArcaClientConfig() *arca.ClientConfig {
+	return c.ARCA.toClientConfig()
+}
+
+// ToRetryPolicies converts driver.rpc_retries to ARCA retry policies per RPC
+func (c *Config) ToRetryPolicies() map[string]arca.RetryPolicy {
+	if len(c.Driver.RPCRetries) == 0 {
+		return nil
+	}
+	policies := make(map[string]arca.RetryPolicy, len(c.Driver.RPCRetries))
+	for rpc, r := range c.Driver.RPCRetries {
+		policy := arca.RetryPolicy{Retries: -1, RetryOn: r.RetryOn}
+		if r.Retries != nil {
+			policy.Retries = *r.Retries
+		}
+		policies[rpc] = policy
+	}
+	return policies
+}
+
+// ToLoggingOptions converts to logging options
+func (c *Config) ToLoggingOptions() logging.Options {
+	return logging.Options{
+		Level:      c.Logging.Level,
+		Subsystems: c.Logging.Subsystems,
+		Format:     c.Logging.Format,
+		File:       c.Logging.File,
+		MaxSize:    int64(c.Logging.FileMaxSize) << 20,
+		MaxBackups: c.Logging.FileMaxBackups,
+	}
+}
+
+// ToArcaPoolConfigs converts to ARCA pool configurations
+func (c *Config) ToArcaPoolConfigs() []arca.PoolConfig {
+	return c.Network.ToArcaPoolConfigs()
+}
+
+// ToArcaClientConfig converts the tenant's ARCA client configuration
+func (t *TenantConfig) ToArcaClientConfig() *arca.ClientConfig {
+	return t.ARCA.toClientConfig()
+}
+
+// TenantNetwork returns the tenant's network, falling back to the top-level one
+func (c *Config) TenantNetwork(t *TenantConfig) *NetworkConfig {
+	if t.Network != nil {
+		return t.Network
+	}
+	return &c.Network
+}
+
+// toClientConfig converts to ARCA client configuration
+func (a *ArcaConfig) toClientConfig() *arca.ClientConfig {
+	retries := 3
+	if a.Retries != nil {
+		retries = *a.Retries
+		if retries == 0 {
+			retries = -1
+		}
+	}
 	return &arca.ClientConfig{
-		BaseURL:    c.ARCA.BaseURL,
-		Timeout:    c.ARCA.Timeout.Duration,
-		RetryCount: 3,
-		AuthToken:  c.ARCA.AuthToken,
+		BaseURL:    a.BaseURL,
+		Timeout:    a.Timeout.Duration,
+		RetryCount: retries,
+		RetryOn:    a.RetryOn,
+		AuthToken:  a.AuthToken,
 		TLSConfig: &arca.TLSConfig{
-			CACertPath:     c.ARCA.TLS.CACertPath,
-			ClientCertPath: c.ARCA.TLS.ClientCertPath,
-			ClientKeyPath:  c.ARCA.TLS.ClientKeyPath,
-			InsecureSkip:   c.ARCA.TLS.InsecureSkip,
+			CACertPath:     a.TLS.CACertPath,
+			ClientCertPath: a.TLS.ClientCertPath,
+			ClientKeyPath:  a.TLS.ClientKeyPath,
+			InsecureSkip:   a.TLS.InsecureSkip,
 		},
+		Endpoints:        a.Endpoints,
+		EndpointPolicy:   arca.EndpointPolicy(a.EndpointPolicy),
+		EndpointCooldown: a.EndpointCooldown.Duration,
+		HealthCheckPath:  a.HealthCheckPath,
+		OperationTimeouts: arca.OperationTimeouts{
+			SVM:       a.Timeouts.SVM.Duration,
+			Directory: a.Timeouts.Directory.Duration,
+			Snapshot:  a.Timeouts.Snapshot.Duration,
+			Quota:     a.Timeouts.Quota.Duration,
+		},
+		StuckCallCeiling:   a.StuckCallCeiling.Duration,
+		StuckCallReconnect: a.StuckCallReconnect,
+		MaxResponseSize:    a.MaxResponseSize,
 	}
 }
 
-// ToArcaPoolConfigs converts to ARCA pool configurations
-func (c *Config) ToArcaPoolConfigs() []arca.PoolConfig {
-	pools := make([]arca.PoolConfig, len(c.Network.Pools))
-	for i, p := range c.Network.Pools {
+// ToArcaPoolConfigs converts the network's IP pools to ARCA pool configurations
+func (n *NetworkConfig) ToArcaPoolConfigs() []arca.PoolConfig {
+	pools := make([]arca.PoolConfig, len(n.Pools))
+	for i, p := range n.Pools {
 		pools[i] = arca.PoolConfig{
-			CIDR:    p.CIDR,
-			Range:   p.Range,
-			VLANID:  p.VLANID,
-			Gateway: p.Gateway,
+			Name:       p.Name,
+			Drained:    p.Drained,
+			CIDR:       p.CIDR,
+			Range:      p.Range,
+			VLANID:     p.VLANID,
+			Gateway:    p.Gateway,
+			Exclude:    p.Exclude,
+			StaticVIPs: p.StaticVIPs,
 		}
 	}
 	return pools
 }
+
+// PoolNames returns the names of the network's named pools; affinity rules
+// can only refer to those
+func (n *NetworkConfig) PoolNames() []string {
+	var names []string
+	for _, p := range n.Pools {
+		if p.Name != "" {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// ToPoolRules converts the network's affinity to pool affinity rules
+func (n *NetworkConfig) ToPoolRules() []policy.PoolRule {
+	rules := make([]policy.PoolRule, len(n.Affinity))
+	for i, a := range n.Affinity {
+		rules[i] = policy.PoolRule{
+			Pools:      a.Pools,
+			Namespaces: a.Namespaces,
+			Selector:   a.NamespaceSelector,
+		}
+	}
+	return rules
+}
+
+// ToPolicyRules converts to policy engine rules; policy.ValidateRules checks
+// the rules themselves
+func (c *Config) ToPolicyRules() ([]policy.Rule, error) {
+	rules := make([]policy.Rule, len(c.Policy.Rules))
+	for i, r := range c.Policy.Rules {
+		minBytes, err := parseCapacity(r.MinCapacity)
+		if err != nil {
+			return nil, fmt.Errorf("policy.rules[%d].min_capacity: %w", i, err)
+		}
+		maxBytes, err := parseCapacity(r.MaxCapacity)
+		if err != nil {
+			return nil, fmt.Errorf("policy.rules[%d].max_capacity: %w", i, err)
+		}
+		rules[i] = policy.Rule{
+			Name:             r.Name,
+			Namespaces:       r.Namespaces,
+			Parameters:       r.Parameters,
+			MinCapacityBytes: minBytes,
+			MaxCapacityBytes: maxBytes,
+			Message:          r.Message,
+		}
+	}
+	return rules, nil
+}
+
+// ToFSCacheBudget returns the cache budget per SVM in bytes (0 = unlimited)
+func (c *Config) ToFSCacheBudget() (int64, error) {
+	budget, err := parseCapacity(c.Driver.FSCacheBudgetPerSVM)
+	if err != nil {
+		return 0, fmt.Errorf("driver.fscache_budget_per_svm: %w", err)
+	}
+	if budget < 0 {
+		return 0, fmt.Errorf("driver.fscache_budget_per_svm must not be negative")
+	}
+	return budget, nil
+}
+
+// parseCapacity parses a Kubernetes quantity string into bytes (empty = 0)
+func parseCapacity(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q: %w", s, err)
+	}
+	return q.Value(), nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Watch polls the configuration file and invokes onChange with the new,
+// validated configuration whenever its content changes. Polling (instead of
+// inotify) is used because ConfigMap volumes are updated via symlink swaps.
+// Invalid configurations are logged and ignored.
+func Watch(ctx context.Context, path string, interval time.Duration, onChange func(*Config)) {
+	last, err := os.ReadFile(path)
+	if err != nil {
+		klog.Warningf("Config watcher: failed to read %s: %v", path, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			klog.Warningf("Config watcher: failed to read %s: %v", path, err)
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			klog.Errorf("Config watcher: ignoring unparsable configuration: %v", err)
+			last = data
+			continue
+		}
+		if err := cfg.Validate(); err != nil {
+			klog.Errorf("Config watcher: ignoring invalid configuration: %v", err)
+			last = data
+			continue
+		}
+
+		klog.Infof("Configuration file %s changed, reloading", path)
+		last = data
+		onChange(cfg)
+	}
+}
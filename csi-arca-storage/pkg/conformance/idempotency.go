@@ -0,0 +1,347 @@
+// Package conformance checks the driver's CSI behaviour end to end against
+// an in-memory ARCA backend. The idempotency check fires storms of
+// identical, concurrent controller calls - as external-provisioner and
+// external-snapshotter do when they retry - and verifies that each storm
+// changes the backend exactly once and that every call sees the same result.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/arca/arcatest"
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/lock"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// Defaults of Options
+const (
+	DefaultCount   = 32
+	DefaultLatency = 20 * time.Millisecond
+)
+
+// retryInterval is how long a call that was Aborted waits before it is
+// repeated, like the sidecars' retries
+const retryInterval = 10 * time.Millisecond
+
+// namespace is the namespace of the volumes created by the check
+const namespace = "conformance"
+
+// Options configure an idempotency check
+type Options struct {
+	// Count is the number of identical calls of each storm (DefaultCount)
+	Count int
+	// Latency delays every backend mutation, widening the windows in which
+	// the calls can interleave (DefaultLatency)
+	Latency time.Duration
+}
+
+// Result is the outcome of one storm
+type Result struct {
+	// Name describes the storm, e.g. "CreateVolume"
+	Name string
+	// Calls is the number of concurrent calls, Aborted the attempts that
+	// were rejected because an identical call was in progress and repeated
+	Calls   int
+	Aborted int
+	// Mutations are the backend changes the storm made, by mutation
+	Mutations map[string]int
+	// Failures describe what the storm did wrong; empty when it passed
+	Failures []string
+}
+
+// Passed reports whether the storm behaved as expected
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// failf records a failure of the storm
+func (r *Result) failf(format string, args ...interface{}) {
+	r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+}
+
+// checker runs storms against a controller driver with an in-memory
+// backend and store
+type checker struct {
+	opts   Options
+	server *arcatest.Server
+	store  store.Store
+	driver *driver.Driver
+}
+
+// RunIdempotency runs the idempotency check: storms of CreateVolume,
+// CreateSnapshot, CreateVolume from the snapshot, interleaved CreateVolume
+// and DeleteVolume of the restored volume, DeleteSnapshot and DeleteVolume
+func RunIdempotency(ctx context.Context, opts Options) ([]*Result, error) {
+	if opts.Count <= 0 {
+		opts.Count = DefaultCount
+	}
+	if opts.Latency <= 0 {
+		opts.Latency = DefaultLatency
+	}
+
+	server := arcatest.NewServer()
+	defer server.Close()
+	server.SetLatency(opts.Latency)
+
+	client, err := arca.NewClient(&arca.ClientConfig{BaseURL: server.URL})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ARCA client: %w", err)
+	}
+	allocator, err := arca.NewStandaloneAllocator([]arca.PoolConfig{{
+		Name:    "conformance",
+		CIDR:    "192.0.2.0/24",
+		Range:   "192.0.2.10-192.0.2.200",
+		VLANID:  100,
+		Gateway: "192.0.2.1",
+	}}, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create network allocator: %w", err)
+	}
+	locks := lock.NewManager(lock.NewLeaseBackend(fake.NewClientset(), "default"), "conformance")
+	st := store.NewMemoryStore()
+	d, err := driver.NewDriver(&driver.DriverConfig{
+		Mode:        "controller",
+		ArcaClient:  client,
+		SVMManager:  arca.NewSVMManager(client, allocator, locks, 0),
+		Allocator:   allocator,
+		LockManager: locks,
+		Store:       st,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create driver: %w", err)
+	}
+	c := &checker{opts: opts, server: server, store: st, driver: d}
+	return c.run(ctx)
+}
+
+// run runs the storms in order; each builds on the volumes and snapshots
+// of the previous ones
+func (c *checker) run(ctx context.Context) ([]*Result, error) {
+	var results []*Result
+
+	createReq := c.createVolumeRequest("pvc-conformance-source", nil)
+	result, resp := c.storm(ctx, "CreateVolume", func(ctx context.Context) (proto.Message, error) {
+		return c.driver.CreateVolume(ctx, createReq)
+	})
+	c.expect(result, map[string]int{arcatest.CreateSVM: 1, arcatest.CreateDirectory: 1, arcatest.SetQuota: 1})
+	results = append(results, result)
+	if resp == nil {
+		return results, nil
+	}
+	volumeID := resp.(*csi.CreateVolumeResponse).GetVolume().GetVolumeId()
+
+	snapshotReq := &csi.CreateSnapshotRequest{Name: "snapshot-conformance", SourceVolumeId: volumeID}
+	result, resp = c.storm(ctx, "CreateSnapshot", func(ctx context.Context) (proto.Message, error) {
+		return c.driver.CreateSnapshot(ctx, snapshotReq)
+	})
+	// The first snapshot of the SVM also creates its snapshot directory
+	c.expect(result, map[string]int{arcatest.CreateDirectory: 1, arcatest.CreateSnapshot: 1})
+	results = append(results, result)
+	if resp == nil {
+		return results, nil
+	}
+	snapshotID := resp.(*csi.CreateSnapshotResponse).GetSnapshot().GetSnapshotId()
+
+	restoreReq := c.createVolumeRequest("pvc-conformance-restore", &csi.VolumeContentSource{
+		Type: &csi.VolumeContentSource_Snapshot{
+			Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: snapshotID},
+		},
+	})
+	result, resp = c.storm(ctx, "CreateVolume from snapshot", func(ctx context.Context) (proto.Message, error) {
+		return c.driver.CreateVolume(ctx, restoreReq)
+	})
+	c.expect(result, map[string]int{arcatest.RestoreSnapshot: 1, arcatest.SetQuota: 1})
+	results = append(results, result)
+
+	if resp != nil {
+		restoredID := resp.(*csi.CreateVolumeResponse).GetVolume().GetVolumeId()
+		results = append(results, c.createDeleteStorm(ctx, restoreReq, restoredID))
+	}
+
+	deleteSnapshotReq := &csi.DeleteSnapshotRequest{SnapshotId: snapshotID}
+	result, _ = c.storm(ctx, "DeleteSnapshot", func(ctx context.Context) (proto.Message, error) {
+		return c.driver.DeleteSnapshot(ctx, deleteSnapshotReq)
+	})
+	c.expect(result, map[string]int{arcatest.DeleteSnapshot: 1})
+	results = append(results, result)
+
+	deleteReq := &csi.DeleteVolumeRequest{VolumeId: volumeID}
+	result, _ = c.storm(ctx, "DeleteVolume", func(ctx context.Context) (proto.Message, error) {
+		return c.driver.DeleteVolume(ctx, deleteReq)
+	})
+	c.expect(result, map[string]int{arcatest.DeleteDirectory: 1})
+	results = append(results, result)
+
+	return results, nil
+}
+
+// createVolumeRequest returns the CreateVolume request of a 1Gi volume in
+// the check's namespace
+func (c *checker) createVolumeRequest(name string, source *csi.VolumeContentSource) *csi.CreateVolumeRequest {
+	return &csi.CreateVolumeRequest{
+		Name:          name,
+		CapacityRange: &csi.CapacityRange{RequiredBytes: 1 << 30},
+		VolumeCapabilities: []*csi.VolumeCapability{{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+		}},
+		Parameters: map[string]string{
+			"csi.storage.k8s.io/pvc/namespace": namespace,
+			"csi.storage.k8s.io/pvc/name":      name,
+		},
+		VolumeContentSource: source,
+	}
+}
+
+// storm makes Count concurrent calls of call, repeating those that were
+// Aborted, and checks that all of them succeed with the same response. It
+// returns the result and the response, or nil when no call succeeded.
+func (c *checker) storm(ctx context.Context, name string, call func(context.Context) (proto.Message, error)) (*Result, proto.Message) {
+	result := &Result{Name: name, Calls: c.opts.Count}
+	before := c.server.Mutations()
+
+	responses := make([]proto.Message, c.opts.Count)
+	errs := make([]error, c.opts.Count)
+	aborted := make([]int, c.opts.Count)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < c.opts.Count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			responses[i], errs[i], aborted[i] = callUntilDone(ctx, call)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	result.Mutations = mutationsSince(before, c.server.Mutations())
+	var first proto.Message
+	for i := range responses {
+		result.Aborted += aborted[i]
+		if errs[i] != nil {
+			result.failf("call %d failed: %v", i, errs[i])
+			continue
+		}
+		if first == nil {
+			first = responses[i]
+		} else if !proto.Equal(first, responses[i]) {
+			result.failf("call %d returned %v, call 0 returned %v", i, responses[i], first)
+		}
+	}
+	return result, first
+}
+
+// createDeleteStorm makes Count concurrent calls, alternately CreateVolume
+// with req and DeleteVolume of volumeID, the volume req created. The
+// volume may end up existing or not, but its record and its directory must
+// agree.
+func (c *checker) createDeleteStorm(ctx context.Context, req *csi.CreateVolumeRequest, volumeID string) *Result {
+	info, err := c.store.GetVolume(volumeID)
+	if err != nil {
+		result := &Result{Name: "CreateVolume/DeleteVolume", Calls: c.opts.Count}
+		result.failf("volume %s is not recorded: %v", volumeID, err)
+		return result
+	}
+
+	var mu sync.Mutex
+	calls := 0
+	deleteReq := &csi.DeleteVolumeRequest{VolumeId: volumeID}
+	result, _ := c.storm(ctx, "CreateVolume/DeleteVolume", func(ctx context.Context) (proto.Message, error) {
+		mu.Lock()
+		calls++
+		create := calls%2 == 1
+		mu.Unlock()
+		if create {
+			if _, err := c.driver.CreateVolume(ctx, req); err != nil {
+				return nil, err
+			}
+		} else if _, err := c.driver.DeleteVolume(ctx, deleteReq); err != nil {
+			return nil, err
+		}
+		// The responses differ by design; only errors count
+		return &csi.DeleteVolumeResponse{}, nil
+	})
+
+	_, err = c.store.GetVolume(volumeID)
+	recorded := err == nil
+	if err != nil && !store.IsNotFound(err) {
+		result.failf("failed to get volume %s: %v", volumeID, err)
+	}
+	exists := c.server.Exists(info.SVMName, info.Path)
+	switch {
+	case recorded && !exists:
+		result.failf("volume %s is recorded but its directory %s is gone", volumeID, info.Path)
+	case !recorded && exists:
+		result.failf("volume %s is not recorded but its directory %s is left", volumeID, info.Path)
+	}
+
+	if recorded {
+		if _, err := c.driver.DeleteVolume(ctx, deleteReq); err != nil {
+			result.failf("failed to delete volume %s: %v", volumeID, err)
+		}
+	}
+	return result
+}
+
+// expect checks that the storm made exactly the expected backend mutations
+func (c *checker) expect(result *Result, expected map[string]int) {
+	if !maps.Equal(result.Mutations, expected) {
+		result.failf("backend mutations %v, expected %v", formatMutations(result.Mutations), formatMutations(expected))
+	}
+}
+
+// callUntilDone calls call until it returns anything but Aborted, and
+// returns its response and error and how often it was Aborted
+func callUntilDone(ctx context.Context, call func(context.Context) (proto.Message, error)) (proto.Message, error, int) {
+	aborted := 0
+	for {
+		resp, err := call(ctx)
+		if status.Code(err) != codes.Aborted {
+			return resp, err, aborted
+		}
+		aborted++
+		select {
+		case <-time.After(retryInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err(), aborted
+		}
+	}
+}
+
+// mutationsSince returns the mutations counted in after but not in before
+func mutationsSince(before, after map[string]int) map[string]int {
+	delta := make(map[string]int)
+	for name, n := range after {
+		if n > before[name] {
+			delta[name] = n - before[name]
+		}
+	}
+	return delta
+}
+
+// formatMutations formats mutations in a stable order
+func formatMutations(mutations map[string]int) string {
+	s := ""
+	for _, name := range slices.Sorted(maps.Keys(mutations)) {
+		if s != "" {
+			s += " "
+		}
+		s += fmt.Sprintf("%s=%d", name, mutations[name])
+	}
+	return "[" + s + "]"
+}
@@ -0,0 +1,33 @@
+package conformance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akam1o/csi-arca-storage/pkg/conformance"
+)
+
+func TestIdempotency(t *testing.T) {
+	opts := conformance.Options{Count: 8, Latency: 5 * time.Millisecond}
+	if testing.Short() {
+		opts.Count = 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	results, err := conformance.RunIdempotency(ctx, opts)
+	if err != nil {
+		t.Fatalf("RunIdempotency: %v", err)
+	}
+	// A storm whose calls all failed ends the check early
+	if len(results) != 6 {
+		t.Errorf("ran %d storms, want 6", len(results))
+	}
+	for _, r := range results {
+		for _, f := range r.Failures {
+			t.Errorf("%s: %s", r.Name, f)
+		}
+	}
+}
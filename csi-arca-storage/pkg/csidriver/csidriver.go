@@ -0,0 +1,107 @@
+// Package csidriver keeps the cluster's CSIDriver object in line with the
+// features enabled in the driver configuration.
+package csidriver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// Options are the configuration-dependent CSIDriver settings
+type Options struct {
+	// StorageCapacity lets the scheduler use CSIStorageCapacity objects
+	// (published from GetCapacity with capacity reservations)
+	StorageCapacity bool
+	// SELinuxMount lets kubelet mount with the pod's SELinux context
+	SELinuxMount bool
+	// TokenRequests are the service account tokens kubelet passes to
+	// NodePublishVolume; volumes are then republished periodically so the
+	// node plugin can renew what it derives from them
+	TokenRequests []storagev1.TokenRequest
+}
+
+// Spec returns the CSIDriver spec for the given options. The driver never
+// attaches volumes, needs pod information on mount and lets kubelet apply
+// fsGroup ownership to the NFS files.
+func Spec(opts Options) storagev1.CSIDriverSpec {
+	return storagev1.CSIDriverSpec{
+		AttachRequired:       toPtr(false),
+		PodInfoOnMount:       toPtr(true),
+		VolumeLifecycleModes: []storagev1.VolumeLifecycleMode{storagev1.VolumeLifecyclePersistent},
+		StorageCapacity:      toPtr(opts.StorageCapacity),
+		FSGroupPolicy:        toPtr(storagev1.FileFSGroupPolicy),
+		TokenRequests:        slices.Clone(opts.TokenRequests),
+		RequiresRepublish:    toPtr(len(opts.TokenRequests) > 0),
+		SELinuxMount:         toPtr(opts.SELinuxMount),
+	}
+}
+
+// toPtr returns a pointer to v
+func toPtr[T any](v T) *T {
+	return &v
+}
+
+// Ensure creates the named CSIDriver or updates it to spec. attachRequired
+// and volumeLifecycleModes are immutable, so a CSIDriver that differs in
+// them is deleted and recreated.
+func Ensure(ctx context.Context, clientset kubernetes.Interface, name string, spec storagev1.CSIDriverSpec) error {
+	drivers := clientset.StorageV1().CSIDrivers()
+
+	existing, err := drivers.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return create(ctx, clientset, name, spec)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get CSIDriver %s: %w", name, err)
+	}
+
+	if !equality.Semantic.DeepEqual(existing.Spec.AttachRequired, spec.AttachRequired) ||
+		!equality.Semantic.DeepEqual(existing.Spec.VolumeLifecycleModes, spec.VolumeLifecycleModes) {
+		klog.Warningf("Recreating CSIDriver %s to change immutable fields", name)
+		if err := drivers.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete CSIDriver %s: %w", name, err)
+		}
+		return create(ctx, clientset, name, spec)
+	}
+
+	// Leave fields the driver does not manage as they are
+	updated := existing.Spec
+	updated.PodInfoOnMount = spec.PodInfoOnMount
+	updated.StorageCapacity = spec.StorageCapacity
+	updated.FSGroupPolicy = spec.FSGroupPolicy
+	updated.TokenRequests = spec.TokenRequests
+	updated.RequiresRepublish = spec.RequiresRepublish
+	updated.SELinuxMount = spec.SELinuxMount
+	if equality.Semantic.DeepEqual(existing.Spec, updated) {
+		klog.V(2).Infof("CSIDriver %s is up to date", name)
+		return nil
+	}
+
+	existing.Spec = updated
+	if _, err := drivers.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update CSIDriver %s: %w", name, err)
+	}
+	klog.Infof("Updated CSIDriver %s", name)
+	return nil
+}
+
+// create creates the named CSIDriver
+func create(ctx context.Context, clientset kubernetes.Interface, name string, spec storagev1.CSIDriverSpec) error {
+	driver := &storagev1.CSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       spec,
+	}
+	if _, err := clientset.StorageV1().CSIDrivers().Create(ctx, driver, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create CSIDriver %s: %w", name, err)
+	}
+	klog.Infof("Created CSIDriver %s", name)
+	return nil
+}
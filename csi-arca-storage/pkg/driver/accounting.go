@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"context"
+
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+const (
+	// Parameters added by the external-provisioner and csi-snapshotter when
+	// run with --extra-create-metadata
+	paramPVName                    = "csi.storage.k8s.io/pv/name"
+	paramVolumeSnapshotName        = "csi.storage.k8s.io/volumesnapshot/name"
+	paramVolumeSnapshotNamespace   = "csi.storage.k8s.io/volumesnapshot/namespace"
+	paramVolumeSnapshotContentName = "csi.storage.k8s.io/volumesnapshotcontent/name"
+
+	// paramRequester is the StorageClass/VolumeSnapshotClass parameter naming
+	// the service account charged for the volume or snapshot
+	paramRequester = "requester"
+)
+
+// Snapshot ID schemes recorded in AnnotationSnapshotIDScheme
+const (
+	snapshotIDSchemeHash   = "hash"
+	snapshotIDSchemeSalted = "salted-hash"
+	snapshotIDSchemeUUID   = "uuid"
+)
+
+// volumeAnnotations returns the accounting annotations of a new volume. The
+// StorageClass is that of the request context, looked up from the PVC.
+func (d *Driver) volumeAnnotations(ctx context.Context, name, namespace, pvcName string, params map[string]string) map[string]string {
+	annotations := map[string]string{
+		store.AnnotationPVName:        name,
+		store.AnnotationPVC:           namespace + "/" + pvcName,
+		store.AnnotationDriverVersion: d.version,
+	}
+	if pv := params[paramPVName]; pv != "" {
+		annotations[store.AnnotationPVName] = pv
+	}
+	if requester := params[paramRequester]; requester != "" {
+		annotations[store.AnnotationRequester] = requester
+	}
+	if sc := requestInfoFrom(ctx).storageClass; sc != "" {
+		annotations[store.AnnotationStorageClass] = sc
+	}
+	return annotations
+}
+
+// snapshotAnnotations returns the accounting annotations of a new snapshot,
+// including the key and scheme its ID was assigned with. The StorageClass is
+// inherited from the source volume.
+func (d *Driver) snapshotAnnotations(source *store.VolumeInfo, snapshotKey string, params map[string]string) map[string]string {
+	annotations := map[string]string{
+		store.AnnotationDriverVersion:    d.version,
+		store.AnnotationSnapshotIDKey:    snapshotKey,
+		store.AnnotationSnapshotIDScheme: d.snapshotIDScheme,
+	}
+	if sc := source.Annotations[store.AnnotationStorageClass]; sc != "" {
+		annotations[store.AnnotationStorageClass] = sc
+	}
+	if name := params[paramVolumeSnapshotName]; name != "" {
+		annotations[store.AnnotationVolumeSnapshot] = params[paramVolumeSnapshotNamespace] + "/" + name
+	}
+	if content := params[paramVolumeSnapshotContentName]; content != "" {
+		annotations[store.AnnotationVolumeSnapshotContent] = content
+	}
+	if requester := params[paramRequester]; requester != "" {
+		annotations[store.AnnotationRequester] = requester
+	}
+	return annotations
+}
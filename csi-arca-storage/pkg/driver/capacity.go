@@ -0,0 +1,157 @@
+package driver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+)
+
+// checkReservedCapacity rejects a volume that would eat into capacity held
+// by other namespaces' ArcaCapacityReservations. The namespace's own
+// reservation covers the volume first; only the rest must fit into the
+// SVM's available capacity that is not held for anyone else.
+func (d *Driver) checkReservedCapacity(ctx context.Context, backend *arca.Backend, svmName, namespace string, capacityBytes int64) error {
+	if d.reservations == nil {
+		return nil
+	}
+
+	own, others := d.reservations.Held(backend.Name, namespace)
+	needed := max(capacityBytes-own, 0)
+	if needed == 0 || others == 0 {
+		return nil
+	}
+
+	capacity, err := backend.Client.GetSVMCapacity(ctx, svmName)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to get capacity of SVM %s: %v", svmName, err)
+	}
+	if free := capacity.AvailableBytes - others; needed > free {
+		return status.Errorf(codes.ResourceExhausted,
+			"%d bytes requested but only %d bytes are available on SVM %s (%d bytes are reserved for other namespaces)",
+			capacityBytes, max(free, 0)+own, svmName, others)
+	}
+	return nil
+}
+
+// StorageClass parameters selecting the SVM GetCapacity reports on; they
+// have no effect on CreateVolume
+const (
+	// paramCapacityNamespace reports the capacity of the namespace's SVM,
+	// on the namespace's backend
+	paramCapacityNamespace = "capacityNamespace"
+	// paramCapacitySVM reports the capacity of the named SVM
+	paramCapacitySVM = "capacitySVM"
+)
+
+// availableCapacity returns the capacity a GetCapacity request asks for,
+// less the capacity held by reservations: that of the SVM named by the
+// StorageClass parameters, that of the backend measured through an SVM in
+// the pools of the topology, or else that of the backend. A namespace's own
+// reservation is not subtracted from the capacity of its SVM.
+func (d *Driver) availableCapacity(ctx context.Context, params map[string]string, topology *csi.Topology) (int64, error) {
+	namespace := params[paramCapacityNamespace]
+	backend, err := d.backends.Get(arca.DefaultBackend)
+	if err != nil {
+		return 0, err
+	}
+	if namespace != "" {
+		backend = d.backends.ForNamespace(namespace)
+	}
+
+	svmName := params[paramCapacitySVM]
+	if svmName == "" && namespace != "" {
+		svmName = arca.SVMNameForNamespace(namespace)
+	}
+	if svmName != "" {
+		capacity, err := backend.Client.GetSVMCapacity(ctx, svmName)
+		switch {
+		case err == nil:
+			_, others := d.heldCapacity(backend.Name, namespace)
+			klog.V(4).Infof("Capacity of SVM %s: %d bytes available, %d bytes reserved for others", svmName, capacity.AvailableBytes, others)
+			return max(capacity.AvailableBytes-others, 0), nil
+		case errors.Is(err, arca.ErrSVMNotFound):
+			// The SVM is created from the backend's storage with the first
+			// volume
+			klog.V(4).Infof("SVM %s does not exist yet, reporting the capacity of backend %q", svmName, backend.Name)
+		default:
+			return 0, err
+		}
+	} else if pools := segmentPools(topology); d.topology && len(pools) > 0 {
+		available, found, err := d.poolsCapacity(ctx, backend, pools)
+		if err != nil {
+			return 0, err
+		}
+		if found {
+			own, others := d.heldCapacity(backend.Name, "")
+			return max(available-own-others, 0), nil
+		}
+		klog.V(4).Infof("No SVM in pools %s yet, reporting the capacity of backend %q", capacityTopology(topology), backend.Name)
+	}
+	return d.backendCapacity(ctx, backend)
+}
+
+// poolsCapacity returns the available capacity of a backend measured
+// through one of its SVMs in the given pools. Like backendCapacity it
+// measures once: every SVM reports the backend's shared storage. found is
+// false when no SVM is in the pools.
+func (d *Driver) poolsCapacity(ctx context.Context, backend *arca.Backend, pools map[string]bool) (available int64, found bool, err error) {
+	svms, err := backend.Client.ListSVMs(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	for i := range svms {
+		svm := &svms[i]
+		if pool, ok := backend.Allocator.PoolForSVM(svm); !ok || !pools[pool] {
+			continue
+		}
+		capacity, err := backend.Client.GetSVMCapacity(ctx, svm.Name)
+		if err != nil {
+			if errors.Is(err, arca.ErrSVMNotFound) {
+				continue
+			}
+			return 0, false, err
+		}
+		klog.V(4).Infof("Capacity of pool SVM %s: %d bytes available", svm.Name, capacity.AvailableBytes)
+		return capacity.AvailableBytes, true, nil
+	}
+	return 0, false, nil
+}
+
+// backendCapacity returns the available capacity of a backend that is not
+// held by reservations, measured through one of its SVMs (SVMs share the
+// backend's storage). It returns 0 (unknown) when there is no SVM yet.
+func (d *Driver) backendCapacity(ctx context.Context, backend *arca.Backend) (int64, error) {
+	svms, err := backend.Client.ListSVMs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, svm := range svms {
+		capacity, err := backend.Client.GetSVMCapacity(ctx, svm.Name)
+		if err != nil {
+			if errors.Is(err, arca.ErrSVMNotFound) {
+				continue
+			}
+			return 0, err
+		}
+		own, others := d.heldCapacity(backend.Name, "")
+		held := own + others
+		klog.V(4).Infof("Capacity of SVM %s: %d bytes available, %d bytes reserved", svm.Name, capacity.AvailableBytes, held)
+		return max(capacity.AvailableBytes-held, 0), nil
+	}
+	return 0, nil
+}
+
+// heldCapacity returns the capacity reservations hold on a backend for a
+// namespace and for all others; none without reservations
+func (d *Driver) heldCapacity(backend, namespace string) (own, others int64) {
+	if d.reservations == nil {
+		return 0, 0
+	}
+	return d.reservations.Held(backend, namespace)
+}
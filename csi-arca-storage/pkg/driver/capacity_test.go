@@ -0,0 +1,86 @@
+package driver_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/arca/arcatest"
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/lock"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// newTopologyDriver creates a controller plugin reporting pool topology on
+// an in-memory backend with one pool
+func newTopologyDriver(t *testing.T) *driver.Driver {
+	t.Helper()
+
+	server := arcatest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := arca.NewClient(&arca.ClientConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	allocator, err := arca.NewStandaloneAllocator([]arca.PoolConfig{{
+		Name:    "pool-a",
+		CIDR:    "192.0.2.0/24",
+		Range:   "192.0.2.10-192.0.2.200",
+		VLANID:  100,
+		Gateway: "192.0.2.1",
+	}}, client)
+	if err != nil {
+		t.Fatalf("NewStandaloneAllocator: %v", err)
+	}
+	locks := lock.NewManager(lock.NewLeaseBackend(fake.NewClientset(), "default"), "controller-1")
+
+	d, err := driver.NewDriver(&driver.DriverConfig{
+		Mode:        "controller",
+		ArcaClient:  client,
+		SVMManager:  arca.NewSVMManager(client, allocator, locks, 0),
+		Allocator:   allocator,
+		LockManager: locks,
+		Store:       store.NewMemoryStore(),
+		Topology:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewDriver: %v", err)
+	}
+	return d
+}
+
+func TestGetCapacityPoolTopologyMeasuresBackendOnce(t *testing.T) {
+	d := newTopologyDriver(t)
+
+	// Two SVMs in the pool, sharing the backend's storage
+	for _, namespace := range []string{"team-a", "team-b"} {
+		_, err := d.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+			Name:          "pvc-" + namespace,
+			CapacityRange: &csi.CapacityRange{RequiredBytes: 1 << 30},
+			VolumeCapabilities: []*csi.VolumeCapability{{
+				AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+				AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+			}},
+			Parameters: map[string]string{
+				"csi.storage.k8s.io/pvc/namespace": namespace,
+				"csi.storage.k8s.io/pvc/name":      "pvc-" + namespace,
+			},
+		})
+		if err != nil {
+			t.Fatalf("CreateVolume in %s: %v", namespace, err)
+		}
+	}
+
+	resp, err := d.GetCapacity(context.Background(), &csi.GetCapacityRequest{
+		AccessibleTopology: &csi.Topology{Segments: map[string]string{driver.DriverName + "/pool-pool-a": "true"}},
+	})
+	if err != nil {
+		t.Fatalf("GetCapacity: %v", err)
+	}
+	if want := int64(arcatest.DefaultSVMCapacity - 1<<30); resp.AvailableCapacity != want {
+		t.Errorf("GetCapacity = %d, want %d (the backend's capacity, counted once)", resp.AvailableCapacity, want)
+	}
+}
@@ -0,0 +1,148 @@
+package driver
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"golang.org/x/sync/singleflight"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// DefaultCapacityCacheTTL is how long a GetCapacity result is served from
+// the cache
+const DefaultCapacityCacheTTL = 10 * time.Second
+
+// capacityQueryTimeout bounds a backend query shared by concurrent
+// GetCapacity calls
+const capacityQueryTimeout = 30 * time.Second
+
+// capacityCacheEvictAfter is how long an expired entry, and its metrics,
+// are kept after its StorageClass or topology was last asked for
+const capacityCacheEvictAfter = 10 * time.Minute
+
+// capacityCache holds the available capacity per combination of
+// StorageClass parameters and topology. external-provisioner polls
+// GetCapacity for every combination; within the TTL the answers come from
+// the cache, and concurrent misses of one combination share a single
+// backend query.
+type capacityCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*capacityEntry
+	group   singleflight.Group
+}
+
+// capacityEntry is a cached GetCapacity result
+type capacityEntry struct {
+	parameters string
+	topology   string
+	available  int64
+	expires    time.Time
+}
+
+// newCapacityCache creates a cache whose entries live for ttl (0 = default)
+func newCapacityCache(ttl time.Duration) *capacityCache {
+	if ttl <= 0 {
+		ttl = DefaultCapacityCacheTTL
+	}
+	return &capacityCache{ttl: ttl, entries: make(map[string]*capacityEntry)}
+}
+
+// get returns the cached capacity of the request's StorageClass and
+// topology, calling compute when it is missing or expired. Errors are not
+// cached. The query outlives the call that started it, so that calls
+// waiting for it do not fail when that one is cancelled.
+func (c *capacityCache) get(ctx context.Context, req *csi.GetCapacityRequest, compute func(ctx context.Context) (int64, error)) (int64, error) {
+	parameters := capacityParameters(req.GetParameters())
+	topology := capacityTopology(req.GetAccessibleTopology())
+	key := parameters + "|" + topology
+
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		metrics.CapacityCacheRequests.WithLabelValues("hit").Inc()
+		return entry.available, nil
+	}
+	c.mu.Unlock()
+
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		queryCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), capacityQueryTimeout)
+		defer cancel()
+
+		available, err := compute(queryCtx)
+		if err != nil {
+			return int64(0), err
+		}
+		c.store(&capacityEntry{
+			parameters: parameters,
+			topology:   topology,
+			available:  available,
+			expires:    time.Now().Add(c.ttl),
+		})
+		return available, nil
+	})
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	if res.Shared {
+		metrics.CapacityCacheRequests.WithLabelValues("shared").Inc()
+	} else {
+		metrics.CapacityCacheRequests.WithLabelValues("miss").Inc()
+	}
+	if res.Err != nil {
+		return 0, res.Err
+	}
+	return res.Val.(int64), nil
+}
+
+// store caches an entry and evicts the entries no longer asked for
+func (c *capacityCache) store(entry *capacityEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[entry.parameters+"|"+entry.topology] = entry
+	metrics.AvailableCapacity.WithLabelValues(entry.parameters, entry.topology).Set(float64(entry.available))
+
+	for key, old := range c.entries {
+		if time.Since(old.expires) > capacityCacheEvictAfter {
+			delete(c.entries, key)
+			metrics.AvailableCapacity.DeleteLabelValues(old.parameters, old.topology)
+			klog.V(4).Infof("Evicted capacity of parameters %q and topology %q from the cache", old.parameters, old.topology)
+		}
+	}
+}
+
+// capacityParameters returns the StorageClass parameters of a request as
+// sorted key=value pairs, leaving out those added by the CSI sidecars
+func capacityParameters(params map[string]string) string {
+	var pairs []string
+	for key, value := range params {
+		if strings.HasPrefix(key, paramPrefixCSI) {
+			continue
+		}
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// capacityTopology returns the topology segments of a request as sorted
+// key=value pairs
+func capacityTopology(topology *csi.Topology) string {
+	var pairs []string
+	for key, value := range topology.GetSegments() {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,56 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+func TestCapacityCacheQueryOutlivesCaller(t *testing.T) {
+	c := newCapacityCache(0)
+	req := &csi.GetCapacityRequest{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	compute := func(ctx context.Context) (int64, error) {
+		close(started)
+		select {
+		case <-release:
+			return 1 << 30, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	// The first call starts the query and is cancelled while a second call
+	// waits for it
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.get(first, req, compute)
+		firstErr <- err
+	}()
+	<-started
+
+	type result struct {
+		available int64
+		err       error
+	}
+	second := make(chan result, 1)
+	go func() {
+		available, err := c.get(context.Background(), req, func(context.Context) (int64, error) {
+			return 0, errors.New("second query started")
+		})
+		second <- result{available, err}
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled get = %v, want %v", err, context.Canceled)
+	}
+	close(release)
+	if res := <-second; res.err != nil || res.available != 1<<30 {
+		t.Errorf("waiting get = %d, %v, want %d", res.available, res.err, 1<<30)
+	}
+}
@@ -2,7 +2,11 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
@@ -11,6 +15,10 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/idempotency"
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+	"github.com/akam1o/csi-arca-storage/pkg/opqueue"
+	"github.com/akam1o/csi-arca-storage/pkg/policy"
 	"github.com/akam1o/csi-arca-storage/pkg/store"
 )
 
@@ -19,10 +27,52 @@ const (
 	paramNamespace = "csi.storage.k8s.io/pvc/namespace"
 	paramPVCName   = "csi.storage.k8s.io/pvc/name"
 
+	// paramProvisioningMode is the StorageClass parameter selecting thin
+	// (default) or thick provisioning
+	paramProvisioningMode = "provisioningMode"
+
+	// paramInodeLimit is the StorageClass parameter limiting the number of
+	// files and directories per volume
+	paramInodeLimit = "inodeLimit"
+
+	// paramSecureDelete is the StorageClass parameter wiping a volume's data
+	// before its directory is deleted
+	paramSecureDelete = "secureDelete"
+
+	// paramMountProfile is the StorageClass parameter selecting the NFS
+	// mount tuning of a workload type (see mount.LookupProfile)
+	paramMountProfile = "mountProfile"
+
+	// StorageClass parameters setting the NFS attribute cache options of a
+	// volume, overriding its mount profile (see mount.AttributeCache)
+	paramActimeo     = "actimeo"
+	paramNoAC        = "noac"
+	paramNoCTO       = "nocto"
+	paramLookupCache = "lookupcache"
+
+	// paramFSCache is the StorageClass parameter caching volume data on the
+	// nodes' local disks (fsc)
+	paramFSCache = "fsCache"
+
+	// VolumeSnapshotClass parameters: the prefix of the snapshot's backend
+	// label (followed by the snapshot name), a retention hint for policy
+	// processing and the backend consistency level
+	paramSnapshotLabelPrefix = "snapshotLabelPrefix"
+	paramSnapshotRetention   = "retention"
+	paramSnapshotConsistency = "consistency"
+
+	// paramPrefixCSI marks parameters added by the CSI sidecars
+	paramPrefixCSI = "csi.storage.k8s.io/"
+
 	// Volume context keys
 	volumeContextSVM        = "svm"
 	volumeContextVIP        = "vip"
 	volumeContextVolumePath = "volumePath"
+	volumeContextSVMHost    = "svmHost"
+	volumeContextExportPath = "exportPath"
+	volumeContextProfile    = "mountProfile"
+	volumeContextAttrCache  = "attributeCache"
+	volumeContextFSCache    = "fsCache"
 
 	// Default capacity if not specified
 	defaultCapacityBytes = 1 * 1024 * 1024 * 1024 // 1 GiB
@@ -44,9 +94,248 @@ func compareVolumeParameters(existing *store.VolumeInfo, req *csi.CreateVolumeRe
 	if !contentSourcesMatch(req.GetVolumeContentSource(), existing.ContentSource) {
 		return fmt.Errorf("content source mismatch")
 	}
+
+	// Compare provisioning mode (records without one are thin)
+	requestedMode, err := provisioningMode(req.GetParameters())
+	if err != nil {
+		return err
+	}
+	existingMode := existing.ProvisioningMode
+	if existingMode == "" {
+		existingMode = arca.ProvisioningModeThin
+	}
+	if requestedMode != existingMode {
+		return fmt.Errorf("provisioning mode mismatch: requested %s, existing %s", requestedMode, existingMode)
+	}
+
+	// Compare inode limit
+	requestedInodes, err := inodeLimit(req.GetParameters())
+	if err != nil {
+		return err
+	}
+	if requestedInodes != existing.InodeLimit {
+		return fmt.Errorf("inode limit mismatch: requested %d, existing %d", requestedInodes, existing.InodeLimit)
+	}
+
+	// Compare mount profile
+	requestedProfile, err := mountProfile(req.GetParameters())
+	if err != nil {
+		return err
+	}
+	if requestedProfile != existing.MountProfile {
+		return fmt.Errorf("mount profile mismatch: requested %q, existing %q", requestedProfile, existing.MountProfile)
+	}
+
+	// Compare attribute cache options
+	requestedAttrs, err := attributeCache(req.GetParameters())
+	if err != nil {
+		return err
+	}
+	if requestedAttrs != existing.AttributeCache {
+		return fmt.Errorf("attribute cache options mismatch: requested %q, existing %q", requestedAttrs, existing.AttributeCache)
+	}
+
+	// Compare local data caching
+	requestedFSCache, err := fsCache(req.GetParameters())
+	if err != nil {
+		return err
+	}
+	if requestedFSCache != existing.FSCache {
+		return fmt.Errorf("fsCache mismatch: requested %t, existing %t", requestedFSCache, existing.FSCache)
+	}
+	return nil
+}
+
+// validateContentSource checks that a volume created from a source volume or
+// snapshot is compatible with it: the source must be on the namespace's SVM
+// (volumes cannot be copied into another namespace), and the requested
+// capacity and inode limit must hold the source's size and usage
+func (d *Driver) validateContentSource(ctx context.Context, backend *arca.Backend, namespace, source, sourceSVM, sourcePath string, sourceBytes, capacityBytes, inodes int64) error {
+	if svmName := arca.SVMNameForNamespace(namespace); sourceSVM != svmName {
+		return status.Errorf(codes.InvalidArgument, "%s belongs to SVM %s, not to the SVM %s of namespace %s", source, sourceSVM, svmName, namespace)
+	}
+
+	if capacityBytes < sourceBytes {
+		return status.Errorf(codes.OutOfRange, "requested capacity %d is smaller than the %d bytes of %s", capacityBytes, sourceBytes, source)
+	}
+
+	usage, err := backend.Client.GetQuota(ctx, sourceSVM, sourcePath)
+	if errors.Is(err, arca.ErrNotSupported) || arca.IsNotFoundError(err) {
+		// Sources without a quota (e.g. snapshots on some backends) have
+		// no usage to compare
+		klog.V(4).Infof("No usage reported for %s, skipping usage check", source)
+		return nil
+	}
+	if err != nil {
+		return toStatus(err, "failed to get usage of %s", source)
+	}
+	if usage.UsedBytes > capacityBytes {
+		return status.Errorf(codes.OutOfRange, "requested capacity %d cannot hold the %d bytes used by %s", capacityBytes, usage.UsedBytes, source)
+	}
+	if inodes > 0 && usage.UsedInodes > inodes {
+		return status.Errorf(codes.OutOfRange, "%s %d cannot hold the %d inodes used by %s", paramInodeLimit, inodes, usage.UsedInodes, source)
+	}
 	return nil
 }
 
+// inodeLimit returns the inodeLimit parameter (0 = unlimited)
+func inodeLimit(params map[string]string) (int64, error) {
+	value := params[paramInodeLimit]
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("invalid %s %q (must be a positive integer)", paramInodeLimit, value)
+	}
+	return limit, nil
+}
+
+// provisioningMode returns the provisioningMode parameter, defaulting to thin
+func provisioningMode(params map[string]string) (string, error) {
+	switch mode := params[paramProvisioningMode]; mode {
+	case "", arca.ProvisioningModeThin:
+		return arca.ProvisioningModeThin, nil
+	case arca.ProvisioningModeThick:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q (must be %q or %q)", paramProvisioningMode, mode, arca.ProvisioningModeThin, arca.ProvisioningModeThick)
+	}
+}
+
+// mountProfile returns the mountProfile parameter; the general profile,
+// which uses the default mount, is returned as ""
+func mountProfile(params map[string]string) (string, error) {
+	profile, err := mount.LookupProfile(params[paramMountProfile])
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", paramMountProfile, err)
+	}
+	if profile.Name == mount.ProfileGeneral {
+		return "", nil
+	}
+	return profile.Name, nil
+}
+
+// attributeCache returns the attribute cache options set by the actimeo,
+// noac, nocto and lookupcache parameters, in the form recorded for volumes
+// ("" = the mount profile's)
+func attributeCache(params map[string]string) (string, error) {
+	var attrs mount.AttributeCache
+	if value := params[paramActimeo]; value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s %q (must be a number of seconds)", paramActimeo, value)
+		}
+		attrs.Actimeo = &seconds
+	}
+	flags := []struct {
+		param string
+		value *bool
+	}{{paramNoAC, &attrs.NoAC}, {paramNoCTO, &attrs.NoCTO}}
+	for _, flag := range flags {
+		if value := params[flag.param]; value != "" {
+			set, err := strconv.ParseBool(value)
+			if err != nil {
+				return "", fmt.Errorf("invalid %s %q (must be \"true\" or \"false\")", flag.param, value)
+			}
+			*flag.value = set
+		}
+	}
+	attrs.LookupCache = params[paramLookupCache]
+	if err := attrs.Validate(); err != nil {
+		return "", err
+	}
+	return attrs.String(), nil
+}
+
+// fsCache returns the fsCache parameter (default false)
+func fsCache(params map[string]string) (bool, error) {
+	value := params[paramFSCache]
+	if value == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q (must be \"true\" or \"false\")", paramFSCache, value)
+	}
+	return enabled, nil
+}
+
+// secureDelete returns the secureDelete parameter, defaulting to the
+// driver's setting
+func (d *Driver) secureDelete(params map[string]string) (bool, error) {
+	value := params[paramSecureDelete]
+	if value == "" {
+		return d.secureDeleteDefault, nil
+	}
+	wipe, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q (must be \"true\" or \"false\")", paramSecureDelete, value)
+	}
+	return wipe, nil
+}
+
+// snapshotOptions are the parsed VolumeSnapshotClass parameters
+type snapshotOptions struct {
+	label       string
+	consistency string
+	// parameters are the class parameters without the CSI sidecar ones
+	parameters map[string]string
+}
+
+// parseSnapshotParameters validates the VolumeSnapshotClass parameters of
+// a CreateSnapshot request. Unknown parameters are kept for policy
+// processing.
+func parseSnapshotParameters(name string, params map[string]string) (*snapshotOptions, error) {
+	opts := &snapshotOptions{}
+	for k, v := range params {
+		if strings.HasPrefix(k, paramPrefixCSI) {
+			continue
+		}
+		if opts.parameters == nil {
+			opts.parameters = make(map[string]string)
+		}
+		opts.parameters[k] = v
+	}
+
+	if prefix := params[paramSnapshotLabelPrefix]; prefix != "" {
+		if len(prefix) > 63 || strings.IndexFunc(prefix, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.')
+		}) >= 0 {
+			return nil, fmt.Errorf("invalid %s %q (must be at most 63 letters, digits, '-', '_' or '.')", paramSnapshotLabelPrefix, prefix)
+		}
+		opts.label = prefix + name
+	}
+
+	if retention := params[paramSnapshotRetention]; retention != "" {
+		if d, err := time.ParseDuration(retention); err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s %q (must be a positive duration, e.g. 720h)", paramSnapshotRetention, retention)
+		}
+	}
+
+	switch consistency := params[paramSnapshotConsistency]; consistency {
+	case "", arca.SnapshotConsistencyCrash:
+	case arca.SnapshotConsistencyFilesystem:
+		opts.consistency = consistency
+	default:
+		return nil, fmt.Errorf("invalid %s %q (must be %q or %q)", paramSnapshotConsistency, consistency, arca.SnapshotConsistencyCrash, arca.SnapshotConsistencyFilesystem)
+	}
+	return opts, nil
+}
+
+// secretKeys returns the sorted keys of a CSI secrets map
+func secretKeys(secrets map[string]string) []string {
+	if len(secrets) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
 // contentSourcesMatch compares two content sources
 func contentSourcesMatch(a, b *csi.VolumeContentSource) bool {
 	if a == nil && b == nil {
@@ -68,6 +357,69 @@ func contentSourcesMatch(a, b *csi.VolumeContentSource) bool {
 	return false
 }
 
+// toCSIVolume converts volume metadata to a CSI volume with driver-level context
+func (d *Driver) toCSIVolume(info *store.VolumeInfo) *csi.Volume {
+	vol := info.ToCSIVolume()
+	vol.VolumeContext[volumeContextSchemaVersion] = strconv.Itoa(volumeContextVersion)
+	if d.svmDNSTemplate != "" {
+		vol.VolumeContext[volumeContextSVMHost] = strings.ReplaceAll(d.svmDNSTemplate, "{svm}", info.SVMName)
+	}
+	if len(d.volumeContextKey) > 0 {
+		signVolumeContext(d.volumeContextKey, vol.VolumeId, vol.VolumeContext)
+	}
+	return vol
+}
+
+// backendFor returns the ARCA backend recorded for a volume or snapshot
+func (d *Driver) backendFor(name string) (*arca.Backend, error) {
+	backend, err := d.backends.Get(name)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "%v (was the backend removed from configuration?)", err)
+	}
+	return backend, nil
+}
+
+// withOperationTimeout bounds ctx by timeout when it is set
+func withOperationTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// volumeIDLookup finds the ID of an existing volume by request name
+func volumeIDLookup(st store.Store) idempotency.IDLookup {
+	return func(name string) (string, bool, error) {
+		info, err := st.GetVolumeByName(name)
+		if err != nil {
+			if store.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		return info.VolumeID, true, nil
+	}
+}
+
+// snapshotIDLookup finds the ID of an existing snapshot by its
+// "<sourceVolumeID>/<name>" key
+func snapshotIDLookup(st store.Store) idempotency.IDLookup {
+	return func(key string) (string, bool, error) {
+		sourceVolumeID, name, _ := strings.Cut(key, "/")
+		info, err := st.GetSnapshotByName(name)
+		if err != nil {
+			if store.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		if info.SourceVolumeID != sourceVolumeID {
+			return "", false, nil
+		}
+		return info.SnapshotID, true, nil
+	}
+}
+
 // ensureControllerServiceConfigured checks if the driver is running in controller mode
 func (d *Driver) ensureControllerServiceConfigured() error {
 	if d.mode != "controller" {
@@ -81,10 +433,28 @@ func (d *Driver) ensureControllerServiceConfigured() error {
 func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
 	klog.V(4).Infof("CreateVolume called with name: %s", req.GetName())
 
+	timer := newPhaseTimer("CreateVolume", req.GetName())
+	timer.attempt = d.createVolumeRetries.begin(req.GetName())
+	resp, err := d.createVolume(ctx, req, timer)
+	timer.finish(d.createVolumeSLO, err)
+	if err == nil {
+		d.createVolumeRetries.succeeded(req.GetName())
+	}
+	return resp, err
+}
+
+// createVolume implements CreateVolume, timing its phases with timer
+func (d *Driver) createVolume(ctx context.Context, req *csi.CreateVolumeRequest, timer *phaseTimer) (*csi.CreateVolumeResponse, error) {
 	// Defensive check for correct mode
 	if err := d.ensureControllerServiceConfigured(); err != nil {
 		return nil, err
 	}
+	if err := d.checkMaintenance("volume creation"); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withOperationTimeout(ctx, d.operationTimeouts.CreateVolume)
+	defer cancel()
 
 	// Validate request
 	if req.GetName() == "" {
@@ -112,37 +482,118 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		pvcName = req.GetName()
 	}
 
+	// Determine capacity
+	capacityBytes := int64(defaultCapacityBytes)
+	if req.GetCapacityRange() != nil && req.GetCapacityRange().GetRequiredBytes() > 0 {
+		capacityBytes = req.GetCapacityRange().GetRequiredBytes()
+	}
+
+	mode, err := provisioningMode(params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	inodes, err := inodeLimit(params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	wipe, err := d.secureDelete(params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	profile, err := mountProfile(params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	attrs, err := attributeCache(params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	cached, err := fsCache(params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// Clones and restores are reflinks sharing blocks with their source, so
+	// their space cannot be preallocated
+	if mode == arca.ProvisioningModeThick && req.GetVolumeContentSource() != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s %q is not supported for clones and snapshot restores", paramProvisioningMode, mode)
+	}
+
 	// Generate stable volume ID (idempotent)
-	volumeID := d.volumeIDGen.GenerateVolumeID(req.GetName())
+	volumeID, err := d.volumeIDGen.GenerateVolumeID(req.GetName())
+	if err != nil {
+		return nil, toStatus(err, "failed to assign volume ID for %s", req.GetName())
+	}
+	// Operations are serialized per volume ID, which every call on the
+	// volume knows it by
+	done, err := d.inflight.begin(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
 	// Check if volume already exists (idempotency)
 	existingVol, err := d.store.GetVolume(volumeID)
+	if err == nil && existingVol.Deleting {
+		return nil, status.Errorf(codes.Aborted, "volume %s is still being deleted", volumeID)
+	}
 	if err == nil {
 		if err := compareVolumeParameters(existingVol, req); err != nil {
 			return nil, status.Errorf(codes.AlreadyExists, "volume %s already exists but is incompatible: %v", volumeID, err)
 		}
 		klog.V(4).Infof("Volume %s already exists, returning existing volume", volumeID)
-		return &csi.CreateVolumeResponse{
-			Volume: existingVol.ToCSIVolume(),
-		}, nil
+		return d.existingVolumeResponse(ctx, existingVol)
 	}
 	if !store.IsNotFound(err) {
-		return nil, status.Errorf(codes.Internal, "failed to check existing volume %s: %v", volumeID, err)
+		return nil, toStatus(err, "failed to check existing volume %s", volumeID)
+	}
+
+	// Evaluate provisioning policy before touching the backend. Retries of
+	// volumes that already exist are answered above, so a rule added or
+	// tightened since cannot fail them.
+	if d.policy != nil {
+		err := d.policy.Admit(ctx, &policy.Request{
+			Name:          req.GetName(),
+			Namespace:     namespace,
+			CapacityBytes: capacityBytes,
+			Parameters:    params,
+		})
+		if err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "CreateVolume %s rejected: %v", req.GetName(), err)
+		}
 	}
 
-	// Determine capacity
-	capacityBytes := int64(defaultCapacityBytes)
-	if req.GetCapacityRange() != nil && req.GetCapacityRange().GetRequiredBytes() > 0 {
-		capacityBytes = req.GetCapacityRange().GetRequiredBytes()
+	// Throttle the namespace before touching the backend
+	if err := d.namespaceLimiter.allow("CreateVolume", namespace); err != nil {
+		return nil, err
 	}
+	info := requestInfo{namespace: namespace, storageClass: d.pvcStorageClass(ctx, namespace, params)}
+	ctx = withRequestInfo(ctx, info)
+	timer.info = info
 
 	// Handle content source first to determine which SVM to use
 	var svm *arca.SVM
 	var contentSource *csi.VolumeContentSource
+	var topology []*csi.Topology
+
+	// New SVMs are only allocated in pools the scheduled node reaches
+	var allowedPools map[string]bool
+	if d.topology {
+		allowedPools = requestedPools(req.GetAccessibilityRequirements())
+		if allowedPools != nil {
+			ctx = arca.WithAllowedPools(ctx, allowedPools)
+		}
+	}
+
+	// New volumes go to the namespace's backend; clones and restores stay
+	// on the backend of their source
+	backend := d.backends.ForNamespace(namespace)
 
 	// Determine directory path (relative path, no leading slash)
 	// This will be joined with SVM mount path on the node side
-	volumePath := volumeID
+	volumePath, err := volumeBackendPath(volumeID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build volume path: %v", err)
+	}
 
 	if req.GetVolumeContentSource() != nil {
 		src := req.GetVolumeContentSource()
@@ -157,24 +608,52 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 
 			sourceVol, err := d.store.GetVolume(sourceVolumeID)
 			if err != nil {
-				return nil, status.Errorf(codes.NotFound, "source volume %s not found: %v", sourceVolumeID, err)
+				return nil, toStatus(err, "failed to get source volume %s", sourceVolumeID)
+			}
+			if sourceVol.Deleting {
+				return nil, status.Errorf(codes.NotFound, "source volume %s is being deleted", sourceVolumeID)
 			}
 
-			// Clone must use the same SVM as the source volume
+			// Clone must use the same backend and SVM as the source volume
+			backend, err = d.backendFor(sourceVol.Backend)
+			if err != nil {
+				return nil, err
+			}
 			svm = &arca.SVM{
-				Name: sourceVol.SVMName,
-				VIP:  sourceVol.VIP,
+				Name:       sourceVol.SVMName,
+				VIP:        sourceVol.VIP,
+				ExportPath: sourceVol.ExportPath,
 			}
 			klog.V(4).Infof("Using source SVM for clone: %s with VIP: %s", svm.Name, svm.VIP)
+			topology, err = d.svmTopology(ctx, backend, svm.Name, allowedPools)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkRecordedPath(sourceVol.Path); err != nil {
+				return nil, status.Errorf(codes.FailedPrecondition, "source volume %s has an invalid path: %v", sourceVolumeID, err)
+			}
+			if err := d.validateContentSource(ctx, backend, namespace, "source volume "+sourceVolumeID,
+				sourceVol.SVMName, sourceVol.Path, sourceVol.CapacityBytes, capacityBytes, inodes); err != nil {
+				return nil, err
+			}
+
+			if err := d.checkReservedCapacity(ctx, backend, sourceVol.SVMName, namespace, capacityBytes); err != nil {
+				return nil, err
+			}
 
 			// Create snapshot of source volume first (server-side reflink)
-			err = d.arcaClient.CreateSnapshot(ctx, &arca.CreateSnapshotRequest{
-				SVMName:      sourceVol.SVMName,
-				SourcePath:   sourceVol.Path,
-				SnapshotPath: volumePath,
+			endPhase := timer.time(phaseClone)
+			op := cloneOperation(backend, volumeID, cloneKindVolume, sourceVol.SVMName, sourceVol.Path, volumePath)
+			err = d.runOperation(ctx, op, func(ctx context.Context) error {
+				return backend.Client.CreateSnapshot(ctx, &arca.CreateSnapshotRequest{
+					SVMName:      sourceVol.SVMName,
+					SourcePath:   sourceVol.Path,
+					SnapshotPath: volumePath,
+				})
 			})
+			endPhase()
 			if err != nil && !arca.IsAlreadyExistsError(err) {
-				return nil, status.Errorf(codes.Internal, "failed to clone volume: %v", err)
+				return nil, toStatus(err, "failed to clone volume")
 			}
 
 			contentSource = &csi.VolumeContentSource{
@@ -194,28 +673,52 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 
 			snapshot, err := d.store.GetSnapshot(snapshotID)
 			if err != nil {
-				return nil, status.Errorf(codes.NotFound, "snapshot %s not found: %v", snapshotID, err)
+				return nil, toStatus(err, "failed to get snapshot %s", snapshotID)
 			}
 
 			if !snapshot.ReadyToUse {
 				return nil, status.Errorf(codes.Unavailable, "snapshot %s is not ready", snapshotID)
 			}
 
-			// Restore must use the same SVM as the snapshot
-			svm, err = d.arcaClient.GetSVM(ctx, snapshot.SVMName)
+			// Restore must use the same backend and SVM as the snapshot
+			backend, err = d.backendFor(snapshot.Backend)
 			if err != nil {
-				return nil, status.Errorf(codes.Internal, "failed to get SVM %s for snapshot restore: %v", snapshot.SVMName, err)
+				return nil, err
+			}
+			svm, err = backend.Client.GetSVM(ctx, snapshot.SVMName)
+			if err != nil {
+				return nil, toStatus(err, "failed to get SVM %s for snapshot restore", snapshot.SVMName)
 			}
 			klog.V(4).Infof("Using snapshot SVM for restore: %s (VIP: %s)", svm.Name, svm.VIP)
+			topology, err = d.svmTopology(ctx, backend, svm.Name, allowedPools)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkRecordedPath(snapshot.Path); err != nil {
+				return nil, status.Errorf(codes.FailedPrecondition, "snapshot %s has an invalid path: %v", snapshotID, err)
+			}
+			if err := d.validateContentSource(ctx, backend, namespace, "snapshot "+snapshotID,
+				snapshot.SVMName, snapshot.Path, snapshot.SizeBytes, capacityBytes, inodes); err != nil {
+				return nil, err
+			}
+
+			if err := d.checkReservedCapacity(ctx, backend, snapshot.SVMName, namespace, capacityBytes); err != nil {
+				return nil, err
+			}
 
 			// Copy snapshot to new volume path (server-side reflink)
-			err = d.arcaClient.CreateSnapshot(ctx, &arca.CreateSnapshotRequest{
-				SVMName:      snapshot.SVMName,
-				SourcePath:   snapshot.Path,
-				SnapshotPath: volumePath,
+			endPhase := timer.time(phaseRestore)
+			op := cloneOperation(backend, volumeID, cloneKindSnapshot, snapshot.SVMName, snapshot.Path, volumePath)
+			err = d.runOperation(ctx, op, func(ctx context.Context) error {
+				return backend.Client.RestoreSnapshot(ctx, &arca.RestoreSnapshotRequest{
+					SVMName:      snapshot.SVMName,
+					SnapshotPath: snapshot.Path,
+					TargetPath:   volumePath,
+				})
 			})
+			endPhase()
 			if err != nil && !arca.IsAlreadyExistsError(err) {
-				return nil, status.Errorf(codes.Internal, "failed to restore from snapshot: %v", err)
+				return nil, toStatus(err, "failed to restore from snapshot")
 			}
 
 			contentSource = &csi.VolumeContentSource{
@@ -233,32 +736,55 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		// Ensure SVM exists for this namespace
 		klog.V(4).Infof("Ensuring SVM exists for namespace: %s", namespace)
 		var err error
-		svm, err = d.svmManager.EnsureSVM(ctx, namespace)
+		endPhase := timer.time(phaseSVMEnsure)
+		svm, err = backend.SVMManager.EnsureSVM(ctx, namespace)
+		endPhase()
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to ensure SVM: %v", err)
+			return nil, toStatus(err, "failed to ensure SVM")
 		}
 		klog.V(4).Infof("Using SVM: %s with VIP: %s", svm.Name, svm.VIP)
+		topology, err = d.svmTopology(ctx, backend, svm.Name, allowedPools)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.checkReservedCapacity(ctx, backend, svm.Name, namespace, capacityBytes); err != nil {
+			return nil, err
+		}
 
 		// Create new directory
 		klog.V(4).Infof("Creating new directory: %s", volumePath)
-		err = d.arcaClient.CreateDirectory(ctx, &arca.CreateDirectoryRequest{
-			SVMName: svm.Name,
-			Path:    volumePath,
-		})
+		dirReq := &arca.CreateDirectoryRequest{
+			SVMName:          svm.Name,
+			Path:             volumePath,
+			ProvisioningMode: mode,
+		}
+		if mode == arca.ProvisioningModeThick {
+			dirReq.QuotaBytes = capacityBytes
+		}
+		endPhase = timer.time(phaseDirectoryCreate)
+		err = backend.Client.CreateDirectory(ctx, dirReq)
+		endPhase()
+		if errors.Is(err, arca.ErrSVMNotFound) {
+			// The SVM was deleted since it was cached; look it up on retry
+			backend.SVMManager.InvalidateSVM(svm.Name)
+		}
 		if err != nil && !arca.IsAlreadyExistsError(err) {
-			return nil, status.Errorf(codes.Internal, "failed to create directory: %v", err)
+			return nil, toStatus(err, "failed to create directory")
 		}
 	}
 
 	// Set quota
-	klog.V(4).Infof("Setting quota for volume %s: %d bytes", volumeID, capacityBytes)
-	err = d.arcaClient.SetQuota(ctx, &arca.SetQuotaRequest{
+	klog.V(4).Infof("Setting quota for volume %s: %d bytes, %d inodes", volumeID, capacityBytes, inodes)
+	endPhase := timer.time(phaseQuota)
+	err = d.setQuota(ctx, backend, &arca.SetQuotaRequest{
 		SVMName:    svm.Name,
 		Path:       volumePath,
 		QuotaBytes: capacityBytes,
+		InodeLimit: inodes,
 	})
+	endPhase()
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to set quota: %v", err)
+		return nil, err
 	}
 
 	// Store volume metadata
@@ -271,26 +797,64 @@ func (d *Driver) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest)
 		CapacityBytes: capacityBytes,
 		CreatedAt:     time.Now(),
 		ContentSource: contentSource,
-	}
-
-	if err := d.store.CreateVolume(volumeInfo); err != nil {
+		Backend:       backend.Name,
+		Annotations:   d.volumeAnnotations(ctx, req.GetName(), namespace, pvcName, params),
+
+		ProvisioningMode: mode,
+		InodeLimit:       inodes,
+		ExportPath:       svm.ExportPath,
+		SecureDelete:     wipe,
+		MountProfile:     profile,
+		AttributeCache:   attrs,
+		FSCache:          cached,
+		PVCNamespace:     namespace,
+		PVCName:          params[paramPVCName],
+		PVName:           params[paramPVName],
+	}
+
+	endPhase = timer.time(phaseStoreWrite)
+	err = d.store.CreateVolume(volumeInfo)
+	endPhase()
+	if err != nil {
 		if store.IsAlreadyExists(err) {
 			existingVol, getErr := d.store.GetVolume(volumeID)
 			if getErr == nil {
 				if err := compareVolumeParameters(existingVol, req); err != nil {
 					return nil, status.Errorf(codes.AlreadyExists, "volume %s already exists but is incompatible: %v", volumeID, err)
 				}
-				return &csi.CreateVolumeResponse{Volume: existingVol.ToCSIVolume()}, nil
+				d.volumeIDGen.Forget(req.GetName())
+				return d.existingVolumeResponse(ctx, existingVol)
 			}
 		}
-		return nil, status.Errorf(codes.Internal, "failed to store volume metadata: %v", err)
+		return nil, toStatus(err, "failed to store volume metadata")
+	}
+	d.volumeIDGen.Forget(req.GetName())
+	if d.reservations != nil {
+		d.reservations.Consume(namespace, capacityBytes)
 	}
 
-	klog.Infof("Volume %s created successfully (SVM: %s, Path: %s)", volumeID, svm.Name, volumePath)
+	klog.Infof("Volume %s created successfully (backend: %q, SVM: %s, Path: %s)", volumeID, backend.Name, svm.Name, volumePath)
 
-	return &csi.CreateVolumeResponse{
-		Volume: volumeInfo.ToCSIVolume(),
-	}, nil
+	volume := d.toCSIVolume(volumeInfo)
+	volume.AccessibleTopology = topology
+	return &csi.CreateVolumeResponse{Volume: volume}, nil
+}
+
+// existingVolumeResponse answers a repeated CreateVolume with the recorded
+// volume and the topology of its SVM
+func (d *Driver) existingVolumeResponse(ctx context.Context, volumeInfo *store.VolumeInfo) (*csi.CreateVolumeResponse, error) {
+	volume := d.toCSIVolume(volumeInfo)
+	if d.topology {
+		backend, err := d.backendFor(volumeInfo.Backend)
+		if err != nil {
+			return nil, err
+		}
+		volume.AccessibleTopology, err = d.svmTopology(ctx, backend, volumeInfo.SVMName, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &csi.CreateVolumeResponse{Volume: volume}, nil
 }
 
 // DeleteVolume deletes a volume
@@ -300,11 +864,27 @@ func (d *Driver) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest)
 	if err := d.ensureControllerServiceConfigured(); err != nil {
 		return nil, err
 	}
+	if err := d.checkMaintenance("volume deletion"); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withOperationTimeout(ctx, d.operationTimeouts.DeleteVolume)
+	defer cancel()
 
 	volumeID := req.GetVolumeId()
 	if volumeID == "" {
 		return nil, status.Error(codes.InvalidArgument, "volume ID is required")
 	}
+	if !idempotency.IsVolumeID(volumeID) {
+		// No volume can have a malformed ID - idempotent success
+		klog.V(4).Infof("Volume ID %s is not a driver volume ID, considering it already deleted", volumeID)
+		return &csi.DeleteVolumeResponse{}, nil
+	}
+	done, err := d.inflight.begin(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
 	// Get volume info
 	volumeInfo, err := d.store.GetVolume(volumeID)
@@ -314,23 +894,40 @@ func (d *Driver) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest)
 			klog.V(4).Infof("Volume %s not found in store, considering it already deleted", volumeID)
 			return &csi.DeleteVolumeResponse{}, nil
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get volume %s: %v", volumeID, err)
+		return nil, toStatus(err, "failed to get volume %s", volumeID)
 	}
-
-	// Delete directory from ARCA
-	klog.V(4).Infof("Deleting directory: %s on SVM: %s", volumeInfo.Path, volumeInfo.SVMName)
-	err = d.arcaClient.DeleteDirectory(ctx, volumeInfo.SVMName, volumeInfo.Path)
-	if err != nil && !arca.IsNotFoundError(err) {
-		return nil, status.Errorf(codes.Internal, "failed to delete directory: %v", err)
+	if volumeInfo.Deleting {
+		klog.V(4).Infof("Volume %s is already queued for deletion", volumeID)
+		return &csi.DeleteVolumeResponse{}, nil
 	}
 
-	// Delete volume metadata - MUST succeed for proper cleanup
-	if err := d.store.DeleteVolume(volumeID); err != nil {
-		// Only ignore if already deleted (idempotent)
-		if !store.IsNotFound(err) {
-			return nil, status.Errorf(codes.Internal, "failed to delete volume metadata: %v", err)
+	op := &opqueue.Operation{Type: opqueue.TypeVolumeDelete, Key: volumeID, Backend: volumeInfo.Backend}
+	err = d.runOperation(ctx, op, func(ctx context.Context) error {
+		if err := d.deleteVolumeData(ctx, volumeInfo); err != nil {
+			return err
+		}
+		// Delete volume metadata - MUST succeed for proper cleanup
+		if err := d.store.DeleteVolume(volumeID); err != nil {
+			// Only ignore if already deleted (idempotent)
+			if !store.IsNotFound(err) {
+				return toStatus(err, "failed to delete volume metadata")
+			}
+			klog.V(4).Infof("Volume metadata %s already deleted", volumeID)
+		}
+		return nil
+	})
+	if err != nil {
+		if !d.deferredDelete || !deferrable(err) {
+			return nil, err
+		}
+		// Acknowledge the call and leave the retries to the deletion queue;
+		// the ArcaVolume stays until the backend data is gone
+		if markErr := d.store.MarkVolumeDeleting(volumeID); markErr != nil {
+			klog.Warningf("Failed to queue deletion of volume %s: %v", volumeID, markErr)
+			return nil, err
 		}
-		klog.V(4).Infof("Volume metadata %s already deleted", volumeID)
+		klog.Warningf("Deleting volume %s failed, retrying in the background: %v", volumeID, err)
+		return &csi.DeleteVolumeResponse{}, nil
 	}
 
 	klog.Infof("Volume %s deleted successfully", volumeID)
@@ -368,7 +965,7 @@ func (d *Driver) ValidateVolumeCapabilities(ctx context.Context, req *csi.Valida
 	// Check if volume exists
 	_, err := d.store.GetVolume(volumeID)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "volume %s not found", volumeID)
+		return nil, toStatus(err, "failed to get volume %s", volumeID)
 	}
 
 	// Validate capabilities
@@ -398,13 +995,13 @@ func (d *Driver) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (
 
 	volumes, nextToken, err := d.store.ListVolumes(startingToken, maxEntries)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list volumes: %v", err)
+		return nil, toStatus(err, "failed to list volumes")
 	}
 
 	entries := make([]*csi.ListVolumesResponse_Entry, len(volumes))
 	for i, vol := range volumes {
 		entries[i] = &csi.ListVolumesResponse_Entry{
-			Volume: vol.ToCSIVolume(),
+			Volume: d.toCSIVolume(vol),
 		}
 	}
 
@@ -422,10 +1019,14 @@ func (d *Driver) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (
 		return nil, err
 	}
 
-	// For now, return unlimited capacity
-	// In production, this should query ARCA API for actual SVM capacity
+	available, err := d.capacityCache.get(ctx, req, func(ctx context.Context) (int64, error) {
+		return d.availableCapacity(ctx, req.GetParameters(), req.GetAccessibleTopology())
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to get available capacity: %v", err)
+	}
 	return &csi.GetCapacityResponse{
-		AvailableCapacity: 0, // 0 means unknown/unlimited
+		AvailableCapacity: available,
 	}, nil
 }
 
@@ -444,6 +1045,7 @@ func (d *Driver) ControllerGetCapabilities(ctx context.Context, req *csi.Control
 		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 		csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
 		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+		csi.ControllerServiceCapability_RPC_GET_CAPACITY,
 	}
 
 	caps := make([]*csi.ControllerServiceCapability, len(capabilities))
@@ -469,6 +1071,12 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 	if err := d.ensureControllerServiceConfigured(); err != nil {
 		return nil, err
 	}
+	if err := d.checkMaintenance("snapshot creation"); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withOperationTimeout(ctx, d.operationTimeouts.CreateSnapshot)
+	defer cancel()
 
 	if req.GetName() == "" {
 		return nil, status.Error(codes.InvalidArgument, "snapshot name is required")
@@ -478,41 +1086,94 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 	if sourceVolumeID == "" {
 		return nil, status.Error(codes.InvalidArgument, "source volume ID is required")
 	}
+	if !idempotency.IsVolumeID(sourceVolumeID) {
+		return nil, status.Errorf(codes.NotFound, "source volume %s not found", sourceVolumeID)
+	}
+
+	opts, err := parseSnapshotParameters(req.GetName(), req.GetParameters())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	// Generate stable snapshot ID (idempotent)
 	// Include source volume ID to avoid cross-namespace collisions
-	snapshotID := d.snapshotIDGen.GenerateSnapshotID(sourceVolumeID + "/" + req.GetName())
+	snapshotKey := sourceVolumeID + "/" + req.GetName()
+	snapshotID, err := d.snapshotIDGen.GenerateSnapshotID(snapshotKey)
+	if err != nil {
+		return nil, toStatus(err, "failed to assign snapshot ID for %s", req.GetName())
+	}
+	// Operations are serialized per snapshot ID, which every call on the
+	// snapshot knows it by
+	done, err := d.inflight.begin(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
 	// Check if snapshot already exists (idempotency)
 	existingSnap, err := d.store.GetSnapshot(snapshotID)
 	if err == nil {
+		if !existingSnap.ReadyToUse {
+			backend, err := d.backendFor(existingSnap.Backend)
+			if err != nil {
+				return nil, err
+			}
+			if err := d.checkSnapshotReady(ctx, backend, existingSnap); err != nil {
+				return nil, err
+			}
+		}
 		klog.V(4).Infof("Snapshot %s already exists, returning existing snapshot", snapshotID)
 		return &csi.CreateSnapshotResponse{
 			Snapshot: existingSnap.ToCSISnapshot(),
 		}, nil
 	}
 	if !store.IsNotFound(err) {
-		return nil, status.Errorf(codes.Internal, "failed to check existing snapshot %s: %v", snapshotID, err)
+		return nil, toStatus(err, "failed to check existing snapshot %s", snapshotID)
 	}
 
 	// Get source volume info
 	sourceVolume, err := d.store.GetVolume(sourceVolumeID)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "source volume %s not found", sourceVolumeID)
+		return nil, toStatus(err, "failed to get source volume %s", sourceVolumeID)
+	}
+	if sourceVolume.Deleting {
+		return nil, status.Errorf(codes.NotFound, "source volume %s is being deleted", sourceVolumeID)
+	}
+
+	backend, err := d.backendFor(sourceVolume.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	// Throttle the namespace before touching the backend
+	if err := d.namespaceLimiter.allow("CreateSnapshot", snapshotNamespace(sourceVolume, req.GetParameters())); err != nil {
+		return nil, err
 	}
 
 	// Create snapshot path (relative path for consistency)
-	snapshotPath := fmt.Sprintf(".snapshots/%s", snapshotID)
+	snapshotPath, err := snapshotBackendPath(d.snapshotDir, snapshotID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build snapshot path: %v", err)
+	}
+	if err := checkRecordedPath(sourceVolume.Path); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "source volume %s has an invalid path: %v", sourceVolumeID, err)
+	}
+	if err := d.ensureSnapshotDir(ctx, backend, sourceVolume.SVMName); err != nil {
+		return nil, err
+	}
 
 	// Create snapshot via ARCA API (server-side reflink)
 	klog.V(4).Infof("Creating snapshot %s from volume %s", snapshotID, sourceVolumeID)
-	err = d.arcaClient.CreateSnapshot(ctx, &arca.CreateSnapshotRequest{
+	err = backend.Client.CreateSnapshot(ctx, &arca.CreateSnapshotRequest{
 		SVMName:      sourceVolume.SVMName,
 		SourcePath:   sourceVolume.Path,
 		SnapshotPath: snapshotPath,
+		Label:        opts.label,
+		Consistency:  opts.consistency,
+		Secrets:      req.GetSecrets(),
 	})
 	if err != nil && !arca.IsAlreadyExistsError(err) {
-		return nil, status.Errorf(codes.Internal, "failed to create snapshot: %v", err)
+		return nil, toStatus(err, "failed to create snapshot")
 	}
 
 	// Store snapshot metadata (initially not ready)
@@ -525,32 +1186,34 @@ func (d *Driver) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequ
 		SizeBytes:      sourceVolume.CapacityBytes,
 		CreatedAt:      time.Now(),
 		ReadyToUse:     false, // Initially false, will be set via status update
+		Backend:        backend.Name,
+		Annotations:    d.snapshotAnnotations(sourceVolume, snapshotKey, req.GetParameters()),
+		Parameters:     opts.parameters,
+		SecretKeys:     secretKeys(req.GetSecrets()),
 	}
 
 	if err := d.store.CreateSnapshot(snapshotInfo); err != nil {
 		if store.IsAlreadyExists(err) {
 			existingSnap, getErr := d.store.GetSnapshot(snapshotID)
 			if getErr == nil {
+				d.snapshotIDGen.Forget(snapshotKey)
 				return &csi.CreateSnapshotResponse{Snapshot: existingSnap.ToCSISnapshot()}, nil
 			}
 		}
-		return nil, status.Errorf(codes.Internal, "failed to store snapshot metadata: %v", err)
+		return nil, toStatus(err, "failed to store snapshot metadata")
 	}
+	d.snapshotIDGen.Forget(snapshotKey)
 
-	// Update status to ready (uses /status endpoint which persists correctly)
-	if err := d.store.UpdateSnapshotStatus(snapshotID, true); err != nil {
-		// Status persistence failed - must return error to maintain consistency
-		klog.Errorf("Failed to update snapshot %s status to ready: %v", snapshotID, err)
-		// Attempt to clean up the snapshot metadata since ReadyToUse=false is not useful
-		if delErr := d.store.DeleteSnapshot(snapshotID); delErr != nil {
-			klog.Errorf("Failed to cleanup snapshot metadata after status update failure: %v", delErr)
-		}
-		return nil, status.Errorf(codes.Internal, "failed to persist snapshot ready status: %v", err)
+	// Mark the snapshot ready once the backend confirms the copy completed
+	if err := d.checkSnapshotReady(ctx, backend, snapshotInfo); err != nil {
+		return nil, err
 	}
-	// Update our in-memory info to reflect the status
-	snapshotInfo.ReadyToUse = true
 
-	klog.Infof("Snapshot %s created successfully from volume %s", snapshotID, sourceVolumeID)
+	if snapshotInfo.ReadyToUse {
+		klog.Infof("Snapshot %s created successfully from volume %s", snapshotID, sourceVolumeID)
+	} else {
+		klog.Infof("Snapshot %s created from volume %s, waiting for the backend to complete it", snapshotID, sourceVolumeID)
+	}
 
 	return &csi.CreateSnapshotResponse{
 		Snapshot: snapshotInfo.ToCSISnapshot(),
@@ -564,11 +1227,27 @@ func (d *Driver) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequ
 	if err := d.ensureControllerServiceConfigured(); err != nil {
 		return nil, err
 	}
+	if err := d.checkMaintenance("snapshot deletion"); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withOperationTimeout(ctx, d.operationTimeouts.DeleteSnapshot)
+	defer cancel()
 
 	snapshotID := req.GetSnapshotId()
 	if snapshotID == "" {
 		return nil, status.Error(codes.InvalidArgument, "snapshot ID is required")
 	}
+	if !idempotency.IsSnapshotID(snapshotID) {
+		// No snapshot can have a malformed ID - idempotent success
+		klog.V(4).Infof("Snapshot ID %s is not a driver snapshot ID, considering it already deleted", snapshotID)
+		return &csi.DeleteSnapshotResponse{}, nil
+	}
+	done, err := d.inflight.begin(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
 	// Get snapshot info
 	snapshotInfo, err := d.store.GetSnapshot(snapshotID)
@@ -578,21 +1257,29 @@ func (d *Driver) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequ
 			klog.V(4).Infof("Snapshot %s not found in store, considering it already deleted", snapshotID)
 			return &csi.DeleteSnapshotResponse{}, nil
 		}
-		return nil, status.Errorf(codes.Internal, "failed to get snapshot %s: %v", snapshotID, err)
+		return nil, toStatus(err, "failed to get snapshot %s", snapshotID)
+	}
+
+	backend, err := d.backendFor(snapshotInfo.Backend)
+	if err != nil {
+		return nil, err
 	}
 
 	// Delete snapshot from ARCA
+	if err := checkRecordedPath(snapshotInfo.Path); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "snapshot %s has an invalid path: %v", snapshotID, err)
+	}
 	klog.V(4).Infof("Deleting snapshot: %s on SVM: %s", snapshotInfo.Path, snapshotInfo.SVMName)
-	err = d.arcaClient.DeleteSnapshot(ctx, snapshotInfo.SVMName, snapshotInfo.Path)
+	err = backend.Client.DeleteSnapshot(ctx, snapshotInfo.SVMName, snapshotInfo.Path)
 	if err != nil && !arca.IsNotFoundError(err) {
-		return nil, status.Errorf(codes.Internal, "failed to delete snapshot: %v", err)
+		return nil, toStatus(err, "failed to delete snapshot")
 	}
 
 	// Delete snapshot metadata - MUST succeed for proper cleanup
 	if err := d.store.DeleteSnapshot(snapshotID); err != nil {
 		// Only ignore if already deleted (idempotent)
 		if !store.IsNotFound(err) {
-			return nil, status.Errorf(codes.Internal, "failed to delete snapshot metadata: %v", err)
+			return nil, toStatus(err, "failed to delete snapshot metadata")
 		}
 		klog.V(4).Infof("Snapshot metadata %s already deleted", snapshotID)
 	}
@@ -614,27 +1301,24 @@ func (d *Driver) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsReques
 	snapshotID := req.GetSnapshotId()
 	startingToken := req.GetStartingToken()
 	maxEntries := int(req.GetMaxEntries())
+	if maxEntries < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "max_entries must not be negative, got %d", maxEntries)
+	}
 
-	// If specific snapshot ID is requested, return only that snapshot
+	// A snapshot ID selects at most that snapshot, intersected with the
+	// source volume filter
 	if snapshotID != "" {
-		snapshot, err := d.store.GetSnapshot(snapshotID)
-		if err != nil {
-			return nil, status.Errorf(codes.NotFound, "snapshot %s not found", snapshotID)
-		}
-
-		return &csi.ListSnapshotsResponse{
-			Entries: []*csi.ListSnapshotsResponse_Entry{
-				{
-					Snapshot: snapshot.ToCSISnapshot(),
-				},
-			},
-		}, nil
+		return d.listSnapshotByID(snapshotID, sourceVolumeID)
+	}
+	// No snapshot can have a source volume ID this driver does not assign
+	if sourceVolumeID != "" && !idempotency.IsVolumeID(sourceVolumeID) {
+		return &csi.ListSnapshotsResponse{}, nil
 	}
 
 	// List snapshots with optional source volume filter
 	snapshots, nextToken, err := d.store.ListSnapshots(sourceVolumeID, startingToken, maxEntries)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to list snapshots: %v", err)
+		return nil, toStatus(err, "failed to list snapshots")
 	}
 
 	entries := make([]*csi.ListSnapshotsResponse_Entry, len(snapshots))
@@ -650,6 +1334,32 @@ func (d *Driver) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsReques
 	}, nil
 }
 
+// listSnapshotByID answers a ListSnapshots for one snapshot ID. A snapshot
+// that does not exist or was taken of another volume than sourceVolumeID
+// (when set) yields an empty list, not an error, as the CSI spec requires.
+func (d *Driver) listSnapshotByID(snapshotID, sourceVolumeID string) (*csi.ListSnapshotsResponse, error) {
+	if !idempotency.IsSnapshotID(snapshotID) {
+		return &csi.ListSnapshotsResponse{}, nil
+	}
+
+	snapshot, err := d.store.GetSnapshot(snapshotID)
+	if store.IsNotFound(err) {
+		return &csi.ListSnapshotsResponse{}, nil
+	}
+	if err != nil {
+		return nil, toStatus(err, "failed to get snapshot %s", snapshotID)
+	}
+	if sourceVolumeID != "" && snapshot.SourceVolumeID != sourceVolumeID {
+		return &csi.ListSnapshotsResponse{}, nil
+	}
+
+	return &csi.ListSnapshotsResponse{
+		Entries: []*csi.ListSnapshotsResponse_Entry{
+			{Snapshot: snapshot.ToCSISnapshot()},
+		},
+	}, nil
+}
+
 // ControllerExpandVolume expands a volume
 func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
 	klog.V(4).Infof("ControllerExpandVolume called with volumeID: %s", req.GetVolumeId())
@@ -657,11 +1367,25 @@ func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.Controller
 	if err := d.ensureControllerServiceConfigured(); err != nil {
 		return nil, err
 	}
+	if err := d.checkMaintenance("volume expansion"); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withOperationTimeout(ctx, d.operationTimeouts.ExpandVolume)
+	defer cancel()
 
 	volumeID := req.GetVolumeId()
 	if volumeID == "" {
 		return nil, status.Error(codes.InvalidArgument, "volume ID is required")
 	}
+	if !idempotency.IsVolumeID(volumeID) {
+		return nil, status.Errorf(codes.NotFound, "volume %s not found", volumeID)
+	}
+	done, err := d.inflight.begin(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
 	if req.GetCapacityRange() == nil {
 		return nil, status.Error(codes.InvalidArgument, "capacity range is required")
@@ -675,7 +1399,7 @@ func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.Controller
 	// Get volume info
 	volumeInfo, err := d.store.GetVolume(volumeID)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "volume %s not found", volumeID)
+		return nil, toStatus(err, "failed to get volume %s", volumeID)
 	}
 
 	// Check if expansion is needed
@@ -683,19 +1407,30 @@ func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.Controller
 		klog.V(4).Infof("Volume %s already has capacity >= %d bytes, no expansion needed", volumeID, newCapacityBytes)
 		return &csi.ControllerExpandVolumeResponse{
 			CapacityBytes:         volumeInfo.CapacityBytes,
-			NodeExpansionRequired: false,
+			NodeExpansionRequired: nodeExpansionRequired(req.GetVolumeCapability()),
 		}, nil
 	}
 
-	// Expand quota via ARCA API
+	backend, err := d.backendFor(volumeInfo.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkRecordedPath(volumeInfo.Path); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "volume %s has an invalid path: %v", volumeID, err)
+	}
+
+	// Expand quota via ARCA API; the new size is only recorded once the
+	// backend reports it applied
 	klog.V(4).Infof("Expanding quota for volume %s to %d bytes", volumeID, newCapacityBytes)
-	err = d.arcaClient.SetQuota(ctx, &arca.SetQuotaRequest{
+	err = d.setQuota(ctx, backend, &arca.SetQuotaRequest{
 		SVMName:    volumeInfo.SVMName,
 		Path:       volumeInfo.Path,
 		QuotaBytes: newCapacityBytes,
+		InodeLimit: volumeInfo.InodeLimit,
 	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to expand quota: %v", err)
+		return nil, err
 	}
 
 	// Update volume metadata
@@ -709,7 +1444,7 @@ func (d *Driver) ControllerExpandVolume(ctx context.Context, req *csi.Controller
 
 	return &csi.ControllerExpandVolumeResponse{
 		CapacityBytes:         newCapacityBytes,
-		NodeExpansionRequired: false, // NFS doesn't require node-side expansion
+		NodeExpansionRequired: nodeExpansionRequired(req.GetVolumeCapability()),
 	}, nil
 }
 
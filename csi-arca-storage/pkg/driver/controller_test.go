@@ -0,0 +1,124 @@
+package driver_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+const (
+	testVolumeID   = "pvc-0123456789abcdef"
+	testSnapshotID = "fedcba9876543210"
+)
+
+// newControllerDriver creates a controller plugin on st
+func newControllerDriver(t *testing.T, st store.Store) *driver.Driver {
+	t.Helper()
+
+	d, err := driver.NewDriver(&driver.DriverConfig{Mode: "controller", Store: st})
+	if err != nil {
+		t.Fatalf("NewDriver: %v", err)
+	}
+	return d
+}
+
+// newSnapshotStore returns a store holding a snapshot of a volume
+func newSnapshotStore(t *testing.T) store.Store {
+	t.Helper()
+
+	st := store.NewMemoryStore()
+	err := st.CreateSnapshot(&store.SnapshotInfo{
+		SnapshotID:     testSnapshotID,
+		Name:           "snapshot-1",
+		SourceVolumeID: testVolumeID,
+		SVMName:        "k8s-team-a",
+		Path:           ".snapshots/" + testSnapshotID,
+		SizeBytes:      1 << 30,
+		CreatedAt:      time.Now(),
+		ReadyToUse:     true,
+	})
+	if err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	return st
+}
+
+func TestListSnapshotsByID(t *testing.T) {
+	d := newControllerDriver(t, newSnapshotStore(t))
+
+	tests := []struct {
+		name           string
+		snapshotID     string
+		sourceVolumeID string
+		want           int
+	}{
+		{name: "known ID", snapshotID: testSnapshotID, want: 1},
+		{name: "known ID and source", snapshotID: testSnapshotID, sourceVolumeID: testVolumeID, want: 1},
+		{name: "unknown ID", snapshotID: "0000000000000000"},
+		{name: "unknown UUID", snapshotID: "01234567-89ab-cdef-0123-456789abcdef"},
+		{name: "mismatched source", snapshotID: testSnapshotID, sourceVolumeID: "pvc-aaaaaaaaaaaaaaaa"},
+		{name: "malformed source", snapshotID: testSnapshotID, sourceVolumeID: "vol-1"},
+		{name: "malformed ID", snapshotID: "snapshot-1"},
+		{name: "path-like ID", snapshotID: "../" + testSnapshotID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := d.ListSnapshots(context.Background(), &csi.ListSnapshotsRequest{
+				SnapshotId:     tt.snapshotID,
+				SourceVolumeId: tt.sourceVolumeID,
+			})
+			if err != nil {
+				t.Fatalf("ListSnapshots: %v", err)
+			}
+			if len(resp.Entries) != tt.want {
+				t.Fatalf("ListSnapshots returned %d entries, want %d", len(resp.Entries), tt.want)
+			}
+			if tt.want == 1 && resp.Entries[0].Snapshot.SnapshotId != testSnapshotID {
+				t.Errorf("ListSnapshots returned snapshot %s, want %s", resp.Entries[0].Snapshot.SnapshotId, testSnapshotID)
+			}
+		})
+	}
+}
+
+func TestListSnapshotsBySource(t *testing.T) {
+	d := newControllerDriver(t, newSnapshotStore(t))
+
+	for source, want := range map[string]int{testVolumeID: 1, "pvc-aaaaaaaaaaaaaaaa": 0, "vol-1": 0} {
+		resp, err := d.ListSnapshots(context.Background(), &csi.ListSnapshotsRequest{SourceVolumeId: source})
+		if err != nil {
+			t.Fatalf("ListSnapshots of %s: %v", source, err)
+		}
+		if len(resp.Entries) != want {
+			t.Errorf("ListSnapshots of %s returned %d entries, want %d", source, len(resp.Entries), want)
+		}
+	}
+}
+
+// unavailableStore is a store whose snapshot reads fail
+type unavailableStore struct {
+	store.Store
+}
+
+func (unavailableStore) GetSnapshot(snapshotID string) (*store.SnapshotInfo, error) {
+	return nil, fmt.Errorf("%w: snapshot %s", store.ErrUnavailable, snapshotID)
+}
+
+func TestListSnapshotsByIDStoreError(t *testing.T) {
+	d := newControllerDriver(t, unavailableStore{Store: newSnapshotStore(t)})
+
+	// Only a missing snapshot yields an empty list; a failed lookup is
+	// reported
+	_, err := d.ListSnapshots(context.Background(), &csi.ListSnapshotsRequest{SnapshotId: testSnapshotID})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("ListSnapshots = %v, want Unavailable", err)
+	}
+}
@@ -0,0 +1,213 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// DefaultDeleteQueueInterval is how often the deletion queue is processed
+const DefaultDeleteQueueInterval = 30 * time.Second
+
+// Backoff between background deletion attempts of a volume
+const (
+	deleteRetryInitial = 30 * time.Second
+	deleteRetryMax     = 30 * time.Minute
+)
+
+// deleteQueueTimeout bounds the backend deletion of a single volume
+const deleteQueueTimeout = 5 * time.Minute
+
+// deleteRetry is the backoff state of a queued volume
+type deleteRetry struct {
+	attempts int
+	next     time.Time
+	// heldBy is the PersistentVolume the deletion waits for, logged once
+	heldBy string
+}
+
+// deleteVolumeData wipes the volume when requested and deletes its
+// directory; a directory that is already gone counts as deleted
+func (d *Driver) deleteVolumeData(ctx context.Context, volumeInfo *store.VolumeInfo) error {
+	backend, err := d.backendFor(volumeInfo.Backend)
+	if err != nil {
+		return err
+	}
+	if err := checkRecordedPath(volumeInfo.Path); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "volume %s has an invalid path: %v", volumeInfo.VolumeID, err)
+	}
+	if volumeInfo.SecureDelete {
+		if err := d.wipeVolume(ctx, backend, volumeInfo); err != nil {
+			return err
+		}
+	}
+	klog.V(4).Infof("Deleting directory: %s on SVM: %s", volumeInfo.Path, volumeInfo.SVMName)
+	err = backend.Client.DeleteDirectory(ctx, volumeInfo.SVMName, volumeInfo.Path)
+	if err != nil && !arca.IsNotFoundError(err) {
+		return toStatus(err, "failed to delete directory")
+	}
+	return nil
+}
+
+// deferrable reports whether a failed backend deletion may be left to the
+// deletion queue: only transient failures, which a later attempt can
+// overcome, are acknowledged
+func deferrable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// RunDeleteQueue retries the backend deletion of volumes whose DeleteVolume
+// was acknowledged after a transient failure, every interval until ctx is
+// cancelled. Each volume backs off exponentially between attempts, and is
+// held while a PersistentVolume that is not released still refers to it.
+func (d *Driver) RunDeleteQueue(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultDeleteQueueInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	retries := make(map[string]*deleteRetry)
+	for {
+		d.processDeleteQueue(ctx, retries)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processDeleteQueue makes one pass over the volumes queued for deletion
+func (d *Driver) processDeleteQueue(ctx context.Context, retries map[string]*deleteRetry) {
+	if err := d.checkMaintenance("volume deletion"); err != nil {
+		klog.V(4).Infof("Deletion queue paused: %v", err)
+		return
+	}
+
+	volumes, err := d.store.ListDeletingVolumes()
+	if err != nil {
+		klog.Errorf("Failed to list volumes queued for deletion: %v", err)
+		return
+	}
+	metrics.DeleteQueueVolumes.Set(float64(len(volumes)))
+
+	queued := make(map[string]bool, len(volumes))
+	for _, volumeInfo := range volumes {
+		volumeID := volumeInfo.VolumeID
+		queued[volumeID] = true
+		retry, ok := retries[volumeID]
+		if !ok {
+			retry = &deleteRetry{}
+			retries[volumeID] = retry
+		}
+		if time.Now().Before(retry.next) {
+			continue
+		}
+
+		pv, err := d.activePersistentVolume(ctx, volumeInfo)
+		if err != nil {
+			klog.Warningf("Failed to check PersistentVolume of volume %s queued for deletion: %v", volumeID, err)
+			continue
+		}
+		if pv != "" {
+			if retry.heldBy != pv {
+				klog.Warningf("Holding deletion of volume %s: PersistentVolume %s still refers to it", volumeID, pv)
+				retry.heldBy = pv
+			}
+			metrics.DeleteQueueAttempts.WithLabelValues("held").Inc()
+			continue
+		}
+		retry.heldBy = ""
+
+		if err := d.retryDeleteVolume(ctx, volumeInfo); err != nil {
+			retry.attempts++
+			retry.next = time.Now().Add(deleteBackoff(retry.attempts))
+			klog.Warningf("Background deletion of volume %s failed (attempt %d, next in %s): %v",
+				volumeID, retry.attempts, deleteBackoff(retry.attempts), err)
+			metrics.DeleteQueueAttempts.WithLabelValues("error").Inc()
+			continue
+		}
+		delete(retries, volumeID)
+		metrics.DeleteQueueAttempts.WithLabelValues("success").Inc()
+		klog.Infof("Volume %s deleted successfully after %d background attempts", volumeID, retry.attempts+1)
+	}
+
+	// Forget volumes deleted by other means, e.g. by a DeleteVolume retry
+	for volumeID := range retries {
+		if !queued[volumeID] {
+			delete(retries, volumeID)
+		}
+	}
+}
+
+// retryDeleteVolume deletes the backend data of a queued volume and then
+// its record
+func (d *Driver) retryDeleteVolume(ctx context.Context, volumeInfo *store.VolumeInfo) error {
+	done, err := d.inflight.begin(volumeInfo.VolumeID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	ctx, cancel := context.WithTimeout(ctx, deleteQueueTimeout)
+	defer cancel()
+
+	if err := d.deleteVolumeData(ctx, volumeInfo); err != nil {
+		return err
+	}
+	if err := d.store.DeleteVolume(volumeInfo.VolumeID); err != nil && !store.IsNotFound(err) {
+		return fmt.Errorf("failed to delete volume metadata: %w", err)
+	}
+	return nil
+}
+
+// activePersistentVolume returns the name of the PersistentVolume still
+// referring to a queued volume, unless it is released, failed or being
+// deleted. The PV is named after the CSI request name of the volume.
+func (d *Driver) activePersistentVolume(ctx context.Context, volumeInfo *store.VolumeInfo) (string, error) {
+	if d.k8sClient == nil || volumeInfo.Name == "" {
+		return "", nil
+	}
+
+	pv, err := d.k8sClient.CoreV1().PersistentVolumes().Get(ctx, volumeInfo.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != d.name || pv.Spec.CSI.VolumeHandle != volumeInfo.VolumeID {
+		return "", nil
+	}
+	if pv.DeletionTimestamp != nil || pv.Status.Phase == corev1.VolumeReleased || pv.Status.Phase == corev1.VolumeFailed {
+		return "", nil
+	}
+	return pv.Name, nil
+}
+
+// deleteBackoff returns the delay after the given number of failed attempts
+func deleteBackoff(attempts int) time.Duration {
+	delay := deleteRetryInitial
+	for i := 1; i < attempts && delay < deleteRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, deleteRetryMax)
+}
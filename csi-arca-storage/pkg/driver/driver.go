@@ -7,17 +7,26 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
+	mountutils "k8s.io/mount-utils"
 
 	"github.com/akam1o/csi-arca-storage/pkg/arca"
 	"github.com/akam1o/csi-arca-storage/pkg/idempotency"
 	"github.com/akam1o/csi-arca-storage/pkg/lock"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
 	"github.com/akam1o/csi-arca-storage/pkg/mount"
+	"github.com/akam1o/csi-arca-storage/pkg/mounthelper"
+	"github.com/akam1o/csi-arca-storage/pkg/opqueue"
+	"github.com/akam1o/csi-arca-storage/pkg/policy"
+	"github.com/akam1o/csi-arca-storage/pkg/reservation"
 	"github.com/akam1o/csi-arca-storage/pkg/store"
+	"github.com/akam1o/csi-arca-storage/pkg/volumeevent"
 )
 
 // Driver implements the CSI Driver interface
@@ -32,21 +41,55 @@ type Driver struct {
 	srv      *grpc.Server
 	endpoint string
 
-	// ARCA components
-	arcaClient *arca.Client
-	svmManager *arca.SVMManager
-	allocator  *arca.StandaloneAllocator
+	// ARCA backends (default plus per-namespace tenants)
+	backends *arca.BackendRouter
 
 	// Mount management (for node service)
 	mountManager *mount.MountManager
 	nodeState    *mount.NodeState
+	mounter      mountutils.Interface
+	fs           mount.Filesystem
+
+	// Quotas cross-checking volume stats (node, nil when disabled)
+	volumeQuotas *volumeQuotas
+
+	// ArcaVolume reader for volume context lookup and validation (node)
+	volumeReader          *store.VolumeReader
+	volumeLookup          bool
+	validateVolumeContext bool
+
+	// volumeContextKey signs volume context in CreateVolume (controller)
+	// and verifies it in NodeStageVolume (node)
+	volumeContextKey           []byte
+	requireSignedVolumeContext bool
+
+	// Directory permissions and SELinux mount handling (node)
+	stagingDirMode os.FileMode
+	targetDirMode  os.FileMode
+	seLinuxMount   bool
+
+	// fscache honors the fsCache volume option (node)
+	fscache bool
+
+	// volumeEvents records node calls as ArcaVolumeEvents (node, optional)
+	volumeEvents *volumeevent.Recorder
+
+	// tokenAudience enables exchanging pod service account tokens for
+	// ARCA mount grants in NodePublishVolume (node)
+	tokenAudience string
+
+	// SVM DNS name template for volume context ("{svm}" is replaced)
+	svmDNSTemplate string
 
 	// Idempotency helpers
-	volumeIDGen   *idempotency.VolumeIDGenerator
-	snapshotIDGen *idempotency.SnapshotIDGenerator
+	volumeIDGen   idempotency.VolumeIDGenerator
+	snapshotIDGen idempotency.SnapshotIDGenerator
+	// snapshotIDScheme names how snapshot IDs are assigned, recorded on
+	// each ArcaSnapshot
+	snapshotIDScheme string
 
 	// Kubernetes client
-	k8sClient *kubernetes.Clientset
+	k8sClient kubernetes.Interface
 
 	// Lock manager
 	lockManager *lock.Manager
@@ -54,6 +97,61 @@ type Driver struct {
 	// Metadata store
 	store store.Store
 
+	// Provisioning policy (optional)
+	policy policy.Policy
+
+	// Capacity held by ArcaCapacityReservations (optional)
+	reservations *reservation.Tracker
+
+	// Recent GetCapacity results per StorageClass and topology
+	capacityCache *capacityCache
+
+	// Cluster-wide maintenance switch (controller)
+	maintenance maintenance
+
+	// Deadlines for controller RPCs
+	operationTimeouts OperationTimeouts
+	createVolumeSLO   time.Duration
+
+	// How long quota changes are read back (controller)
+	quotaVerifyTimeout time.Duration
+
+	// Pool topology: the controller places volumes in pools the scheduled
+	// node reaches, the node reports the pools it reaches
+	topology     bool
+	nodeTopology *csi.Topology
+
+	// ARCA retry policies per RPC name
+	retryPolicies map[string]arca.RetryPolicy
+
+	// Secure delete of volumes (controller)
+	secureDeleteDefault bool
+	wipeJobImage        string
+	wipeJobNamespace    string
+	exportPathTemplate  string
+
+	// Backend directory of new snapshots, and the SVMs it was ensured on
+	// ("backend/svm")
+	snapshotDir  string
+	snapshotDirs sync.Map
+
+	// Acknowledge DeleteVolume on transient backend failures and retry in
+	// the deletion queue (controller)
+	deferredDelete bool
+
+	// Volumes and snapshots with a pending operation
+	inflight *inFlight
+
+	// Long-running operations recorded so a restarted controller resumes
+	// them (controller, optional)
+	operations *opqueue.Queue
+
+	// Repeated CreateVolume calls, i.e. sidecar retries
+	createVolumeRetries *retryTracker
+
+	// Per-namespace provisioning rate limits; nil when unlimited
+	namespaceLimiter *namespaceLimiter
+
 	// CSI capabilities
 	csi.UnimplementedIdentityServer
 	csi.UnimplementedControllerServer
@@ -70,11 +168,146 @@ type DriverConfig struct {
 	ArcaClient    *arca.Client
 	SVMManager    *arca.SVMManager
 	Allocator     *arca.StandaloneAllocator
-	K8sClient     *kubernetes.Clientset
+	K8sClient     kubernetes.Interface
 	LockManager   *lock.Manager
 	Store         store.Store
+	Policy        policy.Policy
 	StateFilePath string
 	BaseMountPath string
+
+	// Backends routes namespaces to additional ARCA clusters (controller);
+	// when nil, ArcaClient/SVMManager/Allocator form the only backend
+	Backends *arca.BackendRouter
+	// Reservations accounts for ArcaCapacityReservations in GetCapacity and
+	// CreateVolume (controller, optional)
+	Reservations *reservation.Tracker
+	// CapacityCacheTTL is how long GetCapacity results are reused
+	// (controller; default DefaultCapacityCacheTTL)
+	CapacityCacheTTL time.Duration
+	// SVMDNSTemplate enables hostname-based SVM addressing (controller)
+	SVMDNSTemplate string
+	// ExportPathTemplate is the NFS export of SVMs whose backend reports
+	// none (node and wipe jobs; default arca.DefaultExportPathTemplate)
+	ExportPathTemplate string
+	// SnapshotDir is the backend directory of new snapshots, hidden from
+	// volumes (default DefaultSnapshotDir)
+	SnapshotDir string
+	// OperationTimeouts bound controller RPCs (controller)
+	OperationTimeouts OperationTimeouts
+	// CreateVolumeSLO logs the phases of slower CreateVolume calls
+	// (controller, disabled when zero)
+	CreateVolumeSLO time.Duration
+	// QuotaVerifyTimeout is how long CreateVolume and ControllerExpandVolume
+	// wait for the backend to report a new quota applied (controller;
+	// default DefaultQuotaVerifyTimeout)
+	QuotaVerifyTimeout time.Duration
+	// Topology reports pools as topology segments: the node those it
+	// reaches, the controller the one of each volume
+	Topology bool
+	// TopologyPools are the pools the node probes at startup (node)
+	TopologyPools []arca.PoolConfig
+	// NamespaceRateLimits throttle CreateVolume and CreateSnapshot per
+	// namespace (controller, optional)
+	NamespaceRateLimits NamespaceRateLimits
+	// RetryPolicies override the ARCA client's retries per RPC name (one of
+	// RetryPolicyRPCs, e.g. "CreateVolume")
+	RetryPolicies map[string]arca.RetryPolicy
+	// SecureDelete wipes volumes without a secureDelete StorageClass
+	// parameter before deleting them (controller)
+	SecureDelete bool
+	// WipeJobImage runs wipe Jobs in WipeJobNamespace for backends that
+	// cannot wipe directories themselves (controller, optional)
+	WipeJobImage     string
+	WipeJobNamespace string
+	// DeferredDelete acknowledges DeleteVolume when the backend deletion
+	// fails transiently and keeps retrying in RunDeleteQueue (controller)
+	DeferredDelete bool
+	// Operations records SVM creations, clones and volume deletions while
+	// they run and resumes them after a restart (controller, optional)
+	Operations *opqueue.Queue
+	// IDMode is idempotency.ModeHash (default) or ModeUUID (controller)
+	IDMode string
+	// SnapshotIDSalt keys snapshot ID hashes in hash mode; SnapshotIDCompat
+	// keeps IDs already recorded for a snapshot (controller, optional)
+	SnapshotIDSalt   []byte
+	SnapshotIDCompat bool
+	// DNSCacheTTL is the SVM hostname resolution cache TTL (node)
+	DNSCacheTTL time.Duration
+	// VolumeStatsQuota cross-checks NodeGetVolumeStats with the quota of
+	// each volume on the ARCA API, read at most every VolumeStatsQuotaTTL
+	// (node; default DefaultVolumeStatsQuotaTTL)
+	VolumeStatsQuota    bool
+	VolumeStatsQuotaTTL time.Duration
+	// VolumeReader provides read-only ArcaVolume access (node)
+	VolumeReader *store.VolumeReader
+	// VolumeLookup fills incomplete volume context from VolumeReader (node)
+	VolumeLookup bool
+	// ValidateVolumeContext checks volume context against VolumeReader (node)
+	ValidateVolumeContext bool
+	// VolumeContextKey is the HMAC key of volume context signatures; the
+	// controller signs with it and the node verifies (optional)
+	VolumeContextKey []byte
+	// RequireSignedVolumeContext rejects unsigned volume context (node)
+	RequireSignedVolumeContext bool
+
+	// StagingDirMode/TargetDirMode default to 0750 (node)
+	StagingDirMode os.FileMode
+	TargetDirMode  os.FileMode
+	// SELinuxMount mounts SVMs with the "context=" flag of the staged
+	// volume (node)
+	SELinuxMount bool
+	// TokenAudience is the audience of the CSIDriver tokenRequests entry
+	// exchanged with ARCA on every NodePublishVolume (node, optional)
+	TokenAudience string
+	// VolumeEvents records stage, unstage, publish and unpublish calls in
+	// the volume access audit trail (node, optional)
+	VolumeEvents *volumeevent.Recorder
+	// StateJournal enables journaled NodeState persistence (node)
+	StateJournal           bool
+	StateJournalCompaction int
+	// ReconcileWorkers/ReconcileTimeout tune startup SVM remounts (node)
+	ReconcileWorkers int
+	ReconcileTimeout time.Duration
+	// MountBackoffInitial/MountBackoffMax bound SVM mount retries (node)
+	MountBackoffInitial time.Duration
+	MountBackoffMax     time.Duration
+	// UnmountLinger delays unmounting unused SVMs (node)
+	UnmountLinger time.Duration
+	// MountProfileSysctls applies the sysctls suggested by mount profiles
+	// instead of only logging them (node)
+	MountProfileSysctls bool
+	// FSCache mounts volumes with the fsCache option with fsc, each SVM
+	// holding FSCacheBudget bytes (0 = unlimited) of the cachefilesd cache
+	// in FSCacheDir (node)
+	FSCache       bool
+	FSCacheDir    string
+	FSCacheBudget int64
+	// StatePersistMode is "sync" (default), "coalesce" or "async" (node)
+	StatePersistMode  string
+	StatePersistDelay time.Duration
+	// StateFormat is "json" (default) or "protobuf" (node)
+	StateFormat string
+	// Mounter and Filesystem override the node's OS dependencies (e.g. fakes
+	// in tests); MountAudit/MountBinary are ignored when Mounter is set
+	Mounter    mountutils.Interface
+	Filesystem mount.Filesystem
+	// MountAudit runs mounts through an allow-listed, audited binary (node)
+	MountAudit  bool
+	MountBinary string
+	// MountHelperSocket has the mount helper on this unix socket perform
+	// mounts instead of the node plugin; MountAudit/MountBinary are then
+	// applied by the helper (node, optional)
+	MountHelperSocket string
+}
+
+// OperationTimeouts are deadlines for controller RPCs; zero keeps the
+// deadline of the incoming request
+type OperationTimeouts struct {
+	CreateVolume   time.Duration
+	DeleteVolume   time.Duration
+	ExpandVolume   time.Duration
+	CreateSnapshot time.Duration
+	DeleteSnapshot time.Duration
 }
 
 // NewDriver creates a new CSI driver
@@ -93,19 +326,107 @@ func NewDriver(cfg *DriverConfig) (*Driver, error) {
 	}
 
 	d := &Driver{
-		name:          cfg.Name,
-		version:       cfg.Version,
-		mode:          cfg.Mode,
-		nodeID:        cfg.NodeID,
-		endpoint:      cfg.Endpoint,
-		arcaClient:    cfg.ArcaClient,
-		svmManager:    cfg.SVMManager,
-		allocator:     cfg.Allocator,
-		k8sClient:     cfg.K8sClient,
-		lockManager:   cfg.LockManager,
-		store:         storeInstance,
-		volumeIDGen:   idempotency.NewVolumeIDGenerator(),
-		snapshotIDGen: idempotency.NewSnapshotIDGenerator(),
+		name:           cfg.Name,
+		version:        cfg.Version,
+		mode:           cfg.Mode,
+		nodeID:         cfg.NodeID,
+		endpoint:       cfg.Endpoint,
+		backends:       cfg.Backends,
+		k8sClient:      cfg.K8sClient,
+		lockManager:    cfg.LockManager,
+		store:          storeInstance,
+		policy:         cfg.Policy,
+		svmDNSTemplate: cfg.SVMDNSTemplate,
+		volumeReader:   cfg.VolumeReader,
+		volumeLookup:   cfg.VolumeLookup,
+
+		validateVolumeContext: cfg.ValidateVolumeContext,
+		stagingDirMode:        cfg.StagingDirMode,
+		targetDirMode:         cfg.TargetDirMode,
+		seLinuxMount:          cfg.SELinuxMount,
+		fscache:               cfg.FSCache,
+		tokenAudience:         cfg.TokenAudience,
+		volumeEvents:          cfg.VolumeEvents,
+		operationTimeouts:     cfg.OperationTimeouts,
+		createVolumeSLO:       cfg.CreateVolumeSLO,
+		quotaVerifyTimeout:    cfg.QuotaVerifyTimeout,
+		topology:              cfg.Topology,
+		retryPolicies:         cfg.RetryPolicies,
+		secureDeleteDefault:   cfg.SecureDelete,
+		wipeJobImage:          cfg.WipeJobImage,
+		wipeJobNamespace:      cfg.WipeJobNamespace,
+		deferredDelete:        cfg.DeferredDelete,
+		operations:            cfg.Operations,
+		exportPathTemplate:    cfg.ExportPathTemplate,
+		snapshotDir:           cfg.SnapshotDir,
+		reservations:          cfg.Reservations,
+		capacityCache:         newCapacityCache(cfg.CapacityCacheTTL),
+		inflight:              newInFlight(),
+		createVolumeRetries:   newRetryTracker("CreateVolume", retryWindow),
+		namespaceLimiter:      newNamespaceLimiter(cfg.NamespaceRateLimits),
+
+		volumeContextKey:           cfg.VolumeContextKey,
+		requireSignedVolumeContext: cfg.RequireSignedVolumeContext,
+	}
+
+	switch cfg.IDMode {
+	case "", idempotency.ModeHash:
+		d.volumeIDGen = idempotency.NewVolumeIDGenerator()
+		d.snapshotIDGen = idempotency.NewSnapshotIDGenerator()
+		d.snapshotIDScheme = snapshotIDSchemeHash
+		if len(cfg.SnapshotIDSalt) > 0 {
+			var lookup idempotency.IDLookup
+			if cfg.SnapshotIDCompat {
+				lookup = snapshotIDLookup(storeInstance)
+			}
+			d.snapshotIDGen = idempotency.NewSaltedSnapshotIDGenerator(cfg.SnapshotIDSalt, lookup)
+			d.snapshotIDScheme = snapshotIDSchemeSalted
+		}
+	case idempotency.ModeUUID:
+		// Assignments outlive a controller restart only with the operation
+		// queue
+		var volumeIDs, snapshotIDs idempotency.IDRecorder
+		if d.operations != nil {
+			volumeIDs = &idRecorder{queue: d.operations, markerType: opqueue.TypeVolumeID}
+			snapshotIDs = &idRecorder{queue: d.operations, markerType: opqueue.TypeSnapshotID}
+		} else if cfg.Mode == "controller" {
+			klog.Warning("UUID IDs are assigned without the operation queue; a controller restarting during CreateVolume may leak the volume's directory")
+		}
+		d.volumeIDGen = idempotency.NewUUIDVolumeIDGenerator(volumeIDLookup(storeInstance), volumeIDs)
+		d.snapshotIDGen = idempotency.NewUUIDSnapshotIDGenerator(snapshotIDLookup(storeInstance), snapshotIDs)
+		d.snapshotIDScheme = snapshotIDSchemeUUID
+	default:
+		return nil, fmt.Errorf("unknown ID mode %q", cfg.IDMode)
+	}
+
+	if d.snapshotDir == "" {
+		d.snapshotDir = DefaultSnapshotDir
+	}
+	if err := CheckSnapshotDir(d.snapshotDir); err != nil {
+		return nil, err
+	}
+
+	if d.backends == nil {
+		d.backends = arca.NewBackendRouter(&arca.Backend{
+			Client:     cfg.ArcaClient,
+			SVMManager: cfg.SVMManager,
+			Allocator:  cfg.Allocator,
+		})
+	}
+
+	if d.operations != nil {
+		d.registerOperations()
+	}
+
+	d.fs = cfg.Filesystem
+	if d.fs == nil {
+		d.fs = mount.OSFilesystem{}
+	}
+	if d.stagingDirMode == 0 {
+		d.stagingDirMode = 0750
+	}
+	if d.targetDirMode == 0 {
+		d.targetDirMode = 0750
 	}
 
 	// Initialize node-specific components if this is a node plugin.
@@ -123,17 +444,73 @@ func NewDriver(cfg *DriverConfig) (*Driver, error) {
 		}
 		d.nodeState = nodeState
 
+		if cfg.StateJournal {
+			if err := nodeState.EnableJournal(cfg.StateJournalCompaction); err != nil {
+				return nil, fmt.Errorf("failed to enable node state journal: %w", err)
+			}
+		}
+		if err := nodeState.SetPersistMode(mount.PersistMode(cfg.StatePersistMode), cfg.StatePersistDelay); err != nil {
+			return nil, fmt.Errorf("failed to configure node state persistence: %w", err)
+		}
+		if err := nodeState.SetStateFormat(mount.StateFormat(cfg.StateFormat)); err != nil {
+			return nil, fmt.Errorf("failed to configure node state format: %w", err)
+		}
+
 		// Initialize MountManager with NodeState reference
 		baseMountPath := cfg.BaseMountPath
 		if baseMountPath == "" {
 			baseMountPath = DefaultBaseMountPath
 		}
 
-		mountManager, err := mount.NewMountManager(nodeState, baseMountPath)
+		mounter := cfg.Mounter
+		if mounter == nil && cfg.MountHelperSocket != "" {
+			mounter = mounthelper.NewClient(cfg.MountHelperSocket)
+			klog.Infof("Mounting through the mount helper at %s", cfg.MountHelperSocket)
+		}
+		if mounter == nil {
+			mounter, err = mount.NewMounter(cfg.MountAudit, cfg.MountBinary)
+			if err != nil {
+				return nil, fmt.Errorf("failed to initialize mounter: %w", err)
+			}
+		}
+		d.mounter = mounter
+
+		mountManager, err := mount.NewMountManager(nodeState, &mount.MountManagerConfig{
+			BaseMountPath:    baseMountPath,
+			Mounter:          mounter,
+			Filesystem:       d.fs,
+			ReconcileWorkers: cfg.ReconcileWorkers,
+			ReconcileTimeout: cfg.ReconcileTimeout,
+
+			MountBackoffInitial: cfg.MountBackoffInitial,
+			MountBackoffMax:     cfg.MountBackoffMax,
+			UnmountLinger:       cfg.UnmountLinger,
+			ExportPathTemplate:  cfg.ExportPathTemplate,
+			ApplyProfileSysctls: cfg.MountProfileSysctls,
+			FSCacheDir:          cfg.FSCacheDir,
+			FSCacheBudget:       cfg.FSCacheBudget,
+			Resolver:            mount.NewHostResolver(cfg.DNSCacheTTL),
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize mount manager: %w", err)
 		}
 		d.mountManager = mountManager
+		if cfg.VolumeStatsQuota {
+			d.volumeQuotas = newVolumeQuotas(cfg.VolumeStatsQuotaTTL)
+		}
+		d.cleanupOrphanedPublishes()
+
+		if d.topology {
+			pools, err := reachablePools(cfg.TopologyPools)
+			if err != nil {
+				return nil, fmt.Errorf("failed to probe reachable pools: %w", err)
+			}
+			if len(pools) == 0 {
+				klog.Warningf("Node %s reaches none of the configured pools; no volume can be placed for it", cfg.NodeID)
+			}
+			d.nodeTopology = nodeTopology(pools)
+			klog.Infof("Node %s reaches pools %v", cfg.NodeID, pools)
+		}
 
 		klog.Infof("Node plugin initialized with state file: %s", stateFilePath)
 	}
@@ -191,6 +568,12 @@ func (d *Driver) Run(ctx context.Context) error {
 
 	klog.Infof("CSI driver %s (version %s) listening on %s", d.name, d.version, d.endpoint)
 
+	// Restore the SVM mounts of staged volumes while serving; the driver
+	// is not ready until they are
+	if d.mountManager != nil {
+		go d.mountManager.Reconcile(ctx)
+	}
+
 	// Mark driver as ready
 	d.ready = true
 
@@ -205,18 +588,63 @@ func (d *Driver) Run(ctx context.Context) error {
 	case <-ctx.Done():
 		klog.Info("Shutting down CSI driver...")
 		d.srv.GracefulStop()
+		if d.mountManager != nil {
+			d.mountManager.Close()
+		}
+		if d.nodeState != nil {
+			if err := d.nodeState.Close(); err != nil {
+				klog.Warningf("Failed to close node state: %v", err)
+			}
+		}
 		return ctx.Err()
 	case err := <-errCh:
 		return err
 	}
 }
 
+// RunCanary probes the SVMs mounted by the node plugin every interval
+// until ctx is cancelled (see mount.Canary)
+func (d *Driver) RunCanary(ctx context.Context, interval, timeout time.Duration) {
+	if d.mountManager == nil {
+		return
+	}
+	mount.NewCanary(d.mountManager, d.nodeID, timeout).Run(ctx, interval)
+}
+
+// RunFSCacheMonitor exports the cache statistics of SVMs mounted with fsc
+// every interval until ctx is cancelled
+func (d *Driver) RunFSCacheMonitor(ctx context.Context, interval time.Duration) {
+	if d.mountManager == nil {
+		return
+	}
+	mount.NewFSCacheMonitor(d.mountManager).Run(ctx, interval)
+}
+
+// nodeOperations maps the node calls counted in
+// arca_csi_node_operations_total to their operation label
+var nodeOperations = map[string]string{
+	csi.Node_NodeStageVolume_FullMethodName:     "stage",
+	csi.Node_NodeUnstageVolume_FullMethodName:   "unstage",
+	csi.Node_NodePublishVolume_FullMethodName:   "publish",
+	csi.Node_NodeUnpublishVolume_FullMethodName: "unpublish",
+}
+
 // logGRPC is a gRPC interceptor for logging
 func (d *Driver) logGRPC(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 	klog.V(3).Infof("gRPC call: %s", info.FullMethod)
-	resp, err := handler(ctx, req)
+	resp, err := handler(d.withRetryPolicy(ctx, info.FullMethod), req)
 	if err != nil {
 		klog.Warningf("gRPC call %s failed: %v", info.FullMethod, err)
 	}
+	if operation, ok := nodeOperations[info.FullMethod]; ok {
+		result := "success"
+		if err != nil {
+			result = "error"
+		}
+		metrics.NodeOperations.WithLabelValues(operation, result).Inc()
+		if d.volumeEvents != nil {
+			d.recordVolumeEvent(req, err)
+		}
+	}
 	return resp, err
 }
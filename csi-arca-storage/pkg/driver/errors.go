@@ -0,0 +1,102 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/lock"
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// toStatus returns a gRPC status for an error from the ARCA client, the
+// metadata store, the lock manager or the mount manager, with the message
+// formatted from format and args followed by the error
+func toStatus(err error, format string, args ...any) error {
+	return status.Errorf(errorCode(err), "%s: %v", fmt.Sprintf(format, args...), err)
+}
+
+// errorCode returns the CSI error code for err. Retryable conditions map to
+// codes the sidecars retry without giving up (Aborted, Unavailable,
+// DeadlineExceeded); errors that already carry a gRPC status keep it.
+func errorCode(err error) codes.Code {
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		return s.Code()
+	}
+
+	var apiErr *arca.APIError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+
+	case errors.Is(err, arca.ErrAllPoolsExhausted), errors.Is(err, arca.ErrNoAllowedPool):
+		return codes.ResourceExhausted
+	case errors.Is(err, arca.ErrSVMCreationDenied),
+		errors.Is(err, arca.ErrSVMUnhealthy),
+		errors.Is(err, arca.ErrStaticVIPInUse),
+		errors.Is(err, arca.ErrSnapshotHasDependents):
+		return codes.FailedPrecondition
+	case errors.Is(err, arca.ErrNetworkConflict):
+		return codes.Aborted
+	case errors.Is(err, arca.ErrUnavailable), errors.Is(err, arca.ErrTimeout),
+		errors.Is(err, arca.ErrStuckCall), errors.Is(err, arca.ErrSVMNotReady):
+		return codes.Unavailable
+	case errors.Is(err, arca.ErrNotSupported):
+		return codes.Unimplemented
+	case arca.IsNotFoundError(err):
+		return codes.NotFound
+	case arca.IsAlreadyExistsError(err):
+		return codes.AlreadyExists
+	case errors.As(err, &apiErr):
+		return httpStatusCode(apiErr.StatusCode)
+
+	case errors.Is(err, lock.ErrLockHeld):
+		return codes.Aborted
+	case errors.Is(err, mount.ErrMountBackoff), errors.Is(err, mount.ErrMountFailed):
+		return codes.Unavailable
+
+	case store.IsUnavailable(err):
+		return codes.Unavailable
+	case store.IsConflict(err):
+		return codes.Aborted
+	case store.IsNotFound(err):
+		return codes.NotFound
+	case store.IsAlreadyExists(err):
+		return codes.AlreadyExists
+
+	case errors.As(err, &netErr):
+		// Connection failures to ARCA or the Kubernetes API
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+// httpStatusCode maps an ARCA API HTTP status without a dedicated error
+func httpStatusCode(statusCode int) codes.Code {
+	switch {
+	case statusCode == 400:
+		return codes.InvalidArgument
+	case statusCode == 401 || statusCode == 403:
+		return codes.PermissionDenied
+	case statusCode == 408 || statusCode == 429:
+		return codes.Unavailable
+	case statusCode == 409:
+		return codes.Aborted
+	case statusCode == 412:
+		return codes.FailedPrecondition
+	case statusCode == 507:
+		return codes.ResourceExhausted
+	case statusCode >= 502:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
@@ -0,0 +1,126 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/lock"
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"gRPC status", status.Error(codes.FailedPrecondition, "volume in use"), codes.FailedPrecondition},
+		{"deadline", fmt.Errorf("create: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{"cancelled", context.Canceled, codes.Canceled},
+
+		{"pools exhausted", arca.ErrAllPoolsExhausted, codes.ResourceExhausted},
+		{"no allowed pool", arca.ErrNoAllowedPool, codes.ResourceExhausted},
+		{"SVM creation denied", arca.ErrSVMCreationDenied, codes.FailedPrecondition},
+		{"SVM unhealthy", arca.ErrSVMUnhealthy, codes.FailedPrecondition},
+		{"static VIP in use", arca.ErrStaticVIPInUse, codes.FailedPrecondition},
+		{"snapshot has dependents", arca.ErrSnapshotHasDependents, codes.FailedPrecondition},
+		{"network conflict", arca.ErrNetworkConflict, codes.Aborted},
+		{"ARCA unavailable", arca.ErrUnavailable, codes.Unavailable},
+		{"ARCA timeout", arca.ErrTimeout, codes.Unavailable},
+		{"stuck call", arca.ErrStuckCall, codes.Unavailable},
+		{"SVM not ready", arca.ErrSVMNotReady, codes.Unavailable},
+		{"not supported", arca.ErrNotSupported, codes.Unimplemented},
+		{"directory not found", fmt.Errorf("get: %w", arca.ErrDirectoryNotFound), codes.NotFound},
+		{"snapshot exists", arca.ErrSnapshotAlreadyExists, codes.AlreadyExists},
+		{"API error", arca.NewAPIError(400, "bad quota", nil), codes.InvalidArgument},
+		{"HTTP status", arca.MapHTTPStatusToError(429, "slow down"), codes.Unavailable},
+
+		{"lock held", fmt.Errorf("lock: %w", lock.ErrLockHeld), codes.Aborted},
+		{"mount backoff", mount.ErrMountBackoff, codes.Unavailable},
+		{"mount failed", fmt.Errorf("stage: %w", mount.ErrMountFailed), codes.Unavailable},
+
+		{"store throttled", store.ErrUnavailable, codes.Unavailable},
+		{"store conflict", store.ErrConflict, codes.Aborted},
+		{"store not found", store.ErrNotFound, codes.NotFound},
+		{"store exists", store.ErrAlreadyExists, codes.AlreadyExists},
+
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, codes.Unavailable},
+		{"other", errors.New("unexpected"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorCode(tt.err); got != tt.code {
+				t.Errorf("errorCode(%v) = %v, want %v", tt.err, got, tt.code)
+			}
+		})
+	}
+}
+
+func TestHTTPStatusCode(t *testing.T) {
+	tests := []struct {
+		status int
+		code   codes.Code
+	}{
+		{400, codes.InvalidArgument},
+		{401, codes.PermissionDenied},
+		{403, codes.PermissionDenied},
+		{408, codes.Unavailable},
+		{409, codes.Aborted},
+		{412, codes.FailedPrecondition},
+		{422, codes.Internal},
+		{429, codes.Unavailable},
+		{500, codes.Internal},
+		{502, codes.Unavailable},
+		{504, codes.Unavailable},
+		{507, codes.ResourceExhausted},
+	}
+
+	for _, tt := range tests {
+		if got := httpStatusCode(tt.status); got != tt.code {
+			t.Errorf("httpStatusCode(%d) = %v, want %v", tt.status, got, tt.code)
+		}
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	err := toStatus(arca.ErrSVMNotReady, "failed to create volume %s", "pvc-1")
+
+	s, _ := status.FromError(err)
+	if s.Code() != codes.Unavailable {
+		t.Errorf("code = %v, want Unavailable", s.Code())
+	}
+	if want := "failed to create volume pvc-1: " + arca.ErrSVMNotReady.Error(); s.Message() != want {
+		t.Errorf("message = %q, want %q", s.Message(), want)
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	f := newInFlight()
+
+	done, err := f.begin("pvc-0123456789abcdef")
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := f.begin("pvc-0123456789abcdef"); status.Code(err) != codes.Aborted {
+		t.Errorf("second begin = %v, want Aborted", err)
+	}
+	if f.len() != 1 {
+		t.Errorf("len = %d, want 1", f.len())
+	}
+
+	done()
+	done, err = f.begin("pvc-0123456789abcdef")
+	if err != nil {
+		t.Fatalf("begin after done: %v", err)
+	}
+	done()
+}
@@ -0,0 +1,13 @@
+package driver
+
+import "github.com/container-storage-interface/spec/lib/go/csi"
+
+// nodeExpansionRequired reports whether the node must act after the
+// controller grew a volume's quota. Filesystem (NFS) mounts see the new
+// quota immediately; block volumes would need the node to resize the device,
+// so they require node expansion, which NodeExpandVolume refuses until block
+// volumes are supported. Requests without a capability are filesystem
+// volumes, the only kind the driver provisions.
+func nodeExpansionRequired(capability *csi.VolumeCapability) bool {
+	return capability.GetBlock() != nil
+}
@@ -0,0 +1,26 @@
+package driver
+
+// NodeFeatures returns the volume context fields this node plugin
+// understands. Node plugins publish them so the controller can detect
+// nodes running a version that would ignore fields it sets.
+func NodeFeatures() []string {
+	return []string{volumeContextSVM, volumeContextVIP, volumeContextVolumePath, volumeContextSVMHost, volumeContextSignature, volumeContextExportPath, volumeContextSchemaVersion, volumeContextProfile, volumeContextAttrCache, volumeContextFSCache}
+}
+
+// RequiredNodeFeatures returns the volume context fields the controller
+// sets on new volumes; svmHost is only set with an SVM DNS name template and
+// signature only when volume context is signed. exportPath is left out, as
+// only backends reporting an export path for an SVM make it appear, and so is
+// schemaVersion and the mount options mountProfile, attributeCache and
+// fsCache, which node plugins without them safely ignore (mounting with the
+// default options).
+func RequiredNodeFeatures(svmDNSTemplate string, signed bool) []string {
+	features := []string{volumeContextSVM, volumeContextVIP, volumeContextVolumePath}
+	if svmDNSTemplate != "" {
+		features = append(features, volumeContextSVMHost)
+	}
+	if signed {
+		features = append(features, volumeContextSignature)
+	}
+	return features
+}
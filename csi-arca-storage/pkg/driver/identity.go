@@ -2,6 +2,8 @@ package driver
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
@@ -32,7 +34,7 @@ func (d *Driver) GetPluginInfo(ctx context.Context, req *csi.GetPluginInfoReques
 func (d *Driver) GetPluginCapabilities(ctx context.Context, req *csi.GetPluginCapabilitiesRequest) (*csi.GetPluginCapabilitiesResponse, error) {
 	klog.V(4).Infof("GetPluginCapabilities called")
 
-	capabilities := make([]*csi.PluginCapability, 0, 1)
+	capabilities := make([]*csi.PluginCapability, 0, 2)
 	if d.mode == "controller" {
 		capabilities = append(capabilities, &csi.PluginCapability{
 			Type: &csi.PluginCapability_Service_{
@@ -42,18 +44,50 @@ func (d *Driver) GetPluginCapabilities(ctx context.Context, req *csi.GetPluginCa
 			},
 		})
 	}
+	if d.topology {
+		capabilities = append(capabilities, &csi.PluginCapability{
+			Type: &csi.PluginCapability_Service_{
+				Service: &csi.PluginCapability_Service{
+					Type: csi.PluginCapability_Service_VOLUME_ACCESSIBILITY_CONSTRAINTS,
+				},
+			},
+		})
+	}
 
 	return &csi.GetPluginCapabilitiesResponse{
 		Capabilities: capabilities,
 	}, nil
 }
 
+// Readiness returns why the driver is not ready, or nil once it has
+// started and, in node mode, has finished restoring the SVM mounts of its
+// staged volumes. SVM mounts that could not be restored do not keep it
+// from being ready; they are remounted by the next NodeStage.
+func (d *Driver) Readiness() error {
+	if !d.ready {
+		return errors.New("driver is not ready")
+	}
+	if d.mountManager == nil {
+		return nil
+	}
+	if status := d.mountManager.ReconcileStatus(); !status.Done {
+		return fmt.Errorf("restoring SVM mounts of %d staged SVMs", status.Total)
+	}
+	return nil
+}
+
+// Ready reports whether the driver is ready (see Readiness)
+func (d *Driver) Ready() bool {
+	return d.Readiness() == nil
+}
+
 // Probe checks if the plugin is running
 func (d *Driver) Probe(ctx context.Context, req *csi.ProbeRequest) (*csi.ProbeResponse, error) {
 	klog.V(4).Infof("Probe called")
 
 	// Check if driver is ready
-	if !d.ready {
+	if err := d.Readiness(); err != nil {
+		klog.V(4).Infof("Probe: %v", err)
 		return &csi.ProbeResponse{
 			Ready: &wrapperspb.BoolValue{Value: false},
 		}, nil
@@ -0,0 +1,52 @@
+package driver
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// inFlight tracks the volumes and snapshots with a pending operation, so a
+// concurrent call for the same one fails with Aborted as the CSI spec asks
+// instead of racing with it. Controller operations are keyed by volume or
+// snapshot ID, which creates and deletes of the same object share.
+type inFlight struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// newInFlight creates an empty in-flight tracker
+func newInFlight() *inFlight {
+	return &inFlight{keys: make(map[string]struct{})}
+}
+
+// begin marks key as in flight and returns the function ending it, or an
+// Aborted error when an operation for key is already pending
+func (f *inFlight) begin(key string) (func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.keys[key]; ok {
+		return nil, status.Errorf(codes.Aborted, "an operation for %s is already in progress", key)
+	}
+	f.keys[key] = struct{}{}
+	return func() {
+		f.mu.Lock()
+		delete(f.keys, key)
+		f.mu.Unlock()
+	}, nil
+}
+
+// len returns the number of pending operations
+func (f *inFlight) len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.keys)
+}
+
+// PendingOperations returns the number of volume and snapshot operations in
+// progress
+func (d *Driver) PendingOperations() int {
+	return d.inflight.len()
+}
@@ -0,0 +1,103 @@
+package driver
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maintenanceEnabledKey is the ConfigMap key that turns maintenance on
+	maintenanceEnabledKey = "enabled"
+	// maintenanceMessageKey is the ConfigMap key with an operator message
+	maintenanceMessageKey = "message"
+
+	// DefaultMaintenanceInterval is how often the maintenance ConfigMap is read
+	DefaultMaintenanceInterval = 10 * time.Second
+)
+
+// maintenance holds the cluster-wide maintenance switch
+type maintenance struct {
+	mu      sync.RWMutex
+	enabled bool
+	message string
+}
+
+// SetMaintenance pauses (or resumes) volume and snapshot provisioning.
+// Mount operations on nodes are never affected.
+func (d *Driver) SetMaintenance(enabled bool, message string) {
+	d.maintenance.mu.Lock()
+	defer d.maintenance.mu.Unlock()
+
+	if enabled != d.maintenance.enabled {
+		if enabled {
+			klog.Warningf("Storage maintenance started, provisioning is paused: %s", message)
+		} else {
+			klog.Info("Storage maintenance ended, provisioning resumed")
+		}
+	}
+	d.maintenance.enabled = enabled
+	d.maintenance.message = message
+}
+
+// checkMaintenance rejects a provisioning operation during maintenance
+func (d *Driver) checkMaintenance(operation string) error {
+	d.maintenance.mu.RLock()
+	defer d.maintenance.mu.RUnlock()
+
+	if !d.maintenance.enabled {
+		return nil
+	}
+	if d.maintenance.message != "" {
+		return status.Errorf(codes.Unavailable, "%s is paused for storage maintenance: %s", operation, d.maintenance.message)
+	}
+	return status.Errorf(codes.Unavailable, "%s is paused for storage maintenance", operation)
+}
+
+// WatchMaintenance polls the named ConfigMap and applies its "enabled" and
+// "message" keys until ctx is cancelled. A missing ConfigMap means no
+// maintenance; read errors keep the last known state.
+func (d *Driver) WatchMaintenance(ctx context.Context, namespace, name string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultMaintenanceInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		d.refreshMaintenance(ctx, namespace, name)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshMaintenance reads the maintenance ConfigMap once
+func (d *Driver) refreshMaintenance(ctx context.Context, namespace, name string) {
+	cm, err := d.k8sClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			d.SetMaintenance(false, "")
+			return
+		}
+		klog.Warningf("Failed to read maintenance ConfigMap %s/%s: %v", namespace, name, err)
+		return
+	}
+
+	enabled, err := strconv.ParseBool(cm.Data[maintenanceEnabledKey])
+	if err != nil && cm.Data[maintenanceEnabledKey] != "" {
+		klog.Warningf("Ignoring invalid %q value %q in maintenance ConfigMap %s/%s", maintenanceEnabledKey, cm.Data[maintenanceEnabledKey], namespace, name)
+	}
+	d.SetMaintenance(enabled, cm.Data[maintenanceMessageKey])
+}
@@ -2,17 +2,22 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"k8s.io/klog/v2"
-	"k8s.io/mount-utils"
+
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
 )
 
 func (d *Driver) ensureNodeServiceConfigured() error {
@@ -51,6 +56,21 @@ func validateVolumePath(path string) error {
 	return nil
 }
 
+// exportPathPattern matches the NFS export paths a volume context may carry
+var exportPathPattern = regexp.MustCompile(`^/[A-Za-z0-9._/-]*$`)
+
+// validateExportPath validates an NFS export path reported by the backend,
+// which becomes part of the mount source
+func validateExportPath(path string) error {
+	if !exportPathPattern.MatchString(path) {
+		return fmt.Errorf("export path must be absolute and contain only letters, digits, '.', '_', '-' and '/': %q", path)
+	}
+	if filepath.Clean(path) != path || strings.Contains(path, "..") {
+		return fmt.Errorf("export path must be clean: %q", path)
+	}
+	return nil
+}
+
 // validateVIP validates that a VIP is a valid IP address
 func validateVIP(vip string) error {
 	if vip == "" {
@@ -66,6 +86,48 @@ func validateVIP(vip string) error {
 	return nil
 }
 
+// validateAgainstRecord checks volume context against the ArcaVolume record
+func (d *Driver) validateAgainstRecord(ctx context.Context, volumeID string, record *store.VolumeInfo, svmName, vip, volumePath, exportPath string) error {
+	if record == nil {
+		info, err := d.volumeReader.GetVolume(ctx, volumeID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return status.Errorf(codes.FailedPrecondition, "volume %s has no ArcaVolume record", volumeID)
+			}
+			return status.Errorf(codes.Unavailable, "failed to validate volume %s: %v", volumeID, err)
+		}
+		record = info
+	}
+
+	if svmName != record.SVMName || volumePath != record.Path || exportPath != record.ExportPath {
+		klog.Warningf("Volume context for %s does not match ArcaVolume record (svm %s/%s, path %s/%s, export %s/%s)",
+			volumeID, svmName, record.SVMName, volumePath, record.Path, exportPath, record.ExportPath)
+		return status.Errorf(codes.FailedPrecondition, "volume context for %s does not match ArcaVolume record", volumeID)
+	}
+	if vip != record.VIP {
+		klog.Warningf("Volume context for %s has stale VIP %s (record: %s)", volumeID, vip, record.VIP)
+		return status.Errorf(codes.FailedPrecondition, "volume context for %s has stale VIP %s", volumeID, vip)
+	}
+
+	return nil
+}
+
+// isSELinuxMountOption reports whether a mount flag sets an SELinux context
+func isSELinuxMountOption(opt string) bool {
+	return strings.HasPrefix(opt, "context=")
+}
+
+// seLinuxMountContext returns the SELinux context mount flag of a
+// capability, or ""
+func seLinuxMountContext(capability *csi.VolumeCapability) string {
+	for _, opt := range capability.GetMount().GetMountFlags() {
+		if isSELinuxMountOption(opt) {
+			return opt
+		}
+	}
+	return ""
+}
+
 // NodeStageVolume mounts the volume to a staging path
 func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
 	klog.V(4).Infof("NodeStageVolume called with volumeID: %s", req.GetVolumeId())
@@ -89,16 +151,89 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 		return nil, status.Error(codes.InvalidArgument, "volume capability is required")
 	}
 
-	// Extract volume context
+	done, err := d.inflight.begin(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// Extract volume context, rejecting PV attributes edited after creation
 	volumeContext := req.GetVolumeContext()
-	svmName := volumeContext[volumeContextSVM]
-	vip := volumeContext[volumeContextVIP]
-	volumePath := volumeContext[volumeContextVolumePath]
+	if err := d.verifyVolumeContext(volumeID, volumeContext); err != nil {
+		return nil, err
+	}
+	vc, err := decodeVolumeContext(volumeID, volumeContext)
+	if err != nil {
+		return nil, err
+	}
+	svmName := vc.SVM
+	vip := vc.VIP
+	volumePath := vc.VolumePath
+	exportPath := vc.ExportPath
+	profile := vc.Profile
+	attrCache := vc.AttrCache
+	cached := vc.FSCache
+
+	// Statically provisioned PVs may omit volume attributes; recover them
+	// from the ArcaVolume record when lookup is enabled
+	var record *store.VolumeInfo
+	if (svmName == "" || vip == "" || volumePath == "") && d.volumeLookup && d.volumeReader != nil {
+		info, err := d.volumeReader.GetVolume(ctx, volumeID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil, status.Errorf(codes.NotFound, "volume context is incomplete and volume %s was not found", volumeID)
+			}
+			return nil, toStatus(err, "failed to look up volume %s", volumeID)
+		}
+		record = info
+		if svmName == "" {
+			svmName = info.SVMName
+		}
+		if vip == "" {
+			vip = info.VIP
+		}
+		if volumePath == "" {
+			volumePath = info.Path
+		}
+		if exportPath == "" {
+			exportPath = info.ExportPath
+		}
+		if profile == "" {
+			profile = info.MountProfile
+		}
+		if attrCache == "" {
+			attrCache = info.AttributeCache
+		}
+		cached = cached || info.FSCache
+		klog.V(4).Infof("Resolved volume context for %s from store (SVM: %s, VIP: %s, Path: %s)", volumeID, svmName, vip, volumePath)
+	}
+
+	// After an SVM migration the PV still carries the source VIP; the
+	// ArcaVolume record is authoritative when lookup is enabled
+	if record == nil && d.volumeLookup && d.volumeReader != nil {
+		if info, err := d.volumeReader.GetVolume(ctx, volumeID); err == nil {
+			record = info
+		} else if !errors.Is(err, store.ErrNotFound) {
+			klog.Warningf("Failed to look up volume %s, using volume context VIP: %v", volumeID, err)
+		}
+	}
+	if record != nil && record.VIP != "" && record.VIP != vip && record.SVMName == svmName {
+		klog.Infof("Volume %s moved from VIP %s to %s; using ArcaVolume record", volumeID, vip, record.VIP)
+		vip = record.VIP
+		exportPath = record.ExportPath
+	}
 
 	if svmName == "" || vip == "" || volumePath == "" {
 		return nil, status.Error(codes.InvalidArgument, "volume context must contain svm, vip, and volumePath")
 	}
 
+	// Detect tampered or stale PV volumeAttributes before mounting
+	if d.validateVolumeContext && d.volumeReader != nil {
+		if err := d.validateAgainstRecord(ctx, volumeID, record, svmName, vip, volumePath, exportPath); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate VIP to prevent injection attacks
 	if err := validateVIP(vip); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid VIP: %v", err)
@@ -108,17 +243,51 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 	if err := validateVolumePath(volumePath); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid volume path: %v", err)
 	}
+	if inSnapshotDir(volumePath, d.snapshotDir) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid volume path: %s is in the snapshot directory", volumePath)
+	}
+	if exportPath != "" {
+		if err := validateExportPath(exportPath); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid export path: %v", err)
+		}
+	}
+	profile, err = mountProfile(map[string]string{paramMountProfile: profile})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	attrs, err := mount.ParseAttributeCache(attrCache)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %v", volumeContextAttrCache, err)
+	}
+	if cached && !d.fscache {
+		klog.V(2).Infof("Volume %s requests fsCache, which is not enabled on this node; mounting without it", volumeID)
+		cached = false
+	}
+	svm := mount.SVMMount{
+		SVMName:        svmName,
+		VIP:            vip,
+		Host:           vc.SVMHost,
+		ExportPath:     exportPath,
+		Profile:        profile,
+		AttributeCache: attrs.String(),
+		FSCache:        cached,
+	}
+	// The context labels the files of the NFS mount; it has no effect on
+	// the bind mounts made from it
+	if d.seLinuxMount {
+		svm.SELinuxContext = seLinuxMountContext(req.GetVolumeCapability())
+	}
 
 	klog.V(4).Infof("Staging volume %s (SVM: %s, VIP: %s, Path: %s) to %s", volumeID, svmName, vip, volumePath, stagingTargetPath)
 
-	// Ensure per-SVM shared mount exists
-	svmMountPath, err := d.mountManager.EnsureSVMMount(ctx, svmName, vip)
+	// Ensure the per-SVM mount of the volume's profile exists
+	svmMountPath, err := d.mountManager.EnsureSVMMount(ctx, svm)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to ensure SVM mount: %v", err)
+		return nil, toStatus(err, "failed to mount SVM %s", svmName)
 	}
 
 	// Create staging target directory
-	if err := os.MkdirAll(stagingTargetPath, 0750); err != nil {
+	if err := d.fs.MkdirAll(stagingTargetPath, d.stagingDirMode); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create staging target directory: %v", err)
 	}
 
@@ -126,7 +295,7 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 	sourcePath := filepath.Join(svmMountPath, volumePath)
 
 	// Check if already mounted
-	mounter := mount.New("")
+	mounter := d.mounter
 	notMnt, err := mounter.IsLikelyNotMountPoint(stagingTargetPath)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -144,12 +313,15 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 	klog.V(4).Infof("Creating bind mount from %s to %s", sourcePath, stagingTargetPath)
 
 	mountOptions := []string{"bind"}
-	if err := mounter.Mount(sourcePath, stagingTargetPath, "", mountOptions); err != nil {
+	start := time.Now()
+	err = mounter.Mount(sourcePath, stagingTargetPath, "", mountOptions)
+	mount.ObserveMount(mount.OpBindMount, svmName, vip, start, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to bind mount: %v", err)
 	}
 
 	// Record volume staging in NodeState
-	if err := d.nodeState.RecordVolumeStaging(volumeID, svmName, vip, stagingTargetPath); err != nil {
+	if err := d.nodeState.RecordVolumeStaging(volumeID, svm, stagingTargetPath); err != nil {
 		klog.Warningf("Failed to record volume staging in node state, rolling back mount: %v", err)
 
 		// Best-effort: revert in-memory state (may also fail to persist)
@@ -161,7 +333,7 @@ func (d *Driver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRe
 		if umErr := mounter.Unmount(stagingTargetPath); umErr != nil {
 			klog.Warningf("Failed to unmount staging target path %s during rollback: %v", stagingTargetPath, umErr)
 		}
-		if rmDirErr := os.Remove(stagingTargetPath); rmDirErr != nil && !os.IsNotExist(rmDirErr) {
+		if rmDirErr := d.fs.Remove(stagingTargetPath); rmDirErr != nil && !os.IsNotExist(rmDirErr) {
 			klog.Warningf("Failed to remove staging target directory %s during rollback: %v", stagingTargetPath, rmDirErr)
 		}
 
@@ -191,18 +363,25 @@ func (d *Driver) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolu
 		return nil, status.Error(codes.InvalidArgument, "staging target path is required")
 	}
 
+	done, err := d.inflight.begin(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	klog.V(4).Infof("Unstaging volume %s from %s", volumeID, stagingTargetPath)
 
-	// Get SVM name from NodeState
+	// Get SVM name and mount from NodeState
 	svmName, err := d.nodeState.GetSVMForVolume(volumeID)
 	if err != nil {
 		klog.Warningf("Volume %s not found in node state: %v", volumeID, err)
 		// Continue with unmount attempt
 		svmName = ""
 	}
+	mountKey, _ := d.nodeState.GetMountKeyForVolume(volumeID)
 
 	// Unmount the staging path
-	mounter := mount.New("")
+	mounter := d.mounter
 	notMnt, err := mounter.IsLikelyNotMountPoint(stagingTargetPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -218,13 +397,17 @@ func (d *Driver) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolu
 
 	if !notMnt {
 		klog.V(4).Infof("Unmounting %s", stagingTargetPath)
-		if err := mounter.Unmount(stagingTargetPath); err != nil {
+		_, vip := d.volumeLocation(volumeID)
+		start := time.Now()
+		err := mounter.Unmount(stagingTargetPath)
+		mount.ObserveMount(mount.OpBindUnmount, svmName, vip, start, err)
+		if err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to unmount: %v", err)
 		}
 	}
 
 	// Remove staging directory
-	if err := os.Remove(stagingTargetPath); err != nil && !os.IsNotExist(err) {
+	if err := d.fs.Remove(stagingTargetPath); err != nil && !os.IsNotExist(err) {
 		klog.Warningf("Failed to remove staging directory %s: %v", stagingTargetPath, err)
 	}
 
@@ -234,18 +417,21 @@ func (d *Driver) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolu
 	}
 
 	// Check if SVM mount should be unmounted (derived refcount check)
-	if svmName != "" {
-		shouldUnmount, err := d.mountManager.ShouldUnmountSVM(ctx, svmName)
+	if mountKey != "" {
+		shouldUnmount, err := d.mountManager.ShouldUnmountSVM(ctx, mountKey)
 		if err != nil {
-			klog.Warningf("Failed to check if SVM %s should be unmounted: %v", svmName, err)
+			klog.Warningf("Failed to check if SVM %s should be unmounted: %v", mountKey, err)
 		} else if shouldUnmount {
-			klog.V(4).Infof("Unmounting SVM %s (no more staged volumes)", svmName)
-			if err := d.mountManager.UnmountSVM(ctx, svmName); err != nil {
-				klog.Warningf("Failed to unmount SVM %s: %v", svmName, err)
+			klog.V(4).Infof("Unmounting SVM %s (no more staged volumes)", mountKey)
+			if err := d.mountManager.UnmountSVM(ctx, mountKey); err != nil {
+				klog.Warningf("Failed to unmount SVM %s: %v", mountKey, err)
 			}
 		}
 	}
 
+	if d.volumeQuotas != nil {
+		d.volumeQuotas.forget(volumeID)
+	}
 	klog.Infof("Volume %s unstaged successfully from %s", volumeID, stagingTargetPath)
 
 	return &csi.NodeUnstageVolumeResponse{}, nil
@@ -279,15 +465,36 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 		return nil, status.Error(codes.InvalidArgument, "volume capability is required")
 	}
 
+	done, err := d.inflight.begin(volumeID + ":" + targetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// Renew the ARCA mount grant on every publish, including republishes of
+	// already mounted volumes
+	if d.tokenAudience != "" {
+		svmName, err := d.nodeState.GetSVMForVolume(volumeID)
+		if err != nil {
+			svmName = req.GetVolumeContext()[volumeContextSVM]
+		}
+		if svmName == "" {
+			return nil, status.Errorf(codes.FailedPrecondition, "volume %s is not staged", volumeID)
+		}
+		if err := d.authorizeMount(ctx, volumeID, svmName, req.GetVolumeContext()); err != nil {
+			return nil, err
+		}
+	}
+
 	klog.V(4).Infof("Publishing volume %s from %s to %s", volumeID, stagingTargetPath, targetPath)
 
 	// Create target directory
-	if err := os.MkdirAll(targetPath, 0750); err != nil {
+	if err := d.fs.MkdirAll(targetPath, d.targetDirMode); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create target directory: %v", err)
 	}
 
 	// Check if already mounted
-	mounter := mount.New("")
+	mounter := d.mounter
 	notMnt, err := mounter.IsLikelyNotMountPoint(targetPath)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -320,7 +527,11 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 
 	// Step 1: Create initial bind mount
 	klog.V(4).Infof("Creating bind mount from %s to %s with options: %v", stagingTargetPath, targetPath, mountOptions)
-	if err := mounter.Mount(stagingTargetPath, targetPath, "", mountOptions); err != nil {
+	svmName, vip := d.volumeLocation(volumeID)
+	start := time.Now()
+	err = mounter.Mount(stagingTargetPath, targetPath, "", mountOptions)
+	mount.ObserveMount(mount.OpBindMount, svmName, vip, start, err)
+	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to bind mount: %v", err)
 	}
 
@@ -329,19 +540,22 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 	if readonly {
 		klog.V(4).Infof("Remounting %s as read-only", targetPath)
 		remountOptions := append(mountOptions, "ro", "remount")
-		if err := mounter.Mount(stagingTargetPath, targetPath, "", remountOptions); err != nil {
+		start := time.Now()
+		err := mounter.Mount(stagingTargetPath, targetPath, "", remountOptions)
+		mount.ObserveMount(mount.OpBindMount, svmName, vip, start, err)
+		if err != nil {
 			// Rollback: unmount the initial bind mount
 			klog.Errorf("Failed to remount as read-only, rolling back: %v", err)
 			if unmountErr := mounter.Unmount(targetPath); unmountErr != nil {
 				klog.Errorf("Failed to rollback bind mount: %v", unmountErr)
 			}
-			os.Remove(targetPath)
+			d.fs.Remove(targetPath)
 			return nil, status.Errorf(codes.Internal, "failed to remount as read-only: %v", err)
 		}
 	}
 
 	// Record volume publish in NodeState
-	if err := d.nodeState.RecordVolumePublish(volumeID, targetPath); err != nil {
+	if err := d.nodeState.RecordVolumePublish(volumeID, targetPath, podInfo(req.GetVolumeContext())); err != nil {
 		klog.Warningf("Failed to record volume publish in node state, rolling back mount: %v", err)
 
 		// Best-effort: revert in-memory state (may also fail to persist)
@@ -353,7 +567,7 @@ func (d *Driver) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolu
 		if umErr := mounter.Unmount(targetPath); umErr != nil {
 			klog.Warningf("Failed to unmount target path %s during rollback: %v", targetPath, umErr)
 		}
-		if rmDirErr := os.Remove(targetPath); rmDirErr != nil && !os.IsNotExist(rmDirErr) {
+		if rmDirErr := d.fs.Remove(targetPath); rmDirErr != nil && !os.IsNotExist(rmDirErr) {
 			klog.Warningf("Failed to remove target directory %s during rollback: %v", targetPath, rmDirErr)
 		}
 
@@ -383,10 +597,16 @@ func (d *Driver) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublish
 		return nil, status.Error(codes.InvalidArgument, "target path is required")
 	}
 
+	done, err := d.inflight.begin(volumeID + ":" + targetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	klog.V(4).Infof("Unpublishing volume %s from %s", volumeID, targetPath)
 
 	// Unmount the target path
-	mounter := mount.New("")
+	mounter := d.mounter
 	notMnt, err := mounter.IsLikelyNotMountPoint(targetPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -402,13 +622,17 @@ func (d *Driver) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublish
 
 	if !notMnt {
 		klog.V(4).Infof("Unmounting %s", targetPath)
-		if err := mounter.Unmount(targetPath); err != nil {
+		svmName, vip := d.volumeLocation(volumeID)
+		start := time.Now()
+		err := mounter.Unmount(targetPath)
+		mount.ObserveMount(mount.OpBindUnmount, svmName, vip, start, err)
+		if err != nil {
 			return nil, status.Errorf(codes.Internal, "failed to unmount: %v", err)
 		}
 	}
 
 	// Remove target directory
-	if err := os.Remove(targetPath); err != nil && !os.IsNotExist(err) {
+	if err := d.fs.Remove(targetPath); err != nil && !os.IsNotExist(err) {
 		klog.Warningf("Failed to remove target directory %s: %v", targetPath, err)
 	}
 
@@ -441,22 +665,35 @@ func (d *Driver) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeS
 	}
 
 	// Check if path exists
-	if _, err := os.Stat(volumePath); err != nil {
+	if _, err := d.fs.Stat(volumePath); err != nil {
 		if os.IsNotExist(err) {
 			return nil, status.Errorf(codes.NotFound, "volume path %s does not exist", volumePath)
 		}
 		return nil, status.Errorf(codes.Internal, "failed to stat volume path: %v", err)
 	}
 
-	// For now, return minimal stats
-	// In production, implement proper filesystem stats using statfs syscall
+	// The XFS project quota of the volume directory (bytes and, with an
+	// inodeLimit, inodes) is reported through NFS as the filesystem size
+	stats, err := d.fs.Statfs(volumePath)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get stats of volume path %s: %v", volumePath, err)
+	}
+	if d.volumeQuotas != nil {
+		stats = d.crossCheckQuota(ctx, volumeID, stats)
+	}
 	return &csi.NodeGetVolumeStatsResponse{
 		Usage: []*csi.VolumeUsage{
 			{
-				Unit: csi.VolumeUsage_BYTES,
+				Unit:      csi.VolumeUsage_BYTES,
+				Total:     stats.TotalBytes,
+				Available: stats.AvailableBytes,
+				Used:      stats.UsedBytes,
 			},
 			{
-				Unit: csi.VolumeUsage_INODES,
+				Unit:      csi.VolumeUsage_INODES,
+				Total:     stats.TotalInodes,
+				Available: stats.FreeInodes,
+				Used:      stats.UsedInodes,
 			},
 		},
 	}, nil
@@ -474,12 +711,26 @@ func (d *Driver) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolume
 	if volumeID == "" {
 		return nil, status.Error(codes.InvalidArgument, "volume ID is required")
 	}
+	if req.GetVolumePath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volume path is required")
+	}
+	if _, err := d.nodeState.GetSVMForVolume(volumeID); err != nil {
+		return nil, status.Errorf(codes.NotFound, "volume %s is not staged on this node", volumeID)
+	}
+
+	// Block volumes would need their device resized here; fail instead of
+	// reporting an expansion that did not happen
+	if nodeExpansionRequired(req.GetVolumeCapability()) {
+		return nil, status.Errorf(codes.Unimplemented, "node expansion of block volume %s is not supported", volumeID)
+	}
 
 	// NFS volumes don't require node-side expansion
 	// The quota expansion is handled by the controller
 	klog.V(4).Infof("Volume %s expansion is handled server-side, no node action required", volumeID)
 
-	return &csi.NodeExpandVolumeResponse{}, nil
+	return &csi.NodeExpandVolumeResponse{
+		CapacityBytes: req.GetCapacityRange().GetRequiredBytes(),
+	}, nil
 }
 
 // NodeGetCapabilities returns node capabilities
@@ -521,6 +772,15 @@ func (d *Driver) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoRequest) (
 	}
 
 	return &csi.NodeGetInfoResponse{
-		NodeId: d.nodeID,
+		NodeId:             d.nodeID,
+		AccessibleTopology: d.nodeTopology,
 	}, nil
 }
+
+// volumeLocation returns the SVM and VIP a volume is staged from, or ""
+// for those the node state does not know
+func (d *Driver) volumeLocation(volumeID string) (svmName, vip string) {
+	svmName, _ = d.nodeState.GetSVMForVolume(volumeID)
+	vip, _ = d.nodeState.GetVIPForVolume(volumeID)
+	return svmName, vip
+}
@@ -0,0 +1,440 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	mountutils "k8s.io/mount-utils"
+
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+)
+
+// testVIP is the VIP of the test SVM
+const testVIP = "192.0.2.10"
+
+// countingMounter is a fake mounter counting NFS mounts. NFS mounts take
+// a while, like real ones, so that concurrent callers overlap.
+type countingMounter struct {
+	*mountutils.FakeMounter
+	nfsDelay time.Duration
+}
+
+func newCountingMounter() *countingMounter {
+	return &countingMounter{FakeMounter: mountutils.NewFakeMounter(nil), nfsDelay: 50 * time.Millisecond}
+}
+
+func (m *countingMounter) Mount(source, target, fstype string, options []string) error {
+	if fstype == "nfs4" {
+		time.Sleep(m.nfsDelay)
+	}
+	return m.FakeMounter.Mount(source, target, fstype, options)
+}
+
+// nfsMounts returns the number of NFS mounts made
+func (m *countingMounter) nfsMounts() int {
+	n := 0
+	for _, action := range m.GetLog() {
+		if action.Action == mountutils.FakeActionMount && action.FSType == "nfs4" {
+			n++
+		}
+	}
+	return n
+}
+
+// nodeDriver is a node plugin mounting with a fake mounter under a
+// temporary directory
+type nodeDriver struct {
+	*driver.Driver
+	dir string
+}
+
+// newNodeDriver creates a node plugin mounting with mounter; configure
+// modify the rest of its configuration
+func newNodeDriver(t *testing.T, mounter mountutils.Interface, configure ...func(*driver.DriverConfig)) *nodeDriver {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := &driver.DriverConfig{
+		Mode:          "node",
+		NodeID:        "node-1",
+		Endpoint:      "unix://" + filepath.Join(dir, "csi.sock"),
+		StateFilePath: filepath.Join(dir, "state", "node-state.json"),
+		BaseMountPath: filepath.Join(dir, "mounts"),
+		Mounter:       mounter,
+	}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	d, err := driver.NewDriver(cfg)
+	if err != nil {
+		t.Fatalf("NewDriver: %v", err)
+	}
+	return &nodeDriver{Driver: d, dir: dir}
+}
+
+// stagingPath returns the staging path of a volume
+func (d *nodeDriver) stagingPath(volumeID string) string {
+	return filepath.Join(d.dir, "staging", volumeID)
+}
+
+// stageRequest returns a request staging a volume of the test SVM
+func (d *nodeDriver) stageRequest(volumeID string) *csi.NodeStageVolumeRequest {
+	return &csi.NodeStageVolumeRequest{
+		VolumeId:          volumeID,
+		StagingTargetPath: d.stagingPath(volumeID),
+		VolumeCapability: &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+			AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER},
+		},
+		VolumeContext: map[string]string{
+			"svm":        "k8s-team-a",
+			"vip":        testVIP,
+			"volumePath": volumeID,
+		},
+	}
+}
+
+func TestNodeStageSharesSVMMount(t *testing.T) {
+	mounter := newCountingMounter()
+	d := newNodeDriver(t, mounter)
+
+	const volumes = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, volumes)
+	for i := 0; i < volumes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := d.NodeStageVolume(context.Background(), d.stageRequest(fmt.Sprintf("vol-%d", i)))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("NodeStageVolume: %v", err)
+		}
+	}
+	if n := mounter.nfsMounts(); n != 1 {
+		t.Errorf("NFS mounts = %d, want 1 for %d volumes of one SVM", n, volumes)
+	}
+}
+
+// isMounted reports whether the fake mounter has a mount on path
+func isMounted(t *testing.T, mounter mountutils.Interface, path string) bool {
+	t.Helper()
+
+	notMnt, err := mounter.IsLikelyNotMountPoint(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("IsLikelyNotMountPoint %s: %v", path, err)
+	}
+	return err == nil && !notMnt
+}
+
+func TestNodeVolumeLifecycle(t *testing.T) {
+	mounter := newCountingMounter()
+	d := newNodeDriver(t, mounter)
+	ctx := context.Background()
+	stage := d.stageRequest("vol-1")
+	svmMountPath := filepath.Join(d.dir, "mounts", "k8s-team-a")
+	targetPath := filepath.Join(d.dir, "pods", "pod-1", "volumes", "vol-1")
+
+	if _, err := d.NodeStageVolume(ctx, stage); err != nil {
+		t.Fatalf("NodeStageVolume: %v", err)
+	}
+	if !isMounted(t, mounter, svmMountPath) {
+		t.Errorf("SVM is not mounted at %s", svmMountPath)
+	}
+	if !isMounted(t, mounter, stage.StagingTargetPath) {
+		t.Errorf("volume is not staged at %s", stage.StagingTargetPath)
+	}
+
+	// Staging again is a no-op
+	mounts := len(mounter.GetLog())
+	if _, err := d.NodeStageVolume(ctx, stage); err != nil {
+		t.Fatalf("NodeStageVolume again: %v", err)
+	}
+	if n := len(mounter.GetLog()); n != mounts {
+		t.Errorf("NodeStageVolume again made %d mount calls, want none", n-mounts)
+	}
+
+	publish := &csi.NodePublishVolumeRequest{
+		VolumeId:          "vol-1",
+		StagingTargetPath: stage.StagingTargetPath,
+		TargetPath:        targetPath,
+		VolumeCapability:  stage.VolumeCapability,
+		Readonly:          true,
+	}
+	if _, err := d.NodePublishVolume(ctx, publish); err != nil {
+		t.Fatalf("NodePublishVolume: %v", err)
+	}
+	if !isMounted(t, mounter, targetPath) {
+		t.Fatalf("volume is not published at %s", targetPath)
+	}
+	mountPoints, _ := mounter.List()
+	readonly := false
+	for _, mp := range mountPoints {
+		if mp.Path == targetPath && slices.Contains(mp.Opts, "ro") {
+			readonly = true
+		}
+	}
+	if !readonly {
+		t.Errorf("read-only volume is published without ro: %+v", mountPoints)
+	}
+
+	if _, err := d.NodeUnpublishVolume(ctx, &csi.NodeUnpublishVolumeRequest{VolumeId: "vol-1", TargetPath: targetPath}); err != nil {
+		t.Fatalf("NodeUnpublishVolume: %v", err)
+	}
+	if isMounted(t, mounter, targetPath) {
+		t.Errorf("volume is still published at %s", targetPath)
+	}
+	if _, err := os.Stat(targetPath); !os.IsNotExist(err) {
+		t.Errorf("target path %s was not removed: %v", targetPath, err)
+	}
+
+	if _, err := d.NodeUnstageVolume(ctx, &csi.NodeUnstageVolumeRequest{VolumeId: "vol-1", StagingTargetPath: stage.StagingTargetPath}); err != nil {
+		t.Fatalf("NodeUnstageVolume: %v", err)
+	}
+	if isMounted(t, mounter, stage.StagingTargetPath) {
+		t.Errorf("volume is still staged at %s", stage.StagingTargetPath)
+	}
+	// The last volume of the SVM releases its mount
+	if isMounted(t, mounter, svmMountPath) {
+		t.Errorf("unused SVM is still mounted at %s", svmMountPath)
+	}
+
+	// Unstaging again is a no-op
+	if _, err := d.NodeUnstageVolume(ctx, &csi.NodeUnstageVolumeRequest{VolumeId: "vol-1", StagingTargetPath: stage.StagingTargetPath}); err != nil {
+		t.Fatalf("NodeUnstageVolume again: %v", err)
+	}
+}
+
+func TestNodeStageVolumeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *csi.NodeStageVolumeRequest)
+		code   codes.Code
+	}{
+		{
+			name:   "missing volume ID",
+			modify: func(req *csi.NodeStageVolumeRequest) { req.VolumeId = "" },
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "missing staging path",
+			modify: func(req *csi.NodeStageVolumeRequest) { req.StagingTargetPath = "" },
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "missing capability",
+			modify: func(req *csi.NodeStageVolumeRequest) { req.VolumeCapability = nil },
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "incomplete volume context",
+			modify: func(req *csi.NodeStageVolumeRequest) { delete(req.VolumeContext, "vip") },
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "invalid VIP",
+			modify: func(req *csi.NodeStageVolumeRequest) { req.VolumeContext["vip"] = "192.0.2.10,nolock" },
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "path traversal",
+			modify: func(req *csi.NodeStageVolumeRequest) { req.VolumeContext["volumePath"] = "../k8s-team-b/vol-1" },
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "newer volume context",
+			modify: func(req *csi.NodeStageVolumeRequest) { req.VolumeContext["schemaVersion"] = "99" },
+			code:   codes.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounter := newCountingMounter()
+			d := newNodeDriver(t, mounter)
+			req := d.stageRequest("vol-1")
+			tt.modify(req)
+
+			_, err := d.NodeStageVolume(context.Background(), req)
+			if status.Code(err) != tt.code {
+				t.Fatalf("NodeStageVolume = %v, want code %v", err, tt.code)
+			}
+			if n := len(mounter.GetLog()); n != 0 {
+				t.Errorf("rejected NodeStageVolume made %d mount calls", n)
+			}
+		})
+	}
+}
+
+// unreachableMounter is a fake mounter whose NFS mounts time out
+type unreachableMounter struct {
+	*mountutils.FakeMounter
+}
+
+func (m *unreachableMounter) Mount(source, target, fstype string, options []string) error {
+	if fstype == "nfs4" {
+		return errors.New("mount.nfs: Connection timed out")
+	}
+	return m.FakeMounter.Mount(source, target, fstype, options)
+}
+
+func TestNodeStageVolumeUnreachableSVM(t *testing.T) {
+	d := newNodeDriver(t, &unreachableMounter{FakeMounter: mountutils.NewFakeMounter(nil)})
+
+	// Failed and backed off SVM mounts are retried by kubelet
+	for _, attempt := range []string{"failed mount", "backoff"} {
+		_, err := d.NodeStageVolume(context.Background(), d.stageRequest("vol-1"))
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("NodeStageVolume (%s) = %v, want Unavailable", attempt, err)
+		}
+		if !strings.Contains(err.Error(), "VIP "+testVIP+" unreachable") {
+			t.Errorf("NodeStageVolume (%s) error %q does not give the failure reason", attempt, err)
+		}
+	}
+}
+
+// nfsSources returns the sources of the NFS mounts made
+func nfsSources(mounter *countingMounter) []string {
+	var sources []string
+	for _, action := range mounter.GetLog() {
+		if action.Action == mountutils.FakeActionMount && action.FSType == "nfs4" {
+			sources = append(sources, action.Source)
+		}
+	}
+	return sources
+}
+
+func TestNodeStageVolumeSVMHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		svmHost string
+		wantIP  string
+	}{
+		{name: "resolved", svmHost: "localhost", wantIP: "127.0.0.1"},
+		{name: "unresolvable", svmHost: "svm..invalid", wantIP: testVIP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounter := newCountingMounter()
+			d := newNodeDriver(t, mounter)
+			stage := d.stageRequest("vol-1")
+			stage.VolumeContext["svmHost"] = tt.svmHost
+
+			if _, err := d.NodeStageVolume(context.Background(), stage); err != nil {
+				t.Fatalf("NodeStageVolume: %v", err)
+			}
+			sources := nfsSources(mounter)
+			if len(sources) != 1 || !strings.HasPrefix(sources[0], tt.wantIP+":") {
+				t.Errorf("NFS mount sources = %v, want one from %s", sources, tt.wantIP)
+			}
+		})
+	}
+}
+
+// testSELinuxContext is the SELinux context mount flag kubelet passes
+const testSELinuxContext = `context="system_u:object_r:container_file_t:s0:c1,c2"`
+
+// mountOptions returns the options of the mounts on path
+func mountOptions(mounter *countingMounter, path string) []string {
+	var opts []string
+	for _, mp := range mounter.MountPoints {
+		if mp.Path == path {
+			opts = append(opts, mp.Opts...)
+		}
+	}
+	return opts
+}
+
+func TestNodeStageVolumeSELinuxContext(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("selinux_mount=%t", enabled), func(t *testing.T) {
+			mounter := newCountingMounter()
+			d := newNodeDriver(t, mounter, func(cfg *driver.DriverConfig) { cfg.SELinuxMount = enabled })
+			stage := d.stageRequest("vol-1")
+			stage.VolumeCapability.GetMount().MountFlags = []string{testSELinuxContext}
+
+			if _, err := d.NodeStageVolume(context.Background(), stage); err != nil {
+				t.Fatalf("NodeStageVolume: %v", err)
+			}
+
+			// The context labels the NFS mount, never the bind mount
+			var nfsOpts []string
+			for _, action := range mounter.GetLog() {
+				if action.Action == mountutils.FakeActionMount && action.FSType == "nfs4" {
+					nfsOpts = mountOptions(mounter, action.Target)
+				}
+			}
+			if got := slices.Contains(nfsOpts, testSELinuxContext); got != enabled {
+				t.Errorf("NFS mount options %v have the SELinux context: %t, want %t", nfsOpts, got, enabled)
+			}
+			if opts := mountOptions(mounter, stage.StagingTargetPath); slices.Contains(opts, testSELinuxContext) {
+				t.Errorf("staging bind mount has the SELinux context: %v", opts)
+			}
+		})
+	}
+}
+
+func TestNodeStageVolumeSELinuxContextSeparatesMounts(t *testing.T) {
+	mounter := newCountingMounter()
+	d := newNodeDriver(t, mounter, func(cfg *driver.DriverConfig) { cfg.SELinuxMount = true })
+
+	// Volumes of one SVM staged for pods of different SELinux contexts
+	// cannot share a mount
+	for i, flags := range [][]string{{testSELinuxContext}, {`context="system_u:object_r:container_file_t:s0:c3,c4"`}, nil} {
+		stage := d.stageRequest(fmt.Sprintf("vol-%d", i))
+		stage.VolumeCapability.GetMount().MountFlags = flags
+		if _, err := d.NodeStageVolume(context.Background(), stage); err != nil {
+			t.Fatalf("NodeStageVolume: %v", err)
+		}
+	}
+	if n := mounter.nfsMounts(); n != 3 {
+		t.Errorf("NFS mounts = %d, want one per SELinux context", n)
+	}
+}
+
+func TestNodePublishVolumePassesSELinuxContext(t *testing.T) {
+	mounter := newCountingMounter()
+	d := newNodeDriver(t, mounter)
+	ctx := context.Background()
+	stage := d.stageRequest("vol-1")
+	if _, err := d.NodeStageVolume(ctx, stage); err != nil {
+		t.Fatalf("NodeStageVolume: %v", err)
+	}
+
+	// Mount flags are passed on to the bind mount whether or not
+	// selinux_mount is set
+	targetPath := filepath.Join(d.dir, "pods", "pod-1", "volumes", "vol-1")
+	capability := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{MountFlags: []string{testSELinuxContext}}},
+		AccessMode: stage.VolumeCapability.AccessMode,
+	}
+	_, err := d.NodePublishVolume(ctx, &csi.NodePublishVolumeRequest{
+		VolumeId:          "vol-1",
+		StagingTargetPath: stage.StagingTargetPath,
+		TargetPath:        targetPath,
+		VolumeCapability:  capability,
+	})
+	if err != nil {
+		t.Fatalf("NodePublishVolume: %v", err)
+	}
+	if opts := mountOptions(mounter, targetPath); !slices.Contains(opts, testSELinuxContext) {
+		t.Errorf("publish bind mount options %v lack the SELinux context", opts)
+	}
+}
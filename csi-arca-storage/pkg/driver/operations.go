@@ -0,0 +1,163 @@
+package driver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/opqueue"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// Parameters of Clone operations
+const (
+	cloneParamSVM    = "svm"
+	cloneParamSource = "source"
+	cloneParamTarget = "target"
+	// cloneParamKind is cloneKindVolume or cloneKindSnapshot
+	cloneParamKind    = "kind"
+	cloneKindVolume   = "volume"
+	cloneKindSnapshot = "snapshot"
+)
+
+// idMarkerParamID is the parameter of VolumeID and SnapshotID markers
+// holding the assigned ID
+const idMarkerParamID = "id"
+
+// registerOperations resumes the operations of the operation queue with
+// the driver and records the SVM creations of every backend (controller)
+func (d *Driver) registerOperations() {
+	d.operations.Handle(opqueue.TypeSVMCreate, d.resumeSVMCreate)
+	d.operations.Handle(opqueue.TypeClone, d.resumeClone)
+	d.operations.Handle(opqueue.TypeVolumeDelete, d.resumeVolumeDelete)
+
+	for _, backend := range d.backends.Backends() {
+		name := backend.Name
+		backend.SVMManager.SetOperationRunner(func(ctx context.Context, namespace string, create func(context.Context) error) error {
+			return d.operations.Do(ctx, &opqueue.Operation{Type: opqueue.TypeSVMCreate, Key: namespace, Backend: name}, create)
+		})
+	}
+}
+
+// runOperation runs fn as op, recorded in the operation queue when it is
+// enabled. An operation running in another controller is Aborted, for the
+// sidecars to retry.
+func (d *Driver) runOperation(ctx context.Context, op *opqueue.Operation, fn func(context.Context) error) error {
+	if d.operations == nil {
+		return fn(ctx)
+	}
+	err := d.operations.Do(ctx, op, fn)
+	if errors.Is(err, opqueue.ErrInProgress) {
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return err
+}
+
+// idRecorder persists in-flight ID assignments as operation queue markers
+type idRecorder struct {
+	queue      *opqueue.Queue
+	markerType string
+}
+
+// RecordedID returns the ID recorded for key
+func (r *idRecorder) RecordedID(key string) (string, bool, error) {
+	params, found, err := r.queue.Marker(context.Background(), r.markerType, key)
+	if err != nil || !found {
+		return "", false, err
+	}
+	return params[idMarkerParamID], params[idMarkerParamID] != "", nil
+}
+
+// RecordID records the ID assigned to key
+func (r *idRecorder) RecordID(key, id string) error {
+	return r.queue.SetMarker(context.Background(), r.markerType, key, map[string]string{idMarkerParamID: id})
+}
+
+// ForgetID drops the record of key
+func (r *idRecorder) ForgetID(key string) error {
+	return r.queue.DeleteMarker(context.Background(), r.markerType, key)
+}
+
+// cloneOperation returns the Clone operation copying source on svm to the
+// path of volumeID
+func cloneOperation(backend *arca.Backend, volumeID, kind, svm, source, target string) *opqueue.Operation {
+	return &opqueue.Operation{
+		Type:    opqueue.TypeClone,
+		Key:     volumeID,
+		Backend: backend.Name,
+		Parameters: map[string]string{
+			cloneParamKind:   kind,
+			cloneParamSVM:    svm,
+			cloneParamSource: source,
+			cloneParamTarget: target,
+		},
+	}
+}
+
+// resumeSVMCreate creates the SVM of a namespace
+func (d *Driver) resumeSVMCreate(ctx context.Context, op *opqueue.Operation) error {
+	backend, err := d.backendFor(op.Backend)
+	if err != nil {
+		return err
+	}
+	svm, err := backend.SVMManager.EnsureSVM(ctx, op.Key)
+	if err != nil {
+		return err
+	}
+	klog.Infof("SVM %s of namespace %s is ready (VIP: %s)", svm.Name, op.Key, svm.VIP)
+	return nil
+}
+
+// resumeClone copies the source of a clone or snapshot restore again; the
+// copy is a reflink, so an interrupted one is simply repeated. The
+// ArcaVolume is written by the CreateVolume retry.
+func (d *Driver) resumeClone(ctx context.Context, op *opqueue.Operation) error {
+	backend, err := d.backendFor(op.Backend)
+	if err != nil {
+		return err
+	}
+	params := op.Parameters
+	for _, path := range []string{params[cloneParamSource], params[cloneParamTarget]} {
+		if err := checkRecordedPath(path); err != nil {
+			return status.Errorf(codes.FailedPrecondition, "clone of volume %s has an invalid path: %v", op.Key, err)
+		}
+	}
+
+	switch params[cloneParamKind] {
+	case cloneKindVolume:
+		err = backend.Client.CreateSnapshot(ctx, &arca.CreateSnapshotRequest{
+			SVMName:      params[cloneParamSVM],
+			SourcePath:   params[cloneParamSource],
+			SnapshotPath: params[cloneParamTarget],
+		})
+	case cloneKindSnapshot:
+		err = backend.Client.RestoreSnapshot(ctx, &arca.RestoreSnapshotRequest{
+			SVMName:      params[cloneParamSVM],
+			SnapshotPath: params[cloneParamSource],
+			TargetPath:   params[cloneParamTarget],
+		})
+	default:
+		return status.Errorf(codes.InvalidArgument, "clone of volume %s has unknown kind %q", op.Key, params[cloneParamKind])
+	}
+	if err != nil && !arca.IsAlreadyExistsError(err) {
+		return toStatus(err, "failed to clone volume %s", op.Key)
+	}
+	return nil
+}
+
+// resumeVolumeDelete deletes the backend data and the record of a volume
+// whose DeleteVolume was interrupted
+func (d *Driver) resumeVolumeDelete(ctx context.Context, op *opqueue.Operation) error {
+	volumeInfo, err := d.store.GetVolume(op.Key)
+	if store.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return toStatus(err, "failed to get volume %s", op.Key)
+	}
+	return d.retryDeleteVolume(ctx, volumeInfo)
+}
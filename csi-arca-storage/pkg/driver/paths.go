@@ -0,0 +1,109 @@
+package driver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/akam1o/csi-arca-storage/pkg/idempotency"
+)
+
+// DefaultSnapshotDir is the backend directory holding snapshots, relative
+// to the SVM root
+const DefaultSnapshotDir = ".snapshots"
+
+// snapshotDirMode are the permissions the snapshot directory is created
+// with: only root on the SVM may enter it
+const snapshotDirMode = "0700"
+
+// maxPathComponentLength bounds a single backend path component (XFS NAME_MAX)
+const maxPathComponentLength = 255
+
+// All backend paths the controller sends to ARCA are built here so that
+// request-derived names never reach the backend unchecked. Paths are
+// relative to the SVM root and must also satisfy the ArcaVolume/ArcaSnapshot
+// "path" schema.
+
+// checkPathComponent verifies name is safe as one backend path component:
+// lowercase alphanumerics and '-', not starting with '-'
+func checkPathComponent(name string) error {
+	if name == "" {
+		return fmt.Errorf("path component is empty")
+	}
+	if len(name) > maxPathComponentLength {
+		return fmt.Errorf("path component %q is longer than %d characters", name, maxPathComponentLength)
+	}
+	if name[0] == '-' {
+		return fmt.Errorf("path component %q starts with '-'", name)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-') {
+			return fmt.Errorf("path component %q contains invalid character %q", name, c)
+		}
+	}
+	return nil
+}
+
+// volumeBackendPath returns the backend directory of a volume
+func volumeBackendPath(volumeID string) (string, error) {
+	if !idempotency.IsVolumeID(volumeID) {
+		return "", fmt.Errorf("invalid volume ID %q", volumeID)
+	}
+	if err := checkPathComponent(volumeID); err != nil {
+		return "", err
+	}
+	return volumeID, nil
+}
+
+// CheckSnapshotDir verifies name is usable as the snapshot directory: one
+// path component of lowercase alphanumerics, '.', '_' and '-' that no volume
+// directory can be named
+func CheckSnapshotDir(name string) error {
+	if name == "" || name == "." || name == ".." {
+		return fmt.Errorf("snapshot directory %q is not a directory name", name)
+	}
+	if len(name) > maxPathComponentLength {
+		return fmt.Errorf("snapshot directory %q is longer than %d characters", name, maxPathComponentLength)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '.' || c == '_' || c == '-') {
+			return fmt.Errorf("snapshot directory %q contains invalid character %q", name, c)
+		}
+	}
+	if idempotency.IsVolumeID(name) {
+		return fmt.Errorf("snapshot directory %q is named like a volume", name)
+	}
+	return nil
+}
+
+// inSnapshotDir reports whether a backend path lies in a snapshot
+// directory, the configured one or the default one of earlier snapshots
+func inSnapshotDir(p, snapshotDir string) bool {
+	first, _, _ := strings.Cut(path.Clean(p), "/")
+	return first == snapshotDir || first == DefaultSnapshotDir
+}
+
+// snapshotBackendPath returns the backend directory of a snapshot
+func snapshotBackendPath(snapshotDir, snapshotID string) (string, error) {
+	if !idempotency.IsSnapshotID(snapshotID) {
+		return "", fmt.Errorf("invalid snapshot ID %q", snapshotID)
+	}
+	if err := checkPathComponent(snapshotID); err != nil {
+		return "", err
+	}
+	return path.Join(snapshotDir, snapshotID), nil
+}
+
+// checkRecordedPath verifies a path read back from the store before it is
+// sent to the backend (e.g. the source of a clone or restore)
+func checkRecordedPath(p string) error {
+	if err := validateVolumePath(p); err != nil {
+		return err
+	}
+	if path.Clean(p) != p {
+		return fmt.Errorf("path %q is not in canonical form", p)
+	}
+	return nil
+}
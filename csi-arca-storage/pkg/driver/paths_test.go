@@ -0,0 +1,219 @@
+package driver
+
+import (
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akam1o/csi-arca-storage/deploy"
+	"github.com/akam1o/csi-arca-storage/pkg/idempotency"
+)
+
+// crdField is the validation schema of a spec field of a CRD
+type crdField struct {
+	Pattern   string `yaml:"pattern"`
+	MinLength int    `yaml:"minLength"`
+	MaxLength int    `yaml:"maxLength"`
+}
+
+// check reports why value does not validate against the field, or ""
+func (f crdField) check(t *testing.T, value string) string {
+	t.Helper()
+
+	if len(value) < f.MinLength {
+		return "shorter than minLength"
+	}
+	if f.MaxLength > 0 && len(value) > f.MaxLength {
+		return "longer than maxLength"
+	}
+	if f.Pattern != "" && !regexp.MustCompile(f.Pattern).MatchString(value) {
+		return "does not match " + f.Pattern
+	}
+	return ""
+}
+
+// loadCRDField returns the schema of spec.<field> in the generated CRD
+// manifest of a resource
+func loadCRDField(t *testing.T, resource, field string) crdField {
+	t.Helper()
+
+	data, err := deploy.CRDs.ReadFile("crds/storage.arca.io_" + resource + ".yaml")
+	if err != nil {
+		t.Fatalf("reading CRD %s: %v", resource, err)
+	}
+	var crd struct {
+		Spec struct {
+			Versions []struct {
+				Schema struct {
+					OpenAPIV3Schema struct {
+						Properties struct {
+							Spec struct {
+								Properties map[string]crdField `yaml:"properties"`
+							} `yaml:"spec"`
+						} `yaml:"properties"`
+					} `yaml:"openAPIV3Schema"`
+				} `yaml:"schema"`
+			} `yaml:"versions"`
+		} `yaml:"spec"`
+	}
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		t.Fatalf("parsing CRD %s: %v", resource, err)
+	}
+	if len(crd.Spec.Versions) == 0 {
+		t.Fatalf("CRD %s has no versions", resource)
+	}
+	schema, ok := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties.Spec.Properties[field]
+	if !ok {
+		t.Fatalf("CRD %s has no spec.%s", resource, field)
+	}
+	return schema
+}
+
+// uuidLookup finds no earlier ID assignment, so UUID generators assign
+// fresh IDs
+func uuidLookup(string) (string, bool, error) {
+	return "", false, nil
+}
+
+// idCandidate is a string built from the characters of volume and snapshot
+// IDs and path separators, so that random candidates come close to valid
+// IDs and reach every check of the path builders
+type idCandidate string
+
+const idCandidateAlphabet = "pvc-0123456789abcdefABCDEF./_ "
+
+func (idCandidate) Generate(r *rand.Rand, size int) reflect.Value {
+	var b strings.Builder
+	if r.Intn(2) == 0 {
+		b.WriteString("pvc-")
+	}
+	for n := r.Intn(40); n > 0; n-- {
+		b.WriteByte(idCandidateAlphabet[r.Intn(len(idCandidateAlphabet))])
+	}
+	return reflect.ValueOf(idCandidate(b.String()))
+}
+
+func TestVolumeBackendPathMatchesCRD(t *testing.T) {
+	volumeID := loadCRDField(t, "arcavolumes", "volumeID")
+	volumePath := loadCRDField(t, "arcavolumes", "path")
+	hashIDs := idempotency.NewVolumeIDGenerator()
+	uuidIDs := idempotency.NewUUIDVolumeIDGenerator(uuidLookup, nil)
+
+	// Every generated volume ID has a backend path the CRD accepts
+	generated := func(name string) bool {
+		for _, gen := range []idempotency.VolumeIDGenerator{hashIDs, uuidIDs} {
+			id, err := gen.GenerateVolumeID(name)
+			if err != nil {
+				t.Logf("GenerateVolumeID(%q): %v", name, err)
+				return false
+			}
+			p, err := volumeBackendPath(id)
+			if err != nil {
+				t.Logf("volumeBackendPath(%q): %v", id, err)
+				return false
+			}
+			if reason := volumeID.check(t, id); reason != "" {
+				t.Logf("volume ID %q %s", id, reason)
+				return false
+			}
+			if reason := volumePath.check(t, p); reason != "" {
+				t.Logf("volume path %q %s", p, reason)
+				return false
+			}
+			if err := checkRecordedPath(p); err != nil || inSnapshotDir(p, DefaultSnapshotDir) {
+				t.Logf("volume path %q is not a volume directory: %v", p, err)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(generated, nil); err != nil {
+		t.Error(err)
+	}
+
+	// Any ID accepted as a backend path is one the CRD accepts
+	accepted := func(c idCandidate) bool {
+		id := string(c)
+		p, err := volumeBackendPath(id)
+		if err != nil {
+			return true
+		}
+		return volumeID.check(t, id) == "" && volumePath.check(t, p) == "" && !strings.Contains(p, "/")
+	}
+	if err := quick.Check(accepted, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSnapshotBackendPathMatchesCRD(t *testing.T) {
+	snapshotID := loadCRDField(t, "arcasnapshots", "snapshotID")
+	snapshotPath := loadCRDField(t, "arcasnapshots", "path")
+	hashIDs := idempotency.NewSnapshotIDGenerator()
+	uuidIDs := idempotency.NewUUIDSnapshotIDGenerator(uuidLookup, nil)
+
+	generated := func(key string) bool {
+		for _, gen := range []idempotency.SnapshotIDGenerator{hashIDs, uuidIDs} {
+			id, err := gen.GenerateSnapshotID(key)
+			if err != nil {
+				t.Logf("GenerateSnapshotID(%q): %v", key, err)
+				return false
+			}
+			p, err := snapshotBackendPath(DefaultSnapshotDir, id)
+			if err != nil {
+				t.Logf("snapshotBackendPath(%q): %v", id, err)
+				return false
+			}
+			if reason := snapshotID.check(t, id); reason != "" {
+				t.Logf("snapshot ID %q %s", id, reason)
+				return false
+			}
+			if reason := snapshotPath.check(t, p); reason != "" {
+				t.Logf("snapshot path %q %s", p, reason)
+				return false
+			}
+			if err := checkRecordedPath(p); err != nil || !inSnapshotDir(p, DefaultSnapshotDir) {
+				t.Logf("snapshot path %q is not in the snapshot directory: %v", p, err)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(generated, nil); err != nil {
+		t.Error(err)
+	}
+
+	accepted := func(c idCandidate) bool {
+		id := string(c)
+		p, err := snapshotBackendPath(DefaultSnapshotDir, id)
+		if err != nil {
+			return true
+		}
+		return snapshotID.check(t, id) == "" && snapshotPath.check(t, p) == "" && p == DefaultSnapshotDir+"/"+id
+	}
+	if err := quick.Check(accepted, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzCheckPathComponent(f *testing.F) {
+	for _, seed := range []string{"pvc-0123456789abcdef", "..", "a/b", "-rf", "", ".snapshots", "PVC"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		if checkPathComponent(name) != nil {
+			return
+		}
+		// An accepted component stays a single directory below the SVM root
+		if strings.ContainsAny(name, "/.") || name[0] == '-' || len(name) > maxPathComponentLength {
+			t.Fatalf("checkPathComponent accepted %q", name)
+		}
+		if err := checkRecordedPath(name); err != nil {
+			t.Fatalf("checkRecordedPath(%q) rejects an accepted component: %v", name, err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package driver
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// CreateVolume phases
+const (
+	phaseSVMEnsure       = "svm_ensure"
+	phaseClone           = "clone"
+	phaseRestore         = "restore"
+	phaseDirectoryCreate = "directory_create"
+	phaseQuota           = "quota"
+	phaseStoreWrite      = "store_write"
+)
+
+// timedPhase is the duration of one phase of an operation
+type timedPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// phaseTimer times an operation and its phases, exports them as histograms
+// and logs the phase breakdown of an operation slower than its SLO
+type phaseTimer struct {
+	operation string
+	name      string
+	attempt   int // of the call for name, counting sidecar retries
+	info      requestInfo
+	start     time.Time
+	phases    []timedPhase
+}
+
+// newPhaseTimer starts timing operation on the named volume or snapshot
+func newPhaseTimer(operation, name string) *phaseTimer {
+	return &phaseTimer{operation: operation, name: name, start: time.Now()}
+}
+
+// time starts a phase and returns the function ending it
+func (t *phaseTimer) time(phase string) func() {
+	start := time.Now()
+	return func() {
+		d := time.Since(start)
+		t.phases = append(t.phases, timedPhase{name: phase, duration: d})
+		metrics.OperationPhaseDuration.WithLabelValues(t.operation, phase).Observe(d.Seconds())
+	}
+}
+
+// finish records the operation's duration and, when it exceeded slo
+// (disabled when zero), logs a "slow operation" entry with each phase
+func (t *phaseTimer) finish(slo time.Duration, err error) {
+	total := time.Since(t.start)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.OperationDuration.WithLabelValues(t.operation, result).Observe(total.Seconds())
+
+	if slo <= 0 || total <= slo {
+		return
+	}
+	metrics.SlowOperations.WithLabelValues(t.operation).Inc()
+	kvs := []any{
+		"operation", t.operation,
+		"name", t.name,
+		"result", result,
+		"attempt", t.attempt,
+		"namespace", t.info.namespace,
+		"storageClass", t.info.storageClass,
+		"duration", total.Round(time.Millisecond).String(),
+		"slo", slo.String(),
+	}
+	for _, p := range t.phases {
+		kvs = append(kvs, p.name, p.duration.Round(time.Millisecond).String())
+	}
+	klog.InfoS("Slow operation", kvs...)
+}
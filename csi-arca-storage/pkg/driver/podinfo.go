@@ -0,0 +1,63 @@
+package driver
+
+import (
+	"os"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+)
+
+// Volume context keys kubelet adds to NodePublishVolume with podInfoOnMount
+const (
+	volumeContextPodName      = "csi.storage.k8s.io/pod.name"
+	volumeContextPodNamespace = "csi.storage.k8s.io/pod.namespace"
+	volumeContextPodUID       = "csi.storage.k8s.io/pod.uid"
+)
+
+// podInfo returns the pod a volume is published for, or nil if kubelet did
+// not pass pod information
+func podInfo(volumeContext map[string]string) *mount.PodInfo {
+	uid := volumeContext[volumeContextPodUID]
+	if uid == "" {
+		return nil
+	}
+	return &mount.PodInfo{
+		UID:       uid,
+		Namespace: volumeContext[volumeContextPodNamespace],
+		Name:      volumeContext[volumeContextPodName],
+	}
+}
+
+// podDir returns the kubelet pod directory containing a target path
+// (".../pods/<uid>"), or "" if the path is not under it
+func podDir(targetPath, uid string) string {
+	marker := "/pods/" + uid + "/"
+	i := strings.Index(targetPath, marker)
+	if i < 0 {
+		return ""
+	}
+	return targetPath[:i+len(marker)-1]
+}
+
+// cleanupOrphanedPublishes drops published paths whose pod directory is
+// gone, e.g. when kubelet removed the pod while the node plugin was down and
+// never called NodeUnpublishVolume
+func (d *Driver) cleanupOrphanedPublishes() {
+	for volumeID, staging := range d.nodeState.GetStagedVolumes() {
+		for targetPath, pod := range staging.Pods {
+			dir := podDir(targetPath, pod.UID)
+			if dir == "" {
+				continue
+			}
+			if _, err := d.fs.Stat(dir); !os.IsNotExist(err) {
+				continue
+			}
+			klog.Infof("Pod %s/%s (%s) is gone, removing stale publish of volume %s at %s", pod.Namespace, pod.Name, pod.UID, volumeID, targetPath)
+			if err := d.nodeState.RemoveVolumePublish(volumeID, targetPath); err != nil {
+				klog.Warningf("Failed to remove stale publish of volume %s: %v", volumeID, err)
+			}
+		}
+	}
+}
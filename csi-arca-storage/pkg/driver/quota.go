@@ -0,0 +1,89 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+)
+
+// DefaultQuotaVerifyTimeout is how long a quota change is read back before
+// the call fails
+const DefaultQuotaVerifyTimeout = 30 * time.Second
+
+// Read-back intervals of quota verification
+const (
+	quotaPollInitial = 200 * time.Millisecond
+	quotaPollMax     = 2 * time.Second
+)
+
+// setQuota sets the quota of a volume directory and reads it back until the
+// backend reports the new limits. Some backends accept a quota and apply it
+// asynchronously, or drop it; the request is sent again once half the
+// verification timeout has passed. A quota still not applied fails with
+// Unavailable, so the caller retries instead of reporting a size Kubernetes
+// would record but the volume does not have. Backends that cannot report
+// quotas are trusted.
+func (d *Driver) setQuota(ctx context.Context, backend *arca.Backend, req *arca.SetQuotaRequest) error {
+	if err := backend.Client.SetQuota(ctx, req); err != nil {
+		return toStatus(err, "failed to set quota of %s", req.Path)
+	}
+
+	timeout := d.quotaVerifyTimeout
+	if timeout <= 0 {
+		timeout = DefaultQuotaVerifyTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	resendAt := time.Now().Add(timeout / 2)
+	resent := false
+	interval := quotaPollInitial
+
+	for {
+		quota, err := backend.Client.GetQuota(ctx, req.SVMName, req.Path)
+		switch {
+		case errors.Is(err, arca.ErrNotSupported):
+			klog.V(4).Infof("Backend %q does not report quotas, assuming the quota of %s is applied", backend.Name, req.Path)
+			return nil
+		case err == nil && quotaApplied(quota, req):
+			return nil
+		case err != nil && !errors.Is(err, arca.ErrQuotaNotFound):
+			return toStatus(err, "failed to read back quota of %s", req.Path)
+		case err == nil:
+			klog.V(4).Infof("Quota of %s on SVM %s is %d bytes and %d inodes, waiting for %d bytes and %d inodes",
+				req.Path, req.SVMName, quota.QuotaBytes, quota.InodeLimit, req.QuotaBytes, req.InodeLimit)
+		}
+
+		now := time.Now()
+		if !now.Before(deadline) {
+			return status.Errorf(codes.Unavailable, "quota of %s on SVM %s was accepted but not applied within %v", req.Path, req.SVMName, timeout)
+		}
+		if !resent && !now.Before(resendAt) {
+			klog.Warningf("Quota of %s on SVM %s is still not applied, sending it again", req.Path, req.SVMName)
+			if err := backend.Client.SetQuota(ctx, req); err != nil {
+				return toStatus(err, "failed to set quota of %s", req.Path)
+			}
+			resent = true
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return status.Errorf(codes.DeadlineExceeded, "quota of %s on SVM %s was not applied before the call ended: %v", req.Path, req.SVMName, ctx.Err())
+		}
+		interval = min(interval*2, quotaPollMax)
+	}
+}
+
+// quotaApplied reports whether a quota read back has at least the limits
+// requested; backends may round limits up
+func quotaApplied(quota *arca.QuotaInfo, req *arca.SetQuotaRequest) bool {
+	if quota.QuotaBytes < req.QuotaBytes {
+		return false
+	}
+	return req.InodeLimit == 0 || quota.InodeLimit >= req.InodeLimit
+}
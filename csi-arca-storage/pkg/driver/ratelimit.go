@@ -0,0 +1,88 @@
+package driver
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// RateLimit is a token bucket: QPS calls per second sustained, with bursts of
+// up to Burst calls
+type RateLimit struct {
+	QPS   float64
+	Burst int
+}
+
+// NamespaceRateLimits limit the provisioning calls of each namespace, so
+// one tenant's PVC churn cannot starve the others
+type NamespaceRateLimits struct {
+	// Default applies to namespaces without an override; zero QPS leaves
+	// them unlimited
+	Default RateLimit
+	// Namespaces override Default per namespace
+	Namespaces map[string]RateLimit
+}
+
+// namespaceLimiter keeps one token bucket per namespace. Buckets that have
+// refilled completely are dropped, as a new one starts full anyway.
+type namespaceLimiter struct {
+	limits   NamespaceRateLimits
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// newNamespaceLimiter returns a limiter for limits, or nil when no namespace
+// is limited
+func newNamespaceLimiter(limits NamespaceRateLimits) *namespaceLimiter {
+	limited := limits.Default.QPS > 0
+	for _, limit := range limits.Namespaces {
+		limited = limited || limit.QPS > 0
+	}
+	if !limited {
+		return nil
+	}
+	return &namespaceLimiter{limits: limits, limiters: make(map[string]*rate.Limiter)}
+}
+
+// allow takes a token for a call of operation in namespace and returns an
+// Unavailable error, which the sidecars retry with backoff, when the
+// namespace has none left. Calls are rejected rather than queued so a
+// throttled namespace does not hold the sidecar's workers.
+func (l *namespaceLimiter) allow(operation, namespace string) error {
+	if l == nil {
+		return nil
+	}
+	limit, ok := l.limits.Namespaces[namespace]
+	if !ok {
+		limit = l.limits.Default
+	}
+	if limit.QPS <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	for ns, limiter := range l.limiters {
+		if limiter.TokensAt(now) >= float64(limiter.Burst()) {
+			delete(l.limiters, ns)
+		}
+	}
+	limiter, ok := l.limiters[namespace]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit.QPS), max(limit.Burst, 1))
+		l.limiters[namespace] = limiter
+	}
+	allowed := limiter.AllowN(now, 1)
+	l.mu.Unlock()
+
+	if !allowed {
+		metrics.RateLimited.WithLabelValues(operation, namespace).Inc()
+		return status.Errorf(codes.Unavailable, "%s rate limit of namespace %s exceeded (%g/s, burst %d)", operation, namespace, limit.QPS, max(limit.Burst, 1))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"context"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// requestInfo is who a provisioning call is made for. It travels in the
+// request context, so the rate limiter, accounting and slow-operation logs
+// see the same tenant without threading it through every call.
+type requestInfo struct {
+	namespace    string
+	storageClass string
+}
+
+// requestInfoKey is the context key of a requestInfo
+type requestInfoKey struct{}
+
+// withRequestInfo returns ctx carrying info
+func withRequestInfo(ctx context.Context, info requestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// requestInfoFrom returns the requestInfo of ctx; the zero value when none
+// was set
+func requestInfoFrom(ctx context.Context) requestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(requestInfo)
+	return info
+}
+
+// pvcStorageClass returns the StorageClass of the PVC a volume is created
+// for. The provisioner only passes the PVC name with --extra-create-metadata;
+// without it, or when the lookup fails, the StorageClass is left empty
+// rather than failing provisioning.
+func (d *Driver) pvcStorageClass(ctx context.Context, namespace string, params map[string]string) string {
+	pvcName := params[paramPVCName]
+	if d.k8sClient == nil || pvcName == "" {
+		return ""
+	}
+	pvc, err := d.k8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("Failed to look up PVC %s/%s for accounting: %v", namespace, pvcName, err)
+		return ""
+	}
+	if pvc.Spec.StorageClassName == nil {
+		return ""
+	}
+	return *pvc.Spec.StorageClassName
+}
+
+// snapshotNamespace returns the namespace a snapshot is created for: that of
+// the VolumeSnapshot when the snapshotter passes it, otherwise that of the
+// source volume's PVC
+func snapshotNamespace(source *store.VolumeInfo, params map[string]string) string {
+	if namespace := params[paramVolumeSnapshotNamespace]; namespace != "" {
+		return namespace
+	}
+	namespace, _, _ := strings.Cut(source.Annotations[store.AnnotationPVC], "/")
+	return namespace
+}
@@ -0,0 +1,82 @@
+package driver
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// retryWindow is how long after the last call a repeated call for the same
+// name counts as a retry. The external-provisioner backs off up to 5 minutes
+// between attempts.
+const retryWindow = 10 * time.Minute
+
+// callHistory is the calls made for one name
+type callHistory struct {
+	first    time.Time
+	last     time.Time
+	attempts int
+}
+
+// retryTracker counts the calls of an operation per name, so sidecar retries
+// of a slow or failing call can be told apart from new requests
+type retryTracker struct {
+	operation string
+	window    time.Duration
+	mu        sync.Mutex
+	calls     map[string]*callHistory
+}
+
+// newRetryTracker creates a tracker for operation
+func newRetryTracker(operation string, window time.Duration) *retryTracker {
+	return &retryTracker{
+		operation: operation,
+		window:    window,
+		calls:     make(map[string]*callHistory),
+	}
+}
+
+// begin records a call for name and returns its attempt number, 1 unless
+// the previous call was less than the window ago
+func (t *retryTracker) begin(name string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for n, h := range t.calls {
+		if now.Sub(h.last) > t.window {
+			delete(t.calls, n)
+		}
+	}
+
+	h, ok := t.calls[name]
+	if !ok {
+		t.calls[name] = &callHistory{first: now, last: now, attempts: 1}
+		return 1
+	}
+	h.attempts++
+	h.last = now
+	metrics.OperationRetries.WithLabelValues(t.operation).Inc()
+	klog.Infof("%s %s is attempt %d (first call %v ago)", t.operation, name, h.attempts, now.Sub(h.first).Round(time.Second))
+	return h.attempts
+}
+
+// succeeded forgets name after a successful call and records how many
+// attempts it took
+func (t *retryTracker) succeeded(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.calls[name]
+	if !ok {
+		return
+	}
+	delete(t.calls, name)
+	metrics.OperationAttempts.WithLabelValues(t.operation).Observe(float64(h.attempts))
+	if h.attempts > 1 {
+		klog.Infof("%s %s succeeded after %d attempts in %v", t.operation, name, h.attempts, time.Since(h.first).Round(time.Second))
+	}
+}
@@ -0,0 +1,28 @@
+package driver
+
+import (
+	"context"
+	"path"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+)
+
+// RetryPolicyRPCs are the RPCs that call the ARCA API and so accept a retry
+// policy
+var RetryPolicyRPCs = []string{
+	"CreateVolume", "DeleteVolume", "ControllerExpandVolume", "ControllerGetVolume",
+	"ListVolumes", "GetCapacity", "CreateSnapshot", "DeleteSnapshot", "ListSnapshots",
+	"NodePublishVolume",
+}
+
+// withRetryPolicy returns ctx with the retry policy configured for an RPC,
+// given by its full gRPC method name. A policy with fewer retries makes the
+// RPC fail sooner, so the kubelet or sidecar that called it retries with its
+// own backoff instead.
+func (d *Driver) withRetryPolicy(ctx context.Context, fullMethod string) context.Context {
+	policy, ok := d.retryPolicies[path.Base(fullMethod)]
+	if !ok {
+		return ctx
+	}
+	return arca.WithRetryPolicy(ctx, policy)
+}
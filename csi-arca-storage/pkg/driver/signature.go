@@ -0,0 +1,82 @@
+package driver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// volumeContextSignature is the volume context key of the HMAC over the
+// fields that tell a node what to mount
+const volumeContextSignature = "signature"
+
+// signedVolumeContextKeys are the volume context fields covered by the
+// signature, in signing order
+var signedVolumeContextKeys = []string{volumeContextSVM, volumeContextVIP, volumeContextVolumePath, volumeContextSVMHost}
+
+// optionalSignedVolumeContextKeys are signed after signedVolumeContextKeys
+// only when present, so the signatures of volumes created before they were
+// added stay valid
+var optionalSignedVolumeContextKeys = []string{volumeContextExportPath, volumeContextSchemaVersion, volumeContextProfile, volumeContextAttrCache, volumeContextFSCache}
+
+// signVolumeContext adds the signature of a volume's context
+func signVolumeContext(key []byte, volumeID string, volumeContext map[string]string) {
+	volumeContext[volumeContextSignature] = volumeContextMAC(key, volumeID, volumeContext)
+}
+
+// volumeContextMAC returns the HMAC-SHA256 of the volume ID and the signed
+// fields. The volume ID binds the signature to one PV, so the attributes of
+// another volume cannot be pasted into it.
+func volumeContextMAC(key []byte, volumeID string, volumeContext map[string]string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(volumeID))
+	for _, k := range signedVolumeContextKeys {
+		mac.Write([]byte{0})
+		mac.Write([]byte(k + "=" + volumeContext[k]))
+	}
+	for _, k := range optionalSignedVolumeContextKeys {
+		if v, ok := volumeContext[k]; ok {
+			mac.Write([]byte{0})
+			mac.Write([]byte(k + "=" + v))
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyVolumeContext rejects a volume context whose signed fields were
+// changed after CreateVolume. Unsigned contexts (volumes created before
+// signing was enabled) are accepted unless signatures are required; then
+// only a context carrying none of the signed fields is accepted, as those
+// are taken from the ArcaVolume record.
+func (d *Driver) verifyVolumeContext(volumeID string, volumeContext map[string]string) error {
+	signature, ok := volumeContext[volumeContextSignature]
+	if !ok {
+		if !d.requireSignedVolumeContext {
+			return nil
+		}
+		for _, keys := range [][]string{signedVolumeContextKeys, optionalSignedVolumeContextKeys} {
+			for _, k := range keys {
+				if volumeContext[k] != "" {
+					return status.Errorf(codes.FailedPrecondition, "volume context for %s is not signed but sets %s", volumeID, k)
+				}
+			}
+		}
+		return nil
+	}
+	if len(d.volumeContextKey) == 0 {
+		klog.V(4).Infof("No volume context key configured, not verifying signature of %s", volumeID)
+		return nil
+	}
+
+	expected := volumeContextMAC(d.volumeContextKey, volumeID, volumeContext)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		klog.Warningf("Volume context for %s has an invalid signature (svm %s, vip %s, path %s)",
+			volumeID, volumeContext[volumeContextSVM], volumeContext[volumeContextVIP], volumeContext[volumeContextVolumePath])
+		return status.Errorf(codes.FailedPrecondition, "volume context for %s has an invalid signature", volumeID)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package driver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// ensureSnapshotDir creates the snapshot directory of an SVM before its
+// first snapshot: readable by root only and kept out of the SVM's quota
+// accounting. Directories that already exist are left as they are.
+func (d *Driver) ensureSnapshotDir(ctx context.Context, backend *arca.Backend, svmName string) error {
+	key := backend.Name + "/" + svmName
+	if _, ok := d.snapshotDirs.Load(key); ok {
+		return nil
+	}
+	err := backend.Client.CreateDirectory(ctx, &arca.CreateDirectoryRequest{
+		SVMName:          svmName,
+		Path:             d.snapshotDir,
+		Mode:             snapshotDirMode,
+		ExcludeFromQuota: true,
+	})
+	if err != nil {
+		return toStatus(err, "failed to create snapshot directory %s on SVM %s", d.snapshotDir, svmName)
+	}
+	d.snapshotDirs.Store(key, true)
+	return nil
+}
+
+// checkSnapshotReady asks the backend whether a snapshot's reflink copy has
+// completed and, once it has, persists ReadyToUse. Until then the snapshot is
+// reported with ready_to_use=false and the external-snapshotter calls
+// CreateSnapshot again. A snapshot that failed on the backend or is missing
+// from it is discarded so the retry starts over.
+func (d *Driver) checkSnapshotReady(ctx context.Context, backend *arca.Backend, info *store.SnapshotInfo) error {
+	state, err := backend.Client.GetSnapshot(ctx, info.SVMName, info.Path)
+	switch {
+	case errors.Is(err, arca.ErrNotSupported):
+		// Backends that cannot report snapshot state complete snapshots
+		// before CreateSnapshot returns
+		klog.V(4).Infof("Backend %q does not report snapshot state, assuming snapshot %s is complete", backend.Name, info.SnapshotID)
+	case arca.IsNotFoundError(err):
+		klog.Warningf("Snapshot %s is missing from backend %q: %v", info.SnapshotID, backend.Name, err)
+		if err := d.store.DeleteSnapshot(info.SnapshotID); err != nil && !store.IsNotFound(err) {
+			return toStatus(err, "failed to delete metadata of missing snapshot %s", info.SnapshotID)
+		}
+		return status.Errorf(codes.Aborted, "snapshot %s is missing from the backend; retry to create it again", info.SnapshotID)
+	case err != nil:
+		return toStatus(err, "failed to get state of snapshot %s", info.SnapshotID)
+	case state.State == arca.SnapshotStateAvailable:
+	case state.State == arca.SnapshotStateError:
+		klog.Errorf("Snapshot %s failed on backend %q: %s", info.SnapshotID, backend.Name, state.Message)
+		if err := backend.Client.DeleteSnapshot(ctx, info.SVMName, info.Path); err != nil {
+			klog.Warningf("Failed to delete failed snapshot %s: %v", info.SnapshotID, err)
+		}
+		if err := d.store.DeleteSnapshot(info.SnapshotID); err != nil && !store.IsNotFound(err) {
+			klog.Warningf("Failed to delete metadata of failed snapshot %s: %v", info.SnapshotID, err)
+		}
+		return status.Errorf(codes.Internal, "snapshot %s failed on the backend: %s", info.SnapshotID, state.Message)
+	default:
+		klog.V(4).Infof("Snapshot %s is not ready yet (state %q)", info.SnapshotID, state.State)
+		return nil
+	}
+
+	// Uses the /status endpoint, which persists correctly
+	if err := d.store.UpdateSnapshotStatus(info.SnapshotID, true); err != nil {
+		return toStatus(err, "failed to persist snapshot ready status")
+	}
+	info.ReadyToUse = true
+	return nil
+}
@@ -0,0 +1,90 @@
+package driver_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/arca/arcatest"
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/idempotency"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// newPendingSnapshot returns a controller plugin on an in-memory backend
+// and a store holding a snapshot of testVolumeID that is not ready yet,
+// which the backend does not have
+func newPendingSnapshot(t *testing.T, name string) (*driver.Driver, *arcatest.Server, store.Store, string) {
+	t.Helper()
+
+	server := arcatest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := arca.NewClient(&arca.ClientConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	snapshotID, err := idempotency.NewSnapshotIDGenerator().GenerateSnapshotID(testVolumeID + "/" + name)
+	if err != nil {
+		t.Fatalf("GenerateSnapshotID: %v", err)
+	}
+	st := store.NewMemoryStore()
+	err = st.CreateSnapshot(&store.SnapshotInfo{
+		SnapshotID:     snapshotID,
+		Name:           name,
+		SourceVolumeID: testVolumeID,
+		SVMName:        "k8s-team-a",
+		Path:           ".snapshots/" + snapshotID,
+		SizeBytes:      1 << 30,
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	d, err := driver.NewDriver(&driver.DriverConfig{Mode: "controller", ArcaClient: client, Store: st})
+	if err != nil {
+		t.Fatalf("NewDriver: %v", err)
+	}
+	return d, server, st, snapshotID
+}
+
+func TestCreateSnapshotWithoutStateEndpoint(t *testing.T) {
+	d, server, st, snapshotID := newPendingSnapshot(t, "snapshot-1")
+	server.SetSnapshotStateSupported(false)
+
+	// Backends that cannot report state completed the snapshot already
+	resp, err := d.CreateSnapshot(context.Background(), &csi.CreateSnapshotRequest{Name: "snapshot-1", SourceVolumeId: testVolumeID})
+	if err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if !resp.GetSnapshot().GetReadyToUse() {
+		t.Error("CreateSnapshot reported the snapshot not ready")
+	}
+	info, err := st.GetSnapshot(snapshotID)
+	if err != nil {
+		t.Fatalf("GetSnapshot: %v", err)
+	}
+	if !info.ReadyToUse {
+		t.Error("snapshot not persisted as ready")
+	}
+}
+
+func TestCreateSnapshotMissingFromBackend(t *testing.T) {
+	d, _, st, snapshotID := newPendingSnapshot(t, "snapshot-1")
+
+	// A snapshot the backend does not have is never ready; the retry
+	// creates it again
+	_, err := d.CreateSnapshot(context.Background(), &csi.CreateSnapshotRequest{Name: "snapshot-1", SourceVolumeId: testVolumeID})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("CreateSnapshot = %v, want Aborted", err)
+	}
+	if _, err := st.GetSnapshot(snapshotID); !store.IsNotFound(err) {
+		t.Errorf("GetSnapshot of the missing snapshot = %v, want not found", err)
+	}
+}
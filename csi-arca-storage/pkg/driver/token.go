@@ -0,0 +1,64 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+)
+
+// volumeContextServiceAccountTokens is the volume context key kubelet puts
+// the CSIDriver tokenRequests into
+const volumeContextServiceAccountTokens = "csi.storage.k8s.io/serviceAccount.tokens"
+
+// serviceAccountToken is one entry of the serviceAccount.tokens map
+type serviceAccountToken struct {
+	Token string `json:"token"`
+}
+
+// authorizeMount exchanges the pod's bound service account token for a
+// short-lived ARCA grant to mount the SVM from this node. ARCA only grants
+// it when the token's namespace owns the SVM, so a PV edited to point at
+// another namespace's SVM cannot be mounted. kubelet republishes volumes
+// periodically (requiresRepublish) to renew the grant.
+func (d *Driver) authorizeMount(ctx context.Context, volumeID, svmName string, volumeContext map[string]string) error {
+	raw := volumeContext[volumeContextServiceAccountTokens]
+	if raw == "" {
+		return status.Errorf(codes.FailedPrecondition, "no service account token for audience %q (is tokenRequests set in the CSIDriver?)", d.tokenAudience)
+	}
+
+	var tokens map[string]serviceAccountToken
+	if err := json.Unmarshal([]byte(raw), &tokens); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid service account tokens: %v", err)
+	}
+	token := tokens[d.tokenAudience].Token
+	if token == "" {
+		return status.Errorf(codes.FailedPrecondition, "no service account token for audience %q", d.tokenAudience)
+	}
+
+	// Token audiences are refused with tenants, so every SVM is on the
+	// default backend
+	backend, err := d.backends.Get(arca.DefaultBackend)
+	if err != nil {
+		return status.Errorf(codes.Internal, "%v", err)
+	}
+	credential, err := backend.Client.ExchangeMountToken(ctx, svmName, &arca.MountCredentialRequest{
+		Token:  token,
+		NodeID: d.nodeID,
+	})
+	if err != nil {
+		var apiErr *arca.APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == 401 || apiErr.StatusCode == 403) {
+			return status.Errorf(codes.PermissionDenied, "ARCA denied mounting SVM %s for volume %s: %v", svmName, volumeID, err)
+		}
+		return status.Errorf(codes.Unavailable, "failed to exchange service account token for SVM %s: %v", svmName, err)
+	}
+
+	klog.V(4).Infof("Authorized mount of SVM %s for namespace %s until %s", svmName, credential.Namespace, credential.ExpiresAt)
+	return nil
+}
@@ -0,0 +1,140 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+)
+
+// poolTopologyPrefix prefixes the topology key of each pool; a node
+// reporting "csi.arca-storage.io/pool-<name>": "true" can reach the SVMs
+// of that pool
+const poolTopologyPrefix = DriverName + "/pool-"
+
+// PoolTopologyKey returns the topology key of a pool
+func PoolTopologyKey(pool string) string {
+	return poolTopologyPrefix + pool
+}
+
+// reachablePools returns the names of the pools with an address on one of
+// the node's interfaces, i.e. whose VLAN is attached to the node
+func reachablePools(pools []arca.PoolConfig) ([]string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+
+	var addrs []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			klog.Warningf("Failed to list addresses of interface %s: %v", iface.Name, err)
+			continue
+		}
+		for _, addr := range ifaceAddrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				addrs = append(addrs, ipNet.IP)
+			}
+		}
+	}
+
+	var reachable []string
+	for _, pool := range pools {
+		_, network, err := net.ParseCIDR(pool.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q of pool %s: %w", pool.CIDR, pool.Name, err)
+		}
+		for _, ip := range addrs {
+			if network.Contains(ip) {
+				reachable = append(reachable, pool.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(reachable)
+	return reachable, nil
+}
+
+// nodeTopology returns the topology segments of the node: one per pool it
+// can reach; nil when it reaches none
+func nodeTopology(pools []string) *csi.Topology {
+	if len(pools) == 0 {
+		return nil
+	}
+	segments := make(map[string]string, len(pools))
+	for _, pool := range pools {
+		segments[PoolTopologyKey(pool)] = "true"
+	}
+	return &csi.Topology{Segments: segments}
+}
+
+// requestedPools returns the pools a new volume may be placed in: those of
+// the first preferred topology, which the provisioner fills with the
+// scheduled node's, else those of any requisite topology. Nil when the
+// request has no accessibility requirements.
+func requestedPools(req *csi.TopologyRequirement) map[string]bool {
+	if req == nil {
+		return nil
+	}
+	topologies := req.GetRequisite()
+	if preferred := req.GetPreferred(); len(preferred) > 0 {
+		topologies = preferred[:1]
+	}
+	if len(topologies) == 0 {
+		return nil
+	}
+
+	pools := make(map[string]bool)
+	for _, topology := range topologies {
+		for pool := range segmentPools(topology) {
+			pools[pool] = true
+		}
+	}
+	return pools
+}
+
+// segmentPools returns the pools of a topology's segments
+func segmentPools(topology *csi.Topology) map[string]bool {
+	pools := make(map[string]bool)
+	for key, value := range topology.GetSegments() {
+		if pool, ok := strings.CutPrefix(key, poolTopologyPrefix); ok && value == "true" {
+			pools[pool] = true
+		}
+	}
+	return pools
+}
+
+// svmTopology returns the accessible topology of volumes on an SVM: the
+// segment of the pool its VIP is in. An SVM outside the allowed pools
+// fails with ResourceExhausted, for the scheduler to pick another node.
+// Nil when topology is disabled or the pool is no longer configured.
+func (d *Driver) svmTopology(ctx context.Context, backend *arca.Backend, svmName string, allowed map[string]bool) ([]*csi.Topology, error) {
+	if !d.topology {
+		return nil, nil
+	}
+	svm, err := backend.SVMManager.GetSVM(ctx, svmName)
+	if err != nil {
+		return nil, toStatus(err, "failed to get SVM %s", svmName)
+	}
+	pool, ok := backend.Allocator.PoolForSVM(svm)
+	if !ok {
+		klog.Warningf("SVM %s (VLAN %d, VIP %s) is in no configured pool, reporting no topology", svm.Name, svm.VLANID, svm.VIP)
+		return nil, nil
+	}
+	if allowed != nil && !allowed[pool] {
+		return nil, status.Errorf(codes.ResourceExhausted, "SVM %s is in pool %s, which the requested topology cannot reach", svmName, pool)
+	}
+	return []*csi.Topology{{Segments: map[string]string{PoolTopologyKey(pool): "true"}}}, nil
+}
@@ -0,0 +1,98 @@
+package driver
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// volumeContextSchemaVersion is the volume context key of the format
+// version the controller wrote the context in
+const volumeContextSchemaVersion = "schemaVersion"
+
+// Volume context format versions. Fields added to the context are optional
+// and do not change the version, so node plugins of older versions ignore
+// them; the version is only raised when a field changes meaning.
+const (
+	// volumeContextVersionLegacy is assumed for contexts without
+	// schemaVersion, written before it was added
+	volumeContextVersionLegacy = 1
+	// volumeContextVersion is the version written by this controller and the
+	// newest this node plugin decodes
+	volumeContextVersion = 1
+)
+
+// stageContext holds the fields of a volume context NodeStageVolume uses
+type stageContext struct {
+	Version    int
+	SVM        string
+	VIP        string
+	VolumePath string
+	SVMHost    string
+	ExportPath string
+	Profile    string
+	AttrCache  string
+	FSCache    bool
+}
+
+// volumeContextProvisionerIdentity is added to volume context by the
+// external-provisioner
+const volumeContextProvisionerIdentity = "storage.kubernetes.io/csiProvisionerIdentity"
+
+// knownVolumeContextKeys are the volume context fields this node plugin
+// decodes or passes on; others are logged and ignored
+var knownVolumeContextKeys = map[string]bool{
+	volumeContextSchemaVersion:        true,
+	volumeContextSVM:                  true,
+	volumeContextVIP:                  true,
+	volumeContextVolumePath:           true,
+	volumeContextSVMHost:              true,
+	volumeContextExportPath:           true,
+	volumeContextProfile:              true,
+	volumeContextAttrCache:            true,
+	volumeContextFSCache:              true,
+	volumeContextSignature:            true,
+	volumeContextServiceAccountTokens: true,
+	volumeContextProvisionerIdentity:  true,
+}
+
+// decodeVolumeContext decodes a volume context written by any controller
+// version up to volumeContextVersion. Unknown fields, e.g. ones a newer
+// controller added, are ignored; a newer version is refused, as its fields
+// may no longer mean what this node plugin expects.
+func decodeVolumeContext(volumeID string, volumeContext map[string]string) (*stageContext, error) {
+	version := volumeContextVersionLegacy
+	if raw, ok := volumeContext[volumeContextSchemaVersion]; ok {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "volume context for %s has an invalid %s %q", volumeID, volumeContextSchemaVersion, raw)
+		}
+		if v > volumeContextVersion {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"volume context for %s has %s %d, this node plugin supports up to %d; upgrade the node plugin",
+				volumeID, volumeContextSchemaVersion, v, volumeContextVersion)
+		}
+		version = v
+	}
+
+	for k := range volumeContext {
+		if !knownVolumeContextKeys[k] && !strings.HasPrefix(k, paramPrefixCSI) {
+			klog.V(4).Infof("Ignoring unknown volume context field %q of %s", k, volumeID)
+		}
+	}
+
+	return &stageContext{
+		Version:    version,
+		SVM:        volumeContext[volumeContextSVM],
+		VIP:        volumeContext[volumeContextVIP],
+		VolumePath: volumeContext[volumeContextVolumePath],
+		SVMHost:    volumeContext[volumeContextSVMHost],
+		ExportPath: volumeContext[volumeContextExportPath],
+		Profile:    volumeContext[volumeContextProfile],
+		AttrCache:  volumeContext[volumeContextAttrCache],
+		FSCache:    volumeContext[volumeContextFSCache] == "true",
+	}, nil
+}
@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"github.com/container-storage-interface/spec/lib/go/csi"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+)
+
+// recordVolumeEvent records a completed node call in the volume access
+// audit trail
+func (d *Driver) recordVolumeEvent(req interface{}, err error) {
+	var operation string
+	var spec v1alpha1.ArcaVolumeEventSpec
+	switch r := req.(type) {
+	case *csi.NodeStageVolumeRequest:
+		operation = v1alpha1.ArcaVolumeEventStage
+		spec.VolumeID = r.GetVolumeId()
+		spec.TargetPath = r.GetStagingTargetPath()
+	case *csi.NodeUnstageVolumeRequest:
+		operation = v1alpha1.ArcaVolumeEventUnstage
+		spec.VolumeID = r.GetVolumeId()
+		spec.TargetPath = r.GetStagingTargetPath()
+	case *csi.NodePublishVolumeRequest:
+		operation = v1alpha1.ArcaVolumeEventPublish
+		spec.VolumeID = r.GetVolumeId()
+		spec.TargetPath = r.GetTargetPath()
+		spec.ReadOnly = r.GetReadonly()
+		if pod := podInfo(r.GetVolumeContext()); pod != nil {
+			spec.PodNamespace = pod.Namespace
+			spec.PodName = pod.Name
+			spec.PodUID = pod.UID
+		}
+	case *csi.NodeUnpublishVolumeRequest:
+		operation = v1alpha1.ArcaVolumeEventUnpublish
+		spec.VolumeID = r.GetVolumeId()
+		spec.TargetPath = r.GetTargetPath()
+	default:
+		return
+	}
+	if spec.VolumeID == "" {
+		// Rejected before touching any volume
+		return
+	}
+	d.volumeEvents.Record(operation, spec, err)
+}
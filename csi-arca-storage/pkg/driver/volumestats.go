@@ -0,0 +1,146 @@
+package driver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+)
+
+// DefaultVolumeStatsQuotaTTL is how long a node reuses the quota of a volume
+// read from the ARCA API for NodeGetVolumeStats
+const DefaultVolumeStatsQuotaTTL = time.Minute
+
+// quotaStatsTolerance is how far, as a fraction, the size statfs reports may
+// be off the quota before the quota is reported instead; NFS rounds sizes
+// to its block size
+const quotaStatsTolerance = 0.01
+
+// quotaStatsMinTolerance is the smallest tolerated difference in bytes
+const quotaStatsMinTolerance = 1 << 20
+
+// volumeQuotas caches the quotas of the volumes staged on a node. kubelet
+// asks for the stats of every volume each minute; the quota only backs the
+// statfs figures up, so it need not be fresher.
+type volumeQuotas struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*volumeQuota
+}
+
+// volumeQuota is a cached quota
+type volumeQuota struct {
+	quota   *arca.QuotaInfo
+	expires time.Time
+	// warned is set once a statfs mismatch of the volume was logged
+	warned bool
+}
+
+// newVolumeQuotas creates a cache whose entries live for ttl (0 = default)
+func newVolumeQuotas(ttl time.Duration) *volumeQuotas {
+	if ttl <= 0 {
+		ttl = DefaultVolumeStatsQuotaTTL
+	}
+	return &volumeQuotas{ttl: ttl, entries: make(map[string]*volumeQuota)}
+}
+
+// get returns the quota of a volume, calling fetch when it is missing or
+// expired. Errors are not cached.
+func (q *volumeQuotas) get(volumeID string, fetch func() (*arca.QuotaInfo, error)) (*volumeQuota, error) {
+	q.mu.Lock()
+	entry, ok := q.entries[volumeID]
+	q.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry, nil
+	}
+
+	quota, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if entry, ok = q.entries[volumeID]; ok {
+		entry.quota = quota
+		entry.expires = time.Now().Add(q.ttl)
+		return entry, nil
+	}
+	entry = &volumeQuota{quota: quota, expires: time.Now().Add(q.ttl)}
+	q.entries[volumeID] = entry
+	return entry, nil
+}
+
+// forget drops the quota of an unstaged volume
+func (q *volumeQuotas) forget(volumeID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.entries, volumeID)
+}
+
+// crossCheckQuota compares the statfs figures of a volume with its quota on
+// the ARCA API. An NFS server that does not report the project quota as the
+// filesystem size hands out the size of the whole SVM filesystem instead;
+// the quota's limits and usage are reported then. Failures to read the quota
+// keep the statfs figures.
+func (d *Driver) crossCheckQuota(ctx context.Context, volumeID string, stats *mount.FsStats) *mount.FsStats {
+	svmName, err := d.nodeState.GetSVMForVolume(volumeID)
+	if err != nil {
+		klog.V(4).Infof("Cannot cross-check stats of volume %s with its quota: %v", volumeID, err)
+		return stats
+	}
+	path, err := volumeBackendPath(volumeID)
+	if err != nil {
+		return stats
+	}
+	backend, err := d.backends.Get("")
+	if err != nil {
+		return stats
+	}
+
+	entry, err := d.volumeQuotas.get(volumeID, func() (*arca.QuotaInfo, error) {
+		return backend.Client.GetQuota(ctx, svmName, path)
+	})
+	if err != nil {
+		klog.V(4).Infof("Cannot cross-check stats of volume %s with its quota: %v", volumeID, err)
+		return stats
+	}
+	quota := entry.quota
+	if quota.QuotaBytes <= 0 || quotaMatches(stats.TotalBytes, quota.QuotaBytes) {
+		return stats
+	}
+
+	d.volumeQuotas.mu.Lock()
+	warn := !entry.warned
+	entry.warned = true
+	d.volumeQuotas.mu.Unlock()
+	if warn {
+		klog.Warningf("Volume %s reports %d bytes through NFS but has a quota of %d bytes on SVM %s; reporting the quota",
+			volumeID, stats.TotalBytes, quota.QuotaBytes, svmName)
+	}
+
+	checked := *stats
+	checked.TotalBytes = quota.QuotaBytes
+	checked.UsedBytes = quota.UsedBytes
+	checked.AvailableBytes = max(quota.QuotaBytes-quota.UsedBytes, 0)
+	if quota.InodeLimit > 0 {
+		checked.TotalInodes = quota.InodeLimit
+		checked.UsedInodes = quota.UsedInodes
+		checked.FreeInodes = max(quota.InodeLimit-quota.UsedInodes, 0)
+	}
+	return &checked
+}
+
+// quotaMatches reports whether a size reported by statfs is the quota
+func quotaMatches(size, quota int64) bool {
+	diff := size - quota
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= max(int64(float64(quota)*quotaStatsTolerance), quotaStatsMinTolerance)
+}
@@ -0,0 +1,149 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+const (
+	// wipeJobPrefix names the wipe Job of a volume, followed by the volume
+	// ID
+	wipeJobPrefix = "arca-wipe-"
+	// wipeJobMountPath is where wipe Jobs mount the volume's directory
+	wipeJobMountPath = "/volume"
+	// wipeJobScript overwrites every file of the volume with random data
+	// and then zeros; DeleteVolume removes the files afterwards
+	wipeJobScript = "set -e; find " + wipeJobMountPath + " -xdev -type f -exec shred -n 1 -z {} +"
+	// wipeJobBackoffLimit is how often a failed wipe pod is retried before
+	// the Job fails
+	wipeJobBackoffLimit int32 = 2
+)
+
+// wipeVolume overwrites the data of a volume before its directory is
+// deleted. ARCA wipes the directory itself when the backend supports it;
+// otherwise a Job mounting the directory over NFS shreds the files. The Job
+// outlives the DeleteVolume call that created it: calls return Aborted
+// until it completes, so the external-provisioner retries the deletion.
+func (d *Driver) wipeVolume(ctx context.Context, backend *arca.Backend, volumeInfo *store.VolumeInfo) error {
+	klog.V(4).Infof("Wiping directory %s on SVM %s of volume %s", volumeInfo.Path, volumeInfo.SVMName, volumeInfo.VolumeID)
+	err := backend.Client.WipeDirectory(ctx, volumeInfo.SVMName, volumeInfo.Path)
+	if err == nil || arca.IsNotFoundError(err) {
+		return nil
+	}
+	if !errors.Is(err, arca.ErrNotSupported) {
+		return toStatus(err, "failed to wipe volume %s", volumeInfo.VolumeID)
+	}
+
+	if d.wipeJobImage == "" || d.k8sClient == nil {
+		return status.Errorf(codes.FailedPrecondition,
+			"volume %s requires %s but the ARCA backend cannot wipe directories and no wipe job image is configured",
+			volumeInfo.VolumeID, paramSecureDelete)
+	}
+	return d.runWipeJob(ctx, volumeInfo)
+}
+
+// runWipeJob creates the wipe Job of a volume or checks on an existing one,
+// returning nil once it succeeded
+func (d *Driver) runWipeJob(ctx context.Context, volumeInfo *store.VolumeInfo) error {
+	jobs := d.k8sClient.BatchV1().Jobs(d.wipeJobNamespace)
+	name := wipeJobPrefix + volumeInfo.VolumeID
+
+	job, err := jobs.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = jobs.Create(ctx, d.wipeJob(name, volumeInfo), metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return toStatus(err, "failed to create wipe job %s/%s", d.wipeJobNamespace, name)
+		}
+		klog.Infof("Created job %s/%s to wipe volume %s", d.wipeJobNamespace, name, volumeInfo.VolumeID)
+		return status.Errorf(codes.Aborted, "volume %s is being wiped by job %s/%s", volumeInfo.VolumeID, d.wipeJobNamespace, name)
+	}
+	if err != nil {
+		return toStatus(err, "failed to get wipe job %s/%s", d.wipeJobNamespace, name)
+	}
+
+	switch {
+	case jobCondition(job, batchv1.JobComplete):
+		klog.Infof("Job %s/%s wiped volume %s", d.wipeJobNamespace, name, volumeInfo.VolumeID)
+		background := metav1.DeletePropagationBackground
+		err := jobs.Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &background})
+		if err != nil && !apierrors.IsNotFound(err) {
+			// The volume is wiped; a leftover Job only costs a re-wipe if
+			// the deletion is retried
+			klog.Warningf("Failed to delete wipe job %s/%s: %v", d.wipeJobNamespace, name, err)
+		}
+		return nil
+	case jobCondition(job, batchv1.JobFailed):
+		// The failed Job is kept for its pod logs; deleting it retries
+		return status.Errorf(codes.Internal, "wipe job %s/%s of volume %s failed; delete the job to retry",
+			d.wipeJobNamespace, name, volumeInfo.VolumeID)
+	default:
+		return status.Errorf(codes.Aborted, "volume %s is being wiped by job %s/%s", volumeInfo.VolumeID, d.wipeJobNamespace, name)
+	}
+}
+
+// wipeJob returns the Job shredding the files of a volume
+func (d *Driver) wipeJob(name string, volumeInfo *store.VolumeInfo) *batchv1.Job {
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": d.name,
+		"storage.arca.io/volume-id":    volumeInfo.VolumeID,
+	}
+	exportPath := arca.ExportPath(d.exportPathTemplate, volumeInfo.SVMName, volumeInfo.ExportPath)
+	backoffLimit := wipeJobBackoffLimit
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: d.wipeJobNamespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:    "wipe",
+						Image:   d.wipeJobImage,
+						Command: []string{"sh", "-c", wipeJobScript},
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "volume",
+							MountPath: wipeJobMountPath,
+						}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "volume",
+						VolumeSource: corev1.VolumeSource{
+							NFS: &corev1.NFSVolumeSource{
+								Server: volumeInfo.VIP,
+								Path:   path.Join(exportPath, volumeInfo.Path),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// jobCondition reports whether a Job has the condition set to true
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == conditionType && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+// Package driverstatus publishes an ArcaDriverStatus summarizing the health
+// of the driver for operators and cluster monitoring.
+package driverstatus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/versionskew"
+)
+
+// DefaultInterval is how often the status is reported
+const DefaultInterval = time.Minute
+
+// reportTimeout bounds a single report
+const reportTimeout = 2 * time.Minute
+
+// listPageSize is the page size used when counting volumes and snapshots
+const listPageSize = 500
+
+// ConditionBackendsReachable is true while every backend has a healthy API
+// endpoint
+const ConditionBackendsReachable = "BackendsReachable"
+
+// defaultBackendName names the default backend in the status
+const defaultBackendName = "default"
+
+// Reporter periodically writes the ArcaDriverStatus named
+// v1alpha1.ArcaDriverStatusName, creating it when missing
+type Reporter struct {
+	client   client.Client
+	backends *arca.BackendRouter
+	version  string
+	identity string
+	pending  func() int
+
+	// clientset and leaseNamespace locate the node plugin Leases; a nil
+	// clientset leaves node versions out
+	clientset      kubernetes.Interface
+	leaseNamespace string
+}
+
+// NewReporter creates a reporter for the controller identified by identity,
+// running driver version; pending returns the number of operations in
+// progress
+func NewReporter(c client.Client, backends *arca.BackendRouter, version, identity string, pending func() int) *Reporter {
+	return &Reporter{
+		client:   c,
+		backends: backends,
+		version:  version,
+		identity: identity,
+		pending:  pending,
+	}
+}
+
+// SetNodeVersions makes the reporter count node plugin versions from the
+// version skew Leases in namespace
+func (r *Reporter) SetNodeVersions(clientset kubernetes.Interface, namespace string) {
+	r.clientset = clientset
+	r.leaseNamespace = namespace
+}
+
+// Run reports the status every interval until ctx is cancelled
+func (r *Reporter) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.report(ctx); err != nil {
+			klog.Errorf("Failed to report driver status: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// report writes one status
+func (r *Reporter) report(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+
+	var record v1alpha1.ArcaDriverStatus
+	err := r.client.Get(ctx, client.ObjectKey{Name: v1alpha1.ArcaDriverStatusName}, &record)
+	if apierrors.IsNotFound(err) {
+		record = v1alpha1.ArcaDriverStatus{ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.ArcaDriverStatusName}}
+		err = r.client.Create(ctx, &record)
+		if apierrors.IsAlreadyExists(err) {
+			err = r.client.Get(ctx, client.ObjectKey{Name: v1alpha1.ArcaDriverStatusName}, &record)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get ArcaDriverStatus %s: %w", v1alpha1.ArcaDriverStatusName, err)
+	}
+
+	status, err := r.collect(ctx, record.Status.Conditions)
+	if err != nil {
+		return err
+	}
+	record.Status = *status
+	if err := r.client.Status().Update(ctx, &record); err != nil {
+		return fmt.Errorf("failed to update ArcaDriverStatus %s: %w", v1alpha1.ArcaDriverStatusName, err)
+	}
+	klog.V(4).Infof("Reported driver status: %d volumes, %d snapshots, %d pending operations",
+		status.Volumes, status.Snapshots, status.PendingOperations)
+	return nil
+}
+
+// collect gathers the status; conditions are the previous conditions, kept
+// so transition times only change on transitions
+func (r *Reporter) collect(ctx context.Context, conditions []metav1.Condition) (*v1alpha1.ArcaDriverStatusStatus, error) {
+	status := &v1alpha1.ArcaDriverStatusStatus{
+		ControllerVersion: r.version,
+		ReportedBy:        r.identity,
+		LastUpdated:       metav1.Now(),
+		PendingOperations: r.pending(),
+		Conditions:        conditions,
+	}
+
+	var err error
+	if status.Volumes, err = r.count(ctx, "ArcaVolumeList"); err != nil {
+		return nil, err
+	}
+	if status.Snapshots, err = r.count(ctx, "ArcaSnapshotList"); err != nil {
+		return nil, err
+	}
+
+	var unreachable []string
+	for _, backend := range r.backends.Backends() {
+		name := backend.Name
+		if name == arca.DefaultBackend {
+			name = defaultBackendName
+		}
+
+		backendStatus := v1alpha1.ArcaDriverBackendStatus{Name: name}
+		for _, endpoint := range backend.Client.EndpointStatuses() {
+			backendStatus.Endpoints++
+			if endpoint.Healthy {
+				backendStatus.HealthyEndpoints++
+			} else if endpoint.LastError != "" {
+				backendStatus.LastError = endpoint.LastError
+			}
+		}
+		if backendStatus.HealthyEndpoints == 0 {
+			unreachable = append(unreachable, name)
+		}
+		status.Backends = append(status.Backends, backendStatus)
+
+		pools, err := r.pools(ctx, name, backend.Allocator)
+		if err != nil {
+			klog.Warningf("Leaving IP pools of backend %s out of the driver status: %v", name, err)
+			continue
+		}
+		status.Pools = append(status.Pools, pools...)
+	}
+
+	if r.clientset != nil {
+		versions, err := versionskew.NodeVersions(ctx, r.clientset, r.leaseNamespace)
+		if err != nil {
+			klog.Warningf("Leaving node plugin versions out of the driver status: %v", err)
+		} else {
+			status.NodeVersions = versions
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    ConditionBackendsReachable,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Healthy",
+		Message: "Every backend has a healthy API endpoint",
+	}
+	if len(unreachable) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NoHealthyEndpoint"
+		condition.Message = "No healthy API endpoint for backends: " + strings.Join(unreachable, ", ")
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+	return status, nil
+}
+
+// pools returns the utilization of the IP pools of a backend
+func (r *Reporter) pools(ctx context.Context, backend string, allocator *arca.StandaloneAllocator) ([]v1alpha1.ArcaDriverPoolStatus, error) {
+	allocations, err := allocator.PoolAllocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []v1alpha1.ArcaDriverPoolStatus
+	for _, pool := range allocator.Pools() {
+		result = append(result, v1alpha1.ArcaDriverPoolStatus{
+			Backend:   backend,
+			Name:      pool.Name,
+			VLANID:    pool.VLANID,
+			Addresses: max(pool.NumHosts-len(pool.Excluded), 0),
+			Allocated: len(allocations[pool.Name]),
+			Drained:   pool.Drained,
+		})
+	}
+	return result, nil
+}
+
+// count returns the number of objects of a list kind, reading metadata only
+func (r *Reporter) count(ctx context.Context, listKind string) (int, error) {
+	total := 0
+	token := ""
+	for {
+		var list metav1.PartialObjectMetadataList
+		list.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind(listKind))
+		if err := r.client.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return 0, fmt.Errorf("failed to count %s items: %w", listKind, err)
+		}
+		total += len(list.Items)
+		token = list.Continue
+		if token == "" {
+			return total, nil
+		}
+	}
+}
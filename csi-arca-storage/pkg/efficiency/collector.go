@@ -0,0 +1,150 @@
+// Package efficiency collects per-volume deduplication and compression
+// statistics from ARCA into ArcaVolume status and Prometheus metrics.
+package efficiency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// DefaultInterval is how often statistics are collected
+const DefaultInterval = 5 * time.Minute
+
+// collectTimeout bounds a single collection pass
+const collectTimeout = 10 * time.Minute
+
+// listPageSize is the page size used when listing ArcaVolumes
+const listPageSize = 500
+
+// Collector periodically reads the efficiency statistics of every volume.
+// ArcaVolume status is only written when the statistics change.
+type Collector struct {
+	client   client.Client
+	backends *arca.BackendRouter
+
+	// seen maps volume IDs reported in the last pass to their SVM, so that
+	// metrics of deleted volumes can be dropped
+	seen map[string]string
+}
+
+// NewCollector creates an efficiency collector
+func NewCollector(c client.Client, backends *arca.BackendRouter) *Collector {
+	return &Collector{
+		client:   c,
+		backends: backends,
+		seen:     make(map[string]string),
+	}
+}
+
+// Run collects statistics every interval until ctx is cancelled
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.collect(ctx); err != nil {
+			klog.Errorf("Failed to collect volume efficiency statistics: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect runs one pass over all ArcaVolumes
+func (c *Collector) collect(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, collectTimeout)
+	defer cancel()
+
+	seen := make(map[string]string)
+	token := ""
+	for {
+		var list v1alpha1.ArcaVolumeList
+		if err := c.client.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return fmt.Errorf("failed to list ArcaVolumes: %w", err)
+		}
+		for i := range list.Items {
+			av := &list.Items[i]
+			if c.collectVolume(ctx, av) {
+				seen[av.Spec.VolumeID] = av.Spec.SVMName
+			}
+		}
+		token = list.Continue
+		if token == "" {
+			break
+		}
+	}
+
+	for volumeID, svmName := range c.seen {
+		if seen[volumeID] != svmName {
+			metrics.VolumeLogicalBytes.DeleteLabelValues(volumeID, svmName)
+			metrics.VolumePhysicalBytes.DeleteLabelValues(volumeID, svmName)
+			metrics.VolumeDedupeSavedBytes.DeleteLabelValues(volumeID, svmName)
+			metrics.VolumeCompressionSavedBytes.DeleteLabelValues(volumeID, svmName)
+		}
+	}
+	c.seen = seen
+	return nil
+}
+
+// collectVolume records the statistics of one volume and reports whether
+// they were available
+func (c *Collector) collectVolume(ctx context.Context, av *v1alpha1.ArcaVolume) bool {
+	backend, err := c.backends.Get(av.Spec.Backend)
+	if err != nil {
+		klog.V(4).Infof("Skipping efficiency statistics of volume %s: %v", av.Name, err)
+		return false
+	}
+
+	info, err := backend.Client.GetEfficiency(ctx, av.Spec.SVMName, av.Spec.Path)
+	if err != nil {
+		if !errors.Is(err, arca.ErrDirectoryNotFound) {
+			klog.Warningf("Failed to get efficiency statistics of volume %s: %v", av.Name, err)
+		}
+		return false
+	}
+
+	labels := []string{av.Spec.VolumeID, av.Spec.SVMName}
+	metrics.VolumeLogicalBytes.WithLabelValues(labels...).Set(float64(info.LogicalBytes))
+	metrics.VolumePhysicalBytes.WithLabelValues(labels...).Set(float64(info.PhysicalBytes))
+	metrics.VolumeDedupeSavedBytes.WithLabelValues(labels...).Set(float64(info.DedupeSavedBytes))
+	metrics.VolumeCompressionSavedBytes.WithLabelValues(labels...).Set(float64(info.CompressionSavedBytes))
+
+	current := av.Status.Efficiency
+	if current != nil &&
+		current.LogicalBytes == info.LogicalBytes &&
+		current.PhysicalBytes == info.PhysicalBytes &&
+		current.DedupeSavedBytes == info.DedupeSavedBytes &&
+		current.CompressionSavedBytes == info.CompressionSavedBytes {
+		return true
+	}
+
+	av.Status.Efficiency = &v1alpha1.ArcaVolumeEfficiency{
+		LogicalBytes:          info.LogicalBytes,
+		PhysicalBytes:         info.PhysicalBytes,
+		DedupeSavedBytes:      info.DedupeSavedBytes,
+		CompressionSavedBytes: info.CompressionSavedBytes,
+		LastUpdateTime:        metav1.Now(),
+	}
+	if err := c.client.Status().Update(ctx, av); err != nil {
+		klog.Warningf("Failed to update efficiency status of volume %s: %v", av.Name, err)
+	}
+	return true
+}
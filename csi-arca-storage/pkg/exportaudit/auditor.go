@@ -0,0 +1,398 @@
+// Package exportaudit records the NFS export rules of each driver-created
+// SVM in ArcaSVM resources and flags rules that drifted from the expected
+// cluster clients (e.g. after manual changes on the backend). It can also
+// check the SVMs' MTU and gateway against the configuration, and applies
+// the MTU, gateway and capacity requested in an ArcaSVM's spec.
+package exportaudit
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// DefaultInterval is how often export rules are audited
+const DefaultInterval = 5 * time.Minute
+
+// auditTimeout bounds a single audit pass
+const auditTimeout = 5 * time.Minute
+
+// Attribute reconcile modes
+const (
+	// AttributesReport flags SVMs whose MTU or gateway differ from the
+	// configuration in their ArcaSVM
+	AttributesReport = "report"
+	// AttributesUpdate also updates those SVMs to the configured values
+	AttributesUpdate = "update"
+)
+
+// Auditor reconciles ArcaSVM resources from the SVMs and export rules on
+// each backend
+type Auditor struct {
+	client     client.Client
+	backends   *arca.BackendRouter
+	clients    []netip.Prefix
+	attributes string
+}
+
+// NewAuditor creates an export auditor. expectedClients are the addresses or
+// CIDRs of the cluster nodes that every SVM must export to read-write.
+func NewAuditor(c client.Client, backends *arca.BackendRouter, expectedClients []string) (*Auditor, error) {
+	clients := make([]netip.Prefix, 0, len(expectedClients))
+	for _, addr := range expectedClients {
+		prefix, err := parseClient(addr)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, prefix)
+	}
+
+	return &Auditor{
+		client:   c,
+		backends: backends,
+		clients:  clients,
+	}, nil
+}
+
+// SetAttributeReconcile enables the MTU and gateway check (AttributesReport
+// or AttributesUpdate); empty disables it
+func (a *Auditor) SetAttributeReconcile(mode string) {
+	a.attributes = mode
+}
+
+// Run audits export rules every interval until ctx is cancelled
+func (a *Auditor) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.audit(ctx); err != nil {
+			klog.Errorf("Failed to audit SVM export rules: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// audit runs one pass over the SVMs of all backends. An SVM is audited on
+// the backend its namespace is routed to; ArcaSVMs of SVMs that no longer
+// exist are deleted once every backend has been listed.
+func (a *Auditor) audit(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, auditTimeout)
+	defer cancel()
+
+	var existing v1alpha1.ArcaSVMList
+	if err := a.client.List(ctx, &existing); err != nil {
+		return fmt.Errorf("failed to list ArcaSVMs: %w", err)
+	}
+	records := make(map[string]*v1alpha1.ArcaSVM, len(existing.Items))
+	for i := range existing.Items {
+		records[existing.Items[i].Name] = &existing.Items[i]
+	}
+
+	seen := make(map[string]bool)
+	complete := true
+	for _, backend := range a.backends.Backends() {
+		svms, err := backend.Client.ListSVMs(ctx)
+		if err != nil {
+			klog.Errorf("Failed to list SVMs on backend %q: %v", backend.Name, err)
+			complete = false
+			continue
+		}
+		for i := range svms {
+			svm := &svms[i]
+			namespace, ok := strings.CutPrefix(svm.Name, arca.SVMNamePrefix)
+			if !ok || a.backends.ForNamespace(namespace) != backend {
+				continue
+			}
+			seen[svm.Name] = true
+			if err := a.auditSVM(ctx, backend, svm, namespace, records[svm.Name]); err != nil {
+				klog.Errorf("Failed to audit SVM %s: %v", svm.Name, err)
+			}
+		}
+	}
+
+	if !complete {
+		return nil
+	}
+	for name, record := range records {
+		if seen[name] {
+			continue
+		}
+		if err := a.client.Delete(ctx, record); err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to delete ArcaSVM %s: %v", name, err)
+			continue
+		}
+		metrics.SVMExportDrift.DeleteLabelValues(name, record.Spec.Backend)
+		metrics.SVMAttributeDrift.DeleteLabelValues(name, record.Spec.Backend)
+		klog.Infof("Deleted ArcaSVM %s: SVM no longer exists", name)
+	}
+	return nil
+}
+
+// auditSVM reads an SVM's export rules and records them in its ArcaSVM
+func (a *Auditor) auditSVM(ctx context.Context, backend *arca.Backend, svm *arca.SVM, namespace string, record *v1alpha1.ArcaSVM) error {
+	exports, err := backend.Client.ListExports(ctx, svm.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list exports: %w", err)
+	}
+
+	if record == nil {
+		record = &v1alpha1.ArcaSVM{
+			ObjectMeta: metav1.ObjectMeta{Name: svm.Name},
+			Spec: v1alpha1.ArcaSVMSpec{
+				Namespace: namespace,
+				Backend:   backend.Name,
+			},
+		}
+		if err := a.client.Create(ctx, record); err != nil {
+			return fmt.Errorf("failed to create ArcaSVM: %w", err)
+		}
+	} else if record.Spec.Backend != backend.Name {
+		// The namespace was migrated to another backend
+		metrics.SVMExportDrift.DeleteLabelValues(svm.Name, record.Spec.Backend)
+		metrics.SVMAttributeDrift.DeleteLabelValues(svm.Name, record.Spec.Backend)
+		record.Spec.Backend = backend.Name
+		if err := a.client.Update(ctx, record); err != nil {
+			return fmt.Errorf("failed to update ArcaSVM: %w", err)
+		}
+	}
+
+	rules := make([]v1alpha1.ArcaExportRule, 0, len(exports))
+	for _, e := range exports {
+		rules = append(rules, v1alpha1.ArcaExportRule{
+			Volume:     e.Volume,
+			Client:     e.Client,
+			Access:     e.Access,
+			RootSquash: e.RootSquash,
+			Sec:        e.Sec,
+		})
+	}
+	slices.SortFunc(rules, func(x, y v1alpha1.ArcaExportRule) int {
+		if c := strings.Compare(x.Volume, y.Volume); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Client, y.Client)
+	})
+	drift := a.drift(rules)
+	metrics.SVMExportDrift.WithLabelValues(svm.Name, backend.Name).Set(float64(len(drift)))
+
+	status := record.Status.DeepCopy()
+	if a.attributes != "" || record.Spec.MTU != 0 || record.Spec.Gateway != "" || record.Spec.CapacityBytes != 0 {
+		svm = a.reconcileAttributes(ctx, backend, svm, record.Spec, status)
+	} else {
+		status.AttributeDrift = nil
+		status.CapacityBytes = 0
+		meta.RemoveStatusCondition(&status.Conditions, v1alpha1.ArcaSVMConditionAttributesInSync)
+	}
+	status.ObservedGeneration = record.Generation
+	status.VIP = svm.VIP
+	status.MTU = svm.MTU
+	status.Gateway = svm.Gateway
+	status.ExportRules = rules
+	status.Drift = drift
+	condition := metav1.Condition{
+		Type:    v1alpha1.ArcaSVMConditionExportsInSync,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ExportsMatch",
+		Message: "Export rules match the expected cluster clients",
+	}
+	if len(drift) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ExportsDrifted"
+		condition.Message = strings.Join(drift, "; ")
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	if equality.Semantic.DeepEqual(status, &record.Status) {
+		return nil
+	}
+	if len(drift) > 0 && !slices.Equal(drift, record.Status.Drift) {
+		klog.Warningf("Export rules of SVM %s on backend %q drifted: %s", svm.Name, backend.Name, condition.Message)
+	}
+	if len(status.AttributeDrift) > 0 && !slices.Equal(status.AttributeDrift, record.Status.AttributeDrift) {
+		klog.Warningf("Attributes of SVM %s on backend %q drifted: %s", svm.Name, backend.Name, strings.Join(status.AttributeDrift, "; "))
+	}
+	record.Status = *status
+	if err := a.client.Status().Update(ctx, record); err != nil {
+		return fmt.Errorf("failed to update ArcaSVM status: %w", err)
+	}
+	return nil
+}
+
+// reconcileAttributes compares an SVM's MTU, gateway and capacity with
+// those requested in the ArcaSVM spec and, unless the check is disabled, the
+// configured ones, and records the drift and the AttributesInSync condition
+// in status. Values requested in the spec are always applied; the configured
+// ones only in AttributesUpdate mode. It returns the SVM as now on the
+// backend.
+func (a *Auditor) reconcileAttributes(ctx context.Context, backend *arca.Backend, svm *arca.SVM, spec v1alpha1.ArcaSVMSpec, status *v1alpha1.ArcaSVMStatus) *arca.SVM {
+	var want arca.UpdateSVMRequest
+	if a.attributes != "" {
+		want = backend.SVMManager.ConfiguredAttributes(svm)
+	}
+	if spec.MTU != 0 {
+		want.MTU = spec.MTU
+	}
+	if spec.Gateway != "" {
+		want.Gateway = spec.Gateway
+	}
+	want.CapacityBytes = spec.CapacityBytes
+
+	status.CapacityBytes = 0
+	if want.CapacityBytes != 0 {
+		capacity, err := backend.Client.GetSVMCapacity(ctx, svm.Name)
+		if err != nil {
+			klog.Errorf("Failed to get capacity of SVM %s on backend %q: %v", svm.Name, backend.Name, err)
+			want.CapacityBytes = 0
+		} else {
+			status.CapacityBytes = capacity.TotalBytes
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    v1alpha1.ArcaSVMConditionAttributesInSync,
+		Status:  metav1.ConditionTrue,
+		Reason:  "AttributesMatch",
+		Message: "MTU, gateway and capacity match the requested values",
+	}
+	update, drift := attributeDrift(svm, status.CapacityBytes, want)
+	if update != nil && a.attributes != AttributesUpdate {
+		if spec.MTU == 0 {
+			update.MTU = 0
+		}
+		if spec.Gateway == "" {
+			update.Gateway = ""
+		}
+		if *update == (arca.UpdateSVMRequest{}) {
+			update = nil
+		}
+	}
+	if update != nil {
+		updated, err := backend.SVMManager.UpdateSVM(ctx, svm.Name, update)
+		if err != nil {
+			klog.Errorf("Failed to update attributes of SVM %s on backend %q (%s): %v", svm.Name, backend.Name, strings.Join(drift, "; "), err)
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "UpdateFailed"
+			condition.Message = fmt.Sprintf("%s: %v", strings.Join(drift, "; "), err)
+			metrics.SVMAttributeDrift.WithLabelValues(svm.Name, backend.Name).Set(float64(len(drift)))
+			status.AttributeDrift = drift
+			meta.SetStatusCondition(&status.Conditions, condition)
+			return svm
+		}
+		svm = updated
+		if update.CapacityBytes != 0 {
+			status.CapacityBytes = update.CapacityBytes
+		}
+		_, drift = attributeDrift(svm, status.CapacityBytes, want)
+	}
+
+	metrics.SVMAttributeDrift.WithLabelValues(svm.Name, backend.Name).Set(float64(len(drift)))
+	if len(drift) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "AttributesDrifted"
+		condition.Message = strings.Join(drift, "; ")
+	}
+	status.AttributeDrift = drift
+	meta.SetStatusCondition(&status.Conditions, condition)
+	return svm
+}
+
+// attributeDrift compares an SVM's attributes and capacity with the wanted
+// ones (zero fields are not checked) and returns the update restoring them,
+// or nil when they match
+func attributeDrift(svm *arca.SVM, capacity int64, want arca.UpdateSVMRequest) (*arca.UpdateSVMRequest, []string) {
+	var update arca.UpdateSVMRequest
+	var drift []string
+	if want.MTU != 0 && svm.MTU != want.MTU {
+		update.MTU = want.MTU
+		drift = append(drift, fmt.Sprintf("MTU is %d instead of %d", svm.MTU, want.MTU))
+	}
+	if want.Gateway != "" && svm.Gateway != want.Gateway {
+		update.Gateway = want.Gateway
+		drift = append(drift, fmt.Sprintf("gateway is %q instead of %q", svm.Gateway, want.Gateway))
+	}
+	if want.CapacityBytes != 0 && capacity != want.CapacityBytes {
+		update.CapacityBytes = want.CapacityBytes
+		drift = append(drift, fmt.Sprintf("capacity is %d bytes instead of %d", capacity, want.CapacityBytes))
+	}
+	if len(drift) == 0 {
+		return nil, nil
+	}
+	return &update, drift
+}
+
+// drift compares export rules with the expected clients: every exported
+// volume must be exported read-write to each expected client and to nobody
+// else
+func (a *Auditor) drift(rules []v1alpha1.ArcaExportRule) []string {
+	if len(rules) == 0 {
+		return []string{"SVM has no export rules"}
+	}
+
+	var drift []string
+	covered := make(map[string]map[netip.Prefix]bool) // volume -> expected clients
+	for _, r := range rules {
+		if covered[r.Volume] == nil {
+			covered[r.Volume] = make(map[netip.Prefix]bool)
+		}
+		prefix, err := parseClient(r.Client)
+		if err != nil || !slices.Contains(a.clients, prefix) {
+			drift = append(drift, fmt.Sprintf("volume %s is exported to unexpected client %s", r.Volume, r.Client))
+			continue
+		}
+		if r.Access != "rw" {
+			drift = append(drift, fmt.Sprintf("volume %s is exported to %s with access %q instead of \"rw\"", r.Volume, r.Client, r.Access))
+		}
+		covered[r.Volume][prefix] = true
+	}
+
+	volumes := make([]string, 0, len(covered))
+	for volume := range covered {
+		volumes = append(volumes, volume)
+	}
+	slices.Sort(volumes)
+	for _, volume := range volumes {
+		for _, c := range a.clients {
+			if !covered[volume][c] {
+				drift = append(drift, fmt.Sprintf("volume %s is not exported to %s", volume, c))
+			}
+		}
+	}
+	return drift
+}
+
+// parseClient parses an export client address or CIDR; addresses are
+// treated as single-host prefixes
+func parseClient(s string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid export client %q", s)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
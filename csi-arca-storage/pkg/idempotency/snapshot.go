@@ -1,38 +1,83 @@
 package idempotency
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 )
 
-// SnapshotIDGenerator generates stable snapshot IDs from snapshot names
-type SnapshotIDGenerator struct{}
+// SnapshotIDGenerator assigns snapshot IDs to CreateSnapshot requests. The
+// key combines the source volume ID and the request name.
+type SnapshotIDGenerator interface {
+	// GenerateSnapshotID returns the ID for a snapshot key
+	GenerateSnapshotID(key string) (string, error)
+	// Forget releases any in-flight assignment for key once the snapshot
+	// record is persisted (or creation is abandoned)
+	Forget(key string)
+}
+
+// HashSnapshotIDGenerator generates stable snapshot IDs from snapshot names
+type HashSnapshotIDGenerator struct{}
 
 // NewSnapshotIDGenerator creates a new snapshot ID generator
-func NewSnapshotIDGenerator() *SnapshotIDGenerator {
-	return &SnapshotIDGenerator{}
+func NewSnapshotIDGenerator() *HashSnapshotIDGenerator {
+	return &HashSnapshotIDGenerator{}
 }
 
 // GenerateSnapshotID creates a deterministic snapshot ID from request name
 // Format: {hash(name)[:16]} (64-bit hash, NO "snap-" prefix here)
 // The "snap-" prefix is added when constructing the full path
-func (g *SnapshotIDGenerator) GenerateSnapshotID(name string) string {
-	h := sha256.Sum256([]byte(name))
-	return hex.EncodeToString(h[:8])
+func (g *HashSnapshotIDGenerator) GenerateSnapshotID(key string) (string, error) {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:8]), nil
 }
 
-// ValidateSnapshotID checks if a snapshot ID has the correct format
-func (g *SnapshotIDGenerator) ValidateSnapshotID(snapshotID string) bool {
-	// Format: 16 hex chars
-	if len(snapshotID) != 16 {
-		return false
-	}
-	// Check if all chars are valid hex
-	for i := 0; i < 16; i++ {
-		c := snapshotID[i]
-		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
-			return false
+// Forget is a no-op; hash IDs need no bookkeeping
+func (g *HashSnapshotIDGenerator) Forget(key string) {}
+
+// SaltedSnapshotIDGenerator generates stable snapshot IDs from an HMAC of
+// the key with a cluster secret, so tenants cannot compute the ID of
+// another tenant's snapshot from its source volume ID and name
+type SaltedSnapshotIDGenerator struct {
+	salt   []byte
+	lookup IDLookup
+}
+
+// NewSaltedSnapshotIDGenerator creates a salted snapshot ID generator. With
+// a lookup (compatibility mode) a snapshot already recorded for a key keeps
+// its ID, e.g. one created before the salt was configured.
+func NewSaltedSnapshotIDGenerator(salt []byte, lookup IDLookup) *SaltedSnapshotIDGenerator {
+	return &SaltedSnapshotIDGenerator{salt: salt, lookup: lookup}
+}
+
+// GenerateSnapshotID returns the recorded ID for key in compatibility mode,
+// otherwise {hmac(salt, key)[:16]} in the same format as the hash generator
+func (g *SaltedSnapshotIDGenerator) GenerateSnapshotID(key string) (string, error) {
+	if g.lookup != nil {
+		id, found, err := g.lookup(key)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return id, nil
 		}
 	}
-	return true
+	mac := hmac.New(sha256.New, g.salt)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil)[:8]), nil
+}
+
+// Forget is a no-op; salted IDs need no bookkeeping
+func (g *SaltedSnapshotIDGenerator) Forget(key string) {}
+
+// ValidateSnapshotID checks if a snapshot ID has the hash format
+func (g *HashSnapshotIDGenerator) ValidateSnapshotID(snapshotID string) bool {
+	// Format: 16 hex chars
+	return len(snapshotID) == 16 && isHex(snapshotID)
+}
+
+// IsSnapshotID reports whether id is a snapshot ID in either the hash or
+// the UUID format
+func IsSnapshotID(id string) bool {
+	return (len(id) == 16 && isHex(id)) || isUUID(id)
 }
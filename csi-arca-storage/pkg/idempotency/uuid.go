@@ -0,0 +1,162 @@
+package idempotency
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"k8s.io/klog/v2"
+)
+
+// ID generation modes
+const (
+	// ModeHash derives IDs from a hash of the request name
+	ModeHash = "hash"
+	// ModeUUID assigns random UUIDs, recovered from the store on retries
+	ModeUUID = "uuid"
+)
+
+// IDLookup returns the ID already recorded for a request key, with found
+// false when no record exists
+type IDLookup func(key string) (id string, found bool, err error)
+
+// IDRecorder persists in-flight ID assignments until the volume or
+// snapshot record is written, so that a retry after a controller restart
+// reuses an ID whose backend objects may already exist
+type IDRecorder interface {
+	// RecordedID returns the ID recorded for key
+	RecordedID(key string) (id string, found bool, err error)
+	// RecordID records the ID assigned to key
+	RecordID(key, id string) error
+	// ForgetID drops the record of key
+	ForgetID(key string) error
+}
+
+// uuidAssigner hands out random UUIDs. The persisted record (found through
+// lookup) is the durable idempotency marker; until it is written the
+// assignment is kept by recorder, if set, and in pending.
+type uuidAssigner struct {
+	mu       sync.Mutex
+	lookup   IDLookup
+	recorder IDRecorder
+	pending  map[string]string
+}
+
+// assign returns the recorded, in-flight or a new ID for key. A new ID is
+// recorded before it is returned, so no backend object is created under
+// an ID that could be lost.
+func (a *uuidAssigner) assign(key, prefix string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if id, ok := a.pending[key]; ok {
+		return id, nil
+	}
+
+	id, found, err := a.lookup(key)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		return id, nil
+	}
+
+	if a.recorder != nil {
+		id, found, err = a.recorder.RecordedID(key)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			a.pending[key] = id
+			return id, nil
+		}
+	}
+
+	id = prefix + uuid.NewString()
+	if a.recorder != nil {
+		if err := a.recorder.RecordID(key, id); err != nil {
+			return "", err
+		}
+	}
+	a.pending[key] = id
+	return id, nil
+}
+
+// forget drops the in-flight assignment for key once its record exists
+func (a *uuidAssigner) forget(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.pending, key)
+	if a.recorder != nil {
+		if err := a.recorder.ForgetID(key); err != nil {
+			// The record is found through lookup from now on; the stale
+			// assignment expires
+			klog.Warningf("Failed to drop the ID assignment of %s: %v", key, err)
+		}
+	}
+}
+
+// UUIDVolumeIDGenerator assigns random "pvc-<uuid>" volume IDs, for
+// backends that dislike hash-derived names
+type UUIDVolumeIDGenerator struct {
+	a uuidAssigner
+}
+
+// NewUUIDVolumeIDGenerator creates a UUID volume ID generator; lookup must
+// find the ID of an existing volume by request name. recorder persists
+// in-flight assignments (nil keeps them in memory only).
+func NewUUIDVolumeIDGenerator(lookup IDLookup, recorder IDRecorder) *UUIDVolumeIDGenerator {
+	return &UUIDVolumeIDGenerator{a: uuidAssigner{lookup: lookup, recorder: recorder, pending: make(map[string]string)}}
+}
+
+// GenerateVolumeID returns the existing or a new random ID for name
+func (g *UUIDVolumeIDGenerator) GenerateVolumeID(name string) (string, error) {
+	return g.a.assign(name, "pvc-")
+}
+
+// Forget drops the in-flight assignment for name
+func (g *UUIDVolumeIDGenerator) Forget(name string) {
+	g.a.forget(name)
+}
+
+// UUIDSnapshotIDGenerator assigns random UUID snapshot IDs
+type UUIDSnapshotIDGenerator struct {
+	a uuidAssigner
+}
+
+// NewUUIDSnapshotIDGenerator creates a UUID snapshot ID generator; lookup
+// must find the ID of an existing snapshot by key. recorder persists
+// in-flight assignments (nil keeps them in memory only).
+func NewUUIDSnapshotIDGenerator(lookup IDLookup, recorder IDRecorder) *UUIDSnapshotIDGenerator {
+	return &UUIDSnapshotIDGenerator{a: uuidAssigner{lookup: lookup, recorder: recorder, pending: make(map[string]string)}}
+}
+
+// GenerateSnapshotID returns the existing or a new random ID for key
+func (g *UUIDSnapshotIDGenerator) GenerateSnapshotID(key string) (string, error) {
+	return g.a.assign(key, "")
+}
+
+// Forget drops the in-flight assignment for key
+func (g *UUIDSnapshotIDGenerator) Forget(key string) {
+	g.a.forget(key)
+}
+
+// isUUID reports whether s is a canonical lowercase UUID
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(s[i : i+1]) {
+				return false
+			}
+		}
+	}
+	return true
+}
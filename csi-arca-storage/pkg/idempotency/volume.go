@@ -4,25 +4,39 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
-// VolumeIDGenerator generates stable volume IDs from PVC names
-type VolumeIDGenerator struct{}
+// VolumeIDGenerator assigns volume IDs to CreateVolume request names. The
+// same name must map to the same ID for as long as the volume exists.
+type VolumeIDGenerator interface {
+	// GenerateVolumeID returns the ID for a request name
+	GenerateVolumeID(name string) (string, error)
+	// Forget releases any in-flight assignment for name once the volume
+	// record is persisted (or creation is abandoned)
+	Forget(name string)
+}
+
+// HashVolumeIDGenerator generates stable volume IDs from PVC names
+type HashVolumeIDGenerator struct{}
 
 // NewVolumeIDGenerator creates a new volume ID generator
-func NewVolumeIDGenerator() *VolumeIDGenerator {
-	return &VolumeIDGenerator{}
+func NewVolumeIDGenerator() *HashVolumeIDGenerator {
+	return &HashVolumeIDGenerator{}
 }
 
 // GenerateVolumeID creates a deterministic volume ID from request name
 // Format: pvc-{hash(name)[:16]} (64-bit hash to reduce collision risk)
-func (g *VolumeIDGenerator) GenerateVolumeID(name string) string {
+func (g *HashVolumeIDGenerator) GenerateVolumeID(name string) (string, error) {
 	h := sha256.Sum256([]byte(name))
-	return fmt.Sprintf("pvc-%s", hex.EncodeToString(h[:8]))
+	return fmt.Sprintf("pvc-%s", hex.EncodeToString(h[:8])), nil
 }
 
-// ValidateVolumeID checks if a volume ID has the correct format
-func (g *VolumeIDGenerator) ValidateVolumeID(volumeID string) bool {
+// Forget is a no-op; hash IDs need no bookkeeping
+func (g *HashVolumeIDGenerator) Forget(name string) {}
+
+// ValidateVolumeID checks if a volume ID has the hash format
+func (g *HashVolumeIDGenerator) ValidateVolumeID(volumeID string) bool {
 	// Format: pvc-{16 hex chars}
 	if len(volumeID) != 20 { // "pvc-" (4) + 16 hex chars
 		return false
@@ -30,9 +44,26 @@ func (g *VolumeIDGenerator) ValidateVolumeID(volumeID string) bool {
 	if volumeID[:4] != "pvc-" {
 		return false
 	}
-	// Check if remaining chars are valid hex
-	for i := 4; i < 20; i++ {
-		c := volumeID[i]
+	return isHex(volumeID[4:])
+}
+
+// IsVolumeID reports whether id is a volume ID in either the hash or the
+// UUID format
+func IsVolumeID(id string) bool {
+	rest, ok := strings.CutPrefix(id, "pvc-")
+	if !ok {
+		return false
+	}
+	return (len(rest) == 16 && isHex(rest)) || isUUID(rest)
+}
+
+// isHex reports whether s is non-empty lowercase hex
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
 		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
 			return false
 		}
@@ -0,0 +1,89 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+)
+
+// crdBackend stores locks as cluster-scoped ArcaLocks, for clusters whose
+// policy forbids creating Leases. Updates carry the resourceVersion they
+// read, so concurrent writers are serialized by the API server.
+type crdBackend struct {
+	client client.Client
+}
+
+// NewCRDBackend stores locks as ArcaLock resources
+func NewCRDBackend(c client.Client) Backend {
+	return &crdBackend{client: c}
+}
+
+func (b *crdBackend) Get(ctx context.Context, name string) (*Record, error) {
+	lock := &v1alpha1.ArcaLock{}
+	if err := b.client.Get(ctx, client.ObjectKey{Name: name}, lock); err != nil {
+		return nil, err
+	}
+	return arcaLockToRecord(lock), nil
+}
+
+func (b *crdBackend) Create(ctx context.Context, record *Record) error {
+	return b.client.Create(ctx, recordToArcaLock(record))
+}
+
+func (b *crdBackend) Update(ctx context.Context, record *Record) error {
+	return b.client.Update(ctx, recordToArcaLock(record))
+}
+
+func (b *crdBackend) Delete(ctx context.Context, name string, revision *Record) error {
+	lock := &v1alpha1.ArcaLock{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if revision == nil {
+		return b.client.Delete(ctx, lock)
+	}
+	uid := types.UID(revision.UID)
+	return b.client.Delete(ctx, lock, client.Preconditions{UID: &uid, ResourceVersion: &revision.ResourceVersion})
+}
+
+func (b *crdBackend) List(ctx context.Context) ([]Record, error) {
+	list := &v1alpha1.ArcaLockList{}
+	if err := b.client.List(ctx, list); err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(list.Items))
+	for i := range list.Items {
+		records = append(records, *arcaLockToRecord(&list.Items[i]))
+	}
+	return records, nil
+}
+
+// arcaLockToRecord converts an ArcaLock to a lock record
+func arcaLockToRecord(lock *v1alpha1.ArcaLock) *Record {
+	return &Record{
+		Name:            lock.Name,
+		HolderIdentity:  lock.Spec.HolderIdentity,
+		LeaseDuration:   time.Duration(lock.Spec.LeaseDurationSeconds) * time.Second,
+		RenewTime:       lock.Spec.RenewTime.Time,
+		UID:             string(lock.UID),
+		ResourceVersion: lock.ResourceVersion,
+	}
+}
+
+// recordToArcaLock converts a lock record to an ArcaLock
+func recordToArcaLock(record *Record) *v1alpha1.ArcaLock {
+	return &v1alpha1.ArcaLock{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            record.Name,
+			UID:             types.UID(record.UID),
+			ResourceVersion: record.ResourceVersion,
+		},
+		Spec: v1alpha1.ArcaLockSpec{
+			HolderIdentity:       record.HolderIdentity,
+			LeaseDurationSeconds: int32(record.LeaseDuration.Seconds()),
+			RenewTime:            metav1.NewMicroTime(record.RenewTime),
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// DefaultGCInterval is how often expired locks are collected
+const DefaultGCInterval = 5 * time.Minute
+
+// Collector deletes the locks a crashed holder left behind. Release deletes
+// a lock, but one whose holder died mid-hold stays until the next
+// AcquireLock for the same resource takes it over, which may never come.
+type Collector struct {
+	backend Backend
+}
+
+// NewCollector creates a collector for the locks stored in backend
+func NewCollector(backend Backend) *Collector {
+	return &Collector{backend: backend}
+}
+
+// Run collects expired locks every interval until ctx is cancelled
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultGCInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.collect(ctx); err != nil {
+			klog.Errorf("Failed to collect expired locks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect deletes the locks that expired at least one lease duration ago,
+// so a holder whose renewal is merely late keeps its lock. The delete is
+// conditional on the resourceVersion, so a lock that AcquireLock took over
+// since it was listed is left alone.
+func (c *Collector) collect(ctx context.Context) error {
+	records, err := c.backend.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list locks: %w", err)
+	}
+
+	now := time.Now()
+	held, expired := 0, 0
+	for i := range records {
+		record := &records[i]
+		expiryTime, ok := record.expiry()
+		if !ok || now.Before(expiryTime) {
+			held++
+			continue
+		}
+		expired++
+		if now.Before(expiryTime.Add(record.LeaseDuration)) {
+			continue
+		}
+
+		err := c.backend.Delete(ctx, record.Name, record)
+		switch {
+		case err == nil:
+			metrics.LockLeasesCollected.Inc()
+			klog.Infof("Deleted expired lock %s (expired %v ago)", record.Name, now.Sub(expiryTime).Round(time.Second))
+		case apierrors.IsNotFound(err), apierrors.IsConflict(err):
+			klog.V(4).Infof("Lock %s was released or taken over, not deleting it", record.Name)
+		default:
+			klog.Warningf("Failed to delete expired lock %s: %v", record.Name, err)
+		}
+	}
+
+	metrics.LockLeases.WithLabelValues("held").Set(float64(held))
+	metrics.LockLeases.WithLabelValues("expired").Set(float64(expired))
+	return nil
+}
@@ -0,0 +1,104 @@
+package lock
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// leaseBackend stores locks as coordination.k8s.io Leases in one namespace
+type leaseBackend struct {
+	clientset kubernetes.Interface
+	namespace string
+}
+
+// NewLeaseBackend stores locks as Leases in namespace
+func NewLeaseBackend(clientset kubernetes.Interface, namespace string) Backend {
+	return &leaseBackend{clientset: clientset, namespace: namespace}
+}
+
+func (b *leaseBackend) Get(ctx context.Context, name string) (*Record, error) {
+	lease, err := b.clientset.CoordinationV1().Leases(b.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return leaseToRecord(lease), nil
+}
+
+func (b *leaseBackend) Create(ctx context.Context, record *Record) error {
+	_, err := b.clientset.CoordinationV1().Leases(b.namespace).Create(ctx, b.recordToLease(record), metav1.CreateOptions{})
+	return err
+}
+
+func (b *leaseBackend) Update(ctx context.Context, record *Record) error {
+	_, err := b.clientset.CoordinationV1().Leases(b.namespace).Update(ctx, b.recordToLease(record), metav1.UpdateOptions{})
+	return err
+}
+
+func (b *leaseBackend) Delete(ctx context.Context, name string, revision *Record) error {
+	opts := metav1.DeleteOptions{}
+	if revision != nil {
+		uid := types.UID(revision.UID)
+		opts.Preconditions = &metav1.Preconditions{UID: &uid, ResourceVersion: &revision.ResourceVersion}
+	}
+	return b.clientset.CoordinationV1().Leases(b.namespace).Delete(ctx, name, opts)
+}
+
+// List returns the lock Leases, skipping the namespace's other Leases
+func (b *leaseBackend) List(ctx context.Context) ([]Record, error) {
+	leases, err := b.clientset.CoordinationV1().Leases(b.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	for i := range leases.Items {
+		if strings.HasPrefix(leases.Items[i].Name, LeasePrefix) {
+			records = append(records, *leaseToRecord(&leases.Items[i]))
+		}
+	}
+	return records, nil
+}
+
+// leaseToRecord converts a Lease to a lock record
+func leaseToRecord(lease *coordinationv1.Lease) *Record {
+	record := &Record{
+		Name:            lease.Name,
+		UID:             string(lease.UID),
+		ResourceVersion: lease.ResourceVersion,
+	}
+	if lease.Spec.HolderIdentity != nil {
+		record.HolderIdentity = *lease.Spec.HolderIdentity
+	}
+	if lease.Spec.LeaseDurationSeconds != nil {
+		record.LeaseDuration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	if lease.Spec.RenewTime != nil {
+		record.RenewTime = lease.Spec.RenewTime.Time
+	}
+	return record
+}
+
+// recordToLease converts a lock record to a Lease in the backend's namespace
+func (b *leaseBackend) recordToLease(record *Record) *coordinationv1.Lease {
+	holder := record.HolderIdentity
+	duration := int32(record.LeaseDuration.Seconds())
+	renewTime := metav1.NewMicroTime(record.RenewTime)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            record.Name,
+			Namespace:       b.namespace,
+			UID:             types.UID(record.UID),
+			ResourceVersion: record.ResourceVersion,
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			RenewTime:            &renewTime,
+		},
+	}
+}
@@ -2,21 +2,59 @@ package lock
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	coordinationv1 "k8s.io/api/coordination/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 )
 
-// Manager manages distributed locks using Kubernetes Leases
+// ErrLockHeld indicates the lock stayed held by another owner
+var ErrLockHeld = errors.New("lock held by another owner")
+
+// LeasePrefix starts the name of every lock
+const LeasePrefix = "arca-csi-svm-"
+
+// Lock backends
+const (
+	BackendLease = "lease"
+	BackendCRD   = "crd"
+)
+
+// Record is a lock as stored by a Backend
+type Record struct {
+	Name           string
+	HolderIdentity string
+	LeaseDuration  time.Duration
+	RenewTime      time.Time
+
+	// UID and ResourceVersion identify the stored revision; updates and
+	// conditional deletes of a changed record fail with a conflict
+	UID             string
+	ResourceVersion string
+}
+
+// Backend stores lock records. Errors are Kubernetes API errors, so NotFound,
+// AlreadyExists and Conflict can be told apart.
+type Backend interface {
+	// Get returns the named record
+	Get(ctx context.Context, name string) (*Record, error)
+	// Create stores a new record
+	Create(ctx context.Context, record *Record) error
+	// Update replaces a record at the revision it was read at
+	Update(ctx context.Context, record *Record) error
+	// Delete removes the named record; with a non-nil revision only while
+	// the record is still at that revision
+	Delete(ctx context.Context, name string, revision *Record) error
+	// List returns all lock records
+	List(ctx context.Context) ([]Record, error)
+}
+
+// Manager manages distributed locks stored in a Backend
 type Manager struct {
-	clientset *kubernetes.Clientset
-	namespace string
-	identity  string
+	backend  Backend
+	identity string
 }
 
 // Lock represents an acquired lock
@@ -28,17 +66,16 @@ type Lock struct {
 }
 
 // NewManager creates a new lock manager
-func NewManager(clientset *kubernetes.Clientset, namespace, identity string) *Manager {
+func NewManager(backend Backend, identity string) *Manager {
 	return &Manager{
-		clientset: clientset,
-		namespace: namespace,
-		identity:  identity,
+		backend:  backend,
+		identity: identity,
 	}
 }
 
 // AcquireLock acquires a distributed lock for the given resource
 func (m *Manager) AcquireLock(ctx context.Context, resourceName string, ttl time.Duration) (*Lock, error) {
-	leaseName := fmt.Sprintf("arca-csi-svm-%s", resourceName)
+	leaseName := LeasePrefix + resourceName
 
 	lockCtx, cancel := context.WithCancel(ctx)
 	lock := &Lock{
@@ -74,39 +111,32 @@ func (m *Manager) AcquireLock(ctx context.Context, resourceName string, ttl time
 	}
 
 	cancel()
-	return nil, fmt.Errorf("failed to acquire lock for %s within %v", resourceName, ttl)
+	return nil, fmt.Errorf("failed to acquire lock for %s within %v: %w", resourceName, ttl, ErrLockHeld)
 }
 
-// tryAcquireLease attempts to acquire or update a lease
+// tryAcquireLease attempts to acquire or renew a lock. Losing a race with
+// another holder (conflict or already exists) is not an error: the lock is
+// simply not acquired this time.
 func (m *Manager) tryAcquireLease(ctx context.Context, leaseName string, ttl time.Duration) (bool, error) {
-	leaseDuration := int32(ttl.Seconds())
-	now := metav1.NewMicroTime(time.Now())
-
-	leaseClient := m.clientset.CoordinationV1().Leases(m.namespace)
+	now := time.Now()
 
 	// Try to get existing lease
-	lease, err := leaseClient.Get(ctx, leaseName, metav1.GetOptions{})
+	record, err := m.backend.Get(ctx, leaseName)
 	if err == nil {
 		// Lease exists - check if we own it or it's expired
-		if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == m.identity {
+		if record.HolderIdentity == m.identity {
 			// We own it - renew
-			lease.Spec.RenewTime = &now
-			_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
-			return err == nil, err
+			record.RenewTime = now
+			return m.written(m.backend.Update(ctx, record))
 		}
 
 		// Check if expired
-		if lease.Spec.RenewTime != nil && lease.Spec.LeaseDurationSeconds != nil {
-			renewTime := lease.Spec.RenewTime.Time
-			expiryTime := renewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
-			if time.Now().After(expiryTime) {
-				// Expired - take over
-				lease.Spec.HolderIdentity = &m.identity
-				lease.Spec.RenewTime = &now
-				lease.Spec.LeaseDurationSeconds = &leaseDuration
-				_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
-				return err == nil, err
-			}
+		if expiryTime, ok := record.expiry(); ok && now.After(expiryTime) {
+			// Expired - take over
+			record.HolderIdentity = m.identity
+			record.RenewTime = now
+			record.LeaseDuration = ttl
+			return m.written(m.backend.Update(ctx, record))
 		}
 
 		// Someone else owns it and it's not expired
@@ -120,22 +150,32 @@ func (m *Manager) tryAcquireLease(ctx context.Context, leaseName string, ttl tim
 	}
 
 	// Lease doesn't exist - create it
-	lease = &coordinationv1.Lease{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      leaseName,
-			Namespace: m.namespace,
-		},
-		Spec: coordinationv1.LeaseSpec{
-			HolderIdentity:       &m.identity,
-			LeaseDurationSeconds: &leaseDuration,
-			RenewTime:            &now,
-		},
-	}
+	return m.written(m.backend.Create(ctx, &Record{
+		Name:           leaseName,
+		HolderIdentity: m.identity,
+		LeaseDuration:  ttl,
+		RenewTime:      now,
+	}))
+}
 
-	_, err = leaseClient.Create(ctx, lease, metav1.CreateOptions{})
+// written reports whether a record write succeeded, treating a lost race as
+// not acquired rather than as an error
+func (m *Manager) written(err error) (bool, error) {
+	if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+		return false, nil
+	}
 	return err == nil, err
 }
 
+// expiry returns when a record expires unless renewed, or false for a record
+// without renew time or duration
+func (r *Record) expiry() (time.Time, bool) {
+	if r.RenewTime.IsZero() || r.LeaseDuration <= 0 {
+		return time.Time{}, false
+	}
+	return r.RenewTime.Add(r.LeaseDuration), true
+}
+
 // renewLoop renews the lease periodically
 func (l *Lock) renewLoop(ttl time.Duration) {
 	ticker := time.NewTicker(ttl / 3) // Renew at 1/3 of TTL
@@ -144,9 +184,11 @@ func (l *Lock) renewLoop(ttl time.Duration) {
 	for {
 		select {
 		case <-ticker.C:
-			_, err := l.manager.tryAcquireLease(l.ctx, l.leaseName, ttl)
+			renewed, err := l.manager.tryAcquireLease(l.ctx, l.leaseName, ttl)
 			if err != nil {
 				klog.Warningf("Failed to renew lease %s: %v", l.leaseName, err)
+			} else if !renewed {
+				klog.Warningf("Lost lease %s to another holder", l.leaseName)
 			}
 		case <-l.ctx.Done():
 			return
@@ -159,8 +201,7 @@ func (l *Lock) Release(ctx context.Context) error {
 	l.cancel() // Stop renewal
 
 	// Delete the lease
-	leaseClient := l.manager.clientset.CoordinationV1().Leases(l.manager.namespace)
-	err := leaseClient.Delete(ctx, l.leaseName, metav1.DeleteOptions{})
+	err := l.manager.backend.Delete(ctx, l.leaseName, nil)
 	if err != nil {
 		klog.Warningf("Failed to delete lease %s: %v", l.leaseName, err)
 		return err
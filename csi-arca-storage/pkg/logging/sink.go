@@ -0,0 +1,218 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// modulePrefix is the import path prefix of the driver's packages
+const modulePrefix = "github.com/akam1o/csi-arca-storage/"
+
+// Log severities
+const (
+	severityInfo    = "info"
+	severityWarning = "warning"
+	severityError   = "error"
+	severityFatal   = "fatal"
+)
+
+// output serializes the lines written by a sink and its derived sinks
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// sink is the logr backend klog writes to. klog reports warnings and fatal
+// errors as plain Info calls, so the severity and the subsystem are taken
+// from the call stack.
+type sink struct {
+	out        *output
+	json       bool
+	level      int
+	subsystems map[string]int
+	name       string
+	values     []any
+}
+
+// newSink creates a sink writing lines to w
+func newSink(w io.Writer, json bool, level int, subsystems map[string]int) *sink {
+	return &sink{out: &output{w: w}, json: json, level: level, subsystems: subsystems}
+}
+
+func (s *sink) Init(logr.RuntimeInfo) {}
+
+// Enabled lets every level through; klog has already checked -v, and the
+// per-subsystem level needs the caller, which is resolved in Info
+func (s *sink) Enabled(int) bool {
+	return true
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...any) {
+	caller, subsystem, severity := resolveCaller()
+	if level > s.levelFor(subsystem) {
+		return
+	}
+	s.write(severity, level, caller, subsystem, msg, nil, keysAndValues)
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...any) {
+	caller, subsystem, severity := resolveCaller()
+	if severity != severityFatal {
+		severity = severityError
+	}
+	s.write(severity, 0, caller, subsystem, msg, err, keysAndValues)
+}
+
+func (s *sink) WithValues(keysAndValues ...any) logr.LogSink {
+	c := *s
+	c.values = append(append([]any(nil), s.values...), keysAndValues...)
+	return &c
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	c := *s
+	if c.name != "" {
+		name = c.name + "/" + name
+	}
+	c.name = name
+	return &c
+}
+
+// levelFor returns the verbosity of a subsystem
+func (s *sink) levelFor(subsystem string) int {
+	if level, ok := s.subsystems[subsystem]; ok {
+		return level
+	}
+	return s.level
+}
+
+// write formats one entry as a klog text line or a JSON object
+func (s *sink) write(severity string, level int, caller, subsystem, msg string, err error, keysAndValues []any) {
+	now := time.Now()
+	kvs := append(append([]any(nil), s.values...), keysAndValues...)
+	if s.name != "" {
+		kvs = append([]any{"logger", s.name}, kvs...)
+	}
+	if err != nil {
+		kvs = append(kvs, "err", err.Error())
+	}
+
+	var buf bytes.Buffer
+	if s.json {
+		buf.WriteString(`{"ts":`)
+		writeJSON(&buf, now.UTC().Format(time.RFC3339Nano))
+		buf.WriteString(`,"severity":`)
+		writeJSON(&buf, severity)
+		if level > 0 {
+			buf.WriteString(`,"v":` + strconv.Itoa(level))
+		}
+		if subsystem != "" {
+			buf.WriteString(`,"subsystem":`)
+			writeJSON(&buf, subsystem)
+		}
+		buf.WriteString(`,"caller":`)
+		writeJSON(&buf, caller)
+		buf.WriteString(`,"msg":`)
+		writeJSON(&buf, msg)
+		for i := 0; i < len(kvs); i += 2 {
+			buf.WriteByte(',')
+			writeJSON(&buf, fmt.Sprint(kvs[i]))
+			buf.WriteByte(':')
+			writeJSON(&buf, valueAt(kvs, i+1))
+		}
+		buf.WriteString("}\n")
+	} else {
+		// Same header as klog: Lmmdd hh:mm:ss.uuuuuu pid file:line]
+		fmt.Fprintf(&buf, "%c%s %7d %s] %s", strings.ToUpper(severity)[0], now.Format("0102 15:04:05.000000"), os.Getpid(), caller, msg)
+		for i := 0; i < len(kvs); i += 2 {
+			fmt.Fprintf(&buf, " %v=", kvs[i])
+			if v, ok := valueAt(kvs, i+1).(string); ok {
+				buf.WriteString(strconv.Quote(v))
+			} else {
+				fmt.Fprintf(&buf, "%+v", valueAt(kvs, i+1))
+			}
+		}
+		buf.WriteByte('\n')
+	}
+
+	s.out.mu.Lock()
+	defer s.out.mu.Unlock()
+	_, _ = s.out.w.Write(buf.Bytes())
+}
+
+// valueAt returns kvs[i], or a marker for a key without value
+func valueAt(kvs []any, i int) any {
+	if i < len(kvs) {
+		return kvs[i]
+	}
+	return "(MISSING)"
+}
+
+// writeJSON writes v as JSON, falling back to its string form for values
+// that do not marshal
+func writeJSON(buf *bytes.Buffer, v any) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%+v", v))
+	}
+	buf.Write(data)
+}
+
+// resolveCaller walks the stack past klog, logr and this package and returns
+// the file:line and subsystem of the code that logged, and the severity of
+// the klog function it called
+func resolveCaller() (caller, subsystem, severity string) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	severity = severityInfo
+	for {
+		frame, more := frames.Next()
+		switch {
+		case strings.HasPrefix(frame.Function, "k8s.io/klog/"):
+			switch name := strings.TrimPrefix(frame.Function, "k8s.io/klog/v2."); {
+			case strings.HasPrefix(name, "Warning"):
+				severity = severityWarning
+			case strings.HasPrefix(name, "Fatal"), strings.HasPrefix(name, "Exit"):
+				severity = severityFatal
+			}
+		case strings.HasPrefix(frame.Function, "github.com/go-logr/logr."),
+			strings.HasPrefix(frame.Function, modulePrefix+"pkg/logging."):
+		default:
+			return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line), subsystemOf(frame.Function), severity
+		}
+		if !more {
+			return "???:1", "", severity
+		}
+	}
+}
+
+// subsystemOf returns the subsystem of a function from its import path
+func subsystemOf(function string) string {
+	switch {
+	case strings.HasPrefix(function, modulePrefix+"pkg/"):
+		rest := strings.TrimPrefix(function, modulePrefix+"pkg/")
+		if i := strings.IndexAny(rest, "/."); i >= 0 {
+			return rest[:i]
+		}
+		return rest
+	case strings.HasPrefix(function, modulePrefix+"cmd/"):
+		return "cmd"
+	case strings.HasPrefix(function, "k8s.io/"), strings.HasPrefix(function, "sigs.k8s.io/"):
+		return "kubernetes"
+	}
+	return ""
+}
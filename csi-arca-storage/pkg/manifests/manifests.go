@@ -0,0 +1,174 @@
+// Package manifests renders the deployment YAML from the driver's own
+// constants so socket paths, state directories and the driver name never
+// drift from what the code expects.
+package manifests
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akam1o/csi-arca-storage/deploy"
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+)
+
+//go:embed templates/*.yaml.tmpl
+var templates embed.FS
+
+// templateOrder is the order manifests are rendered in (dependencies first)
+var templateOrder = []string{
+	"csidriver.yaml.tmpl",
+	"rbac-controller.yaml.tmpl",
+	"rbac-node.yaml.tmpl",
+	"controller.yaml.tmpl",
+	"node.yaml.tmpl",
+}
+
+// Values holds the user-tunable settings for rendering manifests
+type Values struct {
+	Namespace          string  `yaml:"namespace"`
+	Image              string  `yaml:"image"`
+	ImagePullPolicy    string  `yaml:"image_pull_policy"`
+	LogLevel           int     `yaml:"log_level"`
+	ControllerReplicas int     `yaml:"controller_replicas"`
+	ConfigMapName      string  `yaml:"config_map_name"`
+	SecretName         string  `yaml:"secret_name"`
+	KubeletDir         string  `yaml:"kubelet_dir"`
+	SELinuxMount       bool    `yaml:"selinux_mount"`
+	FSCacheDir         string  `yaml:"fscache_dir"` // cachefilesd cache mounted into node plugins ("" = none)
+	IncludeCRDs        *bool   `yaml:"include_crds"`
+	Sidecars           Sidecar `yaml:"sidecars"`
+}
+
+// Sidecar holds the CSI sidecar container images
+type Sidecar struct {
+	Provisioner         string `yaml:"provisioner"`
+	Snapshotter         string `yaml:"snapshotter"`
+	Resizer             string `yaml:"resizer"`
+	LivenessProbe       string `yaml:"liveness_probe"`
+	NodeDriverRegistrar string `yaml:"node_driver_registrar"`
+}
+
+// renderData is passed to the templates (values plus derived constants)
+type renderData struct {
+	Values
+	DriverName string
+	SocketPath string
+	PluginDir  string
+	MountsDir  string
+	StateDir   string
+}
+
+// socketPath is the CSI socket inside the containers (matches driver.endpoint)
+const socketPath = "/csi/csi.sock"
+
+// DefaultValues returns the values used when no values file is given
+func DefaultValues() *Values {
+	includeCRDs := true
+	return &Values{
+		Namespace:          "kube-system",
+		Image:              "csi-arca-storage:" + driver.DriverVersion,
+		ImagePullPolicy:    "IfNotPresent",
+		LogLevel:           5,
+		ControllerReplicas: 1,
+		ConfigMapName:      "csi-arca-storage-config",
+		SecretName:         "csi-arca-storage-secret",
+		KubeletDir:         "/var/lib/kubelet",
+		IncludeCRDs:        &includeCRDs,
+		Sidecars: Sidecar{
+			Provisioner:         "registry.k8s.io/sig-storage/csi-provisioner:v5.1.0",
+			Snapshotter:         "registry.k8s.io/sig-storage/csi-snapshotter:v8.1.0",
+			Resizer:             "registry.k8s.io/sig-storage/csi-resizer:v1.12.0",
+			LivenessProbe:       "registry.k8s.io/sig-storage/livenessprobe:v2.14.0",
+			NodeDriverRegistrar: "registry.k8s.io/sig-storage/csi-node-driver-registrar:v2.12.0",
+		},
+	}
+}
+
+// LoadValues reads a values file on top of the defaults (empty path = defaults)
+func LoadValues(path string) (*Values, error) {
+	values := DefaultValues()
+	if path == "" {
+		return values, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, values); err != nil {
+		return nil, fmt.Errorf("failed to parse values file: %w", err)
+	}
+
+	return values, nil
+}
+
+// Render writes all manifests as a single multi-document YAML stream
+func Render(w io.Writer, values *Values) error {
+	if values.Namespace == "" || values.Image == "" {
+		return fmt.Errorf("namespace and image are required")
+	}
+
+	// The mount and state paths are compiled into the driver defaults; the
+	// mounts directory only follows the kubelet dir when it is customized
+	data := renderData{
+		Values:     *values,
+		DriverName: driver.DriverName,
+		SocketPath: socketPath,
+		PluginDir:  filepath.Join(values.KubeletDir, "plugins", driver.DriverName),
+		MountsDir:  driver.DefaultBaseMountPath,
+		StateDir:   filepath.Dir(driver.DefaultStateFilePath),
+	}
+	if values.KubeletDir != DefaultValues().KubeletDir {
+		data.MountsDir = filepath.Join(data.PluginDir, "mounts")
+	}
+
+	var out bytes.Buffer
+	if values.IncludeCRDs == nil || *values.IncludeCRDs {
+		if err := writeCRDs(&out); err != nil {
+			return err
+		}
+	}
+
+	tmpl, err := template.ParseFS(templates, "templates/*.yaml.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to parse manifest templates: %w", err)
+	}
+	for _, name := range templateOrder {
+		if err := tmpl.ExecuteTemplate(&out, name, data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", name, err)
+		}
+	}
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+// writeCRDs appends the embedded CRD manifests in a stable order
+func writeCRDs(out *bytes.Buffer) error {
+	names, err := fs.Glob(deploy.CRDs, "crds/*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to list CRDs: %w", err)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		crd, err := deploy.CRDs.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if !bytes.HasPrefix(crd, []byte("---")) {
+			out.WriteString("---\n")
+		}
+		out.Write(crd)
+	}
+	return nil
+}
@@ -0,0 +1,503 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog/v2"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "arca_csi"
+
+// Registry holds all driver metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// NodeStateVolumes is the number of staged volumes tracked in NodeState
+	NodeStateVolumes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node_state",
+		Name:      "volumes",
+		Help:      "Number of staged volumes recorded in node state.",
+	})
+
+	// NodeStatePublishedPaths is the number of published target paths in NodeState
+	NodeStatePublishedPaths = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node_state",
+		Name:      "published_paths",
+		Help:      "Number of published target paths recorded in node state.",
+	})
+
+	// NodePodVolumes is 1 for each pod a volume is published for on this node
+	NodePodVolumes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "pod_volume",
+		Help:      "Pods a volume is published for on this node (1 per pod and volume).",
+	}, []string{"svm", "volume_id", "pod_namespace", "pod"})
+
+	// NodeMountDuration is the duration of the mount syscalls of the node
+	// plugin
+	NodeMountDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "mount_duration_seconds",
+		Help:      "Duration of mount syscalls (nfs_mount, nfs_unmount, bind_mount, bind_unmount), by operation, SVM, VIP and result (success or error).",
+		Buckets:   mountBuckets,
+	}, []string{"operation", "svm", "vip", "result"})
+
+	// NodeSVMHealthy is 1 for each mounted SVM whose last canary probe
+	// succeeded and 0 for those whose probe failed or timed out
+	NodeSVMHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "svm_healthy",
+		Help:      "Whether the last canary write and read on a mounted SVM succeeded within the timeout (1) or not (0).",
+	}, []string{"svm", "vip"})
+
+	// NodeCanaryDuration is the duration of canary probes
+	NodeCanaryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "canary_duration_seconds",
+		Help:      "Duration of canary probes (write, sync, read and remove of a small file) on mounted SVMs, capped at the timeout.",
+		Buckets:   mountBuckets,
+	}, []string{"svm", "vip"})
+
+	// NodeFSCacheReadBytes is the bytes read from the fsc mounts of an SVM,
+	// by applications and from the server
+	NodeFSCacheReadBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "fscache_read_bytes",
+		Help:      "Bytes read from the fsc mounts of an SVM since they were mounted, by source (application: read by applications, server: fetched from the NFS server).",
+	}, []string{"svm", "source"})
+
+	// NodeFSCacheHitRatio is the share of application reads of an SVM's
+	// fsc mounts not fetched from the server during the last interval
+	NodeFSCacheHitRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "fscache_hit_ratio",
+		Help:      "Share of the bytes read from the fsc mounts of an SVM during the last collection interval that were served from the page cache or local disk cache instead of the server.",
+	}, []string{"svm"})
+
+	// NodeFSCacheUsedBytes is the space used in the cache filesystem
+	NodeFSCacheUsedBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "fscache_used_bytes",
+		Help:      "Bytes used in the filesystem of the cachefilesd cache directory.",
+	})
+
+	// NodeFSCacheReservedBytes is the cache budget held by SVMs with fsc
+	// mounts
+	NodeFSCacheReservedBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "fscache_reserved_bytes",
+		Help:      "Cache budget held by the SVMs mounted with fsc (budget per SVM times SVMs).",
+	})
+
+	// NodeFSCacheRejected counts fsc mounts made without the cache because
+	// no budget was left
+	NodeFSCacheRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "fscache_rejected_total",
+		Help:      "SVM mounts requested with fsc that were made without it, as the cache directory had no budget left for the SVM.",
+	}, []string{"svm"})
+
+	// NodeOperations counts staging and publish calls
+	NodeOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "node",
+		Name:      "operations_total",
+		Help:      "Node plugin calls, by operation (stage, unstage, publish, unpublish) and result (success or error).",
+	}, []string{"operation", "result"})
+
+	// NodeStateJournalEntries is the number of journal entries since the last compaction
+	NodeStateJournalEntries = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node_state",
+		Name:      "journal_entries",
+		Help:      "Number of node state journal entries since the last compaction.",
+	})
+
+	// NodeStateBytes is the size of the last persisted node state file
+	NodeStateBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node_state",
+		Name:      "bytes",
+		Help:      "Size in bytes of the last persisted node state file.",
+	})
+
+	// VolumeLogicalBytes is the data written to a volume before data reduction
+	VolumeLogicalBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "volume",
+		Name:      "logical_bytes",
+		Help:      "Bytes written to the volume before deduplication and compression.",
+	}, []string{"volume_id", "svm"})
+
+	// VolumePhysicalBytes is the backend space a volume occupies
+	VolumePhysicalBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "volume",
+		Name:      "physical_bytes",
+		Help:      "Backend bytes occupied by the volume after deduplication and compression.",
+	}, []string{"volume_id", "svm"})
+
+	// VolumeDedupeSavedBytes is the space saved by deduplication
+	VolumeDedupeSavedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "volume",
+		Name:      "dedupe_saved_bytes",
+		Help:      "Bytes saved by deduplication, including blocks shared with snapshots and clones.",
+	}, []string{"volume_id", "svm"})
+
+	// VolumeCompressionSavedBytes is the space saved by compression
+	VolumeCompressionSavedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "volume",
+		Name:      "compression_saved_bytes",
+		Help:      "Bytes saved by compression.",
+	}, []string{"volume_id", "svm"})
+
+	// SVMExportDrift is the number of export rule differences of an SVM
+	SVMExportDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "svm",
+		Name:      "export_drift",
+		Help:      "Number of differences between the SVM's export rules and the expected cluster clients.",
+	}, []string{"svm", "backend"})
+
+	// SVMAttributeDrift is the number of SVM attributes (MTU, gateway) that
+	// differ from the configuration
+	SVMAttributeDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "svm",
+		Name:      "attribute_drift",
+		Help:      "Number of SVM attributes (MTU, gateway) that differ from the driver configuration.",
+	}, []string{"svm", "backend"})
+
+	// OrphanedDirectories is the number of volume directories of an SVM
+	// without an ArcaVolume
+	OrphanedDirectories = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "svm",
+		Name:      "orphaned_directories",
+		Help:      "Number of volume directories on the SVM that no ArcaVolume records.",
+	}, []string{"svm", "backend"})
+
+	// OrphanedSnapshots is the number of snapshots of an SVM without an
+	// ArcaSnapshot
+	OrphanedSnapshots = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "svm",
+		Name:      "orphaned_snapshots",
+		Help:      "Number of snapshots on the SVM that no ArcaSnapshot records.",
+	}, []string{"svm", "backend"})
+
+	// OrphanedSnapshotsDeleted counts orphaned snapshots deleted by the
+	// orphan scan
+	OrphanedSnapshotsDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "orphaned_snapshots_deleted_total",
+		Help:      "Total number of snapshots without an ArcaSnapshot deleted by the orphan scan.",
+	}, []string{"backend"})
+
+	// NodePluginIncompatible is 1 for each node whose plugin lacks volume
+	// context fields the controller sets
+	NodePluginIncompatible = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "node_plugin",
+		Name:      "incompatible",
+		Help:      "Node plugins that lack volume context fields required by the controller (1 per node).",
+	}, []string{"node", "version"})
+
+	// LockLeases is the number of locks (Leases or ArcaLocks) by state (held or expired)
+	// seen in the last collection pass
+	LockLeases = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "lock",
+		Name:      "leases",
+		Help:      "Locks seen in the last collection pass, by state (held or expired).",
+	}, []string{"state"})
+
+	// LockLeasesCollected counts expired locks deleted by the collector
+	LockLeasesCollected = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "lock",
+		Name:      "leases_collected_total",
+		Help:      "Expired locks deleted after their holder stopped renewing them.",
+	})
+
+	// VolumeEvents counts ArcaVolumeEvents by result (recorded, error or
+	// dropped when the queue was full)
+	VolumeEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "volume_events",
+		Name:      "total",
+		Help:      "Volume access events of the node plugin, by result (recorded, error or dropped).",
+	}, []string{"result"})
+
+	// VolumeEventsPruned counts ArcaVolumeEvents deleted by the pruner
+	VolumeEventsPruned = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "volume_events",
+		Name:      "pruned_total",
+		Help:      "Volume access events deleted for exceeding the retention or size caps.",
+	})
+
+	// VolumeEventsStored is the number of ArcaVolumeEvents kept after the
+	// last pruning pass
+	VolumeEventsStored = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "volume_events",
+		Name:      "stored",
+		Help:      "Volume access events kept after the last pruning pass.",
+	})
+
+	// DeleteQueueVolumes is the number of volumes whose backend deletion is
+	// retried in the background
+	DeleteQueueVolumes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "delete_queue",
+		Name:      "volumes",
+		Help:      "Volumes whose deletion was acknowledged and whose backend data is still being deleted.",
+	})
+
+	// DeleteQueueAttempts counts background deletion attempts by result
+	// (success, error or held while a PersistentVolume still uses the volume)
+	DeleteQueueAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "delete_queue",
+		Name:      "attempts_total",
+		Help:      "Background volume deletion attempts, by result (success, error or held).",
+	}, []string{"result"})
+
+	// OperationDuration is the duration of controller operations by result
+	// (success or error)
+	OperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of controller operations, by operation and result (success or error).",
+		Buckets:   operationBuckets,
+	}, []string{"operation", "result"})
+
+	// OperationPhaseDuration is the duration of each phase of a controller
+	// operation
+	OperationPhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "operation_phase_duration_seconds",
+		Help:      "Duration of the phases of controller operations (e.g. svm_ensure, quota), by operation and phase.",
+		Buckets:   operationBuckets,
+	}, []string{"operation", "phase"})
+
+	// SlowOperations counts controller operations that took longer than
+	// their SLO
+	SlowOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "slow_operations_total",
+		Help:      "Controller operations that exceeded their configured SLO, by operation.",
+	}, []string{"operation"})
+
+	// OperationRetries counts repeated calls for the same volume or snapshot
+	// name, i.e. sidecar retries
+	OperationRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "operation_retries_total",
+		Help:      "Calls repeating an earlier call for the same name within 10 minutes (sidecar retries), by operation.",
+	}, []string{"operation"})
+
+	// OperationAttempts is the number of calls a successful operation took
+	OperationAttempts = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "operation_attempts",
+		Help:      "Calls made for a name until the operation succeeded, by operation.",
+		Buckets:   []float64{1, 2, 3, 5, 8, 13, 21},
+	}, []string{"operation"})
+
+	// RateLimited counts calls rejected by a namespace's provisioning rate
+	// limit
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "rate_limited_total",
+		Help:      "Provisioning calls rejected by the namespace rate limit, by operation and namespace.",
+	}, []string{"operation", "namespace"})
+
+	// CapacityCacheRequests counts GetCapacity calls by how the cache
+	// answered them (hit, miss or shared with a concurrent miss)
+	CapacityCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "capacity_cache_requests_total",
+		Help:      "GetCapacity calls, by cache result (hit, miss or shared: waited for a concurrent backend query).",
+	}, []string{"result"})
+
+	// AvailableCapacity is the capacity last reported by GetCapacity per
+	// StorageClass parameters and topology
+	AvailableCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "available_capacity_bytes",
+		Help:      "Available capacity last reported by GetCapacity, by StorageClass parameters and topology segments (sorted key=value pairs).",
+	}, []string{"parameters", "topology"})
+
+	// NamespacesBlockedOnStorage is the number of terminating or deleted
+	// namespaces whose storage is not cleaned up yet
+	NamespacesBlockedOnStorage = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "namespaces_blocked_on_storage",
+		Help:      "Terminating namespaces with bound PersistentVolumes and deleted namespaces whose SVM still holds volumes or snapshots.",
+	})
+
+	// QueuedOperations is the number of recorded ArcaOperations by type and
+	// phase
+	QueuedOperations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "operation_queue",
+		Name:      "operations",
+		Help:      "Long-running controller operations recorded as ArcaOperations, by type and phase.",
+	}, []string{"type", "phase"})
+
+	// ResumedOperations counts operations resumed from the operation queue
+	// by type and result (success or error)
+	ResumedOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "operation_queue",
+		Name:      "resumed_total",
+		Help:      "Operations resumed from the operation queue, by type and result (success or error).",
+	}, []string{"type", "result"})
+
+	// ArcaStuckCalls counts ARCA API calls the watchdog cancelled for
+	// running past the stuck call ceiling
+	ArcaStuckCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "arca",
+		Name:      "stuck_calls_total",
+		Help:      "ARCA API calls cancelled by the watchdog for running past the stuck call ceiling, by endpoint.",
+	}, []string{"endpoint"})
+
+	// ArcaReconnects counts replacements of the ARCA client's connections
+	// after repeated stuck calls
+	ArcaReconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "arca",
+		Name:      "reconnects_total",
+		Help:      "Times an ARCA client dropped its connections and started a new transport after repeated stuck calls.",
+	})
+)
+
+// operationBuckets span 10ms to about 10 minutes, as creating an SVM can
+// take minutes
+var operationBuckets = prometheus.ExponentialBuckets(0.01, 2, 17)
+
+// mountBuckets span 5ms to about 5 minutes, as hard NFS mounts of an
+// unreachable VIP only fail after their retransmissions time out
+var mountBuckets = prometheus.ExponentialBuckets(0.005, 2, 17)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		NodeStateVolumes,
+		NodeStatePublishedPaths,
+		NodePodVolumes,
+		NodeMountDuration,
+		NodeOperations,
+		NodeSVMHealthy,
+		NodeCanaryDuration,
+		NodeFSCacheReadBytes,
+		NodeFSCacheHitRatio,
+		NodeFSCacheUsedBytes,
+		NodeFSCacheReservedBytes,
+		NodeFSCacheRejected,
+		NodeStateBytes,
+		NodeStateJournalEntries,
+		VolumeLogicalBytes,
+		VolumePhysicalBytes,
+		VolumeDedupeSavedBytes,
+		VolumeCompressionSavedBytes,
+		SVMExportDrift,
+		SVMAttributeDrift,
+		OrphanedDirectories,
+		OrphanedSnapshots,
+		OrphanedSnapshotsDeleted,
+		NodePluginIncompatible,
+		LockLeases,
+		LockLeasesCollected,
+		VolumeEvents,
+		VolumeEventsPruned,
+		VolumeEventsStored,
+		DeleteQueueVolumes,
+		DeleteQueueAttempts,
+		OperationDuration,
+		OperationPhaseDuration,
+		SlowOperations,
+		OperationRetries,
+		OperationAttempts,
+		RateLimited,
+		CapacityCacheRequests,
+		AvailableCapacity,
+		NamespacesBlockedOnStorage,
+		QueuedOperations,
+		ResumedOperations,
+		ArcaStuckCalls,
+		ArcaReconnects,
+	)
+}
+
+// Serve exposes the metrics endpoint on addr until the context is cancelled.
+// Besides the driver's metrics it serves those controller-runtime registers,
+// such as the Kubernetes client and workqueue metrics. If ready is non-nil,
+// /readyz answers 200 while it returns nil and 503 with the error otherwise.
+func Serve(ctx context.Context, addr string, ready func(context.Context) error) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{Registry, ctrlmetrics.Registry}, promhttp.HandlerOpts{}))
+	if ready != nil {
+		mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+			if err := ready(r.Context()); err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprintln(w, "ok")
+		})
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	klog.Infof("Serving metrics on %s/metrics", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
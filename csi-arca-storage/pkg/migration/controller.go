@@ -0,0 +1,335 @@
+// Package migration moves a namespace's SVM and volumes between ARCA
+// backends as driven by ArcaMigration resources.
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// DefaultInterval is how often ArcaMigrations are reconciled
+const DefaultInterval = 15 * time.Second
+
+// reconcileTimeout bounds a single reconcile pass
+const reconcileTimeout = 2 * time.Minute
+
+// listPageSize is the page size used when scanning volume records
+const listPageSize = 500
+
+// errInvalid marks migrations that can never succeed
+var errInvalid = errors.New("invalid migration")
+
+// Controller reconciles ArcaMigration resources. A migration prepares the
+// SVM on the target backend, waits for the data copy to be confirmed through
+// spec.dataCopied, then rewrites the ArcaVolume records to the target
+// backend and VIP and routes the namespace to it.
+type Controller struct {
+	client         client.Client
+	store          store.Store
+	backends       *arca.BackendRouter
+	exportTemplate string
+}
+
+// NewController creates a migration controller
+func NewController(c client.Client, st store.Store, backends *arca.BackendRouter) *Controller {
+	return &Controller{
+		client:   c,
+		store:    st,
+		backends: backends,
+	}
+}
+
+// SetExportPathTemplate sets the export path template shown in copy
+// instructions for SVMs whose backend reports no export path
+func (c *Controller) SetExportPathTemplate(template string) {
+	c.exportTemplate = template
+}
+
+// Run reconciles ArcaMigrations every interval until ctx is cancelled
+func (c *Controller) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.reconcileAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcileAll advances every unfinished migration by at most one phase and
+// re-applies the routing of completed ones
+func (c *Controller) reconcileAll(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, reconcileTimeout)
+	defer cancel()
+
+	var list v1alpha1.ArcaMigrationList
+	if err := c.client.List(ctx, &list); err != nil {
+		klog.Errorf("Failed to list ArcaMigrations: %v", err)
+		return
+	}
+
+	// Oldest first so a later migration of the same namespace wins
+	slices.SortFunc(list.Items, func(a, b v1alpha1.ArcaMigration) int {
+		return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+	})
+
+	for i := range list.Items {
+		m := &list.Items[i]
+		switch m.Status.Phase {
+		case v1alpha1.ArcaMigrationPhaseCompleted:
+			if err := c.backends.PinNamespace(m.Spec.Namespace, m.Spec.TargetBackend); err != nil {
+				klog.Errorf("Failed to route namespace %s for migration %s: %v", m.Spec.Namespace, m.Name, err)
+			}
+		case v1alpha1.ArcaMigrationPhaseFailed:
+		default:
+			c.reconcile(ctx, m)
+		}
+	}
+}
+
+// reconcile runs the current phase of a migration and records the outcome
+func (c *Controller) reconcile(ctx context.Context, m *v1alpha1.ArcaMigration) {
+	var err error
+	switch m.Status.Phase {
+	case "", v1alpha1.ArcaMigrationPhasePending:
+		err = c.start(ctx, m)
+	case v1alpha1.ArcaMigrationPhasePreparing:
+		err = c.prepare(ctx, m)
+	case v1alpha1.ArcaMigrationPhaseAwaitingCopy:
+		if m.Spec.DataCopied {
+			m.Status.Phase = v1alpha1.ArcaMigrationPhaseCuttingOver
+			m.Status.Message = "Data copy confirmed; cutting over"
+		}
+	case v1alpha1.ArcaMigrationPhaseCuttingOver:
+		err = c.cutover(ctx, m)
+	}
+
+	if err != nil {
+		if errors.Is(err, errInvalid) {
+			klog.Errorf("Migration %s failed: %v", m.Name, err)
+			m.Status.Phase = v1alpha1.ArcaMigrationPhaseFailed
+		} else {
+			klog.Warningf("Migration %s: %v (will retry)", m.Name, err)
+		}
+		m.Status.Message = err.Error()
+	}
+
+	m.Status.ObservedGeneration = m.Generation
+	if err := c.client.Status().Update(ctx, m); err != nil {
+		klog.Errorf("Failed to update status of migration %s: %v", m.Name, err)
+	}
+}
+
+// start validates the migration and records its source
+func (c *Controller) start(ctx context.Context, m *v1alpha1.ArcaMigration) error {
+	namespace := m.Spec.Namespace
+	if _, err := c.backends.Get(m.Spec.TargetBackend); err != nil {
+		return fmt.Errorf("%w: %v", errInvalid, err)
+	}
+
+	svmName := arca.SVMNameForNamespace(namespace)
+	volumes, err := c.listSVMVolumes(svmName)
+	if err != nil {
+		return err
+	}
+
+	// All volumes of an SVM live on one backend; without volumes the
+	// namespace's current route is the source
+	sourceName := c.backends.ForNamespace(namespace).Name
+	for i, v := range volumes {
+		if i == 0 {
+			sourceName = v.Backend
+		} else if v.Backend != sourceName {
+			return fmt.Errorf("%w: volumes of SVM %s are spread across backends %q and %q", errInvalid, svmName, sourceName, v.Backend)
+		}
+	}
+	if sourceName == m.Spec.TargetBackend {
+		return fmt.Errorf("%w: SVM %s is already on backend %q", errInvalid, svmName, sourceName)
+	}
+
+	source, err := c.backends.Get(sourceName)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalid, err)
+	}
+	svm, err := source.SVMManager.GetSVM(ctx, svmName)
+	if err != nil && !errors.Is(err, arca.ErrSVMNotFound) {
+		return fmt.Errorf("failed to get source SVM %s: %w", svmName, err)
+	}
+	if svm != nil {
+		m.Status.SourceVIP = svm.VIP
+	}
+
+	m.Status.SVMName = svmName
+	m.Status.SourceBackend = sourceName
+	m.Status.Volumes = volumeIDs(volumes)
+	m.Status.Phase = v1alpha1.ArcaMigrationPhasePreparing
+	m.Status.Message = fmt.Sprintf("Preparing %d volumes on backend %q", len(volumes), m.Spec.TargetBackend)
+	klog.Infof("Migration %s: moving SVM %s (%d volumes) from backend %q to %q",
+		m.Name, svmName, len(volumes), sourceName, m.Spec.TargetBackend)
+	return nil
+}
+
+// prepare creates the SVM, volume directories and quotas on the target
+func (c *Controller) prepare(ctx context.Context, m *v1alpha1.ArcaMigration) error {
+	target, err := c.backends.Get(m.Spec.TargetBackend)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalid, err)
+	}
+
+	svm, err := target.SVMManager.EnsureSVM(ctx, m.Spec.Namespace)
+	if err != nil {
+		if errors.Is(err, arca.ErrSVMCreationDenied) {
+			return fmt.Errorf("%w: %v", errInvalid, err)
+		}
+		return fmt.Errorf("failed to ensure SVM on target backend: %w", err)
+	}
+	m.Status.TargetVIP = svm.VIP
+
+	sourceExport := ""
+	for _, volumeID := range m.Status.Volumes {
+		info, err := c.store.GetVolume(volumeID)
+		if err != nil {
+			return fmt.Errorf("failed to get volume %s: %w", volumeID, err)
+		}
+		sourceExport = info.ExportPath
+		dirReq := &arca.CreateDirectoryRequest{
+			SVMName:          svm.Name,
+			Path:             info.Path,
+			ProvisioningMode: info.ProvisioningMode,
+		}
+		if info.ProvisioningMode == arca.ProvisioningModeThick {
+			dirReq.QuotaBytes = info.CapacityBytes
+		}
+		err = target.Client.CreateDirectory(ctx, dirReq)
+		if err != nil && !arca.IsAlreadyExistsError(err) {
+			return fmt.Errorf("failed to create directory for volume %s: %w", volumeID, err)
+		}
+		err = target.Client.SetQuota(ctx, &arca.SetQuotaRequest{
+			SVMName:    svm.Name,
+			Path:       info.Path,
+			QuotaBytes: info.CapacityBytes,
+			InodeLimit: info.InodeLimit,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set quota for volume %s: %w", volumeID, err)
+		}
+	}
+
+	m.Status.Phase = v1alpha1.ArcaMigrationPhaseAwaitingCopy
+	m.Status.Message = fmt.Sprintf("Copy data from %s:%s to %s:%s, then set spec.dataCopied (arcactl migrate confirm %s)",
+		m.Status.SourceVIP, arca.ExportPath(c.exportTemplate, m.Status.SVMName, sourceExport),
+		m.Status.TargetVIP, arca.ExportPath(c.exportTemplate, m.Status.SVMName, svm.ExportPath), m.Name)
+	klog.Infof("Migration %s: target SVM ready at %s, awaiting data copy", m.Name, svm.VIP)
+	return nil
+}
+
+// cutover points the volume records at the target backend and VIP
+func (c *Controller) cutover(ctx context.Context, m *v1alpha1.ArcaMigration) error {
+	volumes, err := c.listSVMVolumes(m.Status.SVMName)
+	if err != nil {
+		return err
+	}
+
+	// Volumes provisioned on the source since preparation have no copy on
+	// the target; prepare them and wait for another copy confirmation
+	pending := false
+	for _, v := range volumes {
+		if v.Backend != m.Spec.TargetBackend && !slices.Contains(m.Status.Volumes, v.VolumeID) {
+			pending = true
+			break
+		}
+	}
+	if pending {
+		m.Spec.DataCopied = false
+		if err := c.client.Update(ctx, m); err != nil {
+			return fmt.Errorf("failed to reset data copy confirmation: %w", err)
+		}
+		m.Status.Volumes = volumeIDs(volumes)
+		m.Status.Phase = v1alpha1.ArcaMigrationPhasePreparing
+		m.Status.Message = "New volumes appeared during the migration; preparing them on the target"
+		klog.Warningf("Migration %s: new volumes on SVM %s, returning to preparation", m.Name, m.Status.SVMName)
+		return nil
+	}
+
+	// Nodes mount the target SVM's own export, if its backend reports one
+	target, err := c.backends.Get(m.Spec.TargetBackend)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalid, err)
+	}
+	svm, err := target.SVMManager.GetSVM(ctx, m.Status.SVMName)
+	if err != nil {
+		return fmt.Errorf("failed to get target SVM %s: %w", m.Status.SVMName, err)
+	}
+
+	for _, v := range volumes {
+		if v.Backend == m.Spec.TargetBackend && v.VIP == m.Status.TargetVIP && v.ExportPath == svm.ExportPath {
+			continue
+		}
+		v.Backend = m.Spec.TargetBackend
+		v.VIP = m.Status.TargetVIP
+		v.ExportPath = svm.ExportPath
+		if err := c.store.UpdateVolume(v); err != nil {
+			return fmt.Errorf("failed to update volume %s: %w", v.VolumeID, err)
+		}
+		klog.V(2).Infof("Migration %s: volume %s now served by %s", m.Name, v.VolumeID, v.VIP)
+	}
+
+	if err := c.backends.PinNamespace(m.Spec.Namespace, m.Spec.TargetBackend); err != nil {
+		return fmt.Errorf("%w: %v", errInvalid, err)
+	}
+
+	m.Status.Phase = v1alpha1.ArcaMigrationPhaseCompleted
+	m.Status.Message = fmt.Sprintf("Volume records point at %s; restart pods in namespace %s to remount, then delete SVM %s on backend %q",
+		m.Status.TargetVIP, m.Spec.Namespace, m.Status.SVMName, m.Status.SourceBackend)
+	klog.Infof("Migration %s completed: namespace %s now uses backend %q", m.Name, m.Spec.Namespace, m.Spec.TargetBackend)
+	return nil
+}
+
+// listSVMVolumes returns the volume records of an SVM
+func (c *Controller) listSVMVolumes(svmName string) ([]*store.VolumeInfo, error) {
+	var result []*store.VolumeInfo
+	token := ""
+	for {
+		volumes, next, err := c.store.ListVolumes(token, listPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list volumes: %w", err)
+		}
+		for _, v := range volumes {
+			if v.SVMName == svmName {
+				result = append(result, v)
+			}
+		}
+		if next == "" {
+			return result, nil
+		}
+		token = next
+	}
+}
+
+// volumeIDs returns the IDs of the given volumes
+func volumeIDs(volumes []*store.VolumeInfo) []string {
+	ids := make([]string, 0, len(volumes))
+	for _, v := range volumes {
+		ids = append(ids, v.VolumeID)
+	}
+	return ids
+}
@@ -0,0 +1,112 @@
+package mount
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxActimeo is the longest attribute cache timeout a volume may set, in
+// seconds
+const MaxActimeo = 3600
+
+// Lookup cache modes
+const (
+	LookupCacheAll      = "all"
+	LookupCachePositive = "positive"
+	LookupCacheNone     = "none"
+)
+
+// attributeTimeoutOptions are the NFS options noac overrides
+var attributeTimeoutOptions = map[string]bool{
+	"ac": true, "actimeo": true, "acregmin": true, "acregmax": true, "acdirmin": true, "acdirmax": true,
+}
+
+// AttributeCache are the NFS attribute cache options of a volume, which
+// override those of its mount profile. They trade performance for
+// consistency between clients: noac revalidates attributes on every access
+// and writes synchronously, nocto skips the close-to-open revalidation, and
+// lookupcache controls caching of directory entries.
+type AttributeCache struct {
+	// Actimeo caches file and directory attributes for this many seconds
+	// (nil = profile's)
+	Actimeo *int
+	// NoAC disables the attribute cache
+	NoAC bool
+	// NoCTO disables close-to-open consistency
+	NoCTO bool
+	// LookupCache is all, positive or none ("" = profile's)
+	LookupCache string
+}
+
+// Validate checks the options for values out of range and conflicts
+func (a AttributeCache) Validate() error {
+	if a.Actimeo != nil && (*a.Actimeo < 0 || *a.Actimeo > MaxActimeo) {
+		return fmt.Errorf("actimeo %d is out of range (0-%d seconds)", *a.Actimeo, MaxActimeo)
+	}
+	if a.NoAC && a.Actimeo != nil {
+		return fmt.Errorf("actimeo cannot be combined with noac")
+	}
+	switch a.LookupCache {
+	case "", LookupCacheAll, LookupCachePositive, LookupCacheNone:
+	default:
+		return fmt.Errorf("invalid lookupcache %q (must be %q, %q or %q)", a.LookupCache, LookupCacheAll, LookupCachePositive, LookupCacheNone)
+	}
+	return nil
+}
+
+// options returns the NFS mount options
+func (a AttributeCache) options() []string {
+	var options []string
+	if a.Actimeo != nil {
+		options = append(options, "actimeo="+strconv.Itoa(*a.Actimeo))
+	}
+	if a.NoAC {
+		options = append(options, "noac")
+	}
+	if a.NoCTO {
+		options = append(options, "nocto")
+	}
+	if a.LookupCache != "" {
+		options = append(options, "lookupcache="+a.LookupCache)
+	}
+	return options
+}
+
+// String returns the options in the canonical form recorded for volumes,
+// e.g. "actimeo=3,nocto" ("" = none)
+func (a AttributeCache) String() string {
+	return strings.Join(a.options(), ",")
+}
+
+// ParseAttributeCache parses options recorded by AttributeCache.String,
+// refusing any other mount option
+func ParseAttributeCache(s string) (AttributeCache, error) {
+	var a AttributeCache
+	if s == "" {
+		return a, nil
+	}
+	for _, option := range strings.Split(s, ",") {
+		key, value, hasValue := strings.Cut(option, "=")
+		switch {
+		case key == "actimeo" && hasValue:
+			seconds, err := strconv.Atoi(value)
+			if err != nil {
+				return AttributeCache{}, fmt.Errorf("invalid attribute cache option %q", option)
+			}
+			a.Actimeo = &seconds
+		case key == "noac" && !hasValue:
+			a.NoAC = true
+		case key == "nocto" && !hasValue:
+			a.NoCTO = true
+		case key == "lookupcache" && hasValue:
+			a.LookupCache = value
+		default:
+			return AttributeCache{}, fmt.Errorf("invalid attribute cache option %q", option)
+		}
+	}
+	if err := a.Validate(); err != nil {
+		return AttributeCache{}, err
+	}
+	return a, nil
+}
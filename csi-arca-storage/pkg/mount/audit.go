@@ -0,0 +1,150 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/mount-utils"
+)
+
+// allowedMountBinaries lists the mount helpers the audited mounter may execute
+var allowedMountBinaries = map[string]bool{
+	"/bin/mount":      true,
+	"/sbin/mount":     true,
+	"/usr/bin/mount":  true,
+	"/usr/sbin/mount": true,
+}
+
+// defaultMountBinary is used when no mount binary is configured
+const defaultMountBinary = "/bin/mount"
+
+// AuditedMounter executes mount operations through an allow-listed binary
+// and logs every invocation with its full argument list and outcome.
+// Sensitive mount options are redacted from the log.
+type AuditedMounter struct {
+	mount.Interface
+	binary string
+}
+
+// NewMounter returns the mounter used for all node mount operations.
+// When audit is disabled the default mount-utils mounter is returned, with
+// cancellable mounts (see ContextMounter).
+func NewMounter(audit bool, binary string) (mount.Interface, error) {
+	if !audit {
+		return &execMounter{Interface: mount.New(""), binary: defaultMountBinary}, nil
+	}
+	return NewAuditedMounter(binary)
+}
+
+// NewAuditedMounter creates a new audited mounter using the given mount binary
+func NewAuditedMounter(binary string) (*AuditedMounter, error) {
+	if binary == "" {
+		binary = defaultMountBinary
+	}
+	if err := validateMountBinary(binary); err != nil {
+		return nil, err
+	}
+
+	klog.Infof("Mount audit enabled (binary: %s)", binary)
+
+	return &AuditedMounter{
+		Interface: mount.New(binary),
+		binary:    binary,
+	}, nil
+}
+
+// Mount mounts source to target and records an audit entry
+func (m *AuditedMounter) Mount(source, target, fstype string, options []string) error {
+	start := time.Now()
+	err := m.Interface.Mount(source, target, fstype, options)
+	m.audit("mount", source, target, fstype, options, start, err)
+	return err
+}
+
+// MountContext mounts source to target, cancellably, and records an audit entry
+func (m *AuditedMounter) MountContext(ctx context.Context, source, target, fstype string, options []string) error {
+	start := time.Now()
+	err := execMount(ctx, m.binary, source, target, fstype, options)
+	m.audit("mount", source, target, fstype, options, start, err)
+	return err
+}
+
+// MountSensitive mounts with sensitive options and records a redacted audit entry
+func (m *AuditedMounter) MountSensitive(source, target, fstype string, options, sensitiveOptions []string) error {
+	start := time.Now()
+	err := m.Interface.MountSensitive(source, target, fstype, options, sensitiveOptions)
+	m.audit("mount", source, target, fstype, redactOptions(options, sensitiveOptions), start, err)
+	return err
+}
+
+// MountSensitiveWithoutSystemd mounts without systemd-run and records a redacted audit entry
+func (m *AuditedMounter) MountSensitiveWithoutSystemd(source, target, fstype string, options, sensitiveOptions []string) error {
+	start := time.Now()
+	err := m.Interface.MountSensitiveWithoutSystemd(source, target, fstype, options, sensitiveOptions)
+	m.audit("mount", source, target, fstype, redactOptions(options, sensitiveOptions), start, err)
+	return err
+}
+
+// MountSensitiveWithoutSystemdWithMountFlags mounts with extra flags and records a redacted audit entry
+func (m *AuditedMounter) MountSensitiveWithoutSystemdWithMountFlags(source, target, fstype string, options, sensitiveOptions, mountFlags []string) error {
+	start := time.Now()
+	err := m.Interface.MountSensitiveWithoutSystemdWithMountFlags(source, target, fstype, options, sensitiveOptions, mountFlags)
+	m.audit("mount", source, target, fstype, append(redactOptions(options, sensitiveOptions), mountFlags...), start, err)
+	return err
+}
+
+// Unmount unmounts target and records an audit entry
+func (m *AuditedMounter) Unmount(target string) error {
+	start := time.Now()
+	err := m.Interface.Unmount(target)
+	m.audit("umount", "", target, "", nil, start, err)
+	return err
+}
+
+// audit logs a single mount operation
+func (m *AuditedMounter) audit(op, source, target, fstype string, options []string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	klog.Infof("mount-audit: op=%s binary=%s source=%q target=%q fstype=%q options=%q duration=%s result=%q",
+		op, m.binary, source, target, fstype, strings.Join(options, ","), time.Since(start), result)
+}
+
+// redactOptions returns options with placeholders for sensitive options
+func redactOptions(options, sensitiveOptions []string) []string {
+	result := make([]string, 0, len(options)+len(sensitiveOptions))
+	result = append(result, options...)
+	for range sensitiveOptions {
+		result = append(result, "<redacted>")
+	}
+	return result
+}
+
+// validateMountBinary checks that the binary is allow-listed and safe to execute
+func validateMountBinary(binary string) error {
+	if !filepath.IsAbs(binary) {
+		return fmt.Errorf("mount binary must be an absolute path: %s", binary)
+	}
+	if !allowedMountBinaries[filepath.Clean(binary)] {
+		return fmt.Errorf("mount binary %s is not allow-listed", binary)
+	}
+
+	info, err := os.Stat(binary)
+	if err != nil {
+		return fmt.Errorf("failed to stat mount binary %s: %w", binary, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("mount binary %s is not a regular file", binary)
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("mount binary %s is group- or world-writable", binary)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package mount
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Default SVM mount retry backoff
+const (
+	DefaultMountBackoffInitial = 5 * time.Second
+	DefaultMountBackoffMax     = 5 * time.Minute
+)
+
+// ErrMountBackoff is returned while an SVM mount is backing off after failures
+var ErrMountBackoff = errors.New("SVM mount is backing off")
+
+// ErrMountFailed is wrapped by the errors of failed SVM mounts
+var ErrMountFailed = errors.New("SVM mount failed")
+
+// mountFailure tracks consecutive mount failures for an SVM mount
+type mountFailure struct {
+	attempts  int
+	reason    string
+	nextRetry time.Time
+}
+
+// mountError is a failed SVM mount: its message is the user-facing reason,
+// and it wraps ErrMountFailed and the error of the mount
+type mountError struct {
+	reason string
+	err    error
+}
+
+func (e *mountError) Error() string {
+	return e.reason
+}
+
+func (e *mountError) Unwrap() []error {
+	return []error{ErrMountFailed, e.err}
+}
+
+// checkBackoffLocked returns ErrMountBackoff if the SVM mount must not be retried yet (must hold lock)
+func (m *MountManager) checkBackoffLocked(key string) error {
+	f, exists := m.failures[key]
+	if !exists {
+		return nil
+	}
+
+	if wait := time.Until(f.nextRetry); wait > 0 {
+		return fmt.Errorf("%w: %s (%d failed attempts, next retry in %s)",
+			ErrMountBackoff, f.reason, f.attempts, wait.Round(time.Second))
+	}
+	return nil
+}
+
+// recordFailureLocked records a failed mount attempt and schedules the next retry (must hold lock)
+func (m *MountManager) recordFailureLocked(key, vip string, err error) {
+	f, exists := m.failures[key]
+	if !exists {
+		f = &mountFailure{}
+		m.failures[key] = f
+	}
+	f.attempts++
+	f.reason = mountFailureReason(vip, err)
+
+	backoff := m.backoffInitial << uint(min(f.attempts-1, 30))
+	if backoff <= 0 || backoff > m.backoffMax {
+		backoff = m.backoffMax
+	}
+	f.nextRetry = time.Now().Add(backoff)
+
+	klog.Warningf("Mount of SVM %s failed (attempt %d, retry in %v): %s", key, f.attempts, backoff, f.reason)
+}
+
+// clearFailureLocked resets the backoff after a successful mount (must hold lock)
+func (m *MountManager) clearFailureLocked(key string) {
+	if f, exists := m.failures[key]; exists {
+		klog.Infof("SVM %s mounted after %d failed attempts", key, f.attempts)
+		delete(m.failures, key)
+	}
+}
+
+// mountFailureReason turns a mount error into a short, user-facing reason
+func mountFailureReason(vip string, err error) string {
+	msg := err.Error()
+	lower := strings.ToLower(msg)
+
+	switch {
+	case strings.Contains(lower, "no route to host"),
+		strings.Contains(lower, "network is unreachable"),
+		strings.Contains(lower, "connection timed out"),
+		strings.Contains(lower, "timed out"):
+		return fmt.Sprintf("VIP %s unreachable: %s", vip, msg)
+	case strings.Contains(lower, "connection refused"):
+		return fmt.Sprintf("NFS service on VIP %s refused connection: %s", vip, msg)
+	case strings.Contains(lower, "access denied"),
+		strings.Contains(lower, "permission denied"):
+		return fmt.Sprintf("export access denied by VIP %s: %s", vip, msg)
+	case strings.Contains(lower, "no such file or directory"):
+		return fmt.Sprintf("export not found on VIP %s: %s", vip, msg)
+	}
+	return msg
+}
@@ -0,0 +1,199 @@
+package mount
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// Default canary settings
+const (
+	DefaultCanaryInterval = 30 * time.Second
+	DefaultCanaryTimeout  = 10 * time.Second
+)
+
+// canaryFilePrefix names the file a node writes in the root of each SVM
+// mount, followed by the node ID so nodes do not overwrite each other's
+const canaryFilePrefix = ".arca-canary-"
+
+// Canary periodically writes, reads back and removes a small file on every
+// mounted SVM, exporting whether the SVM answered within the timeout and
+// how long it took. NFS mounts are hard, so a degraded server stalls the
+// syscalls instead of failing them; a probe still stalled when the next one
+// is due is not repeated and keeps the SVM reported unhealthy.
+type Canary struct {
+	manager *MountManager
+	file    string
+	timeout time.Duration
+
+	mu sync.Mutex
+	// stalled holds the SVMs whose last probe has not returned
+	stalled map[string]bool
+	// reported maps SVMs with health metrics to their VIP, so the metrics
+	// of unmounted SVMs can be dropped
+	reported map[string]string
+}
+
+// NewCanary creates a canary for the SVMs mounted by manager on node.
+// Probes slower than timeout (DefaultCanaryTimeout when not positive) mark
+// the SVM unhealthy.
+func NewCanary(manager *MountManager, node string, timeout time.Duration) *Canary {
+	if timeout <= 0 {
+		timeout = DefaultCanaryTimeout
+	}
+	return &Canary{
+		manager:  manager,
+		file:     canaryFilePrefix + node,
+		timeout:  timeout,
+		stalled:  make(map[string]bool),
+		reported: make(map[string]string),
+	}
+}
+
+// Run probes the mounted SVMs every interval until ctx is cancelled
+func (c *Canary) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCanaryInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.probeAll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeAll probes the mounted SVMs concurrently and waits for the results.
+// An SVM mounted for several mount profiles is probed once, through its
+// default mount if it has one, as all mounts share the canary file.
+func (c *Canary) probeAll() {
+	bySVM := make(map[string]SVMMount)
+	for _, svm := range c.manager.Mounts() {
+		if probed, ok := bySVM[svm.SVMName]; !ok || probed.Profile != "" && svm.Profile == "" {
+			bySVM[svm.SVMName] = svm
+		}
+	}
+	mounts := slices.Collect(maps.Values(bySVM))
+
+	var wg sync.WaitGroup
+	for _, svm := range mounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.probeSVM(svm)
+		}()
+	}
+	wg.Wait()
+
+	mounted := make(map[string]string, len(mounts))
+	for _, svm := range mounts {
+		mounted[svm.SVMName] = svm.VIP
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for svmName, vip := range c.reported {
+		if mounted[svmName] != vip {
+			metrics.NodeSVMHealthy.DeleteLabelValues(svmName, vip)
+			metrics.NodeCanaryDuration.DeleteLabelValues(svmName, vip)
+		}
+	}
+	c.reported = mounted
+}
+
+// probeSVM probes one SVM, giving up on it after the timeout
+func (c *Canary) probeSVM(svm SVMMount) {
+	c.mu.Lock()
+	stalled := c.stalled[svm.SVMName]
+	if !stalled {
+		c.stalled[svm.SVMName] = true
+	}
+	c.mu.Unlock()
+	if stalled {
+		klog.V(4).Infof("Canary probe of SVM %s still running, not probing again", svm.SVMName)
+		metrics.NodeSVMHealthy.WithLabelValues(svm.SVMName, svm.VIP).Set(0)
+		return
+	}
+
+	result := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		err := c.probe(svm.MountPath)
+		c.mu.Lock()
+		delete(c.stalled, svm.SVMName)
+		c.mu.Unlock()
+		result <- err
+	}()
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-timer.C:
+		err = fmt.Errorf("no answer within %v", c.timeout)
+	}
+	metrics.NodeCanaryDuration.WithLabelValues(svm.SVMName, svm.VIP).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		klog.Warningf("Canary probe of SVM %s (VIP %s) failed: %v", svm.SVMName, svm.VIP, err)
+		metrics.NodeSVMHealthy.WithLabelValues(svm.SVMName, svm.VIP).Set(0)
+		return
+	}
+	metrics.NodeSVMHealthy.WithLabelValues(svm.SVMName, svm.VIP).Set(1)
+}
+
+// probe writes, syncs, reads back and removes the canary file of an SVM
+// mount
+func (c *Canary) probe(mountPath string) error {
+	path := filepath.Join(mountPath, c.file)
+	data := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+
+	read, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !bytes.Equal(read, data) {
+		return fmt.Errorf("read back %d bytes from %s that differ from those written", len(read), path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
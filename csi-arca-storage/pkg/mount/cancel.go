@@ -0,0 +1,60 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"k8s.io/mount-utils"
+)
+
+// ContextMounter is implemented by mounters whose mounts can be cancelled.
+// An NFS mount of an unreachable server blocks in the mount helper for
+// minutes; cancelling ctx kills it instead of leaving it running.
+type ContextMounter interface {
+	MountContext(ctx context.Context, source, target, fstype string, options []string) error
+}
+
+// execMounter adds cancellable mounts to a mount-utils mounter by running
+// the mount binary itself
+type execMounter struct {
+	mount.Interface
+	binary string
+}
+
+// MountContext runs the mount binary, killing it and the helpers it
+// started when ctx is cancelled
+func (m *execMounter) MountContext(ctx context.Context, source, target, fstype string, options []string) error {
+	return execMount(ctx, m.binary, source, target, fstype, options)
+}
+
+// execMount runs binary in its own process group so that cancelling ctx
+// also kills the fs-specific helper (mount.nfs) it forks
+func execMount(ctx context.Context, binary, source, target, fstype string, options []string) error {
+	args := mount.MakeMountArgs(source, target, fstype, options)
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Errorf("mount of %s on %s cancelled: %w", source, target, context.Cause(ctx))
+	}
+	if err != nil {
+		return fmt.Errorf("mount failed: %w\nMounting command: %s\nMounting arguments: %s\nOutput: %s",
+			err, binary, strings.Join(args, " "), output)
+	}
+	return nil
+}
+
+// mountContext mounts through mounter, cancellably when it supports it
+func mountContext(ctx context.Context, mounter mount.Interface, source, target, fstype string, options []string) error {
+	if cm, ok := mounter.(ContextMounter); ok {
+		return cm.MountContext(ctx, source, target, fstype, options)
+	}
+	return mounter.Mount(source, target, fstype, options)
+}
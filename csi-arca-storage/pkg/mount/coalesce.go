@@ -0,0 +1,136 @@
+package mount
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// PersistMode controls when NodeState changes reach disk
+type PersistMode string
+
+const (
+	// PersistSync writes every change before the mutating call returns
+	PersistSync PersistMode = "sync"
+	// PersistCoalesce groups changes made within the persist delay into a
+	// single write; callers still wait until their change is durable
+	PersistCoalesce PersistMode = "coalesce"
+	// PersistAsync groups changes like PersistCoalesce but returns before
+	// they are durable; pending changes are flushed on shutdown
+	PersistAsync PersistMode = "async"
+)
+
+// DefaultPersistDelay is the coalescing window for PersistCoalesce/PersistAsync
+const DefaultPersistDelay = 20 * time.Millisecond
+
+// persistBatch is a group of changes written together
+type persistBatch struct {
+	entries []journalEntry
+	done    chan struct{}
+	err     error
+}
+
+// coalescer debounces NodeState persistence
+type coalescer struct {
+	mode    PersistMode
+	delay   time.Duration
+	batch   *persistBatch
+	timer   *time.Timer
+	pending bool // a flush is scheduled
+}
+
+// SetPersistMode configures write coalescing for state changes
+func (ns *NodeState) SetPersistMode(mode PersistMode, delay time.Duration) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	switch mode {
+	case "", PersistSync:
+		if ns.coalescer != nil {
+			if err := ns.coalescer.flushLocked(ns); err != nil {
+				return err
+			}
+		}
+		ns.coalescer = nil
+		return nil
+	case PersistCoalesce, PersistAsync:
+	default:
+		return fmt.Errorf("unknown persist mode %q", mode)
+	}
+
+	if delay <= 0 {
+		delay = DefaultPersistDelay
+	}
+	if ns.coalescer == nil {
+		ns.coalescer = &coalescer{batch: newPersistBatch()}
+	}
+	ns.coalescer.mode = mode
+	ns.coalescer.delay = delay
+
+	klog.Infof("Node state persistence mode: %s (delay: %v)", mode, delay)
+	return nil
+}
+
+// Flush writes pending state changes immediately
+func (ns *NodeState) Flush() error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	if ns.coalescer == nil {
+		return nil
+	}
+	return ns.coalescer.flushLocked(ns)
+}
+
+func newPersistBatch() *persistBatch {
+	return &persistBatch{done: make(chan struct{})}
+}
+
+// commitLocked queues an applied change and, unless async, waits until it is
+// durable. The state lock is released while waiting (must hold lock).
+func (c *coalescer) commitLocked(ns *NodeState, entry journalEntry) error {
+	batch := c.batch
+	batch.entries = append(batch.entries, entry)
+
+	if !c.pending {
+		c.pending = true
+		c.timer = time.AfterFunc(c.delay, func() {
+			ns.mu.Lock()
+			defer ns.mu.Unlock()
+			if err := c.flushLocked(ns); err != nil {
+				klog.Errorf("Failed to persist coalesced node state: %v", err)
+			}
+		})
+	}
+
+	if c.mode == PersistAsync {
+		return nil
+	}
+
+	ns.mu.Unlock()
+	<-batch.done
+	ns.mu.Lock()
+
+	return batch.err
+}
+
+// flushLocked writes the current batch and releases its waiters (must hold lock)
+func (c *coalescer) flushLocked(ns *NodeState) error {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.pending = false
+
+	batch := c.batch
+	if len(batch.entries) == 0 {
+		return nil
+	}
+	c.batch = newPersistBatch()
+
+	batch.err = ns.writeLocked(batch.entries)
+	close(batch.done)
+
+	klog.V(5).Infof("Flushed %d coalesced node state changes", len(batch.entries))
+	return batch.err
+}
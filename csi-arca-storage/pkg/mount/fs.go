@@ -0,0 +1,61 @@
+package mount
+
+import (
+	"os"
+	"syscall"
+)
+
+// Filesystem abstracts the filesystem operations used for mount management,
+// allowing node operations to run against a fake in tests
+type Filesystem interface {
+	MkdirAll(path string, perm os.FileMode) error
+	Remove(path string) error
+	Stat(path string) (os.FileInfo, error)
+	Statfs(path string) (*FsStats, error)
+}
+
+// FsStats is the capacity and inode usage of the filesystem holding a path.
+// On ARCA volumes the XFS project quota is reported as the filesystem size.
+type FsStats struct {
+	TotalBytes     int64
+	AvailableBytes int64
+	UsedBytes      int64
+	TotalInodes    int64
+	FreeInodes     int64
+	UsedInodes     int64
+}
+
+// OSFilesystem implements Filesystem using the os package
+type OSFilesystem struct{}
+
+// MkdirAll creates a directory and any missing parents
+func (OSFilesystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// Remove removes a file or empty directory
+func (OSFilesystem) Remove(path string) error {
+	return os.Remove(path)
+}
+
+// Stat returns file info for a path
+func (OSFilesystem) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// Statfs returns capacity and inode usage for a path
+func (OSFilesystem) Statfs(path string) (*FsStats, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return nil, err
+	}
+	bsize := int64(st.Bsize)
+	return &FsStats{
+		TotalBytes:     int64(st.Blocks) * bsize,
+		AvailableBytes: int64(st.Bavail) * bsize,
+		UsedBytes:      int64(st.Blocks-st.Bfree) * bsize,
+		TotalInodes:    int64(st.Files),
+		FreeInodes:     int64(st.Ffree),
+		UsedInodes:     int64(st.Files - st.Ffree),
+	}, nil
+}
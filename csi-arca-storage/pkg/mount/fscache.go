@@ -0,0 +1,188 @@
+package mount
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// DefaultFSCacheDir is the cachefilesd cache directory
+const DefaultFSCacheDir = "/var/cache/fscache"
+
+// DefaultFSCacheStatsInterval is how often cache statistics are collected
+const DefaultFSCacheStatsInterval = 30 * time.Second
+
+// mountStatsPath lists the I/O counters of the NFS mounts visible to the
+// node plugin
+const mountStatsPath = "/proc/self/mountstats"
+
+// reserveFSCacheLocked reports whether a new fsc mount may use the cache.
+// Every SVM mounted with fsc holds one budget of the cache filesystem,
+// shared by its mounts; an SVM without one is admitted while the cache
+// filesystem fits one more. cachefilesd culls the cache as a whole, so the
+// budget bounds how many SVMs compete for it (must hold lock).
+func (m *MountManager) reserveFSCacheLocked(svm SVMMount) bool {
+	key := svm.Key()
+	svms := make(map[string]bool)
+	for _, svmName := range m.fscacheHolders {
+		svms[svmName] = true
+	}
+	if m.fscacheBudget > 0 && !svms[svm.SVMName] {
+		var st unix.Statfs_t
+		if err := unix.Statfs(m.fscacheDir, &st); err != nil {
+			klog.Warningf("Mounting SVM %s without fsc: cannot check cache directory %s: %v", key, m.fscacheDir, err)
+			metrics.NodeFSCacheRejected.WithLabelValues(svm.SVMName).Inc()
+			return false
+		}
+		capacity := int64(st.Blocks) * int64(st.Bsize)
+		if int64(len(svms)+1)*m.fscacheBudget > capacity {
+			klog.Warningf("Mounting SVM %s without fsc: cache directory %s (%d bytes) has no budget of %d bytes left for another SVM (%d hold one)",
+				key, m.fscacheDir, capacity, m.fscacheBudget, len(svms))
+			metrics.NodeFSCacheRejected.WithLabelValues(svm.SVMName).Inc()
+			return false
+		}
+	}
+	m.fscacheHolders[key] = svm.SVMName
+	return true
+}
+
+// FSCacheMonitor exports, per SVM mounted with fsc, the bytes read by
+// applications and from the server and the share of reads served locally,
+// along with the usage of the cache filesystem
+type FSCacheMonitor struct {
+	manager *MountManager
+	// last holds the counters of the previous collection per SVM
+	last map[string]readCounters
+}
+
+// readCounters are the read byte counters of NFS mounts
+type readCounters struct {
+	application uint64 // read by applications (normal and direct reads)
+	server      uint64 // read from the server
+}
+
+// NewFSCacheMonitor creates a monitor for the fsc mounts of manager
+func NewFSCacheMonitor(manager *MountManager) *FSCacheMonitor {
+	return &FSCacheMonitor{manager: manager, last: make(map[string]readCounters)}
+}
+
+// Run collects statistics every interval until ctx is cancelled
+func (f *FSCacheMonitor) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultFSCacheStatsInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		f.collect()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect updates the cache metrics
+func (f *FSCacheMonitor) collect() {
+	paths := make(map[string]string) // mount path -> SVM name
+	svms := make(map[string]bool)
+	for _, svm := range f.manager.Mounts() {
+		if svm.FSCacheActive {
+			paths[svm.MountPath] = svm.SVMName
+			svms[svm.SVMName] = true
+		}
+	}
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(f.manager.fscacheDir, &st); err != nil {
+		klog.V(4).Infof("Cannot read usage of cache directory %s: %v", f.manager.fscacheDir, err)
+	} else {
+		metrics.NodeFSCacheUsedBytes.Set(float64((st.Blocks - st.Bfree) * uint64(st.Bsize)))
+	}
+	metrics.NodeFSCacheReservedBytes.Set(float64(int64(len(svms)) * f.manager.fscacheBudget))
+
+	counters := make(map[string]readCounters)
+	if len(paths) > 0 {
+		file, err := os.Open(mountStatsPath)
+		if err != nil {
+			klog.Warningf("Failed to read NFS mount statistics: %v", err)
+			return
+		}
+		stats, err := parseMountStats(file)
+		file.Close()
+		if err != nil {
+			klog.Warningf("Failed to parse NFS mount statistics: %v", err)
+			return
+		}
+		for path, svmName := range paths {
+			c := counters[svmName]
+			c.application += stats[path].application
+			c.server += stats[path].server
+			counters[svmName] = c
+		}
+	}
+
+	for svmName, c := range counters {
+		metrics.NodeFSCacheReadBytes.WithLabelValues(svmName, "application").Set(float64(c.application))
+		metrics.NodeFSCacheReadBytes.WithLabelValues(svmName, "server").Set(float64(c.server))
+
+		// The ratio covers the reads since the last collection; counters
+		// going backwards belong to remounted SVMs and start over
+		last, ok := f.last[svmName]
+		if ok && c.application > last.application && c.server >= last.server {
+			read := c.application - last.application
+			fetched := min(c.server-last.server, read)
+			metrics.NodeFSCacheHitRatio.WithLabelValues(svmName).Set(1 - float64(fetched)/float64(read))
+		}
+	}
+	for svmName := range f.last {
+		if _, ok := counters[svmName]; !ok {
+			metrics.NodeFSCacheReadBytes.DeleteLabelValues(svmName, "application")
+			metrics.NodeFSCacheReadBytes.DeleteLabelValues(svmName, "server")
+			metrics.NodeFSCacheHitRatio.DeleteLabelValues(svmName)
+		}
+	}
+	f.last = counters
+}
+
+// parseMountStats returns the read counters of the NFS mounts in a
+// mountstats file, keyed by mount path. Per mount, the "bytes:" line holds
+// normal, direct and server read and write byte counts.
+func parseMountStats(r io.Reader) (map[string]readCounters, error) {
+	stats := make(map[string]readCounters)
+	path := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) >= 5 && fields[0] == "device" && fields[2] == "mounted" && fields[3] == "on":
+			path = fields[4]
+		case len(fields) >= 7 && fields[0] == "bytes:" && path != "":
+			var values [6]uint64
+			for i := range values {
+				v, err := strconv.ParseUint(fields[i+1], 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				values[i] = v
+			}
+			// normalreadbytes normalwritebytes directreadbytes
+			// directwritebytes serverreadbytes serverwritebytes
+			stats[path] = readCounters{application: values[0] + values[2], server: values[4]}
+		}
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,293 @@
+package mount
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// DefaultJournalCompactionThreshold is the number of journal entries after
+// which the full state is rewritten and the journal truncated
+const DefaultJournalCompactionThreshold = 1000
+
+// journalOp is a NodeState mutation recorded in the journal
+type journalOp string
+
+const (
+	journalOpStage     journalOp = "stage"
+	journalOpUnstage   journalOp = "unstage"
+	journalOpPublish   journalOp = "publish"
+	journalOpUnpublish journalOp = "unpublish"
+)
+
+// journalEntry is a single append-only journal record (one JSON line).
+// Entries are idempotent so replaying them on top of a snapshot that
+// already contains them yields the same state.
+type journalEntry struct {
+	Op             journalOp `json:"op"`
+	VolumeID       string    `json:"volume_id"`
+	SVMName        string    `json:"svm_name,omitempty"`
+	VIP            string    `json:"vip,omitempty"`
+	Host           string    `json:"host,omitempty"`
+	ExportPath     string    `json:"export_path,omitempty"`
+	Profile        string    `json:"profile,omitempty"`
+	AttributeCache string    `json:"attribute_cache,omitempty"`
+	FSCache        bool      `json:"fscache,omitempty"`
+	SELinuxContext string    `json:"selinux_context,omitempty"`
+	StagingPath    string    `json:"staging_path,omitempty"`
+	TargetPath     string    `json:"target_path,omitempty"`
+	Pod            *PodInfo  `json:"pod,omitempty"`
+}
+
+// stateJournal is an append-only log of mutations since the last snapshot
+type stateJournal struct {
+	f         *os.File
+	entries   int
+	threshold int
+	stale     bool // journal is missing applied changes until next compaction
+}
+
+// journalPath returns the journal file path for the state file
+func (ns *NodeState) journalPath() string {
+	return ns.stateFilePath + ".journal"
+}
+
+// EnableJournal switches persistence to an append-only journal with periodic
+// compaction. Each mutation appends and fsyncs a single record instead of
+// rewriting the full state file.
+func (ns *NodeState) EnableJournal(threshold int) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	if ns.journal != nil {
+		return nil
+	}
+	if threshold <= 0 {
+		threshold = DefaultJournalCompactionThreshold
+	}
+
+	f, err := os.OpenFile(ns.journalPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open state journal: %w", err)
+	}
+	ns.journal = &stateJournal{f: f, threshold: threshold}
+
+	klog.Infof("Node state journal enabled (compaction threshold: %d)", threshold)
+	return nil
+}
+
+// Close flushes pending changes and releases the journal file
+func (ns *NodeState) Close() error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	var err error
+	if ns.coalescer != nil {
+		err = ns.coalescer.flushLocked(ns)
+	}
+
+	if ns.journal == nil {
+		return err
+	}
+	if closeErr := ns.journal.f.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	ns.journal = nil
+	return err
+}
+
+// commitLocked makes an applied mutation durable (must hold lock)
+func (ns *NodeState) commitLocked(entry journalEntry) error {
+	if ns.coalescer != nil {
+		return ns.coalescer.commitLocked(ns, entry)
+	}
+	return ns.writeLocked([]journalEntry{entry})
+}
+
+// writeLocked persists applied mutations via the journal or a full snapshot (must hold lock)
+func (ns *NodeState) writeLocked(entries []journalEntry) error {
+	if ns.journal == nil {
+		return ns.persistLocked()
+	}
+
+	// After a failed write the journal no longer matches memory
+	if ns.journal.stale {
+		return ns.compactLocked()
+	}
+
+	if err := ns.appendJournalLocked(entries); err != nil {
+		// A failed append may leave a torn record; a full snapshot makes the
+		// journal redundant so it can be truncated safely
+		klog.Warningf("Failed to append to state journal, writing full snapshot: %v", err)
+		return ns.compactLocked()
+	}
+
+	if ns.journal.entries >= ns.journal.threshold {
+		if err := ns.compactLocked(); err != nil {
+			// The journal still holds the mutations; compaction is retried later
+			klog.Warningf("Failed to compact state journal: %v", err)
+		}
+	}
+	return nil
+}
+
+// appendJournalLocked appends records with a single fsync (must hold lock)
+func (ns *NodeState) appendJournalLocked(entries []journalEntry) error {
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal journal entry: %w", err)
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+
+	if _, err := ns.journal.f.Write(data); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	if err := ns.journal.f.Sync(); err != nil {
+		return fmt.Errorf("failed to fsync journal: %w", err)
+	}
+
+	ns.journal.entries += len(entries)
+	metrics.NodeStateJournalEntries.Set(float64(ns.journal.entries))
+	return nil
+}
+
+// compactLocked writes a full snapshot and truncates the journal (must hold lock)
+func (ns *NodeState) compactLocked() error {
+	if err := ns.persistLocked(); err != nil {
+		if ns.journal != nil {
+			ns.journal.stale = true
+		}
+		return err
+	}
+	if ns.journal == nil {
+		return nil
+	}
+	ns.journal.stale = false
+
+	if err := ns.journal.f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate journal: %w", err)
+	}
+	if err := ns.journal.f.Sync(); err != nil {
+		return fmt.Errorf("failed to fsync journal: %w", err)
+	}
+
+	klog.V(4).Infof("Compacted node state journal (%d entries)", ns.journal.entries)
+	ns.journal.entries = 0
+	metrics.NodeStateJournalEntries.Set(0)
+	return nil
+}
+
+// replayJournal applies journal records written after the last snapshot.
+// A torn trailing record (crash during append) is ignored.
+func (ns *NodeState) replayJournal() (int, error) {
+	f, err := os.Open(ns.journalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to open state journal: %w", err)
+	}
+	defer f.Close()
+
+	replayed := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			klog.Warningf("Ignoring torn state journal record after %d entries: %v", replayed, err)
+			break
+		}
+		ns.applyLocked(entry)
+		replayed++
+	}
+	if err := scanner.Err(); err != nil {
+		return replayed, fmt.Errorf("failed to read state journal: %w", err)
+	}
+
+	return replayed, nil
+}
+
+// applyLocked applies a mutation to the in-memory state (must hold lock)
+func (ns *NodeState) applyLocked(entry journalEntry) {
+	switch entry.Op {
+	case journalOpStage:
+		if old, exists := ns.data.Volumes[entry.VolumeID]; exists {
+			ns.unrefSVMLocked(old.mount().Key())
+			deletePodMetrics(old)
+		}
+		staging := &VolumeStaging{
+			VolumeID:       entry.VolumeID,
+			SVMName:        entry.SVMName,
+			VIP:            entry.VIP,
+			Host:           entry.Host,
+			ExportPath:     entry.ExportPath,
+			Profile:        entry.Profile,
+			AttributeCache: entry.AttributeCache,
+			FSCache:        entry.FSCache,
+			SELinuxContext: entry.SELinuxContext,
+			StagingPath:    entry.StagingPath,
+		}
+		ns.data.Volumes[entry.VolumeID] = staging
+		ns.svmRefs[staging.mount().Key()]++
+
+	case journalOpUnstage:
+		if old, exists := ns.data.Volumes[entry.VolumeID]; exists {
+			ns.unrefSVMLocked(old.mount().Key())
+			delete(ns.data.Volumes, entry.VolumeID)
+			deletePodMetrics(old)
+		}
+
+	case journalOpPublish:
+		staging, exists := ns.data.Volumes[entry.VolumeID]
+		if !exists {
+			return
+		}
+		if entry.Pod != nil {
+			if staging.Pods == nil {
+				staging.Pods = make(map[string]PodInfo)
+			}
+			old, replaced := staging.Pods[entry.TargetPath]
+			staging.Pods[entry.TargetPath] = *entry.Pod
+			if replaced {
+				deletePodMetric(staging, old)
+			}
+			setPodMetric(staging, *entry.Pod)
+		}
+		for _, path := range staging.PublishedPaths {
+			if path == entry.TargetPath {
+				return
+			}
+		}
+		staging.PublishedPaths = append(staging.PublishedPaths, entry.TargetPath)
+
+	case journalOpUnpublish:
+		staging, exists := ns.data.Volumes[entry.VolumeID]
+		if !exists {
+			return
+		}
+		newPaths := make([]string, 0, len(staging.PublishedPaths))
+		for _, path := range staging.PublishedPaths {
+			if path != entry.TargetPath {
+				newPaths = append(newPaths, path)
+			}
+		}
+		staging.PublishedPaths = newPaths
+		if pod, ok := staging.Pods[entry.TargetPath]; ok {
+			delete(staging.Pods, entry.TargetPath)
+			deletePodMetric(staging, pod)
+		}
+
+	default:
+		klog.Warningf("Ignoring unknown state journal operation %q", entry.Op)
+	}
+}
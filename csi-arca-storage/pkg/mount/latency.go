@@ -0,0 +1,26 @@
+package mount
+
+import (
+	"time"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// Mount syscalls timed in arca_csi_node_mount_duration_seconds
+const (
+	OpNFSMount    = "nfs_mount"
+	OpNFSUnmount  = "nfs_unmount"
+	OpBindMount   = "bind_mount"
+	OpBindUnmount = "bind_unmount"
+)
+
+// ObserveMount records the duration of a mount syscall of an SVM, started
+// at start, that returned err. svm and vip are "" when unknown, e.g. when
+// unstaging a volume the node state lost.
+func ObserveMount(operation, svm, vip string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.NodeMountDuration.WithLabelValues(operation, svm, vip, result).Observe(time.Since(start).Seconds())
+}
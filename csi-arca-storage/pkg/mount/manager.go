@@ -2,240 +2,690 @@ package mount
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
+	"golang.org/x/sync/singleflight"
 	"k8s.io/klog/v2"
 	"k8s.io/mount-utils"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
 )
 
 // SVMMount represents an SVM mount point
 type SVMMount struct {
-	SVMName   string
-	VIP       string
+	SVMName string
+	VIP     string
+	// Host is the DNS name of the SVM, resolved whenever the SVM is
+	// mounted; VIP is mounted when it does not resolve ("" = VIP)
+	Host      string
 	MountPath string
+	// ExportPath is the NFS export reported by the backend ("" = template)
+	ExportPath string
+	// Profile is the mount profile of the volumes using the mount
+	// ("" = general)
+	Profile string
+	// AttributeCache are the attribute cache options of the volumes using
+	// the mount, as returned by AttributeCache.String ("" = profile's)
+	AttributeCache string
+	// FSCache requests a mount caching file data on local disk (fsc)
+	FSCache bool
+	// SELinuxContext is the "context=" option labelling every file of the
+	// mount with the SELinux context of the pods using it ("" = none)
+	SELinuxContext string
+	// FSCacheActive is set on mounts made with fsc, which FSCache mounts
+	// are not when the cache has no budget left for the SVM
+	FSCacheActive bool
 }
 
-// MountManager manages per-SVM NFS mounts with NodeState-derived refcounting
+// Key identifies the mount: the SVM name for the shared default mount, or
+// the SVM name and profile for the separate mount of a profile. Attribute
+// cache options and an SELinux context add a hash of the options, keeping
+// the key a short path element, and FSCache a -fsc suffix.
+func (s SVMMount) Key() string {
+	if s.Profile == "" && s.AttributeCache == "" && !s.FSCache && s.SELinuxContext == "" {
+		return s.SVMName
+	}
+	variant := s.Profile
+	if variant == "" {
+		variant = ProfileGeneral
+	}
+	if s.AttributeCache != "" {
+		sum := sha256.Sum256([]byte(s.AttributeCache))
+		variant += "-" + hex.EncodeToString(sum[:4])
+	}
+	if s.SELinuxContext != "" {
+		sum := sha256.Sum256([]byte(s.SELinuxContext))
+		variant += "-se" + hex.EncodeToString(sum[:4])
+	}
+	if s.FSCache {
+		variant += "-fsc"
+	}
+	return s.SVMName + "@" + variant
+}
+
+// Default reconcile settings
+const (
+	DefaultReconcileWorkers = 8
+	DefaultReconcileTimeout = 2 * time.Minute
+)
+
+// MountManagerConfig holds mount manager settings
+type MountManagerConfig struct {
+	// BaseMountPath is the base path for SVM mounts
+	BaseMountPath string
+	// Mounter performs mount operations (default mount-utils mounter)
+	Mounter mount.Interface
+	// Filesystem performs directory operations (default OSFilesystem)
+	Filesystem Filesystem
+	// ReconcileWorkers bounds concurrent SVM mounts during startup reconcile
+	ReconcileWorkers int
+	// ReconcileTimeout bounds the time spent restoring a single SVM mount
+	ReconcileTimeout time.Duration
+	// MountBackoffInitial/MountBackoffMax bound retry delays after failed mounts
+	MountBackoffInitial time.Duration
+	MountBackoffMax     time.Duration
+	// UnmountLinger keeps unused SVM mounts for this long before unmounting
+	UnmountLinger time.Duration
+	// ExportPathTemplate is the NFS export of SVMs without a reported one
+	// (default arca.DefaultExportPathTemplate)
+	ExportPathTemplate string
+	// ApplyProfileSysctls applies the sysctls suggested by mount profiles
+	// instead of only logging them
+	ApplyProfileSysctls bool
+	// FSCacheDir is the cachefilesd cache directory (default
+	// DefaultFSCacheDir)
+	FSCacheDir string
+	// FSCacheBudget is the share of the cache filesystem reserved for each
+	// SVM mounted with fsc (0 = unlimited)
+	FSCacheBudget int64
+	// Resolver resolves the DNS names of SVMs (default a HostResolver
+	// with the default TTL)
+	Resolver *HostResolver
+}
+
+// ReconcileStatus reports the progress and outcome of the startup reconcile
+type ReconcileStatus struct {
+	Done     bool // The reconcile has finished
+	Total    int
+	Restored int
+	Failed   []string // Keys of the SVM mounts that could not be restored
+}
+
+// MountManager manages per-SVM NFS mounts with NodeState-derived refcounting.
+// Mounts are keyed by SVMMount.Key, so an SVM has one mount per profile in use.
 type MountManager struct {
-	mounts      map[string]*SVMMount // svmName -> mount info (in-memory only)
-	nodeState   *NodeState           // Reference to NodeState for refcount derivation
-	baseMountPath string              // Base path for SVM mounts
-	mounter     mount.Interface
-	mu          sync.Mutex
+	mounts         map[string]*SVMMount // mount key -> mount info (in-memory only)
+	nodeState      *NodeState           // Reference to NodeState for refcount derivation
+	baseMountPath  string               // Base path for SVM mounts
+	exportTemplate string               // NFS export path template
+	resolver       *HostResolver        // Resolves SVM DNS names
+	mounter        mount.Interface
+	fs             Filesystem
+	mu             sync.Mutex
+
+	reconcileWorkers int
+	reconcileTimeout time.Duration
+	reconcileStatus  ReconcileStatus
+
+	// Per-SVM mount failure tracking for retry backoff
+	failures       map[string]*mountFailure
+	backoffInitial time.Duration
+	backoffMax     time.Duration
+
+	// In-flight SVM mounts, keyed by mount key
+	inflight singleflight.Group
+
+	// Delayed unmounts of unused SVMs; none are scheduled once closed
+	linger          time.Duration
+	pendingUnmounts map[string]*time.Timer
+	closed          bool
+
+	// Mount profile sysctls: applied or only suggested, once per profile
+	applySysctls  bool
+	tunedProfiles map[string]bool
+
+	// fsc mounts hold a cache budget for their SVM, keyed by mount key
+	fscacheDir     string
+	fscacheBudget  int64
+	fscacheHolders map[string]string // mount key -> SVM name
 }
 
 // NewMountManager creates a new mount manager with NodeState reference
-func NewMountManager(nodeState *NodeState, baseMountPath string) (*MountManager, error) {
+func NewMountManager(nodeState *NodeState, cfg *MountManagerConfig) (*MountManager, error) {
+	if cfg == nil {
+		cfg = &MountManagerConfig{}
+	}
+	mounter := cfg.Mounter
+	if mounter == nil {
+		mounter = mount.New("")
+	}
+	fs := cfg.Filesystem
+	if fs == nil {
+		fs = OSFilesystem{}
+	}
+	baseMountPath := cfg.BaseMountPath
 	if baseMountPath == "" {
 		baseMountPath = "/var/lib/kubelet/plugins/csi.arca-storage.io/mounts"
 	}
+	workers := cfg.ReconcileWorkers
+	if workers <= 0 {
+		workers = DefaultReconcileWorkers
+	}
+	timeout := cfg.ReconcileTimeout
+	if timeout <= 0 {
+		timeout = DefaultReconcileTimeout
+	}
+
+	backoffInitial := cfg.MountBackoffInitial
+	if backoffInitial <= 0 {
+		backoffInitial = DefaultMountBackoffInitial
+	}
+	backoffMax := cfg.MountBackoffMax
+	if backoffMax < backoffInitial {
+		backoffMax = max(DefaultMountBackoffMax, backoffInitial)
+	}
 
 	// Ensure base mount directory exists
-	if err := os.MkdirAll(baseMountPath, 0750); err != nil {
+	if err := fs.MkdirAll(baseMountPath, 0750); err != nil {
 		return nil, fmt.Errorf("failed to create base mount directory: %w", err)
 	}
 
 	mgr := &MountManager{
-		mounts:        make(map[string]*SVMMount),
-		nodeState:     nodeState,
-		baseMountPath: baseMountPath,
-		mounter:       mount.New(""),
+		mounts:           make(map[string]*SVMMount),
+		nodeState:        nodeState,
+		baseMountPath:    baseMountPath,
+		exportTemplate:   cfg.ExportPathTemplate,
+		resolver:         cfg.Resolver,
+		mounter:          mounter,
+		fs:               fs,
+		reconcileWorkers: workers,
+		reconcileTimeout: timeout,
+		failures:         make(map[string]*mountFailure),
+		backoffInitial:   backoffInitial,
+		backoffMax:       backoffMax,
+		linger:           cfg.UnmountLinger,
+		pendingUnmounts:  make(map[string]*time.Timer),
+		applySysctls:     cfg.ApplyProfileSysctls,
+		tunedProfiles:    make(map[string]bool),
+		fscacheDir:       cfg.FSCacheDir,
+		fscacheBudget:    cfg.FSCacheBudget,
+		fscacheHolders:   make(map[string]string),
 	}
-
-	// Reconcile mounts from NodeState on startup
-	if err := mgr.reconcile(); err != nil {
-		return nil, fmt.Errorf("failed to reconcile mounts: %w", err)
+	if mgr.fscacheDir == "" {
+		mgr.fscacheDir = DefaultFSCacheDir
+	}
+	if mgr.resolver == nil {
+		mgr.resolver = NewHostResolver(0)
 	}
 
 	return mgr, nil
 }
 
-// reconcile restores mounts based on NodeState (single source of truth)
-func (m *MountManager) reconcile() error {
+// Reconcile restores mounts based on NodeState (single source of truth).
+// It is run once at startup, in the background: ReconcileStatus reports
+// its progress, and the node plugin is not ready until it is done. SVMs are
+// mounted concurrently by a bounded worker pool; mounts that fail or exceed
+// the per-SVM timeout are cancelled, reported and remounted on the next
+// NodeStage.
+func (m *MountManager) Reconcile(ctx context.Context) {
+	// Get unique SVMs from NodeState
+	svms := m.nodeState.GetUniqueSVMs()
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.reconcileStatus = ReconcileStatus{Total: len(svms)}
+	m.mu.Unlock()
 
-	klog.Info("Reconciling SVM mounts from node state")
+	klog.Infof("Reconciling %d SVM mounts from node state (workers: %d)", len(svms), m.reconcileWorkers)
 
-	// Get unique SVMs from NodeState
-	svms := m.nodeState.GetUniqueSVMs()
+	type result struct {
+		key string
+		err error
+	}
 
-	for svmName, vip := range svms {
-		mountPath := m.getMountPath(svmName)
+	jobs := make(chan string)
+	results := make(chan result, len(svms))
 
-		// Check if already mounted
-		isMounted, err := m.isMountPoint(mountPath)
-		if err != nil {
-			klog.Warningf("Failed to check mount point %s: %v", mountPath, err)
+	var wg sync.WaitGroup
+	for i := 0; i < m.reconcileWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				results <- result{key: key, err: m.reconcileSVM(ctx, svms[key])}
+			}
+		}()
+	}
+
+	for key := range svms {
+		jobs <- key
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+
+	status := ReconcileStatus{Done: true, Total: len(svms)}
+	for r := range results {
+		if r.err != nil {
+			klog.Errorf("Failed to restore mount for SVM %s: %v", r.key, r.err)
+			status.Failed = append(status.Failed, r.key)
 			continue
 		}
+		status.Restored++
+	}
 
-		if !isMounted {
-			// Mount is missing - restore it
-			klog.Infof("Restoring missing mount for SVM %s (VIP: %s)", svmName, vip)
-			if err := m.mountSVMLocked(svmName, vip); err != nil {
-				klog.Errorf("Failed to restore mount for SVM %s: %v", svmName, err)
-				// Continue with other SVMs
-				continue
-			}
-		} else {
-			// Mount exists - record it
-			m.mounts[svmName] = &SVMMount{
-				SVMName:   svmName,
-				VIP:       vip,
-				MountPath: mountPath,
-			}
-			klog.V(4).Infof("Found existing mount for SVM %s at %s", svmName, mountPath)
+	m.mu.Lock()
+	m.reconcileStatus = status
+	m.mu.Unlock()
+
+	if len(status.Failed) > 0 {
+		klog.Warningf("Reconciliation partially complete: %d/%d SVM mounts restored, failed: %v",
+			status.Restored, status.Total, status.Failed)
+	} else {
+		klog.Infof("Reconciliation complete: %d SVM mounts restored", status.Restored)
+	}
+}
+
+// reconcileSVM restores a single SVM mount within the reconcile timeout
+func (m *MountManager) reconcileSVM(ctx context.Context, svm SVMMount) error {
+	key, vip := svm.Key(), svm.VIP
+	mountPath := m.getMountPath(key)
+
+	// Check if already mounted
+	isMounted, err := m.isMountPoint(mountPath)
+	if err != nil {
+		return fmt.Errorf("failed to check mount point %s: %w", mountPath, err)
+	}
+
+	if isMounted {
+		// Mount exists - record it; an fsc mount is taken to have been made
+		// with the cache, whose budget it holds again
+		if svm.FSCache {
+			m.mu.Lock()
+			m.fscacheHolders[key] = svm.SVMName
+			m.mu.Unlock()
+			svm.FSCacheActive = true
 		}
+		m.recordMount(svm)
+		klog.V(4).Infof("Found existing mount for SVM %s at %s", key, mountPath)
+		return nil
 	}
 
-	klog.Infof("Reconciliation complete: %d SVM mounts restored", len(m.mounts))
-	return nil
+	// Mount is missing - restore it. The mount syscall can block on an
+	// unreachable server, so it is bounded by the reconcile timeout, which
+	// cancels it.
+	klog.Infof("Restoring missing mount for SVM %s (VIP: %s)", key, vip)
+	ctx, cancel := context.WithTimeout(ctx, m.reconcileTimeout)
+	defer cancel()
+	ch := m.inflight.DoChan(key, func() (interface{}, error) {
+		return m.mountShared(ctx, svm)
+	})
+
+	select {
+	case res := <-ch:
+		return res.Err
+	case <-ctx.Done():
+		err := fmt.Errorf("timed out after %v", m.reconcileTimeout)
+		if _, ok := m.mounter.(ContextMounter); !ok {
+			// The mount cannot be cancelled and keeps running; back off
+			// until it completes. Cancelled mounts record their own failure.
+			m.mu.Lock()
+			m.recordFailureLocked(key, vip, err)
+			m.mu.Unlock()
+		}
+		return err
+	}
 }
 
-// EnsureSVMMount ensures an SVM is mounted (creates mount if needed)
-func (m *MountManager) EnsureSVMMount(ctx context.Context, svmName, vip string) (string, error) {
+// ReconcileStatus returns the progress and outcome of the startup reconcile
+func (m *MountManager) ReconcileStatus() ReconcileStatus {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Check if already mounted
-	if mount, exists := m.mounts[svmName]; exists {
-		// Verify the mount actually exists
-		isMounted, err := m.isMountPoint(mount.MountPath)
-		if err != nil {
-			return "", fmt.Errorf("failed to check mount point: %w", err)
+	status := m.reconcileStatus
+	status.Failed = append([]string(nil), m.reconcileStatus.Failed...)
+	return status
+}
+
+// EnsureSVMMount ensures an SVM is mounted with the mount profile of svm
+// (creates mount if needed). Concurrent callers for the same mount share a
+// single in-flight mount, so only one NFS mount syscall is issued per mount.
+// svm.ExportPath is the export reported by the backend, or empty for the
+// export path template.
+func (m *MountManager) EnsureSVMMount(ctx context.Context, svm SVMMount) (string, error) {
+	key := svm.Key()
+	m.mu.Lock()
+
+	// The SVM is in use again; keep a lingering mount
+	m.cancelUnmountLocked(key)
+
+	mountPath, mounted, err := m.mountedPathLocked(key)
+	if err != nil || mounted {
+		m.mu.Unlock()
+		return mountPath, err
+	}
+	if err := m.checkBackoffLocked(key); err != nil {
+		m.mu.Unlock()
+		return "", err
+	}
+	m.mu.Unlock()
+
+	// Mount doesn't exist - create it (or join an in-flight mount). The
+	// mount is shared by every caller, so none of their contexts cancels it.
+	ch := m.inflight.DoChan(key, func() (interface{}, error) {
+		return m.mountShared(context.Background(), SVMMount{
+			SVMName:        svm.SVMName,
+			VIP:            svm.VIP,
+			Host:           svm.Host,
+			ExportPath:     svm.ExportPath,
+			Profile:        svm.Profile,
+			AttributeCache: svm.AttributeCache,
+			FSCache:        svm.FSCache,
+			SELinuxContext: svm.SELinuxContext,
+		})
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Err
 		}
-		if isMounted {
-			klog.V(4).Infof("SVM %s already mounted at %s", svmName, mount.MountPath)
-			return mount.MountPath, nil
+		if res.Shared {
+			klog.V(4).Infof("Joined in-flight mount of SVM %s", key)
 		}
+		return res.Val.(string), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
 
-		// Mount record exists but actual mount is gone - need to remount
-		klog.Warningf("SVM %s mount record exists but mount is gone, remounting", svmName)
-		delete(m.mounts, svmName)
+// mountedPathLocked returns the mount path if the mount is tracked and still
+// mounted; stale records are dropped (must hold lock)
+func (m *MountManager) mountedPathLocked(key string) (string, bool, error) {
+	mount, exists := m.mounts[key]
+	if !exists {
+		return "", false, nil
 	}
 
-	// Mount doesn't exist - create it
-	return m.ensureSVMMountLocked(svmName, vip)
+	// Verify the mount actually exists
+	isMounted, err := m.isMountPoint(mount.MountPath)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to check mount point: %w", err)
+	}
+	if isMounted {
+		klog.V(4).Infof("SVM %s already mounted at %s", key, mount.MountPath)
+		return mount.MountPath, true, nil
+	}
+
+	// Mount record exists but actual mount is gone - need to remount
+	klog.Warningf("SVM %s mount record exists but mount is gone, remounting", key)
+	delete(m.mounts, key)
+	return "", false, nil
 }
 
-// ensureSVMMountLocked mounts an SVM (must hold lock)
-func (m *MountManager) ensureSVMMountLocked(svmName, vip string) (string, error) {
-	if err := m.mountSVMLocked(svmName, vip); err != nil {
-		return "", err
+// mountShared performs a deduplicated SVM mount (run via the in-flight group)
+func (m *MountManager) mountShared(ctx context.Context, svm SVMMount) (string, error) {
+	key := svm.Key()
+
+	// A previous flight may have completed after the caller's check
+	m.mu.Lock()
+	mountPath, mounted, err := m.mountedPathLocked(key)
+	m.mu.Unlock()
+	if err != nil || mounted {
+		return mountPath, err
+	}
+
+	if svm.FSCache {
+		m.mu.Lock()
+		svm.FSCacheActive = m.reserveFSCacheLocked(svm)
+		m.mu.Unlock()
+	}
+
+	err = m.mountSVM(ctx, svm)
+	if err == nil {
+		m.applyProfile(svm, m.getMountPath(key))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		delete(m.fscacheHolders, key)
+		m.recordFailureLocked(key, svm.VIP, err)
+		return "", &mountError{reason: m.failures[key].reason, err: err}
 	}
 
-	return m.getMountPath(svmName), nil
+	svm.MountPath = m.getMountPath(key)
+	m.mounts[key] = &svm
+	m.clearFailureLocked(key)
+
+	return m.getMountPath(key), nil
 }
 
-// mountSVMLocked performs the actual NFS mount (must hold lock)
-func (m *MountManager) mountSVMLocked(svmName, vip string) error {
-	mountPath := m.getMountPath(svmName)
+// mountSVM performs the NFS mount syscall without touching tracked mounts;
+// cancelling ctx cancels mounts of a ContextMounter
+func (m *MountManager) mountSVM(ctx context.Context, svm SVMMount) error {
+	svmName := svm.SVMName
+	mountPath := m.getMountPath(svm.Key())
 
 	// Create mount point directory
-	if err := os.MkdirAll(mountPath, 0750); err != nil {
+	if err := m.fs.MkdirAll(mountPath, 0750); err != nil {
 		return fmt.Errorf("failed to create mount point: %w", err)
 	}
 
 	// NFS mount options
-	nfsSource := fmt.Sprintf("%s:/exports/%s", vip, svmName)
-	options := []string{
-		"vers=4.2",
-		"rsize=1048576",
-		"wsize=1048576",
-		"hard",
-		"timeo=600",
-		"retrans=2",
-		"noresvport",
-	}
+	nfsSource := m.address(ctx, svm) + ":" + arca.ExportPath(m.exportTemplate, svmName, svm.ExportPath)
+	options := nfsOptions(svm)
 
-	klog.Infof("Mounting NFS: %s -> %s", nfsSource, mountPath)
+	klog.Infof("Mounting NFS: %s -> %s (options: %s)", nfsSource, mountPath, strings.Join(options, ","))
 
 	// Perform NFS mount
-	if err := m.mounter.Mount(nfsSource, mountPath, "nfs4", options); err != nil {
+	start := time.Now()
+	err := mountContext(ctx, m.mounter, nfsSource, mountPath, "nfs4", options)
+	ObserveMount(OpNFSMount, svmName, svm.VIP, start, err)
+	if err != nil {
 		return fmt.Errorf("failed to mount NFS: %w", err)
 	}
 
-	// Record mount
-	m.mounts[svmName] = &SVMMount{
-		SVMName:   svmName,
-		VIP:       vip,
-		MountPath: mountPath,
+	klog.Infof("Successfully mounted SVM %s at %s", svm.Key(), mountPath)
+	return nil
+}
+
+// address returns the address to mount an SVM from: its DNS name resolved
+// now, so that a remount follows changes of the name, or its VIP when it
+// has no name or the name does not resolve
+func (m *MountManager) address(ctx context.Context, svm SVMMount) string {
+	if svm.Host == "" {
+		return svm.VIP
+	}
+	addr, err := m.resolver.Resolve(ctx, svm.Host)
+	if err != nil {
+		klog.Warningf("Failed to resolve SVM host %s, falling back to VIP %s: %v", svm.Host, svm.VIP, err)
+		return svm.VIP
 	}
+	return addr
+}
 
-	klog.Infof("Successfully mounted SVM %s at %s", svmName, mountPath)
-	return nil
+// recordMount tracks a mounted SVM
+func (m *MountManager) recordMount(svm SVMMount) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := svm.Key()
+	m.clearFailureLocked(key)
+
+	svm.MountPath = m.getMountPath(key)
+	m.mounts[key] = &svm
 }
 
-// ShouldUnmountSVM checks if an SVM should be unmounted (refcount == 0)
-// Refcount is derived from NodeState, not stored
-func (m *MountManager) ShouldUnmountSVM(ctx context.Context, svmName string) (bool, error) {
+// ShouldUnmountSVM checks if the SVM mount with the given key should be
+// unmounted now (refcount == 0).
+// Refcount is derived from NodeState, not stored. With a linger period, a
+// healthy unused mount is kept and a delayed unmount is scheduled instead;
+// unhealthy (e.g. stale) mounts are always released immediately.
+func (m *MountManager) ShouldUnmountSVM(ctx context.Context, key string) (bool, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Derive refcount from NodeState
-	refcount := m.nodeState.CountStagedVolumesForSVM(svmName)
+	refcount := m.nodeState.CountStagedVolumesForSVM(key)
+
+	klog.V(4).Infof("SVM %s refcount (derived from NodeState): %d", key, refcount)
+
+	if refcount > 0 {
+		return false, nil
+	}
+	if m.linger <= 0 {
+		return true, nil
+	}
+
+	svmMount, exists := m.mounts[key]
+	if !exists {
+		return true, nil
+	}
+	if _, err := m.mounter.IsLikelyNotMountPoint(svmMount.MountPath); err != nil && mount.IsCorruptedMnt(err) {
+		klog.Warningf("SVM %s mount at %s is unhealthy, unmounting without linger: %v", key, svmMount.MountPath, err)
+		return true, nil
+	}
+
+	m.scheduleUnmountLocked(key)
+	return false, nil
+}
+
+// scheduleUnmountLocked schedules a delayed unmount after the linger period (must hold lock)
+func (m *MountManager) scheduleUnmountLocked(key string) {
+	if timer, exists := m.pendingUnmounts[key]; exists {
+		timer.Stop()
+	}
+	if m.closed {
+		delete(m.pendingUnmounts, key)
+		return
+	}
+
+	klog.V(4).Infof("SVM %s unused, unmounting in %v unless reused", key, m.linger)
+	m.pendingUnmounts[key] = time.AfterFunc(m.linger, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		// Close stopped the timer after it had fired
+		if m.closed {
+			return
+		}
+		delete(m.pendingUnmounts, key)
+		if refcount := m.nodeState.CountStagedVolumesForSVM(key); refcount > 0 {
+			klog.V(4).Infof("SVM %s reused during linger period (refcount %d), keeping mount", key, refcount)
+			return
+		}
+		if err := m.unmountSVMLocked(key); err != nil {
+			klog.Warningf("Delayed unmount of SVM %s failed: %v", key, err)
+		}
+	})
+}
+
+// cancelUnmountLocked cancels a pending delayed unmount (must hold lock)
+func (m *MountManager) cancelUnmountLocked(key string) {
+	if timer, exists := m.pendingUnmounts[key]; exists {
+		timer.Stop()
+		delete(m.pendingUnmounts, key)
+		klog.V(4).Infof("Cancelled delayed unmount of SVM %s", key)
+	}
+}
 
-	klog.V(4).Infof("SVM %s refcount (derived from NodeState): %d", svmName, refcount)
+// Close stops the pending delayed unmounts. The lingering SVMs stay
+// mounted; the manager must not be used afterwards.
+func (m *MountManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	return refcount == 0, nil
+	m.closed = true
+	for key, timer := range m.pendingUnmounts {
+		timer.Stop()
+		delete(m.pendingUnmounts, key)
+	}
+	klog.V(4).Info("Mount manager closed")
 }
 
-// UnmountSVM unmounts an SVM
-func (m *MountManager) UnmountSVM(ctx context.Context, svmName string) error {
+// UnmountSVM unmounts the SVM mount with the given key
+func (m *MountManager) UnmountSVM(ctx context.Context, key string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	mount, exists := m.mounts[svmName]
+	m.cancelUnmountLocked(key)
+	return m.unmountSVMLocked(key)
+}
+
+// unmountSVMLocked unmounts an SVM mount if it is unused (must hold lock)
+func (m *MountManager) unmountSVMLocked(key string) error {
+	mount, exists := m.mounts[key]
 	if !exists {
-		klog.V(4).Infof("SVM %s not mounted, nothing to unmount", svmName)
+		klog.V(4).Infof("SVM %s not mounted, nothing to unmount", key)
 		return nil
 	}
 
 	// Double-check refcount before unmounting (safety check)
-	refcount := m.nodeState.CountStagedVolumesForSVM(svmName)
+	refcount := m.nodeState.CountStagedVolumesForSVM(key)
 	if refcount > 0 {
-		return fmt.Errorf("cannot unmount SVM %s: refcount is %d (not zero)", svmName, refcount)
+		return fmt.Errorf("cannot unmount SVM %s: refcount is %d (not zero)", key, refcount)
 	}
 
-	klog.Infof("Unmounting SVM %s from %s", svmName, mount.MountPath)
+	klog.Infof("Unmounting SVM %s from %s", key, mount.MountPath)
 
 	// Unmount
-	if err := m.mounter.Unmount(mount.MountPath); err != nil {
-		return fmt.Errorf("failed to unmount SVM %s: %w", svmName, err)
+	start := time.Now()
+	err := m.mounter.Unmount(mount.MountPath)
+	ObserveMount(OpNFSUnmount, mount.SVMName, mount.VIP, start, err)
+	if err != nil {
+		return fmt.Errorf("failed to unmount SVM %s: %w", key, err)
 	}
 
 	// Remove mount point directory
-	if err := os.Remove(mount.MountPath); err != nil {
+	if err := m.fs.Remove(mount.MountPath); err != nil {
 		klog.Warningf("Failed to remove mount point directory %s: %v", mount.MountPath, err)
 	}
 
 	// Remove from tracked mounts
-	delete(m.mounts, svmName)
+	delete(m.mounts, key)
+	delete(m.fscacheHolders, key)
 
-	klog.Infof("Successfully unmounted SVM %s", svmName)
+	klog.Infof("Successfully unmounted SVM %s", key)
 	return nil
 }
 
-// GetMountPath returns the mount path for an SVM
-func (m *MountManager) GetMountPath(svmName string) (string, error) {
+// GetMountPath returns the path of the SVM mount with the given key
+func (m *MountManager) GetMountPath(key string) (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	mount, exists := m.mounts[svmName]
+	mount, exists := m.mounts[key]
 	if !exists {
-		return "", fmt.Errorf("SVM %s is not mounted", svmName)
+		return "", fmt.Errorf("SVM %s is not mounted", key)
 	}
 
 	return mount.MountPath, nil
 }
 
-// getMountPath constructs the mount path for an SVM (must hold lock or be in init)
-func (m *MountManager) getMountPath(svmName string) string {
-	return filepath.Join(m.baseMountPath, svmName)
+// Mounts returns the tracked SVM mounts
+func (m *MountManager) Mounts() []SVMMount {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mounts := make([]SVMMount, 0, len(m.mounts))
+	for _, svm := range m.mounts {
+		mounts = append(mounts, *svm)
+	}
+	return mounts
+}
+
+// getMountPath constructs the path of an SVM mount from its key (must hold
+// lock or be in init)
+func (m *MountManager) getMountPath(key string) string {
+	return filepath.Join(m.baseMountPath, key)
 }
 
 // isMountPoint checks if a path is a mount point
@@ -0,0 +1,132 @@
+package mount_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mountutils "k8s.io/mount-utils"
+
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+)
+
+// newTestManager returns a mount manager mounting with mounter under a
+// temporary directory, and its node state
+func newTestManager(t *testing.T, mounter mountutils.Interface, cfg mount.MountManagerConfig) (*mount.MountManager, *mount.NodeState) {
+	t.Helper()
+
+	dir := t.TempDir()
+	nodeState, err := mount.NewNodeState(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatalf("NewNodeState: %v", err)
+	}
+	t.Cleanup(func() { nodeState.Close() })
+
+	cfg.BaseMountPath = filepath.Join(dir, "mounts")
+	cfg.Mounter = mounter
+	mgr, err := mount.NewMountManager(nodeState, &cfg)
+	if err != nil {
+		t.Fatalf("NewMountManager: %v", err)
+	}
+	return mgr, nodeState
+}
+
+// hangingMounter is a mounter whose mounts block, like those of an
+// unreachable NFS server, until they are cancelled
+type hangingMounter struct {
+	*mountutils.FakeMounter
+	cancelled chan struct{}
+}
+
+func (m *hangingMounter) MountContext(ctx context.Context, source, target, fstype string, options []string) error {
+	<-ctx.Done()
+	close(m.cancelled)
+	return ctx.Err()
+}
+
+func TestReconcileCancelsTimedOutMount(t *testing.T) {
+	mounter := &hangingMounter{FakeMounter: mountutils.NewFakeMounter(nil), cancelled: make(chan struct{})}
+	mgr, nodeState := newTestManager(t, mounter, mount.MountManagerConfig{ReconcileTimeout: 50 * time.Millisecond})
+
+	svm := mount.SVMMount{SVMName: "k8s-team-a", VIP: "192.0.2.10"}
+	if err := nodeState.RecordVolumeStaging("vol-1", svm, "/staging/vol-1"); err != nil {
+		t.Fatalf("RecordVolumeStaging: %v", err)
+	}
+
+	if mgr.ReconcileStatus().Done {
+		t.Fatal("ReconcileStatus is done before Reconcile ran")
+	}
+	mgr.Reconcile(context.Background())
+
+	status := mgr.ReconcileStatus()
+	if !status.Done || status.Total != 1 || status.Restored != 0 || len(status.Failed) != 1 {
+		t.Fatalf("ReconcileStatus = %+v, want done with 1 failed mount", status)
+	}
+	select {
+	case <-mounter.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out reconcile mount was not cancelled")
+	}
+}
+
+// failingMounter is a mounter whose mounts fail with err
+type failingMounter struct {
+	*mountutils.FakeMounter
+	err    error
+	mounts int
+}
+
+func (m *failingMounter) Mount(source, target, fstype string, options []string) error {
+	m.mounts++
+	return m.err
+}
+
+func TestEnsureSVMMountBackoff(t *testing.T) {
+	mountErr := errors.New("mount.nfs: Connection timed out")
+	mounter := &failingMounter{FakeMounter: mountutils.NewFakeMounter(nil), err: mountErr}
+	mgr, _ := newTestManager(t, mounter, mount.MountManagerConfig{MountBackoffInitial: time.Minute})
+
+	svm := mount.SVMMount{SVMName: "k8s-team-a", VIP: "192.0.2.10"}
+	_, err := mgr.EnsureSVMMount(context.Background(), svm)
+	if !errors.Is(err, mount.ErrMountFailed) || !errors.Is(err, mountErr) {
+		t.Fatalf("EnsureSVMMount = %v, want ErrMountFailed wrapping the mount error", err)
+	}
+	if !strings.Contains(err.Error(), "VIP 192.0.2.10 unreachable") {
+		t.Errorf("EnsureSVMMount error %q does not give the failure reason", err)
+	}
+
+	// The SVM is not mounted again until the backoff expires
+	_, err = mgr.EnsureSVMMount(context.Background(), svm)
+	if !errors.Is(err, mount.ErrMountBackoff) {
+		t.Fatalf("EnsureSVMMount while backing off = %v, want ErrMountBackoff", err)
+	}
+	if mounter.mounts != 1 {
+		t.Errorf("mounts = %d, want 1", mounter.mounts)
+	}
+}
+
+func TestCloseStopsDelayedUnmounts(t *testing.T) {
+	mounter := mountutils.NewFakeMounter(nil)
+	mgr, _ := newTestManager(t, mounter, mount.MountManagerConfig{UnmountLinger: 50 * time.Millisecond})
+
+	svm := mount.SVMMount{SVMName: "k8s-team-a", VIP: "192.0.2.10"}
+	if _, err := mgr.EnsureSVMMount(context.Background(), svm); err != nil {
+		t.Fatalf("EnsureSVMMount: %v", err)
+	}
+	// The unused SVM lingers, then Close drops its delayed unmount
+	unmount, err := mgr.ShouldUnmountSVM(context.Background(), svm.Key())
+	if err != nil || unmount {
+		t.Fatalf("ShouldUnmountSVM = %t, %v, want a delayed unmount", unmount, err)
+	}
+	mgr.Close()
+	time.Sleep(150 * time.Millisecond)
+
+	for _, action := range mounter.GetLog() {
+		if action.Action == mountutils.FakeActionUnmount {
+			t.Errorf("SVM unmounted at %s after Close", action.Target)
+		}
+	}
+}
@@ -1,23 +1,60 @@
 package mount
 
 import (
-	"encoding/json"
+	"bufio"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"syscall"
+	"time"
 
 	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
 )
 
 // VolumeStaging represents a staged volume's information
 type VolumeStaging struct {
-	VolumeID      string   `json:"volume_id"`
-	SVMName       string   `json:"svm_name"`
-	VIP           string   `json:"vip"`
-	StagingPath   string   `json:"staging_path"`
+	VolumeID       string   `json:"volume_id"`
+	SVMName        string   `json:"svm_name"`
+	VIP            string   `json:"vip"`
+	Host           string   `json:"host,omitempty"` // SVM DNS name, resolved on every mount
+	ExportPath     string   `json:"export_path,omitempty"`
+	Profile        string   `json:"profile,omitempty"`         // Mount profile ("" = general)
+	AttributeCache string   `json:"attribute_cache,omitempty"` // Attribute cache options ("" = profile's)
+	FSCache        bool     `json:"fscache,omitempty"`         // Mounted with local data cache (fsc)
+	SELinuxContext string   `json:"selinux_context,omitempty"` // "context=" option of the SVM mount
+	StagingPath    string   `json:"staging_path"`
 	PublishedPaths []string `json:"published_paths"` // Target paths where volume is published
+
+	// Pods maps published target paths to the pod they were published for
+	// (when kubelet passes pod information on mount)
+	Pods map[string]PodInfo `json:"pods,omitempty"`
+}
+
+// mount returns the SVM mount the volume is staged from
+func (v *VolumeStaging) mount() SVMMount {
+	return SVMMount{
+		SVMName:        v.SVMName,
+		VIP:            v.VIP,
+		Host:           v.Host,
+		ExportPath:     v.ExportPath,
+		Profile:        v.Profile,
+		AttributeCache: v.AttributeCache,
+		FSCache:        v.FSCache,
+		SELinuxContext: v.SELinuxContext,
+	}
+}
+
+// PodInfo identifies the pod a volume is published for
+type PodInfo struct {
+	UID       string `json:"uid"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
 }
 
 // NodeStateData represents the persistent state on a node
@@ -31,6 +68,22 @@ type NodeState struct {
 	stateFilePath string
 	mu            sync.RWMutex
 	data          *NodeStateData
+
+	// svmRefs indexes staged volume counts per SVM mount key (derived, not
+	// persisted)
+	svmRefs map[string]int
+
+	// journal is set when journaled persistence is enabled
+	journal *stateJournal
+
+	// coalescer is set when write coalescing is enabled
+	coalescer *coalescer
+
+	// format and codec encode the state file; fileFormat is the format of
+	// the file on disk ("" while there is none)
+	format     StateFormat
+	codec      stateCodec
+	fileFormat StateFormat
 }
 
 // NewNodeState creates a new NodeState manager
@@ -40,6 +93,9 @@ func NewNodeState(stateFilePath string) (*NodeState, error) {
 		data: &NodeStateData{
 			Volumes: make(map[string]*VolumeStaging),
 		},
+		svmRefs: make(map[string]int),
+		format:  StateFormatJSON,
+		codec:   jsonStateCodec{},
 	}
 
 	// Ensure state directory exists
@@ -61,22 +117,53 @@ func NewNodeState(stateFilePath string) (*NodeState, error) {
 		}
 	}
 
+	// Apply mutations journaled after the last snapshot, then fold them into
+	// a fresh snapshot so the journal starts empty
+	replayed, replayErr := ns.replayJournal()
+	if replayed > 0 {
+		klog.Infof("Replayed %d node state journal entries", replayed)
+		if err := ns.persistLocked(); err != nil {
+			return nil, fmt.Errorf("failed to persist replayed state: %w", err)
+		}
+	}
+	ns.rebuildPodMetricsLocked()
+	if replayErr != nil {
+		// Keep the records not replayed for inspection instead of dropping
+		// them with the journal
+		klog.Warningf("Failed to replay state journal: %v", replayErr)
+		if err := ns.quarantineJournal(); err != nil {
+			return nil, err
+		}
+	} else if err := os.Remove(ns.journalPath()); err != nil && !os.IsNotExist(err) {
+		klog.Warningf("Failed to remove state journal: %v", err)
+	}
+
 	return ns, nil
 }
 
-// RecordVolumeStaging records a volume staging operation (atomic, with fsync)
-func (ns *NodeState) RecordVolumeStaging(volumeID, svmName, vip, stagingPath string) error {
+// RecordVolumeStaging records a volume staging operation (atomic, with
+// fsync); svm is the SVM mount the volume is staged from, with the SVM's
+// reported NFS export, if any
+func (ns *NodeState) RecordVolumeStaging(volumeID string, svm SVMMount, stagingPath string) error {
 	ns.mu.Lock()
 	defer ns.mu.Unlock()
 
-	ns.data.Volumes[volumeID] = &VolumeStaging{
-		VolumeID:    volumeID,
-		SVMName:     svmName,
-		VIP:         vip,
-		StagingPath: stagingPath,
+	entry := journalEntry{
+		Op:             journalOpStage,
+		VolumeID:       volumeID,
+		SVMName:        svm.SVMName,
+		VIP:            svm.VIP,
+		Host:           svm.Host,
+		ExportPath:     svm.ExportPath,
+		Profile:        svm.Profile,
+		AttributeCache: svm.AttributeCache,
+		FSCache:        svm.FSCache,
+		SELinuxContext: svm.SELinuxContext,
+		StagingPath:    stagingPath,
 	}
+	ns.applyLocked(entry)
 
-	return ns.persistLocked()
+	return ns.commitLocked(entry)
 }
 
 // RemoveVolumeStaging removes a volume from staging records (atomic, with fsync)
@@ -84,9 +171,10 @@ func (ns *NodeState) RemoveVolumeStaging(volumeID string) error {
 	ns.mu.Lock()
 	defer ns.mu.Unlock()
 
-	delete(ns.data.Volumes, volumeID)
+	entry := journalEntry{Op: journalOpUnstage, VolumeID: volumeID}
+	ns.applyLocked(entry)
 
-	return ns.persistLocked()
+	return ns.commitLocked(entry)
 }
 
 // GetSVMForVolume retrieves the SVM name for a volume
@@ -102,6 +190,20 @@ func (ns *NodeState) GetSVMForVolume(volumeID string) (string, error) {
 	return staging.SVMName, nil
 }
 
+// GetMountKeyForVolume retrieves the key of the SVM mount a volume is
+// staged from
+func (ns *NodeState) GetMountKeyForVolume(volumeID string) (string, error) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+
+	staging, exists := ns.data.Volumes[volumeID]
+	if !exists {
+		return "", fmt.Errorf("volume %s not found in node state", volumeID)
+	}
+
+	return staging.mount().Key(), nil
+}
+
 // GetVIPForVolume retrieves the VIP for a volume
 func (ns *NodeState) GetVIPForVolume(volumeID string) (string, error) {
 	ns.mu.RLock()
@@ -115,20 +217,31 @@ func (ns *NodeState) GetVIPForVolume(volumeID string) (string, error) {
 	return staging.VIP, nil
 }
 
-// CountStagedVolumesForSVM counts how many volumes are staged for a given SVM
+// CountStagedVolumesForSVM counts how many volumes are staged from the SVM
+// mount with the given key (see SVMMount.Key)
 // This is used to derive refcount for mount management
-func (ns *NodeState) CountStagedVolumesForSVM(svmName string) int {
+func (ns *NodeState) CountStagedVolumesForSVM(key string) int {
 	ns.mu.RLock()
 	defer ns.mu.RUnlock()
 
-	count := 0
-	for _, staging := range ns.data.Volumes {
-		if staging.SVMName == svmName {
-			count++
-		}
+	return ns.svmRefs[key]
+}
+
+// unrefSVMLocked decrements the staged volume count for an SVM mount (must hold lock)
+func (ns *NodeState) unrefSVMLocked(key string) {
+	if ns.svmRefs[key] <= 1 {
+		delete(ns.svmRefs, key)
+		return
 	}
+	ns.svmRefs[key]--
+}
 
-	return count
+// rebuildIndexLocked recomputes derived indexes from state data (must hold lock)
+func (ns *NodeState) rebuildIndexLocked() {
+	ns.svmRefs = make(map[string]int)
+	for _, staging := range ns.data.Volumes {
+		ns.svmRefs[staging.mount().Key()]++
+	}
 }
 
 // GetStagedVolumes returns all staged volume information
@@ -140,20 +253,24 @@ func (ns *NodeState) GetStagedVolumes() map[string]*VolumeStaging {
 	result := make(map[string]*VolumeStaging, len(ns.data.Volumes))
 	for k, v := range ns.data.Volumes {
 		staging := *v // Copy struct
+		staging.PublishedPaths = slices.Clone(v.PublishedPaths)
+		staging.Pods = maps.Clone(v.Pods)
 		result[k] = &staging
 	}
 
 	return result
 }
 
-// GetUniqueSVMs returns a list of unique SVM names from staged volumes
-func (ns *NodeState) GetUniqueSVMs() map[string]string {
+// GetUniqueSVMs returns the SVM mounts of staged volumes with their VIP,
+// export path and mount options, keyed by mount key
+func (ns *NodeState) GetUniqueSVMs() map[string]SVMMount {
 	ns.mu.RLock()
 	defer ns.mu.RUnlock()
 
-	svms := make(map[string]string) // svmName -> VIP
+	svms := make(map[string]SVMMount)
 	for _, staging := range ns.data.Volumes {
-		svms[staging.SVMName] = staging.VIP
+		svm := staging.mount()
+		svms[svm.Key()] = svm
 	}
 
 	return svms
@@ -166,9 +283,15 @@ func (ns *NodeState) load() error {
 		return err
 	}
 
-	var stateData NodeStateData
-	if err := json.Unmarshal(data, &stateData); err != nil {
-		return fmt.Errorf("failed to unmarshal state: %w", err)
+	// Files of either format are read, whichever one is configured
+	format := detectStateFormat(data)
+	codec, err := codecFor(format)
+	if err != nil {
+		return err
+	}
+	stateData, err := codec.decode(data)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal %s state: %w", format, err)
 	}
 
 	// Initialize map if nil
@@ -176,20 +299,32 @@ func (ns *NodeState) load() error {
 		stateData.Volumes = make(map[string]*VolumeStaging)
 	}
 
-	ns.data = &stateData
-	klog.V(2).Infof("Loaded node state with %d volumes", len(ns.data.Volumes))
+	ns.data = stateData
+	ns.fileFormat = format
+	ns.rebuildIndexLocked()
+	ns.updateMetricsLocked(int64(len(data)))
+	klog.V(2).Infof("Loaded node state with %d volumes (%s)", len(ns.data.Volumes), format)
 
 	return nil
 }
 
-// persistLocked persists state to file with atomic write and fsync (must hold lock)
-func (ns *NodeState) persistLocked() error {
-	// Marshal to JSON
-	data, err := json.MarshalIndent(ns.data, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal state: %w", err)
-	}
+// countingWriter counts bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
 
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// persistLocked persists state to file with atomic write and fsync (must hold lock).
+// State is written in the configured format (compact JSON by default)
+// through a buffered writer to keep large states (tens of thousands of
+// published paths) small on disk and in memory.
+func (ns *NodeState) persistLocked() error {
 	// Atomic write: write to temp file, fsync, then rename
 	tempPath := ns.stateFilePath + ".tmp"
 
@@ -199,7 +334,14 @@ func (ns *NodeState) persistLocked() error {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
 
-	if _, err := f.Write(data); err != nil {
+	buf := bufio.NewWriter(f)
+	counter := &countingWriter{w: buf}
+	if err := ns.codec.encode(counter, ns.data); err != nil {
+		f.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := buf.Flush(); err != nil {
 		f.Close()
 		os.Remove(tempPath)
 		return fmt.Errorf("failed to write temp file: %w", err)
@@ -227,11 +369,85 @@ func (ns *NodeState) persistLocked() error {
 		dir.Close()
 	}
 
-	klog.V(4).Infof("Persisted node state with %d volumes", len(ns.data.Volumes))
+	ns.fileFormat = ns.format
+	ns.updateMetricsLocked(counter.n)
+	klog.V(4).Infof("Persisted node state with %d volumes (%d bytes)", len(ns.data.Volumes), counter.n)
 
 	return nil
 }
 
+// SetStateFormat selects the encoding of the state file. A state file
+// read in another format is rewritten at once; files are read in either
+// format, so the setting can be changed back at any time.
+func (ns *NodeState) SetStateFormat(format StateFormat) error {
+	codec, err := codecFor(format)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = StateFormatJSON
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	ns.format, ns.codec = format, codec
+	if ns.fileFormat == "" || ns.fileFormat == format {
+		return nil
+	}
+	if err := ns.persistLocked(); err != nil {
+		return fmt.Errorf("failed to rewrite node state as %s: %w", format, err)
+	}
+	klog.Infof("Converted node state file to %s", format)
+	return nil
+}
+
+// updateMetricsLocked publishes state size metrics (must hold lock)
+func (ns *NodeState) updateMetricsLocked(size int64) {
+	published := 0
+	for _, staging := range ns.data.Volumes {
+		published += len(staging.PublishedPaths)
+	}
+	metrics.NodeStateVolumes.Set(float64(len(ns.data.Volumes)))
+	metrics.NodeStatePublishedPaths.Set(float64(published))
+	metrics.NodeStateBytes.Set(float64(size))
+}
+
+// rebuildPodMetricsLocked publishes which pods use each volume and SVM
+// from the whole state, once it is loaded; mutations update their own
+// series (must hold lock)
+func (ns *NodeState) rebuildPodMetricsLocked() {
+	metrics.NodePodVolumes.Reset()
+	for _, staging := range ns.data.Volumes {
+		for _, pod := range staging.Pods {
+			setPodMetric(staging, pod)
+		}
+	}
+}
+
+// setPodMetric publishes that a volume is published for a pod
+func setPodMetric(staging *VolumeStaging, pod PodInfo) {
+	metrics.NodePodVolumes.WithLabelValues(staging.SVMName, staging.VolumeID, pod.Namespace, pod.Name).Set(1)
+}
+
+// deletePodMetric withdraws the series of a pod no longer using a volume,
+// unless the volume is still published for it at another target path
+func deletePodMetric(staging *VolumeStaging, pod PodInfo) {
+	for _, other := range staging.Pods {
+		if other.Namespace == pod.Namespace && other.Name == pod.Name {
+			return
+		}
+	}
+	metrics.NodePodVolumes.DeleteLabelValues(staging.SVMName, staging.VolumeID, pod.Namespace, pod.Name)
+}
+
+// deletePodMetrics withdraws the series of all pods of a volume
+func deletePodMetrics(staging *VolumeStaging) {
+	for _, pod := range staging.Pods {
+		metrics.NodePodVolumes.DeleteLabelValues(staging.SVMName, staging.VolumeID, pod.Namespace, pod.Name)
+	}
+}
+
 // quarantineCorruptState moves corrupt state file to a timestamped backup
 func (ns *NodeState) quarantineCorruptState() error {
 	backupPath := fmt.Sprintf("%s.corrupt.%d", ns.stateFilePath, syscall.Getpid())
@@ -244,6 +460,17 @@ func (ns *NodeState) quarantineCorruptState() error {
 	return nil
 }
 
+// quarantineJournal moves a journal that could not be replayed to a
+// timestamped backup, so that it is neither lost nor appended to
+func (ns *NodeState) quarantineJournal() error {
+	backupPath := fmt.Sprintf("%s.corrupt.%d", ns.journalPath(), time.Now().Unix())
+	if err := os.Rename(ns.journalPath(), backupPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to quarantine state journal: %w", err)
+	}
+	klog.Warningf("Quarantined state journal to %s; its remaining records were not applied", backupPath)
+	return nil
+}
+
 // Lock acquires an exclusive file lock for cross-process synchronization
 // This is important when multiple processes might access the state file
 func (ns *NodeState) Lock() error {
@@ -259,8 +486,9 @@ func (ns *NodeState) Unlock() {
 	ns.mu.Unlock()
 }
 
-// RecordVolumePublish records that a volume has been published to a target path
-func (ns *NodeState) RecordVolumePublish(volumeID, targetPath string) error {
+// RecordVolumePublish records that a volume has been published to a target
+// path, for the given pod if known
+func (ns *NodeState) RecordVolumePublish(volumeID, targetPath string, pod *PodInfo) error {
 	ns.mu.Lock()
 	defer ns.mu.Unlock()
 
@@ -271,17 +499,18 @@ func (ns *NodeState) RecordVolumePublish(volumeID, targetPath string) error {
 
 	// Check if already published to this path
 	for _, path := range staging.PublishedPaths {
-		if path == targetPath {
+		if path == targetPath && (pod == nil || staging.Pods[targetPath] == *pod) {
 			klog.V(4).Infof("Volume %s already published to %s", volumeID, targetPath)
 			return nil
 		}
 	}
 
 	// Add target path
-	staging.PublishedPaths = append(staging.PublishedPaths, targetPath)
+	entry := journalEntry{Op: journalOpPublish, VolumeID: volumeID, TargetPath: targetPath, Pod: pod}
+	ns.applyLocked(entry)
 
 	// Persist updated state
-	if err := ns.persistLocked(); err != nil {
+	if err := ns.commitLocked(entry); err != nil {
 		return fmt.Errorf("failed to persist state: %w", err)
 	}
 
@@ -294,23 +523,17 @@ func (ns *NodeState) RemoveVolumePublish(volumeID, targetPath string) error {
 	ns.mu.Lock()
 	defer ns.mu.Unlock()
 
-	staging, exists := ns.data.Volumes[volumeID]
-	if !exists {
+	if _, exists := ns.data.Volumes[volumeID]; !exists {
 		// Volume not in state - idempotent success
 		return nil
 	}
 
 	// Remove target path
-	newPaths := make([]string, 0, len(staging.PublishedPaths))
-	for _, path := range staging.PublishedPaths {
-		if path != targetPath {
-			newPaths = append(newPaths, path)
-		}
-	}
-	staging.PublishedPaths = newPaths
+	entry := journalEntry{Op: journalOpUnpublish, VolumeID: volumeID, TargetPath: targetPath}
+	ns.applyLocked(entry)
 
 	// Persist updated state
-	if err := ns.persistLocked(); err != nil {
+	if err := ns.commitLocked(entry); err != nil {
 		return fmt.Errorf("failed to persist state: %w", err)
 	}
 
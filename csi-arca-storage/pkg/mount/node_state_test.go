@@ -0,0 +1,78 @@
+package mount_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+	"github.com/akam1o/csi-arca-storage/pkg/mount"
+)
+
+// podVolumeSeries returns the number of pod_volume series
+func podVolumeSeries(t *testing.T) int {
+	t.Helper()
+
+	ch := make(chan prometheus.Metric, 64)
+	metrics.NodePodVolumes.Collect(ch)
+	close(ch)
+	return len(ch)
+}
+
+func TestPodVolumeMetrics(t *testing.T) {
+	metrics.NodePodVolumes.Reset()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	nodeState, err := mount.NewNodeState(statePath)
+	if err != nil {
+		t.Fatalf("NewNodeState: %v", err)
+	}
+
+	svm := mount.SVMMount{SVMName: "k8s-team-a", VIP: "192.0.2.10"}
+	podA := &mount.PodInfo{UID: "uid-a", Namespace: "team-a", Name: "pod-a"}
+	podB := &mount.PodInfo{UID: "uid-b", Namespace: "team-a", Name: "pod-b"}
+	steps := []struct {
+		name string
+		do   func() error
+		want int
+	}{
+		{"stage", func() error { return nodeState.RecordVolumeStaging("vol-1", svm, "/staging/vol-1") }, 0},
+		{"publish pod-a", func() error { return nodeState.RecordVolumePublish("vol-1", "/pods/a/vol-1", podA) }, 1},
+		{"publish pod-a again", func() error { return nodeState.RecordVolumePublish("vol-1", "/pods/a/vol-1-bis", podA) }, 1},
+		{"publish pod-b", func() error { return nodeState.RecordVolumePublish("vol-1", "/pods/b/vol-1", podB) }, 2},
+		{"unpublish one path of pod-a", func() error { return nodeState.RemoveVolumePublish("vol-1", "/pods/a/vol-1") }, 2},
+		{"unpublish pod-a", func() error { return nodeState.RemoveVolumePublish("vol-1", "/pods/a/vol-1-bis") }, 1},
+	}
+	for _, step := range steps {
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := podVolumeSeries(t); got != step.want {
+			t.Errorf("after %s: %d pod_volume series, want %d", step.name, got, step.want)
+		}
+	}
+	if !metrics.NodePodVolumes.DeleteLabelValues("k8s-team-a", "vol-1", "team-a", "pod-b") {
+		t.Error("no pod_volume series left for pod-b")
+	}
+
+	// A restarted plugin publishes the series of the loaded state
+	if err := nodeState.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	metrics.NodePodVolumes.Reset()
+	reloaded, err := mount.NewNodeState(statePath)
+	if err != nil {
+		t.Fatalf("NewNodeState: %v", err)
+	}
+	t.Cleanup(func() { reloaded.Close() })
+	if got := podVolumeSeries(t); got != 1 {
+		t.Errorf("after reload: %d pod_volume series, want 1", got)
+	}
+
+	if err := reloaded.RemoveVolumeStaging("vol-1"); err != nil {
+		t.Fatalf("RemoveVolumeStaging: %v", err)
+	}
+	if got := podVolumeSeries(t); got != 0 {
+		t.Errorf("after unstage: %d pod_volume series, want 0", got)
+	}
+}
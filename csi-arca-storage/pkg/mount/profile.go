@@ -0,0 +1,218 @@
+package mount
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+// Mount profile names
+const (
+	ProfileGeneral    = "general"
+	ProfileDatabase   = "database"
+	ProfileAITraining = "ai-training"
+)
+
+// Profile is a curated NFS tuning for a workload type. Volumes with the
+// same profile on an SVM share one mount of the SVM; every profile other
+// than general gets a separate mount, as NFS options apply per mount.
+type Profile struct {
+	Name string
+	// Options are added to the default NFS options, replacing defaults
+	// with the same key
+	Options []string
+	// ReadAheadKB is the readahead of the mount (0 = kernel default)
+	ReadAheadKB int
+	// Sysctls are host settings suggested for the workload, applied only
+	// when the node plugin is configured to; values are only ever raised
+	Sysctls map[string]string
+}
+
+// profiles are the known mount profiles
+var profiles = map[string]Profile{
+	// The driver's default options
+	ProfileGeneral: {Name: ProfileGeneral},
+
+	// Database files may be opened by another node after a failover, so
+	// attributes and lookups are revalidated instead of cached; small
+	// readahead suits random I/O, and more RPC slots concurrent requests
+	ProfileDatabase: {
+		Name:        ProfileDatabase,
+		Options:     []string{"actimeo=0", "lookupcache=positive"},
+		ReadAheadKB: 128,
+		Sysctls: map[string]string{
+			"sunrpc.tcp_slot_table_entries": "128",
+		},
+	},
+
+	// Training datasets are large, read sequentially and rarely change:
+	// attributes are cached for long, reads spread over several TCP
+	// connections (kernel 5.3 and later) and read far ahead, with socket
+	// buffers sized for the throughput
+	ProfileAITraining: {
+		Name:        ProfileAITraining,
+		Options:     []string{"nconnect=8", "actimeo=600"},
+		ReadAheadKB: 16384,
+		Sysctls: map[string]string{
+			"net.core.rmem_max": "16777216",
+			"net.core.wmem_max": "16777216",
+		},
+	},
+}
+
+// ProfileNames returns the names of the known mount profiles, sorted
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupProfile returns the mount profile named name ("" = general)
+func LookupProfile(name string) (Profile, error) {
+	if name == "" {
+		name = ProfileGeneral
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown mount profile %q (must be one of %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// nfsOptions returns the NFS options of an SVM mount: the defaults with the
+// profile's and then the attribute cache options applied, and the SELinux
+// context of the mount. Mounts other than
+// the shared default one use nosharecache, as the kernel refuses a second
+// mount of an export with different options otherwise.
+func nfsOptions(svm SVMMount) []string {
+	options := GetDefaultNFSOptions()
+	if svm.Key() == svm.SVMName {
+		return options
+	}
+	if profile, err := LookupProfile(svm.Profile); err != nil {
+		klog.Warningf("SVM %s: %v, using default NFS options", svm.SVMName, err)
+	} else {
+		for _, option := range profile.Options {
+			options = setOption(options, option)
+		}
+	}
+	if attrs, err := ParseAttributeCache(svm.AttributeCache); err != nil {
+		klog.Warningf("SVM %s: %v, using the profile's attribute cache options", svm.SVMName, err)
+	} else {
+		for _, option := range attrs.options() {
+			options = setOption(options, option)
+		}
+	}
+	if svm.FSCacheActive {
+		// Each fsc superblock of an export needs its own cache identifier
+		sum := sha256.Sum256([]byte(svm.Key()))
+		options = append(options, "fsc="+hex.EncodeToString(sum[:8]))
+	}
+	if svm.SELinuxContext != "" {
+		options = append(options, svm.SELinuxContext)
+	}
+	return append(options, "nosharecache")
+}
+
+// setOption adds a mount option, replacing options it overrides: those
+// with the same key and, for noac, the attribute cache timeouts
+func setOption(options []string, option string) []string {
+	key, _, _ := strings.Cut(option, "=")
+	options = slices.DeleteFunc(options, func(o string) bool {
+		k, _, _ := strings.Cut(o, "=")
+		return k == key || key == "noac" && attributeTimeoutOptions[k]
+	})
+	return append(options, option)
+}
+
+// applyProfile tunes a new SVM mount for its profile: the readahead of the
+// mount is set, and the profile's sysctls are applied when enabled or else
+// suggested once. Tuning is best-effort, failures are only logged.
+func (m *MountManager) applyProfile(svm SVMMount, mountPath string) {
+	if svm.Profile == "" {
+		return
+	}
+	profile, err := LookupProfile(svm.Profile)
+	if err != nil {
+		return
+	}
+
+	if profile.ReadAheadKB > 0 {
+		if err := setReadAhead(mountPath, profile.ReadAheadKB); err != nil {
+			klog.Warningf("Failed to set readahead of %s to %d KiB for mount profile %s: %v", mountPath, profile.ReadAheadKB, profile.Name, err)
+		} else {
+			klog.V(4).Infof("Set readahead of %s to %d KiB for mount profile %s", mountPath, profile.ReadAheadKB, profile.Name)
+		}
+	}
+
+	if len(profile.Sysctls) == 0 {
+		return
+	}
+	m.mu.Lock()
+	tuned := m.tunedProfiles[profile.Name]
+	m.tunedProfiles[profile.Name] = true
+	m.mu.Unlock()
+	if tuned {
+		return
+	}
+
+	keys := make([]string, 0, len(profile.Sysctls))
+	for key := range profile.Sysctls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := profile.Sysctls[key]
+		if !m.applySysctls {
+			klog.Infof("Mount profile %s suggests sysctl %s=%s on this node", profile.Name, key, value)
+			continue
+		}
+		if err := raiseSysctl(key, value); err != nil {
+			klog.Warningf("Failed to apply sysctl %s=%s for mount profile %s: %v", key, value, profile.Name, err)
+		}
+	}
+}
+
+// setReadAhead sets the readahead of the NFS mount at path through the
+// backing device info of its superblock
+func setReadAhead(path string, kb int) error {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return err
+	}
+	bdi := fmt.Sprintf("/sys/class/bdi/%d:%d/read_ahead_kb", unix.Major(st.Dev), unix.Minor(st.Dev))
+	return os.WriteFile(bdi, []byte(strconv.Itoa(kb)), 0644)
+}
+
+// raiseSysctl sets a sysctl to value unless it is already at least value;
+// values that are not single integers are always written
+func raiseSysctl(key, value string) error {
+	path := filepath.Join("/proc/sys", strings.ReplaceAll(key, ".", "/"))
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	have, haveErr := strconv.ParseInt(strings.TrimSpace(string(current)), 10, 64)
+	want, wantErr := strconv.ParseInt(value, 10, 64)
+	if haveErr == nil && wantErr == nil && have >= want {
+		klog.V(4).Infof("Sysctl %s is %d, not lowering to %d", key, have, want)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(value), 0644); err != nil {
+		return err
+	}
+	klog.Infof("Set sysctl %s=%s", key, value)
+	return nil
+}
@@ -0,0 +1,79 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// resolveEntry is a cached DNS resolution result
+type resolveEntry struct {
+	ip        string
+	expiresAt time.Time
+}
+
+// HostResolver resolves SVM DNS names to IPv4 addresses with a TTL cache.
+// When a lookup fails, the last known address is returned if available.
+type HostResolver struct {
+	ttl      time.Duration
+	resolver *net.Resolver
+	cache    map[string]*resolveEntry
+	mu       sync.Mutex
+}
+
+// NewHostResolver creates a new caching resolver
+func NewHostResolver(ttl time.Duration) *HostResolver {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &HostResolver{
+		ttl:      ttl,
+		resolver: net.DefaultResolver,
+		cache:    make(map[string]*resolveEntry),
+	}
+}
+
+// Resolve returns an IPv4 address for the host
+func (r *HostResolver) Resolve(ctx context.Context, host string) (string, error) {
+	r.mu.Lock()
+	entry, cached := r.cache[host]
+	if cached && time.Now().Before(entry.expiresAt) {
+		r.mu.Unlock()
+		return entry.ip, nil
+	}
+	r.mu.Unlock()
+
+	ip, err := r.lookup(ctx, host)
+	if err != nil {
+		if cached {
+			klog.Warningf("Failed to resolve %s, using stale address %s: %v", host, entry.ip, err)
+			return entry.ip, nil
+		}
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.cache[host] = &resolveEntry{ip: ip, expiresAt: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+
+	klog.V(4).Infof("Resolved SVM host %s to %s", host, ip)
+	return ip, nil
+}
+
+// lookup performs the DNS query and returns the first IPv4 address
+func (r *HostResolver) lookup(ctx context.Context, host string) (string, error) {
+	addrs, err := r.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if ip4 := addr.IP.To4(); ip4 != nil {
+			return ip4.String(), nil
+		}
+	}
+	return "", fmt.Errorf("no IPv4 address found for %s", host)
+}
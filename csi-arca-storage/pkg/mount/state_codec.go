@@ -0,0 +1,283 @@
+package mount
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// StateFormat is the encoding of the node state file
+type StateFormat string
+
+const (
+	// StateFormatJSON writes the state as JSON (default)
+	StateFormatJSON StateFormat = "json"
+	// StateFormatProtobuf writes the state in the protobuf wire format,
+	// several times faster to encode and decode than JSON on nodes with
+	// thousands of volumes
+	StateFormatProtobuf StateFormat = "protobuf"
+)
+
+// protobufStateMagic starts protobuf state files, telling them from JSON
+// ones (which start with '{') on load
+var protobufStateMagic = []byte("ARCANS\x00\x01")
+
+// stateCodec encodes and decodes the node state file
+type stateCodec interface {
+	encode(w io.Writer, data *NodeStateData) error
+	decode(data []byte) (*NodeStateData, error)
+}
+
+// codecFor returns the codec of a format ("" is JSON)
+func codecFor(format StateFormat) (stateCodec, error) {
+	switch format {
+	case "", StateFormatJSON:
+		return jsonStateCodec{}, nil
+	case StateFormatProtobuf:
+		return protobufStateCodec{}, nil
+	}
+	return nil, fmt.Errorf("unknown state format %q", format)
+}
+
+// detectStateFormat returns the format a state file was written in
+func detectStateFormat(data []byte) StateFormat {
+	if bytes.HasPrefix(data, protobufStateMagic) {
+		return StateFormatProtobuf
+	}
+	return StateFormatJSON
+}
+
+// jsonStateCodec writes compact JSON
+type jsonStateCodec struct{}
+
+func (jsonStateCodec) encode(w io.Writer, data *NodeStateData) error {
+	return json.NewEncoder(w).Encode(data)
+}
+
+func (jsonStateCodec) decode(data []byte) (*NodeStateData, error) {
+	var stateData NodeStateData
+	if err := json.Unmarshal(data, &stateData); err != nil {
+		return nil, err
+	}
+	return &stateData, nil
+}
+
+// protobufStateCodec writes the state as the following messages, after
+// protobufStateMagic. Fields are only ever added, so older plugins skip
+// the fields of newer ones.
+//
+//	message NodeState {
+//	  repeated VolumeStaging volumes = 1;
+//	}
+//	message VolumeStaging {
+//	  string volume_id = 1;
+//	  string svm_name = 2;
+//	  string vip = 3;
+//	  string export_path = 4;
+//	  string profile = 5;
+//	  string attribute_cache = 6;
+//	  bool fscache = 7;
+//	  string staging_path = 8;
+//	  repeated string published_paths = 9;
+//	  repeated Pod pods = 10;
+//	  string selinux_context = 11;
+//	  string host = 12;
+//	}
+//	message Pod {
+//	  string target_path = 1;
+//	  string uid = 2;
+//	  string namespace = 3;
+//	  string name = 4;
+//	}
+type protobufStateCodec struct{}
+
+func (protobufStateCodec) encode(w io.Writer, data *NodeStateData) error {
+	b := append([]byte(nil), protobufStateMagic...)
+	var volume []byte
+	for _, v := range data.Volumes {
+		volume = appendVolumeStaging(volume[:0], v)
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, volume)
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func (protobufStateCodec) decode(data []byte) (*NodeStateData, error) {
+	b, ok := bytes.CutPrefix(data, protobufStateMagic)
+	if !ok {
+		return nil, fmt.Errorf("missing protobuf state header")
+	}
+
+	stateData := &NodeStateData{Volumes: make(map[string]*VolumeStaging)}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		v, err := consumeVolumeStaging(value)
+		if err != nil {
+			return err
+		}
+		stateData.Volumes[v.VolumeID] = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stateData, nil
+}
+
+// appendVolumeStaging appends the fields of a VolumeStaging message
+func appendVolumeStaging(b []byte, v *VolumeStaging) []byte {
+	b = appendString(b, 1, v.VolumeID)
+	b = appendString(b, 2, v.SVMName)
+	b = appendString(b, 3, v.VIP)
+	b = appendString(b, 4, v.ExportPath)
+	b = appendString(b, 5, v.Profile)
+	b = appendString(b, 6, v.AttributeCache)
+	if v.FSCache {
+		b = protowire.AppendTag(b, 7, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = appendString(b, 8, v.StagingPath)
+	for _, path := range v.PublishedPaths {
+		b = protowire.AppendTag(b, 9, protowire.BytesType)
+		b = protowire.AppendString(b, path)
+	}
+	for path, pod := range v.Pods {
+		var msg []byte
+		msg = appendString(msg, 1, path)
+		msg = appendString(msg, 2, pod.UID)
+		msg = appendString(msg, 3, pod.Namespace)
+		msg = appendString(msg, 4, pod.Name)
+		b = protowire.AppendTag(b, 10, protowire.BytesType)
+		b = protowire.AppendBytes(b, msg)
+	}
+	b = appendString(b, 11, v.SELinuxContext)
+	b = appendString(b, 12, v.Host)
+	return b
+}
+
+// consumeVolumeStaging decodes a VolumeStaging message
+func consumeVolumeStaging(b []byte) (*VolumeStaging, error) {
+	v := &VolumeStaging{}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num == 7 && typ == protowire.VarintType {
+			n, length := protowire.ConsumeVarint(value)
+			if length < 0 {
+				return protowire.ParseError(length)
+			}
+			v.FSCache = n != 0
+			return nil
+		}
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			v.VolumeID = string(value)
+		case 2:
+			v.SVMName = string(value)
+		case 3:
+			v.VIP = string(value)
+		case 4:
+			v.ExportPath = string(value)
+		case 5:
+			v.Profile = string(value)
+		case 6:
+			v.AttributeCache = string(value)
+		case 8:
+			v.StagingPath = string(value)
+		case 9:
+			v.PublishedPaths = append(v.PublishedPaths, string(value))
+		case 10:
+			path, pod, err := consumePod(value)
+			if err != nil {
+				return err
+			}
+			if v.Pods == nil {
+				v.Pods = make(map[string]PodInfo)
+			}
+			v.Pods[path] = pod
+		case 11:
+			v.SELinuxContext = string(value)
+		case 12:
+			v.Host = string(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid volume record: %w", err)
+	}
+	if v.VolumeID == "" {
+		return nil, fmt.Errorf("volume record without volume ID")
+	}
+	return v, nil
+}
+
+// consumePod decodes a Pod message into its target path and pod
+func consumePod(b []byte) (string, PodInfo, error) {
+	var path string
+	var pod PodInfo
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			path = string(value)
+		case 2:
+			pod.UID = string(value)
+		case 3:
+			pod.Namespace = string(value)
+		case 4:
+			pod.Name = string(value)
+		}
+		return nil
+	})
+	return path, pod, err
+}
+
+// appendString appends a string field, leaving out empty ones
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// consumeFields calls fn with each field of a message: the contents of
+// length-delimited fields and the raw encoding of others
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var value []byte
+		if typ == protowire.BytesType {
+			v, m := protowire.ConsumeBytes(b)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+			value, n = v, m
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value = b[:n]
+		}
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
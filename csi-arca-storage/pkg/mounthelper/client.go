@@ -0,0 +1,110 @@
+package mounthelper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	mountutils "k8s.io/mount-utils"
+)
+
+// requestTimeout bounds a mount call; NFS mounts of an unreachable server
+// retry for a while before mount gives up
+const requestTimeout = 5 * time.Minute
+
+// Client is a mounter for the node plugin that has the helper perform
+// mounts and unmounts. Mount table queries (IsLikelyNotMountPoint, List,
+// ...) need no privileges and are answered locally.
+type Client struct {
+	mountutils.Interface
+	socketPath string
+	http       *http.Client
+}
+
+// NewClient creates a mounter calling the helper listening on socketPath
+func NewClient(socketPath string) *Client {
+	return &Client{
+		Interface:  mountutils.New(""),
+		socketPath: socketPath,
+		http: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// Mount mounts source to target through the helper
+func (c *Client) Mount(source, target, fstype string, options []string) error {
+	return c.call(pathMount, &mountRequest{Source: source, Target: target, FSType: fstype, Options: options})
+}
+
+// MountContext mounts source to target through the helper; cancelling ctx
+// cancels the helper's mount
+func (c *Client) MountContext(ctx context.Context, source, target, fstype string, options []string) error {
+	return c.callContext(ctx, pathMount, &mountRequest{Source: source, Target: target, FSType: fstype, Options: options})
+}
+
+// MountSensitive mounts with sensitive options through the helper
+func (c *Client) MountSensitive(source, target, fstype string, options, sensitiveOptions []string) error {
+	return c.call(pathMount, &mountRequest{Source: source, Target: target, FSType: fstype, Options: options, SensitiveOptions: sensitiveOptions})
+}
+
+// MountSensitiveWithoutSystemd mounts with sensitive options through the
+// helper, which decides whether to use systemd-run
+func (c *Client) MountSensitiveWithoutSystemd(source, target, fstype string, options, sensitiveOptions []string) error {
+	return c.MountSensitive(source, target, fstype, options, sensitiveOptions)
+}
+
+// MountSensitiveWithoutSystemdWithMountFlags mounts with extra mount flags
+// through the helper
+func (c *Client) MountSensitiveWithoutSystemdWithMountFlags(source, target, fstype string, options, sensitiveOptions, mountFlags []string) error {
+	return c.call(pathMount, &mountRequest{Source: source, Target: target, FSType: fstype, Options: options, SensitiveOptions: sensitiveOptions, MountFlags: mountFlags})
+}
+
+// Unmount unmounts target through the helper
+func (c *Client) Unmount(target string) error {
+	return c.call(pathUnmount, &unmountRequest{Target: target})
+}
+
+// call posts body to the helper and returns the error it answered with
+func (c *Client) call(path string, body interface{}) error {
+	return c.callContext(context.Background(), path, body)
+}
+
+// callContext is call with a context cancelling the request
+func (c *Client) callContext(ctx context.Context, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// The host is ignored, the transport always dials the socket
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://mount-helper"+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("mount helper at %s: %w", c.socketPath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	var errResp errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+		return fmt.Errorf("mount helper answered %s", resp.Status)
+	}
+	return fmt.Errorf("mount helper: %s", errResp.Error)
+}
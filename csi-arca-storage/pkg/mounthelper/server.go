@@ -0,0 +1,266 @@
+// Package mounthelper splits node mounts out of the node plugin: a small
+// privileged helper performs mounts and unmounts requested over a unix
+// socket, so the node plugin container itself runs without privileges or
+// capabilities and only needs to see the mounts (HostToContainer mount
+// propagation).
+package mounthelper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+	mountutils "k8s.io/mount-utils"
+)
+
+// DefaultSocketPath is where the helper listens unless configured otherwise
+const DefaultSocketPath = "/var/lib/kubelet/plugins/csi.arca-storage.io/mount-helper/mount.sock"
+
+// DefaultKubeletPath holds the staging and publish targets kubelet passes
+// to the node plugin
+const DefaultKubeletPath = "/var/lib/kubelet"
+
+// API paths of the helper
+const (
+	pathMount   = "/v1/mount"
+	pathUnmount = "/v1/unmount"
+)
+
+// shutdownTimeout bounds waiting for running mounts when the helper stops
+const shutdownTimeout = 30 * time.Second
+
+// nfsTypes are the filesystem types of SVM mounts; bind mounts have none
+var nfsTypes = []string{"nfs", "nfs4"}
+
+// mountRequest is the body of a mount call
+type mountRequest struct {
+	Source           string   `json:"source"`
+	Target           string   `json:"target"`
+	FSType           string   `json:"fstype,omitempty"`
+	Options          []string `json:"options,omitempty"`
+	SensitiveOptions []string `json:"sensitiveOptions,omitempty"`
+	MountFlags       []string `json:"mountFlags,omitempty"`
+}
+
+// unmountRequest is the body of an unmount call
+type unmountRequest struct {
+	Target string `json:"target"`
+}
+
+// errorResponse is the body of a failed call
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// contextMounter is a mounter with cancellable mounts (see
+// mount.ContextMounter)
+type contextMounter interface {
+	MountContext(ctx context.Context, source, target, fstype string, options []string) error
+}
+
+// Server performs the mounts and unmounts requested by the node plugin. It
+// only mounts NFS exports and bind mounts into, and unmounts from, the
+// allowed directories, so a compromised node plugin cannot use it to mount
+// over arbitrary host paths. Paths are checked and used with their
+// symlinks resolved, as pods can create symlinks under the kubelet
+// directory.
+type Server struct {
+	mounter      mountutils.Interface
+	allowedPaths []string
+}
+
+// NewServer creates a helper mounting with mounter into allowedPaths
+// (absolute directories)
+func NewServer(mounter mountutils.Interface, allowedPaths []string) *Server {
+	paths := make([]string, 0, len(allowedPaths))
+	for _, p := range allowedPaths {
+		// Resolved paths are compared with the resolved allowed directories
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			p = resolved
+		}
+		paths = append(paths, filepath.Clean(p))
+	}
+	return &Server{
+		mounter:      mounter,
+		allowedPaths: paths,
+	}
+}
+
+// Serve answers requests on a unix socket at socketPath until ctx is
+// cancelled. A stale socket is replaced; the new one is only accessible to
+// the helper's user.
+func (s *Server) Serve(ctx context.Context, socketPath string) error {
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket %s: %w", socketPath, err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict socket %s: %w", socketPath, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+pathMount, s.handleMount)
+	mux.HandleFunc("POST "+pathUnmount, s.handleUnmount)
+	srv := &http.Server{Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	klog.Infof("Mount helper listening on %s (allowed paths: %s)", socketPath, strings.Join(s.allowedPaths, ", "))
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handleMount performs a mount call
+func (s *Server) handleMount(w http.ResponseWriter, r *http.Request) {
+	var req mountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if err := s.validateMount(&req); err != nil {
+		klog.Warningf("Refusing mount of %q on %q: %v", req.Source, req.Target, err)
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	var err error
+	cm, cancellable := s.mounter.(contextMounter)
+	switch {
+	case cancellable && len(req.MountFlags) == 0 && len(req.SensitiveOptions) == 0:
+		// The request is cancelled when the client gives up on it
+		err = cm.MountContext(r.Context(), req.Source, req.Target, req.FSType, req.Options)
+	case len(req.MountFlags) > 0:
+		err = s.mounter.MountSensitiveWithoutSystemdWithMountFlags(req.Source, req.Target, req.FSType, req.Options, req.SensitiveOptions, req.MountFlags)
+	default:
+		err = s.mounter.MountSensitive(req.Source, req.Target, req.FSType, req.Options, req.SensitiveOptions)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	klog.V(4).Infof("Mounted %s on %s (type %q, options %v)", req.Source, req.Target, req.FSType, req.Options)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUnmount performs an unmount call
+func (s *Server) handleUnmount(w http.ResponseWriter, r *http.Request) {
+	var req unmountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	target, err := s.resolvePath("target", req.Target)
+	if err != nil {
+		klog.Warningf("Refusing unmount of %q: %v", req.Target, err)
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	req.Target = target
+
+	if err := s.mounter.Unmount(req.Target); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	klog.V(4).Infof("Unmounted %s", req.Target)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateMount accepts NFS mounts and bind mounts of allowed paths into
+// allowed paths, and replaces the paths of the request with the resolved
+// ones it checked
+func (s *Server) validateMount(req *mountRequest) error {
+	target, err := s.resolvePath("target", req.Target)
+	if err != nil {
+		return err
+	}
+	switch {
+	case slices.Contains(nfsTypes, req.FSType):
+		if !strings.Contains(req.Source, ":/") {
+			return fmt.Errorf("NFS source %q is not host:/path", req.Source)
+		}
+	case req.FSType == "" && slices.Contains(req.Options, "bind"):
+		source, err := s.resolvePath("source", req.Source)
+		if err != nil {
+			return err
+		}
+		req.Source = source
+	default:
+		return fmt.Errorf("only NFS and bind mounts are allowed, not type %q with options %v", req.FSType, req.Options)
+	}
+	req.Target = target
+	return nil
+}
+
+// resolvePath requires an absolute, clean path that is inside an allowed
+// directory once its symlinks are resolved, and returns it resolved. The
+// last element of a path is not followed when it is a corrupted mount point
+// (e.g. a stale NFS mount), so that it can still be unmounted.
+func (s *Server) resolvePath(name, p string) (string, error) {
+	if !filepath.IsAbs(p) || filepath.Clean(p) != p {
+		return "", fmt.Errorf("%s %q is not a clean absolute path", name, p)
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Dir(p))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s %q: %w", name, p, err)
+	}
+	resolved := filepath.Join(dir, filepath.Base(p))
+	fi, err := os.Lstat(resolved)
+	switch {
+	case err == nil && fi.Mode()&os.ModeSymlink != 0:
+		if resolved, err = filepath.EvalSymlinks(resolved); err != nil {
+			return "", fmt.Errorf("failed to resolve %s %q: %w", name, p, err)
+		}
+	case err != nil && !mountutils.IsCorruptedMnt(err):
+		return "", fmt.Errorf("failed to resolve %s %q: %w", name, p, err)
+	}
+
+	if !s.allowed(resolved) {
+		if resolved == p {
+			return "", fmt.Errorf("%s %q is outside the allowed paths", name, p)
+		}
+		return "", fmt.Errorf("%s %q resolves to %q, outside the allowed paths", name, p, resolved)
+	}
+	return resolved, nil
+}
+
+// allowed reports whether a path is inside an allowed directory
+func (s *Server) allowed(p string) bool {
+	for _, allowed := range s.allowedPaths {
+		if strings.HasPrefix(p, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeError answers a call with an error
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,97 @@
+package mounthelper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mountutils "k8s.io/mount-utils"
+)
+
+// newTestServer returns a helper allowed to mount under a temporary
+// directory, the directory, and a directory outside it
+func newTestServer(t *testing.T) (*Server, *mountutils.FakeMounter, string, string) {
+	t.Helper()
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("EvalSymlinks: %v", err)
+	}
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("EvalSymlinks: %v", err)
+	}
+	for _, dir := range []string{"staging", "pods/pod-1/volume"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0750); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+	}
+	// Symlinks a pod could create, escaping the allowed root and not
+	if err := os.Symlink(outside, filepath.Join(root, "pods", "escape")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "staging"), filepath.Join(root, "pods", "inside")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	mounter := mountutils.NewFakeMounter(nil)
+	return NewServer(mounter, []string{root}), mounter, root, outside
+}
+
+func TestValidateMountResolvesSymlinks(t *testing.T) {
+	s, _, root, outside := newTestServer(t)
+	if err := os.Mkdir(filepath.Join(outside, "etc"), 0750); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		source     string
+		target     string
+		fstype     string
+		wantErr    bool
+		wantTarget string
+	}{
+		{name: "nfs", source: "192.0.2.10:/exports/k8s-team-a", target: root + "/staging", fstype: "nfs4", wantTarget: root + "/staging"},
+		{name: "bind", source: root + "/staging", target: root + "/pods/pod-1/volume", wantTarget: root + "/pods/pod-1/volume"},
+		{name: "symlink inside", source: "192.0.2.10:/exports/k8s-team-a", target: root + "/pods/inside", fstype: "nfs4", wantTarget: root + "/staging"},
+		{name: "target symlink escaping", source: "192.0.2.10:/exports/k8s-team-a", target: root + "/pods/escape", fstype: "nfs4", wantErr: true},
+		{name: "target under symlink escaping", source: root + "/staging", target: root + "/pods/escape/etc", wantErr: true},
+		{name: "bind source symlink escaping", source: root + "/pods/escape", target: root + "/pods/pod-1/volume", wantErr: true},
+		{name: "outside", source: "192.0.2.10:/exports/k8s-team-a", target: outside, fstype: "nfs4", wantErr: true},
+		{name: "unclean", source: "192.0.2.10:/exports/k8s-team-a", target: root + "/staging/../..", fstype: "nfs4", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &mountRequest{Source: tt.source, Target: tt.target, FSType: tt.fstype}
+			if tt.fstype == "" {
+				req.Options = []string{"bind"}
+			}
+			err := s.validateMount(req)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("validateMount = %v, want error: %t", err, tt.wantErr)
+			}
+			if err == nil && req.Target != tt.wantTarget {
+				t.Errorf("validated target = %s, want %s", req.Target, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestHandleUnmountRefusesEscapingSymlink(t *testing.T) {
+	s, mounter, root, _ := newTestServer(t)
+
+	body := strings.NewReader(`{"target": "` + root + `/pods/escape"}`)
+	rec := httptest.NewRecorder()
+	s.handleUnmount(rec, httptest.NewRequest(http.MethodPost, pathUnmount, body))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("unmount through an escaping symlink answered %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if log := mounter.GetLog(); len(log) != 0 {
+		t.Errorf("mounter called: %v", log)
+	}
+}
@@ -0,0 +1,341 @@
+// Package nscleanup cleans up the storage of deleted namespaces: it watches
+// Namespaces, warns about PersistentVolumes still bound in terminating
+// namespaces and, once the volumes and snapshots of a deleted namespace are
+// gone, reports or deletes the namespace's SVM, which frees its VIP.
+package nscleanup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// Cleanup modes
+const (
+	// ModeReport logs the SVMs of deleted namespaces that are unused
+	ModeReport = "report"
+	// ModeDelete also deletes those SVMs
+	ModeDelete = "delete"
+)
+
+// DefaultInterval is how often namespaces are checked besides the checks
+// triggered by Namespace events
+const DefaultInterval = 5 * time.Minute
+
+// cleanupTimeout bounds a single pass
+const cleanupTimeout = 10 * time.Minute
+
+// listPageSize is the page size used when listing ArcaVolumes, ArcaSnapshots
+// and PersistentVolumes
+const listPageSize = 500
+
+// ReasonNamespaceTerminating is the reason of the warning Events recorded on
+// PersistentVolumes that stay bound in a terminating namespace
+const ReasonNamespaceTerminating = "NamespaceTerminating"
+
+// svmKey identifies an SVM on a backend
+type svmKey struct {
+	backend string
+	svm     string
+}
+
+// Cleaner checks the storage of terminating and deleted namespaces whenever
+// a Namespace is deleted, and every interval
+type Cleaner struct {
+	client     client.Client
+	clientset  kubernetes.Interface
+	backends   *arca.BackendRouter
+	driverName string
+	mode       string
+
+	namespaces ctrlcache.Cache
+	recorder   record.EventRecorder
+	trigger    chan struct{}
+
+	// warned maps PersistentVolumes warned about to their namespace, so
+	// each is warned about once
+	warned map[string]string
+	// reported holds the unused SVMs already logged in ModeReport
+	reported map[svmKey]bool
+}
+
+// NewCleaner creates a cleaner for the PersistentVolumes of driverName in
+// the given mode (ModeReport or ModeDelete). Namespaces are watched through
+// the shared informers of the controller's manager.
+func NewCleaner(c client.Client, informers ctrlcache.Cache, clientset kubernetes.Interface, backends *arca.BackendRouter, driverName, mode string) *Cleaner {
+	return &Cleaner{
+		client:     c,
+		clientset:  clientset,
+		backends:   backends,
+		driverName: driverName,
+		mode:       mode,
+		namespaces: informers,
+		trigger:    make(chan struct{}, 1),
+		warned:     make(map[string]string),
+		reported:   make(map[svmKey]bool),
+	}
+}
+
+// Run watches Namespaces and checks the storage of terminating and deleted
+// ones on each deletion and every interval until ctx is cancelled
+func (c *Cleaner) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: c.clientset.CoreV1().Events("")})
+	defer broadcaster.Shutdown()
+	c.recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: c.driverName})
+
+	informer, err := c.namespaces.GetInformer(ctx, &corev1.Namespace{})
+	if err != nil {
+		klog.Errorf("Failed to watch Namespaces, namespace cleanup disabled: %v", err)
+		return
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, obj interface{}) {
+			if ns, ok := obj.(*corev1.Namespace); ok && ns.DeletionTimestamp != nil {
+				c.wake()
+			}
+		},
+		DeleteFunc: func(interface{}) { c.wake() },
+	}); err != nil {
+		klog.Errorf("Failed to watch Namespaces, namespace cleanup disabled: %v", err)
+		return
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.cleanup(ctx); err != nil {
+			klog.Errorf("Failed to clean up storage of deleted namespaces: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.trigger:
+		}
+	}
+}
+
+// wake schedules a pass unless one is already pending
+func (c *Cleaner) wake() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// cleanup runs one pass. Namespaces are blocked on storage cleanup while
+// they are terminating with bound PersistentVolumes, or are deleted while
+// volumes or snapshots on their SVM remain.
+func (c *Cleaner) cleanup(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, cleanupTimeout)
+	defer cancel()
+
+	var namespaces corev1.NamespaceList
+	if err := c.namespaces.List(ctx, &namespaces); err != nil {
+		return fmt.Errorf("failed to list Namespaces: %w", err)
+	}
+	terminating := make(map[string]bool)
+	existing := make(map[string]bool, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		existing[ns.Name] = true
+		if ns.DeletionTimestamp != nil {
+			terminating[ns.Name] = true
+		}
+	}
+
+	blocked := make(map[string]bool)
+	if err := c.warnBound(ctx, terminating, blocked); err != nil {
+		return err
+	}
+
+	records, err := c.records(ctx)
+	if err != nil {
+		return err
+	}
+	for _, backend := range c.backends.Backends() {
+		svms, err := backend.Client.ListSVMs(ctx)
+		if err != nil {
+			klog.Warningf("Failed to list SVMs of backend %q for namespace cleanup: %v", backend.Name, err)
+			continue
+		}
+		for _, svm := range svms {
+			namespace, ok := strings.CutPrefix(svm.Name, arca.SVMNamePrefix)
+			if !ok || existing[namespace] && !terminating[namespace] {
+				continue
+			}
+			key := svmKey{backend: backend.Name, svm: svm.Name}
+			if records[key] > 0 {
+				klog.V(4).Infof("SVM %s of deleted namespace %s still holds %d volumes and snapshots", svm.Name, namespace, records[key])
+				blocked[namespace] = true
+				continue
+			}
+			if existing[namespace] {
+				continue
+			}
+			c.release(ctx, backend, namespace, key)
+		}
+	}
+
+	// Forget SVMs that are gone or in use again
+	for key := range c.reported {
+		namespace := strings.TrimPrefix(key.svm, arca.SVMNamePrefix)
+		if existing[namespace] && !terminating[namespace] || records[key] > 0 {
+			delete(c.reported, key)
+		}
+	}
+
+	metrics.NamespacesBlockedOnStorage.Set(float64(len(blocked)))
+	if len(blocked) > 0 {
+		names := make([]string, 0, len(blocked))
+		for namespace := range blocked {
+			names = append(names, namespace)
+		}
+		sort.Strings(names)
+		klog.V(2).Infof("Namespaces blocked on storage cleanup: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// warnBound records a warning Event on each of the driver's
+// PersistentVolumes bound to a claim in a terminating namespace and marks
+// the namespace blocked. Events go to the PersistentVolumes, as a
+// terminating namespace accepts no new Events.
+func (c *Cleaner) warnBound(ctx context.Context, terminating, blocked map[string]bool) error {
+	bound := make(map[string]bool)
+	token := ""
+	for {
+		var list corev1.PersistentVolumeList
+		if err := c.client.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return fmt.Errorf("failed to list PersistentVolumes: %w", err)
+		}
+		for i := range list.Items {
+			pv := &list.Items[i]
+			if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != c.driverName || pv.Spec.ClaimRef == nil {
+				continue
+			}
+			namespace := pv.Spec.ClaimRef.Namespace
+			if !terminating[namespace] || pv.Status.Phase != corev1.VolumeBound {
+				continue
+			}
+			bound[pv.Name] = true
+			blocked[namespace] = true
+			if c.warned[pv.Name] == namespace {
+				continue
+			}
+			c.warned[pv.Name] = namespace
+			klog.Warningf("PersistentVolume %s is still bound to claim %s/%s in terminating namespace %s",
+				pv.Name, namespace, pv.Spec.ClaimRef.Name, namespace)
+			c.recorder.Eventf(pv, corev1.EventTypeWarning, ReasonNamespaceTerminating,
+				"Still bound to claim %s/%s while namespace %s is terminating; the namespace's storage is cleaned up once the claim is deleted",
+				namespace, pv.Spec.ClaimRef.Name, namespace)
+		}
+		token = list.Continue
+		if token == "" {
+			break
+		}
+	}
+
+	for name := range c.warned {
+		if !bound[name] {
+			delete(c.warned, name)
+		}
+	}
+	return nil
+}
+
+// records counts the ArcaVolumes and ArcaSnapshots per SVM
+func (c *Cleaner) records(ctx context.Context) (map[svmKey]int, error) {
+	counts := make(map[svmKey]int)
+	token := ""
+	for {
+		var list v1alpha1.ArcaVolumeList
+		if err := c.client.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return nil, fmt.Errorf("failed to list ArcaVolumes: %w", err)
+		}
+		for _, av := range list.Items {
+			counts[svmKey{backend: av.Spec.Backend, svm: av.Spec.SVMName}]++
+		}
+		token = list.Continue
+		if token == "" {
+			break
+		}
+	}
+	for {
+		var list v1alpha1.ArcaSnapshotList
+		if err := c.client.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return nil, fmt.Errorf("failed to list ArcaSnapshots: %w", err)
+		}
+		for _, as := range list.Items {
+			counts[svmKey{backend: as.Spec.Backend, svm: as.Spec.SVMName}]++
+		}
+		token = list.Continue
+		if token == "" {
+			break
+		}
+	}
+	return counts, nil
+}
+
+// release reports or deletes the unused SVM of a deleted namespace
+func (c *Cleaner) release(ctx context.Context, backend *arca.Backend, namespace string, key svmKey) {
+	if c.mode != ModeDelete {
+		if !c.reported[key] {
+			klog.Infof("SVM %s of deleted namespace %s holds no volumes or snapshots and can be deleted", key.svm, namespace)
+			c.reported[key] = true
+		}
+		return
+	}
+
+	// Re-check under the namespace's lock, so a namespace recreated in the
+	// meantime keeps its SVM
+	err := backend.SVMManager.DeleteNamespaceSVM(ctx, namespace, func(ctx context.Context) error {
+		_, err := c.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if err == nil {
+			return fmt.Errorf("namespace %s was recreated", namespace)
+		}
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		records, err := c.records(ctx)
+		if err != nil {
+			return err
+		}
+		if records[key] > 0 {
+			return fmt.Errorf("SVM %s holds %d volumes and snapshots again", key.svm, records[key])
+		}
+		return nil
+	})
+	if err != nil {
+		klog.Warningf("Not deleting SVM %s of deleted namespace %s: %v", key.svm, namespace, err)
+		return
+	}
+	klog.Infof("Deleted SVM %s of deleted namespace %s", key.svm, namespace)
+}
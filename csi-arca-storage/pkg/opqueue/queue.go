@@ -0,0 +1,520 @@
+// Package opqueue records long-running controller operations (SVM creation,
+// clones, volume deletion) as ArcaOperations while they are in progress, so
+// that a restarted controller resumes them instead of waiting for the CSI
+// sidecars to call again. An operation runs at most once at a time per type
+// and key: in one controller concurrent callers share a single execution,
+// and across controllers the running one renews its claim on the record.
+package opqueue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// Operation types
+const (
+	// TypeSVMCreate creates the SVM of the namespace in Key
+	TypeSVMCreate = "SVMCreate"
+	// TypeClone copies a volume or snapshot to the volume ID in Key
+	TypeClone = "Clone"
+	// TypeVolumeDelete deletes the data and record of the volume ID in Key
+	TypeVolumeDelete = "VolumeDelete"
+)
+
+// Marker types record a value rather than an operation: they are never
+// resumed and are deleted by their writer, or after markerRetention
+const (
+	// TypeVolumeID records the volume ID assigned to the CSI request name
+	// in Key
+	TypeVolumeID = "VolumeID"
+	// TypeSnapshotID records the snapshot ID assigned to the
+	// "<sourceVolumeID>/<name>" in Key
+	TypeSnapshotID = "SnapshotID"
+)
+
+// markerRetention is how long a marker its writer never deleted is kept,
+// e.g. that of a CreateVolume abandoned by the provisioner
+const markerRetention = 7 * 24 * time.Hour
+
+// isMarker reports whether records of opType are markers
+func isMarker(opType string) bool {
+	return opType == TypeVolumeID || opType == TypeSnapshotID
+}
+
+// DefaultInterval is how often recorded operations are checked for ones to
+// resume
+const DefaultInterval = 30 * time.Second
+
+const (
+	// renewInterval is how often a running operation renews its claim
+	renewInterval = 15 * time.Second
+	// staleAfter is how long a claim lasts without renewal, after which
+	// another controller may take the operation over
+	staleAfter = time.Minute
+
+	// maxAttempts bounds the resumptions of an operation before it is
+	// marked Failed
+	maxAttempts = 10
+	// Backoff between resumptions of a failing operation
+	retryInitial = 30 * time.Second
+	retryMax     = 30 * time.Minute
+
+	// failedRetention is how long Failed operations are kept for operators
+	failedRetention = 24 * time.Hour
+
+	// resumeTimeout bounds a resumed operation
+	resumeTimeout = 30 * time.Minute
+	// requestTimeout bounds a single ArcaOperation request
+	requestTimeout = 10 * time.Second
+)
+
+// ErrInProgress is returned by Do while another controller runs the
+// operation
+var ErrInProgress = errors.New("operation is in progress")
+
+// Operation is a long-running operation
+type Operation struct {
+	Type string
+	Key  string
+	// Backend is the ARCA backend (tenant), empty for the default backend
+	Backend string
+	// Parameters are what a Handler needs to resume the operation
+	Parameters map[string]string
+}
+
+// Handler resumes a recorded operation; it must be idempotent, as the
+// interrupted attempt may have completed any part of it
+type Handler func(ctx context.Context, op *Operation) error
+
+// Queue runs operations while recording them as ArcaOperations and resumes
+// the operations that a previous controller left behind (controller only)
+type Queue struct {
+	client   client.Client
+	identity string
+
+	// group lets concurrent callers of one operation share its execution
+	group singleflight.Group
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	// resuming holds the operations resumed in the background
+	resuming map[string]bool
+}
+
+// operationKey marks contexts of running operations with their record name
+type operationKey struct{}
+
+// NewQueue creates a queue whose claims are held as identity, which must be
+// unique per controller instance
+func NewQueue(c client.Client, identity string) *Queue {
+	return &Queue{
+		client:   c,
+		identity: identity,
+		handlers: make(map[string]Handler),
+		resuming: make(map[string]bool),
+	}
+}
+
+// Handle registers the handler resuming operations of opType
+func (q *Queue) Handle(opType string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[opType] = h
+}
+
+// Name returns the name of the ArcaOperation of an operation
+func Name(opType, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return strings.ToLower(opType) + "-" + hex.EncodeToString(sum[:10])
+}
+
+// Do runs fn as op, recorded until it completes. Callers of an operation
+// already running in this controller wait for its result; while another
+// controller runs it, Do returns ErrInProgress. When fn fails, the record
+// is deleted and retrying is left to the caller, unless ctx ended first:
+// then the operation is resumed in the background.
+func (q *Queue) Do(ctx context.Context, op *Operation, fn func(context.Context) error) error {
+	name := Name(op.Type, op.Key)
+	// Nested calls for the operation being run, e.g. by its Handler
+	if ctx.Value(operationKey{}) == name {
+		return fn(ctx)
+	}
+	_, err, _ := q.group.Do(name, func() (interface{}, error) {
+		return nil, q.execute(ctx, name, op, fn, false)
+	})
+	return err
+}
+
+// execute claims the record of an operation, runs fn while renewing the
+// claim and records the outcome
+func (q *Queue) execute(ctx context.Context, name string, op *Operation, fn func(context.Context) error, resumed bool) error {
+	attempts, err := q.claim(ctx, name, op)
+	if err != nil {
+		return err
+	}
+
+	renewCtx, stopRenewing := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		q.renew(renewCtx, name)
+	}()
+
+	err = fn(context.WithValue(ctx, operationKey{}, name))
+	stopRenewing()
+	<-renewed
+
+	switch {
+	case err == nil:
+		q.complete(name)
+	case resumed || ctx.Err() != nil:
+		q.release(name, attempts, err)
+	default:
+		q.complete(name)
+	}
+	return err
+}
+
+// claim records the operation as running in this controller and returns
+// its attempt number
+func (q *Queue) claim(ctx context.Context, name string, op *Operation) (int32, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	now := metav1.NowMicro()
+	record := &v1alpha1.ArcaOperation{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1alpha1.ArcaOperationSpec{
+			Type:       op.Type,
+			Key:        op.Key,
+			Backend:    op.Backend,
+			Parameters: op.Parameters,
+		},
+		Status: v1alpha1.ArcaOperationStatus{
+			Phase:          v1alpha1.ArcaOperationPhaseRunning,
+			HolderIdentity: q.identity,
+			RenewTime:      &now,
+			Attempts:       1,
+		},
+	}
+	err := q.client.Create(ctx, record)
+	if err == nil {
+		return 1, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return 0, fmt.Errorf("failed to record %s operation %s: %w", op.Type, op.Key, err)
+	}
+
+	if err := q.client.Get(ctx, client.ObjectKey{Name: name}, record); err != nil {
+		return 0, fmt.Errorf("failed to get ArcaOperation %s: %w", name, err)
+	}
+	if record.Spec.Type != op.Type || record.Spec.Key != op.Key {
+		return 0, fmt.Errorf("ArcaOperation %s records %s operation %s, not %s operation %s",
+			name, record.Spec.Type, record.Spec.Key, op.Type, op.Key)
+	}
+	if claimed(record) {
+		return 0, fmt.Errorf("%w: %s operation %s is running in %s", ErrInProgress, op.Type, op.Key, record.Status.HolderIdentity)
+	}
+
+	// Take over a pending, failed or abandoned operation
+	if op.Parameters != nil {
+		record.Spec.Parameters = op.Parameters
+	}
+	record.Spec.Backend = op.Backend
+	record.Status = v1alpha1.ArcaOperationStatus{
+		Phase:          v1alpha1.ArcaOperationPhaseRunning,
+		HolderIdentity: q.identity,
+		RenewTime:      &now,
+		Attempts:       record.Status.Attempts + 1,
+	}
+	if err := q.client.Update(ctx, record); err != nil {
+		if apierrors.IsConflict(err) {
+			return 0, fmt.Errorf("%w: %s operation %s was claimed concurrently", ErrInProgress, op.Type, op.Key)
+		}
+		return 0, fmt.Errorf("failed to claim ArcaOperation %s: %w", name, err)
+	}
+	return record.Status.Attempts, nil
+}
+
+// claimed reports whether a controller is running the operation
+func claimed(record *v1alpha1.ArcaOperation) bool {
+	return record.Status.Phase == v1alpha1.ArcaOperationPhaseRunning &&
+		record.Status.RenewTime != nil && time.Since(record.Status.RenewTime.Time) < staleAfter
+}
+
+// renew renews the claim on an operation until ctx is cancelled
+func (q *Queue) renew(ctx context.Context, name string) {
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := q.update(ctx, name, func(record *v1alpha1.ArcaOperation) {
+			now := metav1.NowMicro()
+			record.Status.RenewTime = &now
+		})
+		if err != nil {
+			klog.Warningf("Failed to renew ArcaOperation %s: %v", name, err)
+		}
+	}
+}
+
+// complete deletes the record of a finished operation
+func (q *Queue) complete(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	record := &v1alpha1.ArcaOperation{}
+	err := q.client.Get(ctx, client.ObjectKey{Name: name}, record)
+	if err == nil && record.Status.HolderIdentity == q.identity {
+		uid := types.UID(record.UID)
+		err = q.client.Delete(ctx, record, client.Preconditions{UID: &uid, ResourceVersion: &record.ResourceVersion})
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		// The operation is then resumed, which its handler must allow
+		klog.Warningf("Failed to delete ArcaOperation %s: %v", name, err)
+	}
+}
+
+// release leaves a failed or interrupted operation to be resumed after a
+// backoff, or marks it Failed once its attempts are exhausted
+func (q *Queue) release(name string, attempts int32, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	err := q.update(ctx, name, func(record *v1alpha1.ArcaOperation) {
+		record.Status.Phase = v1alpha1.ArcaOperationPhasePending
+		record.Status.HolderIdentity = ""
+		record.Status.LastError = truncate(cause.Error(), 1024)
+		next := metav1.NewTime(time.Now().Add(backoff(attempts)))
+		record.Status.NextAttempt = &next
+		if attempts >= maxAttempts {
+			record.Status.Phase = v1alpha1.ArcaOperationPhaseFailed
+			record.Status.NextAttempt = nil
+		}
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Warningf("Failed to release ArcaOperation %s: %v", name, err)
+	}
+}
+
+// update changes the record of an operation held by this controller
+func (q *Queue) update(ctx context.Context, name string, mutate func(*v1alpha1.ArcaOperation)) error {
+	record := &v1alpha1.ArcaOperation{}
+	if err := q.client.Get(ctx, client.ObjectKey{Name: name}, record); err != nil {
+		return err
+	}
+	if record.Status.HolderIdentity != q.identity {
+		return fmt.Errorf("claim was taken over by %q", record.Status.HolderIdentity)
+	}
+	mutate(record)
+	return q.client.Update(ctx, record)
+}
+
+// Run resumes the recorded operations that no controller runs, every
+// interval until ctx is cancelled
+func (q *Queue) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := q.resume(ctx); err != nil {
+			klog.Errorf("Failed to resume operations: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resume starts the operations due to be resumed and deletes expired
+// Failed ones
+func (q *Queue) resume(ctx context.Context) error {
+	listCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	list := &v1alpha1.ArcaOperationList{}
+	if err := q.client.List(listCtx, list); err != nil {
+		return fmt.Errorf("failed to list ArcaOperations: %w", err)
+	}
+
+	metrics.QueuedOperations.Reset()
+	for i := range list.Items {
+		record := &list.Items[i]
+		metrics.QueuedOperations.WithLabelValues(record.Spec.Type, string(record.Status.Phase)).Inc()
+
+		switch {
+		case isMarker(record.Spec.Type):
+			if record.Status.RenewTime == nil || time.Since(record.Status.RenewTime.Time) > markerRetention {
+				if err := q.client.Delete(listCtx, record); err != nil && !apierrors.IsNotFound(err) {
+					klog.Warningf("Failed to delete expired ArcaOperation %s: %v", record.Name, err)
+				}
+			}
+			continue
+		case record.Status.Phase == v1alpha1.ArcaOperationPhaseFailed:
+			if record.Status.RenewTime == nil || time.Since(record.Status.RenewTime.Time) > failedRetention {
+				if err := q.client.Delete(listCtx, record); err != nil && !apierrors.IsNotFound(err) {
+					klog.Warningf("Failed to delete failed ArcaOperation %s: %v", record.Name, err)
+				}
+			}
+			continue
+		case claimed(record):
+			continue
+		case record.Status.NextAttempt != nil && time.Now().Before(record.Status.NextAttempt.Time):
+			continue
+		}
+
+		q.mu.Lock()
+		handler := q.handlers[record.Spec.Type]
+		busy := q.resuming[record.Name]
+		if handler != nil && !busy {
+			q.resuming[record.Name] = true
+		}
+		q.mu.Unlock()
+		if handler == nil {
+			klog.V(4).Infof("No handler resumes %s operations, leaving ArcaOperation %s", record.Spec.Type, record.Name)
+			continue
+		}
+		if busy {
+			continue
+		}
+
+		op := &Operation{
+			Type:       record.Spec.Type,
+			Key:        record.Spec.Key,
+			Backend:    record.Spec.Backend,
+			Parameters: record.Spec.Parameters,
+		}
+		go q.resumeOperation(ctx, record.Name, op, handler)
+	}
+	return nil
+}
+
+// resumeOperation runs a recorded operation's handler
+func (q *Queue) resumeOperation(ctx context.Context, name string, op *Operation, handler Handler) {
+	defer func() {
+		q.mu.Lock()
+		delete(q.resuming, name)
+		q.mu.Unlock()
+	}()
+
+	klog.Infof("Resuming %s operation %s", op.Type, op.Key)
+	_, err, _ := q.group.Do(name, func() (interface{}, error) {
+		return nil, q.execute(ctx, name, op, func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, resumeTimeout)
+			defer cancel()
+			return handler(ctx, op)
+		}, true)
+	})
+	switch {
+	case errors.Is(err, ErrInProgress):
+		klog.V(4).Infof("Not resuming %s operation %s: %v", op.Type, op.Key, err)
+	case err != nil:
+		metrics.ResumedOperations.WithLabelValues(op.Type, "error").Inc()
+		klog.Warningf("Resumed %s operation %s failed: %v", op.Type, op.Key, err)
+	default:
+		metrics.ResumedOperations.WithLabelValues(op.Type, "success").Inc()
+		klog.Infof("Resumed %s operation %s completed", op.Type, op.Key)
+	}
+}
+
+// Marker returns the parameters of the marker of opType and key, with found
+// false when there is none
+func (q *Queue) Marker(ctx context.Context, opType, key string) (params map[string]string, found bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	record := &v1alpha1.ArcaOperation{}
+	if err := q.client.Get(ctx, client.ObjectKey{Name: Name(opType, key)}, record); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get %s marker %s: %w", opType, key, err)
+	}
+	if record.Spec.Type != opType || record.Spec.Key != key {
+		return nil, false, fmt.Errorf("ArcaOperation %s records %s %s, not %s marker %s",
+			record.Name, record.Spec.Type, record.Spec.Key, opType, key)
+	}
+	return record.Spec.Parameters, true, nil
+}
+
+// SetMarker records a marker of opType and key with params, failing when
+// one already exists
+func (q *Queue) SetMarker(ctx context.Context, opType, key string, params map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	now := metav1.NowMicro()
+	record := &v1alpha1.ArcaOperation{
+		ObjectMeta: metav1.ObjectMeta{Name: Name(opType, key)},
+		Spec: v1alpha1.ArcaOperationSpec{
+			Type:       opType,
+			Key:        key,
+			Parameters: params,
+		},
+		Status: v1alpha1.ArcaOperationStatus{
+			Phase:     v1alpha1.ArcaOperationPhasePending,
+			RenewTime: &now,
+		},
+	}
+	if err := q.client.Create(ctx, record); err != nil {
+		return fmt.Errorf("failed to record %s marker %s: %w", opType, key, err)
+	}
+	return nil
+}
+
+// DeleteMarker deletes the marker of opType and key, if any
+func (q *Queue) DeleteMarker(ctx context.Context, opType, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	record := &v1alpha1.ArcaOperation{ObjectMeta: metav1.ObjectMeta{Name: Name(opType, key)}}
+	if err := q.client.Delete(ctx, record); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s marker %s: %w", opType, key, err)
+	}
+	return nil
+}
+
+// backoff returns the delay before the next attempt of a failing operation
+func backoff(attempts int32) time.Duration {
+	delay := retryInitial
+	for i := int32(1); i < attempts && delay < retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMax)
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
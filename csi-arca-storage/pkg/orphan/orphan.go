@@ -0,0 +1,155 @@
+// Package orphan finds backend directories and snapshots the driver created
+// that no ArcaVolume or ArcaSnapshot records any more, e.g. after a crash
+// between creating them and recording them, or after the resources were
+// deleted by hand.
+package orphan
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/idempotency"
+)
+
+// DefaultGracePeriod is how old a directory or snapshot must be to be
+// reported; younger ones may belong to a CreateVolume or CreateSnapshot that
+// has not recorded them yet
+const DefaultGracePeriod = time.Hour
+
+// listPageSize is the page size used when listing ArcaVolumes and
+// ArcaSnapshots
+const listPageSize = 500
+
+// DefaultSnapshotDir is the backend directory the driver creates snapshots
+// in by default (relative to the SVM root, as in the driver)
+const DefaultSnapshotDir = ".snapshots"
+
+// location identifies a backend path
+type location struct {
+	backend string
+	svm     string
+	path    string
+}
+
+// Index holds the backend paths recorded in ArcaVolumes and ArcaSnapshots
+type Index struct {
+	volumes   map[location]bool
+	snapshots map[location]bool
+}
+
+// LoadIndex lists all ArcaVolumes and ArcaSnapshots into an index
+func LoadIndex(ctx context.Context, c client.Client) (*Index, error) {
+	index := &Index{
+		volumes:   make(map[location]bool),
+		snapshots: make(map[location]bool),
+	}
+
+	token := ""
+	for {
+		var list v1alpha1.ArcaVolumeList
+		if err := c.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return nil, fmt.Errorf("failed to list ArcaVolumes: %w", err)
+		}
+		for i := range list.Items {
+			spec := &list.Items[i].Spec
+			index.volumes[location{spec.Backend, spec.SVMName, strings.TrimPrefix(spec.Path, "/")}] = true
+		}
+		token = list.Continue
+		if token == "" {
+			break
+		}
+	}
+
+	for {
+		var list v1alpha1.ArcaSnapshotList
+		if err := c.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return nil, fmt.Errorf("failed to list ArcaSnapshots: %w", err)
+		}
+		for i := range list.Items {
+			spec := &list.Items[i].Spec
+			index.snapshots[location{spec.Backend, spec.SVMName, strings.TrimPrefix(spec.Path, "/")}] = true
+		}
+		token = list.Continue
+		if token == "" {
+			return index, nil
+		}
+	}
+}
+
+// HasVolume reports whether an ArcaVolume records the path
+func (x *Index) HasVolume(backend, svmName, path string) bool {
+	return x.volumes[location{backend, svmName, strings.TrimPrefix(path, "/")}]
+}
+
+// HasSnapshot reports whether an ArcaSnapshot records the path
+func (x *Index) HasSnapshot(backend, svmName, path string) bool {
+	return x.snapshots[location{backend, svmName, strings.TrimPrefix(path, "/")}]
+}
+
+// FindDirectories returns the volume directories of an SVM on a backend that
+// no ArcaVolume in index records. Directories not named like a volume ID are
+// not the driver's and are skipped, as are directories created within grace
+// (DefaultGracePeriod when not positive).
+func FindDirectories(ctx context.Context, c *arca.Client, backend, svmName string, index *Index, grace time.Duration) ([]arca.DirectoryInfo, error) {
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+
+	directories, err := c.ListDirectories(ctx, svmName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directories of SVM %s: %w", svmName, err)
+	}
+
+	var orphans []arca.DirectoryInfo
+	cutoff := time.Now().Add(-grace)
+	for _, dir := range directories {
+		p := strings.TrimPrefix(dir.Path, "/")
+		if !idempotency.IsVolumeID(p) || index.HasVolume(backend, svmName, p) {
+			continue
+		}
+		if !dir.CreatedAt.IsZero() && dir.CreatedAt.After(cutoff) {
+			continue
+		}
+		orphans = append(orphans, dir)
+	}
+	return orphans, nil
+}
+
+// FindSnapshots returns the snapshots of an SVM on a backend that no
+// ArcaSnapshot in index records. Snapshots outside the driver's snapshot
+// directory (snapshotDir or DefaultSnapshotDir) or not named like a
+// snapshot ID are skipped, as are snapshots created within grace
+// (DefaultGracePeriod when not positive).
+func FindSnapshots(ctx context.Context, c *arca.Client, backend, svmName, snapshotDir string, index *Index, grace time.Duration) ([]arca.SnapshotInfo, error) {
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+
+	snapshots, err := c.ListSnapshots(ctx, svmName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots of SVM %s: %w", svmName, err)
+	}
+
+	var orphans []arca.SnapshotInfo
+	cutoff := time.Now().Add(-grace)
+	for _, snap := range snapshots {
+		p := strings.TrimPrefix(snap.Path, "/")
+		dir, id := path.Split(p)
+		inDir := dir == DefaultSnapshotDir+"/" || snapshotDir != "" && dir == snapshotDir+"/"
+		if !inDir || !idempotency.IsSnapshotID(id) || index.HasSnapshot(backend, svmName, p) {
+			continue
+		}
+		if !snap.CreatedAt.IsZero() && snap.CreatedAt.After(cutoff) {
+			continue
+		}
+		orphans = append(orphans, snap)
+	}
+	return orphans, nil
+}
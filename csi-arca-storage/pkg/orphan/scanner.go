@@ -0,0 +1,214 @@
+package orphan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// DefaultInterval is how often backends are scanned
+const DefaultInterval = time.Hour
+
+// scanTimeout bounds a single scan
+const scanTimeout = 30 * time.Minute
+
+// Policies for orphaned snapshots
+const (
+	// SnapshotPolicyReport only reports orphaned snapshots
+	SnapshotPolicyReport = "report"
+	// SnapshotPolicyDelete deletes orphaned snapshots whose backend creation
+	// time is known
+	SnapshotPolicyDelete = "delete"
+)
+
+// svmKey identifies an SVM on a backend
+type svmKey struct {
+	backend string
+	svm     string
+}
+
+// found holds the orphaned paths of an SVM
+type found struct {
+	directories map[string]bool
+	snapshots   map[string]bool
+}
+
+// Scanner periodically reports the orphaned directories and snapshots of
+// every driver-created SVM. Directories are only reported; snapshots are
+// deleted with SnapshotPolicyDelete.
+type Scanner struct {
+	client         client.Client
+	backends       *arca.BackendRouter
+	grace          time.Duration
+	snapshotPolicy string
+	snapshotDir    string
+
+	// reported maps SVMs to the orphans found in the last scan, so each is
+	// logged once and metrics of gone SVMs dropped
+	reported map[svmKey]found
+}
+
+// NewScanner creates an orphan scanner. Directories and snapshots younger
+// than grace are not reported (DefaultGracePeriod when not positive).
+func NewScanner(c client.Client, backends *arca.BackendRouter, grace time.Duration) *Scanner {
+	return &Scanner{
+		client:         c,
+		backends:       backends,
+		grace:          grace,
+		snapshotPolicy: SnapshotPolicyReport,
+		reported:       make(map[svmKey]found),
+	}
+}
+
+// SetSnapshotDir sets the snapshot directory configured for the driver, in
+// addition to DefaultSnapshotDir
+func (s *Scanner) SetSnapshotDir(dir string) {
+	s.snapshotDir = dir
+}
+
+// SetSnapshotPolicy sets what is done with orphaned snapshots
+// (SnapshotPolicyReport when empty)
+func (s *Scanner) SetSnapshotPolicy(policy string) {
+	if policy == "" {
+		policy = SnapshotPolicyReport
+	}
+	s.snapshotPolicy = policy
+}
+
+// Run scans the backends every interval until ctx is cancelled
+func (s *Scanner) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.scan(ctx); err != nil {
+			klog.Errorf("Failed to scan for orphaned directories and snapshots: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan runs one pass over the SVMs of all backends. The ArcaVolumes and
+// ArcaSnapshots are listed before the backend, so anything created in
+// between is young enough to be skipped.
+func (s *Scanner) scan(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, scanTimeout)
+	defer cancel()
+
+	index, err := LoadIndex(ctx, s.client)
+	if err != nil {
+		return err
+	}
+
+	reported := make(map[svmKey]found)
+	for _, backend := range s.backends.Backends() {
+		svms, err := backend.Client.ListSVMs(ctx)
+		if err != nil {
+			klog.Errorf("Failed to list SVMs on backend %q: %v", backend.Name, err)
+			// Keep what was found so the SVMs' metrics are not dropped
+			for key, f := range s.reported {
+				if key.backend == backend.Name {
+					reported[key] = f
+				}
+			}
+			continue
+		}
+		for _, svm := range svms {
+			if !strings.HasPrefix(svm.Name, arca.SVMNamePrefix) {
+				continue
+			}
+			key := svmKey{backend.Name, svm.Name}
+			previous := s.reported[key]
+			f := found{
+				directories: s.scanDirectories(ctx, backend, svm.Name, index, previous.directories),
+				snapshots:   s.scanSnapshots(ctx, backend, svm.Name, index, previous.snapshots),
+			}
+			metrics.OrphanedDirectories.WithLabelValues(svm.Name, backend.Name).Set(float64(len(f.directories)))
+			metrics.OrphanedSnapshots.WithLabelValues(svm.Name, backend.Name).Set(float64(len(f.snapshots)))
+			reported[key] = f
+		}
+	}
+
+	for key := range s.reported {
+		if _, ok := reported[key]; !ok {
+			metrics.OrphanedDirectories.DeleteLabelValues(key.svm, key.backend)
+			metrics.OrphanedSnapshots.DeleteLabelValues(key.svm, key.backend)
+		}
+	}
+	s.reported = reported
+	return nil
+}
+
+// scanDirectories returns the orphaned directories of an SVM, logging those
+// not in previous. On errors previous is kept.
+func (s *Scanner) scanDirectories(ctx context.Context, backend *arca.Backend, svmName string, index *Index, previous map[string]bool) map[string]bool {
+	orphans, err := FindDirectories(ctx, backend.Client, backend.Name, svmName, index, s.grace)
+	if err != nil {
+		klog.Errorf("Failed to scan directories of SVM %s on backend %q: %v", svmName, backend.Name, err)
+		return previous
+	}
+
+	paths := make(map[string]bool, len(orphans))
+	for _, dir := range orphans {
+		paths[dir.Path] = true
+		if !previous[dir.Path] {
+			klog.Warningf("Directory %s of SVM %s on backend %q has no ArcaVolume (created %s, %d bytes used)",
+				dir.Path, svmName, backend.Name, dir.CreatedAt.Format(time.RFC3339), dir.UsedBytes)
+		}
+	}
+	return paths
+}
+
+// scanSnapshots returns the orphaned snapshots of an SVM that remain after
+// applying the snapshot policy, logging those not in previous. On errors
+// previous is kept.
+func (s *Scanner) scanSnapshots(ctx context.Context, backend *arca.Backend, svmName string, index *Index, previous map[string]bool) map[string]bool {
+	orphans, err := FindSnapshots(ctx, backend.Client, backend.Name, svmName, s.snapshotDir, index, s.grace)
+	if err != nil {
+		klog.Errorf("Failed to scan snapshots of SVM %s on backend %q: %v", svmName, backend.Name, err)
+		return previous
+	}
+
+	paths := make(map[string]bool, len(orphans))
+	for _, snap := range orphans {
+		// A snapshot of unknown age may be one a CreateSnapshot is about to
+		// record, so it is never deleted
+		if s.snapshotPolicy == SnapshotPolicyDelete && !snap.CreatedAt.IsZero() {
+			err := backend.Client.DeleteSnapshot(ctx, svmName, snap.Path)
+			if err == nil {
+				metrics.OrphanedSnapshotsDeleted.WithLabelValues(backend.Name).Inc()
+				klog.Infof("Deleted snapshot %s of SVM %s on backend %q: no ArcaSnapshot (created %s)",
+					snap.Path, svmName, backend.Name, snap.CreatedAt.Format(time.RFC3339))
+				continue
+			}
+			if errors.Is(err, arca.ErrSnapshotHasDependents) {
+				klog.V(2).Infof("Not deleting orphaned snapshot %s of SVM %s: %v", snap.Path, svmName, err)
+			} else {
+				klog.Errorf("Failed to delete orphaned snapshot %s of SVM %s on backend %q: %v", snap.Path, svmName, backend.Name, err)
+			}
+		}
+
+		paths[snap.Path] = true
+		if !previous[snap.Path] {
+			klog.Warningf("Snapshot %s of SVM %s on backend %q has no ArcaSnapshot (created %s, %d bytes)",
+				snap.Path, svmName, backend.Name, snap.CreatedAt.Format(time.RFC3339), snap.SizeBytes)
+		}
+	}
+	return paths
+}
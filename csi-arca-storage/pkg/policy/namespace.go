@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// NamespaceFilter restricts which namespaces may trigger SVM creation.
+// Deny patterns take precedence over allow patterns; the label selector is
+// evaluated against the Namespace object and must also match.
+type NamespaceFilter struct {
+	allow    []*regexp.Regexp
+	deny     []*regexp.Regexp
+	selector labels.Selector
+	client   kubernetes.Interface
+}
+
+// NewNamespaceFilter creates a namespace filter.
+// Patterns are regular expressions matched against the full namespace name.
+func NewNamespaceFilter(allowed, denied []string, selector string, client kubernetes.Interface) (*NamespaceFilter, error) {
+	f := &NamespaceFilter{client: client}
+
+	var err error
+	if f.allow, err = compilePatterns(allowed); err != nil {
+		return nil, fmt.Errorf("invalid allowed namespace pattern: %w", err)
+	}
+	if f.deny, err = compilePatterns(denied); err != nil {
+		return nil, fmt.Errorf("invalid denied namespace pattern: %w", err)
+	}
+
+	if selector != "" {
+		if client == nil {
+			return nil, fmt.Errorf("namespace selector requires a Kubernetes client")
+		}
+		if f.selector, err = labels.Parse(selector); err != nil {
+			return nil, fmt.Errorf("invalid namespace selector %q: %w", selector, err)
+		}
+	}
+
+	return f, nil
+}
+
+// AllowSVMCreation reports whether the namespace may trigger SVM creation.
+// When creation is denied, the returned reason explains why.
+func (f *NamespaceFilter) AllowSVMCreation(ctx context.Context, namespace string) (bool, string, error) {
+	allowed, reason, err := f.matches(ctx, namespace)
+	if allowed {
+		klog.V(4).Infof("Namespace %s is allowed to create SVMs", namespace)
+	}
+	return allowed, reason, err
+}
+
+// matches reports whether the namespace passes the filter, with the reason
+// when it does not
+func (f *NamespaceFilter) matches(ctx context.Context, namespace string) (bool, string, error) {
+	for _, re := range f.deny {
+		if re.MatchString(namespace) {
+			return false, fmt.Sprintf("namespace %s matches denied pattern %q", namespace, re.String()), nil
+		}
+	}
+
+	if len(f.allow) > 0 {
+		allowed := false
+		for _, re := range f.allow {
+			if re.MatchString(namespace) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false, fmt.Sprintf("namespace %s does not match any allowed pattern", namespace), nil
+		}
+	}
+
+	if f.selector != nil {
+		ns, err := f.client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get namespace %s for selector check: %w", namespace, err)
+		}
+		if !f.selector.Matches(labels.Set(ns.Labels)) {
+			return false, fmt.Sprintf("namespace %s does not match selector %q", namespace, f.selector.String()), nil
+		}
+	}
+
+	return true, "", nil
+}
+
+// compilePatterns compiles regular expressions anchored to the full string
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", p, err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
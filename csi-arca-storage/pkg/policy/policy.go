@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"k8s.io/klog/v2"
+)
+
+// Request describes a provisioning request evaluated by the policy engine
+type Request struct {
+	Name          string
+	Namespace     string
+	CapacityBytes int64
+	Parameters    map[string]string
+}
+
+// Policy decides whether a provisioning request is admitted
+type Policy interface {
+	// Admit returns a *Violation if the request must be rejected
+	Admit(ctx context.Context, req *Request) error
+}
+
+// Rule is a simple declarative deny rule.
+// A rule matches when all of its selectors match the request; an empty
+// selector matches everything.
+type Rule struct {
+	Name string
+
+	// Namespaces is a list of glob patterns (path.Match syntax)
+	Namespaces []string
+
+	// Parameters must all be present with equal values ("*" matches any value)
+	Parameters map[string]string
+
+	// MinCapacityBytes/MaxCapacityBytes restrict the rule to requests outside
+	// the given bounds (0 disables the bound)
+	MinCapacityBytes int64
+	MaxCapacityBytes int64
+
+	// Message is returned to the user when the rule denies a request
+	Message string
+}
+
+// Violation is returned when a request is denied by a rule
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	if v.Message != "" {
+		return fmt.Sprintf("denied by policy %q: %s", v.Rule, v.Message)
+	}
+	return fmt.Sprintf("denied by policy %q", v.Rule)
+}
+
+// Engine evaluates an ordered list of deny rules
+type Engine struct {
+	rules  []Rule
+	dryRun bool
+}
+
+// NewEngine creates a new rule-based policy engine.
+// In dry-run mode violations are logged but requests are admitted.
+func NewEngine(rules []Rule, dryRun bool) (*Engine, error) {
+	if err := ValidateRules(rules); err != nil {
+		return nil, err
+	}
+
+	klog.V(2).Infof("Loaded %d provisioning policy rules (dry-run: %v)", len(rules), dryRun)
+
+	return &Engine{
+		rules:  rules,
+		dryRun: dryRun,
+	}, nil
+}
+
+// ValidateRules checks that every rule is named, its namespace patterns
+// parse and its capacity bounds are ordered
+func ValidateRules(rules []Rule) error {
+	for i, r := range rules {
+		if r.Name == "" {
+			return fmt.Errorf("policy rule %d: name is required", i)
+		}
+		for _, pattern := range r.Namespaces {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("policy rule %s: invalid namespace pattern %q: %w", r.Name, pattern, err)
+			}
+		}
+		if r.MinCapacityBytes > 0 && r.MaxCapacityBytes > 0 && r.MinCapacityBytes > r.MaxCapacityBytes {
+			return fmt.Errorf("policy rule %s: min capacity exceeds max capacity", r.Name)
+		}
+	}
+	return nil
+}
+
+// Admit evaluates all rules and returns the first violation
+func (e *Engine) Admit(ctx context.Context, req *Request) error {
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if !rule.matches(req) {
+			continue
+		}
+
+		violation := &Violation{Rule: rule.Name, Message: rule.Message}
+		if e.dryRun {
+			klog.Warningf("[dry-run] CreateVolume %s in namespace %s would be %v", req.Name, req.Namespace, violation)
+			continue
+		}
+		return violation
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to the request
+func (r *Rule) matches(req *Request) bool {
+	if len(r.Namespaces) > 0 && !matchAny(r.Namespaces, req.Namespace) {
+		return false
+	}
+
+	for key, want := range r.Parameters {
+		got, ok := req.Parameters[key]
+		if !ok || (want != "*" && got != want) {
+			return false
+		}
+	}
+
+	// Without capacity bounds the rule denies every matching request
+	if r.MinCapacityBytes == 0 && r.MaxCapacityBytes == 0 {
+		return true
+	}
+	if r.MinCapacityBytes > 0 && req.CapacityBytes < r.MinCapacityBytes {
+		return true
+	}
+	if r.MaxCapacityBytes > 0 && req.CapacityBytes > r.MaxCapacityBytes {
+		return true
+	}
+	return false
+}
+
+// matchAny checks if name matches any of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
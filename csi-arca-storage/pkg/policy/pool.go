@@ -0,0 +1,78 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// PoolRule reserves pools for the namespaces it matches
+type PoolRule struct {
+	// Pools are the names of the pools reserved for matching namespaces
+	Pools []string
+	// Namespaces are regular expressions matched against the full
+	// namespace name; empty matches every name
+	Namespaces []string
+	// Selector is a label selector the Namespace object must also match
+	Selector string
+}
+
+// PoolAffinity maps namespaces to the IP pools their SVMs are allocated
+// in. The first rule matching a namespace gives its pools; the pools of all
+// rules are reserved, so a namespace no rule matches only uses the others.
+type PoolAffinity struct {
+	rules      []poolRule
+	unreserved map[string]bool
+}
+
+// poolRule is a compiled PoolRule
+type poolRule struct {
+	pools  map[string]bool
+	filter *NamespaceFilter
+}
+
+// NewPoolAffinity creates a pool affinity over the pools of a network
+func NewPoolAffinity(rules []PoolRule, pools []string, client kubernetes.Interface) (*PoolAffinity, error) {
+	a := &PoolAffinity{unreserved: make(map[string]bool, len(pools))}
+	known := make(map[string]bool, len(pools))
+	for _, pool := range pools {
+		a.unreserved[pool] = true
+		known[pool] = true
+	}
+	for i, rule := range rules {
+		if len(rule.Pools) == 0 {
+			return nil, fmt.Errorf("rule %d lists no pools", i)
+		}
+		filter, err := NewNamespaceFilter(rule.Namespaces, nil, rule.Selector, client)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		compiled := poolRule{pools: make(map[string]bool, len(rule.Pools)), filter: filter}
+		for _, pool := range rule.Pools {
+			if !known[pool] {
+				return nil, fmt.Errorf("rule %d: unknown pool %q", i, pool)
+			}
+			compiled.pools[pool] = true
+			delete(a.unreserved, pool)
+		}
+		a.rules = append(a.rules, compiled)
+	}
+	return a, nil
+}
+
+// PoolsFor returns the pools the namespace may be allocated in
+func (a *PoolAffinity) PoolsFor(ctx context.Context, namespace string) (map[string]bool, error) {
+	for i, rule := range a.rules {
+		matched, _, err := rule.filter.matches(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			klog.V(4).Infof("Namespace %s matches pool affinity rule %d", namespace, i)
+			return rule.pools, nil
+		}
+	}
+	return a.unreserved, nil
+}
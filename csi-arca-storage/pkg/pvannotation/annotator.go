@@ -0,0 +1,165 @@
+// Package pvannotation copies the backend placement of volumes from their
+// ArcaVolumes into annotations on the PersistentVolumes, so that
+// "kubectl describe pv" shows the SVM, VIP and backend path.
+package pvannotation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+)
+
+// Annotations written to PersistentVolumes
+const (
+	// AnnotationSVM is the SVM the volume lives on
+	AnnotationSVM = "storage.arca.io/svm"
+	// AnnotationVIP is the address node plugins mount the SVM from
+	AnnotationVIP = "storage.arca.io/vip"
+	// AnnotationPath is the volume's directory on the SVM
+	AnnotationPath = "storage.arca.io/backend-path"
+	// AnnotationBackend is the ARCA backend (tenant) of the volume; it is
+	// not set for volumes on the default backend
+	AnnotationBackend = "storage.arca.io/backend"
+)
+
+// DefaultInterval is how often PersistentVolumes are annotated
+const DefaultInterval = time.Minute
+
+// annotateTimeout bounds a single pass
+const annotateTimeout = 5 * time.Minute
+
+// listPageSize is the page size used when listing ArcaVolumes and
+// PersistentVolumes
+const listPageSize = 500
+
+// Annotator periodically annotates the PersistentVolumes of the driver with
+// the placement recorded in their ArcaVolumes. PersistentVolumes are only
+// patched when an annotation differs, e.g. right after provisioning or
+// after an SVM migration moved the volume to another VIP.
+type Annotator struct {
+	client     client.Client
+	driverName string
+}
+
+// NewAnnotator creates an annotator for the PersistentVolumes of driverName
+func NewAnnotator(c client.Client, driverName string) *Annotator {
+	return &Annotator{
+		client:     c,
+		driverName: driverName,
+	}
+}
+
+// Run annotates PersistentVolumes every interval until ctx is cancelled
+func (a *Annotator) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.annotate(ctx); err != nil {
+			klog.Errorf("Failed to annotate PersistentVolumes: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// annotate runs one pass over the driver's PersistentVolumes
+func (a *Annotator) annotate(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, annotateTimeout)
+	defer cancel()
+
+	volumes := make(map[string]*v1alpha1.ArcaVolumeSpec)
+	token := ""
+	for {
+		var list v1alpha1.ArcaVolumeList
+		if err := a.client.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return fmt.Errorf("failed to list ArcaVolumes: %w", err)
+		}
+		for i := range list.Items {
+			volumes[list.Items[i].Spec.VolumeID] = &list.Items[i].Spec
+		}
+		token = list.Continue
+		if token == "" {
+			break
+		}
+	}
+
+	for {
+		var list corev1.PersistentVolumeList
+		if err := a.client.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return fmt.Errorf("failed to list PersistentVolumes: %w", err)
+		}
+		for i := range list.Items {
+			pv := &list.Items[i]
+			if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != a.driverName {
+				continue
+			}
+			spec, ok := volumes[pv.Spec.CSI.VolumeHandle]
+			if !ok {
+				// Not recorded yet, or statically provisioned without an
+				// ArcaVolume
+				continue
+			}
+			if err := a.annotateVolume(ctx, pv, spec); err != nil {
+				klog.Warningf("Failed to annotate PersistentVolume %s: %v", pv.Name, err)
+			}
+		}
+		token = list.Continue
+		if token == "" {
+			return nil
+		}
+	}
+}
+
+// annotateVolume patches the placement annotations of a PersistentVolume
+// that differ from spec
+func (a *Annotator) annotateVolume(ctx context.Context, pv *corev1.PersistentVolume, spec *v1alpha1.ArcaVolumeSpec) error {
+	want := map[string]string{
+		AnnotationSVM:     spec.SVMName,
+		AnnotationVIP:     spec.VIP,
+		AnnotationPath:    spec.Path,
+		AnnotationBackend: spec.Backend,
+	}
+	changed := false
+	for k, v := range want {
+		current, ok := pv.Annotations[k]
+		if current != v || (v == "" && ok) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	original := pv.DeepCopy()
+	if pv.Annotations == nil {
+		pv.Annotations = make(map[string]string)
+	}
+	for k, v := range want {
+		if v == "" {
+			delete(pv.Annotations, k)
+		} else {
+			pv.Annotations[k] = v
+		}
+	}
+	if err := a.client.Patch(ctx, pv, client.MergeFrom(original)); err != nil {
+		return err
+	}
+
+	klog.V(2).Infof("Annotated PersistentVolume %s (SVM: %s, VIP: %s, Path: %s)", pv.Name, spec.SVMName, spec.VIP, spec.Path)
+	return nil
+}
@@ -0,0 +1,251 @@
+// Package reservation tracks ArcaCapacityReservation resources, which hold
+// backend capacity for volumes that will be created in a namespace.
+package reservation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// DefaultInterval is how often ArcaCapacityReservations are refreshed
+const DefaultInterval = 30 * time.Second
+
+// refreshTimeout bounds a single refresh pass
+const refreshTimeout = 2 * time.Minute
+
+// listPageSize is the page size used when scanning volume records
+const listPageSize = 500
+
+// hold is the capacity still reserved for one namespace
+type hold struct {
+	backend string
+	bytes   int64
+}
+
+// Tracker keeps the capacity held by ArcaCapacityReservations. A
+// reservation is consumed by volumes created in its namespace after the
+// reservation, oldest reservation first; the rest stays held until it is
+// consumed, expires or the reservation is deleted.
+type Tracker struct {
+	client   client.Client
+	store    store.Store
+	backends *arca.BackendRouter
+
+	mu    sync.RWMutex
+	holds map[string]hold // namespace -> held capacity
+}
+
+// NewTracker creates a reservation tracker
+func NewTracker(c client.Client, st store.Store, backends *arca.BackendRouter) *Tracker {
+	return &Tracker{
+		client:   c,
+		store:    st,
+		backends: backends,
+		holds:    make(map[string]hold),
+	}
+}
+
+// Run refreshes reservations every interval until ctx is cancelled
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.refresh(ctx); err != nil {
+			klog.Errorf("Failed to refresh capacity reservations: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Held returns the capacity held on a backend for the namespace itself and
+// for all other namespaces
+func (t *Tracker) Held(backend, namespace string) (own, others int64) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for ns, h := range t.holds {
+		if h.backend != backend {
+			continue
+		}
+		if ns == namespace {
+			own = h.bytes
+		} else {
+			others += h.bytes
+		}
+	}
+	return own, others
+}
+
+// HeldOnBackend returns the capacity held on a backend for all namespaces
+func (t *Tracker) HeldOnBackend(backend string) int64 {
+	own, others := t.Held(backend, "")
+	return own + others
+}
+
+// Consume releases capacity held for a namespace once a volume has been
+// created there, until the next refresh recomputes it from the volumes
+func (t *Tracker) Consume(namespace string, bytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.holds[namespace]
+	if !ok {
+		return
+	}
+	h.bytes = max(h.bytes-bytes, 0)
+	t.holds[namespace] = h
+}
+
+// refresh recomputes every reservation from the volume records and updates
+// the status of those that changed
+func (t *Tracker) refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+
+	var list v1alpha1.ArcaCapacityReservationList
+	if err := t.client.List(ctx, &list); err != nil {
+		return fmt.Errorf("failed to list ArcaCapacityReservations: %w", err)
+	}
+
+	byNamespace := make(map[string][]*v1alpha1.ArcaCapacityReservation)
+	for i := range list.Items {
+		r := &list.Items[i]
+		byNamespace[r.Spec.Namespace] = append(byNamespace[r.Spec.Namespace], r)
+	}
+
+	volumes := make(map[string][]*store.VolumeInfo) // SVM name -> volumes
+	if len(byNamespace) > 0 {
+		if err := t.listVolumes(func(v *store.VolumeInfo) {
+			volumes[v.SVMName] = append(volumes[v.SVMName], v)
+		}); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	holds := make(map[string]hold, len(byNamespace))
+	for namespace, reservations := range byNamespace {
+		svmName := arca.SVMNameForNamespace(namespace)
+		backend := t.backends.ForNamespace(namespace).Name
+		statuses := consume(reservations, volumes[svmName], now)
+
+		var held int64
+		for i, r := range reservations {
+			s := statuses[i]
+			s.ObservedGeneration = r.Generation
+			s.Backend = backend
+			s.SVMName = svmName
+			held += s.RemainingBytes
+
+			if s == r.Status {
+				continue
+			}
+			r.Status = s
+			if err := t.client.Status().Update(ctx, r); err != nil {
+				klog.Errorf("Failed to update status of capacity reservation %s: %v", r.Name, err)
+			}
+		}
+		if held > 0 {
+			holds[namespace] = hold{backend: backend, bytes: held}
+		}
+	}
+
+	t.mu.Lock()
+	t.holds = holds
+	t.mu.Unlock()
+	return nil
+}
+
+// consume attributes the capacity of an SVM's volumes to the reservations of
+// its namespace: each volume consumes the oldest unexpired reservations made
+// before it. It returns the resulting status of each reservation.
+func consume(reservations []*v1alpha1.ArcaCapacityReservation, volumes []*store.VolumeInfo, now time.Time) []v1alpha1.ArcaCapacityReservationStatus {
+	order := make([]int, len(reservations))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return reservations[a].CreationTimestamp.Compare(reservations[b].CreationTimestamp.Time)
+	})
+
+	volumes = slices.Clone(volumes)
+	slices.SortFunc(volumes, func(a, b *store.VolumeInfo) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	statuses := make([]v1alpha1.ArcaCapacityReservationStatus, len(reservations))
+	for _, v := range volumes {
+		bytes := v.CapacityBytes
+		for _, i := range order {
+			r := reservations[i]
+			if bytes == 0 || r.CreationTimestamp.Time.After(v.CreatedAt) {
+				break
+			}
+			if expired(r, now) {
+				continue
+			}
+			take := min(bytes, r.Spec.CapacityBytes-statuses[i].ConsumedBytes)
+			statuses[i].ConsumedBytes += take
+			bytes -= take
+		}
+	}
+
+	for i, r := range reservations {
+		s := &statuses[i]
+		switch {
+		case expired(r, now):
+			// Keep the consumption recorded before expiry
+			s.ConsumedBytes = r.Status.ConsumedBytes
+			s.Phase = v1alpha1.ArcaCapacityReservationPhaseExpired
+		case s.ConsumedBytes >= r.Spec.CapacityBytes:
+			s.Phase = v1alpha1.ArcaCapacityReservationPhaseFulfilled
+		default:
+			s.RemainingBytes = r.Spec.CapacityBytes - s.ConsumedBytes
+			s.Phase = v1alpha1.ArcaCapacityReservationPhaseActive
+		}
+	}
+	return statuses
+}
+
+// expired reports whether a reservation has passed its expiresAt
+func expired(r *v1alpha1.ArcaCapacityReservation, now time.Time) bool {
+	return r.Spec.ExpiresAt != nil && !now.Before(r.Spec.ExpiresAt.Time)
+}
+
+// listVolumes calls fn for every volume record
+func (t *Tracker) listVolumes(fn func(*store.VolumeInfo)) error {
+	token := ""
+	for {
+		volumes, next, err := t.store.ListVolumes(token, listPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list volumes: %w", err)
+		}
+		for _, v := range volumes {
+			fn(v)
+		}
+		if next == "" {
+			return nil
+		}
+		token = next
+	}
+}
@@ -0,0 +1,404 @@
+// Package stateimport recreates ArcaVolume and ArcaSnapshot records for
+// volumes and snapshots provisioned by driver versions that kept their
+// metadata in memory only. The records are rebuilt from the PVs and
+// VolumeSnapshotContents the driver provisioned, or read from a JSON dump.
+package stateimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/driver"
+	"github.com/akam1o/csi-arca-storage/pkg/idempotency"
+	"github.com/akam1o/csi-arca-storage/pkg/pvannotation"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// listPageSize is the page size used when listing PVs and
+// VolumeSnapshotContents
+const listPageSize = 500
+
+// snapshotDir is the backend directory the driver creates snapshots in
+// (relative to the SVM root, as in the driver)
+const snapshotDir = ".snapshots"
+
+// volumeSnapshotContentList is the list kind of VolumeSnapshotContents,
+// read unstructured as the snapshot CRDs are optional
+var volumeSnapshotContentList = schema.GroupVersionKind{
+	Group:   "snapshot.storage.k8s.io",
+	Version: "v1",
+	Kind:    "VolumeSnapshotContentList",
+}
+
+// Dump is the JSON dump format: the volumes and snapshots of a store, e.g.
+// written by hand from the logs of a driver that ran with the memory store
+type Dump struct {
+	Volumes   []DumpVolume   `json:"volumes"`
+	Snapshots []DumpSnapshot `json:"snapshots"`
+}
+
+// DumpVolume is a volume of a dump
+type DumpVolume struct {
+	VolumeID      string            `json:"volumeId"`
+	Name          string            `json:"name"`
+	SVMName       string            `json:"svm"`
+	VIP           string            `json:"vip"`
+	Path          string            `json:"path"`
+	CapacityBytes int64             `json:"capacityBytes"`
+	CreatedAt     time.Time         `json:"createdAt,omitempty"`
+	Backend       string            `json:"backend,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+	ExportPath    string            `json:"exportPath,omitempty"`
+	// SourceVolumeID or SourceSnapshotID name the content source of a
+	// cloned or restored volume
+	SourceVolumeID   string `json:"sourceVolumeId,omitempty"`
+	SourceSnapshotID string `json:"sourceSnapshotId,omitempty"`
+}
+
+// DumpSnapshot is a snapshot of a dump
+type DumpSnapshot struct {
+	SnapshotID     string            `json:"snapshotId"`
+	Name           string            `json:"name"`
+	SourceVolumeID string            `json:"sourceVolumeId"`
+	SVMName        string            `json:"svm"`
+	Path           string            `json:"path,omitempty"`
+	SizeBytes      int64             `json:"sizeBytes"`
+	CreatedAt      time.Time         `json:"createdAt,omitempty"`
+	ReadyToUse     bool              `json:"readyToUse"`
+	Backend        string            `json:"backend,omitempty"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+}
+
+// Records are the volumes and snapshots to import
+type Records struct {
+	Volumes   []*store.VolumeInfo
+	Snapshots []*store.SnapshotInfo
+}
+
+// ReadDump decodes a JSON dump. Snapshots without a path get the driver's
+// snapshot directory; snapshots without an SVM get their source volume's.
+func ReadDump(r io.Reader) (*Records, error) {
+	var dump Dump
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&dump); err != nil {
+		return nil, fmt.Errorf("failed to decode dump: %w", err)
+	}
+
+	records := &Records{}
+	svms := make(map[string]string)
+	for _, v := range dump.Volumes {
+		info := &store.VolumeInfo{
+			VolumeID:      v.VolumeID,
+			Name:          v.Name,
+			SVMName:       v.SVMName,
+			VIP:           v.VIP,
+			Path:          v.Path,
+			CapacityBytes: v.CapacityBytes,
+			CreatedAt:     v.CreatedAt,
+			Backend:       v.Backend,
+			Annotations:   v.Annotations,
+			ExportPath:    v.ExportPath,
+		}
+		switch {
+		case v.SourceVolumeID != "" && v.SourceSnapshotID != "":
+			return nil, fmt.Errorf("volume %s has both sourceVolumeId and sourceSnapshotId", v.VolumeID)
+		case v.SourceVolumeID != "":
+			info.ContentSource = &csi.VolumeContentSource{Type: &csi.VolumeContentSource_Volume{
+				Volume: &csi.VolumeContentSource_VolumeSource{VolumeId: v.SourceVolumeID},
+			}}
+		case v.SourceSnapshotID != "":
+			info.ContentSource = &csi.VolumeContentSource{Type: &csi.VolumeContentSource_Snapshot{
+				Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: v.SourceSnapshotID},
+			}}
+		}
+		if err := checkVolume(info); err != nil {
+			return nil, err
+		}
+		svms[info.VolumeID] = info.SVMName
+		records.Volumes = append(records.Volumes, info)
+	}
+
+	for _, s := range dump.Snapshots {
+		info := &store.SnapshotInfo{
+			SnapshotID:     s.SnapshotID,
+			Name:           s.Name,
+			SourceVolumeID: s.SourceVolumeID,
+			SVMName:        s.SVMName,
+			Path:           s.Path,
+			SizeBytes:      s.SizeBytes,
+			CreatedAt:      s.CreatedAt,
+			ReadyToUse:     s.ReadyToUse,
+			Backend:        s.Backend,
+			Annotations:    s.Annotations,
+		}
+		if info.Path == "" {
+			info.Path = path.Join(snapshotDir, info.SnapshotID)
+		}
+		if info.SVMName == "" {
+			info.SVMName = svms[info.SourceVolumeID]
+		}
+		if err := checkSnapshot(info); err != nil {
+			return nil, err
+		}
+		records.Snapshots = append(records.Snapshots, info)
+	}
+	return records, nil
+}
+
+// FromCluster rebuilds the records of the PVs and VolumeSnapshotContents
+// provisioned by the driver. PVs with an incomplete volume context are
+// skipped with a warning; snapshots are only rebuilt for volumes found, as
+// their SVM is the source volume's. A cluster without the snapshot CRDs has
+// no snapshots.
+func FromCluster(ctx context.Context, clientset kubernetes.Interface, c client.Client) (*Records, error) {
+	records := &Records{}
+	volumes := make(map[string]*store.VolumeInfo)
+
+	token := ""
+	for {
+		pvs, err := clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{Limit: listPageSize, Continue: token})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PVs: %w", err)
+		}
+		for i := range pvs.Items {
+			info, err := volumeFromPV(&pvs.Items[i])
+			if err != nil {
+				klog.Warningf("Skipping PV %s: %v", pvs.Items[i].Name, err)
+				continue
+			}
+			if info == nil {
+				continue
+			}
+			volumes[info.VolumeID] = info
+			records.Volumes = append(records.Volumes, info)
+		}
+		if token = pvs.Continue; token == "" {
+			break
+		}
+	}
+
+	token = ""
+	for {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(volumeSnapshotContentList)
+		if err := c.List(ctx, list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			if meta.IsNoMatchError(err) {
+				klog.Info("VolumeSnapshotContent CRD not installed, importing no snapshots")
+				break
+			}
+			return nil, fmt.Errorf("failed to list VolumeSnapshotContents: %w", err)
+		}
+		for i := range list.Items {
+			info, err := snapshotFromContent(&list.Items[i], volumes)
+			if err != nil {
+				klog.Warningf("Skipping VolumeSnapshotContent %s: %v", list.Items[i].GetName(), err)
+				continue
+			}
+			if info != nil {
+				records.Snapshots = append(records.Snapshots, info)
+			}
+		}
+		if token = list.GetContinue(); token == "" {
+			break
+		}
+	}
+	return records, nil
+}
+
+// volumeFromPV rebuilds the record of a PV provisioned by the driver; PVs
+// of other drivers or without a driver volume ID return nil
+func volumeFromPV(pv *corev1.PersistentVolume) (*store.VolumeInfo, error) {
+	source := pv.Spec.CSI
+	if source == nil || source.Driver != driver.DriverName || !idempotency.IsVolumeID(source.VolumeHandle) {
+		return nil, nil
+	}
+
+	attrs := source.VolumeAttributes
+	info := &store.VolumeInfo{
+		VolumeID:   source.VolumeHandle,
+		Name:       pv.Name,
+		SVMName:    attrs["svm"],
+		VIP:        attrs["vip"],
+		Path:       attrs["volumePath"],
+		CreatedAt:  pv.CreationTimestamp.Time,
+		Backend:    pv.Annotations[pvannotation.AnnotationBackend],
+		ExportPath: attrs["exportPath"],
+		PVName:     pv.Name,
+		Annotations: map[string]string{
+			store.AnnotationPVName: pv.Name,
+		},
+	}
+	if capacity, ok := pv.Spec.Capacity[corev1.ResourceStorage]; ok {
+		info.CapacityBytes = capacity.Value()
+	}
+	if pv.Spec.StorageClassName != "" {
+		info.Annotations[store.AnnotationStorageClass] = pv.Spec.StorageClassName
+	}
+	if ref := pv.Spec.ClaimRef; ref != nil {
+		info.Annotations[store.AnnotationPVC] = ref.Namespace + "/" + ref.Name
+		info.PVCNamespace = ref.Namespace
+		info.PVCName = ref.Name
+	}
+	if err := checkVolume(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// snapshotFromContent rebuilds the record of a VolumeSnapshotContent of a
+// snapshot the driver created; contents of other drivers or of unknown
+// source volumes return nil
+func snapshotFromContent(content *unstructured.Unstructured, volumes map[string]*store.VolumeInfo) (*store.SnapshotInfo, error) {
+	driverName, _, _ := unstructured.NestedString(content.Object, "spec", "driver")
+	snapshotID, _, _ := unstructured.NestedString(content.Object, "status", "snapshotHandle")
+	if driverName != driver.DriverName || !idempotency.IsSnapshotID(snapshotID) {
+		return nil, nil
+	}
+
+	sourceVolumeID, _, _ := unstructured.NestedString(content.Object, "spec", "source", "volumeHandle")
+	source, ok := volumes[sourceVolumeID]
+	if !ok {
+		return nil, fmt.Errorf("source volume %q is not imported", sourceVolumeID)
+	}
+	// The external-snapshotter names snapshots after the VolumeSnapshot UID
+	snapshotUID, _, _ := unstructured.NestedString(content.Object, "spec", "volumeSnapshotRef", "uid")
+	if snapshotUID == "" {
+		return nil, fmt.Errorf("no volumeSnapshotRef UID")
+	}
+
+	info := &store.SnapshotInfo{
+		SnapshotID:     snapshotID,
+		Name:           "snapshot-" + snapshotUID,
+		SourceVolumeID: sourceVolumeID,
+		SVMName:        source.SVMName,
+		Path:           path.Join(snapshotDir, snapshotID),
+		CreatedAt:      content.GetCreationTimestamp().Time,
+		Backend:        source.Backend,
+		Annotations: map[string]string{
+			store.AnnotationVolumeSnapshotContent: content.GetName(),
+		},
+	}
+	if size, ok, _ := unstructured.NestedInt64(content.Object, "status", "restoreSize"); ok {
+		info.SizeBytes = size
+	}
+	if ready, ok, _ := unstructured.NestedBool(content.Object, "status", "readyToUse"); ok {
+		info.ReadyToUse = ready
+	}
+	if creationTime, ok, _ := unstructured.NestedInt64(content.Object, "status", "creationTime"); ok {
+		info.CreatedAt = time.Unix(0, creationTime)
+	}
+	ns, _, _ := unstructured.NestedString(content.Object, "spec", "volumeSnapshotRef", "namespace")
+	name, _, _ := unstructured.NestedString(content.Object, "spec", "volumeSnapshotRef", "name")
+	if ns != "" && name != "" {
+		info.Annotations[store.AnnotationVolumeSnapshot] = ns + "/" + name
+	}
+	return info, nil
+}
+
+// checkVolume rejects a volume record missing fields the node plugin needs
+func checkVolume(info *store.VolumeInfo) error {
+	if !idempotency.IsVolumeID(info.VolumeID) {
+		return fmt.Errorf("invalid volume ID %q", info.VolumeID)
+	}
+	if info.Name == "" || info.SVMName == "" || info.VIP == "" || info.Path == "" {
+		return fmt.Errorf("volume %s needs a name, svm, vip and path", info.VolumeID)
+	}
+	return nil
+}
+
+// checkSnapshot rejects a snapshot record missing fields the controller needs
+func checkSnapshot(info *store.SnapshotInfo) error {
+	if !idempotency.IsSnapshotID(info.SnapshotID) {
+		return fmt.Errorf("invalid snapshot ID %q", info.SnapshotID)
+	}
+	if info.Name == "" || info.SourceVolumeID == "" || info.SVMName == "" {
+		return fmt.Errorf("snapshot %s needs a name, source volume and svm", info.SnapshotID)
+	}
+	return nil
+}
+
+// Result counts the records an import created and found already recorded
+type Result struct {
+	VolumesCreated    int
+	VolumesExisting   int
+	SnapshotsCreated  int
+	SnapshotsExisting int
+}
+
+// Import creates the records missing from st; records already present are
+// left as they are, so an import can be repeated. With dryRun nothing is
+// created and the records that would be are counted as created.
+func Import(st store.Store, records *Records, dryRun bool) (*Result, error) {
+	verb := "Imported"
+	if dryRun {
+		verb = "Would import"
+	}
+
+	result := &Result{}
+	for _, info := range records.Volumes {
+		created, err := importRecord(dryRun,
+			func() error { _, err := st.GetVolume(info.VolumeID); return err },
+			func() error { return st.CreateVolume(info) })
+		if err != nil {
+			return result, fmt.Errorf("volume %s: %w", info.VolumeID, err)
+		}
+		if created {
+			klog.Infof("%s volume %s (%s) on SVM %s", verb, info.VolumeID, info.Name, info.SVMName)
+			result.VolumesCreated++
+		} else {
+			result.VolumesExisting++
+		}
+	}
+	for _, info := range records.Snapshots {
+		created, err := importRecord(dryRun,
+			func() error { _, err := st.GetSnapshot(info.SnapshotID); return err },
+			func() error { return st.CreateSnapshot(info) })
+		if err != nil {
+			return result, fmt.Errorf("snapshot %s: %w", info.SnapshotID, err)
+		}
+		if created {
+			klog.Infof("%s snapshot %s (%s) of volume %s", verb, info.SnapshotID, info.Name, info.SourceVolumeID)
+			result.SnapshotsCreated++
+		} else {
+			result.SnapshotsExisting++
+		}
+	}
+	return result, nil
+}
+
+// importRecord creates a record unless get finds it and reports whether it
+// was (or, with dryRun, would be) created
+func importRecord(dryRun bool, get, create func() error) (bool, error) {
+	err := get()
+	if err == nil {
+		return false, nil
+	}
+	if !store.IsNotFound(err) {
+		return false, err
+	}
+	if dryRun {
+		return true, nil
+	}
+	if err := create(); err != nil {
+		if store.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -4,6 +4,7 @@ package store
 
 import (
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -69,6 +70,7 @@ func deepCopyVolumeInfo(v *VolumeInfo) *VolumeInfo {
 	}
 	copied := *v
 	copied.ContentSource = cloneVolumeContentSource(v.ContentSource)
+	copied.Annotations = copyAnnotations(v.Annotations)
 	return &copied
 }
 
@@ -78,6 +80,9 @@ func deepCopySnapshotInfo(s *SnapshotInfo) *SnapshotInfo {
 		return nil
 	}
 	copied := *s
+	copied.Annotations = copyAnnotations(s.Annotations)
+	copied.Parameters = copyAnnotations(s.Parameters)
+	copied.SecretKeys = slices.Clone(s.SecretKeys)
 	return &copied
 }
 
@@ -143,6 +148,11 @@ func (s *CachedStore) GetVolume(volumeID string) (*VolumeInfo, error) {
 	return deepCopyVolumeInfo(info), nil
 }
 
+// GetVolumeByName retrieves a volume by CSI request name (not cached)
+func (s *CachedStore) GetVolumeByName(name string) (*VolumeInfo, error) {
+	return s.store.GetVolumeByName(name)
+}
+
 // DeleteVolume deletes a volume and invalidates cache
 func (s *CachedStore) DeleteVolume(volumeID string) error {
 	err := s.store.DeleteVolume(volumeID)
@@ -158,6 +168,23 @@ func (s *CachedStore) DeleteVolume(volumeID string) error {
 	return nil
 }
 
+// MarkVolumeDeleting flags a volume as being deleted and invalidates cache
+func (s *CachedStore) MarkVolumeDeleting(volumeID string) error {
+	err := s.store.MarkVolumeDeleting(volumeID)
+
+	// Invalidate cache
+	s.mu.Lock()
+	s.volumeCache.Remove(volumeID)
+	s.mu.Unlock()
+
+	return err
+}
+
+// ListDeletingVolumes returns the volumes being deleted (not cached)
+func (s *CachedStore) ListDeletingVolumes() ([]*VolumeInfo, error) {
+	return s.store.ListDeletingVolumes()
+}
+
 // ListVolumes returns all volumes (no caching for list operations)
 func (s *CachedStore) ListVolumes(startingToken string, maxEntries int) ([]*VolumeInfo, string, error) {
 	return s.store.ListVolumes(startingToken, maxEntries)
@@ -225,6 +252,11 @@ func (s *CachedStore) GetSnapshot(snapshotID string) (*SnapshotInfo, error) {
 	return deepCopySnapshotInfo(info), nil
 }
 
+// GetSnapshotByName retrieves a snapshot by CSI request name (not cached)
+func (s *CachedStore) GetSnapshotByName(name string) (*SnapshotInfo, error) {
+	return s.store.GetSnapshotByName(name)
+}
+
 // DeleteSnapshot deletes a snapshot and invalidates cache
 func (s *CachedStore) DeleteSnapshot(snapshotID string) error {
 	err := s.store.DeleteSnapshot(snapshotID)
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package crd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// labelMigration adds a label to the records created before it existed,
+// once, on first use. Until it has completed, lookups by the label could
+// miss those records, so they run it first; afterwards a lookup by label
+// is complete.
+type labelMigration struct {
+	label string
+	run   func(ctx context.Context) (int, error)
+
+	mu   sync.Mutex
+	done bool
+}
+
+// ensure runs the migration unless an earlier call completed it
+func (m *labelMigration) ensure(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.done {
+		return nil
+	}
+	labeled, err := m.run(ctx)
+	if err != nil {
+		return err
+	}
+	m.done = true
+	if labeled > 0 {
+		klog.Infof("Added label %s to %d records", m.label, labeled)
+	}
+	return nil
+}
+
+// labelVolumes adds store.NameLabel to every ArcaVolume without it and
+// returns how many it labelled
+func (s *Store) labelVolumes(ctx context.Context) (int, error) {
+	labeled := 0
+	token := ""
+	for {
+		avList := &v1alpha1.ArcaVolumeList{}
+		if err := s.client.List(ctx, avList, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return labeled, fmt.Errorf("failed to list ArcaVolumes: %w", store.MapKubernetesError(err, "ArcaVolume", "list"))
+		}
+		for i := range avList.Items {
+			av := &avList.Items[i]
+			if _, ok := av.Labels[store.NameLabel]; ok {
+				continue
+			}
+			if err := s.addLabel(ctx, av, "ArcaVolume", store.NameLabel, store.NameLabelValue(av.Spec.Name)); err != nil {
+				return labeled, fmt.Errorf("failed to label ArcaVolume: %w", err)
+			}
+			labeled++
+		}
+		if avList.Continue == "" {
+			return labeled, nil
+		}
+		token = avList.Continue
+	}
+}
+
+// labelSnapshots adds store.NameLabel to every ArcaSnapshot without it and
+// returns how many it labelled
+func (s *Store) labelSnapshots(ctx context.Context) (int, error) {
+	labeled := 0
+	token := ""
+	for {
+		asList := &v1alpha1.ArcaSnapshotList{}
+		if err := s.client.List(ctx, asList, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return labeled, fmt.Errorf("failed to list ArcaSnapshots: %w", store.MapKubernetesError(err, "ArcaSnapshot", "list"))
+		}
+		for i := range asList.Items {
+			as := &asList.Items[i]
+			if _, ok := as.Labels[store.NameLabel]; ok {
+				continue
+			}
+			if err := s.addLabel(ctx, as, "ArcaSnapshot", store.NameLabel, store.NameLabelValue(as.Spec.Name)); err != nil {
+				return labeled, fmt.Errorf("failed to label ArcaSnapshot: %w", err)
+			}
+			labeled++
+		}
+		if asList.Continue == "" {
+			return labeled, nil
+		}
+		token = asList.Continue
+	}
+}
+
+// labelDeletingVolumes adds DeletingLabel to every ArcaVolume being
+// deleted without it and returns how many it labelled
+func (s *Store) labelDeletingVolumes(ctx context.Context) (int, error) {
+	labeled := 0
+	token := ""
+	for {
+		avList := &v1alpha1.ArcaVolumeList{}
+		if err := s.client.List(ctx, avList, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return labeled, fmt.Errorf("failed to list ArcaVolumes: %w", store.MapKubernetesError(err, "ArcaVolume", "list"))
+		}
+		for i := range avList.Items {
+			av := &avList.Items[i]
+			if av.DeletionTimestamp == nil || av.Labels[DeletingLabel] == "true" {
+				continue
+			}
+			if err := s.addLabel(ctx, av, "ArcaVolume", DeletingLabel, "true"); err != nil {
+				return labeled, fmt.Errorf("failed to label ArcaVolume: %w", err)
+			}
+			labeled++
+		}
+		if avList.Continue == "" {
+			return labeled, nil
+		}
+		token = avList.Continue
+	}
+}
+
+// addLabel sets the label key of a record of kind to value. A record
+// deleted meanwhile is skipped.
+func (s *Store) addLabel(ctx context.Context, obj client.Object, kind, key, value string) error {
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[key] = value
+	obj.SetLabels(labels)
+
+	err := store.MapKubernetesError(s.client.Patch(ctx, obj, patch), kind, obj.GetName())
+	if err != nil && !store.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package crd
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+const (
+	// retryInitialDelay is the first delay before retrying a request the
+	// API server shed; it doubles up to retryMaxDelay
+	retryInitialDelay = 200 * time.Millisecond
+	retryMaxDelay     = 5 * time.Second
+)
+
+// retryClient retries the requests of a controller-runtime client with
+// exponential backoff while the API server throttles them or is unreachable.
+// Retries stop at the request's context deadline, leaving the last error for
+// store.MapKubernetesError to report as store.ErrUnavailable.
+type retryClient struct {
+	client.Client
+}
+
+func (c retryClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return retry(ctx, func() error { return c.Client.Get(ctx, key, obj, opts...) })
+}
+
+func (c retryClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return retry(ctx, func() error { return c.Client.List(ctx, list, opts...) })
+}
+
+func (c retryClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return retry(ctx, func() error { return c.Client.Create(ctx, obj, opts...) })
+}
+
+func (c retryClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return retry(ctx, func() error { return c.Client.Update(ctx, obj, opts...) })
+}
+
+func (c retryClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return retry(ctx, func() error { return c.Client.Patch(ctx, obj, patch, opts...) })
+}
+
+func (c retryClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	return retry(ctx, func() error { return c.Client.Delete(ctx, obj, opts...) })
+}
+
+func (c retryClient) Status() client.SubResourceWriter {
+	return retryStatusWriter{c.Client.Status()}
+}
+
+// retryStatusWriter retries status subresource writes like retryClient
+type retryStatusWriter struct {
+	client.SubResourceWriter
+}
+
+func (w retryStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	return retry(ctx, func() error { return w.SubResourceWriter.Update(ctx, obj, opts...) })
+}
+
+func (w retryStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	return retry(ctx, func() error { return w.SubResourceWriter.Patch(ctx, obj, patch, opts...) })
+}
+
+// retry calls fn until it succeeds, fails with a non-transient error or ctx
+// ends. The delay doubles from retryInitialDelay, and a longer delay the API
+// server suggests (Retry-After) is honored up to retryMaxDelay. Creates are
+// safe to retry as the store treats AlreadyExists as idempotent; updates
+// carry a resourceVersion, so a write that did land fails with a conflict.
+func retry(ctx context.Context, fn func() error) error {
+	delay := retryInitialDelay
+	for {
+		err := fn()
+		if err == nil || !store.IsTransient(err) {
+			return err
+		}
+
+		wait := delay
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+			if suggested := time.Duration(seconds) * time.Second; suggested > wait {
+				wait = min(suggested, retryMaxDelay)
+			}
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		klog.V(4).Infof("Kubernetes API request failed, retrying in %v: %v", wait, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
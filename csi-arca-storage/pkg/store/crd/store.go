@@ -0,0 +1,562 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package crd implements the persistent metadata store on the ArcaVolume and
+// ArcaSnapshot custom resources. Only the controller plugin and arcactl
+// use it; node plugins read volumes through store.VolumeReader.
+package crd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+const (
+	FinalizerArcaStorage = "storage.arca.io/csi-driver"
+
+	// DeletingLabel marks the ArcaVolumes flagged by MarkVolumeDeleting, so
+	// the deletion queue lists only them
+	DeletingLabel = "storage.arca.io/deleting"
+
+	crudTimeout = 10 * time.Second
+	listTimeout = 30 * time.Second
+
+	// listPageSize is the page size for full scans
+	listPageSize = 500
+)
+
+func removeFinalizer(finalizers []string, finalizerToRemove string) []string {
+	result := make([]string, 0, len(finalizers))
+	for _, f := range finalizers {
+		if f != finalizerToRemove {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func hasFinalizer(finalizers []string, finalizer string) bool {
+	for _, f := range finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// Store implements Store interface using Kubernetes Custom Resource Definitions
+type Store struct {
+	client client.Client
+
+	volumeLabels   *labelMigration
+	snapshotLabels *labelMigration
+	deletingLabels *labelMigration
+}
+
+// Check configures how NewStore verifies that the required CRDs are
+// installed
+type Check struct {
+	// Wait retries a failed verification for up to this long, e.g. while a
+	// Helm hook installs the CRDs after the driver (0 = fail at once)
+	Wait time.Duration
+	// Lazy defers verification to the first store operation. Until it
+	// succeeds, operations fail with store.ErrUnavailable and verify again.
+	Lazy bool
+}
+
+// crdRetryInterval is the delay between CRD verification attempts
+const crdRetryInterval = 2 * time.Second
+
+// NewStore creates a new CRD-based store on a controller-runtime client
+// whose scheme has the v1alpha1 types; config is used to verify the CRDs
+func NewStore(c client.Client, config *rest.Config, check Check) (*Store, error) {
+	if check.Lazy {
+		klog.Info("Deferring CRD verification to the first store operation")
+		return newStore(verifyingClient{Client: retryClient{c}, verifier: &crdVerifier{config: config}}), nil
+	}
+
+	// Verify CRDs exist
+	if err := waitForCRDs(context.Background(), config, check.Wait); err != nil {
+		return nil, err
+	}
+
+	klog.Info("All required CRDs are installed")
+
+	return newStore(retryClient{c}), nil
+}
+
+// newStore creates a store on c
+func newStore(c client.Client) *Store {
+	s := &Store{client: c}
+	s.volumeLabels = &labelMigration{label: store.NameLabel, run: s.labelVolumes}
+	s.snapshotLabels = &labelMigration{label: store.NameLabel, run: s.labelSnapshots}
+	s.deletingLabels = &labelMigration{label: DeletingLabel, run: s.labelDeletingVolumes}
+	return s
+}
+
+// waitForCRDs verifies the required CRDs, retrying for up to wait
+func waitForCRDs(ctx context.Context, config *rest.Config, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	for attempt := 1; ; attempt++ {
+		verifyCtx, cancel := context.WithTimeout(ctx, crudTimeout)
+		err := VerifyCRDs(verifyCtx, config, store.RequiredCRDs...)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if attempt > 1 {
+				return fmt.Errorf("CRDs not installed after waiting %s: %w", wait, err)
+			}
+			return err
+		}
+		klog.Infof("Waiting for CRDs (attempt %d, %s left): %v", attempt, remaining.Round(time.Second), err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(min(crdRetryInterval, remaining)):
+		}
+	}
+}
+
+// VerifyCRDs checks that the named CRDs are installed
+func VerifyCRDs(ctx context.Context, config *rest.Config, names ...string) error {
+	apiextClient, err := apiextensionsclientset.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create apiextensions client: %w", err)
+	}
+
+	for _, crdName := range names {
+		_, err := apiextClient.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, crdName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("CRD %s not found: %w - Please install CRDs first: kubectl apply -f deploy/crds/", crdName, err)
+		}
+	}
+	return nil
+}
+
+// CreateVolume stores volume metadata as ArcaVolume CRD (idempotent)
+func (s *Store) CreateVolume(info *store.VolumeInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), crudTimeout)
+	defer cancel()
+
+	av := store.VolumeInfoToArcaVolume(info)
+
+	err := s.client.Create(ctx, av)
+	if err != nil {
+		// Map Kubernetes errors to typed store errors
+		mapped := store.MapKubernetesError(err, "ArcaVolume", info.VolumeID)
+
+		// If already exists, this is idempotent - return the mapped error
+		// so controller can check parameters
+		if store.IsAlreadyExists(mapped) {
+			return mapped
+		}
+
+		return fmt.Errorf("failed to create ArcaVolume: %w", mapped)
+	}
+
+	klog.Infof("Created ArcaVolume %s", info.VolumeID)
+	return nil
+}
+
+// UpdateVolume updates existing volume metadata
+func (s *Store) UpdateVolume(info *store.VolumeInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), crudTimeout)
+	defer cancel()
+
+	// Get existing resource to preserve metadata
+	existing := &v1alpha1.ArcaVolume{}
+	if err := s.client.Get(ctx, client.ObjectKey{Name: info.VolumeID}, existing); err != nil {
+		return fmt.Errorf("failed to get existing ArcaVolume: %w", store.MapKubernetesError(err, "ArcaVolume", info.VolumeID))
+	}
+
+	// Update spec fields (and label records created before store.NameLabel existed)
+	existing.Spec = store.VolumeInfoToArcaVolume(info).Spec
+	if existing.Labels == nil {
+		existing.Labels = make(map[string]string)
+	}
+	existing.Labels[store.NameLabel] = store.NameLabelValue(info.Name)
+	for k, v := range info.Annotations {
+		if existing.Annotations == nil {
+			existing.Annotations = make(map[string]string)
+		}
+		existing.Annotations[k] = v
+	}
+
+	if err := s.client.Update(ctx, existing); err != nil {
+		return fmt.Errorf("failed to update ArcaVolume: %w", store.MapKubernetesError(err, "ArcaVolume", info.VolumeID))
+	}
+
+	klog.Infof("Updated ArcaVolume %s", info.VolumeID)
+	return nil
+}
+
+// GetVolume retrieves volume metadata
+func (s *Store) GetVolume(volumeID string) (*store.VolumeInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), crudTimeout)
+	defer cancel()
+
+	av := &v1alpha1.ArcaVolume{}
+	err := s.client.Get(ctx, client.ObjectKey{Name: volumeID}, av)
+	if err != nil {
+		// Map Kubernetes errors to typed store errors
+		return nil, store.MapKubernetesError(err, "ArcaVolume", volumeID)
+	}
+
+	return store.ArcaVolumeToVolumeInfo(av), nil
+}
+
+// GetVolumeByName finds an ArcaVolume by spec.name using store.NameLabel.
+// The first lookup labels the records that predate the label.
+func (s *Store) GetVolumeByName(name string) (*store.VolumeInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	if err := s.volumeLabels.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	avList := &v1alpha1.ArcaVolumeList{}
+	if err := s.client.List(ctx, avList, client.MatchingLabels{store.NameLabel: store.NameLabelValue(name)}); err != nil {
+		return nil, fmt.Errorf("failed to list ArcaVolumes: %w", store.MapKubernetesError(err, "ArcaVolume", "list"))
+	}
+	for i := range avList.Items {
+		if avList.Items[i].Spec.Name == name {
+			return store.ArcaVolumeToVolumeInfo(&avList.Items[i]), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: ArcaVolume with name %s", store.ErrNotFound, name)
+}
+
+// DeleteVolume removes volume metadata (idempotent)
+func (s *Store) DeleteVolume(volumeID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), crudTimeout)
+	defer cancel()
+
+	// Get the volume
+	av := &v1alpha1.ArcaVolume{}
+	err := s.client.Get(ctx, client.ObjectKey{Name: volumeID}, av)
+	if err != nil {
+		mapped := store.MapKubernetesError(err, "ArcaVolume", volumeID)
+		// If not found, already deleted (idempotent)
+		if store.IsNotFound(mapped) {
+			klog.V(4).Infof("ArcaVolume %s already deleted", volumeID)
+			return nil
+		}
+		// Other errors (e.g., unavailable) should be returned
+		return fmt.Errorf("failed to get ArcaVolume for deletion: %w", mapped)
+	}
+
+	// Remove only this driver's finalizer (do not wipe other controllers' finalizers)
+	if hasFinalizer(av.Finalizers, FinalizerArcaStorage) {
+		av.Finalizers = removeFinalizer(av.Finalizers, FinalizerArcaStorage)
+		if err := s.client.Update(ctx, av); err != nil {
+			mapped := store.MapKubernetesError(err, "ArcaVolume", volumeID)
+			if !store.IsNotFound(mapped) { // Ignore if already deleted
+				klog.Warningf("Failed to remove finalizers from ArcaVolume %s: %v", volumeID, mapped)
+			}
+		}
+	}
+
+	// Delete the resource
+	err = s.client.Delete(ctx, av)
+	if err != nil {
+		mapped := store.MapKubernetesError(err, "ArcaVolume", volumeID)
+		// If not found, already deleted (idempotent)
+		if store.IsNotFound(mapped) {
+			klog.V(4).Infof("ArcaVolume %s already deleted during delete call", volumeID)
+			return nil
+		}
+		// Other errors should be returned
+		return fmt.Errorf("failed to delete ArcaVolume: %w", mapped)
+	}
+
+	klog.Infof("Deleted ArcaVolume %s", volumeID)
+	return nil
+}
+
+// MarkVolumeDeleting labels the ArcaVolume with DeletingLabel and deletes
+// it while keeping the driver's finalizer, so the record stays with a
+// deletionTimestamp until DeleteVolume removes the finalizer
+func (s *Store) MarkVolumeDeleting(volumeID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), crudTimeout)
+	defer cancel()
+
+	av := &v1alpha1.ArcaVolume{}
+	if err := s.client.Get(ctx, client.ObjectKey{Name: volumeID}, av); err != nil {
+		return fmt.Errorf("failed to get ArcaVolume: %w", store.MapKubernetesError(err, "ArcaVolume", volumeID))
+	}
+	if av.DeletionTimestamp != nil {
+		if av.Labels[DeletingLabel] == "true" {
+			return nil
+		}
+		if err := s.addLabel(ctx, av, "ArcaVolume", DeletingLabel, "true"); err != nil {
+			return fmt.Errorf("failed to label ArcaVolume: %w", err)
+		}
+		return nil
+	}
+
+	// Label the record before deleting it; records created without the
+	// finalizer would be removed at once
+	if av.Labels == nil {
+		av.Labels = make(map[string]string)
+	}
+	av.Labels[DeletingLabel] = "true"
+	if !hasFinalizer(av.Finalizers, FinalizerArcaStorage) {
+		av.Finalizers = append(av.Finalizers, FinalizerArcaStorage)
+	}
+	if err := s.client.Update(ctx, av); err != nil {
+		return fmt.Errorf("failed to label ArcaVolume: %w", store.MapKubernetesError(err, "ArcaVolume", volumeID))
+	}
+
+	if err := s.client.Delete(ctx, av); err != nil {
+		return fmt.Errorf("failed to delete ArcaVolume: %w", store.MapKubernetesError(err, "ArcaVolume", volumeID))
+	}
+
+	klog.Infof("Marked ArcaVolume %s as deleting", volumeID)
+	return nil
+}
+
+// ListDeletingVolumes returns the volumes whose ArcaVolume has a
+// deletionTimestamp, listing only those with DeletingLabel. The first call
+// labels the records marked before the label existed.
+func (s *Store) ListDeletingVolumes() ([]*store.VolumeInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	if err := s.deletingLabels.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	var result []*store.VolumeInfo
+	token := ""
+	for {
+		avList := &v1alpha1.ArcaVolumeList{}
+		opts := []client.ListOption{client.MatchingLabels{DeletingLabel: "true"}, client.Limit(listPageSize), client.Continue(token)}
+		if err := s.client.List(ctx, avList, opts...); err != nil {
+			return nil, fmt.Errorf("failed to list ArcaVolumes: %w", store.MapKubernetesError(err, "ArcaVolume", "list"))
+		}
+		// A labelled record is not deleting yet if its deletion failed
+		for i := range avList.Items {
+			if avList.Items[i].DeletionTimestamp != nil {
+				result = append(result, store.ArcaVolumeToVolumeInfo(&avList.Items[i]))
+			}
+		}
+		if avList.Continue == "" {
+			return result, nil
+		}
+		token = avList.Continue
+	}
+}
+
+// ListVolumes returns all volumes with optional pagination
+func (s *Store) ListVolumes(startingToken string, maxEntries int) ([]*store.VolumeInfo, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	avList := &v1alpha1.ArcaVolumeList{}
+	listOpts := &client.ListOptions{
+		Raw: &metav1.ListOptions{
+			Continue: startingToken,
+		},
+	}
+	if maxEntries > 0 {
+		listOpts.Limit = int64(maxEntries)
+	}
+
+	if err := s.client.List(ctx, avList, listOpts); err != nil {
+		return nil, "", fmt.Errorf("failed to list ArcaVolumes: %w", store.MapKubernetesError(err, "ArcaVolume", "list"))
+	}
+
+	result := make([]*store.VolumeInfo, 0, len(avList.Items))
+	for i := range avList.Items {
+		result = append(result, store.ArcaVolumeToVolumeInfo(&avList.Items[i]))
+	}
+
+	// Return results in Kubernetes natural order to maintain pagination consistency
+	// Sorting would invalidate the continue token since K8s paginates before our sort
+	return result, avList.Continue, nil
+}
+
+// CreateSnapshot stores snapshot metadata as ArcaSnapshot CRD (idempotent)
+func (s *Store) CreateSnapshot(info *store.SnapshotInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), crudTimeout)
+	defer cancel()
+
+	as := store.SnapshotInfoToArcaSnapshot(info)
+
+	err := s.client.Create(ctx, as)
+	if err != nil {
+		// Map Kubernetes errors to typed store errors
+		mapped := store.MapKubernetesError(err, "ArcaSnapshot", info.SnapshotID)
+
+		// If already exists, this is idempotent - return the mapped error
+		// so controller can check parameters
+		if store.IsAlreadyExists(mapped) {
+			return mapped
+		}
+
+		return fmt.Errorf("failed to create ArcaSnapshot: %w", mapped)
+	}
+
+	klog.Infof("Created ArcaSnapshot %s", info.SnapshotID)
+	return nil
+}
+
+// UpdateSnapshotStatus updates the status subresource of a snapshot (uses /status endpoint)
+func (s *Store) UpdateSnapshotStatus(snapshotID string, readyToUse bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), crudTimeout)
+	defer cancel()
+
+	// Get the snapshot first
+	as := &v1alpha1.ArcaSnapshot{}
+	if err := s.client.Get(ctx, client.ObjectKey{Name: snapshotID}, as); err != nil {
+		return fmt.Errorf("failed to get snapshot for status update: %w", store.MapKubernetesError(err, "ArcaSnapshot", snapshotID))
+	}
+
+	// Update only the status subresource using Status() writer
+	as.Status.ReadyToUse = readyToUse
+	if err := s.client.Status().Update(ctx, as); err != nil {
+		return fmt.Errorf("failed to update snapshot status: %w", store.MapKubernetesError(err, "ArcaSnapshot", snapshotID))
+	}
+
+	klog.Infof("Updated ArcaSnapshot %s status: ReadyToUse=%v", snapshotID, readyToUse)
+	return nil
+}
+
+// GetSnapshot retrieves snapshot metadata
+func (s *Store) GetSnapshot(snapshotID string) (*store.SnapshotInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), crudTimeout)
+	defer cancel()
+
+	as := &v1alpha1.ArcaSnapshot{}
+	err := s.client.Get(ctx, client.ObjectKey{Name: snapshotID}, as)
+	if err != nil {
+		// Map Kubernetes errors to typed store errors
+		return nil, store.MapKubernetesError(err, "ArcaSnapshot", snapshotID)
+	}
+
+	return store.ArcaSnapshotToSnapshotInfo(as), nil
+}
+
+// GetSnapshotByName finds an ArcaSnapshot by spec.name using
+// store.NameLabel. The first lookup labels the records that predate the
+// label.
+func (s *Store) GetSnapshotByName(name string) (*store.SnapshotInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	if err := s.snapshotLabels.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	asList := &v1alpha1.ArcaSnapshotList{}
+	if err := s.client.List(ctx, asList, client.MatchingLabels{store.NameLabel: store.NameLabelValue(name)}); err != nil {
+		return nil, fmt.Errorf("failed to list ArcaSnapshots: %w", store.MapKubernetesError(err, "ArcaSnapshot", "list"))
+	}
+	for i := range asList.Items {
+		if asList.Items[i].Spec.Name == name {
+			return store.ArcaSnapshotToSnapshotInfo(&asList.Items[i]), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: ArcaSnapshot with name %s", store.ErrNotFound, name)
+}
+
+// DeleteSnapshot removes snapshot metadata (idempotent)
+func (s *Store) DeleteSnapshot(snapshotID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), crudTimeout)
+	defer cancel()
+
+	// Get the snapshot
+	as := &v1alpha1.ArcaSnapshot{}
+	err := s.client.Get(ctx, client.ObjectKey{Name: snapshotID}, as)
+	if err != nil {
+		mapped := store.MapKubernetesError(err, "ArcaSnapshot", snapshotID)
+		// If not found, already deleted (idempotent)
+		if store.IsNotFound(mapped) {
+			klog.V(4).Infof("ArcaSnapshot %s already deleted", snapshotID)
+			return nil
+		}
+		// Other errors (e.g., unavailable) should be returned
+		return fmt.Errorf("failed to get ArcaSnapshot for deletion: %w", mapped)
+	}
+
+	// Remove only this driver's finalizer (do not wipe other controllers' finalizers)
+	if hasFinalizer(as.Finalizers, FinalizerArcaStorage) {
+		as.Finalizers = removeFinalizer(as.Finalizers, FinalizerArcaStorage)
+		if err := s.client.Update(ctx, as); err != nil {
+			mapped := store.MapKubernetesError(err, "ArcaSnapshot", snapshotID)
+			if !store.IsNotFound(mapped) { // Ignore if already deleted
+				klog.Warningf("Failed to remove finalizers from ArcaSnapshot %s: %v", snapshotID, mapped)
+			}
+		}
+	}
+
+	// Delete the resource
+	err = s.client.Delete(ctx, as)
+	if err != nil {
+		mapped := store.MapKubernetesError(err, "ArcaSnapshot", snapshotID)
+		// If not found, already deleted (idempotent)
+		if store.IsNotFound(mapped) {
+			klog.V(4).Infof("ArcaSnapshot %s already deleted during delete call", snapshotID)
+			return nil
+		}
+		// Other errors should be returned
+		return fmt.Errorf("failed to delete ArcaSnapshot: %w", mapped)
+	}
+
+	klog.Infof("Deleted ArcaSnapshot %s", snapshotID)
+	return nil
+}
+
+// ListSnapshots returns all snapshots with optional filtering and pagination
+func (s *Store) ListSnapshots(sourceVolumeID, startingToken string, maxEntries int) ([]*store.SnapshotInfo, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	asList := &v1alpha1.ArcaSnapshotList{}
+	listOpts := &client.ListOptions{
+		Raw: &metav1.ListOptions{
+			Continue: startingToken,
+		},
+	}
+	if maxEntries > 0 {
+		listOpts.Limit = int64(maxEntries)
+	}
+
+	// Add label selector if filtering by source volume
+	if sourceVolumeID != "" {
+		listOpts.LabelSelector, _ = metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"storage.arca.io/source-volume-id": sourceVolumeID,
+			},
+		})
+	}
+
+	if err := s.client.List(ctx, asList, listOpts); err != nil {
+		return nil, "", fmt.Errorf("failed to list ArcaSnapshots: %w", store.MapKubernetesError(err, "ArcaSnapshot", "list"))
+	}
+
+	result := make([]*store.SnapshotInfo, 0, len(asList.Items))
+	for i := range asList.Items {
+		result = append(result, store.ArcaSnapshotToSnapshotInfo(&asList.Items[i]))
+	}
+
+	// Return results in Kubernetes natural order to maintain pagination consistency
+	// Sorting would invalidate the continue token since K8s paginates before our sort
+	return result, asList.Continue, nil
+}
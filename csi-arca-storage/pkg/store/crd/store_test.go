@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package crd
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// countingClient counts the list requests of a client
+type countingClient struct {
+	client.Client
+	lists int
+}
+
+func (c *countingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists++
+	return c.Client.List(ctx, list, opts...)
+}
+
+// newTestStore returns a store on a fake API server holding objs
+func newTestStore(t *testing.T, objs ...client.Object) (*Store, *countingClient) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme: %v", err)
+	}
+	c := &countingClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()}
+	return newStore(c), c
+}
+
+func TestGetVolumeByNameLabelsOlderRecords(t *testing.T) {
+	// A record created before store.NameLabel existed
+	unlabeled := &v1alpha1.ArcaVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-0123456789abcdef"},
+		Spec:       v1alpha1.ArcaVolumeSpec{VolumeID: "pvc-0123456789abcdef", Name: "pvc-old"},
+	}
+	s, c := newTestStore(t, unlabeled)
+
+	info, err := s.GetVolumeByName("pvc-old")
+	if err != nil {
+		t.Fatalf("GetVolumeByName of an unlabeled record: %v", err)
+	}
+	if info.VolumeID != unlabeled.Name {
+		t.Errorf("GetVolumeByName = %s, want %s", info.VolumeID, unlabeled.Name)
+	}
+
+	av := &v1alpha1.ArcaVolume{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: unlabeled.Name}, av); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got := av.Labels[store.NameLabel]; got != "pvc-old" {
+		t.Errorf("label %s = %q, want pvc-old", store.NameLabel, got)
+	}
+
+	// Once labelled, a miss is a single list by label
+	c.lists = 0
+	if _, err := s.GetVolumeByName("pvc-new"); !store.IsNotFound(err) {
+		t.Fatalf("GetVolumeByName of a new name = %v, want not found", err)
+	}
+	if c.lists != 1 {
+		t.Errorf("GetVolumeByName of a new name made %d list requests, want 1", c.lists)
+	}
+}
+
+func TestGetSnapshotByNameLabelsOlderRecords(t *testing.T) {
+	unlabeled := &v1alpha1.ArcaSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "fedcba9876543210"},
+		Spec:       v1alpha1.ArcaSnapshotSpec{SnapshotID: "fedcba9876543210", Name: "snapshot-old"},
+	}
+	s, c := newTestStore(t, unlabeled)
+
+	info, err := s.GetSnapshotByName("snapshot-old")
+	if err != nil {
+		t.Fatalf("GetSnapshotByName of an unlabeled record: %v", err)
+	}
+	if info.SnapshotID != unlabeled.Name {
+		t.Errorf("GetSnapshotByName = %s, want %s", info.SnapshotID, unlabeled.Name)
+	}
+
+	c.lists = 0
+	if _, err := s.GetSnapshotByName("snapshot-new"); !store.IsNotFound(err) {
+		t.Fatalf("GetSnapshotByName of a new name = %v, want not found", err)
+	}
+	if c.lists != 1 {
+		t.Errorf("GetSnapshotByName of a new name made %d list requests, want 1", c.lists)
+	}
+}
+
+func TestListDeletingVolumesByLabel(t *testing.T) {
+	// A record marked as deleting before DeletingLabel existed
+	now := metav1.Now()
+	marked := &v1alpha1.ArcaVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-0123456789abcdef", DeletionTimestamp: &now, Finalizers: []string{FinalizerArcaStorage}},
+		Spec:       v1alpha1.ArcaVolumeSpec{VolumeID: "pvc-0123456789abcdef", Name: "pvc-old"},
+	}
+	live := &v1alpha1.ArcaVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-fedcba9876543210"},
+		Spec:       v1alpha1.ArcaVolumeSpec{VolumeID: "pvc-fedcba9876543210", Name: "pvc-new"},
+	}
+	s, c := newTestStore(t, marked, live)
+
+	volumes, err := s.ListDeletingVolumes()
+	if err != nil {
+		t.Fatalf("ListDeletingVolumes: %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].VolumeID != marked.Name {
+		t.Fatalf("ListDeletingVolumes = %v, want %s", volumes, marked.Name)
+	}
+
+	if err := s.MarkVolumeDeleting(live.Name); err != nil {
+		t.Fatalf("MarkVolumeDeleting: %v", err)
+	}
+
+	// Once labelled, the queue is a single list by label
+	c.lists = 0
+	volumes, err = s.ListDeletingVolumes()
+	if err != nil {
+		t.Fatalf("ListDeletingVolumes: %v", err)
+	}
+	if len(volumes) != 2 {
+		t.Errorf("ListDeletingVolumes returned %d volumes, want 2", len(volumes))
+	}
+	if c.lists != 1 {
+		t.Errorf("ListDeletingVolumes made %d list requests, want 1", c.lists)
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package crd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/store"
+)
+
+// crdVerifier verifies the required CRDs once, on first use
+type crdVerifier struct {
+	config *rest.Config
+
+	mu       sync.Mutex
+	verified bool
+}
+
+// verify checks the required CRDs unless an earlier call succeeded. A
+// failure wraps store.ErrUnavailable, so a missing CRD is never mistaken for a
+// missing resource.
+func (v *crdVerifier) verify(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.verified {
+		return nil
+	}
+	if err := VerifyCRDs(ctx, v.config, store.RequiredCRDs...); err != nil {
+		return fmt.Errorf("%w: required CRDs are not verified: %v", store.ErrUnavailable, err)
+	}
+	v.verified = true
+	klog.Info("All required CRDs are installed")
+	return nil
+}
+
+// verifyingClient verifies the required CRDs before passing requests on
+type verifyingClient struct {
+	client.Client
+	verifier *crdVerifier
+}
+
+func (c verifyingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.verifier.verify(ctx); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c verifyingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.verifier.verify(ctx); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c verifyingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.verifier.verify(ctx); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c verifyingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.verifier.verify(ctx); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c verifyingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.verifier.verify(ctx); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c verifyingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.verifier.verify(ctx); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c verifyingClient) Status() client.SubResourceWriter {
+	return verifyingStatusWriter{c.Client.Status(), c.verifier}
+}
+
+// verifyingStatusWriter verifies the required CRDs like verifyingClient
+type verifyingStatusWriter struct {
+	client.SubResourceWriter
+	verifier *crdVerifier
+}
+
+func (w verifyingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if err := w.verifier.verify(ctx); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+func (w verifyingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	if err := w.verifier.verify(ctx); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+}
@@ -3,11 +3,89 @@
 package store
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+
 	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NameLabel indexes ArcaVolume/ArcaSnapshot records by their CSI request name
+const NameLabel = "storage.arca.io/name"
+
+// NameLabelValue returns the NameLabel value for a CSI request name. Names
+// that are not valid label values (e.g. longer than 63 characters) are
+// replaced by a truncated SHA-256, so lookups must still compare spec.name.
+func NameLabelValue(name string) string {
+	if len(validation.IsValidLabelValue(name)) == 0 {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])[:63]
+}
+
+// Accounting annotations record who and what provisioned a volume or
+// snapshot, for chargeback reports and auditing
+const (
+	// AnnotationStorageClass is the StorageClass of the PVC
+	AnnotationStorageClass = "storage.arca.io/storage-class"
+	// AnnotationPVName is the PersistentVolume created for the volume
+	AnnotationPVName = "storage.arca.io/pv-name"
+	// AnnotationPVC is the "namespace/name" of the PVC
+	AnnotationPVC = "storage.arca.io/pvc"
+	// AnnotationVolumeSnapshot is the "namespace/name" of the VolumeSnapshot
+	AnnotationVolumeSnapshot = "storage.arca.io/volumesnapshot"
+	// AnnotationVolumeSnapshotContent is the VolumeSnapshotContent name
+	AnnotationVolumeSnapshotContent = "storage.arca.io/volumesnapshotcontent"
+	// AnnotationRequester is the requesting service account from the
+	// "requester" StorageClass/VolumeSnapshotClass parameter
+	AnnotationRequester = "storage.arca.io/requester"
+	// AnnotationDriverVersion is the driver version that created the record
+	AnnotationDriverVersion = "storage.arca.io/driver-version"
+	// AnnotationSnapshotIDKey is the "<source volume ID>/<name>" key the
+	// snapshot ID was assigned for
+	AnnotationSnapshotIDKey = "storage.arca.io/snapshot-id-key"
+	// AnnotationSnapshotIDScheme is how the snapshot ID was assigned:
+	// "hash", "salted-hash" or "uuid"
+	AnnotationSnapshotIDScheme = "storage.arca.io/snapshot-id-scheme"
 )
 
+// accountingAnnotationPrefix selects the annotations carried in VolumeInfo
+// and SnapshotInfo; others (e.g. kubectl's last-applied-configuration) stay
+// on the object only
+const accountingAnnotationPrefix = "storage.arca.io/"
+
+// copyAnnotations returns a copy of m, or nil if m is empty
+func copyAnnotations(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// accountingAnnotations returns the accounting annotations of an object
+func accountingAnnotations(m map[string]string) map[string]string {
+	var out map[string]string
+	for k, v := range m {
+		if !strings.HasPrefix(k, accountingAnnotationPrefix) {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[k] = v
+	}
+	return out
+}
+
 // convertContentSourceToCRD converts CSI VolumeContentSource to CRD ArcaContentSource
 func convertContentSourceToCRD(source *csi.VolumeContentSource) *v1alpha1.ArcaContentSource {
 	if source == nil {
@@ -59,14 +137,16 @@ func convertContentSourceFromCRD(source *v1alpha1.ArcaContentSource) *csi.Volume
 	return nil
 }
 
-// volumeInfoToArcaVolume converts VolumeInfo to ArcaVolume CRD
-func volumeInfoToArcaVolume(info *VolumeInfo) *v1alpha1.ArcaVolume {
+// VolumeInfoToArcaVolume converts VolumeInfo to ArcaVolume CRD
+func VolumeInfoToArcaVolume(info *VolumeInfo) *v1alpha1.ArcaVolume {
 	return &v1alpha1.ArcaVolume{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: info.VolumeID,
 			Labels: map[string]string{
 				"storage.arca.io/volume-id": info.VolumeID,
+				NameLabel:                   NameLabelValue(info.Name),
 			},
+			Annotations: copyAnnotations(info.Annotations),
 		},
 		Spec: v1alpha1.ArcaVolumeSpec{
 			VolumeID:      info.VolumeID,
@@ -77,13 +157,25 @@ func volumeInfoToArcaVolume(info *VolumeInfo) *v1alpha1.ArcaVolume {
 			CapacityBytes: info.CapacityBytes,
 			CreatedAt:     metav1.NewTime(info.CreatedAt),
 			ContentSource: convertContentSourceToCRD(info.ContentSource),
+			Backend:       info.Backend,
+
+			ProvisioningMode: info.ProvisioningMode,
+			InodeLimit:       info.InodeLimit,
+			ExportPath:       info.ExportPath,
+			SecureDelete:     info.SecureDelete,
+			MountProfile:     info.MountProfile,
+			AttributeCache:   info.AttributeCache,
+			FSCache:          info.FSCache,
+			PVCNamespace:     info.PVCNamespace,
+			PVCName:          info.PVCName,
+			PVName:           info.PVName,
 		},
 		Status: v1alpha1.ArcaVolumeStatus{},
 	}
 }
 
-// arcaVolumeToVolumeInfo converts ArcaVolume CRD to VolumeInfo
-func arcaVolumeToVolumeInfo(av *v1alpha1.ArcaVolume) *VolumeInfo {
+// ArcaVolumeToVolumeInfo converts ArcaVolume CRD to VolumeInfo
+func ArcaVolumeToVolumeInfo(av *v1alpha1.ArcaVolume) *VolumeInfo {
 	return &VolumeInfo{
 		VolumeID:      av.Spec.VolumeID,
 		Name:          av.Spec.Name,
@@ -93,18 +185,34 @@ func arcaVolumeToVolumeInfo(av *v1alpha1.ArcaVolume) *VolumeInfo {
 		CapacityBytes: av.Spec.CapacityBytes,
 		CreatedAt:     av.Spec.CreatedAt.Time,
 		ContentSource: convertContentSourceFromCRD(av.Spec.ContentSource),
+		Backend:       av.Spec.Backend,
+		Annotations:   accountingAnnotations(av.Annotations),
+
+		ProvisioningMode: av.Spec.ProvisioningMode,
+		InodeLimit:       av.Spec.InodeLimit,
+		ExportPath:       av.Spec.ExportPath,
+		SecureDelete:     av.Spec.SecureDelete,
+		MountProfile:     av.Spec.MountProfile,
+		AttributeCache:   av.Spec.AttributeCache,
+		FSCache:          av.Spec.FSCache,
+		PVCNamespace:     av.Spec.PVCNamespace,
+		PVCName:          av.Spec.PVCName,
+		PVName:           av.Spec.PVName,
+		Deleting:         av.DeletionTimestamp != nil,
 	}
 }
 
-// snapshotInfoToArcaSnapshot converts SnapshotInfo to ArcaSnapshot CRD
-func snapshotInfoToArcaSnapshot(info *SnapshotInfo) *v1alpha1.ArcaSnapshot {
+// SnapshotInfoToArcaSnapshot converts SnapshotInfo to ArcaSnapshot CRD
+func SnapshotInfoToArcaSnapshot(info *SnapshotInfo) *v1alpha1.ArcaSnapshot {
 	return &v1alpha1.ArcaSnapshot{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: info.SnapshotID,
 			Labels: map[string]string{
 				"storage.arca.io/snapshot-id":      info.SnapshotID,
 				"storage.arca.io/source-volume-id": info.SourceVolumeID,
+				NameLabel:                          NameLabelValue(info.Name),
 			},
+			Annotations: copyAnnotations(info.Annotations),
 		},
 		Spec: v1alpha1.ArcaSnapshotSpec{
 			SnapshotID:     info.SnapshotID,
@@ -114,6 +222,9 @@ func snapshotInfoToArcaSnapshot(info *SnapshotInfo) *v1alpha1.ArcaSnapshot {
 			Path:           info.Path,
 			SizeBytes:      info.SizeBytes,
 			CreatedAt:      metav1.NewTime(info.CreatedAt),
+			Backend:        info.Backend,
+			Parameters:     copyAnnotations(info.Parameters),
+			SecretKeys:     slices.Clone(info.SecretKeys),
 		},
 		Status: v1alpha1.ArcaSnapshotStatus{
 			ReadyToUse: info.ReadyToUse,
@@ -121,8 +232,8 @@ func snapshotInfoToArcaSnapshot(info *SnapshotInfo) *v1alpha1.ArcaSnapshot {
 	}
 }
 
-// arcaSnapshotToSnapshotInfo converts ArcaSnapshot CRD to SnapshotInfo
-func arcaSnapshotToSnapshotInfo(as *v1alpha1.ArcaSnapshot) *SnapshotInfo {
+// ArcaSnapshotToSnapshotInfo converts ArcaSnapshot CRD to SnapshotInfo
+func ArcaSnapshotToSnapshotInfo(as *v1alpha1.ArcaSnapshot) *SnapshotInfo {
 	return &SnapshotInfo{
 		SnapshotID:     as.Spec.SnapshotID,
 		Name:           as.Spec.Name,
@@ -132,5 +243,9 @@ func arcaSnapshotToSnapshotInfo(as *v1alpha1.ArcaSnapshot) *SnapshotInfo {
 		SizeBytes:      as.Spec.SizeBytes,
 		CreatedAt:      as.Spec.CreatedAt.Time,
 		ReadyToUse:     as.Status.ReadyToUse,
+		Backend:        as.Spec.Backend,
+		Annotations:    accountingAnnotations(as.Annotations),
+		Parameters:     copyAnnotations(as.Spec.Parameters),
+		SecretKeys:     slices.Clone(as.Spec.SecretKeys),
 	}
 }
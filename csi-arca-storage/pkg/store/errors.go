@@ -3,10 +3,13 @@
 package store
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 )
 
 // Common store errors
@@ -14,6 +17,7 @@ var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrAlreadyExists = errors.New("resource already exists")
 	ErrConflict      = errors.New("resource conflict")
+	ErrUnavailable   = errors.New("kubernetes API unavailable")
 )
 
 // IsNotFound returns true if the error is a "not found" error
@@ -31,6 +35,27 @@ func IsConflict(err error) bool {
 	return errors.Is(err, ErrConflict)
 }
 
+// IsUnavailable returns true if the error is the API server throttling
+// requests or being unreachable
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrUnavailable)
+}
+
+// IsTransient reports whether a Kubernetes API error is the API server
+// shedding load (429, server timeout) or being unreachable, so the request
+// can be retried as is
+func IsTransient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsTimeout(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err)
+}
+
 // MapKubernetesError maps Kubernetes API errors to store errors
 func MapKubernetesError(err error, resourceType, resourceID string) error {
 	if err == nil {
@@ -49,6 +74,13 @@ func MapKubernetesError(err error, resourceType, resourceID string) error {
 		return fmt.Errorf("%w: %s %s", ErrConflict, resourceType, resourceID)
 	}
 
-	// Return the original error for other types (e.g., unavailable, timeout)
+	if IsTransient(err) {
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			return fmt.Errorf("%w: %s %s (retry after %ds): %w", ErrUnavailable, resourceType, resourceID, seconds, err)
+		}
+		return fmt.Errorf("%w: %s %s: %w", ErrUnavailable, resourceType, resourceID, err)
+	}
+
+	// Return the original error for other types
 	return fmt.Errorf("k8s API error for %s %s: %w", resourceType, resourceID, err)
 }
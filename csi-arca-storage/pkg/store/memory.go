@@ -19,6 +19,32 @@ type VolumeInfo struct {
 	CapacityBytes int64
 	CreatedAt     time.Time
 	ContentSource *csi.VolumeContentSource
+	Backend       string            // ARCA backend name ("" = default)
+	Annotations   map[string]string // Accounting metadata (see AnnotationStorageClass etc.)
+	// ProvisioningMode is "thin" or "thick" ("" = thin)
+	ProvisioningMode string
+	// InodeLimit caps files and directories in the volume (0 = unlimited)
+	InodeLimit int64
+	// ExportPath is the NFS export of the SVM when the backend reported one
+	// ("" = export path template)
+	ExportPath string
+	// SecureDelete wipes the volume's data before its directory is deleted
+	SecureDelete bool
+	// MountProfile is the NFS mount tuning of the volume ("" = general)
+	MountProfile string
+	// AttributeCache are the NFS attribute cache options of the volume,
+	// e.g. "actimeo=3,nocto" ("" = the mount profile's)
+	AttributeCache string
+	// FSCache caches the volume's data on the nodes' local disks (fsc)
+	FSCache bool
+	// PVCNamespace, PVCName and PVName are the PersistentVolumeClaim and
+	// PersistentVolume of the volume ("" = unknown)
+	PVCNamespace string
+	PVCName      string
+	PVName       string
+	// Deleting is set once DeleteVolume was acknowledged while the backend
+	// data is still being deleted in the background
+	Deleting bool
 }
 
 // SnapshotInfo represents snapshot metadata
@@ -31,6 +57,12 @@ type SnapshotInfo struct {
 	SizeBytes      int64
 	CreatedAt      time.Time
 	ReadyToUse     bool
+	Backend        string            // ARCA backend name ("" = default)
+	Annotations    map[string]string // Accounting metadata (see AnnotationStorageClass etc.)
+	// Parameters are the VolumeSnapshotClass parameters of the snapshot
+	Parameters map[string]string
+	// SecretKeys are the keys of the snapshotter secret; values are never stored
+	SecretKeys []string
 }
 
 // MemoryStore provides in-memory storage for volume and snapshot metadata
@@ -91,6 +123,20 @@ func (s *MemoryStore) GetVolume(volumeID string) (*VolumeInfo, error) {
 	return info, nil
 }
 
+// GetVolumeByName retrieves volume information by CSI request name
+func (s *MemoryStore) GetVolumeByName(name string) (*VolumeInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, info := range s.volumes {
+		if info.Name == name {
+			return info, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: volume with name %s", ErrNotFound, name)
+}
+
 // DeleteVolume removes volume metadata
 func (s *MemoryStore) DeleteVolume(volumeID string) error {
 	s.mu.Lock()
@@ -100,6 +146,33 @@ func (s *MemoryStore) DeleteVolume(volumeID string) error {
 	return nil
 }
 
+// MarkVolumeDeleting flags a volume as being deleted
+func (s *MemoryStore) MarkVolumeDeleting(volumeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, exists := s.volumes[volumeID]
+	if !exists {
+		return fmt.Errorf("%w: volume %s", ErrNotFound, volumeID)
+	}
+	info.Deleting = true
+	return nil
+}
+
+// ListDeletingVolumes returns the volumes flagged as being deleted
+func (s *MemoryStore) ListDeletingVolumes() ([]*VolumeInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*VolumeInfo
+	for _, info := range s.volumes {
+		if info.Deleting {
+			result = append(result, info)
+		}
+	}
+	return result, nil
+}
+
 // ListVolumes returns all volumes (with optional pagination)
 func (s *MemoryStore) ListVolumes(startingToken string, maxEntries int) ([]*VolumeInfo, string, error) {
 	s.mu.RLock()
@@ -175,6 +248,20 @@ func (s *MemoryStore) GetSnapshot(snapshotID string) (*SnapshotInfo, error) {
 	return info, nil
 }
 
+// GetSnapshotByName retrieves snapshot information by CSI request name
+func (s *MemoryStore) GetSnapshotByName(name string) (*SnapshotInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, info := range s.snapshots {
+		if info.Name == name {
+			return info, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: snapshot with name %s", ErrNotFound, name)
+}
+
 // DeleteSnapshot removes snapshot metadata
 func (s *MemoryStore) DeleteSnapshot(snapshotID string) error {
 	s.mu.Lock()
@@ -222,7 +309,7 @@ func (s *MemoryStore) ListSnapshots(sourceVolumeID, startingToken string, maxEnt
 
 // ToCSIVolume converts VolumeInfo to CSI Volume
 func (v *VolumeInfo) ToCSIVolume() *csi.Volume {
-	return &csi.Volume{
+	vol := &csi.Volume{
 		VolumeId:      v.VolumeID,
 		CapacityBytes: v.CapacityBytes,
 		VolumeContext: map[string]string{
@@ -232,6 +319,19 @@ func (v *VolumeInfo) ToCSIVolume() *csi.Volume {
 		},
 		ContentSource: v.ContentSource,
 	}
+	if v.ExportPath != "" {
+		vol.VolumeContext["exportPath"] = v.ExportPath
+	}
+	if v.MountProfile != "" {
+		vol.VolumeContext["mountProfile"] = v.MountProfile
+	}
+	if v.AttributeCache != "" {
+		vol.VolumeContext["attributeCache"] = v.AttributeCache
+	}
+	if v.FSCache {
+		vol.VolumeContext["fsCache"] = "true"
+	}
+	return vol
 }
 
 // ToCSISnapshot converts SnapshotInfo to CSI Snapshot
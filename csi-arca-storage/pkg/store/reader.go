@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// VolumeReader provides read-only, watch-backed access to ArcaVolume CRs.
+// It is intended for the node plugin, which must never modify metadata.
+type VolumeReader struct {
+	cache cache.Cache
+}
+
+// NewVolumeReader creates a new ArcaVolume reader. Start must be called
+// before GetVolume returns results.
+func NewVolumeReader(config *rest.Config) (*VolumeReader, error) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add v1alpha1 to scheme: %w", err)
+	}
+
+	c, err := cache.New(config, cache.Options{
+		Scheme: scheme,
+		ByObject: map[client.Object]cache.ByObject{
+			&v1alpha1.ArcaVolume{}: {},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ArcaVolume cache: %w", err)
+	}
+
+	// Register the informer up front so it is started with the cache
+	if _, err := c.GetInformer(context.Background(), &v1alpha1.ArcaVolume{}); err != nil {
+		return nil, fmt.Errorf("failed to create ArcaVolume informer: %w", err)
+	}
+
+	return &VolumeReader{cache: c}, nil
+}
+
+// Start runs the watch until the context is cancelled
+func (r *VolumeReader) Start(ctx context.Context) error {
+	klog.Info("Starting ArcaVolume reader")
+	return r.cache.Start(ctx)
+}
+
+// WaitForSync blocks until the initial list has completed
+func (r *VolumeReader) WaitForSync(ctx context.Context) bool {
+	return r.cache.WaitForCacheSync(ctx)
+}
+
+// GetVolume retrieves volume metadata from the local cache
+func (r *VolumeReader) GetVolume(ctx context.Context, volumeID string) (*VolumeInfo, error) {
+	av := &v1alpha1.ArcaVolume{}
+	if err := r.cache.Get(ctx, client.ObjectKey{Name: volumeID}, av); err != nil {
+		return nil, MapKubernetesError(err, "ArcaVolume", volumeID)
+	}
+	return ArcaVolumeToVolumeInfo(av), nil
+}
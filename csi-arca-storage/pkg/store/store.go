@@ -3,19 +3,36 @@
 package store
 
 // Store defines the interface for volume/snapshot metadata storage.
-// Implementations include MemoryStore (in-memory) and CRDStore (persistent via Kubernetes CRDs).
+// Implementations include MemoryStore (in-memory) and crd.Store (persistent via Kubernetes CRDs).
 type Store interface {
 	// Volume operations
 	CreateVolume(info *VolumeInfo) error
 	UpdateVolume(info *VolumeInfo) error
 	GetVolume(volumeID string) (*VolumeInfo, error)
+	// GetVolumeByName returns the volume created for a CSI request name
+	// (the PV name, e.g. "pvc-<uid>")
+	GetVolumeByName(name string) (*VolumeInfo, error)
 	DeleteVolume(volumeID string) error
 	ListVolumes(startingToken string, maxEntries int) ([]*VolumeInfo, string, error)
+	// MarkVolumeDeleting flags a volume whose backend data is deleted in the
+	// background; the record stays, with Deleting set, until DeleteVolume
+	MarkVolumeDeleting(volumeID string) error
+	// ListDeletingVolumes returns the volumes flagged by MarkVolumeDeleting
+	ListDeletingVolumes() ([]*VolumeInfo, error)
 
 	// Snapshot operations
 	CreateSnapshot(info *SnapshotInfo) error
 	UpdateSnapshotStatus(snapshotID string, readyToUse bool) error
 	GetSnapshot(snapshotID string) (*SnapshotInfo, error)
+	// GetSnapshotByName returns the snapshot created for a CSI request name
+	// (the VolumeSnapshotContent-derived name, e.g. "snapshot-<uid>")
+	GetSnapshotByName(name string) (*SnapshotInfo, error)
 	DeleteSnapshot(snapshotID string) error
 	ListSnapshots(sourceVolumeID, startingToken string, maxEntries int) ([]*SnapshotInfo, string, error)
 }
+
+// RequiredCRDs are the CRDs the CRD store needs
+var RequiredCRDs = []string{
+	"arcavolumes.storage.arca.io",
+	"arcasnapshots.storage.arca.io",
+}
@@ -0,0 +1,83 @@
+// Package svmevent records the lifecycle of SVMs as Kubernetes Events on
+// their namespaces, so users waiting on the first PVC of a namespace see
+// its SVM being created, the VIP it was assigned and why creation failed.
+package svmevent
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/arca"
+)
+
+// Reasons of the Events recorded
+const (
+	ReasonCreated      = "SVMCreated"
+	ReasonVIPAssigned  = "VIPAssigned"
+	ReasonCreateFailed = "SVMCreateFailed"
+	ReasonDenied       = "SVMCreationDenied"
+	ReasonDeleted      = "SVMDeleted"
+)
+
+// maxMessageLength is the longest error message put in an Event
+const maxMessageLength = 1024
+
+// Recorder records SVM lifecycle Events; it implements arca.SVMObserver
+type Recorder struct {
+	recorder record.EventRecorder
+}
+
+// NewRecorder creates a recorder whose Events come from component
+func NewRecorder(clientset kubernetes.Interface, component string) *Recorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+
+	return &Recorder{
+		recorder: broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component}),
+	}
+}
+
+// SVMCreated records the creation of a namespace's SVM and its VIP
+func (r *Recorder) SVMCreated(namespace string, svm *arca.SVM, alloc *arca.NetworkAllocation) {
+	ref := namespaceRef(namespace)
+	r.recorder.Eventf(ref, corev1.EventTypeNormal, ReasonCreated, "Created SVM %s for the volumes of namespace %s", svm.Name, namespace)
+
+	kind := "VIP"
+	if alloc.Static {
+		kind = "static VIP"
+	}
+	r.recorder.Eventf(ref, corev1.EventTypeNormal, ReasonVIPAssigned, "SVM %s was assigned %s %s on VLAN %d (pool %s, gateway %s)",
+		svm.Name, kind, alloc.IPCIDR, alloc.VLANID, alloc.PoolName, alloc.Gateway)
+	klog.V(4).Infof("Recorded creation of SVM %s in namespace %s", svm.Name, namespace)
+}
+
+// SVMCreateFailed records why a namespace's SVM could not be created
+func (r *Recorder) SVMCreateFailed(namespace string, err error) {
+	reason, message := ReasonCreateFailed, fmt.Sprintf("Failed to create the SVM of namespace %s: %v", namespace, err)
+	if errors.Is(err, arca.ErrSVMCreationDenied) {
+		reason, message = ReasonDenied, fmt.Sprintf("Namespace %s may not create an SVM: %v", namespace, err)
+	}
+	if len(message) > maxMessageLength {
+		message = message[:maxMessageLength]
+	}
+	r.recorder.Event(namespaceRef(namespace), corev1.EventTypeWarning, reason, message)
+}
+
+// SVMDeleted records the deletion of a namespace's SVM. A namespace being
+// deleted accepts no new Events, so the Event may be lost.
+func (r *Recorder) SVMDeleted(namespace, svmName string) {
+	r.recorder.Eventf(namespaceRef(namespace), corev1.EventTypeNormal, ReasonDeleted, "Deleted SVM %s of namespace %s", svmName, namespace)
+}
+
+// namespaceRef refers to a Namespace, with the Event stored in the
+// namespace itself so its users can read it
+func namespaceRef(namespace string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{Kind: "Namespace", APIVersion: "v1", Name: namespace, Namespace: namespace}
+}
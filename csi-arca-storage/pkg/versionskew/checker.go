@@ -0,0 +1,165 @@
+package versionskew
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// eventReason is the reason of Events about incompatible node plugins
+const eventReason = "IncompatibleNodePlugin"
+
+// Checker compares the features published by node plugins with those the
+// controller requires and warns about nodes that lack some, with a Warning
+// Event on the Node and the arca_csi_node_plugin_incompatible metric
+type Checker struct {
+	clientset kubernetes.Interface
+	namespace string
+	required  []string
+	recorder  record.EventRecorder
+
+	// incompatible and versions map incompatible nodes to their missing
+	// features and plugin version in the last pass, so each change is
+	// announced once
+	incompatible map[string]string
+	versions     map[string]string
+}
+
+// NewChecker creates a checker for node plugin Leases in namespace
+func NewChecker(clientset kubernetes.Interface, namespace, controllerName string, required []string) *Checker {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+
+	return &Checker{
+		clientset:    clientset,
+		namespace:    namespace,
+		required:     required,
+		recorder:     broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerName}),
+		incompatible: make(map[string]string),
+		versions:     make(map[string]string),
+	}
+}
+
+// Run checks node plugin Leases every interval until ctx is cancelled
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.check(ctx); err != nil {
+			klog.Errorf("Failed to check node plugin versions: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check runs one pass over the unexpired node plugin Leases
+func (c *Checker) check(ctx context.Context) error {
+	leases, err := c.clientset.CoordinationV1().Leases(c.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: LabelComponent + "=" + ComponentNode,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list node plugin Leases: %w", err)
+	}
+
+	now := time.Now()
+	incompatible := make(map[string]string)
+	versions := make(map[string]string)
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if lease.Spec.HolderIdentity == nil || expired(lease, now) {
+			continue
+		}
+		node := *lease.Spec.HolderIdentity
+		version := lease.Annotations[AnnotationVersion]
+		missing := c.missing(strings.Split(lease.Annotations[AnnotationFeatures], ","))
+		if len(missing) == 0 {
+			continue
+		}
+
+		detail := strings.Join(missing, ", ")
+		incompatible[node] = detail
+		versions[node] = version
+		metrics.NodePluginIncompatible.WithLabelValues(node, version).Set(1)
+		if c.incompatible[node] != detail || c.versions[node] != version {
+			message := fmt.Sprintf("Node plugin version %s does not support volume context fields required by the controller: %s", version, detail)
+			klog.Warningf("Node %s: %s", node, message)
+			c.recorder.Event(&corev1.ObjectReference{Kind: "Node", Name: node, APIVersion: "v1"}, corev1.EventTypeWarning, eventReason, message)
+		}
+	}
+
+	for node, version := range c.versions {
+		if versions[node] != version {
+			metrics.NodePluginIncompatible.DeleteLabelValues(node, version)
+			if _, ok := incompatible[node]; !ok {
+				klog.Infof("Node %s: node plugin is compatible again", node)
+			}
+		}
+	}
+	c.incompatible = incompatible
+	c.versions = versions
+	return nil
+}
+
+// NodeVersions counts the node plugins with an unexpired Lease in namespace
+// per driver version
+func NodeVersions(ctx context.Context, clientset kubernetes.Interface, namespace string) (map[string]int, error) {
+	leases, err := clientset.CoordinationV1().Leases(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: LabelComponent + "=" + ComponentNode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node plugin Leases: %w", err)
+	}
+
+	now := time.Now()
+	versions := make(map[string]int)
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if lease.Spec.HolderIdentity == nil || expired(lease, now) {
+			continue
+		}
+		versions[lease.Annotations[AnnotationVersion]]++
+	}
+	return versions, nil
+}
+
+// missing returns the required features not in features
+func (c *Checker) missing(features []string) []string {
+	var missing []string
+	for _, f := range c.required {
+		if !slices.Contains(features, f) {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
+
+// expired reports whether a Lease was not renewed within its duration
+func expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return now.After(lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second))
+}
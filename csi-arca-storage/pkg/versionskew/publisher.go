@@ -0,0 +1,134 @@
+// Package versionskew publishes the version and feature set of each driver
+// component in a Lease and lets the controller warn about node plugins that
+// cannot handle the volumes it creates.
+package versionskew
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// DefaultInterval is how often component Leases are renewed and checked
+const DefaultInterval = time.Minute
+
+const (
+	// LabelComponent marks component Leases with "controller" or "node"
+	LabelComponent = "storage.arca.io/component"
+
+	// AnnotationVersion and AnnotationFeatures carry the driver version and
+	// the comma-separated feature list of the component
+	AnnotationVersion  = "storage.arca.io/driver-version"
+	AnnotationFeatures = "storage.arca.io/features"
+)
+
+// Component names
+const (
+	ComponentController = "controller"
+	ComponentNode       = "node"
+)
+
+// Publisher keeps a component's Lease up to date
+type Publisher struct {
+	clientset kubernetes.Interface
+	namespace string
+	component string
+	identity  string
+	version   string
+	features  []string
+}
+
+// NewPublisher creates a publisher for one component instance. identity is
+// the node name for node plugins and the pod name for the controller.
+func NewPublisher(clientset kubernetes.Interface, namespace, component, identity, version string, features []string) *Publisher {
+	features = slices.Clone(features)
+	slices.Sort(features)
+	return &Publisher{
+		clientset: clientset,
+		namespace: namespace,
+		component: component,
+		identity:  identity,
+		version:   version,
+		features:  features,
+	}
+}
+
+// LeaseName returns the name of a component instance's Lease
+func LeaseName(component, identity string) string {
+	return fmt.Sprintf("arca-csi-%s-%s", component, identity)
+}
+
+// Run renews the Lease every interval until ctx is cancelled
+func (p *Publisher) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.publish(ctx, interval); err != nil {
+			klog.Warningf("Failed to publish %s version Lease: %v", p.component, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publish creates or renews the Lease. It stays valid for three intervals
+// so a missed renewal does not make the component disappear.
+func (p *Publisher) publish(ctx context.Context, interval time.Duration) error {
+	leases := p.clientset.CoordinationV1().Leases(p.namespace)
+	name := LeaseName(p.component, p.identity)
+	duration := int32((3 * interval).Seconds())
+	now := metav1.NewMicroTime(time.Now())
+
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: p.namespace,
+			},
+		}
+		p.fill(lease, duration, now)
+		_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	p.fill(lease, duration, now)
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// fill sets the component's labels, annotations and renewal on a Lease
+func (p *Publisher) fill(lease *coordinationv1.Lease, duration int32, now metav1.MicroTime) {
+	if lease.Labels == nil {
+		lease.Labels = make(map[string]string)
+	}
+	lease.Labels[LabelComponent] = p.component
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string)
+	}
+	lease.Annotations[AnnotationVersion] = p.version
+	lease.Annotations[AnnotationFeatures] = strings.Join(p.features, ",")
+	lease.Spec.HolderIdentity = &p.identity
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &now
+}
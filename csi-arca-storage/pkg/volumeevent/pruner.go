@@ -0,0 +1,134 @@
+package volumeevent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// Default pruning settings
+const (
+	DefaultPruneInterval = 10 * time.Minute
+	DefaultRetention     = 30 * 24 * time.Hour
+	DefaultMaxPerVolume  = 100
+	DefaultMaxTotal      = 10000
+)
+
+// pruneTimeout bounds a single pass
+const pruneTimeout = 5 * time.Minute
+
+// listPageSize is the page size used when listing events
+const listPageSize = 500
+
+// Pruner deletes ArcaVolumeEvents older than the retention and, newest
+// first, keeps at most maxPerVolume events per volume and maxTotal events
+// overall, so the audit trail cannot grow without bound in etcd.
+type Pruner struct {
+	client       client.Client
+	retention    time.Duration
+	maxPerVolume int
+	maxTotal     int
+}
+
+// NewPruner creates a pruner; settings that are not positive take their
+// defaults
+func NewPruner(c client.Client, retention time.Duration, maxPerVolume, maxTotal int) *Pruner {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	if maxPerVolume <= 0 {
+		maxPerVolume = DefaultMaxPerVolume
+	}
+	if maxTotal <= 0 {
+		maxTotal = DefaultMaxTotal
+	}
+
+	return &Pruner{
+		client:       c,
+		retention:    retention,
+		maxPerVolume: maxPerVolume,
+		maxTotal:     maxTotal,
+	}
+}
+
+// Run prunes events every interval until ctx is cancelled
+func (p *Pruner) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPruneInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.prune(ctx); err != nil {
+			klog.Errorf("Failed to prune volume events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prune runs one pass over all events
+func (p *Pruner) prune(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pruneTimeout)
+	defer cancel()
+
+	var events []v1alpha1.ArcaVolumeEvent
+	token := ""
+	for {
+		var list v1alpha1.ArcaVolumeEventList
+		if err := p.client.List(ctx, &list, client.Limit(listPageSize), client.Continue(token)); err != nil {
+			return fmt.Errorf("failed to list ArcaVolumeEvents: %w", err)
+		}
+		events = append(events, list.Items...)
+		token = list.Continue
+		if token == "" {
+			break
+		}
+	}
+
+	// Newest first, so the events kept under the caps are the latest
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Spec.Time.After(events[j].Spec.Time.Time)
+	})
+
+	cutoff := time.Now().Add(-p.retention)
+	perVolume := make(map[string]int)
+	kept, pruned := 0, 0
+	for i := range events {
+		event := &events[i]
+		perVolume[event.Spec.VolumeID]++
+		if event.Spec.Time.After(cutoff) && perVolume[event.Spec.VolumeID] <= p.maxPerVolume && kept < p.maxTotal {
+			kept++
+			continue
+		}
+
+		err := p.client.Delete(ctx, event)
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Warningf("Failed to delete volume event %s: %v", event.Name, err)
+			kept++
+			continue
+		}
+		pruned++
+	}
+
+	metrics.VolumeEventsPruned.Add(float64(pruned))
+	metrics.VolumeEventsStored.Set(float64(kept))
+	if pruned > 0 {
+		klog.V(2).Infof("Pruned %d volume events, %d kept", pruned, kept)
+	}
+	return nil
+}
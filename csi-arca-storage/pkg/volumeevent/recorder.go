@@ -0,0 +1,113 @@
+// Package volumeevent records node plugins staging, unstaging, publishing
+// and unpublishing volumes as ArcaVolumeEvents, an audit trail of which
+// nodes accessed which volumes and when, and prunes the trail by age and
+// size.
+package volumeevent
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/akam1o/csi-arca-storage/pkg/apis/storage/v1alpha1"
+	"github.com/akam1o/csi-arca-storage/pkg/metrics"
+)
+
+// LabelVolumeID is the label holding the volume ID of an event
+const LabelVolumeID = "storage.arca.io/volume-id"
+
+// DefaultQueueSize is how many events a recorder holds while the API server
+// is slow or unreachable
+const DefaultQueueSize = 1000
+
+// createTimeout bounds the creation of a single event
+const createTimeout = 10 * time.Second
+
+// maxMessageLength is the longest error message stored in an event
+const maxMessageLength = 1024
+
+// Recorder creates ArcaVolumeEvents for the node calls of a node plugin.
+// Events are queued and created in the background, so node calls never
+// wait on the API server; events that do not fit in the queue are dropped
+// and counted in arca_csi_volume_events_total.
+type Recorder struct {
+	client client.Client
+	nodeID string
+	queue  chan *v1alpha1.ArcaVolumeEvent
+}
+
+// NewRecorder creates a recorder for the events of nodeID, queueing up to
+// queueSize events (DefaultQueueSize when not positive)
+func NewRecorder(c client.Client, nodeID string, queueSize int) *Recorder {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	return &Recorder{
+		client: c,
+		nodeID: nodeID,
+		queue:  make(chan *v1alpha1.ArcaVolumeEvent, queueSize),
+	}
+}
+
+// Record queues an event of operation (e.g. v1alpha1.ArcaVolumeEventStage)
+// on spec.VolumeID that completed now with err. NodeID, Operation, Result,
+// Message and Time of spec are filled in.
+func (r *Recorder) Record(operation string, spec v1alpha1.ArcaVolumeEventSpec, err error) {
+	spec.NodeID = r.nodeID
+	spec.Operation = operation
+	spec.Time = metav1.NowMicro()
+	spec.Result = v1alpha1.ArcaVolumeEventSucceeded
+	if err != nil {
+		spec.Result = v1alpha1.ArcaVolumeEventFailed
+		spec.Message = err.Error()
+		if len(spec.Message) > maxMessageLength {
+			spec.Message = spec.Message[:maxMessageLength]
+		}
+	}
+
+	event := &v1alpha1.ArcaVolumeEvent{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: spec.VolumeID + "-",
+			Labels:       map[string]string{LabelVolumeID: spec.VolumeID},
+		},
+		Spec: spec,
+	}
+
+	select {
+	case r.queue <- event:
+	default:
+		metrics.VolumeEvents.WithLabelValues("dropped").Inc()
+		klog.Warningf("Volume event queue full, dropping %s event of volume %s", operation, spec.VolumeID)
+	}
+}
+
+// Run creates queued events until ctx is cancelled
+func (r *Recorder) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-r.queue:
+			r.create(ctx, event)
+		}
+	}
+}
+
+// create creates one event; failures are logged and counted, not retried,
+// so an unreachable API server cannot back up the queue
+func (r *Recorder) create(ctx context.Context, event *v1alpha1.ArcaVolumeEvent) {
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	if err := r.client.Create(ctx, event); err != nil {
+		metrics.VolumeEvents.WithLabelValues("error").Inc()
+		klog.Warningf("Failed to record %s event of volume %s: %v", event.Spec.Operation, event.Spec.VolumeID, err)
+		return
+	}
+	metrics.VolumeEvents.WithLabelValues("recorded").Inc()
+	klog.V(5).Infof("Recorded %s event %s of volume %s", event.Spec.Operation, event.Name, event.Spec.VolumeID)
+}